@@ -10,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/binaryYuki/error-pages/internal/cli"
+	"github.com/binaryYuki/error-pages/internal/winsvc"
 )
 
 // main CLI application entrypoint.
@@ -25,6 +26,13 @@ func main() {
 func run() error {
 	defer runtime.Gosched() // increase the chance of running deferred functions before exiting
 
+	// when started by the Windows service manager, the service stop request cancels the context
+	if winsvc.IsService() {
+		return winsvc.Run(winsvc.DefaultName, func(ctx context.Context) error {
+			return cli.NewApp(filepath.Base(os.Args[0])).Run(ctx, os.Args)
+		})
+	}
+
 	// create a context that is canceled when the user interrupts the program
 	var ctx, cancel = signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -0,0 +1,5 @@
+//go:build !slim
+
+package main
+
+import _ "time/tzdata" // embed the timezone database (the runtime image has no zoneinfo files)
@@ -0,0 +1,245 @@
+// Package a11y implements the basic accessibility audit of the rendered error pages. The audit is not a replacement
+// for the full-featured tools (like axe) - it catches the most common regressions only (a missing page language or
+// landmark, unlabeled images, or the low contrast of the themed colors).
+package a11y
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/html"
+)
+
+// MinContrastRatio is the minimal contrast ratio of the normal text (WCAG 2.1 AA, success criterion 1.4.3).
+const MinContrastRatio = 4.5
+
+// Finding is a single accessibility issue, found by the [Audit].
+type Finding struct {
+	Rule    string // the short rule name, like `html-lang` or `color-contrast`
+	Message string // the human-readable issue description
+}
+
+// String returns the finding in the `rule: message` format.
+func (f Finding) String() string { return f.Rule + ": " + f.Message }
+
+// The rule names.
+const (
+	RuleHTMLLang      = "html-lang"
+	RuleDocumentTitle = "document-title"
+	RuleLandmarkMain  = "landmark-main"
+	RuleImageAlt      = "image-alt"
+	RuleSVGLabel      = "svg-label"
+	RuleColorContrast = "color-contrast"
+)
+
+// Audit checks the rendered HTML page and returns the found issues (nil if the page passes all the checks):
+//
+//   - the `<html>` element has a non-empty `lang` attribute
+//   - the document has a non-empty `<title>`
+//   - the page has exactly one main landmark (`<main>` or `role="main"`)
+//   - the images (`<img>`, `<area>`, and `<input type="image">`) have the `alt` attribute (may be empty for the
+//     decorative ones)
+//   - the inline `<svg>` images are hidden from the assistive technologies (`aria-hidden="true"`), or labeled
+//     (`role="img"` with the `aria-label`, `aria-labelledby`, or a `<title>` element)
+//   - the text and background colors, declared in the same `<style>` ruleset, have the contrast ratio of at least
+//     [MinContrastRatio] - for the default color scheme and for every at-rule (like `@media
+//     (prefers-color-scheme: dark)`) overriding the custom properties; the translucent colors are skipped
+func Audit(page []byte) []Finding {
+	var (
+		findings []Finding
+		lexer    = html.NewLexer(parse.NewInputBytes(page))
+
+		tag              string            // the current tag name
+		attrs            map[string]string // the current tag attributes
+		hasHTML, hasLang bool
+		title            *string
+		inTitle          bool
+		mainLandmarks    int
+		styles           [][]byte
+		inStyle          bool
+	)
+
+	var closeTag = func() { // handles the tag with all the attributes read
+		switch tag {
+		case "html":
+			hasHTML, hasLang = true, strings.TrimSpace(attrs["lang"]) != ""
+		case "main":
+			mainLandmarks++
+		case "img", "area":
+			if _, ok := attrs["alt"]; !ok {
+				findings = append(findings, Finding{RuleImageAlt, fmt.Sprintf("<%s> without the alt attribute", tag)})
+			}
+		case "input":
+			if _, ok := attrs["alt"]; !ok && strings.EqualFold(attrs["type"], "image") {
+				findings = append(findings, Finding{RuleImageAlt, "<input type=\"image\"> without the alt attribute"})
+			}
+		}
+
+		if tag != "main" && strings.EqualFold(attrs["role"], "main") {
+			mainLandmarks++
+		}
+
+		tag, attrs = "", nil
+	}
+
+	for {
+		tt, data := lexer.Next()
+
+		if tag != "" && tt != html.AttributeToken {
+			closeTag()
+		}
+
+		switch tt { //nolint:exhaustive
+		case html.ErrorToken:
+			if !hasHTML || !hasLang {
+				findings = append(findings, Finding{RuleHTMLLang, "the <html> element has no lang attribute"})
+			}
+
+			if title == nil || strings.TrimSpace(*title) == "" {
+				findings = append(findings, Finding{RuleDocumentTitle, "the document has no (or an empty) <title>"})
+			}
+
+			if mainLandmarks != 1 {
+				findings = append(findings, Finding{RuleLandmarkMain,
+					fmt.Sprintf("the page must have exactly one main landmark, found %d", mainLandmarks),
+				})
+			}
+
+			for _, style := range styles {
+				findings = append(findings, checkContrast(style)...)
+			}
+
+			return findings
+		case html.StartTagToken:
+			tag, attrs = string(lexer.Text()), make(map[string]string)
+			inTitle, inStyle = tag == "title", tag == "style"
+		case html.AttributeToken:
+			if attrs != nil {
+				attrs[strings.ToLower(string(lexer.AttrKey()))] = attrValue(lexer.AttrVal())
+			}
+		case html.EndTagToken:
+			inTitle, inStyle = false, false
+		case html.TextToken:
+			switch {
+			case inTitle && title == nil:
+				var text = string(data)
+
+				title = &text
+			case inStyle:
+				styles = append(styles, slices.Clone(data))
+			}
+		case html.SVGToken:
+			if f, ok := checkSVG(data); !ok {
+				findings = append(findings, f)
+			}
+		}
+	}
+}
+
+// attrValue returns the unquoted attribute value.
+func attrValue(v []byte) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		v = v[1 : len(v)-1]
+	}
+
+	return string(v)
+}
+
+// checkSVG checks the inline `<svg>` element (the whole element markup).
+func checkSVG(svg []byte) (Finding, bool) {
+	const prefix = "<svg"
+
+	var (
+		attrs = make(map[string]string)
+		end   = bytes.IndexByte(svg, '>')
+	)
+
+	// the lexer returns the whole svg element as a single token, so the opening tag is lexed under another name
+	if end > len(prefix) {
+		var lexer = html.NewLexer(parse.NewInputBytes(append([]byte("<span"), svg[len(prefix):end+1]...)))
+
+		_, _ = lexer.Next() // the start tag
+
+		for {
+			if tt, _ := lexer.Next(); tt != html.AttributeToken {
+				break
+			}
+
+			attrs[strings.ToLower(string(lexer.AttrKey()))] = attrValue(lexer.AttrVal())
+		}
+	}
+
+	if strings.EqualFold(attrs["aria-hidden"], "true") {
+		return Finding{}, true
+	}
+
+	if strings.EqualFold(attrs["role"], "img") {
+		if strings.TrimSpace(attrs["aria-label"]) != "" || attrs["aria-labelledby"] != "" ||
+			bytes.Contains(bytes.ToLower(svg), []byte("<title")) {
+			return Finding{}, true
+		}
+	}
+
+	return Finding{RuleSVGLabel, "the inline <svg> is neither hidden (aria-hidden=\"true\") nor labeled " +
+		"(role=\"img\" with aria-label, aria-labelledby, or <title>)"}, false
+}
+
+// checkContrast checks the color pairs of the stylesheet in every color scheme.
+func checkContrast(style []byte) []Finding {
+	var (
+		findings []Finding
+		sheet    = parseStylesheet(style)
+		schemes  = make([]string, 0, len(sheet.schemes)+1)
+	)
+
+	schemes = append(schemes, "") // the default one
+
+	for scheme := range sheet.schemes {
+		schemes = append(schemes, scheme)
+	}
+
+	slices.Sort(schemes[1:]) // for the stable output
+
+	for _, scheme := range schemes {
+		var vars = sheet.vars
+
+		if overrides := sheet.schemes[scheme]; len(overrides) > 0 {
+			vars = make(map[string]string, len(sheet.vars)+len(overrides))
+
+			for k, v := range sheet.vars {
+				vars[k] = v
+			}
+
+			for k, v := range overrides {
+				vars[k] = v
+			}
+		}
+
+		for _, pair := range sheet.pairs {
+			fg, fgOk := parseColor(resolve(pair.color, vars))
+			bg, bgOk := parseColor(resolve(pair.background, vars))
+
+			if !fgOk || !bgOk {
+				continue // unsupported (e.g. translucent, or a gradient) colors
+			}
+
+			if ratio := contrastRatio(fg, bg); ratio < MinContrastRatio {
+				var where = pair.selector
+
+				if scheme != "" {
+					where += " (" + scheme + ")"
+				}
+
+				findings = append(findings, Finding{RuleColorContrast, fmt.Sprintf(
+					"%s: the contrast ratio of %s on %s is %.2f:1, expected at least %.1f:1",
+					where, fg, bg, ratio, MinContrastRatio,
+				)})
+			}
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,142 @@
+package a11y_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/a11y"
+)
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	const valid = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>404: Not Found</title>
+  <style>
+    :root { --fg: #202020; --bg: #fff }
+    @media (prefers-color-scheme: dark) { :root { --fg: #fff; --bg: #1a1a1a } }
+    body { color: var(--fg); background-color: var(--bg) }
+    .shadow { color: #777; background: rgba(0, 0, 0, .1) }
+  </style>
+</head>
+<body>
+  <main>
+    <img src="logo.png" alt="">
+    <svg aria-hidden="true" viewBox="0 0 1 1"><path d="M0 0"/></svg>
+    <svg role="img" viewBox="0 0 1 1"><title>Ghost</title><path d="M0 0"/></svg>
+    <h1>Not Found</h1>
+  </main>
+</body>
+</html>`
+
+	for name, tc := range map[string]struct {
+		givePage  string
+		wantRules []string
+	}{
+		"valid": {givePage: valid},
+		"no lang": {
+			givePage:  strings.Replace(valid, ` lang="en"`, ``, 1),
+			wantRules: []string{a11y.RuleHTMLLang},
+		},
+		"empty lang": {
+			givePage:  strings.Replace(valid, ` lang="en"`, ` lang=""`, 1),
+			wantRules: []string{a11y.RuleHTMLLang},
+		},
+		"empty title": {
+			givePage:  strings.Replace(valid, `404: Not Found`, ` `, 1),
+			wantRules: []string{a11y.RuleDocumentTitle},
+		},
+		"no main": {
+			givePage:  strings.NewReplacer(`<main>`, `<div>`, `</main>`, `</div>`).Replace(valid),
+			wantRules: []string{a11y.RuleLandmarkMain},
+		},
+		"main role": {
+			givePage: strings.NewReplacer(`<main>`, `<div role="main">`, `</main>`, `</div>`).Replace(valid),
+		},
+		"two mains": {
+			givePage:  strings.Replace(valid, `<h1>`, `<div role="main"></div><h1>`, 1),
+			wantRules: []string{a11y.RuleLandmarkMain},
+		},
+		"image without alt": {
+			givePage:  strings.Replace(valid, ` alt=""`, ``, 1),
+			wantRules: []string{a11y.RuleImageAlt},
+		},
+		"image input without alt": {
+			givePage:  strings.Replace(valid, `<h1>`, `<input type="image" src="go.png"><h1>`, 1),
+			wantRules: []string{a11y.RuleImageAlt},
+		},
+		"unlabeled svg": {
+			givePage:  strings.Replace(valid, ` aria-hidden="true"`, ``, 1),
+			wantRules: []string{a11y.RuleSVGLabel},
+		},
+		"svg without title": {
+			givePage:  strings.Replace(valid, `<title>Ghost</title>`, ``, 1),
+			wantRules: []string{a11y.RuleSVGLabel},
+		},
+		"svg with aria-label": {
+			givePage: strings.Replace(valid, `role="img"`, `role="img" aria-label="Ghost"`, 1),
+		},
+		"low contrast": {
+			givePage:  strings.Replace(valid, `--fg: #202020`, `--fg: #ccc`, 1),
+			wantRules: []string{a11y.RuleColorContrast},
+		},
+		"low contrast in the dark scheme": {
+			givePage:  strings.Replace(valid, `--fg: #fff`, `--fg: #333`, 1),
+			wantRules: []string{a11y.RuleColorContrast},
+		},
+		"everything is wrong": {
+			givePage: `<html><body><img src="x.png"><svg></svg>` +
+				`<style>p { color: gray; background-color: grey }</style></body></html>`,
+			wantRules: []string{
+				a11y.RuleImageAlt,
+				a11y.RuleSVGLabel,
+				a11y.RuleHTMLLang,
+				a11y.RuleDocumentTitle,
+				a11y.RuleLandmarkMain,
+				a11y.RuleColorContrast,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var rules []string
+
+			for _, finding := range a11y.Audit([]byte(tc.givePage)) {
+				assert.NotEmpty(t, finding.Message)
+
+				rules = append(rules, finding.Rule)
+			}
+
+			assert.Equal(t, tc.wantRules, rules)
+		})
+	}
+}
+
+func TestAudit_ContrastMessage(t *testing.T) {
+	t.Parallel()
+
+	var findings = a11y.Audit([]byte(`<style>
+		:root { --text: #999 }
+		@media (prefers-color-scheme: dark) { :root { --text: #fff } }
+		.card { color: var(--text); background: var(--undefined, #fff) }
+	</style>`))
+
+	var contrast []string
+
+	for _, finding := range findings {
+		if finding.Rule == a11y.RuleColorContrast {
+			contrast = append(contrast, finding.String())
+		}
+	}
+
+	assert.Equal(t, []string{
+		"color-contrast: .card: the contrast ratio of #999999 on #ffffff is 2.85:1, expected at least 4.5:1",
+		"color-contrast: .card (@media (prefers-color-scheme:dark)): the contrast ratio of #ffffff on #ffffff " +
+			"is 1.00:1, expected at least 4.5:1",
+	}, contrast)
+}
@@ -0,0 +1,128 @@
+package a11y
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rgb is an opaque sRGB color.
+type rgb struct{ r, g, b uint8 }
+
+// String returns the color in the `#rrggbb` format.
+func (c rgb) String() string {
+	const hex = "0123456789abcdef"
+
+	return string([]byte{'#', hex[c.r>>4], hex[c.r&0x0f], hex[c.g>>4], hex[c.g&0x0f], hex[c.b>>4], hex[c.b&0x0f]})
+}
+
+// rgba converts the color to the standard library type.
+func (c rgb) rgba() color.RGBA { return color.RGBA{R: c.r, G: c.g, B: c.b, A: 0xff} }
+
+// luminance returns the relative luminance of the color (https://www.w3.org/TR/WCAG21/#dfn-relative-luminance).
+func (c rgb) luminance() float64 {
+	var channel = func(v uint8) float64 {
+		if s := float64(v) / 255; s <= 0.04045 { //nolint:mnd
+			return s / 12.92 //nolint:mnd
+		} else {
+			return math.Pow((s+0.055)/1.055, 2.4) //nolint:mnd
+		}
+	}
+
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b) //nolint:mnd
+}
+
+// contrastRatio returns the contrast ratio of two colors (from 1 to 21,
+// https://www.w3.org/TR/WCAG21/#dfn-contrast-ratio).
+func contrastRatio(a, b rgb) float64 {
+	var la, lb = a.luminance(), b.luminance()
+
+	if la < lb {
+		la, lb = lb, la
+	}
+
+	return (la + 0.05) / (lb + 0.05) //nolint:mnd
+}
+
+// namedColors are the most common CSS named colors.
+var namedColors = map[string]rgb{ //nolint:gochecknoglobals
+	"black": {0, 0, 0}, "white": {255, 255, 255}, "red": {255, 0, 0}, "green": {0, 128, 0}, "blue": {0, 0, 255},
+	"gray": {128, 128, 128}, "grey": {128, 128, 128}, "silver": {192, 192, 192}, "yellow": {255, 255, 0},
+	"orange": {255, 165, 0},
+}
+
+// parseColor parses the opaque CSS color (`#rgb`, `#rrggbb`, `rgb(...)`, or a common named color). The translucent,
+// relative, and unknown colors are not supported (false is returned), as their contrast depends on the backdrop.
+func parseColor(s string) (rgb, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		switch len(hex) {
+		case 3, 4: //nolint:mnd // #rgb or #rgba
+			if len(hex) == 4 && hex[3] != 'f' {
+				return rgb{}, false
+			}
+
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		case 6, 8: //nolint:mnd // #rrggbb or #rrggbbaa
+			if len(hex) == 8 && hex[6:] != "ff" {
+				return rgb{}, false
+			}
+
+			hex = hex[:6]
+		default:
+			return rgb{}, false
+		}
+
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return rgb{}, false
+		}
+
+		return rgb{uint8(v >> 16), uint8(v >> 8), uint8(v)}, true //nolint:gosec,mnd
+	}
+
+	for _, fn := range [...]string{"rgb(", "rgba("} {
+		var args, ok = strings.CutPrefix(s, fn)
+		if !ok || !strings.HasSuffix(args, ")") {
+			continue
+		}
+
+		var parts = strings.FieldsFunc(strings.TrimSuffix(args, ")"), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '/'
+		})
+
+		if len(parts) == 4 { //nolint:mnd // the alpha channel
+			if alpha, err := strconv.ParseFloat(strings.TrimSuffix(parts[3], "%"), 64); err != nil ||
+				alpha < 1 || (strings.HasSuffix(parts[3], "%") && alpha < 100) {
+				return rgb{}, false
+			}
+
+			parts = parts[:3]
+		}
+
+		if len(parts) != 3 { //nolint:mnd
+			return rgb{}, false
+		}
+
+		var channels [3]uint8
+
+		for i, part := range parts {
+			v, err := strconv.ParseUint(part, 10, 8)
+			if err != nil {
+				return rgb{}, false
+			}
+
+			channels[i] = uint8(v)
+		}
+
+		return rgb{channels[0], channels[1], channels[2]}, true
+	}
+
+	return rgb{}, false
+}
@@ -0,0 +1,119 @@
+package a11y
+
+import (
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/css"
+)
+
+type (
+	// colorPair is a ruleset declaring both the text and the background colors.
+	colorPair struct {
+		selector, color, background string // the raw (unresolved) values
+		atRule                      string // the prelude of the enclosing at-rule (empty outside)
+	}
+
+	// stylesheet is the subset of the parsed stylesheet, needed for the contrast checks.
+	stylesheet struct {
+		vars    map[string]string            // the custom properties, declared outside the at-rules
+		schemes map[string]map[string]string // the custom properties overrides, declared in the at-rules (by prelude)
+		pairs   []colorPair
+	}
+)
+
+// parseStylesheet extracts the custom properties and the color pairs from the stylesheet. The parse errors are
+// skipped, as the browsers do.
+func parseStylesheet(src []byte) stylesheet {
+	var (
+		sheet = stylesheet{vars: make(map[string]string), schemes: make(map[string]map[string]string)}
+		p     = css.NewParser(parse.NewInputBytes(src), false)
+
+		atRule   string // the prelude of the current at-rule (empty outside)
+		selector string
+		pair     colorPair
+	)
+
+	for {
+		gt, _, data := p.Next()
+
+		switch gt { //nolint:exhaustive
+		case css.ErrorGrammar:
+			if p.HasParseError() {
+				continue
+			}
+
+			return sheet // EOF
+		case css.BeginAtRuleGrammar:
+			atRule = string(data) + " " + tokensString(p.Values())
+		case css.EndAtRuleGrammar:
+			atRule = ""
+		case css.BeginRulesetGrammar:
+			selector, pair = tokensString(p.Values()), colorPair{}
+		case css.EndRulesetGrammar:
+			if pair.color != "" && pair.background != "" {
+				pair.selector, pair.atRule = selector, atRule
+				sheet.pairs = append(sheet.pairs, pair)
+			}
+		case css.CustomPropertyGrammar:
+			var name, value = string(data), ""
+
+			if values := p.Values(); len(values) > 0 {
+				value = strings.TrimSpace(string(values[0].Data))
+			}
+
+			if atRule == "" {
+				sheet.vars[name] = value
+			} else {
+				if sheet.schemes[atRule] == nil {
+					sheet.schemes[atRule] = make(map[string]string)
+				}
+
+				sheet.schemes[atRule][name] = value
+			}
+		case css.DeclarationGrammar:
+			switch value := tokensString(p.Values()); string(data) {
+			case "color":
+				pair.color = value
+			case "background-color", "background":
+				pair.background = value
+			}
+		}
+	}
+}
+
+// tokensString joins the tokens data.
+func tokensString(tokens []css.Token) string {
+	var b strings.Builder
+
+	for _, t := range tokens {
+		b.Write(t.Data)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// resolve substitutes the `var(--name[, fallback])` value using the custom properties (the fallback is used for
+// the undefined ones). The nested references are resolved too (up to a limit, to break the cycles).
+func resolve(value string, vars map[string]string) string {
+	const maxDepth = 8
+
+	for range maxDepth {
+		var args, ok = strings.CutPrefix(strings.TrimSpace(value), "var(")
+		if !ok || !strings.HasSuffix(args, ")") {
+			return value
+		}
+
+		var name, fallback, hasFallback = strings.Cut(strings.TrimSuffix(args, ")"), ",")
+
+		if v, defined := vars[strings.TrimSpace(name)]; defined {
+			value = v
+		} else if hasFallback {
+			value = fallback
+		} else {
+			return ""
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,50 @@
+package a11y
+
+import (
+	"image/color"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/html"
+)
+
+// Palette returns the text and background colors of the page - the first `<style>` ruleset (outside the at-rules)
+// declaring both of them, resolved for the default color scheme. False is returned if there is no such ruleset,
+// or its colors are not supported (like the translucent ones).
+func Palette(page []byte) (text, background color.RGBA, _ bool) {
+	var (
+		lexer   = html.NewLexer(parse.NewInputBytes(page))
+		inStyle bool
+	)
+
+	for {
+		tt, data := lexer.Next()
+
+		switch tt { //nolint:exhaustive
+		case html.ErrorToken:
+			return color.RGBA{}, color.RGBA{}, false
+		case html.StartTagToken:
+			inStyle = string(lexer.Text()) == "style"
+		case html.EndTagToken:
+			inStyle = false
+		case html.TextToken:
+			if !inStyle {
+				continue
+			}
+
+			var sheet = parseStylesheet(data)
+
+			for _, pair := range sheet.pairs {
+				if pair.atRule != "" {
+					continue
+				}
+
+				fg, fgOk := parseColor(resolve(pair.color, sheet.vars))
+				bg, bgOk := parseColor(resolve(pair.background, sheet.vars))
+
+				if fgOk && bgOk {
+					return fg.rgba(), bg.rgba(), true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package a11y_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/a11y"
+)
+
+func TestPalette(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		givePage           string
+		wantText, wantBack color.RGBA
+		wantOk             bool
+	}{
+		"variables": {
+			givePage: `<style>
+				:root { --fg: #202020; --bg: #fff }
+				@media (prefers-color-scheme: dark) { :root { --fg: #fff; --bg: #1a1a1a } }
+				body { color: var(--fg); background-color: var(--bg) }
+			</style>`,
+			wantText: color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff},
+			wantBack: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+			wantOk:   true,
+		},
+		"at-rules and translucent colors are skipped": {
+			givePage: `<style>
+				@media print { body { color: black; background: white } }
+				.shadow { color: #777; background: rgba(0, 0, 0, .1) }
+			</style><p>text</p><style>main { color: #eee; background: #123 }</style>`,
+			wantText: color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff},
+			wantBack: color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff},
+			wantOk:   true,
+		},
+		"no colors":    {givePage: `<style>body { margin: 0 }</style><p style="color: red">text</p>`},
+		"empty":        {givePage: ``},
+		"not in style": {givePage: `<p>body { color: #000; background: #fff }</p>`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			text, back, ok := a11y.Palette([]byte(tc.givePage))
+
+			assert.Equal(t, tc.wantOk, ok)
+			assert.Equal(t, tc.wantText, text)
+			assert.Equal(t, tc.wantBack, back)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package charset implements the transcoding of the UTF-8 responses to the legacy character sets (like `GBK` or
+// `ISO-8859-1`), still required by some intranet clients.
+package charset
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Charset is the character set of the text responses. The zero value is UTF-8 (no transcoding).
+type Charset struct {
+	name string            // the canonical (MIME) name
+	enc  encoding.Encoding // nil for UTF-8
+}
+
+// Lookup returns the character set by its IANA name or alias (case is ignored, an empty string means UTF-8). Only
+// the ASCII-compatible character sets are supported, so the markup and the escaped values stay intact.
+func Lookup(name string) (Charset, error) {
+	if name = strings.TrimSpace(name); name == "" {
+		return Charset{}, nil
+	}
+
+	enc, canonical, found := lookup(name)
+	if !found {
+		return Charset{}, fmt.Errorf("unsupported charset: %q", name)
+	}
+
+	if enc == unicode.UTF8 {
+		return Charset{}, nil
+	}
+
+	const probe = "<html lang=\"en\">{\"a\":[0-9]}\n"
+
+	if out, encErr := enc.NewEncoder().String(probe); encErr != nil || out != probe {
+		return Charset{}, fmt.Errorf("charset %q is not ASCII-compatible", name)
+	}
+
+	return Charset{name: canonical, enc: enc}, nil
+}
+
+// String returns the charset name (as used in the `Content-Type` header).
+func (c Charset) String() string {
+	if c.enc == nil {
+		return "utf-8"
+	}
+
+	return c.name
+}
+
+// IsUTF8 reports whether the charset is UTF-8 (so the transcoding is not needed).
+func (c Charset) IsUTF8() bool { return c.enc == nil }
+
+// Encode transcodes the UTF-8 text to the charset. The characters that cannot be represented in the charset are
+// replaced with the numeric character references (like `&#128512;`) if html is true, or with the question marks
+// otherwise. The invalid UTF-8 sequences are replaced too.
+func (c Charset) Encode(text []byte, html bool) ([]byte, error) {
+	if c.enc == nil {
+		return text, nil
+	}
+
+	text = bytes.ToValidUTF8(text, []byte(string(utf8.RuneError)))
+
+	var encoder = c.enc.NewEncoder()
+
+	if html {
+		encoder = encoding.HTMLEscapeUnsupported(encoder)
+	} else {
+		text = c.replaceUnsupported(text, '?')
+	}
+
+	out, err := encoder.Bytes(text)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode to %s: %w", c.name, err)
+	}
+
+	return out, nil
+}
+
+// replaceUnsupported replaces the characters that cannot be represented in the charset with the replacement.
+func (c Charset) replaceUnsupported(text []byte, replacement rune) []byte {
+	var supported map[rune]bool // the checked non-ASCII characters
+
+	return bytes.Map(func(r rune) rune {
+		if r < utf8.RuneSelf {
+			return r
+		}
+
+		ok, checked := supported[r]
+		if !checked {
+			if supported == nil {
+				supported = make(map[rune]bool)
+			}
+
+			_, err := c.enc.NewEncoder().String(string(r))
+			ok, supported[r] = err == nil, err == nil
+		}
+
+		if !ok {
+			return replacement
+		}
+
+		return r
+	}, text)
+}
@@ -0,0 +1,34 @@
+//go:build slim
+
+package charset_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/charset"
+)
+
+func TestLookup_Slim(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"":             "utf-8",
+		"UTF-8":        "utf-8",
+		"latin1":       "ISO-8859-1",
+		"windows-1251": "windows-1251",
+	} {
+		c, err := charset.Lookup(give)
+
+		require.NoError(t, err, give)
+		assert.Equal(t, want, c.String())
+	}
+
+	for _, give := range []string{"gbk", "shift_jis", "foo"} { // the multibyte charsets are excluded
+		_, err := charset.Lookup(give)
+
+		assert.ErrorContains(t, err, "unsupported charset", give)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build !slim
+
+package charset_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/charset"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"":             "utf-8",
+		"UTF-8":        "utf-8",
+		" gbk ":        "GBK",
+		"iso-8859-1":   "ISO-8859-1",
+		"latin1":       "ISO-8859-1",
+		"windows-1251": "windows-1251",
+		"shift_jis":    "Shift_JIS",
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := charset.Lookup(give)
+
+			require.NoError(t, err)
+			assert.Equal(t, want, c.String())
+			assert.Equal(t, want == "utf-8", c.IsUTF8())
+		})
+	}
+
+	for give, wantErr := range map[string]string{
+		"foo":    "unsupported charset",
+		"utf-16": "is not ASCII-compatible",
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := charset.Lookup(give)
+
+			assert.ErrorContains(t, err, wantErr)
+		})
+	}
+}
+
+func TestCharset_Encode(t *testing.T) {
+	t.Parallel()
+
+	var mustLookup = func(name string) charset.Charset {
+		c, err := charset.Lookup(name)
+		require.NoError(t, err)
+
+		return c
+	}
+
+	for name, tc := range map[string]struct {
+		giveCharset string
+		giveText    string
+		giveHTML    bool
+		want        string
+	}{
+		"utf-8 as-is": {
+			giveCharset: "utf-8", giveText: "Café 😀 \xff", want: "Café 😀 \xff",
+		},
+		"gbk": {
+			giveCharset: "gbk", giveText: "错误 404", want: "\xb4\xed\xce\xf3 404",
+		},
+		"gbk html unsupported": {
+			giveCharset: "gbk", giveText: "<p>错 😀</p>", giveHTML: true, want: "<p>\xb4\xed &#128512;</p>",
+		},
+		"latin1": {
+			giveCharset: "iso-8859-1", giveText: "Café", want: "Caf\xe9",
+		},
+		"latin1 text unsupported": {
+			giveCharset: "iso-8859-1", giveText: "Café 错 😀", want: "Caf\xe9 ? ?",
+		},
+		"invalid utf-8": {
+			giveCharset: "iso-8859-1", giveText: "a\xffb", want: "a?b",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := mustLookup(tc.giveCharset).Encode([]byte(tc.giveText), tc.giveHTML)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !slim
+
+package charset
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// lookup returns the encoding and its canonical (MIME) name by the IANA name or alias.
+func lookup(name string) (_ encoding.Encoding, canonical string, found bool) {
+	enc, err := ianaindex.MIME.Encoding(name)
+	if err != nil || enc == nil { // unknown, or known but not supported
+		return nil, "", false
+	}
+
+	if canonical, err = ianaindex.MIME.Name(enc); err != nil {
+		canonical = name
+	}
+
+	return enc, canonical, true
+}
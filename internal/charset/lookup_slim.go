@@ -0,0 +1,34 @@
+//go:build slim
+
+package charset
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// slimCharsets are the charsets supported by the slim build (the multibyte charsets, like GBK or Shift_JIS, are
+// excluded to reduce the binary size), by the lowercased name or alias.
+var slimCharsets = map[string]struct { //nolint:gochecknoglobals
+	enc  encoding.Encoding
+	name string
+}{
+	"utf-8": {unicode.UTF8, "UTF-8"}, "utf8": {unicode.UTF8, "UTF-8"},
+	"iso-8859-1": {charmap.ISO8859_1, "ISO-8859-1"}, "latin1": {charmap.ISO8859_1, "ISO-8859-1"},
+	"iso-8859-15": {charmap.ISO8859_15, "ISO-8859-15"}, "latin-9": {charmap.ISO8859_15, "ISO-8859-15"},
+	"windows-1251": {charmap.Windows1251, "windows-1251"}, "cp1251": {charmap.Windows1251, "windows-1251"},
+	"windows-1252": {charmap.Windows1252, "windows-1252"}, "cp1252": {charmap.Windows1252, "windows-1252"},
+	"koi8-r": {charmap.KOI8R, "KOI8-R"},
+}
+
+// lookup returns the encoding and its canonical name by the name or alias.
+func lookup(name string) (_ encoding.Encoding, canonical string, found bool) {
+	if cs, ok := slimCharsets[strings.ToLower(name)]; ok {
+		return cs.enc, cs.name, true
+	}
+
+	return nil, "", false
+}
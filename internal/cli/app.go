@@ -9,9 +9,7 @@ import (
 	"github.com/urfave/cli/v3"
 
 	"github.com/binaryYuki/error-pages/internal/appmeta"
-	"github.com/binaryYuki/error-pages/internal/cli/build"
 	"github.com/binaryYuki/error-pages/internal/cli/healthcheck"
-	"github.com/binaryYuki/error-pages/internal/cli/perftest"
 	"github.com/binaryYuki/error-pages/internal/cli/serve"
 	"github.com/binaryYuki/error-pages/internal/logger"
 )
@@ -75,12 +73,10 @@ func NewApp(appName string) *cli.Command {
 
 			return ctx, nil
 		},
-		Commands: []*cli.Command{
+		Commands: append([]*cli.Command{
 			serve.NewCommand(log),
-			build.NewCommand(log),
 			healthcheck.NewCommand(log, healthcheck.NewHTTPHealthChecker()),
-			perftest.NewCommand(),
-		},
+		}, optionalCommands(log)...),
 		Version: fmt.Sprintf("%s (%s)", appmeta.Version(), runtime.Version()),
 		Flags: []cli.Flag{ // global flags
 			&logLevelFlag,
@@ -2,37 +2,61 @@ package build
 
 import (
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	textTemplate "text/template"
+	"time"
 
 	"github.com/urfave/cli/v3"
 
 	"github.com/binaryYuki/error-pages/internal/cli/shared"
 	"github.com/binaryYuki/error-pages/internal/config"
 	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/s3"
 	appTemplate "github.com/binaryYuki/error-pages/internal/template"
+	"github.com/binaryYuki/error-pages/l10n"
 )
 
 //go:embed index.html
 var indexHtml string
 
+//go:embed worker.js
+var workerJS string
+
 type command struct {
 	c *cli.Command
 
 	opt struct {
 		createIndex      bool
+		precompress      bool     // write the pre-compressed (`.br` and `.gz`) variants next to the pages
+		formats          []string // the alternative formats built next to the HTML pages
 		targetDirAbsPath string
+		buildTime        time.Time // zero means the current time (the build is not reproducible)
+		layout           Layout
+		s3               struct{ bucket, region, endpoint, prefix string } // an empty bucket means no upload
 	}
 }
 
+// ManifestFileName is the name of the manifest file with the SHA-256 checksums of the built files (in the
+// `sha256sum` format, so it can be verified using `sha256sum -c SHA256SUMS`).
+const ManifestFileName = "SHA256SUMS"
+
+// formatExtensions are the file extensions of the alternative response formats (by the format name, see the
+// [renderFormats]).
+var formatExtensions = map[string]string{"json": ".json", "xml": ".xml", "text": ".txt"} //nolint:gochecknoglobals
+
 // NewCommand creates `build` command.
 func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit
 	var (
@@ -44,12 +68,104 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit
 		addCodeFlag             = shared.AddHTTPCodesFlag
 		disableL10nFlag         = shared.DisableL10nFlag
 		disableMinificationFlag = shared.DisableMinificationFlag
-		createIndexFlag         = cli.BoolFlag{
+		keepCommentsFlag        = shared.MinifyKeepCommentsFlag
+		keepCondCommentsFlag    = shared.MinifyKeepConditionalCommentsFlag
+		keepInlineCSSFlag       = shared.MinifyKeepInlineCSSFlag
+		keepInlineJSFlag        = shared.MinifyKeepInlineJSFlag
+		noMinifyTplFlag         = shared.NoMinifyTemplateFlag
+		layoutFlag              = cli.StringFlag{
+			Name:     "layout",
+			Usage:    "Layout of the built files (" + strings.Join(LayoutStrings(), "/") + ")",
+			Value:    LayoutDefault.String(),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+			Validator: func(s string) error {
+				_, err := ParseLayout(s)
+
+				return err
+			},
+		}
+		formatsFlag = cli.StringSliceFlag{
+			Name: "formats",
+			Usage: "Also build the pages in these response formats (" +
+				strings.Join(slices.Sorted(maps.Keys(formatExtensions)), "/") + "), next to the HTML pages with the " +
+				"same name and the json, xml, or txt extension (the formats with an empty template are skipped)",
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			Validator: func(formats []string) error {
+				for _, f := range formats {
+					if _, ok := formatExtensions[strings.ToLower(f)]; !ok {
+						return fmt.Errorf("unrecognized format: %q", f)
+					}
+				}
+
+				return nil
+			},
+		}
+		createIndexFlag = cli.BoolFlag{
 			Name:     "index",
 			Aliases:  []string{"i"},
 			Usage:    "Generate index.html file with links to all error pages",
 			Category: shared.CategoryBuild,
 		}
+		precompressFlag = cli.BoolFlag{
+			Name: "precompress",
+			Usage: "Also write the Brotli and GZIP compressed variants of the pages next to them (like '404.html.br' " +
+				"and '404.html.gz'), served by the 'serve --static-dir' command depending on the Accept-Encoding " +
+				"request header (or by nginx using the 'brotli_static' and 'gzip_static' directives)",
+			Category: shared.CategoryBuild,
+		}
+		sourceDateEpochFlag = cli.Int64Flag{
+			Name: "source-date-epoch",
+			Usage: "Unix timestamp used as the build time (for the date and time template functions and the file " +
+				"modification times), to make the build reproducible",
+			Sources:  cli.EnvVars("SOURCE_DATE_EPOCH"),
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+			Validator: func(v int64) error {
+				if v < 0 {
+					return fmt.Errorf("wrong source date epoch [%d]: it should not be negative", v)
+				}
+
+				return nil
+			},
+		}
+		s3BucketFlag = cli.StringFlag{
+			Name: "s3-bucket",
+			Usage: "Upload the built files to this Amazon S3 (or S3-compatible) bucket (the credentials are read from " +
+				"the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN environment variables)",
+			Sources:  cli.EnvVars("S3_BUCKET"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+		}
+		s3RegionFlag = cli.StringFlag{
+			Name:     "s3-region",
+			Usage:    "Region of the S3 bucket",
+			Value:    s3.DefaultRegion,
+			Sources:  cli.EnvVars("AWS_REGION"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+		}
+		s3EndpointFlag = cli.StringFlag{
+			Name:     "s3-endpoint",
+			Usage:    "Custom S3-compatible endpoint URL (e.g. 'http://127.0.0.1:9000' for MinIO; path-style URLs are used)",
+			Sources:  cli.EnvVars("AWS_ENDPOINT_URL_S3"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+		}
+		s3PrefixFlag = cli.StringFlag{
+			Name: "s3-prefix",
+			Usage: "Bucket key prefix for the uploaded files (e.g. 'errors'; it's also used for the CloudFront " +
+				"response page paths with the s3 layout)",
+			Sources:  cli.EnvVars("S3_PREFIX"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+		}
 		targetDirFlag = cli.StringFlag{
 			Name:     "target-dir",
 			Aliases:  []string{"out", "dir", "o"},
@@ -83,8 +199,29 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit
 		Action: func(ctx context.Context, c *cli.Command) error {
 			cfg.L10n.Disable = c.Bool(disableL10nFlag.Name)
 			cfg.DisableMinification = c.Bool(disableMinificationFlag.Name)
+			cfg.Minification.KeepComments = c.Bool(keepCommentsFlag.Name)
+			cfg.Minification.KeepConditionalComments = c.Bool(keepCondCommentsFlag.Name)
+			cfg.Minification.KeepInlineCSS = c.Bool(keepInlineCSSFlag.Name)
+			cfg.Minification.KeepInlineJS = c.Bool(keepInlineJSFlag.Name)
 			cmd.opt.createIndex = c.Bool(createIndexFlag.Name)
+			cmd.opt.precompress = c.Bool(precompressFlag.Name)
 			cmd.opt.targetDirAbsPath, _ = filepath.Abs(c.String(targetDirFlag.Name)) // an error checked by [os.Stat] validator
+			cmd.opt.layout, _ = ParseLayout(c.String(layoutFlag.Name))               // already validated
+
+			for _, f := range c.StringSlice(formatsFlag.Name) {
+				if f = strings.ToLower(f); !slices.Contains(cmd.opt.formats, f) {
+					cmd.opt.formats = append(cmd.opt.formats, f)
+				}
+			}
+
+			cmd.opt.s3.bucket = c.String(s3BucketFlag.Name)
+			cmd.opt.s3.region = c.String(s3RegionFlag.Name)
+			cmd.opt.s3.endpoint = c.String(s3EndpointFlag.Name)
+			cmd.opt.s3.prefix = strings.Trim(c.String(s3PrefixFlag.Name), "/")
+
+			if c.IsSet(sourceDateEpochFlag.Name) {
+				cmd.opt.buildTime = time.Unix(c.Int64(sourceDateEpochFlag.Name), 0).UTC()
+			}
 
 			// add templates from files to the configuration
 			if add := c.StringSlice(addTplFlag.Name); len(add) > 0 {
@@ -109,6 +246,15 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit
 				}
 			}
 
+			// opt the templates out of the minification
+			if noMinify := c.StringSlice(noMinifyTplFlag.Name); len(noMinify) > 0 {
+				cfg.TemplateOptions = make(map[string]config.TemplateOptions, len(noMinify))
+
+				for _, templateName := range noMinify {
+					cfg.TemplateOptions[templateName] = config.TemplateOptions{DisableMinification: true}
+				}
+			}
+
 			// add custom HTTP codes to the configuration
 			if add := c.StringMap(addCodeFlag.Name); len(add) > 0 {
 				for code, desc := range shared.ParseHTTPCodes(add) {
@@ -130,7 +276,12 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit
 				logger.String("targetDir", cmd.opt.targetDirAbsPath),
 				logger.Strings("templates", cfg.Templates.Names()...),
 				logger.Bool("index", cmd.opt.createIndex),
+				logger.Bool("precompress", cmd.opt.precompress),
+				logger.String("layout", cmd.opt.layout.String()),
+				logger.Strings("formats", cmd.opt.formats...),
 				logger.Bool("l10n", !cfg.L10n.Disable),
+				logger.Bool("reproducible", !cmd.opt.buildTime.IsZero()),
+				logger.String("s3 bucket", cmd.opt.s3.bucket),
 			)
 
 			return cmd.Run(ctx, log, &cfg)
@@ -141,27 +292,98 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit
 			&addCodeFlag,
 			&disableL10nFlag,
 			&createIndexFlag,
+			&precompressFlag,
+			&layoutFlag,
+			&formatsFlag,
 			&targetDirFlag,
+			&sourceDateEpochFlag,
 			&disableMinificationFlag,
+			&keepCommentsFlag,
+			&keepCondCommentsFlag,
+			&keepInlineCSSFlag,
+			&keepInlineJSFlag,
+			&noMinifyTplFlag,
+			&s3BucketFlag,
+			&s3RegionFlag,
+			&s3EndpointFlag,
+			&s3PrefixFlag,
 		},
 	}
 
 	return cmd.c
 }
 
-func (cmd *command) Run( //nolint:funlen,gocognit
-	_ context.Context,
+func (cmd *command) Run( //nolint:funlen,gocognit,gocyclo
+	ctx context.Context,
 	log *logger.Logger,
 	cfg *config.Config,
 ) error {
 	type historyItem struct{ Code, Message, RelativePath string }
 
-	var history = make(map[string][]historyItem, len(cfg.Codes)*len(cfg.Templates)) // map[template_name]codes
+	var (
+		history   = make(map[string][]historyItem, len(cfg.Codes)*len(cfg.Templates)) // map[template_name]codes
+		checksums = make(map[string]string)                                           // map[relative_path]sha256
+		renderOpt appTemplate.Options
+		minifier  = appTemplate.NewMinifier(appTemplate.MinifyOptions{
+			KeepComments:            cfg.Minification.KeepComments,
+			KeepConditionalComments: cfg.Minification.KeepConditionalComments,
+			KeepInlineCSS:           cfg.Minification.KeepInlineCSS,
+			KeepInlineJS:            cfg.Minification.KeepInlineJS,
+			KeepESI:                 cfg.ESI,
+		})
+	)
+
+	if !cmd.opt.buildTime.IsZero() {
+		renderOpt.Now = func() time.Time { return cmd.opt.buildTime }
+	}
+
+	// writeFile writes the file (the path is relative to the target directory) and remembers its checksum
+	var writeFile = func(relPath string, content []byte) error {
+		var absPath = filepath.Join(cmd.opt.targetDirAbsPath, filepath.FromSlash(relPath))
+
+		if err := os.WriteFile(absPath, content, os.FileMode(0664)); err != nil { //nolint:mnd
+			return err
+		}
+
+		var sum = sha256.Sum256(content)
+
+		checksums[relPath] = hex.EncodeToString(sum[:])
+
+		return cmd.touch(absPath)
+	}
+
+	// writePage writes the page and its pre-compressed variants (if enabled) next to it
+	var writePage = func(relPath string, content []byte) error {
+		if err := writeFile(relPath, content); err != nil {
+			return err
+		}
+
+		if cmd.opt.precompress {
+			for _, v := range precompress(content) {
+				if err := writeFile(relPath+v.ext, v.content); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	// the codes are sorted to make the build order (and logs) stable
+	var codes = slices.Sorted(maps.Keys(cfg.Codes))
+
+	for _, templateName := range cfg.Templates.Names() {
+		var (
+			templateContent, _ = cfg.Templates.Get(templateName)
+			workerPages        = make(map[string]map[string]string) // map[code]map[format]content
+			defaultPage        []byte                               // the default code page (the S3 error document)
+		)
 
-	for templateName, templateContent := range cfg.Templates {
 		log.Debug("Processing template", logger.String("name", templateName))
 
-		for code, codeDescription := range cfg.Codes {
+		for _, code := range codes {
+			var codeDescription = cfg.Codes[code]
+
 			if err := createDirectory(filepath.Join(cmd.opt.targetDirAbsPath, templateName)); err != nil {
 				return fmt.Errorf("cannot create directory for template '%s': %w", templateName, err)
 			}
@@ -173,26 +395,62 @@ func (cmd *command) Run( //nolint:funlen,gocognit
 				continue
 			}
 
-			var outFilePath = path.Join(cmd.opt.targetDirAbsPath, templateName, code+".html")
+			var (
+				relPath = path.Join(templateName, code+".html")
+				props   = appTemplate.Props{
+					Code:               uint16(codeAsUint), //nolint:gosec
+					Message:            codeDescription.Message,
+					Description:        codeDescription.Description,
+					L10nDisabled:       cfg.L10n.Disable,
+					ShowRequestDetails: false,
+					TextDirection:      l10n.Direction(""),
+					LangCode:           l10n.DefaultLocale,
+					Charset:            "utf-8",
+					ReducedMotion:      cfg.Accessibility.ReducedMotion == config.MotionPreferenceReduce, // no client hints
+					PrintFriendly:      cfg.Accessibility.PrintFriendly,
+					ESI:                cfg.ESI,
+				}
+			)
 
-			if content, renderErr := appTemplate.Render(templateContent, appTemplate.Props{ //nolint:nestif
-				Code:               uint16(codeAsUint), //nolint:gosec
-				Message:            codeDescription.Message,
-				Description:        codeDescription.Description,
-				L10nDisabled:       cfg.L10n.Disable,
-				ShowRequestDetails: false,
-			}); renderErr == nil {
-				if !cfg.DisableMinification {
-					if mini, minErr := appTemplate.MiniHTML(content); minErr != nil {
+			if content, renderErr := appTemplate.RenderWith(templateContent, props, renderOpt); renderErr == nil { //nolint:nestif,lll
+				if !cfg.DisableMinification && !cfg.TemplateOptions[templateName].DisableMinification {
+					if mini, minErr := minifier.String(content); minErr != nil {
 						log.Warn("Cannot minify the content", logger.Error(minErr))
 					} else {
 						content = mini
 					}
 				}
 
-				if err := os.WriteFile(outFilePath, []byte(content), os.FileMode(0664)); err != nil { //nolint:mnd
+				if err := writePage(relPath, []byte(content)); err != nil {
 					return err
 				}
+
+				if code == strconv.Itoa(int(cfg.DefaultCodeToRender)) {
+					defaultPage = []byte(content)
+				}
+
+				if cmd.opt.layout == LayoutCloudflareWorkers || len(cmd.opt.formats) > 0 {
+					formats, fmtErr := renderFormats(cfg, props, renderOpt)
+					if fmtErr != nil {
+						return fmtErr
+					}
+
+					for _, name := range cmd.opt.formats {
+						formatted, ok := formats[name]
+						if !ok {
+							continue
+						}
+
+						if err := writePage(path.Join(templateName, code+formatExtensions[name]), []byte(formatted)); err != nil {
+							return err
+						}
+					}
+
+					if cmd.opt.layout == LayoutCloudflareWorkers {
+						formats["html"] = content
+						workerPages[code] = formats
+					}
+				}
 			} else {
 				return fmt.Errorf("cannot render template '%s': %w", templateName, renderErr)
 			}
@@ -202,18 +460,54 @@ func (cmd *command) Run( //nolint:funlen,gocognit
 			history[templateName] = append(history[templateName], historyItem{
 				Code:         code,
 				Message:      codeDescription.Message,
-				RelativePath: "." + strings.TrimPrefix(outFilePath, cmd.opt.targetDirAbsPath), // to make it relative
+				RelativePath: "./" + relPath,
 			})
 		}
+
+		if cmd.opt.layout == LayoutCloudflareWorkers {
+			var script, err = workerScript(templateName, workerPages, cfg.DefaultCodeToRender)
+			if err != nil {
+				return fmt.Errorf("cannot create the worker script for template '%s': %w", templateName, err)
+			}
+
+			if err = writeFile(path.Join(templateName, "_worker.js"), script); err != nil {
+				return err
+			}
+
+			log.Debug("Worker script created", logger.String("template", templateName))
+		}
+
+		if cmd.opt.layout == LayoutS3 {
+			if defaultPage != nil {
+				if err := writeFile(path.Join(templateName, S3ErrorDocumentName), defaultPage); err != nil {
+					return err
+				}
+			}
+
+			var builtCodes = make([]string, 0, len(history[templateName]))
+
+			for _, item := range history[templateName] {
+				builtCodes = append(builtCodes, item.Code)
+			}
+
+			responses, err := cloudFrontErrorResponses(cmd.opt.s3.prefix, templateName, builtCodes)
+			if err != nil {
+				return err
+			}
+
+			if err = writeFile(path.Join(templateName, CloudFrontErrorResponsesName), responses); err != nil {
+				return err
+			}
+		}
+
+		if err := cmd.touch(filepath.Join(cmd.opt.targetDirAbsPath, templateName)); err != nil {
+			return err
+		}
 	}
 
 	if cmd.opt.createIndex {
 		log.Debug("Creating the index file")
 
-		for name := range history {
-			slices.SortFunc(history[name], func(a, b historyItem) int { return strings.Compare(a.Code, b.Code) })
-		}
-
 		indexTpl, tplErr := template.New("index").Parse(indexHtml)
 		if tplErr != nil {
 			return tplErr
@@ -225,16 +519,105 @@ func (cmd *command) Run( //nolint:funlen,gocognit
 			return err
 		}
 
-		return os.WriteFile(
-			filepath.Join(cmd.opt.targetDirAbsPath, "index.html"),
-			[]byte(buf.String()),
-			os.FileMode(0664), //nolint:mnd
-		)
+		if err := writeFile("index.html", []byte(buf.String())); err != nil {
+			return err
+		}
+	}
+
+	// the manifest lists the files sorted by the path, so it can be diffed between the releases
+	var manifest strings.Builder
+
+	for _, relPath := range slices.Sorted(maps.Keys(checksums)) {
+		manifest.WriteString(checksums[relPath] + "  " + relPath + "\n")
+	}
+
+	var manifestPath = filepath.Join(cmd.opt.targetDirAbsPath, ManifestFileName)
+
+	if err := os.WriteFile(manifestPath, []byte(manifest.String()), os.FileMode(0664)); err != nil { //nolint:mnd
+		return err
+	}
+
+	log.Debug("Manifest created", logger.String("path", manifestPath), logger.Int("files", len(checksums)))
+
+	if err := cmd.touch(manifestPath); err != nil {
+		return err
+	}
+
+	if cmd.opt.s3.bucket != "" {
+		// the manifest is uploaded last, so it never lists the files missing in the bucket
+		return cmd.upload(ctx, log, append(slices.Sorted(maps.Keys(checksums)), ManifestFileName))
 	}
 
 	return nil
 }
 
+// renderFormats renders the alternative (JSON, XML, and plain text) response formats of the page, by the format
+// name. The formats with an empty template are skipped.
+func renderFormats(cfg *config.Config, props appTemplate.Props, opt appTemplate.Options) (map[string]string, error) {
+	var result = make(map[string]string, 4) //nolint:mnd // including the HTML page
+
+	for _, f := range [...]struct {
+		name, content string
+		escaping      appTemplate.Escaping
+	}{
+		{"json", cfg.Formats.JSON, appTemplate.EscapeJSON},
+		{"xml", cfg.Formats.XML, appTemplate.EscapeXML},
+		{"text", cfg.Formats.PlainText, appTemplate.EscapeNone},
+	} {
+		if f.content == "" {
+			continue
+		}
+
+		opt.Escaping = f.escaping
+
+		content, err := appTemplate.RenderWith(f.content, props, opt)
+		if err != nil {
+			return nil, fmt.Errorf("cannot render the %s format: %w", f.name, err)
+		}
+
+		result[f.name] = content
+	}
+
+	return result, nil
+}
+
+// workerScript returns the Cloudflare Workers script with the embedded pages (map[code]map[format]content) of the
+// template.
+func workerScript(templateName string, pages map[string]map[string]string, defaultCode uint16) ([]byte, error) {
+	tpl, err := textTemplate.New("worker").Parse(workerJS)
+	if err != nil {
+		return nil, err
+	}
+
+	pagesJSON, err := json.Marshal(pages) // the keys are sorted, so the script is reproducible
+	if err != nil {
+		return nil, err
+	}
+
+	defaultCodeJSON, _ := json.Marshal(strconv.Itoa(int(defaultCode)))
+
+	var buf strings.Builder
+
+	if err = tpl.Execute(&buf, struct{ Template, Pages, DefaultCode string }{
+		Template:    templateName,
+		Pages:       string(pagesJSON),
+		DefaultCode: string(defaultCodeJSON),
+	}); err != nil {
+		return nil, err
+	}
+
+	return []byte(buf.String()), nil
+}
+
+// touch sets the access and modification times of the file (or directory) to the build time (if set).
+func (cmd *command) touch(name string) error {
+	if cmd.opt.buildTime.IsZero() {
+		return nil
+	}
+
+	return os.Chtimes(name, cmd.opt.buildTime, cmd.opt.buildTime)
+}
+
 func createDirectory(path string) error {
 	var stat, err = os.Stat(path)
 	if err != nil {
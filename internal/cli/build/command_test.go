@@ -0,0 +1,302 @@
+//go:build !slim
+
+package build_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/cli/build"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestCommand_Reproducible(t *testing.T) {
+	t.Parallel()
+
+	var tpl = filepath.Join(t.TempDir(), "clock.html")
+
+	require.NoError(t, os.WriteFile(tpl, []byte("<p>{{ code }} built at {{ nowUnix }}</p>"), 0o600))
+
+	var run = func(t *testing.T) (dir, manifest string) {
+		t.Helper()
+
+		dir = t.TempDir()
+
+		require.NoError(t, build.NewCommand(logger.NewNop()).Run(context.Background(), []string{
+			"build",
+			"--add-template", tpl,
+			"--disable-template", "ghost",
+			"--index",
+			"--source-date-epoch", "1700000000",
+			"--target-dir", dir,
+		}))
+
+		data, err := os.ReadFile(filepath.Join(dir, build.ManifestFileName))
+		require.NoError(t, err)
+
+		return dir, string(data)
+	}
+
+	var dir, manifest = run(t)
+
+	var _, again = run(t)
+
+	assert.Equal(t, manifest, again) // the same input produces the same output
+
+	content, err := os.ReadFile(filepath.Join(dir, "clock", "404.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>404 built at 1700000000</p>", string(content))
+
+	var (
+		lines = strings.Split(strings.TrimSuffix(manifest, "\n"), "\n")
+		paths = make([]string, 0, len(lines))
+	)
+
+	for _, line := range lines {
+		sum, relPath, ok := strings.Cut(line, "  ")
+		require.True(t, ok, line)
+
+		data, rErr := os.ReadFile(filepath.Join(dir, filepath.FromSlash(relPath)))
+		require.NoError(t, rErr)
+
+		var want = sha256.Sum256(data)
+
+		assert.Equal(t, hex.EncodeToString(want[:]), sum, relPath)
+
+		info, sErr := os.Stat(filepath.Join(dir, filepath.FromSlash(relPath)))
+		require.NoError(t, sErr)
+		assert.True(t, info.ModTime().Equal(time.Unix(1700000000, 0)), relPath)
+
+		paths = append(paths, relPath)
+	}
+
+	assert.IsIncreasing(t, paths)
+	assert.Contains(t, paths, "clock/404.html")
+	assert.Contains(t, paths, "index.html")
+	assert.NotContains(t, paths, build.ManifestFileName)
+}
+
+func TestCommand_Formats(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tpl = filepath.Join(t.TempDir(), "plain.html")
+		dir = t.TempDir()
+	)
+
+	require.NoError(t, os.WriteFile(tpl, []byte("<p>  {{ code }}  </p>"), 0o600))
+
+	require.NoError(t, build.NewCommand(logger.NewNop()).Run(context.Background(), []string{
+		"build",
+		"--add-template", tpl,
+		"--formats", "JSON",
+		"--formats", "text",
+		"--target-dir", dir,
+	}))
+
+	for name, want := range map[string]string{
+		"404.html": "<p>404</p>", // minified
+		"404.txt":  "Error 404: Not Found\nThe server can not find the requested page\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, "plain", name))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "plain", "503.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"code": 503`)
+
+	_, err = os.Stat(filepath.Join(dir, "plain", "404.xml")) // not requested
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	manifest, err := os.ReadFile(filepath.Join(dir, build.ManifestFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "  plain/404.json\n")
+
+	assert.Error(t, build.NewCommand(logger.NewNop()).Run(context.Background(), []string{
+		"build", "--add-template", tpl, "--formats", "yaml", "--target-dir", dir,
+	}))
+}
+
+func TestCommand_Precompress(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tpl = filepath.Join(t.TempDir(), "long.html")
+		dir = t.TempDir()
+	)
+
+	require.NoError(t, os.WriteFile(tpl, []byte(strings.Repeat("<p>{{ code }}: {{ message }}</p>", 50)), 0o600))
+
+	require.NoError(t, build.NewCommand(logger.NewNop()).Run(context.Background(), []string{
+		"build",
+		"--add-template", tpl,
+		"--disable-template", "ghost",
+		"--formats", "json",
+		"--precompress",
+		"--target-dir", dir,
+	}))
+
+	for _, name := range []string{"404.html", "404.json"} {
+		original, err := os.ReadFile(filepath.Join(dir, "long", name))
+		require.NoError(t, err)
+
+		br, err := os.ReadFile(filepath.Join(dir, "long", name+".br"))
+		require.NoError(t, err)
+
+		decoded, err := fasthttp.AppendUnbrotliBytes(nil, br)
+		require.NoError(t, err)
+		assert.Equal(t, original, decoded, name)
+
+		gz, err := os.ReadFile(filepath.Join(dir, "long", name+".gz"))
+		require.NoError(t, err)
+
+		decoded, err = fasthttp.AppendGunzipBytes(nil, gz)
+		require.NoError(t, err)
+		assert.Equal(t, original, decoded, name)
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(dir, build.ManifestFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "  long/404.html.br\n")
+	assert.Contains(t, string(manifest), "  long/404.html.gz\n")
+
+	_, err = os.Stat(filepath.Join(dir, build.ManifestFileName+".gz")) // only the pages are compressed
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCommand_CloudflareWorkersLayout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tpl = filepath.Join(t.TempDir(), "edge.html")
+		dir = t.TempDir()
+	)
+
+	require.NoError(t, os.WriteFile(tpl, []byte("<p>{{ code }}: {{ message }}</p>"), 0o600))
+
+	require.NoError(t, build.NewCommand(logger.NewNop()).Run(context.Background(), []string{
+		"build",
+		"--add-template", tpl,
+		"--layout", "cloudflare-workers",
+		"--target-dir", dir,
+	}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "edge", "_worker.js"))
+	require.NoError(t, err)
+
+	var script = string(data)
+
+	assert.Contains(t, script, `const defaultCode = "404";`)
+	assert.Contains(t, script, "export default {")
+
+	_, pagesJSON, ok := strings.Cut(script, "const pages = ")
+	require.True(t, ok)
+
+	pagesJSON, _, ok = strings.Cut(pagesJSON, "; // ")
+	require.True(t, ok)
+
+	var pages map[string]map[string]string
+
+	require.NoError(t, json.Unmarshal([]byte(pagesJSON), &pages))
+	require.Contains(t, pages, "404")
+	assert.Equal(t, "<p>404: Not Found</p>", pages["404"]["html"])
+	assert.Equal(t, "Error 404: Not Found\nThe server can not find the requested page\n", pages["404"]["text"])
+	assert.Contains(t, pages["404"]["json"], `"code": 404`)
+	assert.Contains(t, pages["404"]["xml"], "<code>404</code>")
+
+	manifest, err := os.ReadFile(filepath.Join(dir, build.ManifestFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "  edge/_worker.js\n")
+
+	_, err = os.Stat(filepath.Join(dir, "edge", "404.html")) // the default layout files are still built
+	assert.NoError(t, err)
+}
+
+func TestCommand_S3Layout(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	var (
+		tpl = filepath.Join(t.TempDir(), "edge.html")
+		dir = t.TempDir()
+
+		mu       sync.Mutex
+		uploaded = make(map[string]string) // map[key]content_type
+		lastKey  string
+	)
+
+	require.NoError(t, os.WriteFile(tpl, []byte("<p>{{ code }}</p>"), 0o600))
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		lastKey = strings.TrimPrefix(r.URL.Path, "/bucket/")
+		uploaded[lastKey] = r.Header.Get("Content-Type")
+	}))
+
+	t.Cleanup(srv.Close)
+
+	require.NoError(t, build.NewCommand(logger.NewNop()).Run(context.Background(), []string{
+		"build",
+		"--add-template", tpl,
+		"--layout", "s3",
+		"--target-dir", dir,
+		"--s3-bucket", "bucket",
+		"--s3-endpoint", srv.URL,
+		"--s3-prefix", "/errors/",
+	}))
+
+	errorDocument, err := os.ReadFile(filepath.Join(dir, "edge", build.S3ErrorDocumentName))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>404</p>", string(errorDocument))
+
+	responses, err := os.ReadFile(filepath.Join(dir, "edge", build.CloudFrontErrorResponsesName))
+	require.NoError(t, err)
+
+	var cloudFront struct {
+		Quantity int
+		Items    []struct {
+			ErrorCode        int
+			ResponsePagePath string
+			ResponseCode     string
+		}
+	}
+
+	require.NoError(t, json.Unmarshal(responses, &cloudFront))
+	require.Equal(t, len(cloudFront.Items), cloudFront.Quantity)
+	assert.Equal(t, 400, cloudFront.Items[0].ErrorCode)
+	assert.Equal(t, "/errors/edge/400.html", cloudFront.Items[0].ResponsePagePath)
+	assert.Equal(t, "400", cloudFront.Items[0].ResponseCode)
+
+	for _, item := range cloudFront.Items {
+		assert.NotEqual(t, 418, item.ErrorCode) // CloudFront does not support it
+	}
+
+	assert.Equal(t, "text/html; charset=utf-8", uploaded["errors/edge/404.html"])
+	assert.Equal(t, "text/html; charset=utf-8", uploaded["errors/edge/error.html"])
+	assert.Equal(t, "application/json; charset=utf-8", uploaded["errors/edge/cloudfront.json"])
+	assert.Equal(t, "errors/"+build.ManifestFileName, lastKey) // the manifest is uploaded last
+}
@@ -0,0 +1,63 @@
+package build
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layout represents the layout of the built files.
+type Layout byte
+
+const (
+	LayoutDefault           Layout = iota // the `<template>/<code>.html` pages, default
+	LayoutCloudflareWorkers               // the default layout plus the `<template>/_worker.js` Cloudflare Workers script
+	LayoutS3                              // the default layout plus the S3 website error document and CloudFront responses
+)
+
+// String returns a human-readable representation of the layout.
+func (l Layout) String() string {
+	switch l {
+	case LayoutDefault:
+		return "default"
+	case LayoutCloudflareWorkers:
+		return "cloudflare-workers"
+	case LayoutS3:
+		return "s3"
+	}
+
+	return fmt.Sprintf("Layout(%d)", l)
+}
+
+// Layouts returns a slice of all layouts.
+func Layouts() []Layout {
+	return []Layout{LayoutDefault, LayoutCloudflareWorkers, LayoutS3}
+}
+
+// LayoutStrings returns a slice of all layouts as strings.
+func LayoutStrings() []string {
+	var (
+		layouts = Layouts()
+		result  = make([]string, len(layouts))
+	)
+
+	for i := range layouts {
+		result[i] = layouts[i].String()
+	}
+
+	return result
+}
+
+// ParseLayout parses a layout (case is ignored, an empty string means the default layout). If the provided string
+// is invalid, an error is returned.
+func ParseLayout(s string) (Layout, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case LayoutDefault.String(), "":
+		return LayoutDefault, nil
+	case LayoutCloudflareWorkers.String():
+		return LayoutCloudflareWorkers, nil
+	case LayoutS3.String():
+		return LayoutS3, nil
+	}
+
+	return LayoutDefault, fmt.Errorf("unrecognized layout: %q", s)
+}
@@ -0,0 +1,32 @@
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/cli/build"
+)
+
+func TestLayout(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"default", "cloudflare-workers", "s3"}, build.LayoutStrings())
+	assert.Equal(t, "Layout(255)", build.Layout(255).String())
+
+	for give, want := range map[string]build.Layout{
+		"":                     build.LayoutDefault,
+		"default":              build.LayoutDefault,
+		" Cloudflare-Workers ": build.LayoutCloudflareWorkers,
+		"S3":                   build.LayoutS3,
+	} {
+		got, err := build.ParseLayout(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := build.ParseLayout("netlify")
+	assert.ErrorContains(t, err, "unrecognized layout")
+}
@@ -0,0 +1,27 @@
+package build
+
+import "github.com/valyala/fasthttp"
+
+// precompressed is the pre-compressed variant of the built page.
+type precompressed struct {
+	ext     string // the file name suffix (like `.br` for the `404.html.br`)
+	content []byte
+}
+
+// precompress returns the Brotli and GZIP variants of the page content (both using the best compression, since it's
+// done once), so they may be served as-is by the `serve --static-dir` command or the web servers (like the nginx
+// `brotli_static` and `gzip_static` directives). The variants that are not smaller than the content are skipped.
+func precompress(content []byte) []precompressed {
+	var variants = make([]precompressed, 0, 2) //nolint:mnd
+
+	for _, v := range []precompressed{
+		{ext: ".br", content: fasthttp.AppendBrotliBytesLevel(nil, content, fasthttp.CompressBrotliBestCompression)},
+		{ext: ".gz", content: fasthttp.AppendGzipBytesLevel(nil, content, fasthttp.CompressBestCompression)},
+	} {
+		if len(v.content) < len(content) {
+			variants = append(variants, v)
+		}
+	}
+
+	return variants
+}
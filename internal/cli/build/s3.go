@@ -0,0 +1,111 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/s3"
+)
+
+// S3ErrorDocumentName is the name of the S3 static website error document (the default code page), created in
+// every template directory with the [LayoutS3].
+const S3ErrorDocumentName = "error.html"
+
+// CloudFrontErrorResponsesName is the name of the CloudFront custom error responses file, created in every
+// template directory with the [LayoutS3].
+const CloudFrontErrorResponsesName = "cloudfront.json"
+
+// cloudFrontErrorCodes are the HTTP codes CloudFront allows to customize the error responses for.
+var cloudFrontErrorCodes = []uint16{400, 403, 404, 405, 414, 416, 500, 501, 502, 503, 504} //nolint:gochecknoglobals
+
+// cloudFrontErrorResponses returns the `CustomErrorResponses` part of the CloudFront distribution config for the
+// built pages of the template (the other codes are skipped), so it can be used with the `aws cloudfront
+// update-distribution` command. The prefix is the bucket key prefix the pages are uploaded with.
+func cloudFrontErrorResponses(prefix, templateName string, codes []string) ([]byte, error) {
+	type item struct {
+		ErrorCode          uint16
+		ResponsePagePath   string
+		ResponseCode       string
+		ErrorCachingMinTTL uint
+	}
+
+	var items = make([]item, 0, len(cloudFrontErrorCodes))
+
+	for _, code := range codes {
+		if c, err := strconv.ParseUint(code, 10, 16); err == nil && slices.Contains(cloudFrontErrorCodes, uint16(c)) {
+			items = append(items, item{
+				ErrorCode:          uint16(c),
+				ResponsePagePath:   "/" + path.Join(prefix, templateName, code+".html"),
+				ResponseCode:       code,
+				ErrorCachingMinTTL: 10, //nolint:mnd // the CloudFront default
+			})
+		}
+	}
+
+	return json.MarshalIndent(struct {
+		Quantity int
+		Items    []item
+	}{Quantity: len(items), Items: items}, "", "  ")
+}
+
+// contentType returns the content type of the built file (by its extension).
+func contentType(relPath string) string {
+	switch path.Ext(relPath) {
+	case ".html":
+		return "text/html; charset=utf-8"
+	case ".js":
+		return "text/javascript; charset=utf-8"
+	case ".json":
+		return "application/json; charset=utf-8"
+	case ".xml":
+		return "application/xml; charset=utf-8"
+	}
+
+	return "text/plain; charset=utf-8" // the manifest and the plain text pages
+}
+
+// upload uploads the built files (the paths are relative to the target directory) to the S3 bucket.
+func (cmd *command) upload(ctx context.Context, log *logger.Logger, relPaths []string) error {
+	creds, err := s3.CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	client, err := s3.NewClient(cmd.opt.s3.bucket, cmd.opt.s3.region, cmd.opt.s3.endpoint, creds)
+	if err != nil {
+		return err
+	}
+
+	for _, relPath := range relPaths {
+		if ext := path.Ext(relPath); ext == ".br" || ext == ".gz" {
+			continue // the pre-compressed variants can't be negotiated by the bucket
+		}
+
+		content, rErr := os.ReadFile(filepath.Join(cmd.opt.targetDirAbsPath, filepath.FromSlash(relPath)))
+		if rErr != nil {
+			return rErr
+		}
+
+		var key = path.Join(cmd.opt.s3.prefix, relPath)
+
+		if err = client.Put(ctx, key, content, contentType(relPath)); err != nil {
+			return err
+		}
+
+		log.Debug("File uploaded", logger.String("bucket", cmd.opt.s3.bucket), logger.String("key", key))
+	}
+
+	log.Info("Error pages uploaded",
+		logger.String("bucket", cmd.opt.s3.bucket),
+		logger.String("prefix", cmd.opt.s3.prefix),
+		logger.Int("files", len(relPaths)),
+	)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build !slim
+
+package cli
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/binaryYuki/error-pages/internal/cli/build"
+	"github.com/binaryYuki/error-pages/internal/cli/perftest"
+	"github.com/binaryYuki/error-pages/internal/cli/service"
+	"github.com/binaryYuki/error-pages/internal/cli/themetest"
+	"github.com/binaryYuki/error-pages/internal/cli/verifyproxy"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+// optionalCommands returns the commands that are not needed to serve the error pages (they are excluded from the
+// slim build).
+func optionalCommands(log *logger.Logger) []*cli.Command {
+	return []*cli.Command{
+		build.NewCommand(log),
+		perftest.NewCommand(),
+		service.NewCommand(log),
+		themetest.NewCommand(log),
+		verifyproxy.NewCommand(log),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build slim
+
+package cli
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+// optionalCommands returns nothing in the slim build - only the `serve` and `healthcheck` commands are available.
+func optionalCommands(*logger.Logger) []*cli.Command { return nil }
@@ -6,8 +6,11 @@ import (
 
 	"github.com/urfave/cli/v3"
 
+	"github.com/binaryYuki/error-pages/internal/a11y"
 	"github.com/binaryYuki/error-pages/internal/cli/shared"
+	"github.com/binaryYuki/error-pages/internal/config"
 	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/template"
 )
 
 type checker interface {
@@ -15,20 +18,91 @@ type checker interface {
 }
 
 // NewCommand creates `healthcheck` command.
-func NewCommand(_ *logger.Logger, checker checker) *cli.Command {
-	var portFlag = shared.ListenPortFlag
+func NewCommand(log *logger.Logger, checker checker) *cli.Command {
+	var (
+		portFlag       = shared.ListenPortFlag
+		pathPrefixFlag = shared.PathPrefixFlag
+		a11yFlag       = cli.BoolFlag{
+			Name: "a11y",
+			Usage: "Instead of checking the HTTP server, run the basic accessibility checks (page language, title, " +
+				"main landmark, image alternatives, and the color contrast) against the built-in templates rendered " +
+				"with the sample data, and fail on any finding",
+		}
+	)
 
 	portFlag.Usage = "TCP port number with the HTTP server to check"
+	pathPrefixFlag.Usage = "Path prefix the HTTP server routes are mounted under"
 
 	return &cli.Command{
 		Name:    "healthcheck",
 		Aliases: []string{"chk", "health", "check"},
 		Usage:   "Health checker for the HTTP server. The use case - docker health check",
 		Action: func(ctx context.Context, c *cli.Command) error {
-			return checker.Check(ctx, fmt.Sprintf("http://127.0.0.1:%d", c.Uint(portFlag.Name)))
+			if c.Bool(a11yFlag.Name) {
+				return auditTemplates(log, config.New())
+			}
+
+			return checker.Check(ctx, fmt.Sprintf(
+				"http://127.0.0.1:%d%s", c.Uint(portFlag.Name), config.NormalizePathPrefix(c.String(pathPrefixFlag.Name)),
+			))
 		},
 		Flags: []cli.Flag{
 			&portFlag,
+			&pathPrefixFlag,
+			&a11yFlag,
 		},
 	}
 }
+
+// auditTemplates renders every template with the sample data (so the optional blocks, like the request details,
+// the outage banner, or the print styles, are rendered too) and audits the output. The findings are logged.
+func auditTemplates(log *logger.Logger, cfg config.Config) error {
+	var total int
+
+	for _, name := range cfg.Templates.Names() {
+		var content, _ = cfg.Templates.Get(name)
+
+		for _, code := range [...]uint16{404, 503} { //nolint:mnd
+			var desc, _ = cfg.Codes.Find(code)
+
+			page, err := template.Render(content, template.Props{
+				Code:               code,
+				Message:            desc.Message,
+				Description:        desc.Description,
+				RequestID:          "AMS-0193f1e2-7b5c-7d3a-9c1e-2f6a8b4d0e5f",
+				Host:               "example.com",
+				ClientIP:           "203.0.113.7",
+				TextDirection:      "ltr",
+				LangCode:           "en",
+				Charset:            "utf-8",
+				Banner:             "Scheduled maintenance is in progress",
+				BannerSeverity:     "warning",
+				ShowRequestDetails: true,
+				ReducedMotion:      true,
+				PrintFriendly:      true,
+			})
+			if err != nil {
+				return fmt.Errorf("cannot render template '%s': %w", name, err)
+			}
+
+			for _, finding := range a11y.Audit([]byte(page)) {
+				total++
+
+				log.Error("Accessibility issue",
+					logger.String("template", name),
+					logger.Uint64("code", uint64(code)),
+					logger.String("rule", finding.Rule),
+					logger.String("message", finding.Message),
+				)
+			}
+		}
+	}
+
+	if total > 0 {
+		return fmt.Errorf("%d accessibility issue(s) found", total)
+	}
+
+	log.Info("No accessibility issues found", logger.Strings("templates", cfg.Templates.Names()...))
+
+	return nil
+}
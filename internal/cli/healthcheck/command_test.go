@@ -53,3 +53,20 @@ func TestCommand_RunFail(t *testing.T) {
 		assert.AnError,
 	)
 }
+
+func TestCommand_RunWithPathPrefix(t *testing.T) {
+	var cmd = healthcheck.NewCommand(logger.NewNop(), &fakeHealthChecker{
+		t:           t,
+		wantAddress: "http://127.0.0.1:1234/errors",
+	})
+
+	require.NoError(t, cmd.Run(context.Background(), []string{"", "--port", "1234", "--path-prefix", "errors/"}))
+}
+
+func TestCommand_RunA11y(t *testing.T) {
+	t.Parallel()
+
+	var cmd = healthcheck.NewCommand(logger.NewNop(), nil) // the server is not checked
+
+	require.NoError(t, cmd.Run(context.Background(), []string{"", "--a11y"}))
+}
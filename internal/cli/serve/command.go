@@ -5,15 +5,31 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/urfave/cli/v3"
 
+	"github.com/binaryYuki/error-pages/internal/charset"
 	"github.com/binaryYuki/error-pages/internal/cli/shared"
 	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/datacenter"
+	"github.com/binaryYuki/error-pages/internal/featureflag"
+	"github.com/binaryYuki/error-pages/internal/filewatch"
 	appHttp "github.com/binaryYuki/error-pages/internal/http"
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
 	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/s3"
+	"github.com/binaryYuki/error-pages/internal/template"
+	"github.com/binaryYuki/error-pages/internal/upstream"
+	"github.com/binaryYuki/error-pages/internal/wasmhook"
 )
 
 type command struct {
@@ -25,6 +41,20 @@ type command struct {
 			port           uint16
 			readBufferSize uint
 		}
+
+		watch struct { // the configuration files watching
+			interval time.Duration   // zero disables the watching
+			files    func() []string // the files to watch (the reloaded configuration may refer to the others)
+		}
+	}
+
+	// reload creates the configuration from scratch (the configuration file and the templates are read again)
+	reload func(context.Context) (*config.Config, error)
+
+	// runtimeDefaults are the Go runtime memory settings before the configuration was applied (nil until then)
+	runtimeDefaults *struct {
+		gcPercent   int
+		memoryLimit int64
 	}
 }
 
@@ -39,12 +69,44 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit,gocy
 	var (
 		addrFlag                = shared.ListenAddrFlag
 		portFlag                = shared.ListenPortFlag
+		pathPrefixFlag          = shared.PathPrefixFlag
 		addTplFlag              = shared.AddTemplatesFlag
 		disableTplFlag          = shared.DisableTemplateNamesFlag
 		addCodeFlag             = shared.AddHTTPCodesFlag
 		disableL10nFlag         = shared.DisableL10nFlag
 		disableMinificationFlag = shared.DisableMinificationFlag
-		jsonFormatFlag          = cli.StringFlag{
+		keepCommentsFlag        = shared.MinifyKeepCommentsFlag
+		keepCondCommentsFlag    = shared.MinifyKeepConditionalCommentsFlag
+		keepInlineCSSFlag       = shared.MinifyKeepInlineCSSFlag
+		keepInlineJSFlag        = shared.MinifyKeepInlineJSFlag
+		noMinifyTplFlag         = shared.NoMinifyTemplateFlag
+		noCacheTplFlag          = cli.StringSliceFlag{
+			Name: "no-cache-template",
+			Usage: "Do not cache the pages rendered from the specified template (by its name; may be specified multiple " +
+				"times), e.g. when the template embeds per-request nonces",
+			Sources:  env("NO_CACHE_TEMPLATES"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryTemplates,
+		}
+		templatePreloadFlag = cli.StringSliceFlag{
+			Name: "template-preload",
+			Usage: "Send the preload link (the Link header) for the template asset in the 'TEMPLATE=URL' format " +
+				"(e.g. 'ghost=/assets/app.css'; may be specified multiple times), so the browsers fetch the CSS, JS, " +
+				"fonts, or images served separately early",
+			Sources:  env("TEMPLATE_PRELOADS"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryTemplates,
+			Validator: func(preloads []string) error {
+				for _, preload := range preloads {
+					if _, _, err := config.ParseTemplatePreload(preload); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		jsonFormatFlag = cli.StringFlag{
 			Name: "json-format",
 			Usage: "Override the default error page response in JSON format (Go templates are supported; the error " +
 				"page will use this template if the client requests JSON content type)",
@@ -53,6 +115,22 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit,gocy
 			OnlyOnce: true,
 			Config:   trim,
 		}
+		jsonSchemaFlag = cli.StringFlag{
+			Name: "json-schema",
+			Usage: "Version of the default JSON error page response structure (" +
+				strings.Join(config.JSONSchemaVersionStrings(), "/") + "; ignored when the JSON format is " +
+				"overridden by the flag or the configuration file)",
+			Value:    config.JSONSchemaV1.String(),
+			Sources:  env("RESPONSE_JSON_SCHEMA"),
+			Category: shared.CategoryFormats,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseJSONSchemaVersion(s)
+
+				return err
+			},
+		}
 		xmlFormatFlag = cli.StringFlag{
 			Name: "xml-format",
 			Usage: "Override the default error page response in XML format (Go templates are supported; the error " +
@@ -71,6 +149,117 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit,gocy
 			OnlyOnce: true,
 			Config:   trim,
 		}
+		defaultFormatFlag = cli.StringFlag{
+			Name: "default-format",
+			Usage: "The response format used when the client does not specify a supported one (" +
+				strings.Join(config.FormatStrings(), "/") + ")",
+			Value:    cfg.DefaultFormat.String(),
+			Sources:  env("DEFAULT_FORMAT"),
+			Category: shared.CategoryFormats,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseFormat(s)
+
+				return err
+			},
+		}
+		formatOverrideFlag = cli.StringSliceFlag{
+			Name: "format-override",
+			Usage: "Force the response format for the clients with the matching User-Agent header (regular expression) " +
+				"in the 'PATTERN=FORMAT' format (e.g. '^kube-probe/=plaintext'); evaluated before the Accept header",
+			Sources:  env("FORMAT_OVERRIDES"),
+			Category: shared.CategoryFormats,
+			Config:   cli.StringConfig{TrimSpace: true},
+			Validator: func(rules []string) error {
+				for _, rule := range rules {
+					if _, err := config.ParseFormatRule(rule); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		unsupportedFormatFlag = cli.StringFlag{
+			Name: "unsupported-format",
+			Usage: "Override the plain text response used when the requested content format is not supported (Go " +
+				"templates are supported; used when the template of the requested format and the plain text one are empty)",
+			Sources:  env("RESPONSE_UNSUPPORTED_FORMAT"),
+			Category: shared.CategoryFormats,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		plainTextMaxWidthFlag = cli.UintFlag{
+			Name: "plaintext-max-width",
+			Usage: "Truncate the longer lines of the plain text responses (in the terminal columns; the CJK characters " +
+				"and emoji take two) with an ellipsis (0 means no limit)",
+			Value:    cfg.PlainTextOutput.MaxLineWidth,
+			Sources:  env("PLAINTEXT_MAX_WIDTH"),
+			Category: shared.CategoryFormats,
+			OnlyOnce: true,
+		}
+		plainTextNormalizationFlag = cli.StringFlag{
+			Name: "plaintext-normalization",
+			Usage: "Normalize the plain text responses for the terminal clients and SMS gateways (" +
+				strings.Join(config.TextNormalizationStrings(), "/") + "; safe removes the invalid UTF-8, control, " +
+				"and invisible characters, ascii also replaces the non-ASCII ones)",
+			Value:    cfg.PlainTextOutput.Normalization.String(),
+			Sources:  env("PLAINTEXT_NORMALIZATION"),
+			Category: shared.CategoryFormats,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseTextNormalization(s)
+
+				return err
+			},
+		}
+		jsonMaxSizeFlag = cli.UintFlag{
+			Name: "json-max-size",
+			Usage: "Limit the size of the JSON responses in bytes (the string values are truncated, so the body stays " +
+				"valid; 0 means no limit)",
+			Value:     cfg.BodyLimits.JSON,
+			Sources:   env("JSON_MAX_SIZE"),
+			Category:  shared.CategoryFormats,
+			OnlyOnce:  true,
+			Validator: config.ValidateBodyLimit,
+		}
+		xmlMaxSizeFlag = cli.UintFlag{
+			Name: "xml-max-size",
+			Usage: "Limit the size of the XML responses in bytes (the text nodes are truncated, so the body stays " +
+				"valid; 0 means no limit)",
+			Value:     cfg.BodyLimits.XML,
+			Sources:   env("XML_MAX_SIZE"),
+			Category:  shared.CategoryFormats,
+			OnlyOnce:  true,
+			Validator: config.ValidateBodyLimit,
+		}
+		plainTextMaxSizeFlag = cli.UintFlag{
+			Name: "plaintext-max-size",
+			Usage: "Limit the size of the plain text responses in bytes (the text is cut and marked as truncated; 0 " +
+				"means no limit)",
+			Value:     cfg.BodyLimits.PlainText,
+			Sources:   env("PLAINTEXT_MAX_SIZE"),
+			Category:  shared.CategoryFormats,
+			OnlyOnce:  true,
+			Validator: config.ValidateBodyLimit,
+		}
+		charsetFlag = cli.StringFlag{
+			Name: "charset",
+			Usage: "Character set of the HTML and plain text responses for the legacy clients (like GBK or " +
+				"ISO-8859-1); the output is transcoded from UTF-8, the JSON and XML responses are always sent in UTF-8",
+			Value:    cfg.Charset,
+			Sources:  env("RESPONSE_CHARSET"),
+			Category: shared.CategoryFormats,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := charset.Lookup(s)
+
+				return err
+			},
+		}
 		templateNameFlag = cli.StringFlag{
 			Name:    "template-name",
 			Aliases: []string{"t", "template", "theme"},
@@ -97,6 +286,22 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit,gocy
 			},
 			OnlyOnce: true,
 		}
+		unknownCodeLogIntervalFlag = cli.DurationFlag{
+			Name: "unknown-code-log-interval",
+			Usage: "Log the requests with unknown codes or an invalid code header (like X-Code: 0) at most once per " +
+				"this interval (0 disables the logging)",
+			Value:    cfg.UnknownCodeLogInterval,
+			Sources:  env("UNKNOWN_CODE_LOG_INTERVAL"),
+			Category: shared.CategoryCodes,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d < 0 {
+					return fmt.Errorf("wrong unknown code log interval [%s]: it should not be negative", d)
+				}
+
+				return nil
+			},
+		}
 		sendSameHTTPCodeFlag = cli.BoolFlag{
 			Name: "send-same-http-code",
 			Usage: "The HTTP response should have the same status code as the requested error page (by default, " +
@@ -114,197 +319,2063 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit,gocy
 			Category: shared.CategoryOther,
 			OnlyOnce: true,
 		}
-		proxyHeadersListFlag = cli.StringFlag{
-			Name: "proxy-headers",
-			Usage: "HTTP headers listed here will be proxied from the original request to the error page response " +
-				"(comma-separated list)",
-			Value:   strings.Join(cfg.ProxyHeaders, ","),
-			Sources: env("PROXY_HTTP_HEADERS"),
+		detailsNetworksFlag = cli.StringFlag{
+			Name: "details-networks",
+			Usage: "Show the request details only to the clients from these IP addresses or CIDR ranges, like the " +
+				"office VPN (comma-separated list; empty means no restriction by the client IP)",
+			Sources: env("DETAILS_NETWORKS"),
 			Validator: func(s string) error {
-				for _, raw := range strings.Split(s, ",") {
-					if clean := strings.TrimSpace(raw); strings.ContainsRune(clean, ' ') {
-						return fmt.Errorf("whitespaces in the HTTP headers are not allowed: %s", clean)
-					}
-				}
+				_, err := clientip.ParsePrefixes(strings.Split(s, ",")...)
 
-				return nil
+				return err
 			},
 			Category: shared.CategoryOther,
 			OnlyOnce: true,
 			Config:   trim,
 		}
-		rotationModeFlag = cli.StringFlag{
-			Name:     "rotation-mode",
-			Value:    config.RotationModeDisabled.String(),
-			Usage:    "Templates automatic rotation mode (" + strings.Join(config.RotationModeStrings(), "/") + ")",
-			Sources:  env("TEMPLATES_ROTATION_MODE"),
-			Category: shared.CategoryTemplates,
+		detailsTokenFlag = cli.StringFlag{
+			Name: "details-token",
+			Usage: "Show the request details only to the requests with this token in the details token header, or " +
+				"from the details networks (empty means no restriction by the token)",
+			Sources:  env("DETAILS_TOKEN"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		detailsTokenHeaderFlag = cli.StringFlag{
+			Name:     "details-token-header",
+			Usage:    "The request header with the token granting access to the request details",
+			Value:    cfg.DetailsAccess.Header,
+			Sources:  env("DETAILS_TOKEN_HEADER"),
+			Category: shared.CategoryOther,
 			OnlyOnce: true,
 			Config:   trim,
 			Validator: func(s string) error {
-				if _, err := config.ParseRotationMode(s); err != nil {
-					return err
+				if s == "" {
+					return errors.New("missing details token header name")
 				}
 
 				return nil
 			},
 		}
-		readBufferSizeFlag = cli.UintFlag{
-			Name: "read-buffer-size",
-			Usage: "Per-connection buffer size in bytes for reading requests, this also limits the maximum header size " +
-				"(increase this buffer if your clients send multi-KB Request URIs and/or multi-KB headers (e.g., " +
-				"large cookies), note that increasing this value will increase memory consumption)",
-			Value:    1024 * 5, //nolint:mnd // 5 KB
-			Sources:  env("READ_BUFFER_SIZE"),
+		detailsWasmHookFlag = cli.StringFlag{
+			Name: "details-wasm-hook",
+			Usage: "Path to the WebAssembly module deciding whether to show the request details to the requests the " +
+				"details networks and token don't grant access to (it gets the client IP and request headers; see " +
+				"the README for the module exports)",
+			Sources:  env("DETAILS_WASM_HOOK"),
 			Category: shared.CategoryOther,
 			OnlyOnce: true,
+			Config:   trim,
 		}
-	)
-
-	// override some flag usage messages
-	addrFlag.Usage = "The HTTP server will listen on this IP (v4 or v6) address (set 127.0.0.1/::1 for localhost, " +
-		"0.0.0.0 to listen on all interfaces, or specify a custom IP)"
-	portFlag.Usage = "The TCP port number for the HTTP server to listen on (0-65535)"
-
-	disableL10nFlag.Value = cfg.L10n.Disable // set the default value depending on the configuration
-
-	cmd.c = &cli.Command{
-		Name:    "serve",
-		Aliases: []string{"s", "server", "http"},
-		Usage:   "Please start the HTTP server to serve the error pages. You can configure various options - please RTFM :D",
-		Suggest: true,
-		Action: func(ctx context.Context, c *cli.Command) error {
-			cmd.opt.http.addr = c.String(addrFlag.Name)
-			cmd.opt.http.port = uint16(c.Uint(portFlag.Name)) //nolint:gosec
-			cmd.opt.http.readBufferSize = c.Uint(readBufferSizeFlag.Name)
-			cfg.L10n.Disable = c.Bool(disableL10nFlag.Name)
-			cfg.DefaultCodeToRender = uint16(c.Uint(defaultCodeToRenderFlag.Name)) //nolint:gosec
-			cfg.RespondWithSameHTTPCode = c.Bool(sendSameHTTPCodeFlag.Name)
-			cfg.RotationMode, _ = config.ParseRotationMode(c.String(rotationModeFlag.Name))
-			cfg.ShowDetails = c.Bool(showDetailsFlag.Name)
-			cfg.DisableMinification = c.Bool(disableMinificationFlag.Name)
+		showOriginalStatusFlag = cli.BoolFlag{
+			Name: "show-original-status",
+			Usage: "Include the code from the X-Original-Status request header (set by the proxy, which may rewrite " +
+				"the upstream code) into the default JSON and XML payloads",
+			Value:    cfg.ShowOriginalStatus,
+			Sources:  env("SHOW_ORIGINAL_STATUS"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		reducedMotionFlag = cli.StringFlag{
+			Name: "reduced-motion",
+			Usage: "Ask the templates to disable the animations (" +
+				strings.Join(config.MotionPreferenceStrings(), "/") + "; auto honors the Sec-CH-Prefers-Reduced-Motion " +
+				"client hint on the server side, reduce disables them for everyone)",
+			Value:    cfg.Accessibility.ReducedMotion.String(),
+			Sources:  env("REDUCED_MOTION"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseMotionPreference(s)
 
-			{ // override default JSON, XML, and PlainText formats
-				if c.IsSet(jsonFormatFlag.Name) {
-					cfg.Formats.JSON = strings.TrimSpace(c.String(jsonFormatFlag.Name))
-				}
+				return err
+			},
+		}
+		printFriendlyFlag = cli.BoolFlag{
+			Name:     "print-friendly",
+			Usage:    "Ask the templates to include the print-friendly styles (if supported by the template)",
+			Value:    cfg.Accessibility.PrintFriendly,
+			Sources:  env("PRINT_FRIENDLY"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+		}
+		shadowFlag = cli.BoolFlag{
+			Name: "shadow",
+			Usage: "Shadow (dry-run) mode: log what would be rendered (code, format, template, cache hit) and respond " +
+				"with 204 instead of the content, to validate a new configuration behind a traffic mirror",
+			Value:    cfg.Shadow,
+			Sources:  env("SHADOW"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		codePrecedenceFlag = cli.StringFlag{
+			Name: "code-precedence",
+			Usage: "What to do when the URL and the X-Code header codes differ: use the URL or header code, or reject " +
+				"the request (" + strings.Join(config.CodePrecedenceStrings(), "/") + ")",
+			Value:    cfg.RequestHeaders.CodePrecedence.String(),
+			Sources:  env("CODE_PRECEDENCE"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseCodePrecedence(s)
 
-				if c.IsSet(xmlFormatFlag.Name) {
-					cfg.Formats.XML = strings.TrimSpace(c.String(xmlFormatFlag.Name))
-				}
+				return err
+			},
+		}
+		rejectDuplicateHeadersFlag = cli.BoolFlag{
+			Name: "reject-duplicate-headers",
+			Usage: "Reject the requests with repeated code, format, or error kind headers having different values " +
+				"(otherwise, the first value is used)",
+			Value:    cfg.RequestHeaders.RejectDuplicates,
+			Sources:  env("REJECT_DUPLICATE_HEADERS"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		maxHeaderValueSizeFlag = cli.UintFlag{
+			Name: "max-header-value-size",
+			Usage: "Reject the requests with longer (in bytes) code, format, or error kind header values (0 means " +
+				"no limit)",
+			Value:    cfg.RequestHeaders.MaxValueSize,
+			Sources:  env("MAX_HEADER_VALUE_SIZE"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		strictCodesFlag = cli.BoolFlag{
+			Name: "strict-codes",
+			Usage: "Accept only the canonical codes in the URL (like /404 or /404.html) and X-Code header (three " +
+				"digits, 100..599), instead of normalizing the encoded paths, path parameters, and padded values",
+			Value:    cfg.RequestHeaders.StrictCodes,
+			Sources:  env("STRICT_CODES"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		tlsErrorHeaderFlag = cli.StringFlag{
+			Name: "tls-error-header",
+			Usage: "The request header the terminating proxy reports the TLS errors in (like an expired client " +
+				"certificate or an unsupported protocol), to render the dedicated error pages (empty to disable)",
+			Value:    cfg.TLSErrors.Header,
+			Sources:  env("TLS_ERROR_HEADER"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		cspFlag = cli.StringFlag{
+			Name: "content-security-policy",
+			Usage: "Content-Security-Policy header value for the HTML pages; the " + config.CSPNoncePlaceholder +
+				" placeholders are replaced with the per-response nonce (available as the csp_nonce token)",
+			Value:     cfg.ContentSecurityPolicy,
+			Sources:   env("CONTENT_SECURITY_POLICY"),
+			Category:  shared.CategoryHTTP,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateContentSecurityPolicy,
+		}
+		earlyHintsFlag = cli.BoolFlag{
+			Name: "early-hints",
+			Usage: "Send the 103 Early Hints response with the template preload links before rendering the HTML page " +
+				"(some older HTTP/1.1 clients may not support it)",
+			Value:    cfg.EarlyHints,
+			Sources:  env("EARLY_HINTS"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		esiFlag = cli.BoolFlag{
+			Name: "esi",
+			Usage: "Enable the Edge Side Includes: the esiInclude template function emits the ESI include tags, and " +
+				"the HTML responses are marked for the ESI processors using the Surrogate-Control header",
+			Value:    cfg.ESI,
+			Sources:  env("ESI"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		unavailableUntilReadyFlag = cli.BoolFlag{
+			Name:     "unavailable-until-ready",
+			Usage:    "Respond with the 503 error page to every request until the service is ready (e.g. warmed up)",
+			Value:    cfg.UnavailableUntilReady,
+			Sources:  env("UNAVAILABLE_UNTIL_READY"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		signingAlgorithmFlag = cli.StringFlag{
+			Name: "signing-algorithm",
+			Usage: "Sign the rendered response bodies (the " + ep.SignatureHeader + " header) using this algorithm (" +
+				strings.Join(config.SigningAlgorithmStrings(), "/") + ")",
+			Value:    cfg.Signing.Algorithm.String(),
+			Sources:  env("SIGNING_ALGORITHM"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseSigningAlgorithm(s)
 
-				if c.IsSet(plainTextFormatFlag.Name) {
-					cfg.Formats.PlainText = strings.TrimSpace(c.String(plainTextFormatFlag.Name))
-				}
-			}
+				return err
+			},
+		}
+		signingKeyFlag = cli.StringFlag{
+			Name: "signing-key",
+			Usage: "Signing key: the shared secret for hmac-sha256, or the base64-encoded seed (32 bytes) or private " +
+				"key (64 bytes) for ed25519",
+			Sources:  env("SIGNING_KEY"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		enableAPIFlag = cli.BoolFlag{
+			Name: "enable-api",
+			Usage: "Enable the management API endpoints (/api/rotation, /api/banner); without the token, the changes are " +
+				"accepted from the loopback addresses only",
+			Value:    cfg.EnableAPI,
+			Sources:  env("ENABLE_API"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		disableCompressionFlag = cli.BoolFlag{
+			Name:     "disable-compression",
+			Usage:    "Disable the gzip and Brotli compression of the responses (e.g. if the reverse proxy compresses them)",
+			Value:    cfg.DisableCompression,
+			Sources:  env("DISABLE_COMPRESSION"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		enableMetricsFlag = cli.BoolFlag{
+			Name: "enable-metrics",
+			Usage: "Enable the Prometheus metrics endpoint (/metrics) with the served pages by code and format, the " +
+				"cache hits and misses, and the render latency",
+			Value:    cfg.EnableMetrics,
+			Sources:  env("ENABLE_METRICS"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		apiTokenFlag = cli.StringFlag{
+			Name: "api-token",
+			Usage: "The bearer token required by the management API endpoints (the Authorization header); also enables " +
+				"the debugging endpoints (/api/render-props, /api/render)",
+			Sources:  env("API_TOKEN"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		disableAutoEscapeFlag = cli.BoolFlag{
+			Name: "disable-auto-escape",
+			Usage: "Disable the context-aware escaping of the values in the HTML, JSON, and XML responses (the values " +
+				"are written as-is, like in the previous versions; unsafe if the request details are shown)",
+			Value:    cfg.DisableAutoEscape,
+			Sources:  env("DISABLE_AUTO_ESCAPE"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+		}
+		bodyPreviewSizeFlag = cli.UintFlag{
+			Name: "body-preview-size",
+			Usage: "Expose the first N bytes of the request body (sanitized) as the body_preview token, for the " +
+				"internal error backends debugging only (0 means disabled)",
+			Value:    cfg.BodyPreviewSize,
+			Sources:  env("BODY_PREVIEW_SIZE"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		requestIDFormatFlag = cli.StringFlag{
+			Name: "request-id-format",
+			Usage: "Format of the generated request IDs (" + strings.Join(config.RequestIDFormatStrings(), "/") + "; " +
+				"ulid and sonyflake are sortable by time, the sonyflake machine ID is derived from the datacenter code)",
+			Value:    cfg.RequestIDFormat.String(),
+			Sources:  env("REQUEST_ID_FORMAT"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseRequestIDFormat(s)
 
-			// add templates from files to the configuration
-			if add := c.StringSlice(addTplFlag.Name); len(add) > 0 {
-				for _, templatePath := range add {
-					if addedName, err := cfg.Templates.AddFromFile(templatePath); err != nil {
-						return fmt.Errorf("cannot add template from file %s: %w", templatePath, err)
-					} else {
-						log.Info("Template added",
-							logger.String("name", addedName),
-							logger.String("path", templatePath),
-						)
+				return err
+			},
+		}
+		requestIDHeadersFlag = cli.StringFlag{
+			Name:     "request-id-headers",
+			Usage:    "Request headers with the upstream request ID, checked in order (comma-separated list)",
+			Value:    strings.Join(cfg.RequestID.Headers, ","),
+			Sources:  env("REQUEST_ID_HEADERS"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(s string) error {
+				for _, raw := range strings.Split(s, ",") {
+					if clean := strings.TrimSpace(raw); strings.ContainsAny(clean, " :") {
+						return fmt.Errorf("wrong request ID header name: %s", clean)
 					}
 				}
-			}
-
-			// set the list of HTTP headers we need to proxy from the incoming request to the error page response
-			if c.IsSet(proxyHeadersListFlag.Name) {
-				var m = make(map[string]struct{}) // map is used to avoid duplicates
 
-				for _, header := range strings.Split(c.String(proxyHeadersListFlag.Name), ",") {
-					m[http.CanonicalHeaderKey(strings.TrimSpace(header))] = struct{}{}
+				return nil
+			},
+		}
+		requestIDPrefixFlag = cli.StringFlag{
+			Name:      "request-id-prefix",
+			Usage:     "Prefix of the request IDs (defaults to the datacenter code)",
+			Sources:   env("REQUEST_ID_PREFIX"),
+			Category:  shared.CategoryOther,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateRequestIDPrefix,
+		}
+		requestIDLayoutFlag = cli.StringFlag{
+			Name: "request-id-layout",
+			Usage: "Layout of the generated request IDs, overrides the format (the placeholders are prefix, rand:N " +
+				"with N random bytes, uuidv7, uuidv4, ulid, sonyflake, and unixms, wrapped in curly braces)",
+			Sources:  env("REQUEST_ID_LAYOUT"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				if s == "" {
+					return nil // the format layout
 				}
 
-				cfg.ProxyHeaders = make([]string, 0, len(m)) // clear the list before adding new headers
+				_, err := config.ParseRequestIDLayout(s)
 
-				for header := range m {
-					cfg.ProxyHeaders = append(cfg.ProxyHeaders, header)
-				}
-			}
+				return err
+			},
+		}
+		requestIDCFRayFlag = cli.BoolFlag{
+			Name:     "request-id-cf-ray",
+			Usage:    "Use the Cloudflare CF-Ray request header as-is as the request ID (takes precedence over the headers)",
+			Value:    cfg.RequestID.CFRay,
+			Sources:  env("REQUEST_ID_CF_RAY"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		datacenterFlag = cli.StringFlag{
+			Name:     "datacenter",
+			Usage:    "Datacenter code, used in the generated request IDs and the datacenter token",
+			Sources:  env("DATACENTER", "DATA_CENTRE_CODE"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := datacenter.Validate(s)
 
-			// add custom HTTP codes to the configuration
-			if add := c.StringMap(addCodeFlag.Name); len(add) > 0 {
-				for code, desc := range shared.ParseHTTPCodes(add) {
-					cfg.Codes[code] = desc
+				return err
+			},
+		}
+		datacenterFileFlag = cli.StringFlag{
+			Name:     "datacenter-file",
+			Usage:    "Path to the file with the datacenter code (used if the code is not set explicitly)",
+			Sources:  env("DATACENTER_FILE"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		datacenterMetadataFlag = cli.StringFlag{
+			Name: "datacenter-metadata",
+			Usage: "Cloud metadata service (" + strings.Join(datacenter.MetadataServices(), "/") + ") to take the " +
+				"availability zone as the datacenter code from (used if the code and file are not set)",
+			Sources:  env("DATACENTER_METADATA"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				if !slices.Contains(datacenter.MetadataServices(), strings.ToLower(s)) {
+					return fmt.Errorf("unsupported metadata service [%s]", s)
+				}
 
-					log.Info("HTTP code added",
-						logger.String("code", code),
-						logger.String("message", desc.Message),
-						logger.String("description", desc.Description),
-					)
+				return nil
+			},
+		}
+		catchAllFlag = cli.BoolFlag{
+			Name: "catch-all",
+			Usage: "Enable the \"default backend\" mode: any request without a code in the URL or headers renders the " +
+				"404 error page (instead of the default one), and the Retry-After header is never sent",
+			Value:    cfg.CatchAll.Enabled,
+			Sources:  env("CATCH_ALL"),
+			Category: shared.CategoryCodes,
+			OnlyOnce: true,
+		}
+		catchAllLogRateFlag = cli.FloatFlag{
+			Name:     "catch-all-log-rate",
+			Usage:    "A fraction (0..1) of the unmatched request paths to log in the catch-all mode (0 disables logging)",
+			Value:    cfg.CatchAll.LogSampleRate,
+			Sources:  env("CATCH_ALL_LOG_RATE"),
+			Category: shared.CategoryCodes,
+			OnlyOnce: true,
+			Validator: func(rate float64) error {
+				if rate < 0 || rate > 1 {
+					return fmt.Errorf("wrong log sample rate [%v]: it should be in the range 0..1", rate)
 				}
-			}
 
-			// disable templates specified by the user
-			if disable := c.StringSlice(disableTplFlag.Name); len(disable) > 0 {
-				for _, templateName := range disable {
-					if ok := cfg.Templates.Remove(templateName); ok {
-						log.Info("Template disabled", logger.String("name", templateName))
+				return nil
+			},
+		}
+		proxyHeadersListFlag = cli.StringFlag{
+			Name: "proxy-headers",
+			Usage: "HTTP headers listed here will be proxied from the original request to the error page response " +
+				"(comma-separated list)",
+			Value:   strings.Join(cfg.ProxyHeaders, ","),
+			Sources: env("PROXY_HTTP_HEADERS"),
+			Validator: func(s string) error {
+				for _, raw := range strings.Split(s, ",") {
+					if clean := strings.TrimSpace(raw); strings.ContainsRune(clean, ' ') {
+						return fmt.Errorf("whitespaces in the HTTP headers are not allowed: %s", clean)
 					}
 				}
-			}
-
-			// check if there are any templates available to render error pages
-			if len(cfg.Templates.Names()) == 0 {
-				return errors.New("no templates available to render error pages")
-			}
 
-			// if the rotation mode is set to random-on-startup, pick a random template (ignore the user-provided
-			// template name)
-			if cfg.RotationMode == config.RotationModeRandomOnStartup {
-				cfg.TemplateName = cfg.Templates.RandomName()
-			} else { // otherwise, use the user-provided template name
-				cfg.TemplateName = c.String(templateNameFlag.Name)
-
-				if !cfg.Templates.Has(cfg.TemplateName) {
-					return fmt.Errorf(
-						"template '%s' not found and cannot be used (available templates: %s)",
-						cfg.TemplateName,
-						cfg.Templates.Names(),
-					)
-				}
+				return nil
+			},
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		templateHeadersFlag = cli.StringFlag{
+			Name: "template-headers",
+			Usage: "Request headers available to the templates using the header function, like X-Tenant " +
+				"(comma-separated list; the pages are cached per the values of these headers)",
+			Value:    strings.Join(cfg.TemplateHeaders, ","),
+			Sources:  env("TEMPLATE_HEADERS"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Validator: func(s string) error {
+				for _, raw := range strings.Split(s, ",") {
+					if clean := strings.TrimSpace(raw); strings.ContainsAny(clean, " :") {
+						return fmt.Errorf("wrong template header name: %s", clean)
+					}
+				}
+
+				return nil
+			},
+		}
+		allowedHostsFlag = cli.StringFlag{
+			Name: "allowed-hosts",
+			Usage: "Only requests with the Host header listed here will be served, others will receive a minimal " +
+				"response without the error page (comma-separated list; the port is ignored, and a leading wildcard " +
+				"like '*.example.com' matches any subdomain; empty means any host is allowed)",
+			Value:   strings.Join(cfg.AllowedHosts, ","),
+			Sources: env("ALLOWED_HOSTS"),
+			Validator: func(s string) error {
+				for _, raw := range strings.Split(s, ",") {
+					if clean := strings.TrimSpace(raw); strings.ContainsAny(clean, " /") {
+						return fmt.Errorf("wrong allowed host: %s", clean)
+					}
+				}
+
+				return nil
+			},
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		allowMethodsFlag = cli.StringSliceFlag{
+			Name: "allow-methods",
+			Usage: "Map the request path pattern (regular expression) to the Allow header value of the 405 responses " +
+				"in the 'PATTERN=METHOD[ METHOD...]' format (e.g. '^/api/=GET HEAD POST'); the path is taken from the " +
+				"X-Original-URI header if present, and the Allow request header (set by the upstream) takes precedence",
+			Sources:  env("ALLOW_METHODS"),
+			Category: shared.CategoryCodes,
+			Config:   cli.StringConfig{TrimSpace: true},
+			Validator: func(rules []string) error {
+				for _, rule := range rules {
+					if _, err := config.ParseAllowRule(rule); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		authChallengeFlag = cli.StringSliceFlag{
+			Name: "auth-challenge",
+			Usage: "WWW-Authenticate challenge to send with the 401 responses (e.g. 'Basic realm=\"example\"' or " +
+				"'Bearer'; may be specified multiple times; use the configuration file for the challenges with commas)",
+			Sources:  env("AUTH_CHALLENGES"),
+			Category: shared.CategoryCodes,
+			Config:   cli.StringConfig{TrimSpace: true},
+			Validator: func(challenges []string) error {
+				for _, challenge := range challenges {
+					if err := config.ValidateAuthChallenge(challenge); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		trustedProxiesFlag = cli.StringFlag{
+			Name: "trusted-proxies",
+			Usage: "The X-Forwarded-For header will be used to extract the client IP address only for requests " +
+				"coming from these IP addresses or CIDR ranges (comma-separated list; empty means the header is ignored)",
+			Sources: env("TRUSTED_PROXIES"),
+			Validator: func(s string) error {
+				_, err := clientip.ParsePrefixes(strings.Split(s, ",")...)
+
+				return err
+			},
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		maxProxyHopsFlag = cli.UintFlag{
+			Name: "max-proxy-hops",
+			Usage: "The maximum number of the X-Forwarded-For header entries to walk (from right to left) while " +
+				"extracting the client IP address (0 means no limit; when only the trusted proxies are found within the " +
+				"limit, the remote address is used)",
+			Value:    cfg.ClientIP.MaxHops,
+			Sources:  env("MAX_PROXY_HOPS"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		proxyModeFlag = cli.StringFlag{
+			Name: "proxy-mode",
+			Usage: "Reverse proxy integration mode, which determines the request headers with the original request " +
+				"details (" + strings.Join(config.ProxyModeStrings(), "/") + "; traefik reads the X-Forwarded-Uri " +
+				"header instead of X-Original-URI)",
+			Value:    cfg.Proxy.Mode.String(),
+			Sources:  env("PROXY_MODE"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+			Validator: func(s string) error {
+				_, err := config.ParseProxyMode(s)
+
+				return err
+			},
+			Config: trim,
+		}
+		originalURIHeaderFlag = cli.StringFlag{
+			Name:      "original-uri-header",
+			Usage:     "Request header with the URI of the original request (overrides the proxy mode default)",
+			Sources:   env("ORIGINAL_URI_HEADER"),
+			Category:  shared.CategoryHTTP,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateProxyHeader,
+		}
+		forwardedForHeaderFlag = cli.StringFlag{
+			Name: "forwarded-for-header",
+			Usage: "Request header with the client addresses chain for the forwarded_for token (overrides the proxy " +
+				"mode default; the client IP address is always extracted from X-Forwarded-For)",
+			Sources:   env("FORWARDED_FOR_HEADER"),
+			Category:  shared.CategoryHTTP,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateProxyHeader,
+		}
+		configFlag = cli.StringFlag{
+			Name:    "config",
+			Aliases: []string{"c"},
+			Usage: "Path to the configuration file (YAML or JSON), '-' to read it from stdin, or an http(s):// URL to " +
+				"fetch it from (flags and environment variables override the values from the configuration file)",
+			Sources:  env("CONFIG"),
+			Category: shared.CategoryConfig,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		configSHA256Flag = cli.StringFlag{
+			Name:    "config-sha256",
+			Usage:   "Expected SHA-256 checksum (hex encoded) of the configuration content (verified before applying)",
+			Sources: env("CONFIG_SHA256"),
+			Validator: func(s string) error {
+				if _, err := config.ParseSHA256(s); err != nil {
+					return fmt.Errorf("%w: %s", err, s)
+				}
+
+				return nil
+			},
+			Category: shared.CategoryConfig,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		configInsecureFlag = cli.BoolFlag{
+			Name:     "config-insecure",
+			Usage:    "Skip the TLS certificate verification when fetching the configuration from an https:// URL",
+			Sources:  env("CONFIG_INSECURE"),
+			Category: shared.CategoryConfig,
+			OnlyOnce: true,
+		}
+		watchIntervalFlag = cli.DurationFlag{
+			Name: "watch-interval",
+			Usage: "Check the local configuration file and the template files for changes with this interval, and " +
+				"reload them without a restart (0 disables the watching, the SIGHUP signal reloads them anyway)",
+			Sources:  env("WATCH_INTERVAL"),
+			Category: shared.CategoryConfig,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d < 0 {
+					return fmt.Errorf("wrong watch interval [%s]: it should not be negative", d)
+				}
+
+				return nil
+			},
+		}
+		staticDirFlag = cli.StringFlag{
+			Name: "static-dir",
+			Usage: "Serve the pre-built error pages (the output of the 'build' command, like '404.html') from this " +
+				"directory as-is, without templating at runtime (the format is selected by the file extension in the " +
+				"URL); the pages of the '--template-name' template subdirectory (like '{dir}/ghost/404.html') are " +
+				"served if it exists, otherwise the pages from the directory itself",
+			Sources:  env("STATIC_DIR"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(dir string) error {
+				if stat, err := os.Stat(dir); err != nil {
+					return fmt.Errorf("cannot access the static directory '%s': %w", dir, err)
+				} else if !stat.IsDir() {
+					return fmt.Errorf("'%s' is not a directory", dir)
+				}
+
+				return nil
+			},
+		}
+		routeFlag = cli.StringSliceFlag{
+			Name: "route",
+			Usage: "Map the request path pattern (regular expression) to the HTTP code and/or template in the " +
+				"'PATTERN=CODE[:TEMPLATE]' format (e.g. '^/old-api/=410' or '^/internal/=403:ghost'); the routes are " +
+				"evaluated in order before the code extraction from the URL, and the first match wins",
+			Sources:  env("ROUTES"),
+			Category: shared.CategoryCodes,
+			Config:   cli.StringConfig{TrimSpace: true},
+			Validator: func(routes []string) error {
+				for _, route := range routes {
+					if _, err := config.ParseRoute(route); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		rotationModeFlag = cli.StringFlag{
+			Name:     "rotation-mode",
+			Value:    config.RotationModeDisabled.String(),
+			Usage:    "Templates automatic rotation mode (" + strings.Join(config.RotationModeStrings(), "/") + ")",
+			Sources:  env("TEMPLATES_ROTATION_MODE"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				if _, err := config.ParseRotationMode(s); err != nil {
+					return err
+				}
+
+				return nil
+			},
+		}
+		maxRendersFlag = cli.UintFlag{
+			Name: "max-concurrent-renders",
+			Usage: "Limit the number of templates rendered at the same time (excess requests receive the cached or a " +
+				"minimal error page without templating; 0 means no limit)",
+			Value:    cfg.MaxConcurrentRenders,
+			Sources:  env("MAX_CONCURRENT_RENDERS"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		bannerFlag = cli.StringFlag{
+			Name:     "banner",
+			Usage:    "Outage banner message shown on the error pages (can be changed at runtime using the API)",
+			Sources:  env("BANNER"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		bannerSeverityFlag = cli.StringFlag{
+			Name:     "banner-severity",
+			Usage:    "Outage banner severity (" + strings.Join(config.BannerSeverityStrings(), "/") + ")",
+			Value:    cfg.Banner.Severity.String(),
+			Sources:  env("BANNER_SEVERITY"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseBannerSeverity(s)
+
+				return err
+			},
+		}
+		watermarkFlag = cli.StringFlag{
+			Name: "watermark",
+			Usage: "Watermark the HTML pages with the instance ID, the time, and the request ID (" +
+				strings.Join(config.WatermarkModeStrings(), "/") + ")",
+			Value:    cfg.Watermark.Mode.String(),
+			Sources:  env("WATERMARK"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				_, err := config.ParseWatermarkMode(s)
+
+				return err
+			},
+		}
+		instanceIDFlag = cli.StringFlag{
+			Name:     "instance-id",
+			Usage:    "Serving instance identifier used in the watermark (the host name is used if empty)",
+			Sources:  env("INSTANCE_ID"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		upstreamHealthURLFlag = cli.StringFlag{
+			Name: "upstream-health-url",
+			Usage: "Upstream health endpoint to poll in the background (any 2xx or 3xx response means healthy); the " +
+				"result is exposed to the templates as the upstream_healthy and upstream_checked_at tokens",
+			Sources:   env("UPSTREAM_HEALTH_URL"),
+			Category:  shared.CategoryOther,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: upstream.ValidateURL,
+		}
+		upstreamHealthIntervalFlag = cli.DurationFlag{
+			Name:     "upstream-health-interval",
+			Usage:    "Time between the upstream health checks",
+			Value:    cfg.UpstreamHealth.Interval,
+			Sources:  env("UPSTREAM_HEALTH_INTERVAL"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d <= 0 {
+					return fmt.Errorf("wrong upstream health interval [%s]: it should be positive", d)
+				}
+
+				return nil
+			},
+		}
+		upstreamHealthTimeoutFlag = cli.DurationFlag{
+			Name:     "upstream-health-timeout",
+			Usage:    "Timeout of a single upstream health check (capped by the interval)",
+			Value:    cfg.UpstreamHealth.Timeout,
+			Sources:  env("UPSTREAM_HEALTH_TIMEOUT"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d <= 0 {
+					return fmt.Errorf("wrong upstream health timeout [%s]: it should be positive", d)
+				}
+
+				return nil
+			},
+		}
+		upstreamRecoveryURLFlag = cli.StringFlag{
+			Name: "upstream-recovery-url",
+			Usage: "URL of the /check endpoint as seen by the browsers (e.g. /check); when set, the HTML 503 pages " +
+				"poll it and reload once the upstream is healthy again (requires the upstream health URL)",
+			Sources:   env("UPSTREAM_RECOVERY_URL"),
+			Category:  shared.CategoryOther,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: upstream.ValidateRecoveryURL,
+		}
+		liveStatusURLFlag = cli.StringFlag{
+			Name: "live-status-url",
+			Usage: "URL of the /status.json endpoint as seen by the browsers (e.g. /status.json); when set, the HTML " +
+				"pages poll it and update the outage banner without reloading",
+			Sources:   env("LIVE_STATUS_URL"),
+			Category:  shared.CategoryOther,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateLiveStatusURL,
+		}
+		ogImageBaseURLFlag = cli.StringFlag{
+			Name: "og-image-base-url",
+			Usage: "Public URL of this server as seen by the browsers (e.g. https://errors.example.com); when set, the " +
+				"og_image template token holds the absolute URL of the social preview image, served by this server",
+			Sources:   env("OG_IMAGE_BASE_URL"),
+			Category:  shared.CategoryTemplates,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateOGImageBaseURL,
+		}
+		liveStatusIntervalFlag = cli.DurationFlag{
+			Name:     "live-status-interval",
+			Usage:    "Time between the live status polls of the HTML pages (backing off up to 16 intervals on failures)",
+			Value:    cfg.LiveStatus.Interval,
+			Sources:  env("LIVE_STATUS_INTERVAL"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d <= 0 {
+					return fmt.Errorf("wrong live status interval [%s]: it should be positive", d)
+				}
+
+				return nil
+			},
+		}
+		featureFlagFlag = cli.StringMapFlag{
+			Name: "feature-flag",
+			Usage: "Set the feature flag, available to the templates using the flag and flagOn functions, in the " +
+				"'NAME=VALUE' format (e.g. 'new_503_copy=true'; a share like '25%' rolls the flag out to the part of " +
+				"the clients; the 'template' flag switches the HTML template; may be specified multiple times)",
+			Sources:  env("FEATURE_FLAGS"),
+			Category: shared.CategoryTemplates,
+			Config:   cli.StringConfig{TrimSpace: true},
+			Validator: func(flags map[string]string) error {
+				for name, value := range flags {
+					if _, err := featureflag.Normalize(name, value); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		featureFlagsFileFlag = cli.StringFlag{
+			Name: "feature-flags-file",
+			Usage: "Path to the YAML (or JSON) file with the feature flags map, reread periodically (the values " +
+				"override the ones set using the flags)",
+			Sources:  env("FEATURE_FLAGS_FILE"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		featureFlagsRedisURLFlag = cli.StringFlag{
+			Name: "feature-flags-redis-url",
+			Usage: "URL of the Redis server with the feature flags hash (e.g. redis://:password@redis:6379/0), read " +
+				"periodically (the values override the ones from the file)",
+			Sources:   env("FEATURE_FLAGS_REDIS_URL"),
+			Category:  shared.CategoryTemplates,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: featureflag.ValidateRedisURL,
+		}
+		featureFlagsRedisKeyFlag = cli.StringFlag{
+			Name:     "feature-flags-redis-key",
+			Usage:    "Key of the Redis hash with the feature flags",
+			Value:    cfg.FeatureFlags.RedisKey,
+			Sources:  env("FEATURE_FLAGS_REDIS_KEY"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		featureFlagsIntervalFlag = cli.DurationFlag{
+			Name:     "feature-flags-interval",
+			Usage:    "Time between the reloads of the feature flags from the file and Redis",
+			Value:    cfg.FeatureFlags.Interval,
+			Sources:  env("FEATURE_FLAGS_INTERVAL"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d <= 0 {
+					return fmt.Errorf("wrong feature flags interval [%s]: it should be positive", d)
+				}
+
+				return nil
+			},
+		}
+		publishBucketFlag = cli.StringFlag{
+			Name: "publish-bucket",
+			Usage: "Publish the rendered HTML pages of the active template to this Amazon S3 (or S3-compatible) " +
+				"bucket on startup and whenever they change (the credentials are read from the AWS_ACCESS_KEY_ID, " +
+				"AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN environment variables)",
+			Value:    cfg.Publish.Bucket,
+			Sources:  env("PUBLISH_BUCKET"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		publishRegionFlag = cli.StringFlag{
+			Name:     "publish-region",
+			Usage:    "Region of the publish bucket (empty means " + s3.DefaultRegion + ")",
+			Value:    cfg.Publish.Region,
+			Sources:  env("PUBLISH_REGION"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		publishEndpointFlag = cli.StringFlag{
+			Name:      "publish-endpoint",
+			Usage:     "Custom S3-compatible endpoint URL of the publish bucket (e.g. 'http://127.0.0.1:9000' for MinIO)",
+			Value:     cfg.Publish.Endpoint,
+			Sources:   env("PUBLISH_ENDPOINT"),
+			Category:  shared.CategoryOther,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: s3.ValidateEndpoint,
+		}
+		publishPrefixFlag = cli.StringFlag{
+			Name:     "publish-prefix",
+			Usage:    "Bucket key prefix of the published pages (e.g. 'errors')",
+			Value:    cfg.Publish.Prefix,
+			Sources:  env("PUBLISH_PREFIX"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Config:   trim,
+		}
+		publishIntervalFlag = cli.DurationFlag{
+			Name: "publish-interval",
+			Usage: "Time between the checks whether the active template has changed (e.g. by the rotation); the " +
+				"pages are published on startup, after every reload, and when it changes",
+			Value:    cfg.Publish.Interval,
+			Sources:  env("PUBLISH_INTERVAL"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d <= 0 {
+					return fmt.Errorf("wrong publish interval [%s]: it should be positive", d)
+				}
+
+				return nil
+			},
+		}
+		viaPseudonymFlag = cli.StringFlag{
+			Name: "via-pseudonym",
+			Usage: "Name of this service in the Via header chains; the requests that have already passed through it " +
+				"are rejected as the proxy loops (empty disables the check)",
+			Value:     cfg.LoopGuard.Pseudonym,
+			Sources:   env("VIA_PSEUDONYM"),
+			Category:  shared.CategoryHTTP,
+			OnlyOnce:  true,
+			Config:    trim,
+			Validator: config.ValidateViaPseudonym,
+		}
+		loopMaxRateFlag = cli.UintFlag{
+			Name: "loop-max-rate",
+			Usage: "Maximum number of requests per second from the same client for the same code, the rest are " +
+				"rejected as the suspected proxy loops (0 means no limit)",
+			Value:    cfg.LoopGuard.MaxRate,
+			Sources:  env("LOOP_MAX_RATE"),
+			Category: shared.CategoryHTTP,
+			OnlyOnce: true,
+		}
+		cacheTenantQuotaFlag = cli.UintFlag{
+			Name: "cache-tenant-quota",
+			Usage: "Limit the number of the rendered pages cached per tenant (every allowed host is a separate " +
+				"tenant; the oldest pages of the same tenant are evicted first; 0 means no limit)",
+			Value:    cfg.CacheTenantQuota,
+			Sources:  env("CACHE_TENANT_QUOTA"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		gcPercentFlag = cli.IntFlag{
+			Name: "gc-percent",
+			Usage: "Garbage collection target percentage, like the GOGC environment variable (a negative value " +
+				"disables the GC until the memory limit is reached; 0 keeps the runtime default)",
+			Value:    cfg.Memory.GCPercent,
+			Sources:  env("GC_PERCENT"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		memoryLimitFlag = cli.UintFlag{
+			Name: "memory-limit",
+			Usage: "Soft memory limit of the runtime in MiB, like the GOMEMLIMIT environment variable (set it a bit " +
+				"below the container limit; 0 keeps the runtime default)",
+			Value:    cfg.Memory.Limit,
+			Sources:  env("MEMORY_LIMIT"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		cacheShrinkAtFlag = cli.UintFlag{
+			Name: "cache-shrink-at",
+			Usage: "Purge the rendered pages cache when the heap size exceeds this threshold in MiB, so the memory " +
+				"is freed during the traffic spikes (0 disables the check)",
+			Value:    cfg.Memory.CacheShrinkAt,
+			Sources:  env("CACHE_SHRINK_AT"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+		streamThresholdFlag = cli.UintFlag{
+			Name: "stream-threshold",
+			Usage: "Stream the pages rendered from the HTML templates larger than this size (in bytes) to the client " +
+				"in chunks, without minification and caching (0 disables the streaming)",
+			Value:    cfg.StreamThreshold,
+			Sources:  env("STREAM_THRESHOLD"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+		}
+		timezoneFlag = cli.StringFlag{
+			Name:     "timezone",
+			Usage:    "Default timezone (IANA name, e.g. Europe/Berlin) for the date and time template functions",
+			Value:    cfg.Timezone,
+			Sources:  env("TIMEZONE"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				if _, err := time.LoadLocation(s); err != nil {
+					return fmt.Errorf("wrong timezone [%s]: %w", s, err)
+				}
+
+				return nil
+			},
+		}
+		renderTimeoutFlag = cli.DurationFlag{
+			Name:     "render-timeout",
+			Usage:    "Abort the template render that takes longer than this duration (0 means no limit)",
+			Value:    cfg.TemplateLimits.RenderTimeout,
+			Sources:  env("RENDER_TIMEOUT"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d < 0 {
+					return fmt.Errorf("wrong render timeout [%s]: it should not be negative", d)
+				}
+
+				return nil
+			},
+		}
+		templateMaxDepthFlag = cli.UintFlag{
+			Name: "template-max-depth",
+			Usage: "Reject templates with deeper nested (or recursive) {{ template }} calls than this value (0 means " +
+				"no limit)",
+			Value:    cfg.TemplateLimits.MaxDepth,
+			Sources:  env("TEMPLATE_MAX_DEPTH"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+		}
+		templateMaxIncludesFlag = cli.UintFlag{
+			Name:     "template-max-includes",
+			Usage:    "Reject templates with more {{ template }} calls than this value (0 means no limit)",
+			Value:    cfg.TemplateLimits.MaxIncludes,
+			Sources:  env("TEMPLATE_MAX_INCLUDES"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+		}
+		templateMaxIterationsFlag = cli.UintFlag{
+			Name: "template-max-iterations",
+			Usage: "Abort the template render when its {{ range }} loops iterate more times in total than this value " +
+				"(0 means no limit)",
+			Value:    cfg.TemplateLimits.MaxIterations,
+			Sources:  env("TEMPLATE_MAX_ITERATIONS"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+		}
+		experimentTemplatesFlag = cli.StringFlag{
+			Name: "experiment-templates",
+			Usage: "Two template names (comma-separated) to split the traffic between in the 'experiment' rotation " +
+				"mode; the picked template is reported in the X-Error-Page-Variant header and kept using a cookie",
+			Sources:  env("EXPERIMENT_TEMPLATES"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Config:   trim,
+			Validator: func(s string) error {
+				if names := strings.Split(s, ","); len(names) != 2 || //nolint:mnd
+					strings.TrimSpace(names[0]) == "" || strings.TrimSpace(names[1]) == "" {
+					return fmt.Errorf("exactly two experiment templates are expected: %s", s)
+				}
+
+				return nil
+			},
+		}
+		experimentSplitFlag = cli.UintFlag{
+			Name:     "experiment-split",
+			Usage:    "A share of the traffic (in percent) that receives the second template in the 'experiment' rotation mode",
+			Value:    uint(cfg.Experiment.Split),
+			Sources:  env("EXPERIMENT_SPLIT"),
+			Category: shared.CategoryTemplates,
+			OnlyOnce: true,
+			Validator: func(split uint) error {
+				if split > 100 { //nolint:mnd
+					return fmt.Errorf("wrong experiment split [%d]: it should be in the range 0..100", split)
+				}
+
+				return nil
+			},
+		}
+		readBufferSizeFlag = cli.UintFlag{
+			Name: "read-buffer-size",
+			Usage: "Per-connection buffer size in bytes for reading requests, this also limits the maximum header size " +
+				"(increase this buffer if your clients send multi-KB Request URIs and/or multi-KB headers (e.g., " +
+				"large cookies), note that increasing this value will increase memory consumption)",
+			Value:    1024 * 5, //nolint:mnd // 5 KB
+			Sources:  env("READ_BUFFER_SIZE"),
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+		}
+	)
+
+	// override some flag usage messages
+	addrFlag.Usage = "The HTTP server will listen on this IP (v4 or v6) address (set 127.0.0.1/::1 for localhost, " +
+		"0.0.0.0 to listen on all interfaces, or specify a custom IP)"
+	portFlag.Usage = "The TCP port number for the HTTP server to listen on (0-65535)"
+
+	disableL10nFlag.Value = cfg.L10n.Disable // set the default value depending on the configuration
+
+	// configure loads the configuration file (if specified) and applies the flags to the configuration. It's called
+	// on startup and on every configuration reload, so the templates and the configuration file are read again
+	var configure = func(ctx context.Context, c *cli.Command, cfg *config.Config) error {
+		// load the configuration file first (if specified), so the flags and environment variables can
+		// override its values
+		if source := c.String(configFlag.Name); source != "" {
+			data, err := config.ReadSource(ctx, source, config.SourceOptions{
+				SHA256:             c.String(configSHA256Flag.Name),
+				InsecureSkipVerify: c.Bool(configInsecureFlag.Name),
+			})
+			if err != nil {
+				return err
+			}
+
+			file, err := config.ParseFile(data)
+			if err != nil {
+				return err
+			}
+
+			if err = file.Apply(cfg); err != nil {
+				return fmt.Errorf("wrong configuration: %w", err)
+			}
+
+			log.Info("Configuration loaded", logger.String("source", redactSource(source)))
+		}
+
+		// the flags below override the configuration values only if they are set explicitly
+		if c.IsSet(disableL10nFlag.Name) {
+			cfg.L10n.Disable = c.Bool(disableL10nFlag.Name)
+		}
+
+		if c.IsSet(defaultCodeToRenderFlag.Name) {
+			cfg.DefaultCodeToRender = uint16(c.Uint(defaultCodeToRenderFlag.Name)) //nolint:gosec
+		}
+
+		if c.IsSet(unknownCodeLogIntervalFlag.Name) {
+			cfg.UnknownCodeLogInterval = c.Duration(unknownCodeLogIntervalFlag.Name)
+		}
+
+		if c.IsSet(sendSameHTTPCodeFlag.Name) {
+			cfg.RespondWithSameHTTPCode = c.Bool(sendSameHTTPCodeFlag.Name)
+		}
+
+		if c.IsSet(rotationModeFlag.Name) {
+			cfg.RotationMode, _ = config.ParseRotationMode(c.String(rotationModeFlag.Name))
+		}
+
+		if c.IsSet(catchAllFlag.Name) {
+			cfg.CatchAll.Enabled = c.Bool(catchAllFlag.Name)
+		}
+
+		if c.IsSet(catchAllLogRateFlag.Name) {
+			cfg.CatchAll.LogSampleRate = c.Float(catchAllLogRateFlag.Name)
+		}
+
+		if c.IsSet(experimentTemplatesFlag.Name) {
+			var names = strings.Split(c.String(experimentTemplatesFlag.Name), ",")
+
+			cfg.Experiment.Templates = [2]string{strings.TrimSpace(names[0]), strings.TrimSpace(names[1])}
+		}
+
+		if c.IsSet(experimentSplitFlag.Name) {
+			cfg.Experiment.Split = uint8(c.Uint(experimentSplitFlag.Name)) //nolint:gosec
+		}
+
+		if c.IsSet(showDetailsFlag.Name) {
+			cfg.ShowDetails = c.Bool(showDetailsFlag.Name)
+		}
+
+		if c.IsSet(detailsNetworksFlag.Name) {
+			cfg.DetailsAccess.Networks, _ = clientip.ParsePrefixes(strings.Split(c.String(detailsNetworksFlag.Name), ",")...)
+		}
+
+		if c.IsSet(detailsTokenFlag.Name) {
+			cfg.DetailsAccess.Token = c.String(detailsTokenFlag.Name)
+		}
+
+		if c.IsSet(detailsTokenHeaderFlag.Name) {
+			cfg.DetailsAccess.Header = c.String(detailsTokenHeaderFlag.Name)
+		}
+
+		if c.IsSet(detailsWasmHookFlag.Name) {
+			cfg.DetailsAccess.Hook = nil // an empty path disables the hook
+
+			if path := c.String(detailsWasmHookFlag.Name); path != "" {
+				hook, err := wasmhook.Load(path)
+				if err != nil {
+					return fmt.Errorf("wrong details WASM hook '%s': %w", path, err)
+				}
+
+				cfg.DetailsAccess.Hook = hook
+			}
+		}
+
+		if c.IsSet(showOriginalStatusFlag.Name) {
+			cfg.ShowOriginalStatus = c.Bool(showOriginalStatusFlag.Name)
+		}
+
+		if c.IsSet(reducedMotionFlag.Name) {
+			cfg.Accessibility.ReducedMotion, _ = config.ParseMotionPreference(c.String(reducedMotionFlag.Name)) // validated
+		}
+
+		if c.IsSet(printFriendlyFlag.Name) {
+			cfg.Accessibility.PrintFriendly = c.Bool(printFriendlyFlag.Name)
+		}
+
+		if c.IsSet(maxProxyHopsFlag.Name) {
+			cfg.ClientIP.MaxHops = c.Uint(maxProxyHopsFlag.Name)
+		}
+
+		if c.IsSet(proxyModeFlag.Name) {
+			cfg.Proxy.Mode, _ = config.ParseProxyMode(c.String(proxyModeFlag.Name)) // validated
+		}
+
+		if c.IsSet(originalURIHeaderFlag.Name) {
+			cfg.Proxy.OriginalURI = c.String(originalURIHeaderFlag.Name)
+		}
+
+		if c.IsSet(forwardedForHeaderFlag.Name) {
+			cfg.Proxy.ForwardedFor = c.String(forwardedForHeaderFlag.Name)
+		}
+
+		if c.IsSet(pathPrefixFlag.Name) {
+			cfg.PathPrefix = config.NormalizePathPrefix(c.String(pathPrefixFlag.Name))
+		}
+
+		if c.IsSet(staticDirFlag.Name) {
+			cfg.StaticDir = c.String(staticDirFlag.Name)
+		}
+
+		if c.IsSet(maxRendersFlag.Name) {
+			cfg.MaxConcurrentRenders = c.Uint(maxRendersFlag.Name)
+		}
+
+		if c.IsSet(bannerFlag.Name) {
+			cfg.Banner.Message = c.String(bannerFlag.Name)
+		}
+
+		if c.IsSet(bannerSeverityFlag.Name) {
+			cfg.Banner.Severity, _ = config.ParseBannerSeverity(c.String(bannerSeverityFlag.Name)) // validated
+		}
+
+		if c.IsSet(watermarkFlag.Name) {
+			cfg.Watermark.Mode, _ = config.ParseWatermarkMode(c.String(watermarkFlag.Name)) // validated
+		}
+
+		if c.IsSet(instanceIDFlag.Name) {
+			cfg.Watermark.InstanceID = c.String(instanceIDFlag.Name)
+		}
+
+		if c.IsSet(upstreamHealthURLFlag.Name) {
+			cfg.UpstreamHealth.URL = c.String(upstreamHealthURLFlag.Name)
+		}
+
+		if c.IsSet(upstreamHealthIntervalFlag.Name) {
+			cfg.UpstreamHealth.Interval = c.Duration(upstreamHealthIntervalFlag.Name)
+		}
+
+		if c.IsSet(upstreamHealthTimeoutFlag.Name) {
+			cfg.UpstreamHealth.Timeout = c.Duration(upstreamHealthTimeoutFlag.Name)
+		}
+
+		if c.IsSet(upstreamRecoveryURLFlag.Name) {
+			cfg.UpstreamHealth.RecoveryURL = c.String(upstreamRecoveryURLFlag.Name)
+		}
+
+		if c.IsSet(liveStatusURLFlag.Name) {
+			cfg.LiveStatus.URL = c.String(liveStatusURLFlag.Name)
+		}
+
+		if c.IsSet(liveStatusIntervalFlag.Name) {
+			cfg.LiveStatus.Interval = c.Duration(liveStatusIntervalFlag.Name)
+		}
+
+		if c.IsSet(ogImageBaseURLFlag.Name) {
+			cfg.OGImageBaseURL = strings.TrimSuffix(c.String(ogImageBaseURLFlag.Name), "/")
+		}
+
+		if flags := c.StringMap(featureFlagFlag.Name); len(flags) > 0 {
+			if cfg.FeatureFlags.Values == nil {
+				cfg.FeatureFlags.Values = make(map[string]string, len(flags))
+			}
+
+			for name, value := range flags {
+				cfg.FeatureFlags.Values[name], _ = featureflag.Normalize(name, value) // validated
+			}
+		}
+
+		if c.IsSet(featureFlagsFileFlag.Name) {
+			cfg.FeatureFlags.File = c.String(featureFlagsFileFlag.Name)
+		}
+
+		if c.IsSet(featureFlagsRedisURLFlag.Name) {
+			cfg.FeatureFlags.RedisURL = c.String(featureFlagsRedisURLFlag.Name)
+		}
+
+		if c.IsSet(featureFlagsRedisKeyFlag.Name) {
+			if key := c.String(featureFlagsRedisKeyFlag.Name); key != "" {
+				cfg.FeatureFlags.RedisKey = key
+			}
+		}
+
+		if c.IsSet(featureFlagsIntervalFlag.Name) {
+			cfg.FeatureFlags.Interval = c.Duration(featureFlagsIntervalFlag.Name)
+		}
+
+		if c.IsSet(publishBucketFlag.Name) {
+			cfg.Publish.Bucket = c.String(publishBucketFlag.Name)
+		}
+
+		if c.IsSet(publishRegionFlag.Name) {
+			cfg.Publish.Region = c.String(publishRegionFlag.Name)
+		}
+
+		if c.IsSet(publishEndpointFlag.Name) {
+			cfg.Publish.Endpoint = c.String(publishEndpointFlag.Name)
+		}
+
+		if c.IsSet(publishPrefixFlag.Name) {
+			cfg.Publish.Prefix = strings.Trim(c.String(publishPrefixFlag.Name), "/")
+		}
+
+		if c.IsSet(publishIntervalFlag.Name) {
+			cfg.Publish.Interval = c.Duration(publishIntervalFlag.Name)
+		}
+
+		if c.IsSet(viaPseudonymFlag.Name) {
+			cfg.LoopGuard.Pseudonym = c.String(viaPseudonymFlag.Name)
+		}
+
+		if c.IsSet(loopMaxRateFlag.Name) {
+			cfg.LoopGuard.MaxRate = c.Uint(loopMaxRateFlag.Name)
+		}
+
+		if c.IsSet(cacheTenantQuotaFlag.Name) {
+			cfg.CacheTenantQuota = c.Uint(cacheTenantQuotaFlag.Name)
+		}
+
+		if c.IsSet(gcPercentFlag.Name) {
+			cfg.Memory.GCPercent = c.Int(gcPercentFlag.Name)
+		}
+
+		if c.IsSet(memoryLimitFlag.Name) {
+			cfg.Memory.Limit = c.Uint(memoryLimitFlag.Name)
+		}
+
+		if c.IsSet(cacheShrinkAtFlag.Name) {
+			cfg.Memory.CacheShrinkAt = c.Uint(cacheShrinkAtFlag.Name)
+		}
+
+		if c.IsSet(streamThresholdFlag.Name) {
+			cfg.StreamThreshold = c.Uint(streamThresholdFlag.Name)
+		}
+
+		if c.IsSet(timezoneFlag.Name) {
+			cfg.Timezone = c.String(timezoneFlag.Name)
+		}
+
+		if c.IsSet(renderTimeoutFlag.Name) {
+			cfg.TemplateLimits.RenderTimeout = c.Duration(renderTimeoutFlag.Name)
+		}
+
+		if c.IsSet(templateMaxDepthFlag.Name) {
+			cfg.TemplateLimits.MaxDepth = c.Uint(templateMaxDepthFlag.Name)
+		}
+
+		if c.IsSet(templateMaxIncludesFlag.Name) {
+			cfg.TemplateLimits.MaxIncludes = c.Uint(templateMaxIncludesFlag.Name)
+		}
+
+		if c.IsSet(templateMaxIterationsFlag.Name) {
+			cfg.TemplateLimits.MaxIterations = c.Uint(templateMaxIterationsFlag.Name)
+		}
+
+		if c.IsSet(requestIDFormatFlag.Name) {
+			cfg.RequestIDFormat, _ = config.ParseRequestIDFormat(c.String(requestIDFormatFlag.Name)) // validated
+		}
+
+		if c.IsSet(requestIDHeadersFlag.Name) {
+			cfg.RequestID.Headers = cfg.RequestID.Headers[:0]
+
+			for _, header := range strings.Split(c.String(requestIDHeadersFlag.Name), ",") {
+				if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" &&
+					!slices.Contains(cfg.RequestID.Headers, header) {
+					cfg.RequestID.Headers = append(cfg.RequestID.Headers, header)
+				}
+			}
+		}
+
+		if c.IsSet(requestIDPrefixFlag.Name) {
+			cfg.RequestID.Prefix = c.String(requestIDPrefixFlag.Name)
+		}
+
+		if c.IsSet(requestIDLayoutFlag.Name) {
+			cfg.RequestID.Layout = c.String(requestIDLayoutFlag.Name)
+		}
+
+		if c.IsSet(requestIDCFRayFlag.Name) {
+			cfg.RequestID.CFRay = c.Bool(requestIDCFRayFlag.Name)
+		}
+
+		if c.IsSet(datacenterFlag.Name) {
+			cfg.Datacenter.Code = c.String(datacenterFlag.Name)
+		}
+
+		if c.IsSet(datacenterFileFlag.Name) {
+			cfg.Datacenter.File = c.String(datacenterFileFlag.Name)
+		}
+
+		if c.IsSet(datacenterMetadataFlag.Name) {
+			cfg.Datacenter.Metadata = strings.ToLower(c.String(datacenterMetadataFlag.Name))
+		}
+
+		if c.IsSet(bodyPreviewSizeFlag.Name) {
+			cfg.BodyPreviewSize = c.Uint(bodyPreviewSizeFlag.Name)
+		}
+
+		if c.IsSet(enableAPIFlag.Name) {
+			cfg.EnableAPI = c.Bool(enableAPIFlag.Name)
+		}
+
+		if c.IsSet(enableMetricsFlag.Name) {
+			cfg.EnableMetrics = c.Bool(enableMetricsFlag.Name)
+		}
+
+		if c.IsSet(apiTokenFlag.Name) {
+			cfg.APIToken = strings.TrimSpace(c.String(apiTokenFlag.Name))
+		}
+
+		if c.IsSet(shadowFlag.Name) {
+			cfg.Shadow = c.Bool(shadowFlag.Name)
+		}
+
+		if c.IsSet(codePrecedenceFlag.Name) {
+			cfg.RequestHeaders.CodePrecedence, _ = config.ParseCodePrecedence(c.String(codePrecedenceFlag.Name)) // validated
+		}
+
+		if c.IsSet(rejectDuplicateHeadersFlag.Name) {
+			cfg.RequestHeaders.RejectDuplicates = c.Bool(rejectDuplicateHeadersFlag.Name)
+		}
+
+		if c.IsSet(maxHeaderValueSizeFlag.Name) {
+			cfg.RequestHeaders.MaxValueSize = c.Uint(maxHeaderValueSizeFlag.Name)
+		}
+
+		if c.IsSet(strictCodesFlag.Name) {
+			cfg.RequestHeaders.StrictCodes = c.Bool(strictCodesFlag.Name)
+		}
+
+		if c.IsSet(tlsErrorHeaderFlag.Name) {
+			cfg.TLSErrors.Header = c.String(tlsErrorHeaderFlag.Name)
+		}
+
+		if c.IsSet(cspFlag.Name) {
+			cfg.ContentSecurityPolicy = c.String(cspFlag.Name)
+		}
+
+		if c.IsSet(earlyHintsFlag.Name) {
+			cfg.EarlyHints = c.Bool(earlyHintsFlag.Name)
+		}
+
+		if c.IsSet(esiFlag.Name) {
+			cfg.ESI = c.Bool(esiFlag.Name)
+		}
+
+		if c.IsSet(unavailableUntilReadyFlag.Name) {
+			cfg.UnavailableUntilReady = c.Bool(unavailableUntilReadyFlag.Name)
+		}
+
+		if c.IsSet(signingAlgorithmFlag.Name) {
+			cfg.Signing.Algorithm, _ = config.ParseSigningAlgorithm(c.String(signingAlgorithmFlag.Name)) // validated
+		}
+
+		if c.IsSet(signingKeyFlag.Name) {
+			cfg.Signing.Key = c.String(signingKeyFlag.Name)
+		}
+
+		if c.IsSet(disableAutoEscapeFlag.Name) {
+			cfg.DisableAutoEscape = c.Bool(disableAutoEscapeFlag.Name)
+		}
+
+		if c.IsSet(disableMinificationFlag.Name) {
+			cfg.DisableMinification = c.Bool(disableMinificationFlag.Name)
+		}
+
+		if c.IsSet(disableCompressionFlag.Name) {
+			cfg.DisableCompression = c.Bool(disableCompressionFlag.Name)
+		}
+
+		if c.IsSet(keepCommentsFlag.Name) {
+			cfg.Minification.KeepComments = c.Bool(keepCommentsFlag.Name)
+		}
+
+		if c.IsSet(keepCondCommentsFlag.Name) {
+			cfg.Minification.KeepConditionalComments = c.Bool(keepCondCommentsFlag.Name)
+		}
+
+		if c.IsSet(keepInlineCSSFlag.Name) {
+			cfg.Minification.KeepInlineCSS = c.Bool(keepInlineCSSFlag.Name)
+		}
+
+		if c.IsSet(keepInlineJSFlag.Name) {
+			cfg.Minification.KeepInlineJS = c.Bool(keepInlineJSFlag.Name)
+		}
+
+		{ // override default JSON, XML, and PlainText formats
+			// the schema selects one of the default formats, so the custom one (like the format from the
+			// configuration file) is never replaced
+			if c.IsSet(jsonSchemaFlag.Name) && config.IsJSONSchemaFormat(cfg.Formats.JSON) {
+				v, _ := config.ParseJSONSchemaVersion(c.String(jsonSchemaFlag.Name)) // already validated
+
+				cfg.Formats.JSON, _ = v.Format()
+			}
+
+			if c.IsSet(jsonFormatFlag.Name) {
+				cfg.Formats.JSON = strings.TrimSpace(c.String(jsonFormatFlag.Name))
+			}
+
+			if c.IsSet(xmlFormatFlag.Name) {
+				cfg.Formats.XML = strings.TrimSpace(c.String(xmlFormatFlag.Name))
+			}
+
+			if c.IsSet(plainTextFormatFlag.Name) {
+				cfg.Formats.PlainText = strings.TrimSpace(c.String(plainTextFormatFlag.Name))
+			}
+
+			if c.IsSet(defaultFormatFlag.Name) {
+				cfg.DefaultFormat, _ = config.ParseFormat(c.String(defaultFormatFlag.Name)) // already validated
+			}
+
+			if c.IsSet(unsupportedFormatFlag.Name) {
+				cfg.Formats.Unsupported = strings.TrimSpace(c.String(unsupportedFormatFlag.Name))
+			}
+
+			if c.IsSet(plainTextMaxWidthFlag.Name) {
+				cfg.PlainTextOutput.MaxLineWidth = c.Uint(plainTextMaxWidthFlag.Name)
+			}
+
+			if c.IsSet(plainTextNormalizationFlag.Name) {
+				n, _ := config.ParseTextNormalization(c.String(plainTextNormalizationFlag.Name)) // already validated
+
+				cfg.PlainTextOutput.Normalization = n
+			}
+
+			if c.IsSet(jsonMaxSizeFlag.Name) {
+				cfg.BodyLimits.JSON = c.Uint(jsonMaxSizeFlag.Name)
+			}
+
+			if c.IsSet(xmlMaxSizeFlag.Name) {
+				cfg.BodyLimits.XML = c.Uint(xmlMaxSizeFlag.Name)
+			}
+
+			if c.IsSet(plainTextMaxSizeFlag.Name) {
+				cfg.BodyLimits.PlainText = c.Uint(plainTextMaxSizeFlag.Name)
+			}
+
+			if c.IsSet(charsetFlag.Name) {
+				cfg.Charset = c.String(charsetFlag.Name)
+			}
+		}
+
+		// add templates from files to the configuration
+		if add := c.StringSlice(addTplFlag.Name); len(add) > 0 {
+			for _, templatePath := range add {
+				if addedName, err := cfg.Templates.AddFromFile(templatePath); err != nil {
+					return fmt.Errorf("cannot add template from file %s: %w", templatePath, err)
+				} else {
+					log.Info("Template added",
+						logger.String("name", addedName),
+						logger.String("path", templatePath),
+					)
+				}
+			}
+		}
+
+		// set the list of HTTP headers we need to proxy from the incoming request to the error page response
+		if c.IsSet(proxyHeadersListFlag.Name) {
+			var m = make(map[string]struct{}) // map is used to avoid duplicates
+
+			for _, header := range strings.Split(c.String(proxyHeadersListFlag.Name), ",") {
+				m[http.CanonicalHeaderKey(strings.TrimSpace(header))] = struct{}{}
+			}
+
+			cfg.ProxyHeaders = make([]string, 0, len(m)) // clear the list before adding new headers
+
+			for header := range m {
+				cfg.ProxyHeaders = append(cfg.ProxyHeaders, header)
+			}
+		}
+
+		if c.IsSet(templateHeadersFlag.Name) {
+			cfg.TemplateHeaders = cfg.TemplateHeaders[:0]
+
+			for _, header := range strings.Split(c.String(templateHeadersFlag.Name), ",") {
+				if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" &&
+					!slices.Contains(cfg.TemplateHeaders, header) {
+					cfg.TemplateHeaders = append(cfg.TemplateHeaders, header)
+				}
+			}
+		}
+
+		// set the list of hosts that are allowed to be served
+		if c.IsSet(allowedHostsFlag.Name) {
+			cfg.AllowedHosts = cfg.AllowedHosts[:0]
+
+			for _, host := range strings.Split(c.String(allowedHostsFlag.Name), ",") {
+				if host = strings.ToLower(strings.TrimSpace(host)); host != "" && !slices.Contains(cfg.AllowedHosts, host) {
+					cfg.AllowedHosts = append(cfg.AllowedHosts, host)
+				}
+			}
+		}
+
+		// set the User-Agent rules forcing the response format
+		if c.IsSet(formatOverrideFlag.Name) {
+			cfg.FormatRules = cfg.FormatRules[:0]
+
+			for _, raw := range c.StringSlice(formatOverrideFlag.Name) {
+				rule, _ := config.ParseFormatRule(raw) // already validated
+
+				cfg.FormatRules = append(cfg.FormatRules, rule)
+			}
+		}
+
+		// set the Allow header rules for the 405 responses
+		if c.IsSet(allowMethodsFlag.Name) {
+			cfg.AllowRules = cfg.AllowRules[:0]
+
+			for _, raw := range c.StringSlice(allowMethodsFlag.Name) {
+				rule, _ := config.ParseAllowRule(raw) // already validated
+
+				cfg.AllowRules = append(cfg.AllowRules, rule)
+			}
+		}
+
+		// set the WWW-Authenticate challenges for the 401 responses
+		if c.IsSet(authChallengeFlag.Name) {
+			cfg.AuthChallenges = c.StringSlice(authChallengeFlag.Name)
+		}
+
+		// set the trusted proxies to extract the client IP address from the X-Forwarded-For header
+		if c.IsSet(trustedProxiesFlag.Name) {
+			cfg.ClientIP.TrustedProxies, _ = clientip.ParsePrefixes(strings.Split(c.String(trustedProxiesFlag.Name), ",")...)
+		}
+
+		// set the routing table (the routes from the flags replace the routes from the configuration file)
+		if c.IsSet(routeFlag.Name) {
+			cfg.Routes = cfg.Routes[:0]
+
+			for _, raw := range c.StringSlice(routeFlag.Name) {
+				route, _ := config.ParseRoute(raw) // already validated
+
+				cfg.Routes = append(cfg.Routes, route)
+			}
+		}
+
+		// add custom HTTP codes to the configuration
+		if add := c.StringMap(addCodeFlag.Name); len(add) > 0 {
+			for code, desc := range shared.ParseHTTPCodes(add) {
+				cfg.Codes[code] = desc
+
+				log.Info("HTTP code added",
+					logger.String("code", code),
+					logger.String("message", desc.Message),
+					logger.String("description", desc.Description),
+				)
+			}
+		}
+
+		// disable templates specified by the user
+		if disable := c.StringSlice(disableTplFlag.Name); len(disable) > 0 {
+			for _, templateName := range disable {
+				if ok := cfg.Templates.Remove(templateName); ok {
+					log.Info("Template disabled", logger.String("name", templateName))
+				}
+			}
+		}
+
+		// the per-template opt-outs of the minification and caching
+		for flag, apply := range map[string]func(*config.TemplateOptions){
+			noMinifyTplFlag.Name: func(o *config.TemplateOptions) { o.DisableMinification = true },
+			noCacheTplFlag.Name:  func(o *config.TemplateOptions) { o.DisableCache = true },
+		} {
+			for _, name := range c.StringSlice(flag) {
+				if cfg.TemplateOptions == nil {
+					cfg.TemplateOptions = make(map[string]config.TemplateOptions)
+				}
+
+				var o = cfg.TemplateOptions[name]
+
+				apply(&o)
+				cfg.TemplateOptions[name] = o
+			}
+		}
+
+		for _, preload := range c.StringSlice(templatePreloadFlag.Name) {
+			name, link, _ := config.ParseTemplatePreload(preload) // already validated
+
+			if cfg.TemplateOptions == nil {
+				cfg.TemplateOptions = make(map[string]config.TemplateOptions)
+			}
+
+			var o = cfg.TemplateOptions[name]
+
+			o.Preload = append(o.Preload, link)
+			cfg.TemplateOptions[name] = o
+		}
+
+		// check if there are any templates available to render error pages
+		if len(cfg.Templates.Names()) == 0 {
+			return errors.New("no templates available to render error pages")
+		}
+
+		if c.IsSet(templateNameFlag.Name) {
+			cfg.TemplateName = c.String(templateNameFlag.Name)
+		}
+
+		// with the random-on-startup rotation mode, the template is picked by the error page handler (the
+		// user-provided template name is ignored)
+		if cfg.RotationMode != config.RotationModeRandomOnStartup && !cfg.Templates.Has(cfg.TemplateName) {
+			return fmt.Errorf(
+				"template '%s' not found and cannot be used (available templates: %s)",
+				cfg.TemplateName,
+				cfg.Templates.Names(),
+			)
+		}
+
+		// the experiment needs both templates to be available
+		if cfg.RotationMode == config.RotationModeExperiment {
+			for _, name := range cfg.Experiment.Templates {
+				if !cfg.Templates.Has(name) {
+					return fmt.Errorf(
+						"experiment template '%s' not found (available templates: %s)", name, cfg.Templates.Names(),
+					)
+				}
+			}
+		}
+
+		// the maintenance templates must be available too
+		for _, w := range cfg.Maintenance {
+			if w.Template != "" && !cfg.Templates.Has(w.Template) {
+				return fmt.Errorf(
+					"maintenance template '%s' not found (available templates: %s)", w.Template, cfg.Templates.Names(),
+				)
+			}
+		}
+
+		// the per-template options must refer to the available templates
+		for name := range cfg.TemplateOptions {
+			if !cfg.Templates.Has(name) {
+				return fmt.Errorf(
+					"template '%s' (with the options) not found (available templates: %s)", name, cfg.Templates.Names(),
+				)
+			}
+		}
+
+		// and the error kind templates
+		for name, k := range cfg.ErrorKinds {
+			if k.Template != "" && !cfg.Templates.Has(k.Template) {
+				return fmt.Errorf(
+					"error kind '%s' template '%s' not found (available templates: %s)",
+					name, k.Template, cfg.Templates.Names(),
+				)
+			}
+		}
+
+		// the profiles must refer to the available templates and the overridable tokens
+		for name, p := range cfg.Profiles {
+			if p.Template != "" && !cfg.Templates.Has(p.Template) {
+				return fmt.Errorf(
+					"profile '%s' template '%s' not found (available templates: %s)",
+					name, p.Template, cfg.Templates.Names(),
+				)
+			}
+
+			if err := new(template.Props).Override(p.Tokens); err != nil {
+				return fmt.Errorf("profile '%s': %w", name, err)
+			}
+		}
+
+		// the routes may switch the template too
+		for _, r := range cfg.Routes {
+			if r.Template != "" && !cfg.Templates.Has(r.Template) {
+				return fmt.Errorf(
+					"route '%s' template '%s' not found (available templates: %s)",
+					r.Pattern, r.Template, cfg.Templates.Names(),
+				)
+			}
+		}
+
+		for name, k := range cfg.TLSErrors.Kinds {
+			if k.Template != "" && !cfg.Templates.Has(k.Template) {
+				return fmt.Errorf(
+					"TLS error '%s' template '%s' not found (available templates: %s)",
+					name, k.Template, cfg.Templates.Names(),
+				)
 			}
+		}
+
+		if err := ep.CheckSigningKey(cfg.Signing.Algorithm, cfg.Signing.Key); err != nil {
+			return err
+		}
+
+		if cfg.UpstreamHealth.RecoveryURL != "" && cfg.UpstreamHealth.URL == "" {
+			return errors.New("the upstream recovery URL requires the upstream health URL to be set")
+		}
 
-			log.Debug("Configuration",
-				logger.Strings("loaded templates", cfg.Templates.Names()...),
-				logger.Strings("described HTTP codes", cfg.Codes.Codes()...),
-				logger.String("JSON format", cfg.Formats.JSON),
-				logger.String("XML format", cfg.Formats.XML),
-				logger.String("plain text format", cfg.Formats.PlainText),
-				logger.String("template name", cfg.TemplateName),
-				logger.Bool("disable localization", cfg.L10n.Disable),
-				logger.Uint16("default code to render", cfg.DefaultCodeToRender),
-				logger.Bool("respond with the same HTTP code", cfg.RespondWithSameHTTPCode),
-				logger.String("rotation mode", cfg.RotationMode.String()),
-				logger.Bool("show details", cfg.ShowDetails),
-				logger.Strings("proxy HTTP headers", cfg.ProxyHeaders...),
+		// resolve the datacenter code once (the default one is used if the source is not available)
+		dcCode, dcErr := datacenter.Resolve(ctx, datacenter.Sources{
+			Code:     cfg.Datacenter.Code,
+			File:     cfg.Datacenter.File,
+			Metadata: cfg.Datacenter.Metadata,
+		})
+		if dcErr != nil {
+			log.Warn("Cannot resolve the datacenter code, the default one is used",
+				logger.String("default", datacenter.Default),
+				logger.Error(dcErr),
 			)
+		}
+
+		if cfg.Datacenter.Code = dcCode; dcCode == "" {
+			cfg.Datacenter.Code = datacenter.Default
+		}
+
+		log.Debug("Configuration",
+			logger.Strings("loaded templates", cfg.Templates.Names()...),
+			logger.Strings("described HTTP codes", cfg.Codes.Codes()...),
+			logger.String("JSON format", cfg.Formats.JSON),
+			logger.String("XML format", cfg.Formats.XML),
+			logger.String("plain text format", cfg.Formats.PlainText),
+			logger.String("unsupported format", cfg.Formats.Unsupported),
+			logger.String("default format", cfg.DefaultFormat.String()),
+			logger.Uint64("plain text max width", uint64(cfg.PlainTextOutput.MaxLineWidth)),
+			logger.String("plain text normalization", cfg.PlainTextOutput.Normalization.String()),
+			logger.Uint64("json max size", uint64(cfg.BodyLimits.JSON)),
+			logger.Uint64("xml max size", uint64(cfg.BodyLimits.XML)),
+			logger.Uint64("plain text max size", uint64(cfg.BodyLimits.PlainText)),
+			logger.String("charset", cfg.Charset),
+			logger.Int("format rules", len(cfg.FormatRules)),
+			logger.String("template name", cfg.TemplateName),
+			logger.Bool("disable localization", cfg.L10n.Disable),
+			logger.String("reduced motion", cfg.Accessibility.ReducedMotion.String()),
+			logger.Bool("print friendly", cfg.Accessibility.PrintFriendly),
+			logger.Uint16("default code to render", cfg.DefaultCodeToRender),
+			logger.Duration("unknown code log interval", cfg.UnknownCodeLogInterval),
+			logger.Bool("respond with the same HTTP code", cfg.RespondWithSameHTTPCode),
+			logger.String("rotation mode", cfg.RotationMode.String()),
+			logger.Strings("experiment templates", cfg.Experiment.Templates[:]...),
+			logger.Uint64("experiment split", uint64(cfg.Experiment.Split)),
+			logger.Bool("show details", cfg.ShowDetails),
+			logger.Any("details networks", cfg.DetailsAccess.Networks),
+			logger.String("details token header", cfg.DetailsAccess.Header),
+			logger.Bool("details token set", cfg.DetailsAccess.Token != ""),
+			logger.Bool("details WASM hook set", cfg.DetailsAccess.Hook != nil),
+			logger.Bool("show original status", cfg.ShowOriginalStatus),
+			logger.Bool("catch-all mode", cfg.CatchAll.Enabled),
+			logger.Float64("catch-all log sample rate", cfg.CatchAll.LogSampleRate),
+			logger.Strings("proxy HTTP headers", cfg.ProxyHeaders...),
+			logger.Strings("template headers", cfg.TemplateHeaders...),
+			logger.Strings("allowed hosts", cfg.AllowedHosts...),
+			logger.Strings("auth challenges", cfg.AuthChallenges...),
+			logger.Int("allow rules", len(cfg.AllowRules)),
+			logger.Any("trusted proxies", cfg.ClientIP.TrustedProxies),
+			logger.Uint64("max proxy hops", uint64(cfg.ClientIP.MaxHops)),
+			logger.String("proxy mode", cfg.Proxy.Mode.String()),
+			logger.String("original uri header", cfg.Proxy.OriginalURIHeader()),
+			logger.String("forwarded for header", cfg.Proxy.ForwardedForHeader()),
+			logger.String("static directory", cfg.StaticDir),
+			logger.String("path prefix", cfg.PathPrefix),
+			logger.Int("routes", len(cfg.Routes)),
+			logger.Int("error kinds", len(cfg.ErrorKinds)),
+			logger.Int("profiles", len(cfg.Profiles)),
+			logger.Int("TLS errors", len(cfg.TLSErrors.Kinds)),
+			logger.Uint64("max concurrent renders", uint64(cfg.MaxConcurrentRenders)),
+			logger.Uint64("cache tenant quota", uint64(cfg.CacheTenantQuota)),
+			logger.Int("gc percent", cfg.Memory.GCPercent),
+			logger.Uint64("memory limit (MiB)", uint64(cfg.Memory.Limit)),
+			logger.Uint64("cache shrink at (MiB)", uint64(cfg.Memory.CacheShrinkAt)),
+			logger.Uint64("stream threshold", uint64(cfg.StreamThreshold)),
+			logger.String("banner", cfg.Banner.Message),
+			logger.String("banner severity", cfg.Banner.Severity.String()),
+			logger.String("watermark", cfg.Watermark.Mode.String()),
+			logger.String("instance id", cfg.Watermark.InstanceID),
+			logger.String("timezone", cfg.Timezone),
+			logger.Bool("disable auto escape", cfg.DisableAutoEscape),
+			logger.Bool("enable API", cfg.EnableAPI),
+			logger.Bool("enable metrics", cfg.EnableMetrics),
+			logger.Bool("API token set", cfg.APIToken != ""),
+			logger.Bool("shadow mode", cfg.Shadow),
+			logger.Bool("disable minification", cfg.DisableMinification),
+			logger.Bool("disable compression", cfg.DisableCompression),
+			logger.Any("minification", cfg.Minification),
+			logger.Any("template options", cfg.TemplateOptions),
+			logger.String("code precedence", cfg.RequestHeaders.CodePrecedence.String()),
+			logger.Bool("reject duplicate headers", cfg.RequestHeaders.RejectDuplicates),
+			logger.Uint64("max header value size", uint64(cfg.RequestHeaders.MaxValueSize)),
+			logger.Bool("strict codes", cfg.RequestHeaders.StrictCodes),
+			logger.String("TLS error header", cfg.TLSErrors.Header),
+			logger.String("content security policy", cfg.ContentSecurityPolicy),
+			logger.Bool("early hints", cfg.EarlyHints),
+			logger.Bool("esi", cfg.ESI),
+			logger.Bool("unavailable until ready", cfg.UnavailableUntilReady),
+			logger.String("signing algorithm", cfg.Signing.Algorithm.String()),
+			logger.Uint64("body preview size", uint64(cfg.BodyPreviewSize)),
+			logger.String("datacenter", cfg.Datacenter.Code),
+			logger.String("upstream health URL", cfg.UpstreamHealth.URL),
+			logger.Duration("upstream health interval", cfg.UpstreamHealth.Interval),
+			logger.Duration("upstream health timeout", cfg.UpstreamHealth.Timeout),
+			logger.String("publish bucket", cfg.Publish.Bucket),
+			logger.String("publish prefix", cfg.Publish.Prefix),
+			logger.Duration("publish interval", cfg.Publish.Interval),
+			logger.String("via pseudonym", cfg.LoopGuard.Pseudonym),
+			logger.Uint64("loop max rate", uint64(cfg.LoopGuard.MaxRate)),
+			logger.String("upstream recovery URL", cfg.UpstreamHealth.RecoveryURL),
+			logger.String("live status URL", cfg.LiveStatus.URL),
+			logger.Duration("live status interval", cfg.LiveStatus.Interval),
+			logger.String("social preview base URL", cfg.OGImageBaseURL),
+			logger.Int("feature flags", len(cfg.FeatureFlags.Values)),
+			logger.String("feature flags file", cfg.FeatureFlags.File),
+			logger.Bool("feature flags from Redis", cfg.FeatureFlags.RedisURL != ""), // the URL may contain the password
+			logger.Duration("feature flags interval", cfg.FeatureFlags.Interval),
+			logger.String("request ID format", cfg.RequestIDFormat.String()),
+			logger.Strings("request ID headers", cfg.RequestID.Headers...),
+			logger.String("request ID prefix", cfg.RequestID.Prefix),
+			logger.String("request ID layout", cfg.RequestID.Layout),
+			logger.Bool("request ID CF-Ray", cfg.RequestID.CFRay),
+			logger.Duration("render timeout", cfg.TemplateLimits.RenderTimeout),
+			logger.Uint64("template max depth", uint64(cfg.TemplateLimits.MaxDepth)),
+			logger.Uint64("template max includes", uint64(cfg.TemplateLimits.MaxIncludes)),
+			logger.Uint64("template max iterations", uint64(cfg.TemplateLimits.MaxIterations)),
+		)
+
+		return nil
+	}
+
+	cmd.c = &cli.Command{
+		Name:    "serve",
+		Aliases: []string{"s", "server", "http"},
+		Usage:   "Please start the HTTP server to serve the error pages. You can configure various options - please RTFM :D",
+		Suggest: true,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			cmd.opt.http.addr = c.String(addrFlag.Name)
+			cmd.opt.http.port = uint16(c.Uint(portFlag.Name)) //nolint:gosec
+			cmd.opt.http.readBufferSize = c.Uint(readBufferSizeFlag.Name)
+			cmd.opt.watch.interval = c.Duration(watchIntervalFlag.Name)
+			cmd.opt.watch.files = func() []string {
+				return watchedFiles(c.String(configFlag.Name), c.StringSlice(addTplFlag.Name))
+			}
+
+			if err := configure(ctx, c, &cfg); err != nil {
+				return err
+			}
+
+			// the configuration is built from scratch on reload (the flags and environment variables are applied
+			// on top of the re-read configuration file again)
+			cmd.reload = func(ctx context.Context) (*config.Config, error) {
+				if c.String(configFlag.Name) == "-" {
+					return nil, errors.New("the configuration read from stdin cannot be reloaded")
+				}
+
+				var fresh = config.New()
+
+				if err := configure(ctx, c, &fresh); err != nil {
+					return nil, err
+				}
+
+				return &fresh, nil
+			}
 
 			return cmd.Run(ctx, log, &cfg)
 		},
 		Flags: []cli.Flag{
+			&configFlag,
+			&configSHA256Flag,
+			&configInsecureFlag,
+			&watchIntervalFlag,
 			&addrFlag,
 			&portFlag,
+			&pathPrefixFlag,
 			&addTplFlag,
 			&disableTplFlag,
+			&noMinifyTplFlag,
+			&noCacheTplFlag,
+			&templatePreloadFlag,
 			&addCodeFlag,
+			&routeFlag,
+			&allowMethodsFlag,
+			&authChallengeFlag,
 			&jsonFormatFlag,
+			&jsonSchemaFlag,
 			&xmlFormatFlag,
 			&plainTextFormatFlag,
+			&defaultFormatFlag,
+			&formatOverrideFlag,
+			&unsupportedFormatFlag,
+			&plainTextMaxWidthFlag,
+			&plainTextNormalizationFlag,
+			&jsonMaxSizeFlag,
+			&xmlMaxSizeFlag,
+			&plainTextMaxSizeFlag,
+			&charsetFlag,
 			&templateNameFlag,
 			&disableL10nFlag,
 			&defaultCodeToRenderFlag,
+			&unknownCodeLogIntervalFlag,
 			&sendSameHTTPCodeFlag,
+			&catchAllFlag,
+			&catchAllLogRateFlag,
 			&showDetailsFlag,
+			&detailsNetworksFlag,
+			&detailsTokenFlag,
+			&detailsTokenHeaderFlag,
+			&detailsWasmHookFlag,
+			&showOriginalStatusFlag,
+			&reducedMotionFlag,
+			&printFriendlyFlag,
 			&proxyHeadersListFlag,
+			&templateHeadersFlag,
+			&allowedHostsFlag,
+			&trustedProxiesFlag,
+			&maxProxyHopsFlag,
+			&proxyModeFlag,
+			&originalURIHeaderFlag,
+			&forwardedForHeaderFlag,
 			&rotationModeFlag,
+			&experimentTemplatesFlag,
+			&experimentSplitFlag,
 			&readBufferSizeFlag,
+			&maxRendersFlag,
+			&cacheTenantQuotaFlag,
+			&gcPercentFlag,
+			&memoryLimitFlag,
+			&cacheShrinkAtFlag,
+			&streamThresholdFlag,
+			&bannerFlag,
+			&bannerSeverityFlag,
+			&watermarkFlag,
+			&instanceIDFlag,
+			&timezoneFlag,
+			&renderTimeoutFlag,
+			&templateMaxDepthFlag,
+			&templateMaxIncludesFlag,
+			&templateMaxIterationsFlag,
+			&disableAutoEscapeFlag,
+			&enableAPIFlag,
+			&enableMetricsFlag,
+			&apiTokenFlag,
+			&shadowFlag,
+			&codePrecedenceFlag,
+			&rejectDuplicateHeadersFlag,
+			&maxHeaderValueSizeFlag,
+			&strictCodesFlag,
+			&tlsErrorHeaderFlag,
+			&cspFlag,
+			&earlyHintsFlag,
+			&esiFlag,
+			&unavailableUntilReadyFlag,
+			&signingAlgorithmFlag,
+			&signingKeyFlag,
+			&bodyPreviewSizeFlag,
+			&requestIDFormatFlag,
+			&requestIDHeadersFlag,
+			&requestIDPrefixFlag,
+			&requestIDLayoutFlag,
+			&requestIDCFRayFlag,
+			&datacenterFlag,
+			&datacenterFileFlag,
+			&datacenterMetadataFlag,
+			&upstreamHealthURLFlag,
+			&upstreamHealthIntervalFlag,
+			&upstreamHealthTimeoutFlag,
+			&publishBucketFlag,
+			&publishRegionFlag,
+			&publishEndpointFlag,
+			&publishPrefixFlag,
+			&publishIntervalFlag,
+			&viaPseudonymFlag,
+			&loopMaxRateFlag,
+			&upstreamRecoveryURLFlag,
+			&liveStatusURLFlag,
+			&liveStatusIntervalFlag,
+			&ogImageBaseURLFlag,
+			&featureFlagFlag,
+			&featureFlagsFileFlag,
+			&featureFlagsRedisURLFlag,
+			&featureFlagsRedisKeyFlag,
+			&featureFlagsIntervalFlag,
 			&disableMinificationFlag,
+			&disableCompressionFlag,
+			&keepCommentsFlag,
+			&keepCondCommentsFlag,
+			&keepInlineCSSFlag,
+			&keepInlineJSFlag,
+			&staticDirFlag,
 		},
 	}
 
@@ -313,6 +2384,8 @@ func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen,gocognit,gocy
 
 // Run current command.
 func (cmd *command) Run(ctx context.Context, log *logger.Logger, cfg *config.Config) error {
+	cmd.applyMemory(cfg)
+
 	var srv = appHttp.NewServer(log, cmd.opt.http.readBufferSize)
 
 	if err := srv.Register(cfg); err != nil {
@@ -340,20 +2413,151 @@ func (cmd *command) Run(ctx context.Context, log *logger.Logger, cfg *config.Con
 		}
 	}(startingErrCh)
 
-	// and wait for...
-	select {
-	case err := <-startingErrCh: // ..server starting error
-		return err
+	const shutdownTimeout = 5 * time.Second
+
+	// the configuration is reloaded on the SIGHUP signal, or when the watched files are changed
+	var hup = make(chan os.Signal, 1)
+
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var (
+		changes      <-chan struct{} // nil (never ready) if the watching is disabled
+		stopWatching = func() {}
+		watch        = func() { // (re)starts watching the files (the reloaded configuration may refer to the others)
+			stopWatching()
 
-	case <-ctx.Done(): // ..or context cancellation
-		const shutdownTimeout = 5 * time.Second
+			var watchCtx, cancel = context.WithCancel(ctx)
 
-		log.Info("HTTP server stopping", logger.Duration("with timeout", shutdownTimeout))
+			changes, stopWatching = filewatch.Watch(watchCtx, cmd.opt.watch.interval, cmd.opt.watch.files()...), cancel
+		}
+	)
+
+	defer func() { stopWatching() }()
+
+	if cmd.opt.watch.interval > 0 && cmd.opt.watch.files != nil {
+		watch()
+	}
 
-		if err := srv.Stop(shutdownTimeout); err != nil { //nolint:contextcheck
+	for {
+		// and wait for...
+		select {
+		case err := <-startingErrCh: // ..server starting error
 			return err
+
+		case <-hup: // ..or the reload signal
+			if cmd.reloadConfig(ctx, log, &srv, "signal", shutdownTimeout) && changes != nil {
+				watch()
+			}
+
+		case <-changes: // ..or the watched files changes
+			if cmd.reloadConfig(ctx, log, &srv, "files changed", shutdownTimeout) {
+				watch()
+			}
+
+		case <-ctx.Done(): // ..or context cancellation
+			log.Info("HTTP server stopping", logger.Duration("with timeout", shutdownTimeout))
+
+			return srv.Stop(shutdownTimeout) //nolint:contextcheck
+		}
+	}
+}
+
+// reloadConfig reloads the configuration and applies it to the running server. If the configuration is wrong, the
+// current one is kept. It reports whether the new configuration is applied.
+func (cmd *command) reloadConfig(
+	ctx context.Context,
+	log *logger.Logger,
+	srv *appHttp.Server,
+	reason string,
+	timeout time.Duration,
+) bool {
+	if cmd.reload == nil {
+		return false
+	}
+
+	log.Info("Configuration reloading", logger.String("reason", reason))
+
+	cfg, err := cmd.reload(ctx)
+	if err != nil {
+		log.Error("Cannot reload the configuration, the current one is kept", logger.Error(err))
+
+		return false
+	}
+
+	var reloadCtx, cancel = context.WithTimeout(ctx, timeout) // the previous background services stopping timeout
+	defer cancel()
+
+	if err = srv.Reload(reloadCtx, cfg); err != nil {
+		log.Error("Configuration reloaded with errors", logger.Error(err))
+	} else {
+		cmd.applyMemory(cfg)
+
+		log.Info("Configuration reloaded", logger.Strings("loaded templates", cfg.Templates.Names()...))
+	}
+
+	return true
+}
+
+// applyMemory applies the Go runtime memory tuning. The runtime defaults (including the `GOGC` and `GOMEMLIMIT`
+// environment variables) are used for the values that are not set, so the removed settings are reverted on reload.
+func (cmd *command) applyMemory(cfg *config.Config) {
+	if cmd.runtimeDefaults == nil {
+		var gcPercent = debug.SetGCPercent(100) //nolint:mnd // the only way to read the current value
+
+		debug.SetGCPercent(gcPercent)
+
+		cmd.runtimeDefaults = &struct {
+			gcPercent   int
+			memoryLimit int64
+		}{gcPercent: gcPercent, memoryLimit: debug.SetMemoryLimit(-1)} // a negative limit reads the current one
+	}
+
+	var gcPercent, memoryLimit = cmd.runtimeDefaults.gcPercent, cmd.runtimeDefaults.memoryLimit
+
+	if cfg.Memory.GCPercent != 0 {
+		gcPercent = cfg.Memory.GCPercent
+	}
+
+	if cfg.Memory.Limit > 0 {
+		memoryLimit = int64(cfg.Memory.Limit) << 20 //nolint:gosec,mnd // MiB to bytes
+	}
+
+	debug.SetGCPercent(gcPercent)
+	debug.SetMemoryLimit(memoryLimit)
+}
+
+// watchedFiles returns the local files the configuration is loaded from: the configuration file (unless it's
+// fetched from the URL or read from stdin) with the templates it refers to, and the templates added using the flags.
+func watchedFiles(source string, addedTemplates []string) []string {
+	var files = slices.Clone(addedTemplates)
+
+	if lower := strings.ToLower(source); source == "" || source == "-" ||
+		strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return files
+	}
+
+	files = append(files, source)
+
+	if data, err := os.ReadFile(source); err == nil {
+		if file, fErr := config.ParseFile(data); fErr == nil {
+			for _, path := range file.Templates {
+				files = append(files, path)
+			}
 		}
 	}
 
-	return nil
+	return files
+}
+
+// redactSource removes the credentials and query parameters from the configuration source URL (if it's an URL),
+// so it can be logged safely.
+func redactSource(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" && u.Host != "" {
+		u.User, u.RawQuery, u.Fragment = nil, "", ""
+
+		return u.String()
+	}
+
+	return source
 }
@@ -3,7 +3,11 @@ package serve_test
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -76,6 +80,55 @@ func TestCommand_Run(t *testing.T) {
 	require.True(t, connected, "server is not running")
 }
 
+func TestCommand_RunReload(t *testing.T) {
+	t.Parallel()
+
+	var (
+		port     = getFreeTcpPort(t)
+		cmd      = serve.NewCommand(logger.NewNop())
+		template = filepath.Join(t.TempDir(), "reloaded.html")
+	)
+
+	require.NoError(t, os.WriteFile(template, []byte("<p>before {{ code }}</p>"), 0o600))
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	go func() {
+		_ = cmd.Run(ctx, []string{
+			"serve",
+			"--port", strconv.Itoa(int(port)),
+			"--add-template", template,
+			"--template-name", "reloaded",
+			"--watch-interval", "10ms",
+		})
+	}()
+
+	var render = func() string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/404", port), nil)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "" // not started yet
+		}
+
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return string(body)
+	}
+
+	require.Eventually(t, func() bool { return render() == "<p>before 404</p>" }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(template, []byte("<p>after {{ code }}</p>"), 0o600))
+
+	require.Eventually(t, func() bool { return render() == "<p>after 404</p>" }, 5*time.Second, 10*time.Millisecond)
+}
+
 // getFreeTcpPort is a helper function to get a free TCP port number.
 func getFreeTcpPort(t *testing.T) uint16 {
 	t.Helper()
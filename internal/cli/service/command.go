@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/winsvc"
+)
+
+// NewCommand creates `service` command (Windows only; hidden on other platforms).
+func NewCommand(log *logger.Logger) *cli.Command {
+	var nameFlag = cli.StringFlag{
+		Name:     "name",
+		Usage:    "Windows service name",
+		Value:    winsvc.DefaultName,
+		OnlyOnce: true,
+		Config:   cli.StringConfig{TrimSpace: true},
+	}
+
+	return &cli.Command{
+		Name:   "service",
+		Usage:  "Manage the Windows service (install/uninstall)",
+		Hidden: runtime.GOOS != "windows",
+		Commands: []*cli.Command{
+			{
+				Name:      "install",
+				Usage:     "Register the executable as a Windows service; the arguments are passed to it on start",
+				ArgsUsage: "-- serve [FLAGS]",
+				Action: func(_ context.Context, c *cli.Command) error {
+					var args = c.Args().Slice()
+					if len(args) == 0 {
+						args = []string{"serve"}
+					}
+
+					if err := winsvc.Install(c.String(nameFlag.Name), args...); err != nil {
+						return err
+					}
+
+					log.Info("Service installed", logger.String("name", c.String(nameFlag.Name)), logger.Strings("args", args...))
+
+					return nil
+				},
+				Flags: []cli.Flag{&nameFlag},
+			},
+			{
+				Name:  "uninstall",
+				Usage: "Stop (if running) and remove the Windows service",
+				Action: func(_ context.Context, c *cli.Command) error {
+					if err := winsvc.Uninstall(c.String(nameFlag.Name)); err != nil {
+						return err
+					}
+
+					log.Info("Service removed", logger.String("name", c.String(nameFlag.Name)))
+
+					return nil
+				},
+				Flags: []cli.Flag{&nameFlag},
+			},
+		},
+	}
+}
@@ -17,6 +17,7 @@ const (
 	CategoryCodes     = "HTTP CODES:"
 	CategoryFormats   = "FORMATS:"
 	CategoryBuild     = "BUILD:"
+	CategoryConfig    = "CONFIG:"
 	CategoryOther     = "OTHER:"
 )
 
@@ -62,6 +63,23 @@ var ListenPortFlag = cli.UintFlag{
 	},
 }
 
+var PathPrefixFlag = cli.StringFlag{
+	Name: "path-prefix",
+	Usage: "Mount all the HTTP routes under this path prefix (e.g. '/errors'); the prefix is stripped before the " +
+		"error code extraction, and requests outside of the prefix receive a 404",
+	Sources:  cli.EnvVars("PATH_PREFIX"),
+	Category: CategoryHTTP,
+	OnlyOnce: true,
+	Config:   cli.StringConfig{TrimSpace: true},
+	Validator: func(prefix string) error {
+		if strings.ContainsAny(prefix, " ?#") {
+			return fmt.Errorf("wrong path prefix [%s]", prefix)
+		}
+
+		return nil
+	},
+}
+
 var AddTemplatesFlag = cli.StringSliceFlag{
 	Name: "add-template",
 	Usage: "To add a new template, provide the path to the file using this flag (the filename without the extension " +
@@ -95,15 +113,16 @@ var AddHTTPCodesFlag = cli.StringMapFlag{
 	Name: "add-code",
 	Usage: "To add a new HTTP status code, provide the code and its message/description using this flag (the format " +
 		"should be '%code%=%message%/%description%'; the code may contain a wildcard '*' to cover multiple codes at " +
-		"once, for example, '4**' will cover all 4xx codes unless a more specific code is described previously)",
+		"once, for example, '4**' will cover all 4xx codes unless a more specific code is described previously; a range " +
+		"like '500-504' is accepted as well)",
 	Config:   cli.StringConfig{TrimSpace: true},
 	Category: CategoryCodes,
 	Validator: func(codes map[string]string) error {
 		for code, msgAndDesc := range codes {
 			if code == "" {
 				return fmt.Errorf("missing HTTP code")
-			} else if len(code) != 3 {
-				return fmt.Errorf("wrong HTTP code [%s]: it should be 3 characters long", code)
+			} else if err := config.ValidateCodeKey(code); err != nil {
+				return err
 			}
 
 			if parts := strings.SplitN(msgAndDesc, "/", 3); len(parts) < 1 || len(parts) > 2 {
@@ -155,3 +174,43 @@ var DisableMinificationFlag = cli.BoolFlag{
 	Category: CategoryOther,
 	OnlyOnce: true,
 }
+
+var NoMinifyTemplateFlag = cli.StringSliceFlag{
+	Name:     "no-minify-template",
+	Usage:    "Do not minify the pages rendered from the specified template (by its name; may be specified multiple times)",
+	Sources:  cli.EnvVars("NO_MINIFY_TEMPLATES"),
+	Config:   cli.StringConfig{TrimSpace: true},
+	Category: CategoryTemplates,
+}
+
+var MinifyKeepCommentsFlag = cli.BoolFlag{
+	Name:     "minify-keep-comments",
+	Usage:    "Keep all the HTML comments when minifying HTML pages",
+	Sources:  cli.EnvVars("MINIFY_KEEP_COMMENTS"),
+	Category: CategoryOther,
+	OnlyOnce: true,
+}
+
+var MinifyKeepConditionalCommentsFlag = cli.BoolFlag{
+	Name:     "minify-keep-conditional-comments",
+	Usage:    "Keep the IE conditional comments (<!--[if IE]>...<![endif]-->) when minifying HTML pages",
+	Sources:  cli.EnvVars("MINIFY_KEEP_CONDITIONAL_COMMENTS"),
+	Category: CategoryOther,
+	OnlyOnce: true,
+}
+
+var MinifyKeepInlineCSSFlag = cli.BoolFlag{
+	Name:     "minify-keep-inline-css",
+	Usage:    "Do not minify the inline CSS when minifying HTML pages",
+	Sources:  cli.EnvVars("MINIFY_KEEP_INLINE_CSS"),
+	Category: CategoryOther,
+	OnlyOnce: true,
+}
+
+var MinifyKeepInlineJSFlag = cli.BoolFlag{
+	Name:     "minify-keep-inline-js",
+	Usage:    "Do not minify the inline JS when minifying HTML pages",
+	Sources:  cli.EnvVars("MINIFY_KEEP_INLINE_JS"),
+	Category: CategoryOther,
+	OnlyOnce: true,
+}
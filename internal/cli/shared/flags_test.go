@@ -131,9 +131,10 @@ func TestAddHTTPCodesFlag(t *testing.T) {
 	}{
 		"common": {
 			giveValue: map[string]string{
-				"200": "foo/bar",
-				"404": "foo",
-				"2**": "baz",
+				"200":     "foo/bar",
+				"404":     "foo",
+				"2**":     "baz",
+				"500-504": "qux",
 			},
 		},
 
@@ -153,6 +154,10 @@ func TestAddHTTPCodesFlag(t *testing.T) {
 			giveValue:  map[string]string{"1000": "foo"},
 			wantErrMsg: "wrong HTTP code [1000]: it should be 3 characters long",
 		},
+		"wrong HTTP codes range": {
+			giveValue:  map[string]string{"504-500": "foo"},
+			wantErrMsg: "wrong HTTP codes range [504-500]",
+		},
 		"missing message and description": {
 			giveValue:  map[string]string{"200": "//"},
 			wantErrMsg: "wrong message/description format for HTTP code [200]: //",
@@ -226,3 +231,17 @@ func TestDisableMinificationFlag(t *testing.T) {
 	assert.Equal(t, "disable-minification", flag.Name)
 	assert.Contains(t, flag.Sources.String(), "DISABLE_MINIFICATION")
 }
+
+func TestPathPrefixFlag(t *testing.T) {
+	t.Parallel()
+
+	var flag = shared.PathPrefixFlag
+
+	assert.Equal(t, "path-prefix", flag.Name)
+	assert.Contains(t, flag.Sources.String(), "PATH_PREFIX")
+
+	assert.NoError(t, flag.Validator("/errors/"))
+	assert.NoError(t, flag.Validator(""))
+	assert.Error(t, flag.Validator("/foo bar"))
+	assert.Error(t, flag.Validator("/foo?bar"))
+}
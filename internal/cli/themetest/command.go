@@ -0,0 +1,332 @@
+package themetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/binaryYuki/error-pages/internal/cli/shared"
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	appTemplate "github.com/binaryYuki/error-pages/internal/template"
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+type command struct {
+	c *cli.Command
+
+	opt struct {
+		goldenDir string
+		update    bool
+		codes     []uint16
+		locales   []string
+	}
+}
+
+// RenderTime is the fixed time the templates are rendered at, so the golden files do not depend on the current
+// time.
+var RenderTime = time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC) //nolint:gochecknoglobals
+
+// NewCommand creates `test` command.
+func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen
+	var (
+		cmd command
+		cfg = config.New()
+
+		addTplFlag     = shared.AddTemplatesFlag
+		disableTplFlag = shared.DisableTemplateNamesFlag
+		addCodeFlag    = shared.AddHTTPCodesFlag
+		goldenDirFlag  = cli.StringFlag{
+			Name:     "golden-dir",
+			Aliases:  []string{"dir", "d"},
+			Usage:    "Directory with the golden files (the expected rendered pages, one subdirectory per template)",
+			Value:    filepath.Join("testdata", "golden"),
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			OnlyOnce: true,
+			Validator: func(dir string) error {
+				if dir == "" {
+					return errors.New("missing golden files directory")
+				}
+
+				return nil
+			},
+		}
+		updateFlag = cli.BoolFlag{
+			Name:     "update",
+			Aliases:  []string{"u"},
+			Usage:    "Write the rendered pages to the golden files instead of comparing them (to accept the changes)",
+			Category: shared.CategoryBuild,
+		}
+		codesFlag = cli.StringSliceFlag{
+			Name:     "codes",
+			Usage:    "HTTP codes to render every template with",
+			Value:    []string{"404", "500", "503"},
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			Validator: func(codes []string) error {
+				for _, code := range codes {
+					if _, err := parseCode(code); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+		}
+		localesFlag = cli.StringSliceFlag{
+			Name:     "locales",
+			Usage:    "Locales to render every template with (the server-side localization of the messages)",
+			Value:    []string{l10n.DefaultLocale},
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryBuild,
+			Validator: func(locales []string) error {
+				for _, locale := range locales {
+					if !slices.Contains(l10n.Locales(), l10n.NormalizeLocale(locale)) {
+						return fmt.Errorf("unsupported locale [%s]", locale)
+					}
+				}
+
+				return nil
+			},
+		}
+	)
+
+	cmd.c = &cli.Command{
+		Name:    "test",
+		Aliases: []string{"t"},
+		Usage: "Render the templates with the matrix of codes, locales, and request details settings, and compare " +
+			"them with the golden files",
+		Action: func(ctx context.Context, c *cli.Command) error {
+			cmd.opt.goldenDir = c.String(goldenDirFlag.Name)
+			cmd.opt.update = c.Bool(updateFlag.Name)
+
+			for _, code := range c.StringSlice(codesFlag.Name) {
+				parsed, _ := parseCode(code) // already validated
+
+				cmd.opt.codes = append(cmd.opt.codes, parsed)
+			}
+
+			for _, locale := range c.StringSlice(localesFlag.Name) {
+				cmd.opt.locales = append(cmd.opt.locales, l10n.NormalizeLocale(locale)) // already validated
+			}
+
+			// add templates from files to the configuration
+			for _, templatePath := range c.StringSlice(addTplFlag.Name) {
+				addedName, err := cfg.Templates.AddFromFile(templatePath)
+				if err != nil {
+					return fmt.Errorf("cannot add template from file %s: %w", templatePath, err)
+				}
+
+				log.Info("Template added", logger.String("name", addedName), logger.String("path", templatePath))
+			}
+
+			// disable templates specified by the user
+			for _, templateName := range c.StringSlice(disableTplFlag.Name) {
+				if ok := cfg.Templates.Remove(templateName); ok {
+					log.Info("Template disabled", logger.String("name", templateName))
+				}
+			}
+
+			// add custom HTTP codes to the configuration
+			for code, desc := range shared.ParseHTTPCodes(c.StringMap(addCodeFlag.Name)) {
+				cfg.Codes[code] = desc
+			}
+
+			if len(cfg.Templates) == 0 {
+				return errors.New("no templates specified")
+			}
+
+			return cmd.Run(ctx, log, &cfg)
+		},
+		Flags: []cli.Flag{
+			&addTplFlag,
+			&disableTplFlag,
+			&addCodeFlag,
+			&goldenDirFlag,
+			&updateFlag,
+			&codesFlag,
+			&localesFlag,
+		},
+	}
+
+	return cmd.c
+}
+
+// Run renders every template with every combination of the codes, locales, and request details settings, and
+// compares the pages with the golden files (or updates the golden files).
+func (cmd *command) Run(ctx context.Context, log *logger.Logger, cfg *config.Config) error {
+	var total, mismatched int
+
+	for _, templateName := range cfg.Templates.Names() {
+		var content, _ = cfg.Templates.Get(templateName)
+
+		for _, code := range cmd.opt.codes {
+			for _, locale := range cmd.opt.locales {
+				for _, details := range [...]bool{false, true} {
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+
+					var (
+						props   = goldenProps(cfg, code, locale, details)
+						relPath = GoldenFileName(templateName, code, locale, details)
+						absPath = filepath.Join(cmd.opt.goldenDir, relPath)
+					)
+
+					rendered, err := appTemplate.RenderWith(content, props, appTemplate.Options{
+						Now: func() time.Time { return RenderTime },
+					})
+					if err != nil {
+						return fmt.Errorf("cannot render template '%s' (%s): %w", templateName, relPath, err)
+					}
+
+					total++
+
+					if cmd.opt.update {
+						if err = writeGolden(absPath, []byte(rendered)); err != nil {
+							return err
+						}
+
+						continue
+					}
+
+					if diff, ok := compareGolden(absPath, []byte(rendered)); !ok {
+						mismatched++
+
+						log.Error("Rendered page does not match the golden file",
+							logger.String("template", templateName),
+							logger.String("golden file", absPath),
+							logger.String("diff", diff),
+						)
+					}
+				}
+			}
+		}
+	}
+
+	if cmd.opt.update {
+		log.Info("Golden files updated", logger.String("dir", cmd.opt.goldenDir), logger.Int("files", total))
+
+		return nil
+	}
+
+	if mismatched > 0 {
+		return fmt.Errorf("%d of %d rendered pages do not match the golden files (use --update to accept the changes)",
+			mismatched, total,
+		)
+	}
+
+	log.Info("All rendered pages match the golden files", logger.Int("files", total))
+
+	return nil
+}
+
+// GoldenFileName returns the golden file path relative to the golden files directory (like `ghost/404.en.html`,
+// or `ghost/404.en.details.html` with the request details).
+func GoldenFileName(templateName string, code uint16, locale string, details bool) string {
+	var name = strconv.FormatUint(uint64(code), 10) + "." + locale
+
+	if details {
+		name += ".details"
+	}
+
+	return filepath.Join(templateName, name+".html")
+}
+
+// goldenProps returns the props of the golden page. The request details are fixed, so the pages are reproducible.
+func goldenProps(cfg *config.Config, code uint16, locale string, details bool) appTemplate.Props {
+	var desc, _ = cfg.Codes.Find(code)
+
+	var props = appTemplate.Props{
+		Code:               code,
+		Message:            desc.Message,
+		Description:        desc.Description,
+		Locale:             locale,
+		TextDirection:      l10n.Direction(locale),
+		LangCode:           locale,
+		Charset:            "utf-8",
+		ShowRequestDetails: details,
+	}
+
+	if translated, ok := l10n.Translate(locale, props.Message); ok {
+		props.Message = translated
+	}
+
+	if translated, ok := l10n.Translate(locale, props.Description); ok {
+		props.Description = translated
+	}
+
+	if details {
+		props.RequestID = "AAA-0123456789abcdef"
+		props.Host = "example.com"
+		props.ClientIP = "203.0.113.1"
+		props.AcceptLanguage = locale
+	}
+
+	return props
+}
+
+// writeGolden writes the golden file (creating the directories if needed).
+func writeGolden(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0775)); err != nil { //nolint:mnd
+		return fmt.Errorf("cannot create the golden files directory: %w", err)
+	}
+
+	return os.WriteFile(path, content, os.FileMode(0664)) //nolint:mnd
+}
+
+// compareGolden compares the rendered page with the golden file. If they differ, the short description of the
+// first difference is returned.
+func compareGolden(path string, rendered []byte) (string, bool) {
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		return "the golden file is missing or can't be read: " + err.Error(), false
+	}
+
+	if bytes.Equal(golden, rendered) {
+		return "", true
+	}
+
+	var (
+		want = strings.Split(string(golden), "\n")
+		got  = strings.Split(string(rendered), "\n")
+	)
+
+	for i := range max(len(want), len(got)) {
+		var wantLine, gotLine string
+
+		if i < len(want) {
+			wantLine = want[i]
+		}
+
+		if i < len(got) {
+			gotLine = got[i]
+		}
+
+		if wantLine != gotLine {
+			return fmt.Sprintf("line %d: want %q, got %q", i+1, wantLine, gotLine), false
+		}
+	}
+
+	return "the content differs", false
+}
+
+// parseCode parses the HTTP code (100..599).
+func parseCode(s string) (uint16, error) {
+	code, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("wrong HTTP code [%s]", s)
+	}
+
+	return uint16(code), nil
+}
@@ -0,0 +1,62 @@
+package themetest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/cli/themetest"
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tpl = filepath.Join(t.TempDir(), "golden.html")
+		dir = t.TempDir()
+	)
+
+	require.NoError(t, os.WriteFile(tpl, []byte(
+		"<p lang=\"{{ lang_code }}\">{{ code }}: {{ message }}{{ if show_details }} ({{ request_id }}){{ end }}</p>\n"+
+			"<p>{{ nowUnix }}</p>\n",
+	), 0o600))
+
+	var run = func(args ...string) error {
+		args = append([]string{
+			"test",
+			"--add-template", tpl,
+			"--golden-dir", dir,
+			"--codes", "404,503",
+			"--locales", "en,de",
+		}, args...)
+
+		for _, name := range config.New().Templates.Names() { // the built-in templates are not tested here
+			args = append(args, "--disable-template", name)
+		}
+
+		return themetest.NewCommand(logger.NewNop()).Run(context.Background(), args)
+	}
+
+	require.ErrorContains(t, run(), "8 of 8 rendered pages do not match") // no golden files yet
+
+	require.NoError(t, run("--update"))
+
+	content, err := os.ReadFile(filepath.Join(dir, themetest.GoldenFileName("golden", 404, "de", true)))
+	require.NoError(t, err)
+	assert.Equal(t, "<p lang=\"de\">404: Nicht gefunden (AAA-0123456789abcdef)</p>\n<p>1704164645</p>\n",
+		string(content),
+	)
+
+	require.NoError(t, run()) // the golden files match
+
+	var golden = filepath.Join(dir, themetest.GoldenFileName("golden", 503, "en", false))
+
+	require.NoError(t, os.WriteFile(golden, []byte("<p>changed</p>\n"), 0o600))
+	require.ErrorContains(t, run(), "1 of 8 rendered pages do not match")
+}
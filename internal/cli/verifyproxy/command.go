@@ -0,0 +1,173 @@
+package verifyproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/binaryYuki/error-pages/internal/cli/shared"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/proxycheck"
+)
+
+// NewCommand creates `verify-proxy` command.
+func NewCommand(log *logger.Logger) *cli.Command { //nolint:funlen
+	var (
+		names = integrationNames(proxycheck.Integrations())
+
+		urlFlag = cli.StringFlag{
+			Name:     "url",
+			Usage:    "Base URL of the running error pages instance to verify (including the path prefix, if any)",
+			Value:    "http://127.0.0.1:8080",
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(s string) error {
+				if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("wrong URL [%s]: the absolute HTTP(S) URL is expected", s)
+				}
+
+				return nil
+			},
+		}
+		integrationFlag = cli.StringSliceFlag{
+			Name:     "integration",
+			Usage:    "Integrations to verify (" + strings.Join(names, "/") + ")",
+			Value:    names,
+			Config:   cli.StringConfig{TrimSpace: true},
+			Category: shared.CategoryOther,
+			Validator: func(list []string) error {
+				for _, name := range list {
+					if !slices.Contains(names, name) {
+						return fmt.Errorf("unknown integration [%s] (supported: %s)", name, strings.Join(names, ", "))
+					}
+				}
+
+				return nil
+			},
+		}
+		timeoutFlag = cli.DurationFlag{
+			Name:     "timeout",
+			Usage:    "Timeout of every request to the instance",
+			Value:    5 * time.Second, //nolint:mnd
+			Category: shared.CategoryOther,
+			OnlyOnce: true,
+			Validator: func(d time.Duration) error {
+				if d <= 0 {
+					return errors.New("timeout must be positive")
+				}
+
+				return nil
+			},
+		}
+	)
+
+	return &cli.Command{
+		Name: "verify-proxy",
+		Usage: "Send the requests of the reverse proxy integrations (ingress-nginx, Traefik, and the generic ones) " +
+			"to the running instance and report which integrations are fully honored by its configuration",
+		Action: func(ctx context.Context, c *cli.Command) error {
+			var selected = make([]proxycheck.Integration, 0, len(names))
+
+			for _, integration := range proxycheck.Integrations() {
+				if slices.Contains(c.StringSlice(integrationFlag.Name), integration.Name) {
+					selected = append(selected, integration)
+				}
+			}
+
+			results, err := proxycheck.Verify(ctx,
+				&http.Client{
+					Timeout:       c.Duration(timeoutFlag.Name),
+					CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+				},
+				c.String(urlFlag.Name),
+				selected,
+			)
+			if err != nil {
+				return fmt.Errorf("cannot verify the instance: %w", err)
+			}
+
+			return report(log, selected, results)
+		},
+		Flags: []cli.Flag{
+			&urlFlag,
+			&integrationFlag,
+			&timeoutFlag,
+		},
+	}
+}
+
+// report logs the check results and the summary per integration. An error is returned if any integration is not
+// fully honored.
+func report(log *logger.Logger, integrations []proxycheck.Integration, results []proxycheck.Result) error {
+	var notHonored []string
+
+	for _, integration := range integrations {
+		var failed int
+
+		for _, result := range results {
+			if result.Integration != integration.Name {
+				continue
+			}
+
+			if result.Passed() {
+				log.Debug("Check passed", logger.String("integration", result.Integration), logger.String("check", result.Check))
+
+				continue
+			}
+
+			failed++
+
+			var attrs = []logger.Attr{
+				logger.String("integration", result.Integration),
+				logger.String("check", result.Check),
+				logger.Error(result.Err),
+			}
+
+			if result.Hint != "" {
+				attrs = append(attrs, logger.String("hint", result.Hint))
+			}
+
+			log.Warn("Check failed", attrs...)
+		}
+
+		if failed > 0 {
+			notHonored = append(notHonored, integration.Name)
+
+			log.Warn("Integration is not fully honored",
+				logger.String("integration", integration.Name),
+				logger.Int("failed checks", failed),
+				logger.Int("checks", len(integration.Checks)),
+			)
+		} else {
+			log.Info("Integration is fully honored",
+				logger.String("integration", integration.Name),
+				logger.Int("checks", len(integration.Checks)),
+			)
+		}
+	}
+
+	if len(notHonored) > 0 {
+		return fmt.Errorf("not fully honored integrations: %s", strings.Join(notHonored, ", "))
+	}
+
+	return nil
+}
+
+// integrationNames returns the names of the integrations.
+func integrationNames(integrations []proxycheck.Integration) []string {
+	var names = make([]string, 0, len(integrations))
+
+	for _, integration := range integrations {
+		names = append(names, integration.Name)
+	}
+
+	return names
+}
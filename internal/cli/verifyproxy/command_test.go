@@ -0,0 +1,34 @@
+package verifyproxy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/cli/verifyproxy"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	// the instance that ignores all the conventions
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var run = func(args ...string) error {
+		return verifyproxy.NewCommand(logger.NewNop()).Run(context.Background(), append([]string{"verify-proxy"}, args...))
+	}
+
+	assert.EqualError(t, run("--url", srv.URL), "not fully honored integrations: ingress-nginx, traefik, generic")
+	assert.EqualError(t, run("--url", srv.URL, "--integration", "traefik"), "not fully honored integrations: traefik")
+	assert.ErrorContains(t, run("--url", srv.URL, "--integration", "foo"), "unknown integration [foo]")
+	assert.ErrorContains(t, run("--url", "127.0.0.1"), "wrong URL")
+}
@@ -0,0 +1,58 @@
+package clock_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/clock"
+)
+
+func TestSystem(t *testing.T) {
+	t.Parallel()
+
+	var before = time.Now()
+
+	assert.WithinRange(t, clock.System.Now(), before, time.Now())
+}
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	var (
+		start = time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC)
+		fake  = clock.NewFake(start)
+	)
+
+	assert.Equal(t, start, fake.Now())
+	assert.Equal(t, start.Add(time.Hour), fake.Advance(time.Hour))
+	assert.Equal(t, start.Add(time.Hour), fake.Now())
+
+	fake.Set(start)
+	assert.Equal(t, start, fake.Now())
+
+	assert.True(t, new(clock.Fake).Now().IsZero())
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(2)
+
+		go func() { defer wg.Done(); fake.Advance(time.Second) }()
+		go func() { defer wg.Done(); _ = fake.Now() }()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, start.Add(10*time.Second), fake.Now())
+}
+
+func TestFixed(t *testing.T) {
+	t.Parallel()
+
+	var at = time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC)
+
+	assert.Equal(t, at, clock.Fixed(at).Now())
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type (
+	// AllowRule maps the request path pattern to the list of the allowed HTTP methods (the `Allow` header value for
+	// the 405 responses).
+	AllowRule struct {
+		// Pattern is a regular expression the request path is matched against.
+		Pattern *regexp.Regexp
+
+		// Methods is a list of the allowed HTTP methods (in upper case).
+		Methods []string
+	}
+
+	// AllowRules is an ordered list of the allow rules. The first matched rule wins.
+	AllowRules []AllowRule
+)
+
+// NewAllowRule creates a new allow rule with the given pattern and HTTP methods.
+func NewAllowRule(pattern string, methods ...string) (AllowRule, error) {
+	var rule = AllowRule{Methods: make([]string, 0, len(methods))}
+
+	for _, method := range methods {
+		if method = strings.ToUpper(strings.TrimSpace(method)); method == "" {
+			continue
+		}
+
+		for _, r := range method {
+			if !isTokenChar(r) {
+				return AllowRule{}, fmt.Errorf("allow rule [%s]: wrong HTTP method [%s]", pattern, method)
+			}
+		}
+
+		rule.Methods = append(rule.Methods, method)
+	}
+
+	if len(rule.Methods) == 0 {
+		return AllowRule{}, fmt.Errorf("allow rule [%s]: at least one HTTP method must be specified", pattern)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return AllowRule{}, fmt.Errorf("allow rule [%s]: %w", pattern, err)
+	}
+
+	rule.Pattern = re
+
+	return rule, nil
+}
+
+// ParseAllowRule parses the allow rule in the `PATTERN=METHOD[|METHOD...]` format (e.g. `^/api/=GET|HEAD|POST`).
+// The methods may be also separated by spaces or commas.
+func ParseAllowRule(s string) (AllowRule, error) {
+	var idx = strings.LastIndex(s, "=")
+	if idx < 1 {
+		return AllowRule{}, fmt.Errorf("allow rule [%s]: expected format is PATTERN=METHOD[|METHOD...]", s)
+	}
+
+	return NewAllowRule(s[:idx], strings.FieldsFunc(s[idx+1:], func(r rune) bool {
+		return r == '|' || r == ',' || r == ' '
+	})...)
+}
+
+// Match returns the `Allow` header value for the first rule that matches the given request path.
+func (r AllowRules) Match(path string) (string, bool) {
+	for _, rule := range r {
+		if rule.Pattern != nil && rule.Pattern.MatchString(path) {
+			return strings.Join(rule.Methods, ", "), true
+		}
+	}
+
+	return "", false
+}
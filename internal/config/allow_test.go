@@ -0,0 +1,70 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestParseAllowRule(t *testing.T) {
+	t.Parallel()
+
+	for give, tt := range map[string]struct {
+		wantPattern string
+		wantMethods []string
+		wantErr     bool
+	}{
+		"^/api/=GET|HEAD|post": {wantPattern: "^/api/", wantMethods: []string{"GET", "HEAD", "POST"}},
+		"^/a=b/=GET, HEAD":     {wantPattern: "^/a=b/", wantMethods: []string{"GET", "HEAD"}},
+		".*=GET":               {wantPattern: ".*", wantMethods: []string{"GET"}},
+		"^/api/":               {wantErr: true},
+		"=GET":                 {wantErr: true},
+		"^/api/=":              {wantErr: true},
+		"^/api/=| |":           {wantErr: true},
+		"^/api/=GE(T":          {wantErr: true},
+		"(=GET":                {wantErr: true},
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			var rule, err = config.ParseAllowRule(give)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPattern, rule.Pattern.String())
+			assert.Equal(t, tt.wantMethods, rule.Methods)
+		})
+	}
+}
+
+func TestAllowRules_Match(t *testing.T) {
+	t.Parallel()
+
+	var rules = make(config.AllowRules, 0, 2)
+
+	for _, s := range []string{`^/api/=GET|POST`, `.*=GET|HEAD`} {
+		rule, err := config.ParseAllowRule(s)
+		require.NoError(t, err)
+
+		rules = append(rules, rule)
+	}
+
+	allow, ok := rules.Match("/api/users")
+	assert.True(t, ok)
+	assert.Equal(t, "GET, POST", allow)
+
+	allow, ok = rules.Match("/foo")
+	assert.True(t, ok)
+	assert.Equal(t, "GET, HEAD", allow)
+
+	_, ok = config.AllowRules(nil).Match("/foo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateAuthChallenge checks whether the string is a valid `WWW-Authenticate` challenge (like `Basic
+// realm="example"` or `Bearer`): it should start with an authentication scheme name and contain no line breaks.
+func ValidateAuthChallenge(challenge string) error {
+	if strings.ContainsAny(challenge, "\r\n") {
+		return fmt.Errorf("wrong auth challenge [%q]: line breaks are not allowed", challenge)
+	}
+
+	var scheme, _, _ = strings.Cut(challenge, " ")
+
+	if scheme == "" {
+		return fmt.Errorf("wrong auth challenge [%s]: missing authentication scheme", challenge)
+	}
+
+	for _, r := range scheme {
+		if !isTokenChar(r) {
+			return fmt.Errorf("wrong auth challenge [%s]: invalid authentication scheme [%s]", challenge, scheme)
+		}
+	}
+
+	return nil
+}
+
+// isTokenChar reports whether the rune is allowed in the HTTP token (RFC 9110, section 5.6.2).
+func isTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
@@ -0,0 +1,31 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestValidateAuthChallenge(t *testing.T) {
+	t.Parallel()
+
+	for give, wantErr := range map[string]bool{
+		`Basic realm="example"`: false,
+		`Bearer`:                false,
+		`Bearer realm="api", error="invalid_token"`: false,
+		`Negotiate`:              false,
+		``:                       true,
+		` Basic`:                 true,
+		`Bas(ic realm="example"`: true,
+		"Basic realm=\"example\"\r\nX-Injected: true": true,
+		"Basic realm=\"example\"\nX-Injected: true":   true,
+	} {
+		if err := config.ValidateAuthChallenge(give); wantErr {
+			assert.Error(t, err, give)
+		} else {
+			assert.NoError(t, err, give)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BannerSeverity represents the severity of the outage banner (used by the templates for styling).
+type BannerSeverity byte
+
+const (
+	BannerSeverityInfo     BannerSeverity = iota // informational message, default
+	BannerSeverityWarning                        // degraded service
+	BannerSeverityCritical                       // outage
+)
+
+// String returns a human-readable representation of the banner severity.
+func (s BannerSeverity) String() string {
+	switch s {
+	case BannerSeverityInfo:
+		return "info"
+	case BannerSeverityWarning:
+		return "warning"
+	case BannerSeverityCritical:
+		return "critical"
+	}
+
+	return fmt.Sprintf("BannerSeverity(%d)", s)
+}
+
+// BannerSeverities returns a slice of all banner severities.
+func BannerSeverities() []BannerSeverity {
+	return []BannerSeverity{BannerSeverityInfo, BannerSeverityWarning, BannerSeverityCritical}
+}
+
+// BannerSeverityStrings returns a slice of all banner severities as strings.
+func BannerSeverityStrings() []string {
+	var (
+		severities = BannerSeverities()
+		result     = make([]string, len(severities))
+	)
+
+	for i := range severities {
+		result[i] = severities[i].String()
+	}
+
+	return result
+}
+
+// ParseBannerSeverity parses a banner severity (case is ignored, an empty string means info). If the provided
+// string is invalid, an error is returned.
+func ParseBannerSeverity(s string) (BannerSeverity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case BannerSeverityInfo.String(), "":
+		return BannerSeverityInfo, nil
+	case BannerSeverityWarning.String():
+		return BannerSeverityWarning, nil
+	case BannerSeverityCritical.String():
+		return BannerSeverityCritical, nil
+	}
+
+	return BannerSeverityInfo, fmt.Errorf("unrecognized banner severity: %q", s)
+}
@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestBannerSeverity(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"info", "warning", "critical"}, config.BannerSeverityStrings())
+	assert.Equal(t, "BannerSeverity(255)", config.BannerSeverity(255).String())
+
+	for give, want := range map[string]config.BannerSeverity{
+		"":          config.BannerSeverityInfo,
+		"info":      config.BannerSeverityInfo,
+		" WARNING ": config.BannerSeverityWarning,
+		"Critical":  config.BannerSeverityCritical,
+	} {
+		got, err := config.ParseBannerSeverity(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseBannerSeverity("error")
+	assert.ErrorContains(t, err, "unrecognized banner severity")
+}
@@ -0,0 +1,23 @@
+package config
+
+import "fmt"
+
+// MinBodyLimit is the smallest response body size limit (in bytes), so the minimal error body always fits.
+const MinBodyLimit = 128
+
+// BodyLimits contain the maximum sizes (in bytes) of the response bodies by the format, for the log pipelines and
+// SIEMs that choke on the large error payloads. The longer bodies are shortened with the truncation markers: the
+// JSON string values and XML text nodes are truncated (so the body stays valid), and the plain text is cut. Zero
+// means no limit.
+type BodyLimits struct {
+	JSON, XML, PlainText uint
+}
+
+// ValidateBodyLimit checks that the response body size limit is zero (no limit) or at least the [MinBodyLimit].
+func ValidateBodyLimit(n uint) error {
+	if n != 0 && n < MinBodyLimit {
+		return fmt.Errorf("wrong body size limit [%d]: it should be zero or at least %d bytes", n, MinBodyLimit)
+	}
+
+	return nil
+}
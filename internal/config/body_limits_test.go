@@ -0,0 +1,21 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestValidateBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	for _, give := range []uint{0, config.MinBodyLimit, 1 << 20} {
+		assert.NoError(t, config.ValidateBodyLimit(give), give)
+	}
+
+	for _, give := range []uint{1, config.MinBodyLimit - 1} {
+		assert.Error(t, config.ValidateBodyLimit(give), give)
+	}
+}
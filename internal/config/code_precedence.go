@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CodePrecedence determines which code is used when the request URL and the code header contain different codes.
+type CodePrecedence byte
+
+const (
+	CodePrecedenceURL    CodePrecedence = iota // the code from the URL wins, default
+	CodePrecedenceHeader                       // the code from the header wins
+	CodePrecedenceReject                       // the request is rejected with 400 Bad Request
+)
+
+// String returns a human-readable representation of the code precedence.
+func (p CodePrecedence) String() string {
+	switch p {
+	case CodePrecedenceURL:
+		return "url"
+	case CodePrecedenceHeader:
+		return "header"
+	case CodePrecedenceReject:
+		return "reject"
+	}
+
+	return fmt.Sprintf("CodePrecedence(%d)", p)
+}
+
+// CodePrecedences returns a slice of all code precedences.
+func CodePrecedences() []CodePrecedence {
+	return []CodePrecedence{CodePrecedenceURL, CodePrecedenceHeader, CodePrecedenceReject}
+}
+
+// CodePrecedenceStrings returns a slice of all code precedences as strings.
+func CodePrecedenceStrings() []string {
+	var (
+		precedences = CodePrecedences()
+		result      = make([]string, len(precedences))
+	)
+
+	for i := range precedences {
+		result[i] = precedences[i].String()
+	}
+
+	return result
+}
+
+// ParseCodePrecedence parses a code precedence (case is ignored, an empty string means url). If the provided
+// string is invalid, an error is returned.
+func ParseCodePrecedence(s string) (CodePrecedence, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case CodePrecedenceURL.String(), "":
+		return CodePrecedenceURL, nil
+	case CodePrecedenceHeader.String():
+		return CodePrecedenceHeader, nil
+	case CodePrecedenceReject.String():
+		return CodePrecedenceReject, nil
+	}
+
+	return CodePrecedenceURL, fmt.Errorf("unrecognized code precedence: %q", s)
+}
@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestCodePrecedence(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"url", "header", "reject"}, config.CodePrecedenceStrings())
+	assert.Equal(t, "CodePrecedence(255)", config.CodePrecedence(255).String())
+
+	for give, want := range map[string]config.CodePrecedence{
+		"":         config.CodePrecedenceURL,
+		"url":      config.CodePrecedenceURL,
+		" Header ": config.CodePrecedenceHeader,
+		"REJECT":   config.CodePrecedenceReject,
+	} {
+		got, err := config.ParseCodePrecedence(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseCodePrecedence("route")
+	assert.ErrorContains(t, err, "unrecognized code precedence")
+}
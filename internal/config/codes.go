@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 )
 
 type (
@@ -12,6 +14,16 @@ type (
 
 		// Description is a longer description of the HTTP error.
 		Description string
+
+		// Localized holds the per-locale overrides of the message and description (the key is a locale name,
+		// e.g. `de`). Empty override fields are translated using the built-in catalog as usual.
+		Localized map[string]LocalizedDescription
+	}
+
+	// LocalizedDescription is the localized message and description of the HTTP error.
+	LocalizedDescription struct {
+		Message     string
+		Description string
 	}
 
 	// Codes is a map of HTTP codes to their descriptions.
@@ -22,12 +34,16 @@ type (
 	// the value under the key "4xx" will be retrieved.
 	//
 	// The length of the code (in string format) is matter.
+	//
+	// The key may also be an inclusive range of codes, like "500-504". When several keys match the code, the most
+	// specific one wins: the key that covers fewer codes ("40x" over "4xx", "500-504" over "5xx"), then the key with
+	// the longer fixed prefix, and then the alphabetically first key - so the result never depends on the map order.
 	Codes map[string]CodeDescription // map[http_code]description
 )
 
 // Find searches the closest match for the given HTTP code, written in a non-strict manner. Read [Codes] for more
 // information.
-func (c Codes) Find(httpCode uint16) (CodeDescription, bool) { //nolint:gocyclo
+func (c Codes) Find(httpCode uint16) (CodeDescription, bool) {
 	if len(c) == 0 { // empty map, fast return
 		return CodeDescription{}, false
 	}
@@ -39,71 +55,143 @@ func (c Codes) Find(httpCode uint16) (CodeDescription, bool) { //nolint:gocyclo
 	}
 
 	var (
-		keysMap   = make(map[string][]rune, len(c))
-		codeRunes = []rune(code)
+		best      string
+		bestMatch codeMatch
+		found     bool
 	)
 
-	for key := range c { // take only the keys that are the same length and start with the same character or a wildcard
-		if kr := []rune(key); len(kr) > 0 && len(kr) == len(codeRunes) && isWildcardOr(kr[0], codeRunes[0]) {
-			keysMap[key] = kr
+	for key := range c {
+		m, ok := matchCodeKey(key, code, httpCode)
+		if !ok {
+			continue
+		}
+
+		if !found || m.moreSpecific(bestMatch) || (m == bestMatch && key < best) {
+			best, bestMatch, found = key, m, true
 		}
 	}
 
-	if len(keysMap) == 0 { // no matches found using the first rune comparison
+	if !found {
 		return CodeDescription{}, false
 	}
 
-	var matchedMap = make(map[string]uint16, len(keysMap)) // map[mapKey]wildcardMatchedCount
+	return c[best], true
+}
 
-	for mapKey, keyRunes := range keysMap { // search for the closest match
-		var wildcardMatchedCount uint16 = 0
+// codeMatch describes how specific the matched key is.
+type codeMatch struct {
+	covers uint64 // the number of codes the key covers
+	prefix int    // the length of the fixed leading part of the key
+}
 
-		for i := 0; i < len(codeRunes); i++ { // loop through each httpCode rune
-			var keyRune, codeRune = keyRunes[i], codeRunes[i]
+// moreSpecific reports whether m is more specific than other: the key covering fewer codes wins, and the longest
+// fixed prefix breaks the tie.
+func (m codeMatch) moreSpecific(other codeMatch) bool {
+	if m.covers != other.covers {
+		return m.covers < other.covers
+	}
 
-			if wm := isWildcard(keyRune); wm || keyRune == codeRune {
-				if wm {
-					wildcardMatchedCount++
-				}
+	return m.prefix > other.prefix
+}
 
-				if i == len(codeRunes)-1 { // is the last rune?
-					matchedMap[mapKey] = wildcardMatchedCount
-				}
+// matchCodeKey checks if the key (a wildcard pattern or a range) matches the given code.
+func matchCodeKey(key, code string, httpCode uint16) (codeMatch, bool) {
+	if lo, hi, isRange := parseCodeRange(key); isRange {
+		if httpCode < lo || httpCode > hi {
+			return codeMatch{}, false
+		}
 
-				continue
-			}
+		var (
+			from, to = strconv.FormatUint(uint64(lo), 10), strconv.FormatUint(uint64(hi), 10)
+			prefix   int
+		)
 
-			break
+		for len(from) == len(to) && prefix < len(from) && from[prefix] == to[prefix] {
+			prefix++
 		}
+
+		return codeMatch{covers: uint64(hi-lo) + 1, prefix: prefix}, true
 	}
 
-	if len(matchedMap) == 0 { // no matches found
-		return CodeDescription{}, false
-	} else if len(matchedMap) == 1 { // only one match found
-		for mapKey := range matchedMap {
-			return c[mapKey], true
+	var keyRunes, codeRunes = []rune(key), []rune(code)
+
+	if len(keyRunes) == 0 || len(keyRunes) != len(codeRunes) {
+		return codeMatch{}, false
+	}
+
+	var m = codeMatch{covers: 1, prefix: -1}
+
+	for i, keyRune := range keyRunes {
+		if isWildcard(keyRune) {
+			if m.prefix < 0 {
+				m.prefix = i
+			}
+
+			m.covers *= 10 //nolint:mnd
+
+			continue
+		}
+
+		if keyRune != codeRunes[i] {
+			return codeMatch{}, false
 		}
 	}
 
-	// multiple matches found, find the most specific one based on the wildcard matched count (pick the one with the
-	// least wildcards)
-	var (
-		minCount uint16
-		key      string
-	)
+	if m.prefix < 0 {
+		m.prefix = len(keyRunes)
+	}
+
+	return m, true
+}
+
+// parseCodeRange parses the range key (e.g. "500-504"). The last return value is false if the key is not a range.
+func parseCodeRange(key string) (lo, hi uint16, _ bool) {
+	from, to, ok := strings.Cut(key, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	l, lErr := strconv.ParseUint(strings.TrimSpace(from), 10, 16)
+	h, hErr := strconv.ParseUint(strings.TrimSpace(to), 10, 16)
+
+	if lErr != nil || hErr != nil || l > h {
+		return 0, 0, false
+	}
+
+	return uint16(l), uint16(h), true
+}
 
-	for mapKey, count := range matchedMap {
-		if minCount == 0 || count < minCount {
-			minCount, key = count, mapKey
+// ValidateCodeKey checks if the key is a valid HTTP code, a pattern (e.g. "4xx"), or a range (e.g. "500-504").
+func ValidateCodeKey(key string) error {
+	if strings.Contains(key, "-") {
+		if _, _, ok := parseCodeRange(key); !ok {
+			return fmt.Errorf("wrong HTTP codes range [%s]: it should be two codes, the first not above the second", key)
 		}
+
+		return nil
+	}
+
+	if len(key) != 3 { //nolint:mnd
+		return fmt.Errorf("wrong HTTP code [%s]: it should be 3 characters long", key)
 	}
 
-	return c[key], true
+	return nil
 }
 
 func isWildcard(r rune) bool       { return r == '*' || r == 'x' || r == 'X' }
 func isWildcardOr(r, or rune) bool { return isWildcard(r) || r == or }
 
+// Localize returns the overrides for the given locale (if any).
+func (d CodeDescription) Localize(locale string) (LocalizedDescription, bool) {
+	if len(d.Localized) == 0 || locale == "" {
+		return LocalizedDescription{}, false
+	}
+
+	l, ok := d.Localized[locale]
+
+	return l, ok
+}
+
 // Codes returns all HTTP codes sorted alphabetically.
 func (c Codes) Codes() []string {
 	var codes = make([]string, 0, len(c))
@@ -66,6 +66,21 @@ func TestCodes_Find(t *testing.T) {
 		"*":   {Message: "Single"},
 	}
 
+	var overlap = config.Codes{
+		"503":     {Message: "Exact"},
+		"50x":     {Message: "Single wildcard"},
+		"510-519": {Message: "Narrow range"},
+		"500-549": {Message: "Wide range"},
+		"5**":     {Message: "Multi wildcard"},
+	}
+
+	var tie = config.Codes{
+		"4x4": {Message: "4x4"},
+		"4X4": {Message: "4X4"},
+		"42x": {Message: "42x"},
+		"4*4": {Message: "4*4"},
+	}
+
 	for name, tt := range map[string]struct {
 		giveCodes config.Codes
 		giveCode  uint16
@@ -111,6 +126,15 @@ func TestCodes_Find(t *testing.T) {
 		"ladder - strict single match": {giveCodes: ladder, giveCode: 1, wantMessage: "Full single"},
 		"ladder - single wildcard":     {giveCodes: ladder, giveCode: 2, wantMessage: "Single"},
 
+		"range - exact code beats range":  {giveCodes: overlap, giveCode: 503, wantMessage: "Exact"},
+		"range - single wildcard beats":   {giveCodes: overlap, giveCode: 502, wantMessage: "Single wildcard"},
+		"range - narrow range beats":      {giveCodes: overlap, giveCode: 510, wantMessage: "Narrow range"},
+		"range - range beats multi":       {giveCodes: overlap, giveCode: 520, wantMessage: "Wide range"},
+		"range - multi-wildcard fallback": {giveCodes: overlap, giveCode: 599, wantMessage: "Multi wildcard"},
+		"range - out of any key":          {giveCodes: overlap, giveCode: 600, wantNotFound: true},
+		"tie - longer prefix wins":        {giveCodes: tie, giveCode: 424, wantMessage: "42x"},
+		"tie - alphabetically first wins": {giveCodes: tie, giveCode: 434, wantMessage: "4*4"},
+
 		"empty map": {giveCodes: config.Codes{}, giveCode: 404, wantNotFound: true},
 		"zero code": {giveCodes: common, giveCode: 0, wantNotFound: true},
 	} {
@@ -129,3 +153,15 @@ func TestCodes_Find(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCodeKey(t *testing.T) {
+	t.Parallel()
+
+	for _, key := range []string{"404", "4xx", "4**", "500-504", "500 - 504", "404-404"} {
+		assert.NoError(t, config.ValidateCodeKey(key), key)
+	}
+
+	for _, key := range []string{"", "40", "4040", "504-500", "500-", "-504", "foo-bar"} {
+		assert.Error(t, config.ValidateCodeKey(key), key)
+	}
+}
@@ -3,8 +3,12 @@ package config
 import (
 	"maps"
 	"net/http"
+	"net/netip"
 	"slices"
+	"strings"
+	"time"
 
+	"github.com/binaryYuki/error-pages/internal/featureflag"
 	builtinTemplates "github.com/binaryYuki/error-pages/templates"
 )
 
@@ -19,8 +23,44 @@ type Config struct {
 		JSON      string
 		XML       string
 		PlainText string
+
+		// Unsupported is the plain text response used when the template of the requested format (and the plain
+		// text one) is empty. Go templates (with the same tokens) are supported.
+		Unsupported string
+
+		// Fragment is the minimal HTML fragment (without the `html`, `head`, and `body` tags), responded to the
+		// `/fragment/{code}` requests, so the proxies may inject the error content into an existing page shell
+		// using SSI or ESI. Go templates (with the same tokens) are supported.
+		Fragment string
+	}
+
+	// DefaultFormat is the response format used when the client does not specify a supported one (e.g. there is no
+	// `Accept` header, or it's just `*/*`).
+	DefaultFormat Format
+
+	// PlainTextOutput contains the post-processing of the plain text format output (and the unsupported format
+	// fallback) for the terminal clients and SMS gateways.
+	PlainTextOutput struct {
+		// MaxLineWidth is the maximum line width in the terminal columns (the wide characters, like CJK or emoji,
+		// take two); the longer lines are truncated with an ellipsis. Zero means no limit.
+		MaxLineWidth uint
+
+		// Normalization is the normalization of the output characters.
+		Normalization TextNormalization
 	}
 
+	// BodyLimits contain the maximum sizes of the JSON, XML, and plain text response bodies.
+	BodyLimits BodyLimits
+
+	// Charset is the character set of the HTML and plain text responses (like `GBK` or `ISO-8859-1`, for the legacy
+	// clients). The output is transcoded from UTF-8, and the characters that cannot be represented are replaced. An
+	// empty string means UTF-8. The JSON and XML responses are always sent in UTF-8.
+	Charset string
+
+	// FormatRules force the response format for the clients with the matching `User-Agent` header. They are
+	// evaluated before the `Accept` (and similar) headers, so the health checkers and SDKs do not receive the HTML.
+	FormatRules FormatRules
+
 	// Codes hold descriptions for HTTP codes (e.g., 404: "Not Found / The server can not find the requested page").
 	Codes Codes
 
@@ -32,16 +72,108 @@ type Config struct {
 	// error page response.
 	ProxyHeaders []string
 
+	// TemplateHeaders is an allowlist of the request headers available to the templates using the `header`
+	// function (like `{{ header "X-Tenant" }}`). The pages are cached separately for each combination of the
+	// allowlisted headers values.
+	TemplateHeaders []string
+
+	// AllowedHosts contains a list of the `Host` header values that are allowed to be served (the port is ignored,
+	// and a leading wildcard like `*.example.com` matches any subdomain). Requests with other hosts will receive a
+	// minimal hardcoded response. An empty list means that any host is allowed.
+	AllowedHosts []string
+
+	// AuthChallenges contains a list of the `WWW-Authenticate` challenges (like `Basic realm="example"` or `Bearer`)
+	// sent with the 401 responses, so the clients' authentication flows keep working when this service stands in
+	// for the auth gateway.
+	AuthChallenges []string
+
+	// AllowRules maps the request path patterns to the `Allow` header values of the 405 responses (the path is
+	// taken from the `X-Original-URI` header if present). The `Allow` request header (if set by the upstream) takes
+	// precedence over these rules.
+	AllowRules AllowRules
+
+	// ClientIP contains settings for the client IP address extraction (used for logging and the `client_ip` token).
+	ClientIP struct {
+		// TrustedProxies is a list of the trusted proxy addresses. The `X-Forwarded-For` header is taken into
+		// account only for requests coming from these addresses. An empty list means the header is ignored.
+		TrustedProxies []netip.Prefix
+
+		// MaxHops limits the number of the `X-Forwarded-For` entries to walk (0 means no limit).
+		MaxHops uint
+	}
+
+	// Proxy contains settings of the reverse proxy integration (the names of the request headers with the details
+	// of the original request, used for the `original_uri` and `forwarded_for` tokens and the allow rules).
+	Proxy Proxy
+
+	// RequestIDFormat is the format of the generated request IDs (the upstream request IDs are used as-is).
+	RequestIDFormat RequestIDFormat
+
+	// RequestID contains settings of the upstream request ID headers, and the layout of the generated request IDs
+	// (overriding the format).
+	RequestID RequestID
+
+	// Datacenter contains the sources of the datacenter code (used in the generated request IDs and the
+	// `datacenter` token). The first configured source wins: the code, the file, or the cloud metadata service.
+	Datacenter struct {
+		// Code is the datacenter code (e.g. `FRA1`). After the startup, it holds the resolved code.
+		Code string
+
+		// File is a path to the file with the datacenter code.
+		File string
+
+		// Metadata is the name of the cloud metadata service to query (`ec2` or `gcp`).
+		Metadata string
+	}
+
 	// L10n contains localization settings.
 	L10n struct {
 		// Disable the localization of error pages.
 		Disable bool
 	}
 
+	// Accessibility contains the settings of the accessibility-related template variants (the `reduced_motion` and
+	// `print_friendly` tokens).
+	Accessibility struct {
+		// ReducedMotion determines whether the templates are asked to disable (or reduce) the animations. With the
+		// [MotionPreferenceAuto], the `Sec-CH-Prefers-Reduced-Motion` client hint is requested from the browsers and
+		// honored on the server side, so the animations are disabled even before the styles are applied.
+		ReducedMotion MotionPreference
+
+		// PrintFriendly asks the templates to include the print styles (no decorations and animations, the dark
+		// text on the white background).
+		PrintFriendly bool
+	}
+
 	// DefaultCodeToRender is the code for the default error page to be displayed. It is used when the requested
 	// code is not defined in the incoming request (i.e., the code to render as the index page).
 	DefaultCodeToRender uint16
 
+	// RequestHeaders contains the hardening settings for the request headers used to select the error page (the
+	// code, format, and error kind), so the fuzzed traffic can't produce surprising renders.
+	RequestHeaders struct {
+		// CodePrecedence determines which code is used when the URL and the `X-Code` header codes differ.
+		CodePrecedence CodePrecedence
+
+		// RejectDuplicates rejects the requests with the repeated headers having different values (otherwise, the
+		// first value is used).
+		RejectDuplicates bool
+
+		// MaxValueSize is the maximum length of the header value (in bytes), the requests with longer values are
+		// rejected with 431 Request Header Fields Too Large (zero means no limit).
+		MaxValueSize uint
+
+		// StrictCodes accepts only the canonical codes in the URL (like `/404` or `/404.html`) and the `X-Code`
+		// header (three digits in the 100..599 range), otherwise the encoded paths, path parameters, and padded
+		// header values are normalized.
+		StrictCodes bool
+	}
+
+	// UnknownCodeLogInterval limits the logging of the requests with the unknown codes (not defined in the codes
+	// and the standard library) or the invalid code header (like `X-Code: 0`) to one entry per interval (zero
+	// disables the logging, but such requests are still counted in the metrics).
+	UnknownCodeLogInterval time.Duration
+
 	// RespondWithSameHTTPCode determines whether the response should have the same HTTP status code as the requested
 	// error page.
 	// In other words, if set to true and the requested error page has a code of 404, the HTTP response will also have
@@ -53,19 +185,310 @@ type Config struct {
 	// on each request, daily, hourly and so on.
 	RotationMode RotationMode
 
+	// Experiment contains settings for the A/B experiment (used with the [RotationModeExperiment] only).
+	Experiment struct {
+		// Templates are the names of the two templates (variants) to split the traffic between.
+		Templates [2]string
+
+		// Split is a share (in percent, 0..100) of the traffic that receives the second template.
+		Split uint8
+	}
+
+	// Timezone is the IANA name of the timezone (like `Europe/Berlin`) used by the date and time template functions
+	// by default.
+	Timezone string
+
 	// ShowDetails determines whether to show additional details in the error response, extracted from the
 	// incoming request (if supported by the template).
 	ShowDetails bool
 
+	// DetailsAccess limits the requests the details are shown to (when the ShowDetails is enabled).
+	DetailsAccess DetailsAccess
+
+	// ShowOriginalStatus includes the code from the `X-Original-Status` request header (set by the proxy in front
+	// of the service, which may rewrite the upstream code) into the default JSON and XML payloads. The code is
+	// available as the `original_status` token anyway.
+	ShowOriginalStatus bool
+
+	// BodyPreviewSize is the maximum number of the request body bytes exposed (sanitized) as the `body_preview`
+	// token, which is useful for the internal error backends only. Zero (the default) disables the body preview.
+	BodyPreviewSize uint
+
+	// CatchAll contains settings for the "default backend" mode, when the server receives any unmatched request.
+	CatchAll struct {
+		// Enabled means that any request without a code (in the URL or headers) renders the 404 error page
+		// instead of the default one, and the `Retry-After` header is never sent.
+		Enabled bool
+
+		// LogSampleRate is a fraction (0..1) of the unmatched request paths to log (0 means nothing is logged).
+		LogSampleRate float64
+	}
+
+	// Banner is the outage banner shown by the templates (the `banner` and `banner_severity` tokens). It can be
+	// changed at runtime using the management API.
+	Banner struct {
+		// Message is a freeform text of the banner (an empty string means no banner).
+		Message string
+
+		// Severity is the banner severity (used for styling).
+		Severity BannerSeverity
+	}
+
+	// Watermark contains the settings of the HTML pages watermarking with the instance ID, the time, and the
+	// request ID (so the screenshots of the pages can be traced to the serving instance and time).
+	Watermark struct {
+		// Mode is the way the pages are watermarked (none by default).
+		Mode WatermarkMode
+
+		// InstanceID identifies the serving instance (the host name is used if empty).
+		InstanceID string
+	}
+
+	// Maintenance is a list of the scheduled maintenance windows. During the window, the matched requests receive
+	// the maintenance page (with the `maintenance_start` and `maintenance_end` tokens set).
+	Maintenance MaintenanceWindows
+
+	// UpstreamHealth contains settings for the upstream health probe. When the URL is set, it is polled in the
+	// background, and the result is exposed as the `upstream_healthy` and `upstream_checked_at` tokens.
+	UpstreamHealth struct {
+		// URL is the upstream health endpoint (any 2xx or 3xx response means healthy).
+		URL string
+
+		// Interval is the time between the checks.
+		Interval time.Duration
+
+		// Timeout limits a single check (capped by the interval).
+		Timeout time.Duration
+
+		// RecoveryURL is the URL of the `/check` endpoint as seen by the browsers (e.g. `/check` or
+		// `https://errors.example.com/check`). When set, the HTML 503 pages poll it and reload once the upstream
+		// is healthy again.
+		RecoveryURL string
+	}
+
+	// LiveStatus contains settings of the live status updates of the already open HTML pages.
+	LiveStatus LiveStatus
+
+	// OGImageBaseURL is the public URL of the server as seen by the browsers (with the path prefix, if any), used to
+	// build the absolute URLs of the `/og/{code}.png` social preview images for the `og_image` template token. An
+	// empty string omits the image.
+	OGImageBaseURL string
+
+	// FeatureFlags contains settings of the feature flags, available to the templates using the `flag` and `flagOn`
+	// functions (like `{{ if flagOn "new_503_copy" }}`), so the copy and theme changes can be rolled out without
+	// redeploying the configuration. The in-memory values are overridden by the file ones, and those by the Redis
+	// ones. The `template` flag (if set to a known template name) overrides the HTML template, unless the route
+	// sets it.
+	FeatureFlags struct {
+		// Values are the in-memory flags (map[name]value). The percentage values (like `25%`) roll the flag out to
+		// the share of the clients (by the client IP address).
+		Values map[string]string
+
+		// File is the path to the YAML (or JSON) file with the flags (empty disables the file).
+		File string
+
+		// RedisURL is the URL of the Redis server with the flags hash, like `redis://:password@host:6379/0` (empty
+		// disables Redis).
+		RedisURL string
+
+		// RedisKey is the key of the Redis hash with the flags.
+		RedisKey string
+
+		// Interval is the time between the reloads of the file and Redis flags.
+		Interval time.Duration
+	}
+
+	// Publish contains settings for the publisher, which uploads the rendered HTML pages of the active template to
+	// the object storage bucket on startup and whenever they change (e.g. after the template rotation), so a static
+	// fallback (like the S3 website or CDN error pages) stays in sync with the live service.
+	Publish struct {
+		// Bucket is the Amazon S3 (or S3-compatible) bucket name. An empty string disables the publisher.
+		Bucket string
+
+		// Region is the bucket region (empty means `us-east-1`).
+		Region string
+
+		// Endpoint is the custom S3-compatible endpoint URL (empty means the AWS one).
+		Endpoint string
+
+		// Prefix is the bucket key prefix of the published pages (e.g. `errors`, without slashes).
+		Prefix string
+
+		// Interval is the time between the checks whether the active template has changed (the pages are not
+		// rendered again until it changes).
+		Interval time.Duration
+	}
+
+	// LoopGuard contains settings for the proxy loop detection. The requests carrying the `X-Error-Pages` marker
+	// (set on every error page response) are always rejected; these checks are optional.
+	LoopGuard struct {
+		// Pseudonym is the name of this service in the `Via` header chains; the requests that have already passed
+		// through it are rejected (empty disables the check).
+		Pseudonym string
+
+		// MaxRate is the maximum number of requests per second from the same client for the same code (zero means
+		// no limit).
+		MaxRate uint
+	}
+
+	// Memory contains the Go runtime memory tuning for the small containers (zero values keep the runtime defaults,
+	// including the `GOGC` and `GOMEMLIMIT` environment variables).
+	Memory struct {
+		// GCPercent is the garbage collection target percentage (like `GOGC`); negative disables the GC until the
+		// memory limit is reached.
+		GCPercent int
+
+		// Limit is the soft memory limit of the runtime in MiB (like `GOMEMLIMIT`).
+		Limit uint
+
+		// CacheShrinkAt is the heap size in MiB, above which the rendered pages cache is purged proactively (zero
+		// disables the check).
+		CacheShrinkAt uint
+	}
+
+	// TemplateOptions are the per-template options (by the template name), like opting out of the minification or
+	// caching.
+	TemplateOptions map[string]TemplateOptions
+
+	// ErrorKinds are the named business errors (like `quota_exceeded`), selected using the `X-Error-Kind` request
+	// header. Each kind is mapped to the HTTP code and may override the message, description, and template.
+	ErrorKinds ErrorKinds
+
+	// Profiles are the named canned responses (like `blocked-by-waf`), selected using the `X-Error-Profile` request
+	// header or the `/profile/{name}` path. Each profile may set the code, template, format, extra response
+	// headers, and token overrides.
+	Profiles Profiles
+
+	// TLSErrors contains the settings of the TLS (and protocol-level) failures, reported by the terminating proxy
+	// (like an expired client certificate, or an unsupported protocol version) in the request header.
+	TLSErrors struct {
+		// Header is the request header name with the TLS error (empty disables the feature).
+		Header string
+
+		// Kinds maps the TLS error names (like `certificate_expired`) to the HTTP codes, messages, and templates.
+		Kinds ErrorKinds
+	}
+
+	// Routes is a table of the request path patterns mapped to the HTTP codes and/or templates. It is evaluated
+	// before the code extraction from the URL, so the matched paths (e.g. `/old-api/.*`) get the configured code.
+	Routes Routes
+
+	// PathPrefix is the path prefix all the HTTP routes are mounted under (e.g. `/errors`, without a trailing
+	// slash). The prefix is stripped before the routing. An empty string means no prefix.
+	PathPrefix string
+
+	// EnableAPI enables the management API endpoints (`/api/...`), like the template rotation state or the outage
+	// banner. The API is not authenticated without the [Config.APIToken], so it's disabled by default.
+	EnableAPI bool
+
+	// EnableMetrics enables the Prometheus metrics endpoint (`/metrics`), exposing the served error pages by code
+	// and format, the rendered pages cache hits and misses, the render latency, etc.
+	EnableMetrics bool
+
+	// APIToken is the bearer token required by the management API endpoints (empty means no authentication). The
+	// debugging endpoints, like the template props of the synthetic request, are available only with the token.
+	APIToken string
+
+	// Shadow enables the "dry-run" mode: the rendering decision (code, format, template, cache hit) is logged, and
+	// an empty 204 response is sent instead of the content. It's useful to validate a new configuration or theme
+	// behind a traffic mirror before the cutover.
+	Shadow bool
+
+	// ContentSecurityPolicy is the `Content-Security-Policy` header value sent with the HTML pages (empty means the
+	// header is not sent). The `{nonce}` placeholders are replaced with the per-response nonce, which is also
+	// available as the `csp_nonce` token for the inline scripts and styles.
+	ContentSecurityPolicy string
+
+	// EarlyHints enables the `103 Early Hints` responses with the preload links of the template assets (see
+	// [TemplateOptions.Preload]), sent before the page is rendered. The links are sent with the final response
+	// anyway, but some older HTTP/1.1 clients cannot handle the informational responses, so it's disabled by default.
+	EarlyHints bool
+
+	// ESI enables the Edge Side Includes: the `esiInclude` template function emits the `<esi:include>` tags, the
+	// minifier keeps them valid, and the HTML responses carry the `Surrogate-Control: content="ESI/1.0"` header,
+	// so the ESI processors (like Varnish or Fastly) compose the error page from the cached fragments.
+	ESI bool
+
+	// UnavailableUntilReady makes the service answer every request with the 503 error page (instead of the
+	// detected or default code) while it is not ready yet (e.g. until the first upstream health check completes),
+	// so the load balancers do not route the traffic to it. It flips back automatically once the service is ready.
+	UnavailableUntilReady bool
+
+	// Signing contains settings for the response body signing, so the downstream proxies or clients can verify
+	// the error body was produced by the error pages and not tampered with in transit.
+	Signing struct {
+		// Algorithm is the signing algorithm ([SigningNone] disables the signing).
+		Algorithm SigningAlgorithm
+
+		// Key is the shared secret for HMAC-SHA256, or the base64-encoded Ed25519 seed (32 bytes) or private key
+		// (64 bytes).
+		Key string
+	}
+
+	// StaticDir is a path to the directory with the pre-built (using the `build` command) error pages. If set, these
+	// pages are served as-is, without any templating at runtime. The pages are loaded from the [Config.TemplateName]
+	// subdirectory if it exists (the `build` command output layout), or from the directory itself.
+	StaticDir string
+
+	// MaxConcurrentRenders limits the number of templates rendered at the same time (zero means no limit). When
+	// the limit is reached, a minimal response without templating is sent instead of waiting (the cached content
+	// is still served as usual).
+	MaxConcurrentRenders uint
+
+	// StreamThreshold is the size (in bytes) of the HTML template, above which the rendered page is streamed to the
+	// client in chunks instead of being built in memory as a whole (zero disables the streaming). The streamed pages
+	// are neither minified nor cached.
+	StreamThreshold uint
+
+	// CacheTenantQuota limits the number of the rendered pages cached per tenant (zero means no limit). When the
+	// allowed hosts are configured, every entry of the list is a separate tenant (otherwise, there is a single one),
+	// so one noisy domain cannot evict the cached pages of the others.
+	CacheTenantQuota uint
+
+	// TemplateLimits restricts the resources a single template render may consume (zero values mean no limit), so
+	// an uploaded or remote template cannot block the renderer.
+	TemplateLimits struct {
+		// RenderTimeout is the maximum duration of a single render.
+		RenderTimeout time.Duration
+
+		// MaxDepth is the maximum nesting of the `{{ template "name" }}` calls (recursive calls are rejected).
+		MaxDepth uint
+
+		// MaxIncludes is the maximum number of the `{{ template "name" }}` actions in a template.
+		MaxIncludes uint
+
+		// MaxIterations is the maximum total number of the `{{ range }}` loop iterations in a single render.
+		MaxIterations uint
+	}
+
+	// DisableAutoEscape disables the context-aware escaping of the values written by the templates (HTML, JSON, and
+	// XML), so the values are written as-is.
+	DisableAutoEscape bool
+
 	// DisableMinification determines whether to disable minification of the rendered content (e.g., HTML, CSS) or not.
 	DisableMinification bool
+
+	// DisableCompression disables the gzip and Brotli compression of the responses (e.g. when the reverse proxy
+	// compresses them).
+	DisableCompression bool
+
+	// Minification contains the HTML minifier options (used unless the minification is disabled).
+	Minification struct {
+		KeepComments            bool // keep all the HTML comments
+		KeepConditionalComments bool // keep the IE conditional comments (like `<!--[if IE]>...<![endif]-->`)
+		KeepInlineCSS           bool // do not minify the inline CSS
+		KeepInlineJS            bool // do not minify the inline JS
+	}
 }
 
 const defaultJSONFormat string = `{
+  "schema_version": 1,
   "error": true,
   "code": {{ code | json }},
   "message": {{ message | json }},
-  "description": {{ description | json }}{{ if show_details }},
+  "description": {{ description | json }}{{ if and show_original original_status }},
+  "original_status": {{ original_status }}{{ end }}{{ if error_details }},
+  "error_details": {{ error_details | json }}{{ end }}{{ if show_details }},
   "details": {
     "host": {{ host | json }},
     "request_id": {{ request_id | json }},
@@ -78,7 +501,11 @@ const defaultXMLFormat string = `<?xml version="1.0" encoding="utf-8"?>
 <error>
   <code>{{ code }}</code>
   <message>{{ message }}</message>
-  <description>{{ description }}</description>{{ if show_details }}
+  <description>{{ description }}</description>{{ if and show_original original_status }}
+  <originalStatus>{{ original_status }}</originalStatus>{{ end }}{{ if error_details }}
+  <errorDetails>{{ range error_details }}
+    <detail field="{{ .Field }}">{{ .Message }}</detail>{{ end }}
+  </errorDetails>{{ end }}{{ if show_details }}
   <details>
     <host>{{ host }}</host>
     <requestID>{{ request_id }}</requestID>
@@ -95,28 +522,45 @@ Request ID: {{ request_id }}
 Timestamp: {{ nowUnix }}{{ end }}
 ` // an empty line at the end is important for better UX
 
+const defaultUnsupportedFormat string = `Error {{ code }}: {{ message }}
+
+The requested content format is not supported.
+Supported formats: JSON, XML, HTML, Plain Text
+` // an empty line at the end is important for better UX
+
+const defaultFragmentFormat string = `<div class="error-page" data-code="{{ code }}"
+  lang="{{ lang_code }}" dir="{{ text_direction }}">
+  <h1 class="error-page__title">{{ code }}: {{ message }}</h1>{{ if description }}
+  <p class="error-page__description">{{ description }}</p>{{ end }}{{ if show_details }}
+  <dl class="error-page__details">
+    <dt>Host</dt><dd>{{ host }}</dd>
+    <dt>Request ID</dt><dd>{{ request_id }}</dd>
+  </dl>{{ end }}
+</div>
+`
+
 //nolint:lll
 var defaultCodes = Codes{ //nolint:gochecknoglobals
-	"400": {"Bad Request", "The server did not understand the request"},
-	"401": {"Unauthorized", "The requested page needs a username and a password"},
-	"403": {"Forbidden", "Access is forbidden to the requested page"},
-	"404": {"Not Found", "The server can not find the requested page"},
-	"405": {"Method Not Allowed", "The method specified in the request is not allowed"},
-	"407": {"Proxy Authentication Required", "You must authenticate with a proxy server before this request can be served"},
-	"408": {"Request Timeout", "The request took longer than the server was prepared to wait"},
-	"409": {"Conflict", "The request could not be completed because of a conflict"},
-	"410": {"Gone", "The requested page is no longer available"},
-	"411": {"Length Required", "The \"Content-Length\" is not defined. The server will not accept the request without it"},
-	"412": {"Precondition Failed", "The pre condition given in the request evaluated to false by the server"},
-	"413": {"Payload Too Large", "The server will not accept the request, because the request entity is too large"},
-	"416": {"Requested Range Not Satisfiable", "The requested byte range is not available and is out of bounds"},
-	"418": {"I'm a teapot", "Attempt to brew coffee with a teapot is not supported"},
-	"429": {"Too Many Requests", "Too many requests in a given amount of time"},
-	"500": {"Internal Server Error", "The server met an unexpected condition"},
-	"502": {"Bad Gateway", "The server received an invalid response from the upstream server"},
-	"503": {"Service Unavailable", "The server is temporarily overloading or down"},
-	"504": {"Gateway Timeout", "The gateway has timed out"},
-	"505": {"HTTP Version Not Supported", "The server does not support the \"http protocol\" version"},
+	"400": {Message: "Bad Request", Description: "The server did not understand the request"},
+	"401": {Message: "Unauthorized", Description: "The requested page needs a username and a password"},
+	"403": {Message: "Forbidden", Description: "Access is forbidden to the requested page"},
+	"404": {Message: "Not Found", Description: "The server can not find the requested page"},
+	"405": {Message: "Method Not Allowed", Description: "The method specified in the request is not allowed"},
+	"407": {Message: "Proxy Authentication Required", Description: "You must authenticate with a proxy server before this request can be served"},
+	"408": {Message: "Request Timeout", Description: "The request took longer than the server was prepared to wait"},
+	"409": {Message: "Conflict", Description: "The request could not be completed because of a conflict"},
+	"410": {Message: "Gone", Description: "The requested page is no longer available"},
+	"411": {Message: "Length Required", Description: "The \"Content-Length\" is not defined. The server will not accept the request without it"},
+	"412": {Message: "Precondition Failed", Description: "The pre condition given in the request evaluated to false by the server"},
+	"413": {Message: "Payload Too Large", Description: "The server will not accept the request, because the request entity is too large"},
+	"416": {Message: "Requested Range Not Satisfiable", Description: "The requested byte range is not available and is out of bounds"},
+	"418": {Message: "I'm a teapot", Description: "Attempt to brew coffee with a teapot is not supported"},
+	"429": {Message: "Too Many Requests", Description: "Too many requests in a given amount of time"},
+	"500": {Message: "Internal Server Error", Description: "The server met an unexpected condition"},
+	"502": {Message: "Bad Gateway", Description: "The server received an invalid response from the upstream server"},
+	"503": {Message: "Service Unavailable", Description: "The server is temporarily overloading or down"},
+	"504": {Message: "Gateway Timeout", Description: "The gateway has timed out"},
+	"505": {Message: "HTTP Version Not Supported", Description: "The server does not support the \"http protocol\" version"},
 }
 
 var defaultProxyHeaders = []string{ //nolint:gochecknoglobals
@@ -137,6 +581,8 @@ func New() Config {
 	cfg.Formats.JSON = defaultJSONFormat
 	cfg.Formats.XML = defaultXMLFormat
 	cfg.Formats.PlainText = defaultPlainTextFormat
+	cfg.Formats.Unsupported = defaultUnsupportedFormat
+	cfg.Formats.Fragment = defaultFragmentFormat
 
 	// add built-in templates
 	for name, content := range builtinTemplates.BuiltIn() {
@@ -152,9 +598,44 @@ func New() Config {
 
 	// set default HTTP headers to proxy
 	cfg.ProxyHeaders = slices.Clone(defaultProxyHeaders)
+	cfg.RequestID.Headers = DefaultRequestIDHeaders()
 
 	// set defaults
 	cfg.DefaultCodeToRender = http.StatusNotFound
+	cfg.Timezone = "UTC"
+	cfg.CatchAll.LogSampleRate = 0.01 //nolint:mnd // 1%
+	cfg.Experiment.Split = 50         //nolint:mnd // 50/50
+
+	cfg.CacheTenantQuota = 1024 //nolint:mnd
+
+	cfg.UnknownCodeLogInterval = 10 * time.Second
+	cfg.RequestHeaders.MaxValueSize = 1024 //nolint:mnd
+
+	cfg.DetailsAccess.Header = DefaultDetailsTokenHeader
+	cfg.TLSErrors.Header = DefaultTLSErrorHeader
+	cfg.TLSErrors.Kinds = maps.Clone(defaultTLSErrors)
+
+	cfg.UpstreamHealth.Interval = 10 * time.Second
+	cfg.UpstreamHealth.Timeout = 2 * time.Second
+	cfg.LiveStatus.Interval = DefaultLiveStatusInterval
+	cfg.FeatureFlags.RedisKey = featureflag.DefaultRedisKey
+	cfg.FeatureFlags.Interval = 10 * time.Second
+	cfg.Publish.Interval = time.Minute
+
+	cfg.TemplateLimits.RenderTimeout = 2 * time.Second
+	cfg.TemplateLimits.MaxDepth = 16           //nolint:mnd
+	cfg.TemplateLimits.MaxIncludes = 256       //nolint:mnd
+	cfg.TemplateLimits.MaxIterations = 100_000 //nolint:mnd
 
 	return cfg
 }
+
+// NormalizePathPrefix converts the path prefix into the canonical form: with a leading slash and without a
+// trailing one (e.g. `errors/` becomes `/errors`). The root prefix (`/`) is converted into an empty string.
+func NormalizePathPrefix(prefix string) string {
+	if prefix = strings.Trim(strings.TrimSpace(prefix), "/"); prefix == "" {
+		return ""
+	}
+
+	return "/" + prefix
+}
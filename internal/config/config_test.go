@@ -19,12 +19,16 @@ func TestNew(t *testing.T) {
 		assert.NotEmpty(t, cfg.Formats.XML)
 		assert.NotEmpty(t, cfg.Formats.JSON)
 		assert.NotEmpty(t, cfg.Formats.PlainText)
+		assert.NotEmpty(t, cfg.Formats.Unsupported)
+		assert.NotEmpty(t, cfg.Formats.Fragment)
 		assert.True(t, len(cfg.Codes) >= 19)
 		assert.True(t, len(cfg.Templates) >= 1)
 		assert.NotEmpty(t, cfg.TemplateName)
 		assert.True(t, cfg.Templates.Has(cfg.TemplateName))
 		assert.Equal(t, uint16(http.StatusNotFound), cfg.DefaultCodeToRender)
 		assert.False(t, cfg.DisableMinification)
+		assert.Equal(t, "X-SSL-Error", cfg.TLSErrors.Header)
+		assert.Equal(t, uint16(495), cfg.TLSErrors.Kinds[config.TLSErrorCertificateExpired].Code)
 	})
 
 	t.Run("changing cfg1 should not affect cfg2", func(t *testing.T) {
@@ -37,12 +41,18 @@ func TestNew(t *testing.T) {
 		cfg1.ProxyHeaders = append(cfg1.ProxyHeaders, "foo")
 
 		assert.NotEqual(t, cfg1.ProxyHeaders, cfg2.ProxyHeaders)
+
+		cfg1.TLSErrors.Kinds[config.TLSErrorCertificateExpired] = config.ErrorKind{Code: 400}
+
+		assert.NotEqual(t, cfg1.TLSErrors.Kinds, cfg2.TLSErrors.Kinds)
 	})
 
 	t.Run("render default format templates", func(t *testing.T) {
 		var cfg = config.New()
 
-		for _, content := range []string{cfg.Formats.JSON, cfg.Formats.XML, cfg.Formats.PlainText} {
+		for _, content := range []string{
+			cfg.Formats.JSON, cfg.Formats.XML, cfg.Formats.PlainText, cfg.Formats.Unsupported, cfg.Formats.Fragment,
+		} {
 			var result, err = template.Render(content, template.Props{
 				ShowRequestDetails: true,
 				Code:               404,
@@ -56,3 +66,19 @@ func TestNew(t *testing.T) {
 		}
 	})
 }
+
+func TestNormalizePathPrefix(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"":          "",
+		"/":         "",
+		"//":        "",
+		"errors":    "/errors",
+		"/errors/":  "/errors",
+		" /a/b/ ":   "/a/b",
+		"/errors//": "/errors",
+	} {
+		assert.Equal(t, want, config.NormalizePathPrefix(give), give)
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import "fmt"
+
+// CSPNoncePlaceholder is replaced with the per-response nonce in the `Content-Security-Policy` header value.
+const CSPNoncePlaceholder = "{nonce}"
+
+// ValidateContentSecurityPolicy checks whether the string can be used as the `Content-Security-Policy` header
+// value: it should contain no line breaks and other control characters.
+func ValidateContentSecurityPolicy(policy string) error {
+	for _, r := range policy {
+		if (r < ' ' && r != '\t') || r == 0x7f { //nolint:mnd
+			return fmt.Errorf("wrong content security policy [%q]: control characters are not allowed", policy)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestValidateContentSecurityPolicy(t *testing.T) {
+	t.Parallel()
+
+	for give, wantErr := range map[string]bool{
+		"":                   false,
+		"default-src 'self'": false,
+		"script-src 'nonce-{nonce}';\tstyle-src 'self'": false,
+		"upgrade-insecure-requests":                     false,
+		"default-src 'self'\r\nX-Foo: bar":              true,
+		"default-src\x00":                               true,
+		"default-src\x7f":                               true,
+	} {
+		if err := config.ValidateContentSecurityPolicy(give); wantErr {
+			assert.Error(t, err, give)
+		} else {
+			assert.NoError(t, err, give)
+		}
+	}
+}
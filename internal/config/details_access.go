@@ -0,0 +1,35 @@
+package config
+
+import (
+	"net/netip"
+
+	"github.com/binaryYuki/error-pages/internal/wasmhook"
+)
+
+// DefaultDetailsTokenHeader is the request header with the token granting access to the request details.
+const DefaultDetailsTokenHeader = "X-Details-Token"
+
+// DetailsAccess limits the requests the request details (see [Config.ShowDetails]) are shown to, so the same
+// instance can serve the rich pages to the internal clients (like the office VPN) and the sanitized ones to the
+// internet. A request is granted access if the client IP is in any of the networks, if it carries the token, or if
+// the WebAssembly hook allows it.
+type DetailsAccess struct {
+	// Networks are the client IP ranges (the client IP is resolved using the trusted proxies settings).
+	Networks []netip.Prefix
+
+	// Header is the request header name with the token.
+	Header string
+
+	// Token is the expected value of the token header (empty disables the token check).
+	Token string
+
+	// Hook is the WebAssembly module deciding on the requests the networks and token rules don't grant access to
+	// (nil disables the hook).
+	Hook *wasmhook.Hook
+}
+
+// Restricted reports whether the access is limited. Without any networks, token, and hook, the details are shown
+// to everyone.
+func (a DetailsAccess) Restricted() bool {
+	return len(a.Networks) > 0 || a.Token != "" || a.Hook != nil
+}
@@ -0,0 +1,19 @@
+package config_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestDetailsAccess_Restricted(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, config.DetailsAccess{}.Restricted())
+	assert.False(t, config.DetailsAccess{Header: config.DefaultDetailsTokenHeader}.Restricted())
+	assert.True(t, config.DetailsAccess{Token: "secret"}.Restricted())
+	assert.True(t, config.DetailsAccess{Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}.Restricted())
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// ErrorKind is a named business error (like `quota_exceeded` or `region_blocked`), selected by the backend
+	// using the `X-Error-Kind` header instead of the bare HTTP code.
+	ErrorKind struct {
+		// Code is the HTTP code the kind is mapped to.
+		Code uint16
+
+		// Message and Description override the code message and description (empty means the code ones).
+		Message, Description string
+
+		// Template is a template name to use (empty means the template is selected as usual).
+		Template string
+	}
+
+	// ErrorKinds maps the error kind names to the kinds.
+	ErrorKinds map[string]ErrorKind
+)
+
+const maxErrorKindNameLength = 64
+
+// NewErrorKind validates the kind name (lowercase letters, digits, `_`, and `-`) and the HTTP code, and returns the
+// normalized name (trimmed and lowercased).
+func NewErrorKind(name string, code uint16) (string, error) {
+	var normalized = strings.ToLower(strings.TrimSpace(name))
+
+	if normalized == "" || len(normalized) > maxErrorKindNameLength {
+		return "", fmt.Errorf("wrong error kind name [%s]", name)
+	}
+
+	for _, r := range normalized {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			return "", fmt.Errorf("wrong error kind name [%s]: unexpected character %q", name, r)
+		}
+	}
+
+	if code == 0 || code > 999 { //nolint:mnd
+		return "", fmt.Errorf("error kind [%s]: wrong HTTP code [%d]", name, code)
+	}
+
+	return normalized, nil
+}
+
+// Find returns the kind by its name (the case and surrounding spaces are ignored).
+func (k ErrorKinds) Find(name string) (ErrorKind, bool) {
+	if len(k) == 0 || name == "" || len(name) > maxErrorKindNameLength+2 { // +2 for the surrounding spaces
+		return ErrorKind{}, false
+	}
+
+	kind, ok := k[strings.ToLower(strings.TrimSpace(name))]
+
+	return kind, ok
+}
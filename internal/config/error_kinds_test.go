@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestNewErrorKind(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveName string
+		giveCode uint16
+		want     string
+		wantErr  bool
+	}{
+		"simple":      {giveName: "quota_exceeded", giveCode: 429, want: "quota_exceeded"},
+		"normalized":  {giveName: " Region-Blocked ", giveCode: 451, want: "region-blocked"},
+		"digits":      {giveName: "v2_limit", giveCode: 429, want: "v2_limit"},
+		"empty":       {giveName: " ", giveCode: 429, wantErr: true},
+		"space":       {giveName: "quota exceeded", giveCode: 429, wantErr: true},
+		"dot":         {giveName: "quota.exceeded", giveCode: 429, wantErr: true},
+		"too long":    {giveName: string(make([]byte, 65)), giveCode: 429, wantErr: true},
+		"no code":     {giveName: "foo", wantErr: true},
+		"wrong code":  {giveName: "foo", giveCode: 1000, wantErr: true},
+		"non-ascii":   {giveName: "квота", giveCode: 429, wantErr: true},
+		"upper digit": {giveName: "FOO1", giveCode: 400, want: "foo1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := config.NewErrorKind(tt.giveName, tt.giveCode)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestErrorKinds_Find(t *testing.T) {
+	t.Parallel()
+
+	var kinds = config.ErrorKinds{"quota_exceeded": {Code: 429, Message: "Quota Exceeded"}}
+
+	kind, ok := kinds.Find(" Quota_Exceeded ")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(429), kind.Code)
+
+	_, ok = kinds.Find("region_blocked")
+	assert.False(t, ok)
+
+	_, ok = kinds.Find("")
+	assert.False(t, ok)
+
+	_, ok = config.ErrorKinds(nil).Find("quota_exceeded")
+	assert.False(t, ok)
+}
@@ -0,0 +1,1174 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/binaryYuki/error-pages/internal/charset"
+	"github.com/binaryYuki/error-pages/internal/datacenter"
+	"github.com/binaryYuki/error-pages/internal/featureflag"
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+	"github.com/binaryYuki/error-pages/internal/s3"
+	"github.com/binaryYuki/error-pages/internal/upstream"
+	"github.com/binaryYuki/error-pages/internal/wasmhook"
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+// File is the structure of the configuration file (YAML or JSON). All the fields are optional, and only the
+// specified values override the current configuration.
+type File struct {
+	TemplateName     *string           `yaml:"template_name"`
+	Templates        map[string]string `yaml:"templates"`         // map[name]path_to_the_file
+	DisableTemplates []string          `yaml:"disable_templates"` // template names to remove
+	RotationMode     *string           `yaml:"rotation_mode"`
+	RequestIDFormat  *string           `yaml:"request_id_format"`
+
+	Experiment struct {
+		Templates []string `yaml:"templates"` // exactly two template names
+		Split     *uint8   `yaml:"split"`     // percentage of the traffic for the second template
+	} `yaml:"experiment"`
+
+	Codes map[string]struct {
+		Message     string `yaml:"message"`
+		Description string `yaml:"description"`
+		L10n        map[string]struct {
+			Message     string `yaml:"message"`
+			Description string `yaml:"description"`
+		} `yaml:"l10n"` // map[locale]localized_description
+	} `yaml:"codes"`
+
+	Formats struct {
+		JSON        *string `yaml:"json"`
+		JSONSchema  *string `yaml:"json_schema"`
+		XML         *string `yaml:"xml"`
+		PlainText   *string `yaml:"plaintext"`
+		Unsupported *string `yaml:"unsupported"`
+		Fragment    *string `yaml:"fragment"`
+	} `yaml:"formats"`
+
+	PlainTextOutput struct {
+		MaxLineWidth  *uint   `yaml:"max_line_width"`
+		Normalization *string `yaml:"normalization"` // none, safe, or ascii
+	} `yaml:"plaintext_output"`
+
+	BodyLimits struct {
+		JSON      *uint `yaml:"json"`
+		XML       *uint `yaml:"xml"`
+		PlainText *uint `yaml:"plaintext"`
+	} `yaml:"body_limits"`
+
+	Accessibility struct {
+		ReducedMotion *string `yaml:"reduced_motion"` // auto, reduce, or no-preference
+		PrintFriendly *bool   `yaml:"print_friendly"`
+	} `yaml:"accessibility"`
+
+	DefaultErrorPage    *uint16  `yaml:"default_error_page"`
+	DefaultFormat       *string  `yaml:"default_format"` // plaintext, json, xml, or html
+	Charset             *string  `yaml:"charset"`        // like GBK or ISO-8859-1 (UTF-8 by default)
+	SendSameHTTPCode    *bool    `yaml:"send_same_http_code"`
+	ShowDetails         *bool    `yaml:"show_details"`
+	ShowOriginal        *bool    `yaml:"show_original_status"`
+	DisableL10n         *bool    `yaml:"disable_l10n"`
+	DisableMinification *bool    `yaml:"disable_minification"`
+	DisableCompression  *bool    `yaml:"disable_compression"`
+	DisableAutoEscape   *bool    `yaml:"disable_auto_escape"`
+	EnableAPI           *bool    `yaml:"enable_api"`
+	EnableMetrics       *bool    `yaml:"enable_metrics"`
+	Shadow              *bool    `yaml:"shadow"`
+	CSP                 *string  `yaml:"content_security_policy"`
+	EarlyHints          *bool    `yaml:"early_hints"`
+	ESI                 *bool    `yaml:"esi"`
+	UntilReady          *bool    `yaml:"unavailable_until_ready"`
+	ProxyHeaders        []string `yaml:"proxy_headers"`
+	TemplateHeaders     []string `yaml:"template_headers"`
+	AllowedHosts        []string `yaml:"allowed_hosts"`
+	AuthChallenges      []string `yaml:"auth_challenges"`
+	TrustedProxies      []string `yaml:"trusted_proxies"`
+	MaxProxyHops        *uint    `yaml:"max_proxy_hops"`
+	Timezone            *string  `yaml:"timezone"`
+	StaticDir           *string  `yaml:"static_dir"`
+	PathPrefix          *string  `yaml:"path_prefix"`
+	MaxRenders          *uint    `yaml:"max_concurrent_renders"`
+	CacheTenantQuota    *uint    `yaml:"cache_tenant_quota"`
+	StreamThreshold     *uint    `yaml:"stream_threshold"`
+	BodyPreviewSize     *uint    `yaml:"body_preview_size"`
+	UnknownCodeLogEvery *string  `yaml:"unknown_code_log_interval"` // e.g. "10s" (0s disables the logging)
+
+	Proxy struct {
+		Mode               *string `yaml:"mode"`                 // e.g. "traefik"
+		OriginalURIHeader  *string `yaml:"original_uri_header"`  // e.g. "X-Forwarded-Uri"
+		ForwardedForHeader *string `yaml:"forwarded_for_header"` // e.g. "X-Forwarded-For"
+	} `yaml:"proxy"`
+
+	RequestID struct {
+		Headers []string `yaml:"headers"` // e.g. ["X-Request-Id", "X-Correlation-Id"]
+		Prefix  *string  `yaml:"prefix"`
+		Layout  *string  `yaml:"layout"` // e.g. "{prefix}-{rand:5}-{uuidv7}"
+		CFRay   *bool    `yaml:"cf_ray"`
+	} `yaml:"request_id"`
+
+	TemplateLimits struct {
+		RenderTimeout *string `yaml:"render_timeout"` // e.g. "2s" or "500ms"
+		MaxDepth      *uint   `yaml:"max_depth"`
+		MaxIncludes   *uint   `yaml:"max_includes"`
+		MaxIterations *uint   `yaml:"max_iterations"`
+	} `yaml:"template_limits"`
+
+	Datacenter struct {
+		Code     *string `yaml:"code"`
+		File     *string `yaml:"file"`
+		Metadata *string `yaml:"metadata"` // ec2 or gcp
+	} `yaml:"datacenter"`
+
+	CatchAll struct {
+		Enabled       *bool    `yaml:"enabled"`
+		LogSampleRate *float64 `yaml:"log_sample_rate"`
+	} `yaml:"catch_all"`
+
+	UpstreamHealth struct {
+		URL         *string `yaml:"url"`
+		Interval    *string `yaml:"interval"` // e.g. "10s"
+		Timeout     *string `yaml:"timeout"`
+		RecoveryURL *string `yaml:"recovery_url"` // e.g. "/check"
+	} `yaml:"upstream_health"`
+
+	LiveStatus struct {
+		URL      *string `yaml:"url"`      // e.g. "/status.json"
+		Interval *string `yaml:"interval"` // e.g. "30s"
+	} `yaml:"live_status"`
+
+	OGImageBaseURL *string `yaml:"og_image_base_url"` // e.g. "https://errors.example.com"
+
+	FeatureFlags struct {
+		Values   map[string]string `yaml:"values"`    // map[name]value
+		File     *string           `yaml:"file"`      // e.g. "/etc/error-pages/flags.yml"
+		RedisURL *string           `yaml:"redis_url"` // e.g. "redis://:secret@redis:6379/0"
+		RedisKey *string           `yaml:"redis_key"`
+		Interval *string           `yaml:"interval"` // e.g. "10s"
+	} `yaml:"feature_flags"`
+
+	Publish struct {
+		Bucket   *string `yaml:"bucket"`
+		Region   *string `yaml:"region"`
+		Endpoint *string `yaml:"endpoint"`
+		Prefix   *string `yaml:"prefix"`
+		Interval *string `yaml:"interval"` // e.g. "1m"
+	} `yaml:"publish"`
+
+	LoopGuard struct {
+		Pseudonym *string `yaml:"via_pseudonym"`
+		MaxRate   *uint   `yaml:"max_rate"` // requests per second from the same client for the same code
+	} `yaml:"loop_guard"`
+
+	Memory struct {
+		GCPercent     *int  `yaml:"gc_percent"`
+		Limit         *uint `yaml:"limit_mib"`
+		CacheShrinkAt *uint `yaml:"cache_shrink_mib"` // the heap size to purge the rendered pages cache at
+	} `yaml:"memory"`
+
+	Minification struct {
+		KeepComments            *bool `yaml:"keep_comments"`
+		KeepConditionalComments *bool `yaml:"keep_conditional_comments"`
+		KeepInlineCSS           *bool `yaml:"keep_inline_css"`
+		KeepInlineJS            *bool `yaml:"keep_inline_js"`
+	} `yaml:"minification"`
+
+	RequestHeaders struct {
+		CodePrecedence   *string `yaml:"code_precedence"` // url, header, or reject
+		RejectDuplicates *bool   `yaml:"reject_duplicates"`
+		MaxValueSize     *uint   `yaml:"max_value_size"`
+		StrictCodes      *bool   `yaml:"strict_codes"`
+	} `yaml:"request_headers"`
+
+	DetailsAccess struct {
+		Networks []string `yaml:"networks"` // IP addresses or CIDR ranges
+		Header   *string  `yaml:"header"`
+		Token    *string  `yaml:"token"`
+		WasmHook *string  `yaml:"wasm_hook"` // path to the WebAssembly module
+	} `yaml:"details_access"`
+
+	Signing struct {
+		Algorithm *string `yaml:"algorithm"` // none, hmac-sha256, or ed25519
+		Key       *string `yaml:"key"`
+	} `yaml:"signing"`
+
+	Banner struct {
+		Message  *string `yaml:"message"`
+		Severity *string `yaml:"severity"` // info, warning, or critical
+	} `yaml:"banner"`
+
+	Watermark struct {
+		Mode       *string `yaml:"mode"` // none, footer, or invisible
+		InstanceID *string `yaml:"instance_id"`
+	} `yaml:"watermark"`
+
+	FormatOverrides []struct {
+		UserAgent string `yaml:"user_agent"` // regular expression
+		Format    string `yaml:"format"`     // plaintext, json, xml, or html
+	} `yaml:"format_overrides"`
+
+	AllowMethods []struct {
+		Pattern string   `yaml:"pattern"`
+		Methods []string `yaml:"methods"`
+	} `yaml:"allow_methods"`
+
+	Routes []struct {
+		Pattern  string `yaml:"pattern"`
+		Code     uint16 `yaml:"code"`
+		Template string `yaml:"template"`
+	} `yaml:"routes"`
+
+	TemplateOptions map[string]struct {
+		DisableMinification bool     `yaml:"disable_minification"`
+		DisableCache        bool     `yaml:"disable_cache"`
+		Preload             []string `yaml:"preload"`
+	} `yaml:"template_options"`
+
+	ErrorKinds map[string]struct {
+		Code        uint16 `yaml:"code"`
+		Message     string `yaml:"message"`
+		Description string `yaml:"description"`
+		Template    string `yaml:"template"`
+	} `yaml:"error_kinds"`
+
+	Profiles map[string]struct {
+		Code     uint16            `yaml:"code"`
+		Template string            `yaml:"template"`
+		Format   *string           `yaml:"format"`
+		Headers  map[string]string `yaml:"headers"`
+		Tokens   map[string]string `yaml:"tokens"`
+	} `yaml:"profiles"`
+
+	TLSErrors *struct {
+		Header *string `yaml:"header"`
+		Kinds  map[string]struct {
+			Code        uint16 `yaml:"code"`
+			Message     string `yaml:"message"`
+			Description string `yaml:"description"`
+			Template    string `yaml:"template"`
+		} `yaml:"kinds"`
+	} `yaml:"tls_errors"`
+
+	Maintenance []struct {
+		Start    string   `yaml:"start"` // RFC 3339, e.g. 2025-01-01T02:00:00Z
+		End      string   `yaml:"end"`
+		Message  string   `yaml:"message"`
+		Template string   `yaml:"template"`
+		Code     uint16   `yaml:"code"`
+		Paths    []string `yaml:"paths"` // regular expressions
+		Codes    []string `yaml:"codes"` // with wildcards, e.g. 5**
+	} `yaml:"maintenance"`
+}
+
+// ParseFile parses the configuration file content (YAML or JSON). Unknown fields are treated as errors to catch
+// typos early.
+func ParseFile(data []byte) (*File, error) {
+	var (
+		f   File
+		dec = yaml.NewDecoder(bytes.NewReader(data))
+	)
+
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) { // io.EOF means an empty document
+		return nil, fmt.Errorf("cannot parse the configuration file: %w", err)
+	}
+
+	return &f, nil
+}
+
+// Apply overrides the configuration values with the values from the file.
+func (f *File) Apply(cfg *Config) error { //nolint:funlen,gocognit,gocyclo
+	for name, path := range f.Templates {
+		if _, err := cfg.Templates.AddFromFile(path, name); err != nil {
+			return fmt.Errorf("cannot add template %s: %w", name, err)
+		}
+	}
+
+	for _, name := range f.DisableTemplates {
+		cfg.Templates.Remove(name)
+	}
+
+	if f.TemplateName != nil {
+		cfg.TemplateName = *f.TemplateName
+	}
+
+	if f.RotationMode != nil {
+		mode, err := ParseRotationMode(*f.RotationMode)
+		if err != nil {
+			return err
+		}
+
+		cfg.RotationMode = mode
+	}
+
+	if f.Experiment.Templates != nil {
+		if len(f.Experiment.Templates) != 2 { //nolint:mnd
+			return fmt.Errorf("exactly two experiment templates are expected, got %d", len(f.Experiment.Templates))
+		}
+
+		cfg.Experiment.Templates = [2]string(f.Experiment.Templates)
+	}
+
+	if f.Experiment.Split != nil {
+		if *f.Experiment.Split > 100 { //nolint:mnd
+			return fmt.Errorf("wrong experiment split [%d]: it should be in the range 0..100", *f.Experiment.Split)
+		}
+
+		cfg.Experiment.Split = *f.Experiment.Split
+	}
+
+	for code, desc := range f.Codes {
+		if err := ValidateCodeKey(code); err != nil {
+			return err
+		}
+
+		var cd = CodeDescription{Message: desc.Message, Description: desc.Description}
+
+		if len(desc.L10n) > 0 {
+			cd.Localized = make(map[string]LocalizedDescription, len(desc.L10n))
+
+			for locale, l := range desc.L10n {
+				var normalized = l10n.NormalizeLocale(locale)
+
+				if !l10n.HasLocale(normalized) {
+					return fmt.Errorf("wrong locale [%s] for the HTTP code [%s]: it is not supported", locale, code)
+				}
+
+				cd.Localized[normalized] = LocalizedDescription{Message: l.Message, Description: l.Description}
+			}
+		}
+
+		cfg.Codes[code] = cd
+	}
+
+	if f.Formats.JSONSchema != nil {
+		v, err := ParseJSONSchemaVersion(strings.TrimSpace(*f.Formats.JSONSchema))
+		if err != nil {
+			return err
+		}
+
+		cfg.Formats.JSON, _ = v.Format()
+	}
+
+	if f.Formats.JSON != nil {
+		cfg.Formats.JSON = strings.TrimSpace(*f.Formats.JSON)
+	}
+
+	if f.Formats.XML != nil {
+		cfg.Formats.XML = strings.TrimSpace(*f.Formats.XML)
+	}
+
+	if f.Formats.PlainText != nil {
+		cfg.Formats.PlainText = strings.TrimSpace(*f.Formats.PlainText)
+	}
+
+	if f.Formats.Unsupported != nil {
+		cfg.Formats.Unsupported = strings.TrimSpace(*f.Formats.Unsupported)
+	}
+
+	if f.Formats.Fragment != nil {
+		cfg.Formats.Fragment = strings.TrimSpace(*f.Formats.Fragment)
+	}
+
+	if f.DefaultErrorPage != nil {
+		if *f.DefaultErrorPage > 999 { //nolint:mnd
+			return fmt.Errorf("wrong HTTP code [%d] for the default error page", *f.DefaultErrorPage)
+		}
+
+		cfg.DefaultCodeToRender = *f.DefaultErrorPage
+	}
+
+	if f.PlainTextOutput.MaxLineWidth != nil {
+		cfg.PlainTextOutput.MaxLineWidth = *f.PlainTextOutput.MaxLineWidth
+	}
+
+	if f.PlainTextOutput.Normalization != nil {
+		normalization, err := ParseTextNormalization(*f.PlainTextOutput.Normalization)
+		if err != nil {
+			return err
+		}
+
+		cfg.PlainTextOutput.Normalization = normalization
+	}
+
+	if f.BodyLimits.JSON != nil {
+		if err := ValidateBodyLimit(*f.BodyLimits.JSON); err != nil {
+			return err
+		}
+
+		cfg.BodyLimits.JSON = *f.BodyLimits.JSON
+	}
+
+	if f.BodyLimits.XML != nil {
+		if err := ValidateBodyLimit(*f.BodyLimits.XML); err != nil {
+			return err
+		}
+
+		cfg.BodyLimits.XML = *f.BodyLimits.XML
+	}
+
+	if f.BodyLimits.PlainText != nil {
+		if err := ValidateBodyLimit(*f.BodyLimits.PlainText); err != nil {
+			return err
+		}
+
+		cfg.BodyLimits.PlainText = *f.BodyLimits.PlainText
+	}
+
+	if f.Accessibility.ReducedMotion != nil {
+		preference, err := ParseMotionPreference(*f.Accessibility.ReducedMotion)
+		if err != nil {
+			return err
+		}
+
+		cfg.Accessibility.ReducedMotion = preference
+	}
+
+	if f.Accessibility.PrintFriendly != nil {
+		cfg.Accessibility.PrintFriendly = *f.Accessibility.PrintFriendly
+	}
+
+	if f.DefaultFormat != nil {
+		format, err := ParseFormat(*f.DefaultFormat)
+		if err != nil {
+			return err
+		}
+
+		cfg.DefaultFormat = format
+	}
+
+	if f.UnknownCodeLogEvery != nil {
+		d, err := time.ParseDuration(strings.TrimSpace(*f.UnknownCodeLogEvery))
+		if err != nil || d < 0 {
+			return fmt.Errorf("wrong unknown code log interval [%s]", *f.UnknownCodeLogEvery)
+		}
+
+		cfg.UnknownCodeLogInterval = d
+	}
+
+	if f.SendSameHTTPCode != nil {
+		cfg.RespondWithSameHTTPCode = *f.SendSameHTTPCode
+	}
+
+	if f.ShowDetails != nil {
+		cfg.ShowDetails = *f.ShowDetails
+	}
+
+	if f.ShowOriginal != nil {
+		cfg.ShowOriginalStatus = *f.ShowOriginal
+	}
+
+	if f.DisableL10n != nil {
+		cfg.L10n.Disable = *f.DisableL10n
+	}
+
+	if f.DisableAutoEscape != nil {
+		cfg.DisableAutoEscape = *f.DisableAutoEscape
+	}
+
+	if f.EnableAPI != nil {
+		cfg.EnableAPI = *f.EnableAPI
+	}
+
+	if f.EnableMetrics != nil {
+		cfg.EnableMetrics = *f.EnableMetrics
+	}
+
+	if f.Shadow != nil {
+		cfg.Shadow = *f.Shadow
+	}
+
+	if f.Charset != nil {
+		if _, err := charset.Lookup(*f.Charset); err != nil {
+			return err
+		}
+
+		cfg.Charset = strings.TrimSpace(*f.Charset)
+	}
+
+	if f.CSP != nil {
+		if err := ValidateContentSecurityPolicy(*f.CSP); err != nil {
+			return err
+		}
+
+		cfg.ContentSecurityPolicy = strings.TrimSpace(*f.CSP)
+	}
+
+	if f.EarlyHints != nil {
+		cfg.EarlyHints = *f.EarlyHints
+	}
+
+	if f.ESI != nil {
+		cfg.ESI = *f.ESI
+	}
+
+	if f.UntilReady != nil {
+		cfg.UnavailableUntilReady = *f.UntilReady
+	}
+
+	if f.DisableMinification != nil {
+		cfg.DisableMinification = *f.DisableMinification
+	}
+
+	if f.DisableCompression != nil {
+		cfg.DisableCompression = *f.DisableCompression
+	}
+
+	if f.Minification.KeepComments != nil {
+		cfg.Minification.KeepComments = *f.Minification.KeepComments
+	}
+
+	if f.Minification.KeepConditionalComments != nil {
+		cfg.Minification.KeepConditionalComments = *f.Minification.KeepConditionalComments
+	}
+
+	if f.Minification.KeepInlineCSS != nil {
+		cfg.Minification.KeepInlineCSS = *f.Minification.KeepInlineCSS
+	}
+
+	if f.Minification.KeepInlineJS != nil {
+		cfg.Minification.KeepInlineJS = *f.Minification.KeepInlineJS
+	}
+
+	if f.ProxyHeaders != nil {
+		cfg.ProxyHeaders = cfg.ProxyHeaders[:0]
+
+		for _, header := range f.ProxyHeaders {
+			if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" &&
+				!slices.Contains(cfg.ProxyHeaders, header) {
+				cfg.ProxyHeaders = append(cfg.ProxyHeaders, header)
+			}
+		}
+	}
+
+	if f.TemplateHeaders != nil {
+		cfg.TemplateHeaders = cfg.TemplateHeaders[:0]
+
+		for _, header := range f.TemplateHeaders {
+			if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" &&
+				!slices.Contains(cfg.TemplateHeaders, header) {
+				cfg.TemplateHeaders = append(cfg.TemplateHeaders, header)
+			}
+		}
+	}
+
+	if f.AllowedHosts != nil {
+		cfg.AllowedHosts = cfg.AllowedHosts[:0]
+
+		for _, host := range f.AllowedHosts {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" && !slices.Contains(cfg.AllowedHosts, host) {
+				cfg.AllowedHosts = append(cfg.AllowedHosts, host)
+			}
+		}
+	}
+
+	if f.AuthChallenges != nil {
+		cfg.AuthChallenges = make([]string, 0, len(f.AuthChallenges))
+
+		for _, challenge := range f.AuthChallenges {
+			if err := ValidateAuthChallenge(strings.TrimSpace(challenge)); err != nil {
+				return err
+			}
+
+			cfg.AuthChallenges = append(cfg.AuthChallenges, strings.TrimSpace(challenge))
+		}
+	}
+
+	if f.TrustedProxies != nil {
+		trusted, err := clientip.ParsePrefixes(f.TrustedProxies...)
+		if err != nil {
+			return err
+		}
+
+		cfg.ClientIP.TrustedProxies = trusted
+	}
+
+	if f.MaxProxyHops != nil {
+		cfg.ClientIP.MaxHops = *f.MaxProxyHops
+	}
+
+	if f.Proxy.Mode != nil {
+		mode, err := ParseProxyMode(*f.Proxy.Mode)
+		if err != nil {
+			return err
+		}
+
+		cfg.Proxy.Mode = mode
+	}
+
+	if f.Proxy.OriginalURIHeader != nil {
+		var name = strings.TrimSpace(*f.Proxy.OriginalURIHeader)
+
+		if err := ValidateProxyHeader(name); err != nil {
+			return err
+		}
+
+		cfg.Proxy.OriginalURI = name
+	}
+
+	if f.Proxy.ForwardedForHeader != nil {
+		var name = strings.TrimSpace(*f.Proxy.ForwardedForHeader)
+
+		if err := ValidateProxyHeader(name); err != nil {
+			return err
+		}
+
+		cfg.Proxy.ForwardedFor = name
+	}
+
+	if f.DetailsAccess.Networks != nil {
+		networks, err := clientip.ParsePrefixes(f.DetailsAccess.Networks...)
+		if err != nil {
+			return fmt.Errorf("details access: %w", err)
+		}
+
+		cfg.DetailsAccess.Networks = networks
+	}
+
+	if f.DetailsAccess.Header != nil {
+		if strings.TrimSpace(*f.DetailsAccess.Header) == "" {
+			return errors.New("details access: empty token header name")
+		}
+
+		cfg.DetailsAccess.Header = strings.TrimSpace(*f.DetailsAccess.Header)
+	}
+
+	if f.DetailsAccess.Token != nil {
+		cfg.DetailsAccess.Token = *f.DetailsAccess.Token
+	}
+
+	if f.DetailsAccess.WasmHook != nil {
+		cfg.DetailsAccess.Hook = nil // an empty path disables the hook
+
+		if path := strings.TrimSpace(*f.DetailsAccess.WasmHook); path != "" {
+			hook, err := wasmhook.Load(path)
+			if err != nil {
+				return fmt.Errorf("details access: WASM hook: %w", err)
+			}
+
+			cfg.DetailsAccess.Hook = hook
+		}
+	}
+
+	if f.StaticDir != nil {
+		cfg.StaticDir = *f.StaticDir
+	}
+
+	if f.PathPrefix != nil {
+		cfg.PathPrefix = NormalizePathPrefix(*f.PathPrefix)
+	}
+
+	if f.MaxRenders != nil {
+		cfg.MaxConcurrentRenders = *f.MaxRenders
+	}
+
+	if f.CacheTenantQuota != nil {
+		cfg.CacheTenantQuota = *f.CacheTenantQuota
+	}
+
+	if f.StreamThreshold != nil {
+		cfg.StreamThreshold = *f.StreamThreshold
+	}
+
+	if f.Timezone != nil {
+		var tz = strings.TrimSpace(*f.Timezone)
+
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("wrong timezone [%s]: %w", tz, err)
+		}
+
+		cfg.Timezone = tz
+	}
+
+	if f.TemplateLimits.RenderTimeout != nil {
+		d, err := time.ParseDuration(strings.TrimSpace(*f.TemplateLimits.RenderTimeout))
+		if err != nil || d < 0 {
+			return fmt.Errorf("wrong template render timeout [%s]", *f.TemplateLimits.RenderTimeout)
+		}
+
+		cfg.TemplateLimits.RenderTimeout = d
+	}
+
+	if f.TemplateLimits.MaxDepth != nil {
+		cfg.TemplateLimits.MaxDepth = *f.TemplateLimits.MaxDepth
+	}
+
+	if f.TemplateLimits.MaxIncludes != nil {
+		cfg.TemplateLimits.MaxIncludes = *f.TemplateLimits.MaxIncludes
+	}
+
+	if f.TemplateLimits.MaxIterations != nil {
+		cfg.TemplateLimits.MaxIterations = *f.TemplateLimits.MaxIterations
+	}
+
+	if f.RequestIDFormat != nil {
+		format, err := ParseRequestIDFormat(*f.RequestIDFormat)
+		if err != nil {
+			return err
+		}
+
+		cfg.RequestIDFormat = format
+	}
+
+	if f.RequestID.Headers != nil {
+		cfg.RequestID.Headers = make([]string, 0, len(f.RequestID.Headers))
+
+		for _, header := range f.RequestID.Headers {
+			if header = http.CanonicalHeaderKey(strings.TrimSpace(header)); header != "" &&
+				!slices.Contains(cfg.RequestID.Headers, header) {
+				cfg.RequestID.Headers = append(cfg.RequestID.Headers, header)
+			}
+		}
+	}
+
+	if f.RequestID.Prefix != nil {
+		var prefix = strings.TrimSpace(*f.RequestID.Prefix)
+
+		if err := ValidateRequestIDPrefix(prefix); err != nil {
+			return err
+		}
+
+		cfg.RequestID.Prefix = prefix
+	}
+
+	if f.RequestID.Layout != nil {
+		var layout = strings.TrimSpace(*f.RequestID.Layout)
+
+		if layout != "" {
+			if _, err := ParseRequestIDLayout(layout); err != nil {
+				return err
+			}
+		}
+
+		cfg.RequestID.Layout = layout
+	}
+
+	if f.RequestID.CFRay != nil {
+		cfg.RequestID.CFRay = *f.RequestID.CFRay
+	}
+
+	if f.Datacenter.Code != nil {
+		cfg.Datacenter.Code = strings.TrimSpace(*f.Datacenter.Code)
+	}
+
+	if f.Datacenter.File != nil {
+		cfg.Datacenter.File = strings.TrimSpace(*f.Datacenter.File)
+	}
+
+	if f.Datacenter.Metadata != nil {
+		var metadata = strings.ToLower(strings.TrimSpace(*f.Datacenter.Metadata))
+
+		if metadata != "" && !slices.Contains(datacenter.MetadataServices(), metadata) {
+			return fmt.Errorf("wrong datacenter metadata service [%s]", *f.Datacenter.Metadata)
+		}
+
+		cfg.Datacenter.Metadata = metadata
+	}
+
+	if f.UpstreamHealth.URL != nil {
+		var u = strings.TrimSpace(*f.UpstreamHealth.URL)
+
+		if u != "" {
+			if err := upstream.ValidateURL(u); err != nil {
+				return err
+			}
+		}
+
+		cfg.UpstreamHealth.URL = u
+	}
+
+	if f.UpstreamHealth.Interval != nil {
+		d, err := time.ParseDuration(strings.TrimSpace(*f.UpstreamHealth.Interval))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("wrong upstream health interval [%s]", *f.UpstreamHealth.Interval)
+		}
+
+		cfg.UpstreamHealth.Interval = d
+	}
+
+	if f.UpstreamHealth.Timeout != nil {
+		d, err := time.ParseDuration(strings.TrimSpace(*f.UpstreamHealth.Timeout))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("wrong upstream health timeout [%s]", *f.UpstreamHealth.Timeout)
+		}
+
+		cfg.UpstreamHealth.Timeout = d
+	}
+
+	if f.UpstreamHealth.RecoveryURL != nil {
+		var u = strings.TrimSpace(*f.UpstreamHealth.RecoveryURL)
+
+		if u != "" {
+			if err := upstream.ValidateRecoveryURL(u); err != nil {
+				return err
+			}
+		}
+
+		cfg.UpstreamHealth.RecoveryURL = u
+	}
+
+	if f.LiveStatus.URL != nil {
+		var u = strings.TrimSpace(*f.LiveStatus.URL)
+
+		if u != "" {
+			if err := ValidateLiveStatusURL(u); err != nil {
+				return err
+			}
+		}
+
+		cfg.LiveStatus.URL = u
+	}
+
+	if f.LiveStatus.Interval != nil {
+		d, err := time.ParseDuration(*f.LiveStatus.Interval)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("wrong live status interval [%s]", *f.LiveStatus.Interval)
+		}
+
+		cfg.LiveStatus.Interval = d
+	}
+
+	if f.OGImageBaseURL != nil {
+		var u = strings.TrimSpace(*f.OGImageBaseURL)
+
+		if u != "" {
+			if err := ValidateOGImageBaseURL(u); err != nil {
+				return err
+			}
+		}
+
+		cfg.OGImageBaseURL = strings.TrimSuffix(u, "/")
+	}
+
+	if f.FeatureFlags.Values != nil {
+		cfg.FeatureFlags.Values = make(map[string]string, len(f.FeatureFlags.Values))
+
+		for name, value := range f.FeatureFlags.Values {
+			value, err := featureflag.Normalize(strings.TrimSpace(name), value)
+			if err != nil {
+				return err
+			}
+
+			cfg.FeatureFlags.Values[strings.TrimSpace(name)] = value
+		}
+	}
+
+	if f.FeatureFlags.File != nil {
+		cfg.FeatureFlags.File = strings.TrimSpace(*f.FeatureFlags.File)
+	}
+
+	if f.FeatureFlags.RedisURL != nil {
+		var u = strings.TrimSpace(*f.FeatureFlags.RedisURL)
+
+		if u != "" {
+			if err := featureflag.ValidateRedisURL(u); err != nil {
+				return err
+			}
+		}
+
+		cfg.FeatureFlags.RedisURL = u
+	}
+
+	if f.FeatureFlags.RedisKey != nil {
+		if key := strings.TrimSpace(*f.FeatureFlags.RedisKey); key != "" {
+			cfg.FeatureFlags.RedisKey = key
+		}
+	}
+
+	if f.FeatureFlags.Interval != nil {
+		d, err := time.ParseDuration(strings.TrimSpace(*f.FeatureFlags.Interval))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("wrong feature flags interval [%s]", *f.FeatureFlags.Interval)
+		}
+
+		cfg.FeatureFlags.Interval = d
+	}
+
+	if f.Publish.Bucket != nil {
+		cfg.Publish.Bucket = strings.TrimSpace(*f.Publish.Bucket)
+	}
+
+	if f.Publish.Region != nil {
+		cfg.Publish.Region = strings.TrimSpace(*f.Publish.Region)
+	}
+
+	if f.Publish.Endpoint != nil {
+		var u = strings.TrimSpace(*f.Publish.Endpoint)
+
+		if u != "" {
+			if err := s3.ValidateEndpoint(u); err != nil {
+				return err
+			}
+		}
+
+		cfg.Publish.Endpoint = u
+	}
+
+	if f.Publish.Prefix != nil {
+		cfg.Publish.Prefix = strings.Trim(strings.TrimSpace(*f.Publish.Prefix), "/")
+	}
+
+	if f.Publish.Interval != nil {
+		d, err := time.ParseDuration(strings.TrimSpace(*f.Publish.Interval))
+		if err != nil || d <= 0 {
+			return fmt.Errorf("wrong publish interval [%s]", *f.Publish.Interval)
+		}
+
+		cfg.Publish.Interval = d
+	}
+
+	if f.LoopGuard.Pseudonym != nil {
+		var pseudonym = strings.TrimSpace(*f.LoopGuard.Pseudonym)
+
+		if err := ValidateViaPseudonym(pseudonym); err != nil {
+			return err
+		}
+
+		cfg.LoopGuard.Pseudonym = pseudonym
+	}
+
+	if f.LoopGuard.MaxRate != nil {
+		cfg.LoopGuard.MaxRate = *f.LoopGuard.MaxRate
+	}
+
+	if f.Memory.GCPercent != nil {
+		cfg.Memory.GCPercent = *f.Memory.GCPercent
+	}
+
+	if f.Memory.Limit != nil {
+		cfg.Memory.Limit = *f.Memory.Limit
+	}
+
+	if f.Memory.CacheShrinkAt != nil {
+		cfg.Memory.CacheShrinkAt = *f.Memory.CacheShrinkAt
+	}
+
+	if f.RequestHeaders.CodePrecedence != nil {
+		precedence, err := ParseCodePrecedence(*f.RequestHeaders.CodePrecedence)
+		if err != nil {
+			return err
+		}
+
+		cfg.RequestHeaders.CodePrecedence = precedence
+	}
+
+	if f.RequestHeaders.RejectDuplicates != nil {
+		cfg.RequestHeaders.RejectDuplicates = *f.RequestHeaders.RejectDuplicates
+	}
+
+	if f.RequestHeaders.MaxValueSize != nil {
+		cfg.RequestHeaders.MaxValueSize = *f.RequestHeaders.MaxValueSize
+	}
+
+	if f.RequestHeaders.StrictCodes != nil {
+		cfg.RequestHeaders.StrictCodes = *f.RequestHeaders.StrictCodes
+	}
+
+	if f.Signing.Algorithm != nil {
+		alg, err := ParseSigningAlgorithm(*f.Signing.Algorithm)
+		if err != nil {
+			return err
+		}
+
+		cfg.Signing.Algorithm = alg
+	}
+
+	if f.Signing.Key != nil {
+		cfg.Signing.Key = strings.TrimSpace(*f.Signing.Key)
+	}
+
+	if f.BodyPreviewSize != nil {
+		cfg.BodyPreviewSize = *f.BodyPreviewSize
+	}
+
+	if f.CatchAll.Enabled != nil {
+		cfg.CatchAll.Enabled = *f.CatchAll.Enabled
+	}
+
+	if f.CatchAll.LogSampleRate != nil {
+		if rate := *f.CatchAll.LogSampleRate; rate < 0 || rate > 1 {
+			return fmt.Errorf("wrong catch-all log sample rate [%v]: it should be in the range 0..1", rate)
+		}
+
+		cfg.CatchAll.LogSampleRate = *f.CatchAll.LogSampleRate
+	}
+
+	if f.Banner.Message != nil {
+		cfg.Banner.Message = strings.TrimSpace(*f.Banner.Message)
+	}
+
+	if f.Banner.Severity != nil {
+		severity, err := ParseBannerSeverity(*f.Banner.Severity)
+		if err != nil {
+			return err
+		}
+
+		cfg.Banner.Severity = severity
+	}
+
+	if f.Watermark.Mode != nil {
+		mode, err := ParseWatermarkMode(*f.Watermark.Mode)
+		if err != nil {
+			return err
+		}
+
+		cfg.Watermark.Mode = mode
+	}
+
+	if f.Watermark.InstanceID != nil {
+		cfg.Watermark.InstanceID = strings.TrimSpace(*f.Watermark.InstanceID)
+	}
+
+	if f.FormatOverrides != nil {
+		cfg.FormatRules = make(FormatRules, 0, len(f.FormatOverrides))
+
+		for _, r := range f.FormatOverrides {
+			rule, err := NewFormatRule(r.UserAgent, r.Format)
+			if err != nil {
+				return err
+			}
+
+			cfg.FormatRules = append(cfg.FormatRules, rule)
+		}
+	}
+
+	if f.AllowMethods != nil {
+		cfg.AllowRules = make(AllowRules, 0, len(f.AllowMethods))
+
+		for _, r := range f.AllowMethods {
+			rule, err := NewAllowRule(r.Pattern, r.Methods...)
+			if err != nil {
+				return err
+			}
+
+			cfg.AllowRules = append(cfg.AllowRules, rule)
+		}
+	}
+
+	if f.TemplateOptions != nil {
+		if cfg.TemplateOptions == nil {
+			cfg.TemplateOptions = make(map[string]TemplateOptions, len(f.TemplateOptions))
+		}
+
+		for name, o := range f.TemplateOptions {
+			var opts = TemplateOptions{DisableMinification: o.DisableMinification, DisableCache: o.DisableCache}
+
+			for _, assetURL := range o.Preload {
+				link, err := PreloadLink(strings.TrimSpace(assetURL))
+				if err != nil {
+					return err
+				}
+
+				opts.Preload = append(opts.Preload, link)
+			}
+
+			cfg.TemplateOptions[strings.TrimSpace(name)] = opts
+		}
+	}
+
+	if f.ErrorKinds != nil {
+		cfg.ErrorKinds = make(ErrorKinds, len(f.ErrorKinds))
+
+		for name, k := range f.ErrorKinds {
+			normalized, err := NewErrorKind(name, k.Code)
+			if err != nil {
+				return err
+			}
+
+			cfg.ErrorKinds[normalized] = ErrorKind{
+				Code:        k.Code,
+				Message:     strings.TrimSpace(k.Message),
+				Description: strings.TrimSpace(k.Description),
+				Template:    strings.TrimSpace(k.Template),
+			}
+		}
+	}
+
+	if f.Profiles != nil {
+		cfg.Profiles = make(Profiles, len(f.Profiles))
+
+		for name, p := range f.Profiles {
+			var profile = Profile{Code: p.Code, Template: strings.TrimSpace(p.Template), Headers: p.Headers, Tokens: p.Tokens}
+
+			if p.Format != nil {
+				format, err := ParseFormat(*p.Format)
+				if err != nil {
+					return fmt.Errorf("profile [%s]: %w", name, err)
+				}
+
+				profile.Format = &format
+			}
+
+			normalized, profile, err := NewProfile(name, profile)
+			if err != nil {
+				return err
+			}
+
+			cfg.Profiles[normalized] = profile
+		}
+	}
+
+	if f.TLSErrors != nil {
+		if f.TLSErrors.Header != nil {
+			cfg.TLSErrors.Header = strings.TrimSpace(*f.TLSErrors.Header)
+		}
+
+		if len(f.TLSErrors.Kinds) > 0 && cfg.TLSErrors.Kinds == nil {
+			cfg.TLSErrors.Kinds = make(ErrorKinds, len(f.TLSErrors.Kinds))
+		}
+
+		// the kinds are merged into the built-in ones (so the defaults can be overridden one by one)
+		for name, k := range f.TLSErrors.Kinds {
+			normalized, err := NewErrorKind(name, k.Code)
+			if err != nil {
+				return fmt.Errorf("tls error: %w", err)
+			}
+
+			cfg.TLSErrors.Kinds[normalized] = ErrorKind{
+				Code:        k.Code,
+				Message:     strings.TrimSpace(k.Message),
+				Description: strings.TrimSpace(k.Description),
+				Template:    strings.TrimSpace(k.Template),
+			}
+		}
+	}
+
+	if f.Routes != nil {
+		cfg.Routes = make(Routes, 0, len(f.Routes))
+
+		for _, r := range f.Routes {
+			route, err := NewRoute(r.Pattern, r.Code, r.Template)
+			if err != nil {
+				return err
+			}
+
+			cfg.Routes = append(cfg.Routes, route)
+		}
+	}
+
+	if f.Maintenance != nil {
+		cfg.Maintenance = make(MaintenanceWindows, 0, len(f.Maintenance))
+
+		for _, m := range f.Maintenance {
+			w, err := NewMaintenanceWindow(m.Start, m.End, m.Code, m.Paths, m.Codes)
+			if err != nil {
+				return err
+			}
+
+			w.Message, w.Template = strings.TrimSpace(m.Message), strings.TrimSpace(m.Template)
+
+			cfg.Maintenance = append(cfg.Maintenance, w)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,379 @@
+package config_test
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestParseFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+
+		var file, err = config.ParseFile([]byte(`
+template_name: foo
+templates:
+  foo: ./testdata/with-content.htm
+disable_templates: [ghost]
+rotation_mode: random-daily
+experiment: {templates: [foo, ghost], split: 30}
+codes:
+  "4**": {message: Client Error, description: Something went wrong}
+  "500-504": {message: Server Error}
+  "499": {message: Quota Exceeded, l10n: {DE: {message: Kontingent überschritten}}}
+formats:
+  json: ' {"code": {{ code }}} '
+  unsupported: ' {{ code }}: not supported '
+  fragment: ' <p>{{ code }}</p> '
+plaintext_output: {max_line_width: 80, normalization: ascii}
+body_limits: {json: 4096, plaintext: 512}
+accessibility: {reduced_motion: reduce, print_friendly: true}
+default_error_page: 503
+default_format: JSON
+charset: " Latin1 "
+unknown_code_log_interval: 1m
+send_same_http_code: true
+show_details: true
+show_original_status: true
+disable_l10n: true
+disable_minification: true
+disable_compression: true
+minification: {keep_comments: false, keep_conditional_comments: true, keep_inline_css: true, keep_inline_js: true}
+disable_auto_escape: true
+enable_api: true
+enable_metrics: true
+shadow: true
+content_security_policy: " script-src 'nonce-{nonce}' "
+early_hints: true
+esi: true
+unavailable_until_ready: true
+request_headers: {code_precedence: Header, reject_duplicates: true, max_value_size: 64, strict_codes: true}
+signing: {algorithm: HMAC-SHA256, key: " 0123456789abcdef "}
+proxy_headers: [x-foo, X-Foo, " x-bar"]
+template_headers: [x-tenant, " X-Tenant", x-plan]
+allowed_hosts: [Example.com]
+trusted_proxies: [10.0.0.0/8, "::1"]
+max_proxy_hops: 2
+proxy: {mode: Traefik, original_uri_header: " X-Request-Uri "}
+details_access: {networks: [10.8.0.0/16], header: X-Internal-Token, token: s3cr3t}
+body_preview_size: 64
+cache_tenant_quota: 32
+stream_threshold: 1048576
+datacenter: {code: FRA1, metadata: GCP}
+request_id_format: ulid
+request_id:
+  headers: [x-correlation-id, X-Request-Id, x-request-id]
+  prefix: edge
+  layout: "{prefix}.{ulid}"
+  cf_ray: true
+path_prefix: errors/
+catch_all: {enabled: true, log_sample_rate: 0.5}
+banner: {message: " Scheduled maintenance ", severity: Warning}
+watermark: {mode: Footer, instance_id: " eu-1 "}
+upstream_health: {url: " http://app:8080/healthz ", interval: 5s, recovery_url: /errors/check}
+live_status: {url: " /errors/status.json ", interval: 1m}
+og_image_base_url: https://errors.example.com/
+feature_flags:
+  values: {new_503_copy: 25%, dark_theme: true}
+  file: " /etc/error-pages/flags.yml "
+  redis_url: redis://:secret@redis:6379/1
+  redis_key: errors:flags
+  interval: 30s
+publish: {bucket: " errors ", region: eu-west-1, endpoint: "http://127.0.0.1:9000", prefix: /pages/, interval: 30s}
+loop_guard: {via_pseudonym: " error-pages ", max_rate: 300}
+memory: {gc_percent: 50, limit_mib: 48, cache_shrink_mib: 40}
+timezone: Europe/Berlin
+template_limits: {render_timeout: 500ms, max_depth: 4, max_includes: 8, max_iterations: 1000}
+allow_methods:
+  - {pattern: ^/api/, methods: [get, post]}
+format_overrides:
+  - {user_agent: ^kube-probe/, format: plaintext}
+  - {user_agent: Go-http-client, format: JSON}
+template_options:
+  foo: {disable_minification: true, disable_cache: true, preload: [" /assets/foo.css", /assets/foo.woff2]}
+error_kinds:
+  Quota_Exceeded: {code: 429, message: Quota Exceeded, template: connection}
+profiles:
+  Blocked-By-WAF:
+    code: 403
+    template: connection
+    format: HTML
+    headers: {x-blocked-by: waf}
+    tokens: {message: Request Blocked}
+  billing-suspended: {code: 402}
+tls_errors:
+  header: " X-TLS-Error "
+  kinds: {certificate_expired: {code: 403, template: connection}, Client_Banned: {code: 403}}
+routes:
+  - {pattern: ^/old-api/, code: 410}
+  - {pattern: ^/docs/, template: connection}
+maintenance:
+  - {start: "2025-01-01T02:00:00Z", end: "2025-01-01T04:00:00Z", message: Upgrading, template: connection, paths: [^/api/], codes: [5**]}
+`))
+
+		require.NoError(t, err)
+
+		var cfg = config.New()
+
+		require.NoError(t, file.Apply(&cfg))
+
+		assert.Equal(t, "foo", cfg.TemplateName)
+		assert.True(t, cfg.Templates.Has("foo"))
+		assert.False(t, cfg.Templates.Has("ghost"))
+		assert.Equal(t, config.RotationModeRandomDaily, cfg.RotationMode)
+		assert.Equal(t, [2]string{"foo", "ghost"}, cfg.Experiment.Templates)
+		assert.Equal(t, uint8(30), cfg.Experiment.Split)
+		assert.Equal(t, config.CodeDescription{Message: "Client Error", Description: "Something went wrong"}, cfg.Codes["4**"])
+		assert.Equal(t, config.CodeDescription{Message: "Server Error"}, cfg.Codes["500-504"])
+		assert.Equal(t, config.CodeDescription{
+			Message:   "Quota Exceeded",
+			Localized: map[string]config.LocalizedDescription{"de": {Message: "Kontingent überschritten"}},
+		}, cfg.Codes["499"])
+		assert.Equal(t, `{"code": {{ code }}}`, cfg.Formats.JSON)
+		assert.NotEmpty(t, cfg.Formats.XML) // not changed
+		assert.Equal(t, "{{ code }}: not supported", cfg.Formats.Unsupported)
+		assert.Equal(t, "<p>{{ code }}</p>", cfg.Formats.Fragment)
+		assert.Equal(t, uint(80), cfg.PlainTextOutput.MaxLineWidth)
+		assert.Equal(t, config.TextNormalizationASCII, cfg.PlainTextOutput.Normalization)
+		assert.Equal(t, config.BodyLimits{JSON: 4096, PlainText: 512}, cfg.BodyLimits)
+		assert.Equal(t, config.MotionPreferenceReduce, cfg.Accessibility.ReducedMotion)
+		assert.True(t, cfg.Accessibility.PrintFriendly)
+		assert.Equal(t, uint16(503), cfg.DefaultCodeToRender)
+		assert.Equal(t, config.FormatJSON, cfg.DefaultFormat)
+		assert.Equal(t, "Latin1", cfg.Charset)
+		assert.Equal(t, time.Minute, cfg.UnknownCodeLogInterval)
+		assert.True(t, cfg.RespondWithSameHTTPCode)
+		assert.True(t, cfg.ShowDetails)
+		assert.True(t, cfg.ShowOriginalStatus)
+		assert.True(t, cfg.L10n.Disable)
+		assert.True(t, cfg.DisableMinification)
+		assert.True(t, cfg.DisableCompression)
+		assert.False(t, cfg.Minification.KeepComments)
+		assert.True(t, cfg.Minification.KeepConditionalComments)
+		assert.True(t, cfg.Minification.KeepInlineCSS)
+		assert.True(t, cfg.Minification.KeepInlineJS)
+		assert.True(t, cfg.DisableAutoEscape)
+		assert.True(t, cfg.EnableAPI)
+		assert.True(t, cfg.EnableMetrics)
+		assert.True(t, cfg.Shadow)
+		assert.Equal(t, "script-src 'nonce-{nonce}'", cfg.ContentSecurityPolicy)
+		assert.True(t, cfg.EarlyHints)
+		assert.True(t, cfg.ESI)
+		assert.True(t, cfg.UnavailableUntilReady)
+		assert.Equal(t, config.CodePrecedenceHeader, cfg.RequestHeaders.CodePrecedence)
+		assert.True(t, cfg.RequestHeaders.RejectDuplicates)
+		assert.Equal(t, uint(64), cfg.RequestHeaders.MaxValueSize)
+		assert.True(t, cfg.RequestHeaders.StrictCodes)
+		assert.Equal(t, config.SigningHMACSHA256, cfg.Signing.Algorithm)
+		assert.Equal(t, "0123456789abcdef", cfg.Signing.Key)
+		assert.Equal(t, []string{"X-Foo", "X-Bar"}, cfg.ProxyHeaders)
+		assert.Equal(t, []string{"X-Tenant", "X-Plan"}, cfg.TemplateHeaders)
+		assert.Equal(t, []string{"example.com"}, cfg.AllowedHosts)
+		assert.Equal(t, []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("::1/128"),
+		}, cfg.ClientIP.TrustedProxies)
+		assert.Equal(t, uint(2), cfg.ClientIP.MaxHops)
+		assert.Equal(t, config.Proxy{Mode: config.ProxyModeTraefik, OriginalURI: "X-Request-Uri"}, cfg.Proxy)
+		assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.8.0.0/16")}, cfg.DetailsAccess.Networks)
+		assert.Equal(t, "X-Internal-Token", cfg.DetailsAccess.Header)
+		assert.Equal(t, "s3cr3t", cfg.DetailsAccess.Token)
+		assert.Equal(t, uint(64), cfg.BodyPreviewSize)
+		assert.Equal(t, uint(32), cfg.CacheTenantQuota)
+		assert.Equal(t, uint(1<<20), cfg.StreamThreshold)
+		assert.Equal(t, "FRA1", cfg.Datacenter.Code)
+		assert.Equal(t, "gcp", cfg.Datacenter.Metadata)
+		assert.Equal(t, config.RequestIDFormatULID, cfg.RequestIDFormat)
+		assert.Equal(t, config.RequestID{
+			Headers: []string{"X-Correlation-Id", "X-Request-Id"},
+			Prefix:  "edge",
+			Layout:  "{prefix}.{ulid}",
+			CFRay:   true,
+		}, cfg.RequestID)
+		assert.Equal(t, "/errors", cfg.PathPrefix)
+		assert.True(t, cfg.CatchAll.Enabled)
+		assert.Equal(t, "Europe/Berlin", cfg.Timezone)
+		assert.Equal(t, 500*time.Millisecond, cfg.TemplateLimits.RenderTimeout)
+		assert.Equal(t, uint(4), cfg.TemplateLimits.MaxDepth)
+		assert.Equal(t, uint(8), cfg.TemplateLimits.MaxIncludes)
+		assert.Equal(t, uint(1000), cfg.TemplateLimits.MaxIterations)
+		assert.InDelta(t, 0.5, cfg.CatchAll.LogSampleRate, 0.001)
+		assert.Equal(t, "Scheduled maintenance", cfg.Banner.Message)
+		assert.Equal(t, config.BannerSeverityWarning, cfg.Banner.Severity)
+		assert.Equal(t, config.WatermarkModeFooter, cfg.Watermark.Mode)
+		assert.Equal(t, "eu-1", cfg.Watermark.InstanceID)
+		assert.Equal(t, "http://app:8080/healthz", cfg.UpstreamHealth.URL)
+		assert.Equal(t, 5*time.Second, cfg.UpstreamHealth.Interval)
+		assert.Equal(t, 2*time.Second, cfg.UpstreamHealth.Timeout) // default
+		assert.Equal(t, "/errors/check", cfg.UpstreamHealth.RecoveryURL)
+		assert.Equal(t, "/errors/status.json", cfg.LiveStatus.URL)
+		assert.Equal(t, time.Minute, cfg.LiveStatus.Interval)
+		assert.Equal(t, "https://errors.example.com", cfg.OGImageBaseURL)
+		assert.Equal(t, map[string]string{"new_503_copy": "25%", "dark_theme": "true"}, cfg.FeatureFlags.Values)
+		assert.Equal(t, "/etc/error-pages/flags.yml", cfg.FeatureFlags.File)
+		assert.Equal(t, "redis://:secret@redis:6379/1", cfg.FeatureFlags.RedisURL)
+		assert.Equal(t, "errors:flags", cfg.FeatureFlags.RedisKey)
+		assert.Equal(t, 30*time.Second, cfg.FeatureFlags.Interval)
+		assert.Equal(t, "errors", cfg.Publish.Bucket)
+		assert.Equal(t, "eu-west-1", cfg.Publish.Region)
+		assert.Equal(t, "http://127.0.0.1:9000", cfg.Publish.Endpoint)
+		assert.Equal(t, "pages", cfg.Publish.Prefix)
+		assert.Equal(t, 30*time.Second, cfg.Publish.Interval)
+		assert.Equal(t, "error-pages", cfg.LoopGuard.Pseudonym)
+		assert.Equal(t, uint(300), cfg.LoopGuard.MaxRate)
+		assert.Equal(t, 50, cfg.Memory.GCPercent)
+		assert.Equal(t, uint(48), cfg.Memory.Limit)
+		assert.Equal(t, uint(40), cfg.Memory.CacheShrinkAt)
+		require.Len(t, cfg.AllowRules, 1)
+		assert.Equal(t, []string{"GET", "POST"}, cfg.AllowRules[0].Methods)
+		require.Len(t, cfg.FormatRules, 2)
+		assert.Equal(t, "Go-http-client", cfg.FormatRules[1].Pattern.String())
+		assert.Equal(t, config.FormatJSON, cfg.FormatRules[1].Format)
+		assert.Equal(t, map[string]config.TemplateOptions{
+			"foo": {DisableMinification: true, DisableCache: true, Preload: []string{
+				"</assets/foo.css>; rel=preload; as=style",
+				"</assets/foo.woff2>; rel=preload; as=font; crossorigin",
+			}},
+		}, cfg.TemplateOptions)
+		require.Len(t, cfg.ErrorKinds, 1)
+		assert.Equal(t, config.ErrorKind{Code: 429, Message: "Quota Exceeded", Template: "connection"}, cfg.ErrorKinds["quota_exceeded"])
+		var html = config.FormatHTML
+
+		require.Len(t, cfg.Profiles, 2)
+		assert.Equal(t, config.Profile{
+			Code:     403,
+			Template: "connection",
+			Format:   &html,
+			Headers:  map[string]string{"X-Blocked-By": "waf"},
+			Tokens:   map[string]string{"message": "Request Blocked"},
+		}, cfg.Profiles["blocked-by-waf"])
+		assert.Equal(t, config.Profile{Code: 402}, cfg.Profiles["billing-suspended"])
+		assert.Equal(t, "X-TLS-Error", cfg.TLSErrors.Header)
+		assert.Equal(t, config.ErrorKind{Code: 403, Template: "connection"}, cfg.TLSErrors.Kinds[config.TLSErrorCertificateExpired])
+		assert.Equal(t, config.ErrorKind{Code: 403}, cfg.TLSErrors.Kinds["client_banned"])
+		assert.Equal(t, uint16(496), cfg.TLSErrors.Kinds[config.TLSErrorCertificateRequired].Code) // not changed
+		require.Len(t, cfg.Routes, 2)
+		assert.Equal(t, uint16(410), cfg.Routes[0].Code)
+		assert.Equal(t, "connection", cfg.Routes[1].Template)
+		require.Len(t, cfg.Maintenance, 1)
+		assert.Equal(t, "Upgrading", cfg.Maintenance[0].Message)
+		assert.Equal(t, "connection", cfg.Maintenance[0].Template)
+		assert.Equal(t, uint16(503), cfg.Maintenance[0].Code)
+		assert.Equal(t, []string{"5**"}, cfg.Maintenance[0].Codes)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var file, err = config.ParseFile([]byte(`{"show_details": true}`))
+
+		require.NoError(t, err)
+
+		var cfg = config.New()
+
+		require.NoError(t, file.Apply(&cfg))
+
+		assert.True(t, cfg.ShowDetails)
+		assert.False(t, cfg.RespondWithSameHTTPCode) // not changed
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var file, err = config.ParseFile([]byte(""))
+
+		require.NoError(t, err)
+
+		var cfg, want = config.New(), config.New()
+
+		require.NoError(t, file.Apply(&cfg))
+
+		assert.Equal(t, want, cfg)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		t.Parallel()
+
+		var _, err = config.ParseFile([]byte(`show_detials: true`))
+
+		assert.ErrorContains(t, err, "show_detials")
+	})
+
+	t.Run("wrong values", func(t *testing.T) {
+		t.Parallel()
+
+		for name, content := range map[string]string{
+			"rotation mode":     `rotation_mode: foo`,
+			"code":              `codes: {"4040": {message: foo}}`,
+			"codes range":       `codes: {"504-500": {message: foo}}`,
+			"code locale":       `codes: {"404": {l10n: {xx: {message: foo}}}}`,
+			"render timeout":    `template_limits: {render_timeout: foo}`,
+			"timezone":          `timezone: Foo/Bar`,
+			"dc metadata":       `datacenter: {metadata: azure}`,
+			"request id format": `request_id_format: uuid`,
+			"request id prefix": `request_id: {prefix: "eu west"}`,
+			"request id layout": `request_id: {layout: "{prefix}-{uuidv6}"}`,
+			"default code":      `default_error_page: 1000`,
+			"default format":    `default_format: yaml`,
+			"normalization":     `plaintext_output: {normalization: nfc}`,
+			"body limit":        `body_limits: {xml: 100}`,
+			"charset":           `charset: utf-16`,
+			"reduced motion":    `accessibility: {reduced_motion: none}`,
+			"unknown code log":  `unknown_code_log_interval: -1s`,
+			"trusted proxies":   `trusted_proxies: [foo]`,
+			"proxy mode":        `proxy: {mode: nginx}`,
+			"proxy header":      `proxy: {forwarded_for_header: "X Forwarded For"}`,
+			"details networks":  `details_access: {networks: [10.0.0.0/33]}`,
+			"details header":    `details_access: {header: " "}`,
+			"details wasm hook": `details_access: {wasm_hook: ./testdata/not-exists.wasm}`,
+			"template":          `templates: {foo: ./testdata/not-exists}`,
+			"route pattern":     `routes: [{pattern: "(", code: 410}]`,
+			"empty route":       `routes: [{pattern: ^/foo}]`,
+			"log sample rate":   `catch_all: {log_sample_rate: 2}`,
+			"banner severity":   `banner: {severity: fatal}`,
+			"watermark mode":    `watermark: {mode: visible}`,
+			"upstream url":      `upstream_health: {url: app/healthz}`,
+			"upstream interval": `upstream_health: {interval: 0s}`,
+			"recovery url":      `upstream_health: {recovery_url: "javascript:alert(1)"}`,
+			"live status url":   `live_status: {url: status.json}`,
+			"live status every": `live_status: {interval: -1s}`,
+			"og image base url": `og_image_base_url: /errors`,
+			"flag name":         `feature_flags: {values: {"new copy": on}}`,
+			"flags redis":       `feature_flags: {redis_url: "http://redis:6379"}`,
+			"flags interval":    `feature_flags: {interval: -1s}`,
+			"publish endpoint":  `publish: {endpoint: 127.0.0.1:9000}`,
+			"publish interval":  `publish: {interval: 0s}`,
+			"via pseudonym":     `loop_guard: {via_pseudonym: "error pages"}`,
+			"maintenance":       `maintenance: [{start: "2025-01-01T04:00:00Z", end: "2025-01-01T02:00:00Z"}]`,
+			"error kind name":   `error_kinds: {"quota exceeded": {code: 429}}`,
+			"error kind code":   `error_kinds: {quota_exceeded: {message: foo}}`,
+			"tls error code":    `tls_errors: {kinds: {certificate_expired: {message: foo}}}`,
+			"profile name":      `profiles: {"blocked by waf": {code: 403}}`,
+			"profile format":    `profiles: {blocked: {format: yaml}}`,
+			"profile header":    `profiles: {blocked: {headers: {"x-a b": c}}}`,
+			"signing algorithm": `signing: {algorithm: rsa}`,
+			"code precedence":   `request_headers: {code_precedence: route}`,
+			"csp":               `content_security_policy: "default-src\r\nX-Foo: bar"`,
+			"preload":           `template_options: {foo: {preload: [/assets/foo.html]}}`,
+			"allow methods":     `allow_methods: [{pattern: ^/api/}]`,
+			"format override":   `format_overrides: [{user_agent: ^curl/, format: yaml}]`,
+			"experiment":        `experiment: {templates: [foo]}`,
+			"experiment split":  `experiment: {split: 101}`,
+		} {
+			var file, err = config.ParseFile([]byte(content))
+
+			require.NoError(t, err, name)
+
+			var cfg = config.New()
+
+			assert.Error(t, file.Apply(&cfg), name)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is the error page response format, used when the client does not specify a supported one.
+type Format byte
+
+const (
+	FormatPlainText Format = iota // plain text, default
+	FormatJSON                    // JSON
+	FormatXML                     // XML
+	FormatHTML                    // HTML (rendered using the template)
+)
+
+// String returns a human-readable representation of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatPlainText:
+		return "plaintext"
+	case FormatJSON:
+		return "json"
+	case FormatXML:
+		return "xml"
+	case FormatHTML:
+		return "html"
+	}
+
+	return fmt.Sprintf("Format(%d)", f)
+}
+
+// Formats returns a slice of all formats.
+func Formats() []Format { return []Format{FormatPlainText, FormatJSON, FormatXML, FormatHTML} }
+
+// FormatStrings returns a slice of all formats as strings.
+func FormatStrings() []string {
+	var (
+		formats = Formats()
+		result  = make([]string, len(formats))
+	)
+
+	for i := range formats {
+		result[i] = formats[i].String()
+	}
+
+	return result
+}
+
+// ParseFormat parses a format (case is ignored, an empty string means plaintext). If the provided string is
+// invalid, an error is returned.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case FormatPlainText.String(), "":
+		return FormatPlainText, nil
+	case FormatJSON.String():
+		return FormatJSON, nil
+	case FormatXML.String():
+		return FormatXML, nil
+	case FormatHTML.String():
+		return FormatHTML, nil
+	}
+
+	return FormatPlainText, fmt.Errorf("unrecognized format: %q", s)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type (
+	// FormatRule forces the response format for the clients with the matching `User-Agent` header (e.g. the health
+	// checkers or SDKs that should not receive the HTML pages).
+	FormatRule struct {
+		// Pattern is a regular expression the `User-Agent` header value is matched against.
+		Pattern *regexp.Regexp
+
+		// Format is the forced response format.
+		Format Format
+	}
+
+	// FormatRules is an ordered list of the format rules. The first matched rule wins.
+	FormatRules []FormatRule
+)
+
+// NewFormatRule creates a new format rule with the given `User-Agent` pattern and format.
+func NewFormatRule(pattern, format string) (FormatRule, error) {
+	if strings.TrimSpace(format) == "" {
+		return FormatRule{}, fmt.Errorf("format rule [%s]: the format must be specified", pattern)
+	}
+
+	f, err := ParseFormat(format)
+	if err != nil {
+		return FormatRule{}, fmt.Errorf("format rule [%s]: %w", pattern, err)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return FormatRule{}, fmt.Errorf("format rule [%s]: %w", pattern, err)
+	}
+
+	return FormatRule{Pattern: re, Format: f}, nil
+}
+
+// ParseFormatRule parses the format rule in the `PATTERN=FORMAT` format (e.g. `^kube-probe/=plaintext`).
+func ParseFormatRule(s string) (FormatRule, error) {
+	var idx = strings.LastIndex(s, "=")
+	if idx < 1 {
+		return FormatRule{}, fmt.Errorf("format rule [%s]: expected format is PATTERN=FORMAT", s)
+	}
+
+	return NewFormatRule(s[:idx], s[idx+1:])
+}
+
+// Match returns the format of the first rule that matches the given `User-Agent` header value.
+func (r FormatRules) Match(userAgent string) (Format, bool) {
+	for _, rule := range r {
+		if rule.Pattern != nil && rule.Pattern.MatchString(userAgent) {
+			return rule.Format, true
+		}
+	}
+
+	return FormatPlainText, false
+}
@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestParseFormatRule(t *testing.T) {
+	t.Parallel()
+
+	for give, tt := range map[string]struct {
+		wantPattern string
+		wantFormat  config.Format
+		wantErr     bool
+	}{
+		"^kube-probe/=plaintext": {wantPattern: "^kube-probe/", wantFormat: config.FormatPlainText},
+		"Go-http-client=JSON":    {wantPattern: "Go-http-client", wantFormat: config.FormatJSON},
+		"^a=b=xml":               {wantPattern: "^a=b", wantFormat: config.FormatXML},
+		"^curl/":                 {wantErr: true},
+		"=json":                  {wantErr: true},
+		"^curl/=":                {wantErr: true},
+		"^curl/=yaml":            {wantErr: true},
+		"(=json":                 {wantErr: true},
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			var rule, err = config.ParseFormatRule(give)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPattern, rule.Pattern.String())
+			assert.Equal(t, tt.wantFormat, rule.Format)
+		})
+	}
+}
+
+func TestFormatRules_Match(t *testing.T) {
+	t.Parallel()
+
+	var rules = make(config.FormatRules, 0, 2)
+
+	for _, s := range []string{`^kube-probe/=plaintext`, `Go-http-client=json`} {
+		rule, err := config.ParseFormatRule(s)
+		require.NoError(t, err)
+
+		rules = append(rules, rule)
+	}
+
+	format, ok := rules.Match("kube-probe/1.29")
+	assert.True(t, ok)
+	assert.Equal(t, config.FormatPlainText, format)
+
+	format, ok = rules.Match("Go-http-client/2.0")
+	assert.True(t, ok)
+	assert.Equal(t, config.FormatJSON, format)
+
+	_, ok = rules.Match("Mozilla/5.0")
+	assert.False(t, ok)
+
+	_, ok = config.FormatRules(nil).Match("kube-probe/1.29")
+	assert.False(t, ok)
+}
@@ -0,0 +1,33 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"plaintext", "json", "xml", "html"}, config.FormatStrings())
+	assert.Equal(t, "Format(255)", config.Format(255).String())
+
+	for give, want := range map[string]config.Format{
+		"":          config.FormatPlainText,
+		"plaintext": config.FormatPlainText,
+		" JSON ":    config.FormatJSON,
+		"xml":       config.FormatXML,
+		"Html":      config.FormatHTML,
+	} {
+		got, err := config.ParseFormat(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseFormat("yaml")
+	assert.ErrorContains(t, err, "unrecognized format")
+}
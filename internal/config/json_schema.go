@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// JSONSchemaVersion is a version of the default JSON error body structure. The structure of each version is
+// stable and never changes in a backward-incompatible way, so the API consumers can rely on it across upgrades.
+type JSONSchemaVersion uint8
+
+const (
+	// JSONSchemaV1 is a flat structure (the code, message, and description at the top level). The default one.
+	JSONSchemaV1 JSONSchemaVersion = 1
+
+	// JSONSchemaV2 is a structure with the error properties nested into the `error` object.
+	JSONSchemaV2 JSONSchemaVersion = 2
+)
+
+const jsonFormatV2 string = `{
+  "schema_version": 2,
+  "error": {
+    "code": {{ code | json }},
+    "message": {{ message | json }},
+    "description": {{ description | json }}{{ if and show_original original_status }},
+    "original_status": {{ original_status }}{{ end }}{{ if error_details }},
+    "details": {{ error_details | json }}{{ end }}
+  }{{ if show_details }},
+  "details": {
+    "host": {{ host | json }},
+    "request_id": {{ request_id | json }},
+    "client_ip": {{ client_ip | json }},
+    "timestamp": {{ nowUnix }}
+  }{{ end }}
+}
+` // an empty line at the end is important for better UX
+
+// JSONSchemaVersions returns a slice of all the supported JSON schema versions.
+func JSONSchemaVersions() []JSONSchemaVersion { return []JSONSchemaVersion{JSONSchemaV1, JSONSchemaV2} }
+
+// JSONSchemaVersionStrings returns a slice of all the supported JSON schema versions as strings.
+func JSONSchemaVersionStrings() []string {
+	var (
+		versions = JSONSchemaVersions()
+		result   = make([]string, len(versions))
+	)
+
+	for i := range versions {
+		result[i] = versions[i].String()
+	}
+
+	return result
+}
+
+// IsJSONSchemaFormat reports whether the JSON format is the default one of any schema version (and not a custom
+// format, like the one from the configuration file).
+func IsJSONSchemaFormat(format string) bool {
+	for _, v := range JSONSchemaVersions() {
+		if f, _ := v.Format(); f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseJSONSchemaVersion parses the JSON schema version from the string (like `1`, `v2`).
+func ParseJSONSchemaVersion(s string) (JSONSchemaVersion, error) {
+	if len(s) > 0 && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+
+	if v, err := strconv.ParseUint(s, 10, 8); err == nil {
+		if _, fmtErr := JSONSchemaVersion(v).Format(); fmtErr == nil {
+			return JSONSchemaVersion(v), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported JSON schema version [%s]", s)
+}
+
+// String returns the version in the `vN` format.
+func (v JSONSchemaVersion) String() string { return "v" + strconv.FormatUint(uint64(v), 10) }
+
+// Format returns the default JSON format (template) for the schema version.
+func (v JSONSchemaVersion) Format() (string, error) {
+	switch v {
+	case JSONSchemaV1:
+		return defaultJSONFormat, nil
+	case JSONSchemaV2:
+		return jsonFormatV2, nil
+	}
+
+	return "", fmt.Errorf("unsupported JSON schema version [%d]", v)
+}
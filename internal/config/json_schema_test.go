@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestParseJSONSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]config.JSONSchemaVersion{
+		"1":  config.JSONSchemaV1,
+		"v1": config.JSONSchemaV1,
+		"2":  config.JSONSchemaV2,
+		"V2": config.JSONSchemaV2,
+	} {
+		got, err := config.ParseJSONSchemaVersion(give)
+
+		require.NoError(t, err, give)
+		assert.Equal(t, want, got, give)
+	}
+
+	for _, give := range []string{"", "0", "3", "v", "foo", "-1"} {
+		_, err := config.ParseJSONSchemaVersion(give)
+
+		assert.Error(t, err, give)
+	}
+}
+
+func TestIsJSONSchemaFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range config.JSONSchemaVersions() {
+		format, err := v.Format()
+		require.NoError(t, err)
+
+		assert.True(t, config.IsJSONSchemaFormat(format), v.String())
+	}
+
+	assert.True(t, config.IsJSONSchemaFormat(config.New().Formats.JSON))
+	assert.False(t, config.IsJSONSchemaFormat(`{"code": {{ code }}}`))
+	assert.False(t, config.IsJSONSchemaFormat(""))
+}
+
+func TestJSONSchemaVersion_Format(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range config.JSONSchemaVersions() {
+		for _, showDetails := range []bool{true, false} {
+			format, err := v.Format()
+			require.NoError(t, err)
+
+			content, err := template.Render(format, template.Props{
+				Code:               404,
+				Message:            "Not \"Found\"",
+				ShowRequestDetails: showDetails,
+				RequestID:          "foo",
+			})
+			require.NoError(t, err)
+
+			var body map[string]any
+
+			require.NoError(t, json.Unmarshal([]byte(content), &body), content)
+			assert.InDelta(t, float64(v), body["schema_version"], 0, v.String())
+			assert.Equal(t, showDetails, body["details"] != nil, v.String())
+		}
+	}
+
+	_, err := config.JSONSchemaVersion(0).Format()
+	assert.Error(t, err)
+}
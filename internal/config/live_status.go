@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultLiveStatusInterval is the default time between the live status polls of the HTML pages.
+const DefaultLiveStatusInterval = 30 * time.Second
+
+// LiveStatus contains settings of the live status updates. When the URL is set, the HTML pages poll the
+// `/status.json` endpoint (backing off on failures) and update the outage banner of the already open pages.
+type LiveStatus struct {
+	// URL is the URL of the `/status.json` endpoint as seen by the browsers (e.g. `/status.json` or
+	// `https://errors.example.com/status.json`). An empty string disables the updates.
+	URL string
+
+	// Interval is the time between the polls.
+	Interval time.Duration
+}
+
+// ValidateLiveStatusURL checks that the status endpoint URL (as seen by the browsers) is an absolute path or an
+// absolute HTTP(S) URL.
+func ValidateLiveStatusURL(s string) error {
+	if strings.HasPrefix(s, "/") && !strings.HasPrefix(s, "//") {
+		if _, err := url.Parse(s); err != nil {
+			return fmt.Errorf("wrong live status URL [%s]: %w", s, err)
+		}
+
+		return nil
+	}
+
+	if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("wrong live status URL [%s]: an absolute path or http(s) URL is expected", s)
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestValidateLiveStatusURL(t *testing.T) {
+	t.Parallel()
+
+	for _, give := range []string{"/status.json", "/errors/status.json?a=b", "https://errors.example.com/status.json"} {
+		assert.NoError(t, config.ValidateLiveStatusURL(give), give)
+	}
+
+	for _, give := range []string{"", "status.json", "//example.com/status.json", "ftp://example.com/", "https://"} {
+		assert.Error(t, config.ValidateLiveStatusURL(give), give)
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+type (
+	// MaintenanceWindow is a scheduled maintenance window. During the window, the matched requests receive the
+	// maintenance page.
+	MaintenanceWindow struct {
+		// Start and End limit the window (the end is exclusive).
+		Start, End time.Time
+
+		// Message replaces the description on the maintenance page (empty means the description is not changed).
+		Message string
+
+		// Template is a template name to use (empty means the template is selected as usual).
+		Template string
+
+		// Code is an HTTP code to render during the window (503 by default).
+		Code uint16
+
+		// Paths are the request path patterns the window applies to.
+		Paths []*regexp.Regexp
+
+		// Codes are the requested HTTP codes the window applies to (wildcards like `5**` are supported). If both the
+		// paths and codes are empty, the window applies to all the requests.
+		Codes []string
+	}
+
+	// MaintenanceWindows is a list of the maintenance windows. The first active and matched window wins.
+	MaintenanceWindows []MaintenanceWindow
+)
+
+// NewMaintenanceWindow creates a new maintenance window. The start and end must be in the RFC 3339 format (e.g.
+// `2025-01-01T02:00:00Z`).
+func NewMaintenanceWindow(start, end string, code uint16, paths, codes []string) (MaintenanceWindow, error) {
+	var (
+		w   = MaintenanceWindow{Code: code, Codes: codes}
+		err error
+	)
+
+	if w.Start, err = time.Parse(time.RFC3339, start); err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window: wrong start time: %w", err)
+	}
+
+	if w.End, err = time.Parse(time.RFC3339, end); err != nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window: wrong end time: %w", err)
+	}
+
+	if !w.End.After(w.Start) {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window [%s]: the end must be after the start", start)
+	}
+
+	if w.Code == 0 {
+		w.Code = http.StatusServiceUnavailable
+	} else if w.Code > 999 { //nolint:mnd
+		return MaintenanceWindow{}, fmt.Errorf("maintenance window [%s]: wrong HTTP code [%d]", start, w.Code)
+	}
+
+	for _, pattern := range paths {
+		re, reErr := regexp.Compile(pattern)
+		if reErr != nil {
+			return MaintenanceWindow{}, fmt.Errorf("maintenance window [%s]: %w", start, reErr)
+		}
+
+		w.Paths = append(w.Paths, re)
+	}
+
+	return w, nil
+}
+
+// Matches reports whether the window is active at the given time and applies to the request path or the
+// requested HTTP code.
+func (w MaintenanceWindow) Matches(now time.Time, path string, code uint16) bool {
+	if now.Before(w.Start) || !now.Before(w.End) {
+		return false
+	}
+
+	if len(w.Paths) == 0 && len(w.Codes) == 0 {
+		return true
+	}
+
+	for _, re := range w.Paths {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+
+	var codeStr = strconv.FormatUint(uint64(code), 10)
+
+	for _, pattern := range w.Codes {
+		if codeMatches(pattern, codeStr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Active returns the first window that is active at the given time and applies to the request.
+func (ws MaintenanceWindows) Active(now time.Time, path string, code uint16) (MaintenanceWindow, bool) {
+	for _, w := range ws {
+		if w.Matches(now, path, code) {
+			return w, true
+		}
+	}
+
+	return MaintenanceWindow{}, false
+}
+
+// codeMatches reports whether the code matches the pattern with wildcards (e.g. `5**` or `40x`).
+func codeMatches(pattern, code string) bool {
+	var pr, cr = []rune(pattern), []rune(code)
+
+	if len(pr) != len(cr) {
+		return false
+	}
+
+	for i := range pr {
+		if !isWildcardOr(pr[i], cr[i]) {
+			return false
+		}
+	}
+
+	return true
+}
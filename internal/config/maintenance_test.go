@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestNewMaintenanceWindow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		w, err := config.NewMaintenanceWindow("2025-01-01T02:00:00Z", "2025-01-01T04:00:00+01:00", 0, nil, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint16(503), w.Code)
+		assert.Equal(t, time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC), w.Start.UTC())
+		assert.Equal(t, time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC), w.End.UTC())
+	})
+
+	for name, tt := range map[string]struct {
+		giveStart, giveEnd string
+		giveCode           uint16
+		givePaths          []string
+		wantErr            string
+	}{
+		"wrong start":   {giveStart: "tomorrow", giveEnd: "2025-01-01T04:00:00Z", wantErr: "wrong start time"},
+		"wrong end":     {giveStart: "2025-01-01T04:00:00Z", giveEnd: "2025-01-01", wantErr: "wrong end time"},
+		"end <= start":  {giveStart: "2025-01-01T04:00:00Z", giveEnd: "2025-01-01T04:00:00Z", wantErr: "the end must be after"},
+		"wrong code":    {giveStart: "2025-01-01T02:00:00Z", giveEnd: "2025-01-01T04:00:00Z", giveCode: 1000, wantErr: "wrong HTTP code"},
+		"wrong pattern": {giveStart: "2025-01-01T02:00:00Z", giveEnd: "2025-01-01T04:00:00Z", givePaths: []string{"["}, wantErr: "missing closing ]"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := config.NewMaintenanceWindow(tt.giveStart, tt.giveEnd, tt.giveCode, tt.givePaths, nil)
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMaintenanceWindows_Active(t *testing.T) {
+	t.Parallel()
+
+	var mustWindow = func(code uint16, paths, codes []string) config.MaintenanceWindow {
+		w, err := config.NewMaintenanceWindow("2025-01-01T02:00:00Z", "2025-01-01T04:00:00Z", code, paths, codes)
+		require.NoError(t, err)
+
+		return w
+	}
+
+	var (
+		windows = config.MaintenanceWindows{
+			mustWindow(502, []string{"^/api/"}, []string{"5**"}),
+			mustWindow(0, nil, nil), // any request
+		}
+		during = time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+	)
+
+	for name, tt := range map[string]struct {
+		giveTime time.Time
+		givePath string
+		giveCode uint16
+		wantOk   bool
+		wantCode uint16
+	}{
+		"before":          {giveTime: during.Add(-2 * time.Hour), givePath: "/api/", giveCode: 500},
+		"after (the end)": {giveTime: during.Add(time.Hour), givePath: "/api/", giveCode: 500},
+		"the start":       {giveTime: during.Add(-time.Hour), givePath: "/api/", wantOk: true, wantCode: 502},
+		"path":            {giveTime: during, givePath: "/api/users", giveCode: 404, wantOk: true, wantCode: 502},
+		"code":            {giveTime: during, givePath: "/", giveCode: 504, wantOk: true, wantCode: 502},
+		"fallthrough":     {giveTime: during, givePath: "/", giveCode: 404, wantOk: true, wantCode: 503},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w, ok := windows.Active(tt.giveTime, tt.givePath, tt.giveCode)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
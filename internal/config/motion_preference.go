@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MotionPreference determines whether the templates are asked to reduce the animations (the `reduced_motion`
+// token), following the CSS `prefers-reduced-motion` media feature values.
+type MotionPreference byte
+
+const (
+	MotionPreferenceAuto         MotionPreference = iota // the `Sec-CH-Prefers-Reduced-Motion` client hint, default
+	MotionPreferenceReduce                               // the animations are always reduced
+	MotionPreferenceNoPreference                         // the client hint is ignored (not requested)
+)
+
+// String returns a human-readable representation of the motion preference.
+func (p MotionPreference) String() string {
+	switch p {
+	case MotionPreferenceAuto:
+		return "auto"
+	case MotionPreferenceReduce:
+		return "reduce"
+	case MotionPreferenceNoPreference:
+		return "no-preference"
+	}
+
+	return fmt.Sprintf("MotionPreference(%d)", p)
+}
+
+// MotionPreferences returns a slice of all motion preferences.
+func MotionPreferences() []MotionPreference {
+	return []MotionPreference{MotionPreferenceAuto, MotionPreferenceReduce, MotionPreferenceNoPreference}
+}
+
+// MotionPreferenceStrings returns a slice of all motion preferences as strings.
+func MotionPreferenceStrings() []string {
+	var (
+		preferences = MotionPreferences()
+		result      = make([]string, len(preferences))
+	)
+
+	for i := range preferences {
+		result[i] = preferences[i].String()
+	}
+
+	return result
+}
+
+// ParseMotionPreference parses a motion preference (case is ignored, an empty string means auto). If the provided
+// string is invalid, an error is returned.
+func ParseMotionPreference(s string) (MotionPreference, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case MotionPreferenceAuto.String(), "":
+		return MotionPreferenceAuto, nil
+	case MotionPreferenceReduce.String():
+		return MotionPreferenceReduce, nil
+	case MotionPreferenceNoPreference.String():
+		return MotionPreferenceNoPreference, nil
+	}
+
+	return MotionPreferenceAuto, fmt.Errorf("unrecognized motion preference: %q", s)
+}
@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestMotionPreference(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"auto", "reduce", "no-preference"}, config.MotionPreferenceStrings())
+	assert.Equal(t, "MotionPreference(255)", config.MotionPreference(255).String())
+
+	for give, want := range map[string]config.MotionPreference{
+		"":              config.MotionPreferenceAuto,
+		"auto":          config.MotionPreferenceAuto,
+		" Reduce ":      config.MotionPreferenceReduce,
+		"NO-PREFERENCE": config.MotionPreferenceNoPreference,
+	} {
+		got, err := config.ParseMotionPreference(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseMotionPreference("none")
+	assert.ErrorContains(t, err, "unrecognized motion preference")
+}
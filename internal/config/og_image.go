@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ValidateOGImageBaseURL checks that the public base URL of the server (used for the social preview image URLs)
+// is an absolute HTTP(S) URL without the query and fragment.
+func ValidateOGImageBaseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("wrong social preview base URL [%s]: an absolute http(s) URL is expected", s)
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("wrong social preview base URL [%s]: the query and fragment are not allowed", s)
+	}
+
+	return nil
+}
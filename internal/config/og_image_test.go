@@ -0,0 +1,21 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestValidateOGImageBaseURL(t *testing.T) {
+	t.Parallel()
+
+	for _, give := range []string{"https://errors.example.com", "http://example.com/errors/"} {
+		assert.NoError(t, config.ValidateOGImageBaseURL(give), give)
+	}
+
+	for _, give := range []string{"", "/errors", "//example.com", "ftp://example.com", "https://example.com/?a=b"} {
+		assert.Error(t, config.ValidateOGImageBaseURL(give), give)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// preloadDestinations maps the asset file extensions to the `as` attribute values of the preload links.
+var preloadDestinations = map[string]string{ //nolint:gochecknoglobals
+	".css":   "style",
+	".js":    "script",
+	".mjs":   "script",
+	".woff":  "font",
+	".woff2": "font",
+	".ttf":   "font",
+	".otf":   "font",
+	".png":   "image",
+	".jpg":   "image",
+	".jpeg":  "image",
+	".gif":   "image",
+	".svg":   "image",
+	".webp":  "image",
+	".avif":  "image",
+	".ico":   "image",
+}
+
+// PreloadLink returns the `Link` header value to preload the asset (like `</assets/app.css>; rel=preload; as=style`).
+// The asset URL should be an absolute path or an absolute HTTP(S) URL, and the `as` attribute is inferred from the
+// file extension (the fonts are fetched in the CORS mode, so they are marked as `crossorigin`).
+func PreloadLink(assetURL string) (string, error) {
+	if strings.ContainsAny(assetURL, "<>\"' ") || strings.ContainsFunc(assetURL, func(r rune) bool {
+		return r < ' ' || r == 0x7f //nolint:mnd
+	}) {
+		return "", fmt.Errorf("wrong preload URL [%q]: whitespaces, quotes, and angle brackets are not allowed", assetURL)
+	}
+
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return "", fmt.Errorf("wrong preload URL [%s]: %w", assetURL, err)
+	}
+
+	if isPath := strings.HasPrefix(assetURL, "/") && !strings.HasPrefix(assetURL, "//"); !isPath &&
+		((u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		return "", fmt.Errorf("wrong preload URL [%s]: an absolute path or http(s) URL is expected", assetURL)
+	}
+
+	as, ok := preloadDestinations[strings.ToLower(path.Ext(u.Path))]
+	if !ok {
+		return "", fmt.Errorf("wrong preload URL [%s]: unsupported asset type (css, js, fonts, and images are allowed)",
+			assetURL,
+		)
+	}
+
+	var link = "<" + assetURL + ">; rel=preload; as=" + as
+
+	if as == "font" {
+		link += "; crossorigin"
+	}
+
+	return link, nil
+}
+
+// ParseTemplatePreload parses the template asset preload in the `TEMPLATE=URL` format (e.g. `ghost=/assets/app.css`)
+// and returns the template name and the `Link` header value (see [PreloadLink]).
+func ParseTemplatePreload(s string) (templateName, link string, _ error) {
+	name, assetURL, ok := strings.Cut(s, "=")
+	if name, assetURL = strings.TrimSpace(name), strings.TrimSpace(assetURL); !ok || name == "" {
+		return "", "", fmt.Errorf("wrong template preload [%s]: the TEMPLATE=URL format is expected", s)
+	}
+
+	link, err := PreloadLink(assetURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	return name, link, nil
+}
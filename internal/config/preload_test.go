@@ -0,0 +1,57 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestPreloadLink(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"/assets/app.css":                       "</assets/app.css>; rel=preload; as=style",
+		"/assets/app.js?v=2":                    "</assets/app.js?v=2>; rel=preload; as=script",
+		"/assets/app.MJS":                       "</assets/app.MJS>; rel=preload; as=script",
+		"https://cdn.example.com/f/inter.woff2": "<https://cdn.example.com/f/inter.woff2>; rel=preload; as=font; crossorigin",
+		"/img/logo.svg#icon":                    "</img/logo.svg#icon>; rel=preload; as=image",
+	} {
+		got, err := config.PreloadLink(give)
+
+		assert.NoError(t, err, give)
+		assert.Equal(t, want, got, give)
+	}
+
+	for _, give := range []string{
+		"",
+		"assets/app.css",
+		"//cdn.example.com/app.css",
+		"ftp://cdn.example.com/app.css",
+		"/assets/app.html",
+		"/assets/app",
+		"/assets/app.css>; rel=preconnect",
+		"/assets/app.css\r\nX-Foo: bar",
+		"/assets/my app.css",
+	} {
+		_, err := config.PreloadLink(give)
+
+		assert.Error(t, err, give)
+	}
+}
+
+func TestParseTemplatePreload(t *testing.T) {
+	t.Parallel()
+
+	name, link, err := config.ParseTemplatePreload(" ghost = /assets/app.css ")
+	assert.NoError(t, err)
+	assert.Equal(t, "ghost", name)
+	assert.Equal(t, "</assets/app.css>; rel=preload; as=style", link)
+
+	for _, give := range []string{"", "ghost", "=/assets/app.css", "ghost=", "ghost=/assets/app.txt"} {
+		_, _, err = config.ParseTemplatePreload(give)
+
+		assert.Error(t, err, give)
+	}
+}
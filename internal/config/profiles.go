@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+)
+
+type (
+	// Profile is a named canned response (like `blocked-by-waf` or `billing-suspended`), bundling the code, template,
+	// format, extra response headers, and token overrides. It's selected using the `X-Error-Profile` request header
+	// or the `/profile/{name}` path, so one backend can serve many distinct responses.
+	Profile struct {
+		// Code is the HTTP code to render (zero means the code is detected as usual).
+		Code uint16
+
+		// Template is a template name to use (empty means the template is selected as usual).
+		Template string
+
+		// Format is the response format to use (nil means the format is negotiated as usual).
+		Format *Format
+
+		// Headers are the extra response headers (by the canonical header name).
+		Headers map[string]string
+
+		// Tokens override the string tokens values (like `message` or `description`) by the token names.
+		Tokens map[string]string
+	}
+
+	// Profiles maps the profile names to the profiles.
+	Profiles map[string]Profile
+)
+
+const maxProfileNameLength = 64
+
+// NewProfile validates the profile name (lowercase letters, digits, `_`, and `-`), code, and headers, and returns
+// the normalized name (trimmed and lowercased) and the profile with the canonical header names.
+func NewProfile(name string, p Profile) (string, Profile, error) {
+	var normalized = strings.ToLower(strings.TrimSpace(name))
+
+	if normalized == "" || len(normalized) > maxProfileNameLength {
+		return "", Profile{}, fmt.Errorf("wrong profile name [%s]", name)
+	}
+
+	for _, r := range normalized {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			return "", Profile{}, fmt.Errorf("wrong profile name [%s]: unexpected character %q", name, r)
+		}
+	}
+
+	if p.Code > 999 { //nolint:mnd
+		return "", Profile{}, fmt.Errorf("profile [%s]: wrong HTTP code [%d]", name, p.Code)
+	}
+
+	if len(p.Headers) > 0 {
+		var headers = make(map[string]string, len(p.Headers))
+
+		for key, value := range p.Headers {
+			if key = strings.TrimSpace(key); key == "" || strings.ContainsAny(key, " \t\r\n:") {
+				return "", Profile{}, fmt.Errorf("profile [%s]: wrong header name [%s]", name, key)
+			}
+
+			if strings.ContainsAny(value, "\r\n") {
+				return "", Profile{}, fmt.Errorf("profile [%s]: header [%s] value contains a line break", name, key)
+			}
+
+			headers[textproto.CanonicalMIMEHeaderKey(key)] = strings.TrimSpace(value)
+		}
+
+		p.Headers = headers
+	}
+
+	return normalized, p, nil
+}
+
+// Find returns the profile by its name (the case and surrounding spaces are ignored).
+func (p Profiles) Find(name string) (Profile, bool) {
+	if len(p) == 0 || name == "" || len(name) > maxProfileNameLength+2 { // +2 for the surrounding spaces
+		return Profile{}, false
+	}
+
+	profile, ok := p[strings.ToLower(strings.TrimSpace(name))]
+
+	return profile, ok
+}
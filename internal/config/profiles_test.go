@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestNewProfile(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveName    string
+		giveProfile config.Profile
+		wantName    string
+		wantProfile config.Profile
+		wantErr     bool
+	}{
+		"simple": {
+			giveName:    "blocked-by-waf",
+			giveProfile: config.Profile{Code: 403},
+			wantName:    "blocked-by-waf",
+			wantProfile: config.Profile{Code: 403},
+		},
+		"normalized": {
+			giveName:    " Billing_Suspended ",
+			giveProfile: config.Profile{Headers: map[string]string{" x-billing-status ": " suspended "}},
+			wantName:    "billing_suspended",
+			wantProfile: config.Profile{Headers: map[string]string{"X-Billing-Status": "suspended"}},
+		},
+		"template only": {
+			giveName:    "foo",
+			giveProfile: config.Profile{Template: "ghost"},
+			wantName:    "foo",
+			wantProfile: config.Profile{Template: "ghost"},
+		},
+		"empty name": {giveName: " ", wantErr: true},
+		"dot":        {giveName: "blocked.waf", wantErr: true},
+		"too long":   {giveName: string(make([]byte, 65)), wantErr: true},
+		"wrong code": {giveName: "foo", giveProfile: config.Profile{Code: 1000}, wantErr: true},
+		"empty header name": {
+			giveName:    "foo",
+			giveProfile: config.Profile{Headers: map[string]string{"": "x"}},
+			wantErr:     true,
+		},
+		"wrong header name": {
+			giveName:    "foo",
+			giveProfile: config.Profile{Headers: map[string]string{"X-A:": "x"}},
+			wantErr:     true,
+		},
+		"header line breaks": {
+			giveName:    "foo",
+			giveProfile: config.Profile{Headers: map[string]string{"X-A": "x\r\nY: z"}},
+			wantErr:     true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gotName, gotProfile, err := config.NewProfile(tt.giveName, tt.giveProfile)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantName, gotName)
+				assert.Equal(t, tt.wantProfile, gotProfile)
+			}
+		})
+	}
+}
+
+func TestProfiles_Find(t *testing.T) {
+	t.Parallel()
+
+	var profiles = config.Profiles{"blocked-by-waf": {Code: 403}}
+
+	profile, ok := profiles.Find(" Blocked-By-WAF ")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(403), profile.Code)
+
+	_, ok = profiles.Find("billing-suspended")
+	assert.False(t, ok)
+
+	_, ok = profiles.Find("")
+	assert.False(t, ok)
+
+	_, ok = config.Profiles(nil).Find("blocked-by-waf")
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProxyMode represents the reverse proxy integration, which determines the names of the request headers with the
+// details of the original (failed) request. The code is read from the `X-Code` header in every mode.
+type ProxyMode byte
+
+const (
+	ProxyModeGeneric ProxyMode = iota // ingress-nginx and the generic proxies (`X-Original-URI`), default
+	ProxyModeTraefik                  // the Traefik errors middleware and forward auth (`X-Forwarded-Uri`)
+)
+
+// String returns a human-readable representation of the proxy mode.
+func (m ProxyMode) String() string {
+	switch m {
+	case ProxyModeGeneric:
+		return "generic"
+	case ProxyModeTraefik:
+		return "traefik"
+	}
+
+	return fmt.Sprintf("ProxyMode(%d)", m)
+}
+
+// OriginalURIHeader returns the name of the request header with the URI of the original request.
+func (m ProxyMode) OriginalURIHeader() string {
+	if m == ProxyModeTraefik {
+		return "X-Forwarded-Uri"
+	}
+
+	return "X-Original-URI"
+}
+
+// ForwardedForHeader returns the name of the request header with the client addresses chain.
+func (ProxyMode) ForwardedForHeader() string { return "X-Forwarded-For" }
+
+// ProxyModes returns a slice of all proxy modes.
+func ProxyModes() []ProxyMode { return []ProxyMode{ProxyModeGeneric, ProxyModeTraefik} }
+
+// ProxyModeStrings returns a slice of all proxy modes as strings.
+func ProxyModeStrings() []string {
+	var (
+		modes  = ProxyModes()
+		result = make([]string, len(modes))
+	)
+
+	for i := range modes {
+		result[i] = modes[i].String()
+	}
+
+	return result
+}
+
+// ParseProxyMode parses a proxy mode (case is ignored, an empty string means generic). If the provided string is
+// invalid, an error is returned.
+func ParseProxyMode(s string) (ProxyMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case ProxyModeGeneric.String(), "":
+		return ProxyModeGeneric, nil
+	case ProxyModeTraefik.String():
+		return ProxyModeTraefik, nil
+	}
+
+	return ProxyModeGeneric, fmt.Errorf("unrecognized proxy mode: %q", s)
+}
+
+// Proxy contains settings of the reverse proxy integration.
+type Proxy struct {
+	// Mode determines the default names of the request headers set by the proxy.
+	Mode ProxyMode
+
+	// OriginalURI and ForwardedFor override the names of the request headers with the URI of the original request
+	// and the client addresses chain (the mode defaults are used if empty).
+	OriginalURI, ForwardedFor string
+}
+
+// OriginalURIHeader returns the name of the request header with the URI of the original request.
+func (p Proxy) OriginalURIHeader() string {
+	if p.OriginalURI != "" {
+		return p.OriginalURI
+	}
+
+	return p.Mode.OriginalURIHeader()
+}
+
+// ForwardedForHeader returns the name of the request header with the client addresses chain.
+func (p Proxy) ForwardedForHeader() string {
+	if p.ForwardedFor != "" {
+		return p.ForwardedFor
+	}
+
+	return p.Mode.ForwardedForHeader()
+}
+
+// ValidateProxyHeader checks that the proxy header name is a valid HTTP token (an empty name means the mode
+// default).
+func ValidateProxyHeader(name string) error {
+	for _, r := range name {
+		if !isTokenChar(r) {
+			return fmt.Errorf("wrong proxy header name [%s]", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestProxyMode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"generic", "traefik"}, config.ProxyModeStrings())
+	assert.Equal(t, "ProxyMode(255)", config.ProxyMode(255).String())
+
+	for give, want := range map[string]config.ProxyMode{
+		"":          config.ProxyModeGeneric,
+		"generic":   config.ProxyModeGeneric,
+		" TRAEFIK ": config.ProxyModeTraefik,
+	} {
+		got, err := config.ParseProxyMode(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseProxyMode("nginx")
+	assert.ErrorContains(t, err, "unrecognized proxy mode")
+}
+
+func TestProxy_Headers(t *testing.T) {
+	t.Parallel()
+
+	var proxy config.Proxy
+
+	assert.Equal(t, "X-Original-URI", proxy.OriginalURIHeader())
+	assert.Equal(t, "X-Forwarded-For", proxy.ForwardedForHeader())
+
+	proxy.Mode = config.ProxyModeTraefik
+
+	assert.Equal(t, "X-Forwarded-Uri", proxy.OriginalURIHeader())
+	assert.Equal(t, "X-Forwarded-For", proxy.ForwardedForHeader())
+
+	proxy.OriginalURI, proxy.ForwardedFor = "X-Request-URI", "X-Real-Chain"
+
+	assert.Equal(t, "X-Request-URI", proxy.OriginalURIHeader())
+	assert.Equal(t, "X-Real-Chain", proxy.ForwardedForHeader())
+}
+
+func TestValidateProxyHeader(t *testing.T) {
+	t.Parallel()
+
+	for _, give := range []string{"", "X-Forwarded-Uri", "x_original_uri"} {
+		assert.NoError(t, config.ValidateProxyHeader(give), give)
+	}
+
+	for _, give := range []string{"X Forwarded", "X-Uri:", "X-Ürl"} {
+		assert.Error(t, config.ValidateProxyHeader(give), give)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RequestID contains settings of the request IDs - the upstream ones, and the generated ones.
+type RequestID struct {
+	// Headers are the request headers with the upstream request ID, in the order of precedence. The upstream ID is
+	// used (prefixed) instead of generating a new one.
+	Headers []string
+
+	// Prefix is the `{prefix}` of the request IDs (the datacenter code is used if empty).
+	Prefix string
+
+	// Layout is the mini-template of the generated request IDs, like `{prefix}-{rand:5}-{uuidv7}` (the layout of
+	// the [RequestIDFormat] is used if empty). Read [ParseRequestIDLayout] for the placeholders.
+	Layout string
+
+	// CFRay passes the Cloudflare `CF-Ray` header through as the request ID (as-is, without the prefix), so the IDs
+	// match the Cloudflare logs. It takes precedence over the other headers.
+	CFRay bool
+}
+
+// DefaultRequestIDHeaders returns the default request headers with the upstream request ID.
+func DefaultRequestIDHeaders() []string { return []string{"X-Request-Id", "X-RequestID"} }
+
+// Layout returns the request ID layout of the format.
+func (f RequestIDFormat) Layout() string {
+	switch f {
+	case RequestIDFormatULID:
+		return "{prefix}-{ulid}"
+	case RequestIDFormatSonyflake:
+		return "{prefix}-{sonyflake}" // fixed width keeps the lexicographic order
+	}
+
+	return "{prefix}-{rand:5}-{uuidv7}"
+}
+
+// RequestIDPart is a part of the request ID layout - the literal text or the placeholder.
+type RequestIDPart struct {
+	Literal     string // the literal text (if the placeholder is empty)
+	Placeholder string // the placeholder name, like `uuidv7`
+	Size        uint   // the number of the random bytes (for the `rand` placeholder)
+}
+
+// The request ID layout limits.
+const (
+	maxRequestIDRandSize = 32
+	requestIDLiteralSet  = "-_.:+=/@" // besides the letters and digits (the safe characters of the request IDs)
+)
+
+// ParseRequestIDLayout parses the request ID layout. The placeholders are:
+//
+//   - `{prefix}` - the configured prefix, or the datacenter code
+//   - `{rand:N}` - N random bytes (1..32) in hex
+//   - `{uuidv7}` and `{uuidv4}` - the UUID without dashes
+//   - `{ulid}` - the ULID (sortable by time)
+//   - `{sonyflake}` - the Sonyflake ID as 16 hex characters (sortable by time)
+//   - `{unixms}` - the Unix time in milliseconds
+//
+// The rest of the layout is the literal text (letters, digits, and "-_.:+=/@" only). At least one unique part
+// (random, or time-ordered) is required.
+func ParseRequestIDLayout(s string) ([]RequestIDPart, error) {
+	var (
+		parts  []RequestIDPart
+		unique bool
+		rest   = s
+	)
+
+	for rest != "" {
+		var start = strings.IndexByte(rest, '{')
+		if start != 0 {
+			var literal = rest
+
+			if start > 0 {
+				literal = rest[:start]
+			}
+
+			if r, ok := unsafeRequestIDRune(literal); ok {
+				return nil, fmt.Errorf("wrong request ID layout [%s]: the character %q is not allowed", s, r)
+			}
+
+			parts, rest = append(parts, RequestIDPart{Literal: literal}), rest[len(literal):]
+
+			continue
+		}
+
+		var end = strings.IndexByte(rest, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("wrong request ID layout [%s]: unclosed placeholder", s)
+		}
+
+		var part = RequestIDPart{Placeholder: rest[1:end]}
+
+		switch name, arg, hasArg := strings.Cut(part.Placeholder, ":"); {
+		case name == "rand" && hasArg:
+			size, err := strconv.ParseUint(arg, 10, 8)
+			if err != nil || size == 0 || size > maxRequestIDRandSize {
+				return nil, fmt.Errorf("wrong request ID layout [%s]: the random size should be 1..%d bytes",
+					s, maxRequestIDRandSize)
+			}
+
+			part.Placeholder, part.Size, unique = name, uint(size), true
+		case hasArg:
+			return nil, fmt.Errorf("wrong request ID layout [%s]: unexpected argument of {%s}", s, name)
+		case name == "uuidv7", name == "uuidv4", name == "ulid", name == "sonyflake":
+			unique = true
+		case name == "prefix", name == "unixms":
+		default:
+			return nil, fmt.Errorf("wrong request ID layout [%s]: unknown placeholder {%s}", s, part.Placeholder)
+		}
+
+		parts, rest = append(parts, part), rest[end+1:]
+	}
+
+	if !unique {
+		return nil, fmt.Errorf("wrong request ID layout [%s]: no random or time-ordered placeholder", s)
+	}
+
+	return parts, nil
+}
+
+// ValidateRequestIDPrefix checks that the request ID prefix has the safe characters only (letters, digits, and
+// "-_.:+=/@").
+func ValidateRequestIDPrefix(s string) error {
+	if r, ok := unsafeRequestIDRune(s); ok {
+		return fmt.Errorf("wrong request ID prefix [%s]: the character %q is not allowed", s, r)
+	}
+
+	return nil
+}
+
+// unsafeRequestIDRune returns the first character, not allowed in the request IDs.
+func unsafeRequestIDRune(s string) (rune, bool) {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') &&
+			!strings.ContainsRune(requestIDLiteralSet, r) {
+			return r, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequestIDFormat represents the format of the generated request IDs.
+type RequestIDFormat byte
+
+const (
+	RequestIDFormatDefault   RequestIDFormat = iota // random hex + UUIDv7 (without dashes), default
+	RequestIDFormatULID                             // ULID (lexicographically sortable by time)
+	RequestIDFormatSonyflake                        // Sonyflake (time-ordered, the datacenter code is the machine ID)
+)
+
+// String returns a human-readable representation of the request ID format.
+func (f RequestIDFormat) String() string {
+	switch f {
+	case RequestIDFormatDefault:
+		return "default"
+	case RequestIDFormatULID:
+		return "ulid"
+	case RequestIDFormatSonyflake:
+		return "sonyflake"
+	}
+
+	return fmt.Sprintf("RequestIDFormat(%d)", f)
+}
+
+// RequestIDFormats returns a slice of all request ID formats.
+func RequestIDFormats() []RequestIDFormat {
+	return []RequestIDFormat{RequestIDFormatDefault, RequestIDFormatULID, RequestIDFormatSonyflake}
+}
+
+// RequestIDFormatStrings returns a slice of all request ID formats as strings.
+func RequestIDFormatStrings() []string {
+	var (
+		formats = RequestIDFormats()
+		result  = make([]string, len(formats))
+	)
+
+	for i := range formats {
+		result[i] = formats[i].String()
+	}
+
+	return result
+}
+
+// ParseRequestIDFormat parses a request ID format (case is ignored). If the provided string is invalid, an error
+// is returned.
+func ParseRequestIDFormat(s string) (RequestIDFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case RequestIDFormatDefault.String(), "":
+		return RequestIDFormatDefault, nil
+	case RequestIDFormatULID.String():
+		return RequestIDFormatULID, nil
+	case RequestIDFormatSonyflake.String():
+		return RequestIDFormatSonyflake, nil
+	}
+
+	return RequestIDFormatDefault, fmt.Errorf("unrecognized request ID format: %q", s)
+}
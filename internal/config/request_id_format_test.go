@@ -0,0 +1,33 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestRequestIDFormat(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"default", "ulid", "sonyflake"}, config.RequestIDFormatStrings())
+	assert.Equal(t, "RequestIDFormat(255)", config.RequestIDFormat(255).String())
+
+	for give, want := range map[string]config.RequestIDFormat{
+		"":           config.RequestIDFormatDefault,
+		"default":    config.RequestIDFormatDefault,
+		" ULID ":     config.RequestIDFormatULID,
+		"SonyFlake":  config.RequestIDFormatSonyflake,
+		"sonyflake ": config.RequestIDFormatSonyflake,
+	} {
+		got, err := config.ParseRequestIDFormat(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseRequestIDFormat("uuid")
+	assert.ErrorContains(t, err, "unrecognized request ID format")
+}
@@ -0,0 +1,60 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestParseRequestIDLayout(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range config.RequestIDFormats() {
+		_, err := config.ParseRequestIDLayout(format.Layout())
+		require.NoError(t, err, format)
+	}
+
+	parts, err := config.ParseRequestIDLayout("req_{prefix}-{rand:3}.{unixms}{uuidv4}")
+	require.NoError(t, err)
+
+	assert.Equal(t, []config.RequestIDPart{
+		{Literal: "req_"},
+		{Placeholder: "prefix"},
+		{Literal: "-"},
+		{Placeholder: "rand", Size: 3},
+		{Literal: "."},
+		{Placeholder: "unixms"},
+		{Placeholder: "uuidv4"},
+	}, parts)
+
+	for give, wantErr := range map[string]string{
+		"":                     "no random or time-ordered placeholder",
+		"{prefix}-{unixms}":    "no random or time-ordered placeholder",
+		"{prefix}-{uuidv7":     "unclosed placeholder",
+		"{prefix} {uuidv7}":    `the character ' ' is not allowed`,
+		"<{ulid}>":             `the character '<' is not allowed`,
+		"{rand:0}":             "the random size should be 1..32 bytes",
+		"{rand:33}":            "the random size should be 1..32 bytes",
+		"{rand}":               "unknown placeholder {rand}",
+		"{ulid:5}":             "unexpected argument of {ulid}",
+		"{prefix}-{snowflake}": "unknown placeholder {snowflake}",
+	} {
+		_, err = config.ParseRequestIDLayout(give)
+		assert.ErrorContains(t, err, wantErr, give)
+	}
+}
+
+func TestValidateRequestIDPrefix(t *testing.T) {
+	t.Parallel()
+
+	for _, give := range []string{"", "FRA", "eu-west-1a", "app@prod"} {
+		assert.NoError(t, config.ValidateRequestIDPrefix(give), give)
+	}
+
+	for _, give := range []string{"eu west", "<b>", "фра"} {
+		assert.Error(t, config.ValidateRequestIDPrefix(give), give)
+	}
+}
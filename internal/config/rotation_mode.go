@@ -14,6 +14,7 @@ const (
 	RotationModeRandomOnEachRequest                     // pick a random template on each request
 	RotationModeRandomHourly                            // once an hour switch to a random template
 	RotationModeRandomDaily                             // once a day switch to a random template
+	RotationModeExperiment                              // split the traffic between two templates (A/B test)
 )
 
 // String returns a human-readable representation of the rotation mode.
@@ -29,6 +30,8 @@ func (rm RotationMode) String() string {
 		return "random-hourly"
 	case RotationModeRandomDaily:
 		return "random-daily"
+	case RotationModeExperiment:
+		return "experiment"
 	}
 
 	return fmt.Sprintf("RotationMode(%d)", rm)
@@ -42,6 +45,7 @@ func RotationModes() []RotationMode {
 		RotationModeRandomOnEachRequest,
 		RotationModeRandomHourly,
 		RotationModeRandomDaily,
+		RotationModeExperiment,
 	}
 }
 
@@ -81,6 +85,8 @@ func ParseRotationMode[T string | []byte](text T) (RotationMode, error) {
 		return RotationModeRandomHourly, nil
 	case RotationModeRandomDaily.String():
 		return RotationModeRandomDaily, nil
+	case RotationModeExperiment.String():
+		return RotationModeExperiment, nil
 	}
 
 	return RotationModeDisabled, fmt.Errorf("unrecognized rotation mode: %q", mode)
@@ -16,6 +16,7 @@ func TestRotationMode_String(t *testing.T) {
 	assert.Equal(t, "random-on-each-request", config.RotationModeRandomOnEachRequest.String())
 	assert.Equal(t, "random-daily", config.RotationModeRandomDaily.String())
 	assert.Equal(t, "random-hourly", config.RotationModeRandomHourly.String())
+	assert.Equal(t, "experiment", config.RotationModeExperiment.String())
 
 	assert.Equal(t, "RotationMode(255)", config.RotationMode(255).String())
 }
@@ -29,6 +30,7 @@ func TestRotationModes(t *testing.T) {
 		config.RotationModeRandomOnEachRequest,
 		config.RotationModeRandomHourly,
 		config.RotationModeRandomDaily,
+		config.RotationModeExperiment,
 	}, config.RotationModes())
 }
 
@@ -41,6 +43,7 @@ func TestRotationModeStrings(t *testing.T) {
 		"random-on-each-request",
 		"random-hourly",
 		"random-daily",
+		"experiment",
 	}, config.RotationModeStrings())
 }
 
@@ -62,6 +65,7 @@ func TestParseRotationMode(t *testing.T) {
 		"on-each-request":           {giveString: "random-on-each-request", wantMode: config.RotationModeRandomOnEachRequest},
 		"daily":                     {giveString: "random-daily", wantMode: config.RotationModeRandomDaily},
 		"hourly":                    {giveString: "random-hourly", wantMode: config.RotationModeRandomHourly},
+		"experiment":                {giveString: "experiment", wantMode: config.RotationModeExperiment},
 
 		"foobar": {giveString: "foobar", wantErrorMsg: "unrecognized rotation mode: \"foobar\""},
 	} {
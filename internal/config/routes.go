@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type (
+	// Route maps the request path pattern to the HTTP code and/or the template name.
+	Route struct {
+		// Pattern is a regular expression the request path is matched against.
+		Pattern *regexp.Regexp
+
+		// Code is an HTTP code to render (zero means the code is detected as usual).
+		Code uint16
+
+		// Template is a template name to use (empty means the template is selected as usual).
+		Template string
+	}
+
+	// Routes is an ordered list of the routes. The first matched route wins.
+	Routes []Route
+)
+
+// NewRoute creates a new route with the given pattern, HTTP code, and template name. At least one of the code or
+// template should be specified. Only the template name syntax is checked here, because the templates may be added
+// later - the caller should make sure the template is available once all of them are loaded.
+func NewRoute(pattern string, code uint16, template string) (Route, error) {
+	if code == 0 && template == "" {
+		return Route{}, fmt.Errorf("route [%s]: the code or template must be specified", pattern)
+	}
+
+	if strings.ContainsFunc(template, func(r rune) bool { return unicode.IsSpace(r) || r == ':' || r == '=' }) {
+		return Route{}, fmt.Errorf("route [%s]: wrong template name [%s]", pattern, template)
+	}
+
+	if code > 999 { //nolint:mnd
+		return Route{}, fmt.Errorf("route [%s]: wrong HTTP code [%d]", pattern, code)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Route{}, fmt.Errorf("route [%s]: %w", pattern, err)
+	}
+
+	return Route{Pattern: re, Code: code, Template: template}, nil
+}
+
+// ParseRoute parses the route in the `PATTERN=CODE[:TEMPLATE]` format (e.g. `^/old-api/=410` or
+// `^/internal/=403:ghost`). The code may be omitted to change the template only (`^/docs/=:connection`).
+func ParseRoute(s string) (Route, error) {
+	var idx = strings.LastIndex(s, "=")
+	if idx < 1 {
+		return Route{}, fmt.Errorf("route [%s]: expected format is PATTERN=CODE[:TEMPLATE]", s)
+	}
+
+	var (
+		pattern         = s[:idx]
+		codeStr, tpl, _ = strings.Cut(s[idx+1:], ":")
+		code            uint64
+	)
+
+	if codeStr = strings.TrimSpace(codeStr); codeStr != "" {
+		var err error
+
+		if code, err = strconv.ParseUint(codeStr, 10, 16); err != nil {
+			return Route{}, fmt.Errorf("route [%s]: wrong HTTP code [%s]", s, codeStr)
+		}
+	}
+
+	return NewRoute(pattern, uint16(code), strings.TrimSpace(tpl))
+}
+
+// Match returns the first route that matches the given request path.
+func (r Routes) Match(path string) (Route, bool) {
+	for _, route := range r {
+		if route.Pattern != nil && route.Pattern.MatchString(path) {
+			return route, true
+		}
+	}
+
+	return Route{}, false
+}
@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestParseRoute(t *testing.T) {
+	t.Parallel()
+
+	for give, tt := range map[string]struct {
+		wantPattern  string
+		wantCode     uint16
+		wantTemplate string
+		wantErr      bool
+	}{
+		"^/old-api/=410":          {wantPattern: "^/old-api/", wantCode: 410},
+		"^/internal/=403:ghost":   {wantPattern: "^/internal/", wantCode: 403, wantTemplate: "ghost"},
+		"^/docs/=:connection":     {wantPattern: "^/docs/", wantTemplate: "connection"},
+		"^/a=b/=404":              {wantPattern: "^/a=b/", wantCode: 404},
+		"^/foo/":                  {wantErr: true},
+		"=404":                    {wantErr: true},
+		"^/foo/=":                 {wantErr: true},
+		"^/foo/=abc":              {wantErr: true},
+		"^/foo/=1000":             {wantErr: true},
+		"(=404":                   {wantErr: true},
+		"^/foo/=70000:connection": {wantErr: true},
+		"^/foo/=404:a:b":          {wantErr: true},
+		"^/foo/=404:my theme":     {wantErr: true},
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			var route, err = config.ParseRoute(give)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPattern, route.Pattern.String())
+			assert.Equal(t, tt.wantCode, route.Code)
+			assert.Equal(t, tt.wantTemplate, route.Template)
+		})
+	}
+}
+
+func TestRoutes_Match(t *testing.T) {
+	t.Parallel()
+
+	var routes = make(config.Routes, 0, 2)
+
+	for _, s := range []string{`^/old-api/=410`, `^/old-api/v2/=404`, `\.php$=403`} {
+		route, err := config.ParseRoute(s)
+		require.NoError(t, err)
+
+		routes = append(routes, route)
+	}
+
+	route, ok := routes.Match("/old-api/v2/users")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(410), route.Code) // the first match wins
+
+	route, ok = routes.Match("/wp-login.php")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(403), route.Code)
+
+	_, ok = routes.Match("/foo")
+	assert.False(t, ok)
+
+	_, ok = config.Routes(nil).Match("/foo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SigningAlgorithm represents the algorithm used to sign the rendered response bodies.
+type SigningAlgorithm byte
+
+const (
+	SigningNone       SigningAlgorithm = iota // the responses are not signed, default
+	SigningHMACSHA256                         // HMAC-SHA256 with the shared secret
+	SigningEd25519                            // Ed25519 with the private key (the public key is logged on startup)
+)
+
+// String returns a human-readable representation of the signing algorithm.
+func (a SigningAlgorithm) String() string {
+	switch a {
+	case SigningNone:
+		return "none"
+	case SigningHMACSHA256:
+		return "hmac-sha256"
+	case SigningEd25519:
+		return "ed25519"
+	}
+
+	return fmt.Sprintf("SigningAlgorithm(%d)", a)
+}
+
+// SigningAlgorithms returns a slice of all signing algorithms.
+func SigningAlgorithms() []SigningAlgorithm {
+	return []SigningAlgorithm{SigningNone, SigningHMACSHA256, SigningEd25519}
+}
+
+// SigningAlgorithmStrings returns a slice of all signing algorithms as strings.
+func SigningAlgorithmStrings() []string {
+	var (
+		algorithms = SigningAlgorithms()
+		result     = make([]string, len(algorithms))
+	)
+
+	for i := range algorithms {
+		result[i] = algorithms[i].String()
+	}
+
+	return result
+}
+
+// ParseSigningAlgorithm parses a signing algorithm (case is ignored, an empty string means none). If the provided
+// string is invalid, an error is returned.
+func ParseSigningAlgorithm(s string) (SigningAlgorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case SigningNone.String(), "":
+		return SigningNone, nil
+	case SigningHMACSHA256.String():
+		return SigningHMACSHA256, nil
+	case SigningEd25519.String():
+		return SigningEd25519, nil
+	}
+
+	return SigningNone, fmt.Errorf("unrecognized signing algorithm: %q", s)
+}
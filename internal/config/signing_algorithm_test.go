@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestSigningAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"none", "hmac-sha256", "ed25519"}, config.SigningAlgorithmStrings())
+	assert.Equal(t, "SigningAlgorithm(255)", config.SigningAlgorithm(255).String())
+
+	for give, want := range map[string]config.SigningAlgorithm{
+		"":              config.SigningNone,
+		"none":          config.SigningNone,
+		" HMAC-SHA256 ": config.SigningHMACSHA256,
+		"Ed25519":       config.SigningEd25519,
+	} {
+		got, err := config.ParseSigningAlgorithm(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseSigningAlgorithm("rsa")
+	assert.ErrorContains(t, err, "unrecognized signing algorithm")
+}
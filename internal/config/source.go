@@ -0,0 +1,149 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// SourceOptions contains the options for reading the configuration from the source.
+type SourceOptions struct {
+	// SHA256 is the expected SHA-256 checksum of the content (hex encoded). If empty, the checksum is not checked.
+	SHA256 string
+
+	// InsecureSkipVerify disables the TLS certificate verification for the remote (https) sources.
+	InsecureSkipVerify bool
+
+	// Stdin is used to read the content when the source is `-` (os.Stdin will be used if nil).
+	Stdin io.Reader
+
+	// HTTPClient is used to fetch the remote sources (a client with the default timeout will be used if nil).
+	HTTPClient interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+}
+
+// maxSourceSize limits the configuration size to avoid memory exhaustion by the malicious (or broken) sources.
+const maxSourceSize = 16 << 20 // 16 MiB
+
+// ReadSource reads the configuration content from the source, which may be:
+//
+//   - `-` to read from the standard input
+//   - `http://...` or `https://...` to fetch the content from the URL
+//   - any other value is treated as a path to the local file
+func ReadSource(ctx context.Context, source string, opt SourceOptions) ([]byte, error) {
+	var (
+		data, want []byte
+		err        error
+	)
+
+	if opt.SHA256 != "" { // validate the checksum format before reading (or downloading) the content
+		if want, err = ParseSHA256(opt.SHA256); err != nil {
+			return nil, err
+		}
+	}
+
+	switch lower := strings.ToLower(source); {
+	case source == "":
+		return nil, errors.New("empty configuration source")
+
+	case source == "-":
+		var in = opt.Stdin
+		if in == nil {
+			in = os.Stdin
+		}
+
+		if data, err = io.ReadAll(io.LimitReader(in, maxSourceSize+1)); err != nil {
+			return nil, fmt.Errorf("cannot read the configuration from stdin: %w", err)
+		}
+
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		if data, err = fetchSource(ctx, source, opt); err != nil {
+			return nil, err
+		}
+
+	default:
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, fmt.Errorf("cannot read the configuration file: %w", err)
+		}
+	}
+
+	if len(data) > maxSourceSize {
+		return nil, fmt.Errorf("the configuration is too large (limit is %d bytes)", maxSourceSize)
+	}
+
+	if want != nil {
+		if got := sha256.Sum256(data); subtle.ConstantTimeCompare(got[:], want) != 1 {
+			return nil, fmt.Errorf("configuration checksum mismatch (got %s)", hex.EncodeToString(got[:]))
+		}
+	}
+
+	return data, nil
+}
+
+// ParseSHA256 parses the hex encoded SHA-256 checksum (case-insensitive, the `sha256:` prefix is allowed).
+func ParseSHA256(s string) ([]byte, error) {
+	var digits = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "sha256:")
+
+	if len(digits) != hex.EncodedLen(sha256.Size) {
+		return nil, fmt.Errorf("wrong SHA-256 checksum format: expected %d hex characters, got %d",
+			hex.EncodedLen(sha256.Size), len(digits),
+		)
+	}
+
+	sum, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, fmt.Errorf("wrong SHA-256 checksum format: %w", err)
+	}
+
+	return sum, nil
+}
+
+// fetchSource fetches the configuration content from the URL.
+func fetchSource(ctx context.Context, url string, opt SourceOptions) ([]byte, error) {
+	var client = opt.HTTPClient
+
+	if client == nil {
+		const timeout = 30 * time.Second
+
+		client = &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: opt.InsecureSkipVerify}, //nolint:gosec // user's choice
+			},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch the configuration: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot fetch the configuration: unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the configuration: %w", err)
+	}
+
+	return data, nil
+}
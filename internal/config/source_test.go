@@ -0,0 +1,156 @@
+package config_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestReadSource(t *testing.T) {
+	t.Parallel()
+
+	const content = "show_details: true\n"
+
+	var (
+		ctx      = context.Background()
+		sum      = sha256.Sum256([]byte(content))
+		checksum = hex.EncodeToString(sum[:])
+	)
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+
+		var path = filepath.Join(t.TempDir(), "config.yml")
+
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		data, err := config.ReadSource(ctx, path, config.SourceOptions{SHA256: checksum})
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		_, err = config.ReadSource(ctx, path+".missing", config.SourceOptions{})
+		assert.ErrorContains(t, err, "cannot read the configuration file")
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := config.ReadSource(ctx, "-", config.SourceOptions{
+			Stdin:  strings.NewReader(content),
+			SHA256: "sha256:" + strings.ToUpper(checksum),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+
+	t.Run("url", func(t *testing.T) {
+		t.Parallel()
+
+		var srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/config.yml" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(content))
+		}))
+
+		defer srv.Close()
+
+		data, err := config.ReadSource(ctx, srv.URL+"/config.yml", config.SourceOptions{
+			HTTPClient: srv.Client(),
+			SHA256:     checksum,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		_, err = config.ReadSource(ctx, srv.URL+"/config.yml", config.SourceOptions{}) // self-signed certificate
+		assert.ErrorContains(t, err, "certificate")
+
+		data, err = config.ReadSource(ctx, srv.URL+"/config.yml", config.SourceOptions{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		_, err = config.ReadSource(ctx, srv.URL+"/missing", config.SourceOptions{HTTPClient: srv.Client()})
+		assert.ErrorContains(t, err, "unexpected status code 404")
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.ReadSource(ctx, "-", config.SourceOptions{
+			Stdin:  strings.NewReader(content + "#"),
+			SHA256: checksum,
+		})
+		assert.ErrorContains(t, err, "checksum mismatch")
+
+	})
+
+	t.Run("wrong checksum format", func(t *testing.T) {
+		t.Parallel()
+
+		var requested bool
+
+		var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requested = true
+
+			_, _ = w.Write([]byte(content))
+		}))
+
+		defer srv.Close()
+
+		for _, give := range []string{"foo", checksum[:63], checksum + "00", strings.Repeat("z", 64)} {
+			_, err := config.ReadSource(ctx, srv.URL, config.SourceOptions{HTTPClient: srv.Client(), SHA256: give})
+			assert.ErrorContains(t, err, "wrong SHA-256 checksum format", give)
+		}
+
+		assert.False(t, requested, "the content must not be downloaded")
+	})
+
+	t.Run("empty source", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.ReadSource(ctx, "", config.SourceOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSHA256(t *testing.T) {
+	t.Parallel()
+
+	var sum = sha256.Sum256([]byte("foo"))
+
+	for _, give := range []string{
+		hex.EncodeToString(sum[:]),
+		"sha256:" + hex.EncodeToString(sum[:]),
+		" SHA256:" + strings.ToUpper(hex.EncodeToString(sum[:])) + " ",
+	} {
+		got, err := config.ParseSHA256(give)
+		require.NoError(t, err, give)
+		assert.Equal(t, sum[:], got, give)
+	}
+
+	for give, wantErr := range map[string]string{
+		"":                          "expected 64 hex characters, got 0",
+		"sha256:":                   "expected 64 hex characters, got 0",
+		"abc":                       "expected 64 hex characters, got 3",
+		hex.EncodeToString(sum[:1]): "expected 64 hex characters, got 2",
+		strings.Repeat("g", 64):     "invalid byte",
+	} {
+		_, err := config.ParseSHA256(give)
+		assert.ErrorContains(t, err, "wrong SHA-256 checksum format", give)
+		assert.ErrorContains(t, err, wantErr, give)
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+// TemplateOptions are the per-template options, to opt out of the minification and/or caching of the pages
+// rendered from the template, or to preload its assets.
+type TemplateOptions struct {
+	// DisableMinification means the rendered page is not minified (e.g. for the animation-critical whitespace).
+	DisableMinification bool
+
+	// DisableCache means the rendered page is not cached, so the template is rendered on every request (e.g. when
+	// it embeds the per-request nonces).
+	DisableCache bool
+
+	// Preload is a list of the `Link` header values (see [PreloadLink]) sent with the pages rendered from the
+	// template, so the browsers start fetching the template assets (CSS, JS, fonts) early.
+	Preload []string
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextNormalization is the normalization of the plain text format output, for the terminal clients and SMS
+// gateways, which may mangle the unexpected characters.
+type TextNormalization byte
+
+const (
+	TextNormalizationNone  TextNormalization = iota // the output is sent as rendered, default
+	TextNormalizationSafe                           // the invalid UTF-8, control, and invisible characters are removed
+	TextNormalizationASCII                          // same as safe, and the non-ASCII characters are replaced
+)
+
+// String returns a human-readable representation of the normalization.
+func (n TextNormalization) String() string {
+	switch n {
+	case TextNormalizationNone:
+		return "none"
+	case TextNormalizationSafe:
+		return "safe"
+	case TextNormalizationASCII:
+		return "ascii"
+	}
+
+	return fmt.Sprintf("TextNormalization(%d)", n)
+}
+
+// TextNormalizations returns a slice of all normalizations.
+func TextNormalizations() []TextNormalization {
+	return []TextNormalization{TextNormalizationNone, TextNormalizationSafe, TextNormalizationASCII}
+}
+
+// TextNormalizationStrings returns a slice of all normalizations as strings.
+func TextNormalizationStrings() []string {
+	var (
+		normalizations = TextNormalizations()
+		result         = make([]string, len(normalizations))
+	)
+
+	for i := range normalizations {
+		result[i] = normalizations[i].String()
+	}
+
+	return result
+}
+
+// ParseTextNormalization parses a normalization (case is ignored, an empty string means none). If the provided
+// string is invalid, an error is returned.
+func ParseTextNormalization(s string) (TextNormalization, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case TextNormalizationNone.String(), "":
+		return TextNormalizationNone, nil
+	case TextNormalizationSafe.String():
+		return TextNormalizationSafe, nil
+	case TextNormalizationASCII.String():
+		return TextNormalizationASCII, nil
+	}
+
+	return TextNormalizationNone, fmt.Errorf("unrecognized text normalization: %q", s)
+}
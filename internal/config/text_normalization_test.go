@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestTextNormalization(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"none", "safe", "ascii"}, config.TextNormalizationStrings())
+	assert.Equal(t, "TextNormalization(255)", config.TextNormalization(255).String())
+
+	for give, want := range map[string]config.TextNormalization{
+		"":       config.TextNormalizationNone,
+		"none":   config.TextNormalizationNone,
+		" Safe ": config.TextNormalizationSafe,
+		"ASCII":  config.TextNormalizationASCII,
+	} {
+		got, err := config.ParseTextNormalization(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseTextNormalization("nfc")
+	assert.ErrorContains(t, err, "unrecognized text normalization")
+}
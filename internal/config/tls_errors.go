@@ -0,0 +1,33 @@
+package config
+
+// DefaultTLSErrorHeader is the request header the terminating proxy reports the TLS failures in.
+const DefaultTLSErrorHeader = "X-SSL-Error"
+
+// The built-in TLS error kind names (the proxy may report them directly, or using the nginx and OpenSSL values,
+// like `FAILED:certificate has expired` or `10`).
+const (
+	TLSErrorCertificateExpired         = "certificate_expired"
+	TLSErrorCertificateInvalid         = "certificate_invalid"
+	TLSErrorCertificateRevoked         = "certificate_revoked"
+	TLSErrorCertificateUntrusted       = "certificate_untrusted"
+	TLSErrorCertificateRequired        = "certificate_required"
+	TLSErrorHTTPToHTTPS                = "http_to_https"
+	TLSErrorProtocolUnsupported        = "protocol_unsupported"
+	TLSErrorHandshakeFailed            = "handshake_failed"
+	TLSErrorUpstreamCertificateInvalid = "upstream_certificate_invalid"
+)
+
+// defaultTLSErrors are mapped to the nginx (495, 496, 497) and Cloudflare (525, 526) non-standard codes.
+//
+//nolint:lll
+var defaultTLSErrors = ErrorKinds{ //nolint:gochecknoglobals
+	TLSErrorCertificateExpired:         {Code: 495, Message: "SSL Certificate Expired", Description: "Your client certificate has expired, please renew it and try again"},
+	TLSErrorCertificateInvalid:         {Code: 495, Message: "SSL Certificate Error", Description: "Your client certificate could not be verified"},
+	TLSErrorCertificateRevoked:         {Code: 495, Message: "SSL Certificate Revoked", Description: "Your client certificate has been revoked by its issuer"},
+	TLSErrorCertificateUntrusted:       {Code: 495, Message: "SSL Certificate Untrusted", Description: "Your client certificate is not issued by a trusted authority"},
+	TLSErrorCertificateRequired:        {Code: 496, Message: "SSL Certificate Required", Description: "The requested page requires a valid client certificate"},
+	TLSErrorHTTPToHTTPS:                {Code: 497, Message: "HTTP Request Sent to HTTPS Port", Description: "The plain HTTP request was sent to the HTTPS port, please use https:// instead"},
+	TLSErrorProtocolUnsupported:        {Code: 400, Message: "Unsupported TLS Protocol", Description: "Your client uses a TLS version or cipher suite the server does not support, please update it"},
+	TLSErrorHandshakeFailed:            {Code: 525, Message: "SSL Handshake Failed", Description: "The secure connection to the origin server could not be established"},
+	TLSErrorUpstreamCertificateInvalid: {Code: 526, Message: "Invalid SSL Certificate", Description: "The origin server presented an invalid SSL certificate"},
+}
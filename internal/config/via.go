@@ -0,0 +1,19 @@
+package config
+
+import "fmt"
+
+// ValidateViaPseudonym checks that the pseudonym (the name of this service in the `Via` header chains) is a valid
+// HTTP token (no spaces, commas, etc.).
+func ValidateViaPseudonym(s string) error {
+	if s == "" {
+		return nil // the check is disabled
+	}
+
+	for _, r := range s {
+		if !isTokenChar(r) {
+			return fmt.Errorf("wrong Via pseudonym [%s]: only the HTTP token characters are allowed", s)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestValidateViaPseudonym(t *testing.T) {
+	t.Parallel()
+
+	for give, wantErr := range map[string]bool{
+		"":              false,
+		"error-pages":   false,
+		"errors.local":  false,
+		"error pages":   true,
+		"a,b":           true,
+		"error-pages/1": true,
+	} {
+		if err := config.ValidateViaPseudonym(give); wantErr {
+			assert.Error(t, err, give)
+		} else {
+			assert.NoError(t, err, give)
+		}
+	}
+}
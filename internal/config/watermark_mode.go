@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WatermarkMode represents the way the HTML pages are watermarked with the instance ID, the time, and the request
+// ID (so the screenshots attached to the support tickets can be traced to the serving instance and time).
+type WatermarkMode byte
+
+const (
+	WatermarkModeNone      WatermarkMode = iota // the pages are not watermarked, default
+	WatermarkModeFooter                         // the small footer text in the corner of the page
+	WatermarkModeInvisible                      // the nearly transparent text, revealed by the contrast enhancement
+)
+
+// String returns a human-readable representation of the watermark mode.
+func (m WatermarkMode) String() string {
+	switch m {
+	case WatermarkModeNone:
+		return "none"
+	case WatermarkModeFooter:
+		return "footer"
+	case WatermarkModeInvisible:
+		return "invisible"
+	}
+
+	return fmt.Sprintf("WatermarkMode(%d)", m)
+}
+
+// WatermarkModes returns a slice of all watermark modes.
+func WatermarkModes() []WatermarkMode {
+	return []WatermarkMode{WatermarkModeNone, WatermarkModeFooter, WatermarkModeInvisible}
+}
+
+// WatermarkModeStrings returns a slice of all watermark modes as strings.
+func WatermarkModeStrings() []string {
+	var (
+		modes  = WatermarkModes()
+		result = make([]string, len(modes))
+	)
+
+	for i := range modes {
+		result[i] = modes[i].String()
+	}
+
+	return result
+}
+
+// ParseWatermarkMode parses a watermark mode (case is ignored, an empty string means none). If the provided
+// string is invalid, an error is returned.
+func ParseWatermarkMode(s string) (WatermarkMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case WatermarkModeNone.String(), "":
+		return WatermarkModeNone, nil
+	case WatermarkModeFooter.String():
+		return WatermarkModeFooter, nil
+	case WatermarkModeInvisible.String():
+		return WatermarkModeInvisible, nil
+	}
+
+	return WatermarkModeNone, fmt.Errorf("unrecognized watermark mode: %q", s)
+}
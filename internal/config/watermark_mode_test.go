@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestWatermarkMode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"none", "footer", "invisible"}, config.WatermarkModeStrings())
+	assert.Equal(t, "WatermarkMode(255)", config.WatermarkMode(255).String())
+
+	for give, want := range map[string]config.WatermarkMode{
+		"":          config.WatermarkModeNone,
+		"none":      config.WatermarkModeNone,
+		" FOOTER ":  config.WatermarkModeFooter,
+		"Invisible": config.WatermarkModeInvisible,
+	} {
+		got, err := config.ParseWatermarkMode(give)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := config.ParseWatermarkMode("visible")
+	assert.ErrorContains(t, err, "unrecognized watermark mode")
+}
@@ -0,0 +1,197 @@
+// Package datacenter resolves the code of the datacenter (region, availability zone, or any other location
+// identifier) the service is running in.
+package datacenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default is the datacenter code used when no source provides it.
+const Default = "CYK2"
+
+// Supported cloud metadata services.
+const (
+	MetadataEC2 = "ec2" // AWS EC2 instance metadata (IMDSv2), the availability zone is used
+	MetadataGCP = "gcp" // Google Cloud instance metadata, the zone is used
+)
+
+// MetadataServices returns a list of the supported cloud metadata services.
+func MetadataServices() []string { return []string{MetadataEC2, MetadataGCP} }
+
+// Sources are the sources of the datacenter code, in the order of precedence.
+type Sources struct {
+	// Code is the explicitly set datacenter code.
+	Code string
+
+	// File is a path to the file with the datacenter code (e.g. mounted from the downward API or written by the
+	// provisioning scripts).
+	File string
+
+	// Metadata is the name of the cloud metadata service to query ([MetadataEC2] or [MetadataGCP]).
+	Metadata string
+
+	// MetadataEndpoint overrides the metadata service base URL (the default one is used if empty).
+	MetadataEndpoint string
+
+	// HTTPClient is used to query the metadata service (a client with a short timeout is used if nil).
+	HTTPClient interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+}
+
+const (
+	ec2Endpoint = "http://169.254.169.254"
+	gcpEndpoint = "http://metadata.google.internal"
+
+	maxCodeLength = 64
+)
+
+// Resolve returns the datacenter code from the first configured source (the explicit code, the file, or the
+// metadata service). An empty string (without an error) is returned if no source is configured.
+func Resolve(ctx context.Context, s Sources) (string, error) {
+	var (
+		code string
+		err  error
+	)
+
+	switch {
+	case strings.TrimSpace(s.Code) != "":
+		code = s.Code
+
+	case s.File != "":
+		var data []byte
+
+		if data, err = os.ReadFile(s.File); err != nil {
+			return "", fmt.Errorf("cannot read the datacenter code file: %w", err)
+		}
+
+		code = string(data)
+
+	case s.Metadata != "":
+		if code, err = fromMetadata(ctx, s); err != nil {
+			return "", fmt.Errorf("cannot query the %s metadata service: %w", s.Metadata, err)
+		}
+
+	default:
+		return "", nil
+	}
+
+	return Validate(strings.TrimSpace(code))
+}
+
+// Validate checks the datacenter code: it must be non-empty, up to 64 characters long, and contain only letters,
+// digits, dashes, underscores, and dots (it is used in the request IDs and response headers).
+func Validate(code string) (string, error) {
+	if code == "" {
+		return "", errors.New("empty datacenter code")
+	}
+
+	if len(code) > maxCodeLength {
+		return "", fmt.Errorf("the datacenter code is too long (limit is %d characters)", maxCodeLength)
+	}
+
+	for _, r := range code {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.' {
+			return "", fmt.Errorf("wrong datacenter code [%s]: unexpected character %q", code, r)
+		}
+	}
+
+	return code, nil
+}
+
+// fromMetadata queries the cloud metadata service for the availability zone.
+func fromMetadata(ctx context.Context, s Sources) (string, error) {
+	var client = s.HTTPClient
+
+	if client == nil {
+		const timeout = 3 * time.Second
+
+		client = &http.Client{Timeout: timeout}
+	}
+
+	switch s.Metadata {
+	case MetadataEC2:
+		var endpoint = orDefault(s.MetadataEndpoint, ec2Endpoint)
+
+		// IMDSv2 requires the session token
+		token, err := query(ctx, client, http.MethodPut, endpoint+"/latest/api/token", map[string]string{
+			"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": "60",
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return query(ctx, client, http.MethodGet, endpoint+"/latest/meta-data/placement/availability-zone", map[string]string{
+			"X-Aws-Ec2-Metadata-Token": token,
+		})
+
+	case MetadataGCP:
+		var endpoint = orDefault(s.MetadataEndpoint, gcpEndpoint)
+
+		zone, err := query(ctx, client, http.MethodGet, endpoint+"/computeMetadata/v1/instance/zone", map[string]string{
+			"Metadata-Flavor": "Google",
+		})
+		if err != nil {
+			return "", err
+		}
+
+		// the zone is returned in the `projects/{number}/zones/{zone}` format
+		return zone[strings.LastIndexByte(zone, '/')+1:], nil
+	}
+
+	return "", fmt.Errorf("unsupported metadata service (supported: %s)", strings.Join(MetadataServices(), ", "))
+}
+
+// query sends the request to the metadata service and returns the response body.
+func query(
+	ctx context.Context,
+	client interface {
+		Do(*http.Request) (*http.Response, error)
+	},
+	method, url string,
+	headers map[string]string,
+) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	const maxBodySize = 1 << 10 // 1 KiB is more than enough for the zone name or token
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+
+	return v
+}
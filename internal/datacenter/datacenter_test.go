@@ -0,0 +1,80 @@
+package datacenter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/datacenter"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	var file = filepath.Join(t.TempDir(), "dc")
+
+	require.NoError(t, os.WriteFile(file, []byte(" fra1\n"), 0o600))
+
+	var metadata = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" &&
+			r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds") != "":
+			_, _ = w.Write([]byte("secret-token"))
+		case r.URL.Path == "/latest/meta-data/placement/availability-zone" &&
+			r.Header.Get("X-Aws-Ec2-Metadata-Token") == "secret-token":
+			_, _ = w.Write([]byte("eu-central-1a"))
+		case r.URL.Path == "/computeMetadata/v1/instance/zone" && r.Header.Get("Metadata-Flavor") == "Google":
+			_, _ = w.Write([]byte("projects/123456/zones/us-central1-b\n"))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	t.Cleanup(metadata.Close)
+
+	for name, tt := range map[string]struct {
+		giveSources datacenter.Sources
+		want        string
+		wantErr     string
+	}{
+		"nothing":       {giveSources: datacenter.Sources{}, want: ""},
+		"code":          {giveSources: datacenter.Sources{Code: " LHR1 ", File: file}, want: "LHR1"},
+		"file":          {giveSources: datacenter.Sources{File: file, Metadata: datacenter.MetadataEC2}, want: "fra1"},
+		"missing file":  {giveSources: datacenter.Sources{File: file + ".missing"}, wantErr: "cannot read"},
+		"wrong code":    {giveSources: datacenter.Sources{Code: "foo bar"}, wantErr: "unexpected character"},
+		"unknown cloud": {giveSources: datacenter.Sources{Metadata: "azure"}, wantErr: "unsupported metadata service"},
+		"ec2": {
+			giveSources: datacenter.Sources{Metadata: datacenter.MetadataEC2, MetadataEndpoint: metadata.URL},
+			want:        "eu-central-1a",
+		},
+		"gcp": {
+			giveSources: datacenter.Sources{Metadata: datacenter.MetadataGCP, MetadataEndpoint: metadata.URL},
+			want:        "us-central1-b",
+		},
+		"metadata error": {
+			giveSources: datacenter.Sources{Metadata: datacenter.MetadataEC2, MetadataEndpoint: metadata.URL + "/foo"},
+			wantErr:     "unexpected status code 403",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			code, err := datacenter.Resolve(context.Background(), tt.giveSources)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, code)
+		})
+	}
+}
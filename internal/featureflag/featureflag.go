@@ -0,0 +1,187 @@
+// Package featureflag provides the feature flags for the error pages, so the copy and theme changes can be rolled
+// out gradually without redeploying the configuration. The flags are the in-memory defaults, overridden by the
+// values loaded from the backends (a file or Redis), which are polled periodically.
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// TemplateFlag is the name of the flag that switches the HTML template (the theme) of the error pages.
+	TemplateFlag = "template"
+
+	// DefaultRedisKey is the Redis hash key with the flags used when the key is not specified.
+	DefaultRedisKey = "error-pages:flags"
+)
+
+const (
+	maxFlags       = 256 // the maximum number of flags
+	maxNameLength  = 64  // the maximum length of the flag name
+	maxValueLength = 256 // the maximum length of the flag value
+)
+
+// Backend loads the flag values (map[name]value) from the external source.
+type Backend interface {
+	Load(context.Context) (map[string]string, error)
+}
+
+// Store keeps the current flag values. It is safe for concurrent use.
+type Store struct {
+	defaults map[string]string
+	backends []Backend
+	interval time.Duration
+
+	current    atomic.Pointer[map[string]string]
+	loaded     chan struct{} // closed once the first refresh is completed
+	loadedOnce sync.Once
+}
+
+// New creates a new store with the in-memory defaults, which are overridden by the backends values (the later
+// backends take precedence). The backends are reloaded on every interval by the [Store.Run].
+func New(defaults map[string]string, interval time.Duration, backends ...Backend) *Store {
+	var s = Store{defaults: maps.Clone(defaults), backends: backends, interval: interval, loaded: make(chan struct{})}
+
+	var values = maps.Clone(defaults)
+
+	s.current.Store(&values)
+
+	return &s
+}
+
+// Get returns the flag value. The second value is false if the flag is not set.
+func (s *Store) Get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	v, ok := (*s.current.Load())[name]
+
+	return v, ok
+}
+
+// Values returns the current flag values (map[name]value). The returned map must not be modified.
+func (s *Store) Values() map[string]string {
+	if s == nil {
+		return nil
+	}
+
+	return *s.current.Load()
+}
+
+// Refresh loads the values from the backends. On error, the previous values are kept.
+func (s *Store) Refresh(ctx context.Context) error {
+	defer s.loadedOnce.Do(func() { close(s.loaded) })
+
+	var values = maps.Clone(s.defaults)
+
+	if values == nil {
+		values = make(map[string]string)
+	}
+
+	for _, b := range s.backends {
+		loaded, err := b.Load(ctx)
+		if err != nil {
+			return err
+		}
+
+		for name, value := range loaded {
+			if value, err = Normalize(name, value); err != nil {
+				return err
+			}
+
+			values[name] = value
+		}
+	}
+
+	if len(values) > maxFlags {
+		return fmt.Errorf("too many feature flags (%d), the maximum is %d", len(values), maxFlags)
+	}
+
+	s.current.Store(&values)
+
+	return nil
+}
+
+// Loaded returns a channel that is closed once the first refresh is completed (successfully or not).
+func (s *Store) Loaded() <-chan struct{} { return s.loaded }
+
+// Run refreshes the values immediately and then on every interval, until the context is canceled. The refresh
+// errors are passed to the callback (may be nil).
+func (s *Store) Run(ctx context.Context, onError func(error)) {
+	var ticker = time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ValidateName checks that the flag name is not empty and consists of letters, digits, and "_.-" only.
+func ValidateName(name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return fmt.Errorf("wrong feature flag name [%s]: 1..%d characters are expected", name, maxNameLength)
+	}
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+		default:
+			return fmt.Errorf("wrong feature flag name [%s]: the character %q is not allowed", name, r)
+		}
+	}
+
+	return nil
+}
+
+// Normalize validates the flag name and returns the trimmed value.
+func Normalize(name, value string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+
+	if value = strings.TrimSpace(value); len(value) > maxValueLength {
+		return "", fmt.Errorf("the feature flag [%s] value is too long (the maximum is %d bytes)", name, maxValueLength)
+	}
+
+	return value, nil
+}
+
+// Resolve returns the value of the flag for the subject (like the client IP address). The percentage values (like
+// `25%`) roll the flag out gradually: the value is `true` for the given share of the subjects and `false` for the
+// rest, and the same subject always gets the same value. Other values are returned as-is.
+func Resolve(name, value, subject string) string {
+	percent, ok := strings.CutSuffix(value, "%")
+	if !ok {
+		return value
+	}
+
+	share, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+	if err != nil || share < 0 || share > 100 { //nolint:mnd
+		return value
+	}
+
+	var h = fnv.New32a()
+
+	_, _ = h.Write([]byte(name)) // the flags are rolled out to the different subjects
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(subject))
+
+	return strconv.FormatBool(float64(h.Sum32()%10000) < share*100) //nolint:mnd // 0.01% precision
+}
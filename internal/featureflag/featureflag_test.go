@@ -0,0 +1,157 @@
+package featureflag_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/featureflag"
+)
+
+type backendFunc func() (map[string]string, error)
+
+func (f backendFunc) Load(context.Context) (map[string]string, error) { return f() }
+
+func TestStore_Refresh(t *testing.T) {
+	t.Parallel()
+
+	var (
+		remote  = map[string]string{"theme": " dark ", "new_503_copy": "25%"}
+		failure error
+		store   = featureflag.New(map[string]string{"theme": "light", "beta": "on"}, time.Minute,
+			backendFunc(func() (map[string]string, error) { return remote, failure }),
+		)
+	)
+
+	v, ok := store.Get("theme") // the defaults are available right away
+	assert.True(t, ok)
+	assert.Equal(t, "light", v)
+
+	require.NoError(t, store.Refresh(context.Background()))
+
+	assert.Equal(t, map[string]string{"theme": "dark", "beta": "on", "new_503_copy": "25%"}, store.Values())
+
+	failure = errors.New("connection refused")
+
+	require.ErrorContains(t, store.Refresh(context.Background()), "connection refused")
+	assert.Equal(t, "dark", store.Values()["theme"]) // the previous values are kept
+
+	failure, remote = nil, map[string]string{"bad name": "on"}
+
+	require.ErrorContains(t, store.Refresh(context.Background()), "wrong feature flag name [bad name]")
+	assert.Equal(t, "dark", store.Values()["theme"])
+
+	select {
+	case <-store.Loaded():
+	default:
+		t.Fatal("the store is not loaded")
+	}
+
+	_, ok = (*featureflag.Store)(nil).Get("theme")
+	assert.False(t, ok)
+}
+
+func TestStore_Run(t *testing.T) {
+	t.Parallel()
+
+	var (
+		loads = make(chan struct{}, 10)
+		errs  = make(chan error, 10)
+		store = featureflag.New(nil, 5*time.Millisecond, backendFunc(func() (map[string]string, error) {
+			loads <- struct{}{}
+
+			return nil, errors.New("boom")
+		}))
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	go store.Run(ctx, func(err error) { errs <- err })
+
+	for range 2 {
+		<-loads
+	}
+
+	cancel()
+
+	assert.EqualError(t, <-errs, "boom")
+}
+
+func TestValidateName(t *testing.T) {
+	t.Parallel()
+
+	for give, wantErr := range map[string]bool{
+		"new_503_copy":          false,
+		"Theme.v2-beta":         false,
+		"":                      true,
+		"with space":            true,
+		"colon:name":            true,
+		"фуу":                   true,
+		strings.Repeat("a", 64): false,
+		strings.Repeat("a", 65): true,
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			if err := featureflag.ValidateName(give); wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "dark", featureflag.Resolve("theme", "dark", "1.2.3.4"))
+	assert.Equal(t, "true", featureflag.Resolve("copy", "100%", "1.2.3.4"))
+	assert.Equal(t, "false", featureflag.Resolve("copy", "0%", "1.2.3.4"))
+	assert.Equal(t, "150%", featureflag.Resolve("copy", "150%", "1.2.3.4")) // not a share
+	assert.Equal(t, "abc%", featureflag.Resolve("copy", "abc%", "1.2.3.4"))
+
+	var enabled int
+
+	for i := range 1000 {
+		var subject = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+
+		var v = featureflag.Resolve("copy", "25%", subject)
+
+		require.Equal(t, v, featureflag.Resolve("copy", "25%", subject)) // sticky
+
+		if v == "true" {
+			enabled++
+		}
+	}
+
+	assert.InDelta(t, 250, enabled, 60)
+}
+
+func TestFile_Load(t *testing.T) {
+	t.Parallel()
+
+	var path = filepath.Join(t.TempDir(), "flags.yml")
+
+	require.NoError(t, os.WriteFile(path, []byte("new_503_copy: true\nshare: 25%\nlimit: 10\nempty:\n"), 0o600))
+
+	values, err := featureflag.File(path).Load(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"new_503_copy": "true", "share": "25%", "limit": "10", "empty": ""}, values)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"nested": {"foo": "bar"}}`), 0o600))
+
+	_, err = featureflag.File(path).Load(context.Background())
+	require.ErrorContains(t, err, "a scalar is expected")
+
+	_, err = featureflag.File(filepath.Join(t.TempDir(), "missing.yml")).Load(context.Background())
+	require.ErrorContains(t, err, "failed to read the feature flags file")
+}
@@ -0,0 +1,58 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxFileSize limits the size of the flags file.
+const maxFileSize = 1 << 20 // 1 MiB
+
+// File is the backend reading the flags from the YAML (or JSON) file with the flat map of the scalar values, like
+// `new_503_copy: true`. The file is read on every load, so the changes are picked up without a restart.
+type File string
+
+// Load reads the flags file.
+func (f File) Load(context.Context) (map[string]string, error) {
+	var path = string(f)
+
+	if stat, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to read the feature flags file: %w", err)
+	} else if stat.Size() > maxFileSize {
+		return nil, fmt.Errorf("the feature flags file %s is too large (the maximum is %d bytes)", path, maxFileSize)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the feature flags file: %w", err)
+	}
+
+	var raw map[string]any
+
+	if err = yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse the feature flags file %s: %w", path, err)
+	}
+
+	var values = make(map[string]string, len(raw))
+
+	for name, v := range raw {
+		switch v := v.(type) {
+		case nil:
+			values[name] = ""
+		case string:
+			values[name] = v
+		case bool:
+			values[name] = strconv.FormatBool(v)
+		case int, uint64, float64:
+			values[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("wrong value of the feature flag [%s] in the file %s: a scalar is expected", name, path)
+		}
+	}
+
+	return values, nil
+}
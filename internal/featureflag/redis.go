@@ -0,0 +1,204 @@
+//go:build !slim
+
+package featureflag
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisTimeout = 5 * time.Second // the load timeout if the context has no deadline
+	maxRedisBulk = 64 << 10        // the maximum size of a single reply string
+)
+
+// NewRedis creates the Redis backend for the URL (see [ValidateRedisURL]) and the hash key ([DefaultRedisKey] if
+// empty).
+func NewRedis(rawURL, key string) (*Redis, error) { return parseRedisURL(rawURL, key) }
+
+// Load reads the flags from the Redis hash.
+func (r *Redis) Load(ctx context.Context) (_ map[string]string, err error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, redisTimeout)
+		defer cancel()
+	}
+
+	var (
+		conn   net.Conn
+		dialer net.Dialer
+	)
+
+	if r.tls {
+		var cfg = &tls.Config{ServerName: r.host, MinVersion: tls.VersionTLS12}
+
+		conn, err = (&tls.Dialer{NetDialer: &dialer, Config: cfg}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var commands = make([][]string, 0, 3) //nolint:mnd
+
+	if r.password != "" {
+		if r.username != "" {
+			commands = append(commands, []string{"AUTH", r.username, r.password})
+		} else {
+			commands = append(commands, []string{"AUTH", r.password})
+		}
+	}
+
+	if r.db != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+
+	commands = append(commands, []string{"HGETALL", r.key})
+
+	var w = bufio.NewWriter(conn)
+
+	for _, cmd := range commands { // pipelined
+		writeRedisCommand(w, cmd...)
+	}
+
+	if err = w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to send the Redis commands: %w", err)
+	}
+
+	var (
+		rd    = bufio.NewReader(conn)
+		reply []string
+	)
+
+	for _, cmd := range commands {
+		if reply, err = readRedisReply(rd); err != nil {
+			return nil, fmt.Errorf("redis %s failed: %w", cmd[0], err)
+		}
+	}
+
+	if len(reply)%2 != 0 {
+		return nil, errors.New("redis HGETALL failed: odd number of the reply elements")
+	}
+
+	var values = make(map[string]string, len(reply)/2) //nolint:mnd
+
+	for i := 0; i < len(reply); i += 2 {
+		values[reply[i]] = reply[i+1]
+	}
+
+	return values, nil
+}
+
+// writeRedisCommand writes the command as the RESP array of the bulk strings.
+func writeRedisCommand(w *bufio.Writer, args ...string) {
+	_, _ = fmt.Fprintf(w, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readRedisReply reads a single RESP reply. The simple strings, integers, and bulk strings are returned as a single
+// element, the arrays (of the strings only) as the elements, and nil values as nothing.
+func readRedisReply(rd *bufio.Reader) ([]string, error) {
+	line, err := readRedisLine(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return []string{line[1:]}, nil
+
+	case '-':
+		return nil, errors.New(line[1:])
+
+	case '$':
+		s, isNil, bErr := readRedisBulk(rd, line)
+		if bErr != nil || isNil {
+			return nil, bErr
+		}
+
+		return []string{s}, nil
+
+	case '*':
+		n, aErr := strconv.Atoi(line[1:])
+		if aErr != nil || n > maxFlags*2 { //nolint:mnd
+			return nil, fmt.Errorf("wrong array length [%s] (the maximum is %d)", line[1:], maxFlags*2) //nolint:mnd
+		}
+
+		var elements = make([]string, 0, max(n, 0))
+
+		for range n {
+			if line, err = readRedisLine(rd); err != nil {
+				return nil, err
+			}
+
+			if line[0] != '$' {
+				return nil, fmt.Errorf("unexpected array element [%s]", line)
+			}
+
+			s, _, bErr := readRedisBulk(rd, line)
+			if bErr != nil {
+				return nil, bErr
+			}
+
+			elements = append(elements, s)
+		}
+
+		return elements, nil
+	}
+
+	return nil, fmt.Errorf("unexpected reply [%s]", line)
+}
+
+// readRedisLine reads the CRLF-terminated line (without the terminator).
+func readRedisLine(rd *bufio.Reader) (string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if line = strings.TrimSuffix(line, "\r\n"); line == "" {
+		return "", errors.New("empty reply")
+	}
+
+	return line, nil
+}
+
+// readRedisBulk reads the bulk string content by the header line (`$<length>`).
+func readRedisBulk(rd *bufio.Reader, header string) (s string, isNil bool, _ error) {
+	n, err := strconv.Atoi(header[1:])
+	if err != nil || n > maxRedisBulk {
+		return "", false, fmt.Errorf("wrong bulk string length [%s] (the maximum is %d)", header[1:], maxRedisBulk)
+	}
+
+	if n < 0 {
+		return "", true, nil
+	}
+
+	var buf = make([]byte, n+2) //nolint:mnd // with the CRLF
+
+	if _, err = io.ReadFull(rd, buf); err != nil {
+		return "", false, err
+	}
+
+	return string(buf[:n]), false, nil
+}
@@ -0,0 +1,24 @@
+//go:build slim
+
+package featureflag
+
+import (
+	"context"
+	"errors"
+)
+
+// errRedisSlim is returned when the Redis backend is used in the slim build.
+var errRedisSlim = errors.New("the Redis backend of the feature flags is not available in the slim build")
+
+// NewRedis returns the error in the slim build (the Redis client is excluded to reduce the binary size, only the
+// [File] backend is available).
+func NewRedis(rawURL, key string) (*Redis, error) {
+	if _, err := parseRedisURL(rawURL, key); err != nil {
+		return nil, err
+	}
+
+	return nil, errRedisSlim
+}
+
+// Load always returns the error in the slim build.
+func (*Redis) Load(context.Context) (map[string]string, error) { return nil, errRedisSlim }
@@ -0,0 +1,68 @@
+package featureflag
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const redisDefaultPort = "6379"
+
+// Redis is the backend reading the flags from the Redis hash (field - the flag name, value - the flag value) using
+// the `HGETALL` command, so the flags are set like `HSET error-pages:flags new_503_copy true`. A new connection is
+// used for every load, which is fine for the polling.
+type Redis struct {
+	addr     string
+	host     string // the server name for the TLS connections
+	tls      bool
+	username string
+	password string
+	db       int
+	key      string
+}
+
+// ValidateRedisURL checks the Redis URL, like `redis://[[username]:password@]host[:port][/db]` (or `rediss://` for
+// the TLS connections).
+func ValidateRedisURL(s string) error {
+	_, err := parseRedisURL(s, "")
+
+	return err
+}
+
+// parseRedisURL parses the Redis URL (see [ValidateRedisURL]) with the hash key ([DefaultRedisKey] if empty).
+func parseRedisURL(rawURL, key string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("wrong Redis URL: %w", err)
+	}
+
+	if (u.Scheme != "redis" && u.Scheme != "rediss") || u.Hostname() == "" {
+		return nil, errors.New("wrong Redis URL: redis://host[:port][/db] or rediss://... is expected")
+	}
+
+	var r = Redis{addr: u.Host, host: u.Hostname(), tls: u.Scheme == "rediss", key: key}
+
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), redisDefaultPort)
+	}
+
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("wrong Redis URL: wrong database number [%s]", db)
+		}
+	}
+
+	if r.key == "" {
+		r.key = DefaultRedisKey
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,67 @@
+// Package filewatch detects the changes of the local files by polling their modification times and sizes. Polling
+// works with any file system, including the Kubernetes ConfigMap volumes (updated by swapping the symlinks).
+package filewatch
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Watch checks the files with the interval and sends to the returned channel when any of them is changed (created,
+// modified, or removed). The changes made between the reads of the channel are coalesced into a single
+// notification. The channel is never closed; the watching stops when the context is canceled.
+func Watch(ctx context.Context, interval time.Duration, paths ...string) <-chan struct{} {
+	var (
+		changes = make(chan struct{}, 1)
+		last    = fingerprint(paths)
+	)
+
+	go func() {
+		var ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if current := fingerprint(paths); current != last {
+				last = current
+
+				select {
+				case changes <- struct{}{}:
+				default: // the previous notification is not read yet
+				}
+			}
+		}
+	}()
+
+	return changes
+}
+
+// fingerprint returns the string describing the current state of the files (the missing files are described
+// too, so their creation is noticed).
+func fingerprint(paths []string) string {
+	var b strings.Builder
+
+	for _, path := range slices.Sorted(slices.Values(paths)) {
+		b.WriteString(path)
+
+		if info, err := os.Stat(path); err == nil { // the symlinks are followed
+			b.WriteString(":" + strconv.FormatInt(info.ModTime().UnixNano(), 10))
+			b.WriteString(":" + strconv.FormatInt(info.Size(), 10))
+		} else {
+			b.WriteString(":-")
+		}
+
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
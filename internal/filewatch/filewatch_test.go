@@ -0,0 +1,57 @@
+package filewatch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/filewatch"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir          = t.TempDir()
+		existing     = filepath.Join(dir, "existing.html")
+		missing      = filepath.Join(dir, "missing.html")
+		ctx, cancel  = context.WithCancel(context.Background())
+		expectChange = func(t *testing.T, changes <-chan struct{}, want bool) {
+			t.Helper()
+
+			select {
+			case <-changes:
+				require.True(t, want, "unexpected change notification")
+			case <-time.After(100 * time.Millisecond):
+				require.False(t, want, "the change is not noticed")
+			}
+		}
+	)
+
+	defer cancel()
+
+	require.NoError(t, os.WriteFile(existing, []byte("foo"), 0o600))
+
+	var changes = filewatch.Watch(ctx, 5*time.Millisecond, existing, missing)
+
+	expectChange(t, changes, false) // nothing is changed yet
+
+	require.NoError(t, os.WriteFile(existing, []byte("foobar"), 0o600)) // modified
+	expectChange(t, changes, true)
+	expectChange(t, changes, false) // the change is reported once
+
+	require.NoError(t, os.WriteFile(missing, []byte("bar"), 0o600)) // created
+	expectChange(t, changes, true)
+
+	require.NoError(t, os.Remove(existing)) // removed
+	expectChange(t, changes, true)
+
+	cancel()
+
+	require.NoError(t, os.WriteFile(existing, []byte("baz"), 0o600))
+	expectChange(t, changes, false) // stopped
+}
@@ -0,0 +1,7 @@
+//go:build !slim
+
+package http_test
+
+// slimBuild reports whether the tests are run in the slim build, where the social images, publisher, and metrics
+// are not available.
+const slimBuild = false
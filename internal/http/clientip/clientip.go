@@ -0,0 +1,124 @@
+// Package clientip provides the client IP address extraction, taking into account the trusted proxies.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Resolver extracts the client IP address from the request. It's safe for concurrent use.
+//
+// The `X-Forwarded-For` header is taken into account only when the request comes from a trusted proxy. In this
+// case, the header is walked from right to left (the rightmost entry is added by the closest proxy), and the first
+// address that is not trusted is considered as the client IP. The number of hops to walk can be limited, so a
+// client can't spoof its IP by injecting extra entries into the header. When the limit is reached before an
+// untrusted address is found, the header is not used at all (the remote address is returned), since a trusted
+// proxy address must never be considered as the client IP.
+type Resolver struct {
+	trusted []netip.Prefix
+	maxHops uint
+}
+
+// New creates a new Resolver. If the list of trusted proxies is empty, the `X-Forwarded-For` header is ignored.
+// The maxHops limits the number of the `X-Forwarded-For` entries to walk (0 means no limit).
+func New(trusted []netip.Prefix, maxHops uint) *Resolver {
+	return &Resolver{trusted: trusted, maxHops: maxHops}
+}
+
+// Resolve returns the client IP address. An invalid address is returned if the remote address is unknown.
+func (r *Resolver) Resolve(ctx *fasthttp.RequestCtx) netip.Addr {
+	var remote netip.Addr
+
+	if tcp, ok := ctx.RemoteAddr().(*net.TCPAddr); ok {
+		remote = tcp.AddrPort().Addr().Unmap()
+	} else if ap, err := netip.ParseAddrPort(ctx.RemoteAddr().String()); err == nil {
+		remote = ap.Addr().Unmap()
+	}
+
+	if r == nil || len(r.trusted) == 0 || !remote.IsValid() || !r.isTrusted(remote) {
+		return remote
+	}
+
+	var forwarded []string
+
+	// the header may be repeated, so we need to take all the values in order
+	for _, value := range ctx.Request.Header.PeekAll(fasthttp.HeaderXForwardedFor) {
+		forwarded = append(forwarded, strings.Split(string(value), ",")...)
+	}
+
+	var client = remote
+
+	for i, hops := len(forwarded)-1, uint(0); i >= 0; i, hops = i-1, hops+1 {
+		if r.maxHops > 0 && hops >= r.maxHops {
+			return remote // the proxies chain is longer than allowed
+		}
+
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break // malformed entry, stop here and use the last known address
+		}
+
+		client = addr.Unmap()
+
+		if !r.isTrusted(client) {
+			break
+		}
+	}
+
+	return client
+}
+
+// String returns the client IP address as a string (an empty string is returned if the address is unknown).
+func (r *Resolver) String(ctx *fasthttp.RequestCtx) string {
+	if addr := r.Resolve(ctx); addr.IsValid() {
+		return addr.String()
+	}
+
+	return ""
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParsePrefixes parses a list of IP addresses and/or CIDR ranges (like `10.0.0.0/8` or `::1`). A single IP address
+// is converted into a prefix that contains only this address.
+func ParsePrefixes(list ...string) ([]netip.Prefix, error) {
+	var result = make([]netip.Prefix, 0, len(list))
+
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		if strings.ContainsRune(item, '/') {
+			p, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("wrong CIDR range [%s]: %w", item, err)
+			}
+
+			result = append(result, p.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("wrong IP address [%s]: %w", item, err)
+		}
+
+		result = append(result, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package clientip_test
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveTrusted []string
+		giveMaxHops uint
+		giveRemote  string
+		giveXFF     []string
+		want        string
+	}{
+		"no trusted proxies, header ignored": {
+			giveRemote: "1.1.1.1",
+			giveXFF:    []string{"2.2.2.2"},
+			want:       "1.1.1.1",
+		},
+		"remote is not trusted": {
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveRemote:  "1.1.1.1",
+			giveXFF:     []string{"2.2.2.2"},
+			want:        "1.1.1.1",
+		},
+		"remote is trusted": {
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"2.2.2.2"},
+			want:        "2.2.2.2",
+		},
+		"spoofed entries are skipped": {
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"6.6.6.6, 3.3.3.3, 10.0.0.2"},
+			want:        "3.3.3.3",
+		},
+		"repeated headers": {
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"6.6.6.6", "3.3.3.3, 10.0.0.2"},
+			want:        "3.3.3.3",
+		},
+		"hops limit": {
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveMaxHops: 2,
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"6.6.6.6, 3.3.3.3, 10.0.0.2"},
+			want:        "3.3.3.3",
+		},
+		"hops limit exceeded": { // the walked entries are the proxies only, so the header is not used
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveMaxHops: 1,
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"3.3.3.3, 10.0.0.3, 10.0.0.2"},
+			want:        "10.0.0.1",
+		},
+		"all the entries are trusted": {
+			giveTrusted: []string{"10.0.0.0/8"},
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"10.0.0.3, 10.0.0.2"},
+			want:        "10.0.0.3",
+		},
+		"malformed entry": {
+			giveTrusted: []string{"10.0.0.1"},
+			giveRemote:  "10.0.0.1",
+			giveXFF:     []string{"3.3.3.3, foobar"},
+			want:        "10.0.0.1",
+		},
+		"ipv6": {
+			giveTrusted: []string{"::1"},
+			giveRemote:  "::1",
+			giveXFF:     []string{"2001:db8::1"},
+			want:        "2001:db8::1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			trusted, err := clientip.ParsePrefixes(tt.giveTrusted...)
+			require.NoError(t, err)
+
+			var (
+				req fasthttp.Request
+				ctx fasthttp.RequestCtx
+			)
+
+			for _, v := range tt.giveXFF {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+
+			ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP(tt.giveRemote), Port: 12345}, nil)
+
+			assert.Equal(t, tt.want, clientip.New(trusted, tt.giveMaxHops).String(&ctx))
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	t.Parallel()
+
+	got, err := clientip.ParsePrefixes("10.0.0.1/8", " 127.0.0.1 ", "", "::1")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("127.0.0.1/32"),
+		netip.MustParsePrefix("::1/128"),
+	}, got)
+
+	_, err = clientip.ParsePrefixes("foo")
+	assert.ErrorContains(t, err, "wrong IP address")
+
+	_, err = clientip.ParsePrefixes("10.0.0.0/99")
+	assert.ErrorContains(t, err, "wrong CIDR range")
+}
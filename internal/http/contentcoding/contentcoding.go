@@ -0,0 +1,62 @@
+// Package contentcoding provides the content coding negotiation (the `Accept-Encoding` request header handling).
+package contentcoding
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the content coding preferred by the client (the `Accept-Encoding` request header value) among
+// the supported ones (ordered by the server preference, used when the client weights are equal), or an empty string
+// for the identity encoding.
+//
+// The quality values are respected (the `q=0` means "not acceptable"), the names are case-insensitive, and the `*`
+// stands for any coding not listed explicitly, so the order of the items does not matter (e.g. `*, br;q=0` and
+// `br;q=0, *` both exclude the Brotli).
+func Negotiate(header string, supported ...string) string {
+	if header = strings.TrimSpace(header); header == "" || len(supported) == 0 {
+		return ""
+	}
+
+	var (
+		weights  = make(map[string]float64, len(supported))
+		wildcard = -1.0 // not set
+	)
+
+	for item := range strings.SplitSeq(header, ",") {
+		var (
+			name, params, _ = strings.Cut(strings.TrimSpace(item), ";")
+			q               = 1.0
+		)
+
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+
+		if name = strings.ToLower(strings.TrimSpace(name)); name == "*" {
+			wildcard = q
+		} else if name != "" {
+			weights[name] = q
+		}
+	}
+
+	var (
+		best  string
+		bestQ float64
+	)
+
+	for _, coding := range supported {
+		q, ok := weights[strings.ToLower(coding)]
+		if !ok {
+			q = max(wildcard, 0)
+		}
+
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+
+	return best
+}
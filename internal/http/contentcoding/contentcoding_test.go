@@ -0,0 +1,65 @@
+package contentcoding_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/http/contentcoding"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	for giveHeader, want := range map[string]string{
+		"":                           "",
+		"   ":                        "",
+		"identity":                   "",
+		"deflate":                    "",
+		"br":                         "br",
+		"gzip":                       "gzip",
+		"BR":                         "br",
+		"gzip, br":                   "br", // equal weights - the server preference
+		"br, gzip":                   "br",
+		"gzip;q=1, br;q=0.5":         "gzip",
+		"gzip ; q=0.8 , br ; q=0.9":  "br",
+		"br;q=0, gzip":               "gzip",
+		"br;q=0, gzip;q=0":           "",
+		"br;q=0.0":                   "",
+		"*":                          "br",
+		"*;q=0":                      "",
+		"*, br;q=0":                  "gzip",
+		"br;q=0, *":                  "gzip",
+		"*;q=0, br":                  "br",
+		"br, *;q=0":                  "br",
+		"*;q=0, gzip;q=0.1":          "gzip",
+		"*;q=0.5, gzip":              "gzip",
+		"*;q=0.5, gzip;q=0.1":        "br",
+		"br;q=foo":                   "br", // the wrong weight is ignored
+		"gzip, deflate, br, zstd":    "br",
+		"identity, *;q=0":            "",
+		",, ,gzip,":                  "gzip",
+		"gzip;level=1":               "gzip",
+		"x-gzip, compress;q=0.5, br": "br",
+	} {
+		t.Run(giveHeader, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, want, contentcoding.Negotiate(giveHeader, "br", "gzip"))
+		})
+	}
+
+	t.Run("no supported codings", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Empty(t, contentcoding.Negotiate("br, gzip"))
+	})
+
+	t.Run("the supported subset", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "gzip", contentcoding.Negotiate("br, gzip", "gzip"))
+		assert.Empty(t, contentcoding.Negotiate("br", "gzip"))
+		assert.Equal(t, "gzip", contentcoding.Negotiate("*", "gzip"))
+	})
+}
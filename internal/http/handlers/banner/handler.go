@@ -0,0 +1,77 @@
+package banner
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+)
+
+// Path is the path of the banner API endpoint.
+const Path = "/api/banner"
+
+// maxBodySize limits the request body size.
+const maxBodySize = 4 << 10 // 4 KiB
+
+// New creates a handler that returns (GET), sets (PUT or POST with the `{"message": "...", "severity": "..."}`
+// JSON body), or removes (DELETE) the outage banner shown on the error pages.
+func New(ctl *ep.BannerControl) fasthttp.RequestHandler {
+	var notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+
+	var respond = func(ctx *fasthttp.RequestCtx, b ep.Banner) {
+		var body, _ = json.Marshal(b) //nolint:errchkjson
+
+		ctx.SetContentType("application/json; charset=utf-8")
+		ctx.Response.Header.Set("Cache-Control", "no-store")
+		ctx.SetStatusCode(http.StatusOK)
+		_, _ = ctx.Write(body)
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet:
+			respond(ctx, ctl.Get())
+
+		case fasthttp.MethodHead:
+			ctx.SetStatusCode(http.StatusOK)
+
+		case fasthttp.MethodPut, fasthttp.MethodPost:
+			var req ep.Banner
+
+			if body := ctx.PostBody(); len(body) > maxBodySize {
+				ctx.Error(http.StatusText(http.StatusRequestEntityTooLarge)+"\n", http.StatusRequestEntityTooLarge)
+
+				return
+			} else if err := json.Unmarshal(body, &req); err != nil {
+				ctx.Error("wrong request body: "+err.Error()+"\n", http.StatusBadRequest)
+
+				return
+			}
+
+			b, err := ctl.Set(req.Message, req.Severity)
+			if err != nil {
+				ctx.Error(err.Error()+"\n", http.StatusBadRequest)
+
+				return
+			}
+
+			respond(ctx, b)
+
+		case fasthttp.MethodDelete:
+			b, err := ctl.Set("", "")
+			if err != nil {
+				ctx.Error(err.Error()+"\n", http.StatusInternalServerError)
+
+				return
+			}
+
+			respond(ctx, b)
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "GET, HEAD, PUT, POST, DELETE")
+		}
+	}
+}
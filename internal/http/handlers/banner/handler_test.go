@@ -0,0 +1,76 @@
+package banner_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/banner"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cfg = config.New()
+		ctl ep.BannerControl
+		url = "http://testing" + banner.Path
+	)
+
+	cfg.Banner.Message = "Initial"
+
+	var _, closeCache = ep.New(&cfg, logger.NewNop(), ep.WithBannerControl(&ctl))
+	defer closeCache()
+
+	var handler = banner.New(&ctl)
+
+	t.Run("get", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodGet, url, http.NoBody, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
+			assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+			assert.JSONEq(t, `{"message":"Initial","severity":"info"}`, body)
+		})
+	})
+
+	t.Run("set", func(t *testing.T) {
+		var reqBody = strings.NewReader(`{"message":"Investigating","severity":"warning"}`)
+
+		httptest.HandleFast(t, handler, http.MethodPut, url, reqBody, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.JSONEq(t, `{"message":"Investigating","severity":"warning"}`, body)
+		})
+
+		assert.Equal(t, "Investigating", ctl.Get().Message)
+	})
+
+	t.Run("wrong request", func(t *testing.T) {
+		for _, reqBody := range []string{`{"message":"foo","severity":"fatal"}`, `not a json`} {
+			httptest.HandleFast(t, handler, http.MethodPost, url, strings.NewReader(reqBody), func(status int, _ string, _ http.Header) {
+				assert.Equal(t, http.StatusBadRequest, status)
+			})
+		}
+
+		assert.Equal(t, "Investigating", ctl.Get().Message) // not changed
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodDelete, url, http.NoBody, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.JSONEq(t, `{"message":"","severity":"info"}`, body)
+		})
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodPatch, url, http.NoBody, func(status int, _ string, headers http.Header) {
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+			assert.Equal(t, "GET, HEAD, PUT, POST, DELETE", headers.Get("Allow"))
+		})
+	})
+}
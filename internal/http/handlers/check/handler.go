@@ -0,0 +1,46 @@
+package check
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/upstream"
+)
+
+// Path is the path of the upstream check endpoint.
+const Path = "/check"
+
+// New creates a handler that responds with 204 when the last upstream health check succeeded, and with 503
+// otherwise. The upstream is never requested by the handler itself, so the error pages can poll it without
+// hammering the origin. CORS is allowed, since the pages may be served from another origin.
+func New(probe *upstream.Prober, interval time.Duration) fasthttp.RequestHandler {
+	var (
+		retryAfter = strconv.Itoa(int(math.Max(1, math.Ceil(interval.Seconds()))))
+		notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+	)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet, fasthttp.MethodHead:
+			ctx.Response.Header.Set("Cache-Control", "no-store")
+			ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+
+			if status, checked := probe.Status(); checked && status.Healthy {
+				ctx.SetStatusCode(http.StatusNoContent)
+
+				return
+			}
+
+			ctx.Response.Header.Set("Retry-After", retryAfter)
+			ctx.SetStatusCode(http.StatusServiceUnavailable)
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "GET, HEAD") // set after the error, since it resets the response
+		}
+	}
+}
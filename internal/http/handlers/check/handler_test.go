@@ -0,0 +1,73 @@
+package check_test
+
+import (
+	"context"
+	"net/http"
+	stdHttptest "net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/http/handlers/check"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/upstream"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		healthy atomic.Bool
+		hits    atomic.Int32
+	)
+
+	var origin = stdHttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	t.Cleanup(origin.Close)
+
+	var (
+		probe   = upstream.NewProber(origin.URL, 1500*time.Millisecond, time.Second)
+		handler = check.New(probe, 1500*time.Millisecond)
+		url     = "http://testing" + check.Path
+		body    = http.NoBody
+	)
+
+	httptest.HandleFast(t, handler, http.MethodGet, url, body, func(status int, _ string, headers http.Header) {
+		assert.Equal(t, http.StatusServiceUnavailable, status) // not checked yet
+		assert.Equal(t, "2", headers.Get("Retry-After"))
+		assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+		assert.Equal(t, "*", headers.Get("Access-Control-Allow-Origin"))
+	})
+
+	probe.Check(context.Background())
+
+	httptest.HandleFast(t, handler, http.MethodGet, url, body, func(status int, _ string, _ http.Header) {
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+
+	healthy.Store(true)
+	probe.Check(context.Background())
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		httptest.HandleFast(t, handler, method, url, body, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusNoContent, status)
+			assert.Empty(t, body)
+			assert.Empty(t, headers.Get("Retry-After"))
+		})
+	}
+
+	assert.Equal(t, int32(2), hits.Load()) // the handler never requests the origin
+
+	httptest.HandleFast(t, handler, http.MethodPost, url, body, func(status int, _ string, headers http.Header) {
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		assert.Equal(t, "GET, HEAD", headers.Get("Allow"))
+	})
+}
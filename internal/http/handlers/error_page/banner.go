@@ -0,0 +1,86 @@
+package error_page
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// maxBannerLength limits the length of the banner message (in runes).
+const maxBannerLength = 512
+
+// ErrBannerTooLong is returned when the banner message exceeds the maximum length.
+var ErrBannerTooLong = errors.New("the banner message is too long")
+
+type (
+	// Banner is the outage banner shown on the error pages.
+	Banner struct {
+		Message  string `json:"message"`  // an empty message means no banner
+		Severity string `json:"severity"` // info, warning, or critical
+	}
+
+	// BannerControl allows to read and change the outage banner of the handler at runtime. It becomes usable after
+	// the handler is created with the [WithBannerControl] option. The same control may be bound to the handlers of
+	// the reloaded configurations - the banner set at runtime is kept then.
+	BannerControl struct {
+		current *atomic.Pointer[Banner]
+		changed atomic.Bool // the banner was set at runtime, so the configured one is not applied anymore
+	}
+)
+
+// newBanner validates and normalizes the banner.
+func newBanner(message, severity string) (Banner, error) {
+	s, err := config.ParseBannerSeverity(severity)
+	if err != nil {
+		return Banner{}, err
+	}
+
+	if message = strings.TrimSpace(message); utf8.RuneCountInString(message) > maxBannerLength {
+		return Banner{}, ErrBannerTooLong
+	}
+
+	return Banner{Message: message, Severity: s.String()}, nil
+}
+
+// Get returns the current banner.
+func (c *BannerControl) Get() Banner {
+	if c == nil || c.current == nil {
+		return Banner{}
+	}
+
+	return *c.current.Load()
+}
+
+// Set validates and sets the banner (an empty message removes it).
+func (c *BannerControl) Set(message, severity string) (Banner, error) {
+	b, err := newBanner(message, severity)
+	if err != nil {
+		return Banner{}, err
+	}
+
+	if c == nil || c.current == nil {
+		return Banner{}, errors.New("the banner control is not bound to the handler")
+	}
+
+	c.current.Store(&b)
+	c.changed.Store(true)
+
+	return b, nil
+}
+
+// bind binds the control to the handler with the configured banner and returns the current banner holder. The
+// configured banner is ignored if the banner was set at runtime.
+func (c *BannerControl) bind(configured Banner) *atomic.Pointer[Banner] {
+	if c.current == nil {
+		c.current = new(atomic.Pointer[Banner])
+	}
+
+	if !c.changed.Load() {
+		c.current.Store(&configured)
+	}
+
+	return c.current
+}
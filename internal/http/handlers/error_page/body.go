@@ -0,0 +1,51 @@
+package error_page
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bodyPreview returns the sanitized request body preview, truncated to the given number of bytes (the ellipsis is
+// appended if the body is truncated). The invalid UTF-8 sequences and control characters (except the line
+// breaks and tabs) are replaced with the `.` character, so the preview is safe to show and log.
+func bodyPreview(body []byte, limit uint) string {
+	if limit == 0 || len(body) == 0 {
+		return ""
+	}
+
+	var (
+		b         strings.Builder
+		truncated = uint(len(body)) > limit
+	)
+
+	if truncated {
+		body = body[:limit]
+	}
+
+	b.Grow(len(body))
+
+	for len(body) > 0 {
+		var r, size = utf8.DecodeRune(body)
+
+		if r == utf8.RuneError && size <= 1 {
+			if truncated && !utf8.FullRune(body) { // the multibyte rune is cut by the limit
+				break
+			}
+
+			b.WriteByte('.')
+		} else if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			b.WriteByte('.')
+		} else {
+			b.WriteRune(r)
+		}
+
+		body = body[size:]
+	}
+
+	if truncated {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyPreview(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveBody  string
+		giveLimit uint
+		want      string
+	}{
+		"disabled":         {giveBody: "foo", giveLimit: 0, want: ""},
+		"empty":            {giveBody: "", giveLimit: 10, want: ""},
+		"as is":            {giveBody: "{\"foo\":\n\t1}", giveLimit: 100, want: "{\"foo\":\n\t1}"},
+		"truncated":        {giveBody: "foobar", giveLimit: 3, want: "foo…"},
+		"control chars":    {giveBody: "a\x00b\x1bc", giveLimit: 10, want: "a.b.c"},
+		"invalid utf-8":    {giveBody: "a\xffb", giveLimit: 10, want: "a.b"},
+		"multibyte":        {giveBody: "привет", giveLimit: 12, want: "привет"},
+		"multibyte cut":    {giveBody: "привет", giveLimit: 5, want: "пр…"},
+		"exactly at limit": {giveBody: "abc", giveLimit: 3, want: "abc"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, bodyPreview([]byte(tt.giveBody), tt.giveLimit))
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package error_page
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// textTruncationMarker is appended to the cut plain text bodies.
+const textTruncationMarker = "\n[truncated]\n"
+
+// limitBody returns the body shortened to fit the limit (in bytes). The JSON string values and XML text nodes are
+// truncated evenly (each truncated value ends with an ellipsis), so the body stays valid; the fallback is used if
+// even the empty values don't fit. The other bodies (and the JSON or XML that cannot be parsed) are cut with the
+// [textTruncationMarker].
+func limitBody(body []byte, limit uint, format preferredFormat, fallback string) []byte {
+	if limit == 0 || uint(len(body)) <= limit {
+		return body
+	}
+
+	var rebuild func(body []byte, maxRunes int) ([]byte, error)
+
+	switch format {
+	case jsonFormat:
+		rebuild = truncateJSON
+	case xmlFormat:
+		rebuild = truncateXML
+	default:
+		return cutText(body, limit)
+	}
+
+	if shortest, err := rebuild(body, 0); err != nil {
+		return cutText(body, limit) // not a valid document
+	} else if uint(len(shortest)) > limit { // the structure itself is too large
+		if uint(len(fallback)) <= limit {
+			return []byte(fallback)
+		}
+
+		return cutText([]byte(fallback), limit)
+	}
+
+	// the longest value length that fits (the body length is more than any value length in runes)
+	var maxRunes = sort.Search(len(body), func(n int) bool {
+		var b, _ = rebuild(body, n)
+
+		return uint(len(b)) > limit
+	}) - 1
+
+	var result, _ = rebuild(body, maxRunes)
+
+	return result
+}
+
+// cutText cuts the text to fit the limit (with the marker), keeping the multibyte characters intact.
+func cutText(body []byte, limit uint) []byte {
+	if uint(len(body)) <= limit {
+		return body
+	}
+
+	var n = int(limit) - len(textTruncationMarker) //nolint:gosec // the limit is validated
+
+	for n > 0 && !utf8.RuneStart(body[n]) {
+		n--
+	}
+
+	return append(bytes.Clone(body[:max(n, 0)]), textTruncationMarker...)
+}
+
+// truncateString truncates the string to the given number of runes, appending the ellipsis.
+func truncateString(s string, maxRunes int) string {
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+
+	var n int
+
+	for i := range s {
+		if n == maxRunes {
+			return s[:i] + "…"
+		}
+
+		n++
+	}
+
+	return s
+}
+
+// truncateJSON re-encodes the JSON document (compacted, with the key order kept) with the string values (not
+// the keys) truncated to the given number of runes.
+func truncateJSON(body []byte, maxRunes int) ([]byte, error) {
+	type frame struct {
+		object   bool // or array
+		afterKey bool // the key is written, the value is expected
+		items    int  // the number of written values
+	}
+
+	var (
+		dec   = json.NewDecoder(bytes.NewReader(body))
+		out   bytes.Buffer
+		stack []frame
+		enc   = json.NewEncoder(&out)
+	)
+
+	dec.UseNumber()
+	enc.SetEscapeHTML(false)
+
+	var valueDone = func() {
+		if len(stack) > 0 {
+			stack[len(stack)-1].items++
+			stack[len(stack)-1].afterKey = false
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			out.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			valueDone()
+
+			continue
+		}
+
+		var isKey bool
+
+		if len(stack) > 0 {
+			var top = stack[len(stack)-1]
+
+			if isKey = top.object && !top.afterKey; (isKey || !top.object) && top.items > 0 {
+				out.WriteByte(',')
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim: // the opening one
+			out.WriteByte(byte(v))
+			stack = append(stack, frame{object: v == '{'})
+
+			continue
+		case string:
+			if !isKey {
+				v = truncateString(v, maxRunes)
+			}
+
+			if err = enc.Encode(v); err != nil {
+				return nil, err
+			}
+
+			out.Truncate(out.Len() - 1) // the newline added by the encoder
+		case json.Number:
+			out.WriteString(v.String())
+		case bool:
+			out.WriteString(strconv.FormatBool(v))
+		case nil:
+			out.WriteString("null")
+		}
+
+		if isKey {
+			out.WriteByte(':')
+			stack[len(stack)-1].afterKey = true
+
+			continue
+		}
+
+		valueDone()
+	}
+
+	return out.Bytes(), nil
+}
+
+// truncateXML re-encodes the XML document with the text nodes (except the whitespace-only ones) truncated to the
+// given number of runes.
+func truncateXML(body []byte, maxRunes int) ([]byte, error) {
+	var (
+		dec = xml.NewDecoder(bytes.NewReader(body))
+		out bytes.Buffer
+	)
+
+	var name = func(n xml.Name) string {
+		if n.Space != "" { // the raw tokens keep the prefixes
+			return n.Space + ":" + n.Local
+		}
+
+		return n.Local
+	}
+
+	for {
+		tok, err := dec.RawToken() // the namespaces are not translated, so the document is written as is
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			out.WriteString("<" + name(t.Name))
+
+			for _, attr := range t.Attr {
+				out.WriteString(" " + name(attr.Name) + `="`)
+				_ = xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteByte('"')
+			}
+
+			out.WriteByte('>')
+		case xml.EndElement:
+			out.WriteString("</" + name(t.Name) + ">")
+		case xml.CharData:
+			if text := string(t); strings.TrimSpace(text) != "" {
+				_ = xml.EscapeText(&out, []byte(truncateString(text, maxRunes)))
+			} else {
+				out.WriteString(text)
+			}
+		case xml.Comment:
+			out.WriteString("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			out.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Directive:
+			out.WriteString("<!" + string(t) + ">")
+		}
+	}
+
+	return out.Bytes(), nil
+}
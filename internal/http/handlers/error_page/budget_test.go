@@ -0,0 +1,91 @@
+package error_page
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitBody(t *testing.T) {
+	t.Parallel()
+
+	var long = strings.Repeat("Привет ", 100) // the multibyte characters are never split
+
+	t.Run("fits", func(t *testing.T) {
+		assert.Equal(t, []byte("foo"), limitBody([]byte("foo"), 3, plainTextFormat, ""))
+		assert.Equal(t, []byte("foo"), limitBody([]byte("foo"), 0, jsonFormat, ""))
+	})
+
+	t.Run("plain text", func(t *testing.T) {
+		var got = limitBody([]byte(long), 128, plainTextFormat, "")
+
+		assert.LessOrEqual(t, len(got), 128)
+		assert.True(t, strings.HasSuffix(string(got), textTruncationMarker))
+		assert.True(t, strings.HasPrefix(long, strings.TrimSuffix(string(got), textTruncationMarker)))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var body, _ = json.MarshalIndent(map[string]any{
+			"code":    404,
+			"message": "Not Found",
+			"error":   map[string]any{"details": []any{long, "short", true, nil}},
+		}, "", "  ")
+
+		var got = limitBody(body, 256, jsonFormat, "")
+
+		assert.LessOrEqual(t, len(got), 256)
+		assert.Greater(t, len(got), 200) // the values are truncated as little as possible
+
+		var decoded struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Error   struct {
+				Details []any `json:"details"`
+			} `json:"error"`
+		}
+
+		require.NoError(t, json.Unmarshal(got, &decoded), string(got))
+		assert.Equal(t, 404, decoded.Code)
+		assert.Equal(t, "Not Found", decoded.Message) // short enough
+		require.Len(t, decoded.Error.Details, 4)
+		assert.True(t, strings.HasSuffix(decoded.Error.Details[0].(string), "…")) //nolint:forcetypeassert
+		assert.Equal(t, []any{"short", true, nil}, decoded.Error.Details[1:])
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		var body = `<?xml version="1.0" encoding="UTF-8"?>` + "\n<error code=\"404\">\n  <message>Not &amp; Found</message>" +
+			"\n  <!-- note -->\n  <description>" + long + "</description>\n</error>\n"
+
+		var got = limitBody([]byte(body), 200, xmlFormat, "")
+
+		assert.LessOrEqual(t, len(got), 200)
+
+		var decoded struct {
+			Code        string `xml:"code,attr"`
+			Message     string `xml:"message"`
+			Description string `xml:"description"`
+		}
+
+		require.NoError(t, xml.Unmarshal(got, &decoded), string(got))
+		assert.Equal(t, "404", decoded.Code)
+		assert.Equal(t, "Not & Found", decoded.Message)
+		assert.True(t, strings.HasSuffix(decoded.Description, "…"))
+		assert.Contains(t, string(got), "<!-- note -->")
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		var body, _ = json.Marshal(strings.Split(strings.Repeat("a,", 200), ",")) // too many values to fit
+
+		assert.Equal(t, []byte(`{"code":404}`), limitBody(body, 128, jsonFormat, `{"code":404}`))
+	})
+
+	t.Run("not a document", func(t *testing.T) {
+		var got = limitBody([]byte(long), 128, jsonFormat, "")
+
+		assert.True(t, strings.HasSuffix(string(got), textTruncationMarker))
+	})
+}
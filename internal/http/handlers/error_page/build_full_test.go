@@ -0,0 +1,6 @@
+//go:build !slim
+
+package error_page
+
+// SlimBuild reports whether the tests are run in the slim build (see the build_slim_test.go).
+const SlimBuild = false
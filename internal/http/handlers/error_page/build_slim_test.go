@@ -0,0 +1,7 @@
+//go:build slim
+
+package error_page
+
+// SlimBuild reports whether the tests are run in the slim build, where the HTML is not minified, only the `ghost`
+// template is built-in, and the multibyte charsets are not supported.
+const SlimBuild = true
@@ -3,34 +3,68 @@ package error_page
 import (
 	"bytes"
 	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
 	"encoding/gob"
+	"encoding/hex"
+	"math"
 	"sync"
 	"time"
 
+	"github.com/binaryYuki/error-pages/internal/clock"
+	"github.com/binaryYuki/error-pages/internal/metrics"
 	"github.com/binaryYuki/error-pages/internal/template"
 )
 
 type (
 	// RenderedCache is a cache for rendered error pages. It's safe for concurrent use.
-	// It uses a hash of the template and props as a key.
+	// It uses a hash of the template and props as a key. The items are namespaced by tenant (see
+	// [RenderedCache.Tenant]), and the number of items per tenant may be limited by the quota - when it's reached,
+	// the oldest items of the same tenant are evicted, so one noisy tenant cannot evict the others' pages.
 	//
 	// To remove expired items, call ClearExpired method periodically (a bit more often than the ttl).
 	RenderedCache struct {
-		ttl time.Duration
+		ttl   time.Duration
+		quota uint // the maximum number of items per tenant, zero means no limit
 
-		mu    sync.RWMutex
-		items map[[32]byte]cacheItem // map[template_hash[0:15];props_hash[16:32]]cache_item
+		mu      sync.RWMutex
+		tenants map[string]map[[32]byte]cacheItem // map[tenant]map[template_hash[0:15];props_hash[16:32]]cache_item
+
+		requests *metrics.Counter // cache hits and misses by tenant (optional)
+		clock    clock.Clock      // the time source of the items expiry
 	}
 
 	cacheItem struct {
 		content     []byte
+		encoded     map[string][]byte // the compressed content by the encoding name (like `gzip`)
+		etag        string            // the entity tag of the content (computed once, when the item is added)
 		addedAtNano int64
 	}
+
+	// TenantCache is a view of the RenderedCache for a single tenant.
+	TenantCache struct {
+		rc     *RenderedCache
+		tenant string
+	}
 )
 
-// NewRenderedCache creates a new RenderedCache with the specified ttl.
-func NewRenderedCache(ttl time.Duration) *RenderedCache {
-	return &RenderedCache{ttl: ttl, items: make(map[[32]byte]cacheItem)}
+// NewRenderedCache creates a new RenderedCache with the specified ttl. The optional quota limits the number of
+// items per tenant (zero means no limit).
+func NewRenderedCache(ttl time.Duration, quota ...uint) *RenderedCache {
+	var rc = RenderedCache{ttl: ttl, tenants: make(map[string]map[[32]byte]cacheItem), clock: clock.System}
+
+	if len(quota) > 0 {
+		rc.quota = quota[0]
+	}
+
+	return &rc
+}
+
+// WithClock sets the time source of the items expiry (the system clock by default) and returns the cache. It must be
+// called before the cache is used.
+func (rc *RenderedCache) WithClock(c clock.Clock) *RenderedCache {
+	rc.clock = c
+
+	return rc
 }
 
 // genKey generates a key for the cache item by hashing the template and props.
@@ -46,57 +80,211 @@ func (rc *RenderedCache) genKey(template string, props template.Props) [32]byte
 	return key
 }
 
-// Has checks if the cache has an item with the specified template and props.
-func (rc *RenderedCache) Has(template string, props template.Props) bool {
-	var key = rc.genKey(template, props)
-
-	rc.mu.RLock()
-	_, ok := rc.items[key]
-	rc.mu.RUnlock()
+// Tenant returns the cache view for the specified tenant.
+func (rc *RenderedCache) Tenant(tenant string) TenantCache {
+	return TenantCache{rc: rc, tenant: tenant}
+}
 
-	return ok
+// Has checks if the cache has an item with the specified template and props (for the default tenant).
+func (rc *RenderedCache) Has(template string, props template.Props) bool {
+	return rc.Tenant("").Has(template, props)
 }
 
-// Put adds a new item to the cache with the specified template, props, and content.
+// Put adds a new item to the cache with the specified template, props, and content (for the default tenant).
 func (rc *RenderedCache) Put(template string, props template.Props, content []byte) {
-	var key = rc.genKey(template, props)
-
-	rc.mu.Lock()
-	rc.items[key] = cacheItem{content: content, addedAtNano: time.Now().UnixNano()}
-	rc.mu.Unlock()
+	rc.Tenant("").Put(template, props, content)
 }
 
-// Get returns the content of the item with the specified template and props.
+// Get returns the content of the item with the specified template and props (for the default tenant).
 func (rc *RenderedCache) Get(template string, props template.Props) ([]byte, bool) {
-	var key = rc.genKey(template, props)
+	return rc.Tenant("").Get(template, props)
+}
 
+// Len returns the number of items cached for the tenant.
+func (rc *RenderedCache) Len(tenant string) int {
 	rc.mu.RLock()
-	item, ok := rc.items[key]
-	rc.mu.RUnlock()
+	defer rc.mu.RUnlock()
 
-	return item.content, ok
+	return len(rc.tenants[tenant])
 }
 
 // ClearExpired removes all expired items from the cache.
 func (rc *RenderedCache) ClearExpired() {
 	rc.mu.Lock()
 
-	var now = time.Now().UnixNano()
+	var now = rc.clock.Now().UnixNano()
 
-	for key, item := range rc.items {
-		if now-item.addedAtNano > rc.ttl.Nanoseconds() {
-			delete(rc.items, key)
+	for tenant, items := range rc.tenants {
+		for key, item := range items {
+			if now-item.addedAtNano > rc.ttl.Nanoseconds() {
+				delete(items, key)
+			}
+		}
+
+		if len(items) == 0 {
+			delete(rc.tenants, tenant)
 		}
 	}
 
 	rc.mu.Unlock()
 }
 
-// Clear removes all items from the cache.
-func (rc *RenderedCache) Clear() {
+// Invalidate removes the items rendered from the template (for all tenants) and returns the number of removed
+// items. It's used when the template is replaced (e.g. by the rotation), so the pages of the previous template are
+// dropped right away instead of waiting for the ttl expiry.
+func (rc *RenderedCache) Invalidate(template string) (removed int) {
+	var th = hash(template)
+
 	rc.mu.Lock()
-	clear(rc.items)
-	rc.mu.Unlock()
+	defer rc.mu.Unlock()
+
+	for tenant, items := range rc.tenants {
+		for key := range items {
+			if [16]byte(key[:16]) == th {
+				delete(items, key)
+				removed++
+			}
+		}
+
+		if len(items) == 0 {
+			delete(rc.tenants, tenant)
+		}
+	}
+
+	return removed
+}
+
+// Clear removes all items from the cache and returns the number of removed items.
+func (rc *RenderedCache) Clear() (removed int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, items := range rc.tenants {
+		removed += len(items)
+	}
+
+	clear(rc.tenants)
+
+	return removed
+}
+
+// Has checks if the cache has an item with the specified template and props.
+func (tc TenantCache) Has(template string, props template.Props) bool {
+	var key = tc.rc.genKey(template, props)
+
+	tc.rc.mu.RLock()
+	_, ok := tc.rc.tenants[tc.tenant][key]
+	tc.rc.mu.RUnlock()
+
+	return ok
+}
+
+// Put adds a new item to the cache with the specified template, props, and content. If the tenant quota is
+// reached, the oldest tenant item is evicted.
+func (tc TenantCache) Put(template string, props template.Props, content []byte) {
+	var key, etag = tc.rc.genKey(template, props), contentETag(content)
+
+	tc.rc.mu.Lock()
+	defer tc.rc.mu.Unlock()
+
+	var items, ok = tc.rc.tenants[tc.tenant]
+	if !ok {
+		items = make(map[[32]byte]cacheItem)
+		tc.rc.tenants[tc.tenant] = items
+	}
+
+	if _, exists := items[key]; !exists && tc.rc.quota > 0 {
+		for uint(len(items)) >= tc.rc.quota {
+			var (
+				oldestKey [32]byte
+				oldestAt  int64 = math.MaxInt64
+			)
+
+			for k, item := range items {
+				if item.addedAtNano < oldestAt {
+					oldestKey, oldestAt = k, item.addedAtNano
+				}
+			}
+
+			delete(items, oldestKey)
+		}
+	}
+
+	items[key] = cacheItem{content: content, etag: etag, addedAtNano: tc.rc.clock.Now().UnixNano()}
+}
+
+// Get returns the content of the item with the specified template and props.
+func (tc TenantCache) Get(template string, props template.Props) ([]byte, bool) {
+	var key = tc.rc.genKey(template, props)
+
+	tc.rc.mu.RLock()
+	item, ok := tc.rc.tenants[tc.tenant][key]
+	tc.rc.mu.RUnlock()
+
+	if ok {
+		tc.rc.requests.Inc(tc.tenant, "hit")
+	} else {
+		tc.rc.requests.Inc(tc.tenant, "miss")
+	}
+
+	return item.content, ok
+}
+
+// Validators returns the entity tag of the content and the time it was added (the last modification time, with a
+// second precision) of the item with the specified template and props. The lookups are not reported in the metrics.
+func (tc TenantCache) Validators(template string, props template.Props) (etag string, modified time.Time, ok bool) {
+	var key = tc.rc.genKey(template, props)
+
+	tc.rc.mu.RLock()
+	item, ok := tc.rc.tenants[tc.tenant][key]
+	tc.rc.mu.RUnlock()
+
+	if !ok {
+		return "", time.Time{}, false
+	}
+
+	return item.etag, time.Unix(0, item.addedAtNano).UTC().Truncate(time.Second), true
+}
+
+// GetEncoded returns the compressed content (the encoding name is like `gzip` or `br`) of the item with the
+// specified template and props. The lookups are not reported in the metrics.
+func (tc TenantCache) GetEncoded(template string, props template.Props, encoding string) ([]byte, bool) {
+	var key = tc.rc.genKey(template, props)
+
+	tc.rc.mu.RLock()
+	defer tc.rc.mu.RUnlock()
+
+	content, ok := tc.rc.tenants[tc.tenant][key].encoded[encoding]
+
+	return content, ok
+}
+
+// PutEncoded adds the compressed content to the cached item with the specified template and props, so it expires
+// (or is evicted) together with the item. Nothing is done if the item is not cached.
+func (tc TenantCache) PutEncoded(template string, props template.Props, encoding string, content []byte) {
+	var key = tc.rc.genKey(template, props)
+
+	tc.rc.mu.Lock()
+	defer tc.rc.mu.Unlock()
+
+	item, ok := tc.rc.tenants[tc.tenant][key]
+	if !ok {
+		return
+	}
+
+	if item.encoded == nil {
+		item.encoded = make(map[string][]byte, 2) //nolint:mnd
+	}
+
+	item.encoded[encoding] = content
+	tc.rc.tenants[tc.tenant][key] = item
+}
+
+// contentETag returns the strong entity tag of the content (the truncated SHA-256 hash).
+func contentETag(content []byte) string {
+	var sum = sha256.Sum256(content)
+
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
 }
 
 // hash returns an MD5 hash of the provided value (it may be any built-in type).
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"gh.tarampamp.am/error-pages/internal/clock"
 	"gh.tarampamp.am/error-pages/internal/http/handlers/error_page"
 	"gh.tarampamp.am/error-pages/internal/template"
 )
@@ -32,7 +33,8 @@ func TestRenderedCache_CRUD(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, []byte("content"), got)
 
-		cache.Clear()
+		assert.Equal(t, 1, cache.Clear())
+		assert.Zero(t, cache.Clear())
 
 		assert.False(t, cache.Has("template", template.Props{}))
 	})
@@ -72,15 +74,162 @@ func TestRenderedCache_CRUD(t *testing.T) {
 func TestRenderedCache_Expiring(t *testing.T) {
 	t.Parallel()
 
-	var cache = error_page.NewRenderedCache(10 * time.Millisecond)
+	var (
+		fake  = clock.NewFake(time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC))
+		cache = error_page.NewRenderedCache(10 * time.Millisecond).WithClock(fake)
+	)
 
 	cache.Put("template", template.Props{}, []byte("content"))
 	cache.ClearExpired()
 	assert.True(t, cache.Has("template", template.Props{}))
 
-	<-time.After(10 * time.Millisecond)
+	fake.Advance(10 * time.Millisecond)
+	cache.ClearExpired()
+	assert.True(t, cache.Has("template", template.Props{})) // exactly the ttl, not expired yet
+
+	fake.Advance(time.Nanosecond)
 
 	assert.True(t, cache.Has("template", template.Props{})) // expired, but not cleared yet
 	cache.ClearExpired()
 	assert.False(t, cache.Has("template", template.Props{})) // cleared
 }
+
+func TestRenderedCache_Tenants(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cache       = error_page.NewRenderedCache(time.Minute, 2)
+		noisy, calm = cache.Tenant("noisy.com"), cache.Tenant("calm.com")
+	)
+
+	calm.Put("template", template.Props{Code: 1}, []byte("calm"))
+
+	t.Run("isolation", func(t *testing.T) {
+		assert.False(t, noisy.Has("template", template.Props{Code: 1}))
+		assert.False(t, cache.Has("template", template.Props{Code: 1})) // the default tenant
+
+		got, ok := calm.Get("template", template.Props{Code: 1})
+		assert.True(t, ok)
+		assert.Equal(t, []byte("calm"), got)
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		for i := range 10 {
+			noisy.Put("template", template.Props{Code: uint16(i)}, []byte("noisy")) //nolint:gosec
+
+			<-time.After(time.Millisecond) // the items are ordered by the time they were added
+		}
+
+		assert.Equal(t, 2, cache.Len("noisy.com"))
+		assert.True(t, noisy.Has("template", template.Props{Code: 9}))
+		assert.True(t, noisy.Has("template", template.Props{Code: 8}))
+		assert.False(t, noisy.Has("template", template.Props{Code: 7})) // evicted
+
+		assert.Equal(t, 1, cache.Len("calm.com"))
+		assert.True(t, calm.Has("template", template.Props{Code: 1})) // not affected by the noisy tenant
+	})
+
+	t.Run("overwrite does not evict", func(t *testing.T) {
+		noisy.Put("template", template.Props{Code: 9}, []byte("updated"))
+
+		assert.Equal(t, 2, cache.Len("noisy.com"))
+		assert.True(t, noisy.Has("template", template.Props{Code: 8}))
+	})
+}
+
+func TestRenderedCache_Encoded(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cache  = error_page.NewRenderedCache(time.Minute)
+		tenant = cache.Tenant("")
+		props  = template.Props{Code: 1}
+	)
+
+	tenant.PutEncoded("template", props, "gzip", []byte("gzipped"))
+
+	_, ok := tenant.GetEncoded("template", props, "gzip")
+	assert.False(t, ok) // the item is not cached, so the compressed content is not cached too
+
+	tenant.Put("template", props, []byte("plain"))
+	tenant.PutEncoded("template", props, "gzip", []byte("gzipped"))
+	tenant.PutEncoded("template", props, "br", []byte("brotli"))
+
+	got, ok := tenant.GetEncoded("template", props, "gzip")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("gzipped"), got)
+
+	got, ok = tenant.GetEncoded("template", props, "br")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("brotli"), got)
+
+	_, ok = tenant.GetEncoded("template", props, "zstd")
+	assert.False(t, ok)
+
+	_, ok = cache.Tenant("other").GetEncoded("template", props, "gzip")
+	assert.False(t, ok) // the tenants are isolated
+
+	got, ok = tenant.Get("template", props)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("plain"), got) // the plain content is not affected
+
+	tenant.Put("template", props, []byte("updated"))
+
+	_, ok = tenant.GetEncoded("template", props, "gzip")
+	assert.False(t, ok) // the overwritten item drops the stale compressed content
+}
+
+func TestRenderedCache_Validators(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now    = time.Date(2024, 3, 5, 14, 3, 0, 500, time.UTC)
+		cache  = error_page.NewRenderedCache(time.Minute).WithClock(clock.NewFake(now))
+		tenant = cache.Tenant("")
+		props  = template.Props{Code: 1}
+	)
+
+	_, _, ok := tenant.Validators("template", props)
+	assert.False(t, ok)
+
+	tenant.Put("template", props, []byte("content"))
+
+	etag, modified, ok := tenant.Validators("template", props)
+	assert.True(t, ok)
+	assert.Equal(t, `"ed7002b439e9ac84"`, etag) // the SHA-256 prefix of the content
+	assert.Equal(t, now.Truncate(time.Second), modified)
+
+	tenant.Put("other", props, []byte("content"))
+
+	other, _, _ := tenant.Validators("other", props)
+	assert.Equal(t, etag, other) // the same content has the same tag
+
+	tenant.Put("template", props, []byte("updated"))
+
+	updated, _, _ := tenant.Validators("template", props)
+	assert.NotEqual(t, etag, updated)
+
+	_, _, ok = cache.Tenant("other").Validators("template", props)
+	assert.False(t, ok) // the tenants are isolated
+}
+
+func TestRenderedCache_Invalidate(t *testing.T) {
+	t.Parallel()
+
+	var cache = error_page.NewRenderedCache(time.Minute)
+
+	for _, tenant := range []string{"", "example.com"} {
+		cache.Tenant(tenant).Put("old", template.Props{Code: 1}, []byte("old"))
+		cache.Tenant(tenant).Put("old", template.Props{Code: 2}, []byte("old"))
+		cache.Tenant(tenant).Put("new", template.Props{Code: 1}, []byte("new"))
+	}
+
+	assert.Equal(t, 4, cache.Invalidate("old"))
+	assert.Zero(t, cache.Invalidate("old"))
+
+	for _, tenant := range []string{"", "example.com"} {
+		assert.False(t, cache.Tenant(tenant).Has("old", template.Props{Code: 1}))
+		assert.False(t, cache.Tenant(tenant).Has("old", template.Props{Code: 2}))
+		assert.True(t, cache.Tenant(tenant).Has("new", template.Props{Code: 1})) // not affected
+	}
+}
@@ -0,0 +1,40 @@
+package error_page
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/charset"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+// transcoded reports whether the responses of the format (HTML or plain text, the default one) are transcoded to
+// the configured charset. The JSON and XML are always sent in UTF-8, as their specifications require or declare it.
+func transcoded(format preferredFormat) bool {
+	return format != jsonFormat && format != xmlFormat && format != grpcWebFormat
+}
+
+// encodeBody transcodes the response body (rendered in UTF-8) to the charset. The unsupported characters are
+// replaced with the numeric character references in HTML, or with the question marks otherwise. If the body
+// cannot be transcoded, it's sent as-is, and the `Content-Type` charset is reverted to UTF-8.
+func encodeBody(ctx *fasthttp.RequestCtx, log *logger.Logger, cs charset.Charset, html bool) {
+	if cs.IsUTF8() || ctx.Response.IsBodyStream() {
+		return
+	}
+
+	out, err := cs.Encode(ctx.Response.Body(), html)
+	if err != nil {
+		if log != nil {
+			log.Error("Failed to transcode the response", logger.String("charset", cs.String()), logger.Error(err))
+		}
+
+		ctx.SetContentType(strings.Replace(
+			string(ctx.Response.Header.ContentType()), "charset="+cs.String(), "charset=utf-8", 1,
+		))
+
+		return
+	}
+
+	ctx.Response.SetBody(out)
+}
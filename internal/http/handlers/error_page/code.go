@@ -1,62 +1,113 @@
 package error_page
 
 import (
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
 )
 
-// extractCodeFromURL extracts the error code from the given URL.
-func extractCodeFromURL(url string) (uint16, bool) {
-	var parts = strings.SplitN(strings.TrimLeft(url, "/"), "/", 1)
+// originalStatusHeader is the request header with the status code returned by the upstream, set by another error
+// intercepting proxy in the chain (which may rewrite the code).
+const originalStatusHeader = "X-Original-Status"
 
-	if len(parts) == 0 {
-		return 0, false
+// extractOriginalStatus extracts the original status code from the given headers (0 if missing or invalid).
+func extractOriginalStatus(headers *fasthttp.RequestHeader) uint16 {
+	if value := headers.Peek(originalStatusHeader); len(value) > 0 && len(value) <= 3 {
+		if code, err := strconv.ParseUint(string(value), 10, 16); err == nil && code >= 100 {
+			return uint16(code)
+		}
 	}
 
-	var (
-		fileName = strings.ToLower(parts[0])
-		ext      = filepath.Ext(fileName) // ".html", ".htm", ".%something%" or an empty string
-	)
+	return 0
+}
+
+// upstreamDurationHeader is the request header with the time the upstream took to respond (or to fail), set by the
+// proxy (like the nginx `$upstream_response_time`).
+const upstreamDurationHeader = "X-Upstream-Duration"
+
+// maxUpstreamDuration limits the upstream duration (the longer ones are considered invalid).
+const maxUpstreamDuration = 24 * time.Hour
 
-	if ext != "" && ext != ".html" && ext != ".htm" {
-		return 0, false
-	} else if ext != "" {
-		fileName = strings.TrimSuffix(fileName, ext)
+// extractUpstreamDuration extracts the upstream duration from the given headers (0 if missing or invalid). The value
+// is a number of seconds (like `30.004`) or a Go duration (like `1.5s` or `250ms`). The comma (or colon) separated
+// durations of several attempts (like `0.002, 30.001`, as nginx reports them) are summed, and the attempts without
+// a response (`-`) are skipped.
+func extractUpstreamDuration(headers *fasthttp.RequestHeader) time.Duration {
+	var value = headers.Peek(upstreamDurationHeader)
+	if len(value) == 0 || len(value) > 128 { //nolint:mnd
+		return 0
 	}
 
-	if code, err := strconv.ParseUint(fileName, 10, 16); err == nil && code > 0 && code < 999 {
-		return uint16(code), true
+	var total time.Duration
+
+	for _, part := range strings.FieldsFunc(string(value), func(r rune) bool { return r == ',' || r == ':' }) {
+		if part = strings.TrimSpace(part); part == "" || part == "-" {
+			continue
+		}
+
+		var d time.Duration
+
+		if seconds, err := strconv.ParseFloat(part, 64); err == nil {
+			if seconds < 0 || seconds > maxUpstreamDuration.Seconds() {
+				return 0
+			}
+
+			d = time.Duration(seconds * float64(time.Second))
+		} else if parsed, pErr := time.ParseDuration(part); pErr == nil && parsed >= 0 {
+			d = parsed
+		} else {
+			return 0
+		}
+
+		if total += d; total > maxUpstreamDuration {
+			return 0
+		}
 	}
 
-	return 0, false
+	return total
 }
 
-// URLContainsCode checks if the given URL contains an error code.
-func URLContainsCode(url string) (ok bool) { _, ok = extractCodeFromURL(url); return } //nolint:nlreturn
+// upstreamDurationBucket returns the upstream duration bucket (the metric label value, like `5-30s`).
+func upstreamDurationBucket(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "0-1s"
+	case d < 5*time.Second:
+		return "1-5s"
+	case d < 30*time.Second: //nolint:mnd
+		return "5-30s"
+	case d < time.Minute:
+		return "30-60s"
+	}
 
-// extractCodeFromHeaders extracts the error code from the given headers.
-func extractCodeFromHeaders(headers *fasthttp.RequestHeader) (uint16, bool) {
-	if headers == nil {
-		return 0, false
+	return "60s+"
+}
+
+// errorKindHeader is the request header with the name of the error kind (a business error, like `quota_exceeded`).
+const errorKindHeader = "X-Error-Kind"
+
+// extractErrorKind returns the error kind (and its normalized name) by the name from the request headers.
+func extractErrorKind(headers *fasthttp.RequestHeader, kinds config.ErrorKinds) (config.ErrorKind, string, bool) {
+	if headers == nil || len(kinds) == 0 {
+		return config.ErrorKind{}, "", false
 	}
 
-	// https://kubernetes.github.io/ingress-nginx/user-guide/custom-errors/
-	// HTTP status code returned by the request
-	if value := headers.Peek("X-Code"); len(value) > 0 && len(value) <= 3 {
-		if code, err := strconv.ParseUint(string(value), 10, 16); err == nil && code > 0 && code < 999 {
-			return uint16(code), true
-		}
+	var name = strings.ToLower(strings.TrimSpace(string(headers.Peek(errorKindHeader))))
+
+	if kind, ok := kinds.Find(name); ok {
+		return kind, name, true
 	}
 
-	return 0, false
+	return config.ErrorKind{}, "", false
 }
 
-// HeadersContainCode checks if the given headers contain an error code.
-func HeadersContainCode(headers *fasthttp.RequestHeader) (ok bool) {
-	_, ok = extractCodeFromHeaders(headers)
+// HeadersContainErrorKind checks if the given headers contain a known error kind.
+func HeadersContainErrorKind(headers *fasthttp.RequestHeader, kinds config.ErrorKinds) (ok bool) {
+	_, _, ok = extractErrorKind(headers, kinds)
 
 	return
 }
@@ -1,66 +1,57 @@
-package error_page_test
+package error_page
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
-
-	"gh.tarampamp.am/error-pages/internal/http/handlers/error_page"
 )
 
-func TestURLContainsCode(t *testing.T) {
+func TestExtractUpstreamDuration(t *testing.T) {
 	t.Parallel()
 
-	for giveUrl, wantOk := range map[string]bool{
-		"/404":          true,
-		"/404.htm":      true,
-		"/404.HTM":      true,
-		"/404.html":     true,
-		"/404.HtmL":     true,
-		"/404.css":      false,
-		"/foo/404":      false,
-		"/foo/404.html": false,
-		"/error":        false,
-		"/":             false,
-		"/////":         false,
-		"///404//":      false,
-		"":              false,
+	for give, want := range map[string]time.Duration{
+		"":                  0,
+		"30.004":            30004 * time.Millisecond,
+		"0.5":               500 * time.Millisecond,
+		"1.5s":              1500 * time.Millisecond,
+		"250ms":             250 * time.Millisecond,
+		"0.002, 30.001":     30003 * time.Millisecond, // several attempts
+		"0.002 : 1.000":     1002 * time.Millisecond,  // the internal redirect
+		"-, 5.000":          5 * time.Second,          // no response from the first upstream
+		"-":                 0,
+		"-1":                0,
+		"foo":               0,
+		"1.0, foo":          0,
+		"100000":            0, // too long
+		"90000s, 90000s":    0,
+		"30.0 30.0 garbage": 0,
 	} {
-		t.Run(giveUrl, func(t *testing.T) {
-			assert.Equal(t, wantOk, error_page.URLContainsCode(giveUrl))
-		})
-	}
-}
-
-func TestHeadersContainCode(t *testing.T) {
-	t.Parallel()
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
 
-	var mkHeaders = func(key, value string) *fasthttp.RequestHeader {
-		var out = new(fasthttp.RequestHeader)
+			var headers fasthttp.RequestHeader
 
-		out.Set(key, value)
+			if give != "" {
+				headers.Set(upstreamDurationHeader, give)
+			}
 
-		return out
+			assert.Equal(t, want, extractUpstreamDuration(&headers))
+		})
 	}
+}
 
-	for name, _tt := range map[string]struct {
-		giveHeaders *fasthttp.RequestHeader
-		wantOk      bool
-	}{
-		"with code": {giveHeaders: mkHeaders("X-Code", "404"), wantOk: true},
+func TestUpstreamDurationBucket(t *testing.T) {
+	t.Parallel()
 
-		"empty":     {giveHeaders: nil},
-		"no code":   {giveHeaders: mkHeaders("X-Code", "")},
-		"wrong":     {giveHeaders: mkHeaders("X-Code", "foo")},
-		"too big":   {giveHeaders: mkHeaders("X-Code", "1000")},
-		"too small": {giveHeaders: mkHeaders("X-Code", "0")},
-		"negative":  {giveHeaders: mkHeaders("X-Code", "-1")},
+	for give, want := range map[time.Duration]string{
+		time.Millisecond:         "0-1s",
+		time.Second:              "1-5s",
+		29 * time.Second:         "5-30s",
+		30004 * time.Millisecond: "30-60s",
+		time.Hour:                "60s+",
 	} {
-		tt := _tt
-
-		t.Run(name, func(t *testing.T) {
-			assert.Equal(t, tt.wantOk, error_page.HeadersContainCode(tt.giveHeaders))
-		})
+		assert.Equal(t, want, upstreamDurationBucket(give))
 	}
 }
@@ -0,0 +1,64 @@
+package error_page
+
+import (
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/contentcoding"
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+// minCompressSize is the minimal response body size to compress (the smaller bodies are not worth it).
+const minCompressSize = 256
+
+// supportedEncodings are the supported content encodings, ordered by preference (used when the client weights
+// are equal).
+var supportedEncodings = [...]string{"br", "gzip"} //nolint:gochecknoglobals
+
+// compressBody compresses the response body using the encoding negotiated with the client. When the body is the
+// cached content (the cache key template is not empty), the compressed body is cached alongside the plain one, so
+// it's not compressed on every request. The streamed, small, or already encoded bodies are sent as-is.
+func compressBody(ctx *fasthttp.RequestCtx, cache TenantCache, cachedBy string, props template.Props) {
+	var resp = &ctx.Response
+
+	if resp.IsBodyStream() || len(resp.Body()) < minCompressSize || len(resp.Header.ContentEncoding()) > 0 {
+		return
+	}
+
+	resp.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAcceptEncoding) // the response depends on the header
+
+	var encoding = contentcoding.Negotiate(
+		string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptEncoding)), supportedEncodings[:]...,
+	)
+	if encoding == "" {
+		return
+	}
+
+	if cachedBy != "" {
+		if encoded, ok := cache.GetEncoded(cachedBy, props, encoding); ok {
+			resp.Header.SetContentEncoding(encoding)
+			resp.SetBodyRaw(encoded)
+
+			return
+		}
+	}
+
+	var body, encoded = resp.Body(), []byte(nil)
+
+	switch encoding {
+	case "br":
+		encoded = fasthttp.AppendBrotliBytesLevel(nil, body, fasthttp.CompressBrotliDefaultCompression)
+	case "gzip":
+		encoded = fasthttp.AppendGzipBytesLevel(nil, body, fasthttp.CompressDefaultCompression)
+	}
+
+	if len(encoded) == 0 || len(encoded) >= len(body) {
+		return // not worth it
+	}
+
+	if cachedBy != "" {
+		cache.PutEncoded(cachedBy, props, encoding, encoded)
+	}
+
+	resp.Header.SetContentEncoding(encoding)
+	resp.SetBodyRaw(encoded)
+}
@@ -0,0 +1,40 @@
+package error_page
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/precondition"
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+// conditional sets the `ETag` (distinct for each content encoding) and `Last-Modified` (the render time) response
+// headers of the cached content, and responds with the `304 Not Modified` (without the body) if the client already
+// has the same content (per the `If-None-Match` or `If-Modified-Since` request headers), so the repeated requests
+// (like of the crawlers and monitoring systems) are cheap. The preconditions are evaluated for the successful GET
+// and HEAD responses only (RFC 9110, section 13.2.1), so the error status codes are always sent with the page.
+func conditional(ctx *fasthttp.RequestCtx, cache TenantCache, cachedBy string, props template.Props) {
+	etag, modified, ok := cache.Validators(cachedBy, props)
+	if !ok { // evicted in the meantime
+		return
+	}
+
+	if encoding := ctx.Response.Header.ContentEncoding(); len(encoding) > 0 {
+		etag = strings.TrimSuffix(etag, `"`) + "-" + string(encoding) + `"`
+	}
+
+	ctx.Response.Header.Set(fasthttp.HeaderETag, etag)
+	ctx.Response.Header.SetLastModified(modified)
+
+	if status := ctx.Response.StatusCode(); status < http.StatusOK || status >= http.StatusMultipleChoices ||
+		(!ctx.IsGet() && !ctx.IsHead()) {
+		return
+	}
+
+	if precondition.NotModified(&ctx.Request.Header, etag, modified) {
+		ctx.Response.ResetBody()
+		ctx.SetStatusCode(http.StatusNotModified) // the validators are kept (RFC 9110, section 15.4.5)
+	}
+}
@@ -0,0 +1,29 @@
+package error_page
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// cspNonceSize is the size of the CSP nonce in bytes (128 bits, as recommended by the CSP specification).
+const cspNonceSize = 16
+
+// newCSPNonce returns a new random base64-encoded CSP nonce.
+func newCSPNonce() string {
+	var b [cspNonceSize]byte
+
+	_, _ = rand.Read(b[:]) // never returns an error
+
+	return base64.StdEncoding.EncodeToString(b[:])
+}
+
+// withNonce replaces the CSP nonce placeholder in the content with the nonce (the content is returned as-is if
+// the placeholder is empty).
+func withNonce(content, placeholder []byte, nonce string) []byte {
+	if len(placeholder) == 0 {
+		return content
+	}
+
+	return bytes.ReplaceAll(content, placeholder, []byte(nonce))
+}
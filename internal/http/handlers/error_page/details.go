@@ -0,0 +1,97 @@
+package error_page
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+// errorDetailHeader is the request header with the error detail (like a validation error), set by the upstream or
+// API gateway. It may be repeated, and its value is a plain text message or a small JSON blob - the message string,
+// the `{"field": "...", "message": "..."}` object, or an array of them.
+const errorDetailHeader = "X-Error-Detail"
+
+// limits of the error details, so the upstream cannot blow up the error page
+const (
+	maxErrorDetails      = 16
+	maxErrorDetailLength = 256 // in bytes, for both the field and the message
+)
+
+// extractErrorDetails returns the error details from the request headers (nil if there are none). The invalid
+// JSON blobs and empty messages are skipped, the long values are truncated.
+func extractErrorDetails(headers *fasthttp.RequestHeader) []template.ErrorDetail {
+	var details []template.ErrorDetail
+
+	for _, value := range headers.PeekAll(errorDetailHeader) {
+		var trimmed = bytes.TrimSpace(value)
+
+		if len(trimmed) == 0 {
+			continue
+		}
+
+		if trimmed[0] != '[' && trimmed[0] != '{' && trimmed[0] != '"' { // plain text
+			details = appendErrorDetail(details, "", string(trimmed))
+
+			continue
+		}
+
+		var items []json.RawMessage
+
+		if trimmed[0] == '[' {
+			if err := json.Unmarshal(trimmed, &items); err != nil {
+				continue
+			}
+		} else {
+			items = []json.RawMessage{trimmed}
+		}
+
+		for _, item := range items {
+			var (
+				message string
+				object  struct {
+					Field   string `json:"field"`
+					Message string `json:"message"`
+				}
+			)
+
+			if err := json.Unmarshal(item, &message); err == nil {
+				details = appendErrorDetail(details, "", message)
+			} else if err = json.Unmarshal(item, &object); err == nil {
+				details = appendErrorDetail(details, object.Field, object.Message)
+			}
+		}
+	}
+
+	return details
+}
+
+// appendErrorDetail appends the detail with the truncated field and message (unless the message is empty or the
+// limit of the details is reached).
+func appendErrorDetail(details []template.ErrorDetail, field, message string) []template.ErrorDetail {
+	if message = strings.TrimSpace(message); message == "" || len(details) >= maxErrorDetails {
+		return details
+	}
+
+	return append(details, template.ErrorDetail{
+		Field:   truncateUTF8(strings.TrimSpace(field), maxErrorDetailLength),
+		Message: truncateUTF8(message, maxErrorDetailLength),
+	})
+}
+
+// truncateUTF8 truncates the string to the max length in bytes, without breaking the multibyte characters.
+func truncateUTF8(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
+
+	return s[:maxLen]
+}
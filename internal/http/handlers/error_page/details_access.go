@@ -0,0 +1,77 @@
+package error_page
+
+import (
+	"crypto/subtle"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+// DetailsPredicate decides per request whether the request details are shown (when the details are enabled). The
+// built-in rules are the networks, token, and WASM hook ones (see [newDetailsPredicate]); any other logic is
+// plugged in using [WithDetailsPredicate].
+type DetailsPredicate func(ctx *fasthttp.RequestCtx) bool
+
+// newDetailsPredicate returns the predicate granting access to the request details according to the configured
+// rules: the client IP is in any of the networks, the request carries the token, or the WASM hook allows it (the
+// hook is called only if the other rules don't grant access, and its failures are logged and treated as a denial).
+// It returns nil if the access is not restricted.
+func newDetailsPredicate(
+	access config.DetailsAccess,
+	clientIP *clientip.Resolver,
+	log *logger.Logger,
+) DetailsPredicate {
+	if !access.Restricted() {
+		return nil
+	}
+
+	var token = []byte(access.Token)
+
+	return func(ctx *fasthttp.RequestCtx) bool {
+		if len(token) > 0 {
+			if got := ctx.Request.Header.Peek(access.Header); subtle.ConstantTimeCompare(got, token) == 1 {
+				return true
+			}
+		}
+
+		if len(access.Networks) > 0 {
+			if addr := clientIP.Resolve(ctx); addr.IsValid() {
+				for _, network := range access.Networks {
+					if network.Contains(addr) {
+						return true
+					}
+				}
+			}
+		}
+
+		if access.Hook != nil {
+			allowed, err := access.Hook.Allow(hookInput(ctx, clientIP.String(ctx)))
+			if err != nil {
+				log.Warn("Details WASM hook failed", logger.Error(err))
+
+				return false
+			}
+
+			return allowed
+		}
+
+		return false
+	}
+}
+
+// hookInput returns the input of the WASM hook: the client IP on the first line (empty if unknown), followed by the
+// request headers (one `Name: value` per line).
+func hookInput(ctx *fasthttp.RequestCtx, clientIP string) []byte {
+	var buf = make([]byte, 0, len(clientIP)+1+ctx.Request.Header.Len()*32) //nolint:mnd // the average header size
+
+	buf = append(append(buf, clientIP...), '\n')
+
+	for key, value := range ctx.Request.Header.All() {
+		buf = append(append(append(append(buf, key...), ": "...), value...), '\n')
+	}
+
+	return buf
+}
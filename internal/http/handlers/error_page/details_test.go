@@ -0,0 +1,66 @@
+package error_page
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestExtractErrorDetails(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveHeaders []string
+		want        []template.ErrorDetail
+	}{
+		"none":  {},
+		"empty": {giveHeaders: []string{" "}},
+		"plain text": {
+			giveHeaders: []string{"the email is invalid", " quota exceeded "},
+			want:        []template.ErrorDetail{{Message: "the email is invalid"}, {Message: "quota exceeded"}},
+		},
+		"json string": {
+			giveHeaders: []string{`"the email is invalid"`},
+			want:        []template.ErrorDetail{{Message: "the email is invalid"}},
+		},
+		"json object": {
+			giveHeaders: []string{`{"field": "email", "message": "is invalid"}`},
+			want:        []template.ErrorDetail{{Field: "email", Message: "is invalid"}},
+		},
+		"json array": {
+			giveHeaders: []string{`[{"field": "email", "message": "is invalid"}, "quota exceeded", {"field": "x"}, 42]`},
+			want:        []template.ErrorDetail{{Field: "email", Message: "is invalid"}, {Message: "quota exceeded"}},
+		},
+		"invalid json": {giveHeaders: []string{`[{"field": "email"`, `{foo}`}},
+		"truncated": {
+			giveHeaders: []string{strings.Repeat("ж", 200)},
+			want:        []template.ErrorDetail{{Message: strings.Repeat("ж", 128)}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var headers fasthttp.RequestHeader
+
+			for _, v := range tt.giveHeaders {
+				headers.Add("X-Error-Detail", v)
+			}
+
+			assert.Equal(t, tt.want, extractErrorDetails(&headers))
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+
+		var headers fasthttp.RequestHeader
+
+		headers.Set("X-Error-Detail", "["+strings.TrimSuffix(strings.Repeat(`"foo",`, 100), ",")+"]")
+
+		assert.Len(t, extractErrorDetails(&headers), maxErrorDetails)
+	})
+}
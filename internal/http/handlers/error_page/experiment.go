@@ -0,0 +1,77 @@
+package error_page
+
+import (
+	mathRand "math/rand/v2"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+const (
+	experimentHeader    = "X-Error-Page-Variant" // the response header with the picked template name
+	experimentCookie    = "error_page_variant"   // the cookie to keep the client on the same variant
+	experimentCookieTTL = 30 * 24 * time.Hour
+)
+
+// experiment splits the traffic between two templates and counts the renders of each variant.
+type experiment struct {
+	templates [2]string
+	split     uint8 // the share (in percent) of the traffic for the second template
+	renders   *metrics.Counter
+}
+
+// newExperiment creates a new experiment. The renders are reported to the given registry (may be nil).
+func newExperiment(templates [2]string, split uint8, reg *metrics.Registry) *experiment {
+	return &experiment{
+		templates: templates,
+		split:     split,
+		renders: reg.Counter(
+			"error_pages_experiment_renders_total",
+			"The number of error pages rendered for each experiment variant (template)",
+			"variant",
+		),
+	}
+}
+
+// pick chooses the variant (template name) for the request. The client keeps the previously picked variant (using
+// the cookie), so the experience is consistent. The response is tagged with the header and the cookie.
+func (e *experiment) pick(ctx *fasthttp.RequestCtx) string {
+	var variant string
+
+	if fromCookie := string(ctx.Request.Header.Cookie(experimentCookie)); fromCookie != "" &&
+		(fromCookie == e.templates[0] || fromCookie == e.templates[1]) {
+		variant = fromCookie
+	} else if mathRand.IntN(100) < int(e.split) { //nolint:gosec,mnd
+		variant = e.templates[1]
+	} else {
+		variant = e.templates[0]
+	}
+
+	ctx.Response.Header.Set(experimentHeader, variant)
+
+	var cookie = fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(experimentCookie)
+	cookie.SetValue(variant)
+	cookie.SetPath("/")
+	cookie.SetMaxAge(int(experimentCookieTTL.Seconds()))
+	cookie.SetHTTPOnly(true)
+	cookie.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+
+	ctx.Response.Header.SetCookie(cookie)
+
+	e.renders.Inc(variant)
+
+	return variant
+}
+
+// results returns the number of renders for each variant.
+func (e *experiment) results() map[string]uint64 {
+	return map[string]uint64{
+		e.templates[0]: e.renders.Value(e.templates[0]),
+		e.templates[1]: e.renders.Value(e.templates[1]),
+	}
+}
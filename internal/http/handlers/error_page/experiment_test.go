@@ -0,0 +1,54 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+func TestExperiment_Pick(t *testing.T) {
+	t.Parallel()
+
+	t.Run("split", func(t *testing.T) {
+		t.Parallel()
+
+		for split, want := range map[uint8]string{0: "a", 100: "b"} {
+			var (
+				exp = newExperiment([2]string{"a", "b"}, split, nil)
+				ctx fasthttp.RequestCtx
+			)
+
+			for range 10 {
+				assert.Equal(t, want, exp.pick(&ctx))
+			}
+
+			assert.Equal(t, want, string(ctx.Response.Header.Peek(experimentHeader)))
+			assert.Contains(t, string(ctx.Response.Header.PeekCookie(experimentCookie)), experimentCookie+"="+want)
+			assert.Equal(t, uint64(10), exp.results()[want])
+		}
+	})
+
+	t.Run("sticky cookie", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			reg = metrics.NewRegistry()
+			exp = newExperiment([2]string{"a", "b"}, 100, reg)
+			ctx fasthttp.RequestCtx
+		)
+
+		ctx.Request.Header.SetCookie(experimentCookie, "a")
+
+		assert.Equal(t, "a", exp.pick(&ctx))
+
+		ctx.Request.Header.SetCookie(experimentCookie, "unknown") // not a variant - ignored
+
+		assert.Equal(t, "b", exp.pick(&ctx))
+
+		assert.Equal(t, map[string]uint64{"a": 1, "b": 1}, exp.results())
+		assert.Equal(t, uint64(1), reg.Counter("error_pages_experiment_renders_total", "").Value("b"))
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
 )
 
 type preferredFormat = byte
@@ -17,6 +19,7 @@ const (
 	xmlFormat                              // xml
 	htmlFormat                             // html
 	plainTextFormat                        // plain text
+	grpcWebFormat                          // gRPC-Web (the trailers-only response, not templated)
 )
 
 // detectPreferredFormatForClient detects the preferred format for the client based on the headers.
@@ -119,6 +122,8 @@ func detectPreferredFormatForClient(headers *fasthttp.RequestHeader) preferredFo
 // mimeTypeToPreferredFormat converts a MIME type to a preferred format, using non-string comparison.
 func mimeTypeToPreferredFormat(mimeType string) preferredFormat {
 	switch value := strings.ToLower(mimeType); {
+	case isGRPCWebMimeType(value): // application/grpc-web+proto application/grpc-web-text (before the `+json` check)
+		return grpcWebFormat
 	case strings.Contains(value, "/json"): // application/json text/json
 		return jsonFormat
 	case strings.Contains(value, "/xml"): // application/xml text/xml
@@ -133,3 +138,35 @@ func mimeTypeToPreferredFormat(mimeType string) preferredFormat {
 
 	return unknownFormat
 }
+
+// formatName returns the human-readable name of the format (the unknown format is served as a plain text).
+func formatName(f preferredFormat) string {
+	switch f {
+	case jsonFormat:
+		return "json"
+	case xmlFormat:
+		return "xml"
+	case htmlFormat:
+		return "html"
+	case grpcWebFormat:
+		return "grpc-web"
+	default:
+		return "text"
+	}
+}
+
+// configuredFormat converts the configured (default) format to the preferred format.
+func configuredFormat(f config.Format) preferredFormat {
+	switch f {
+	case config.FormatJSON:
+		return jsonFormat
+	case config.FormatXML:
+		return xmlFormat
+	case config.FormatHTML:
+		return htmlFormat
+	case config.FormatPlainText:
+		return plainTextFormat
+	}
+
+	return unknownFormat
+}
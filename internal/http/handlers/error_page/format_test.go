@@ -30,6 +30,14 @@ func Test_detectPreferredFormatForClient(t *testing.T) {
 			giveHeaders: map[string][]string{"Content-Type": {"text/plaIN"}},
 			wantFormat:  plainTextFormat,
 		},
+		"content type grpc-web": {
+			giveHeaders: map[string][]string{"Content-Type": {"application/grpc-web+json"}},
+			wantFormat:  grpcWebFormat,
+		},
+		"content type grpc-web-text": {
+			giveHeaders: map[string][]string{"Content-Type": {"application/grpc-web-text"}},
+			wantFormat:  grpcWebFormat,
+		},
 
 		"accept json": {
 			giveHeaders: map[string][]string{"Accept": {"application/jsoN,*/*;q=0.8"}},
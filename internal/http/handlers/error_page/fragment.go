@@ -0,0 +1,59 @@
+package error_page
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+// FragmentPathPrefix is the path prefix the HTML fragment (the error content without the page shell, for the SSI
+// and ESI includes) is requested with (`/fragment/{code}`).
+const FragmentPathPrefix = "/fragment/"
+
+// fragmentMaxAge is the time the fragment may be cached by the ESI processors (like Varnish or Fastly) and the
+// shared caches.
+const fragmentMaxAge = time.Minute
+
+// extractFragmentCode returns the code of the fragment requested by the path (like `/fragment/404`).
+func extractFragmentCode(path string, codes statuscode.Parser) (uint16, bool) {
+	if rest, ok := strings.CutPrefix(path, FragmentPathPrefix); ok {
+		return codes.FromPath("/" + rest)
+	}
+
+	return 0, false
+}
+
+// URLContainsFragment checks if the given path requests the HTML fragment with a valid code.
+func URLContainsFragment(path string, codes statuscode.Parser) (ok bool) {
+	_, ok = extractFragmentCode(path, codes)
+
+	return
+}
+
+// setFragmentHeaders sets the headers of the fragment response. The fragments with the request details (like the
+// request ID) are never cached, since they are unique for every request.
+func setFragmentHeaders(headers *fasthttp.ResponseHeader, details bool) {
+	if details {
+		headers.Set(fasthttp.HeaderCacheControl, "private, no-store")
+		headers.Set("Surrogate-Control", "no-store")
+
+		return
+	}
+
+	var maxAge = fmt.Sprintf("max-age=%d", int(fragmentMaxAge.Seconds()))
+
+	headers.Set(fasthttp.HeaderCacheControl, "public, "+maxAge)
+	headers.Set("Surrogate-Control", maxAge) // the Edge Architecture Specification (honored by the ESI processors)
+}
+
+// minimalFragment returns the minimal HTML fragment (used when the fragment can't be rendered).
+func minimalFragment(code uint16, message string) string {
+	return fmt.Sprintf("<div class=\"error-page\" data-code=\"%[1]d\"><h1>%[1]d: %[2]s</h1></div>\n",
+		code, html.EscapeString(message),
+	)
+}
@@ -0,0 +1,127 @@
+package error_page
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// gRPC-Web content types (https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md). The `-text` variant is the
+// base64-encoded body, used by the browser clients that can't read the binary responses.
+const (
+	grpcWebContentType      = "application/grpc-web"
+	grpcWebProtoContentType = "application/grpc-web+proto"
+	grpcWebTextContentType  = "application/grpc-web-text"
+)
+
+// gRPC status codes (https://grpc.github.io/grpc/core/md_doc_statuscodes.html), used by the error pages.
+const (
+	grpcStatusUnknown          uint32 = 2
+	grpcStatusPermissionDenied uint32 = 7
+	grpcStatusUnimplemented    uint32 = 12
+	grpcStatusInternal         uint32 = 13
+	grpcStatusUnavailable      uint32 = 14
+	grpcStatusUnauthenticated  uint32 = 16
+)
+
+// isGRPCWebMimeType reports whether the MIME type (lowercased, without the parameters) is one of the gRPC-Web
+// content types (`application/grpc-web`, `application/grpc-web+json`, `application/grpc-web-text`, etc.).
+func isGRPCWebMimeType(mimeType string) bool {
+	return mimeType == grpcWebContentType || strings.HasPrefix(mimeType, grpcWebContentType+"+") ||
+		mimeType == grpcWebTextContentType || strings.HasPrefix(mimeType, grpcWebTextContentType+"+")
+}
+
+// grpcWebResponseContentType returns the content type of the gRPC-Web response - the one used by the client (from
+// the `Content-Type`, `X-Format`, or `Accept` request headers), or the `application/grpc-web+proto` by default.
+func grpcWebResponseContentType(headers *fasthttp.RequestHeader) string {
+	for _, name := range [...]string{fasthttp.HeaderContentType, "X-Format", fasthttp.HeaderAccept} {
+		for segment := range strings.SplitSeq(string(headers.Peek(name)), ",") {
+			var mimeType, _, _ = strings.Cut(segment, ";")
+
+			if mimeType = strings.ToLower(strings.TrimSpace(mimeType)); isGRPCWebMimeType(mimeType) {
+				if mimeType == grpcWebContentType {
+					return grpcWebProtoContentType // the same as the `+proto`
+				}
+
+				return mimeType
+			}
+		}
+	}
+
+	return grpcWebProtoContentType
+}
+
+// grpcStatusFromHTTP maps the HTTP status code to the gRPC status code, the same way the gRPC clients do
+// (https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md).
+func grpcStatusFromHTTP(code uint16) uint32 {
+	switch code {
+	case http.StatusBadRequest:
+		return grpcStatusInternal
+	case http.StatusUnauthorized:
+		return grpcStatusUnauthenticated
+	case http.StatusForbidden:
+		return grpcStatusPermissionDenied
+	case http.StatusNotFound:
+		return grpcStatusUnimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return grpcStatusUnavailable
+	}
+
+	return grpcStatusUnknown
+}
+
+// grpcWebResponse prepares the trailers-only gRPC-Web response with the status mapped from the HTTP code: the
+// `grpc-status` and `grpc-message` are set as the response headers, and the same trailers frame is returned as the
+// body (the clients read the status from any of them). The body is base64-encoded for the `-text` response content
+// type (so it must be set before).
+func grpcWebResponse(ctx *fasthttp.RequestCtx, code uint16, message string) []byte {
+	var (
+		status  = strconv.FormatUint(uint64(grpcStatusFromHTTP(code)), 10)
+		encoded = encodeGRPCMessage(message)
+	)
+
+	ctx.Response.Header.Set("grpc-status", status)
+	ctx.Response.Header.Set("grpc-message", encoded)
+
+	var trailers = "grpc-status:" + status + "\r\ngrpc-message:" + encoded + "\r\n"
+
+	// the frame is the flags byte (the MSB is set for the trailers), the big-endian length, and the trailers
+	var frame = make([]byte, 5, 5+len(trailers)) //nolint:mnd
+
+	frame[0] = 0x80
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(trailers))) //nolint:gosec // the trailers are short
+	frame = append(frame, trailers...)
+
+	if bytes.HasPrefix(ctx.Response.Header.ContentType(), []byte(grpcWebTextContentType)) {
+		return []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+
+	return frame
+}
+
+// encodeGRPCMessage percent-encodes the `grpc-message` value: the bytes outside the printable ASCII range and the
+// `%` itself are encoded (https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md#responses).
+func encodeGRPCMessage(message string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+
+	b.Grow(len(message))
+
+	for i := range len(message) {
+		if c := message[i]; c < ' ' || c > '~' || c == '%' {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		} else {
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
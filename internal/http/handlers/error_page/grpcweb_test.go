@@ -0,0 +1,68 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func Test_grpcWebResponseContentType(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveHeaders map[string]string
+		want        string
+	}{
+		"none": {want: "application/grpc-web+proto"},
+		"bare": {
+			giveHeaders: map[string]string{"Content-Type": "application/grpc-web"},
+			want:        "application/grpc-web+proto",
+		},
+		"json": {
+			giveHeaders: map[string]string{"Content-Type": "application/GRPC-Web+JSON; charset=utf-8"},
+			want:        "application/grpc-web+json",
+		},
+		"text": {
+			giveHeaders: map[string]string{"X-Format": "application/grpc-web-text"},
+			want:        "application/grpc-web-text",
+		},
+		"accept": {
+			giveHeaders: map[string]string{"Accept": "text/html, application/grpc-web-text+proto;q=0.9"},
+			want:        "application/grpc-web-text+proto",
+		},
+		"not grpc-web": {
+			giveHeaders: map[string]string{"Content-Type": "application/grpc-webx"},
+			want:        "application/grpc-web+proto",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var headers fasthttp.RequestHeader
+
+			for k, v := range tt.giveHeaders {
+				headers.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, grpcWebResponseContentType(&headers))
+		})
+	}
+}
+
+func Test_grpcStatusFromHTTP(t *testing.T) {
+	t.Parallel()
+
+	for code, want := range map[uint16]uint32{
+		400: 13, 401: 16, 403: 7, 404: 12, 429: 14, 502: 14, 503: 14, 504: 14, 500: 2, 418: 2,
+	} {
+		assert.Equal(t, want, grpcStatusFromHTTP(code), code)
+	}
+}
+
+func Test_encodeGRPCMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Service Unavailable", encodeGRPCMessage("Service Unavailable"))
+	assert.Equal(t, "100%25 %D0%BE%D1%88%D0%B8%D0%B1%D0%BA%D0%B0%0A", encodeGRPCMessage("100% ошибка\n"))
+}
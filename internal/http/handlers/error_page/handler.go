@@ -1,38 +1,71 @@
 package error_page
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	mathRand "math/rand/v2"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/valyala/fasthttp"
 
+	"github.com/binaryYuki/error-pages/internal/charset"
+	"github.com/binaryYuki/error-pages/internal/clock"
 	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/datacenter"
+	"github.com/binaryYuki/error-pages/internal/featureflag"
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/lifecycle"
 	"github.com/binaryYuki/error-pages/internal/logger"
 	"github.com/binaryYuki/error-pages/internal/template"
+	"github.com/binaryYuki/error-pages/l10n"
 )
 
 // New creates a new handler that returns an error page with the specified status code and format.
-func New(cfg *config.Config, log *logger.Logger) (_ fasthttp.RequestHandler, closeCache func()) { //nolint:funlen,gocognit,gocyclo,lll
+func New(cfg *config.Config, log *logger.Logger, opts ...Option) (_ fasthttp.RequestHandler, closeCache func()) { //nolint:funlen,gocognit,gocyclo,lll
+	var opt options
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	if opt.clock == nil {
+		opt.clock = clock.System
+	}
+
+	if opt.lifecycle == nil {
+		opt.lifecycle = lifecycle.New(log)
+	}
+
 	// if the ttl will be bigger than 1 second, the template functions like `nowUnix` will not work as expected
 	const cacheTtl = 900 * time.Millisecond // the cache TTL
 
+	var cache = NewRenderedCache(cacheTtl, cfg.CacheTenantQuota).WithClock(opt.clock)
+
+	cache.requests = opt.metrics.Counter(
+		"error_pages_cache_requests_total", "Rendered pages cache lookups by tenant and result (hit/miss)",
+		"tenant", "result",
+	)
+
 	var (
-		cache, stopCh = NewRenderedCache(cacheTtl), make(chan struct{})
-		stopOnce      sync.Once
+		shrinkAt = uint64(cfg.Memory.CacheShrinkAt) << 20 // MiB to bytes
+		shrinks  = opt.metrics.Counter(
+			"error_pages_cache_shrinks_total", "Rendered pages cache purges because the heap size exceeds the threshold",
+		)
 	)
 
-	// run a goroutine that will clear the cache from expired items. to stop the goroutine - close the stop channel
-	// or call the closeCache
-	go func() {
+	// run a service that will clear the cache from expired items (and purge it when the heap is too large, so the
+	// memory is freed before the container is killed). to stop the service - call the closeCache or stop the lifecycle
+	// manager
+	var stopJanitor = opt.lifecycle.Go("rendered pages cache janitor", func(ctx context.Context) error {
 		var timer = time.NewTimer(cacheTtl)
 
 		defer func() { timer.Stop(); cache.Clear() }()
@@ -41,36 +74,406 @@ func New(cfg *config.Config, log *logger.Logger) (_ fasthttp.RequestHandler, clo
 			select {
 			case <-timer.C:
 				cache.ClearExpired()
+
+				if shrinkAt > 0 {
+					if heap := heapSize(); heap > shrinkAt {
+						shrinks.Inc()
+
+						if removed := cache.Clear(); removed > 0 && log != nil {
+							log.Warn("Rendered pages cache purged, the heap size exceeds the threshold",
+								logger.Uint64("heap size", heap),
+								logger.Int("removed", removed),
+							)
+						}
+					}
+				}
+
 				timer.Reset(cacheTtl)
-			case <-stopCh:
-				return
+			case <-ctx.Done():
+				return nil
 			}
 		}
-	}()
+	})
+
+	var renderLimits = template.Limits{
+		Timeout:       cfg.TemplateLimits.RenderTimeout,
+		MaxDepth:      cfg.TemplateLimits.MaxDepth,
+		MaxIncludes:   cfg.TemplateLimits.MaxIncludes,
+		MaxIterations: cfg.TemplateLimits.MaxIterations,
+	}
+
+	var dcCode = cfg.Datacenter.Code // resolved on startup
+
+	if dcCode == "" {
+		dcCode = datacenter.Default
+	}
+
+	// the values are escaped depending on the response format (and the context within the HTML)
+	var jsonEscaping, xmlEscaping, htmlEscaping = template.EscapeJSON, template.EscapeXML, template.EscapeHTML
+
+	if cfg.DisableAutoEscape {
+		jsonEscaping, xmlEscaping, htmlEscaping = template.EscapeNone, template.EscapeNone, template.EscapeNone
+	}
+
+	var (
+		misdirected = http.StatusText(http.StatusMisdirectedRequest) + "\n"
+		clientIP    = clientip.New(cfg.ClientIP.TrustedProxies, cfg.ClientIP.MaxHops)
+		limiter     = newRenderLimiter(cfg.MaxConcurrentRenders, renderLimits, opt.clock).withLatency(opt.metrics)
+		requestIDs  = newRequestIDGenerator(cfg.RequestIDFormat, cfg.RequestID, dcCode)
+		codes       = statuscode.Parser{Strict: cfg.RequestHeaders.StrictCodes}
+		shaper      = textShaper{cfg.PlainTextOutput.MaxLineWidth, cfg.PlainTextOutput.Normalization}
+		unknown     = newUnknownCodes(log, cfg.UnknownCodeLogInterval, opt.metrics)
+		exp         *experiment
+	)
+
+	if cfg.RotationMode == config.RotationModeExperiment {
+		exp = newExperiment(cfg.Experiment.Templates, cfg.Experiment.Split, opt.metrics)
+	}
+
+	var minifier *template.Minifier // nil if the minification is disabled
+
+	if !cfg.DisableMinification {
+		minifier = template.NewMinifier(template.MinifyOptions{
+			KeepComments:            cfg.Minification.KeepComments,
+			KeepConditionalComments: cfg.Minification.KeepConditionalComments,
+			KeepInlineCSS:           cfg.Minification.KeepInlineCSS,
+			KeepInlineJS:            cfg.Minification.KeepInlineJS,
+			KeepESI:                 cfg.ESI,
+		})
+	}
+
+	var detailsAllowed = opt.details // nil if the access to the request details is not restricted
+
+	if detailsAllowed == nil {
+		detailsAllowed = newDetailsPredicate(cfg.DetailsAccess, clientIP, log)
+	}
+
+	// the pages are rendered (and cached) with the placeholder instead of the CSP nonce, and the placeholder is
+	// replaced with the fresh nonce in every response, so the nonce is never reused and the cache still works
+	var cspNoncePlaceholder []byte
+
+	if strings.Contains(cfg.ContentSecurityPolicy, config.CSPNoncePlaceholder) {
+		cspNoncePlaceholder = []byte(newCSPNonce())
+	}
+
+	var rejected = opt.metrics.Counter(
+		"error_pages_rejected_requests_total", "Requests rejected because of the selector headers by reason",
+		"reason",
+	)
+
+	// reject responds with the plain status text (the error page is not rendered)
+	var reject = func(ctx *fasthttp.RequestCtx, reason string, status int) {
+		rejected.Inc(reason)
+
+		log.Debug("Request rejected",
+			logger.String("reason", reason),
+			logger.String("path", string(ctx.Path())),
+			logger.String("remote addr", ctx.RemoteAddr().String()),
+		)
+
+		ctx.Error(http.StatusText(status)+"\n", status)
+	}
+
+	var wsRejected = opt.metrics.Counter(
+		"error_pages_websocket_rejected_total", "WebSocket opening handshakes rejected with the error code by code",
+		"code",
+	)
+
+	var served = opt.metrics.Counter(
+		"error_pages_responses_total", "Error pages served by code and format", "code", "format",
+	)
+
+	var upstreamDurations = opt.metrics.Counter(
+		"error_pages_upstream_duration_total", "Error pages with the upstream duration by code and duration bucket",
+		"code", "duration",
+	)
+
+	var rot = newRotator(cfg, log, opt.metrics, opt.clock)
+
+	if opt.rotation != nil {
+		rot.inherit(opt.rotation.r.Load()) // the template of the previous configuration is kept on reload
+	}
+
+	// the pages of the previous template are dropped from the cache as soon as the template is switched
+	rot.onSwitch = func(prev string) {
+		if tpl, ok := cfg.Templates.Get(prev); ok {
+			if removed := cache.Invalidate(tpl); removed > 0 && log != nil {
+				log.Debug("Cached pages of the previous template invalidated",
+					logger.String("template", prev),
+					logger.Int("removed", removed),
+				)
+			}
+		}
+	}
+
+	var (
+		configuredBanner = Banner{Message: cfg.Banner.Message, Severity: cfg.Banner.Severity.String()}
+		banner           = new(atomic.Pointer[Banner]) // may be changed at runtime using the banner control
+	)
+
+	banner.Store(&configuredBanner)
+
+	if opt.banner != nil {
+		banner = opt.banner.bind(configuredBanner)
+	}
+
+	if opt.rotation != nil {
+		opt.rotation.r.Store(rot)
+	}
+
+	var recovery string // the auto-recovery script, injected into the HTML 503 pages
+
+	if opt.probe != nil && cfg.UpstreamHealth.RecoveryURL != "" {
+		recovery = recoveryScript(cfg.UpstreamHealth.RecoveryURL, cfg.UpstreamHealth.Interval)
+	}
+
+	var liveStatus string // the live status polling script, injected into all the HTML pages
+
+	if cfg.LiveStatus.URL != "" {
+		liveStatus = liveStatusScript(cfg.LiveStatus.URL, cfg.LiveStatus.Interval)
+	}
+
+	var mark = newWatermark(cfg.Watermark.Mode, cfg.Watermark.InstanceID) // nil if the watermarking is disabled
+
+	sign, signErr := newSigner(cfg.Signing.Algorithm, cfg.Signing.Key) // nil if the signing is disabled
+	if signErr != nil {
+		log.Error("Response signing disabled", logger.Error(signErr))
+	} else if sign != nil {
+		log.Info("Response signing enabled",
+			logger.String("algorithm", cfg.Signing.Algorithm.String()),
+			logger.String("public key", sign.publicKey()),
+		)
+	}
+
+	var stopReporter = func() {} // noop
+
+	if exp != nil && log != nil { // report the experiment results on shutdown
+		stopReporter = opt.lifecycle.Go("experiment results reporter", func(ctx context.Context) error {
+			<-ctx.Done()
+
+			var attrs = make([]logger.Attr, 0, 2) //nolint:mnd
+
+			for variant, renders := range exp.results() {
+				attrs = append(attrs, logger.Uint64(variant, renders))
+			}
+
+			log.Info("Experiment results (renders per template)", attrs...)
+
+			return nil
+		})
+	}
+
+	var stop = func() { stopReporter(); stopJanitor() }
+
+	respCharset, charsetErr := charset.Lookup(cfg.Charset) // UTF-8 if not set
+	if charsetErr != nil {
+		log.Error("Response charset ignored, UTF-8 is used", logger.Error(charsetErr))
+	}
+
+	// streamed reports whether the HTML template is large enough to be streamed. The response body is required as
+	// a whole for the signing, shadow mode, scripts and watermark injection, and transcoding, so the streaming is
+	// disabled for them
+	var streamed = func(tpl string, code uint16) bool {
+		return cfg.StreamThreshold > 0 && uint(len(tpl)) > cfg.StreamThreshold &&
+			sign == nil && !cfg.Shadow && (recovery == "" || code != http.StatusServiceUnavailable) &&
+			respCharset.IsUTF8() && mark == nil && liveStatus == ""
+	}
 
 	return func(ctx *fasthttp.RequestCtx) {
 		var (
-			reqHeaders = &ctx.Request.Header
-			code       uint16
+			reqHeaders   = &ctx.Request.Header
+			code         uint16
+			routeTplName string // the template name from the matched route (if any)
+			codeSource   string // where the code comes from (reported for the unknown codes)
 		)
 
-		if fromUrl, okUrl := extractCodeFromURL(string(ctx.Path())); okUrl {
-			code = fromUrl
-		} else if fromHeader, okHeaders := extractCodeFromHeaders(reqHeaders); okHeaders {
-			code = fromHeader
+		// requests with unexpected hosts never reach the rendering, so the `Host` header value can't be reflected
+		// into the (potentially cached by the upstream) error page
+		// the matched allowed host is the tenant (the cache namespace); without the list, there is a single tenant
+		var tenant string
+
+		if len(cfg.AllowedHosts) > 0 {
+			var allowed bool
+
+			if tenant, allowed = matchHost(string(reqHeaders.Host()), cfg.AllowedHosts); !allowed {
+				ctx.Error(misdirected, http.StatusMisdirectedRequest)
+
+				return
+			}
+		}
+
+		if reason, status := checkSelectorHeaders(
+			reqHeaders, cfg.RequestHeaders.MaxValueSize, cfg.RequestHeaders.RejectDuplicates,
+		); status != 0 {
+			reject(ctx, reason, status)
+
+			return
+		}
+
+		var tenantCache = cache.Tenant(tenant)
+
+		var route, routed = cfg.Routes.Match(string(ctx.Path()))
+
+		if routed {
+			routeTplName = route.Template
+		}
+
+		if value, invalid := invalidCodeHeader(reqHeaders, codes); invalid {
+			unknown.report(ctx, codeSourceInvalidHeader, value, clientIP.String(ctx))
+		}
+
+		var (
+			fromURL, okURL       = codes.FromPath(string(ctx.Path()))
+			fromHeader, okHeader = codes.FromHeader(reqHeaders.Peek(statuscode.Header))
+		)
+
+		// the HTML fragment (for the SSI and ESI includes) is requested with the code in the path too
+		var fromFragment, isFragment = extractFragmentCode(string(ctx.Path()), codes)
+
+		if isFragment {
+			fromURL, okURL = fromFragment, true
+		}
+
+		// the URL and header codes differ (e.g. `/404` with `X-Code: 503`), and the route does not set the code
+		if okURL && okHeader && fromURL != fromHeader && (!routed || route.Code == 0) {
+			switch cfg.RequestHeaders.CodePrecedence { //nolint:exhaustive // the URL code wins by default
+			case config.CodePrecedenceHeader:
+				okURL = false
+			case config.CodePrecedenceReject:
+				reject(ctx, rejectCodeConflict, http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		if routed && route.Code != 0 {
+			code, codeSource = route.Code, codeSourceRoute
+		} else if okURL {
+			code, codeSource = fromURL, codeSourceURL
+		} else if okHeader {
+			code, codeSource = fromHeader, codeSourceHeader
+		} else if cfg.CatchAll.Enabled {
+			code, codeSource = http.StatusNotFound, codeSourceCatchAll // in the catch-all mode, any unmatched path is "not found"
+
+			if rate := cfg.CatchAll.LogSampleRate; rate > 0 && mathRand.Float64() < rate { //nolint:gosec
+				log.Info("Unmatched request path",
+					logger.String("path", string(ctx.Path())),
+					logger.String("host", string(reqHeaders.Host())),
+				)
+			}
 		} else {
-			code = cfg.DefaultCodeToRender
+			code, codeSource = cfg.DefaultCodeToRender, codeSourceDefault
+		}
+
+		// the response profile (a canned response, selected by the backend) overrides the detected code and template
+		var profile, profileName, hasProfile = extractProfile(string(ctx.Path()), reqHeaders, cfg.Profiles)
+
+		if hasProfile {
+			if profile.Code != 0 {
+				code, codeSource = profile.Code, codeSourceProfile
+			}
+
+			if profile.Template != "" {
+				routeTplName = profile.Template
+			}
+		}
+
+		// the error kind (a business error, set by the backend) overrides the detected code and template
+		var kind, kindName, hasKind = extractErrorKind(reqHeaders, cfg.ErrorKinds)
+
+		if hasKind {
+			code, codeSource = kind.Code, codeSourceErrorKind
+
+			if kind.Template != "" {
+				routeTplName = kind.Template
+			}
+		}
+
+		// the TLS error (reported by the terminating proxy) overrides the detected code and template too, but the
+		// error kind (set by the backend explicitly) wins
+		var tlsErr, tlsErrName, hasTLSErr = extractTLSError(reqHeaders, cfg.TLSErrors.Header, cfg.TLSErrors.Kinds)
+
+		if hasTLSErr = hasTLSErr && !hasKind; hasTLSErr {
+			code, codeSource = tlsErr.Code, codeSourceTLSError
+
+			if tlsErr.Template != "" {
+				routeTplName = tlsErr.Template
+			}
+		}
+
+		// the previews may be requested for another time (the rotation and caching are not affected)
+		var now, previewAt = opt.clock.Now(), false
+
+		if at, ok := renderTime(ctx); ok {
+			now, previewAt = at, true
+		}
+
+		// during the scheduled maintenance window, the matched requests receive the maintenance page
+		var maintenance, inMaintenance = cfg.Maintenance.Active(now, string(ctx.Path()), code)
+
+		if inMaintenance {
+			code, codeSource = maintenance.Code, codeSourceMaintenance
+
+			if maintenance.Template != "" {
+				routeTplName = maintenance.Template
+			}
+		}
+
+		// while the service is warming up (or reloading), the load balancers should see it is not ready yet, so any
+		// request is answered with the 503 (the status code is sent even if the same HTTP code is not responded)
+		var notReady = cfg.UnavailableUntilReady && !opt.ready.Ready()
+
+		if notReady {
+			code, codeSource = http.StatusServiceUnavailable, codeSourceNotReady
 		}
 
 		var httpCode int
 
-		if cfg.RespondWithSameHTTPCode {
+		if cfg.RespondWithSameHTTPCode || notReady {
 			httpCode = int(code)
 		} else {
 			httpCode = http.StatusOK
 		}
 
-		var format = detectPreferredFormatForClient(reqHeaders)
+		// the WebSocket clients can't show the page, and the failed handshake must be answered with the real (non-101)
+		// status (RFC 6455, section 4.1), so the minimal plain text body is sent instead of the themed page
+		if isWebSocketUpgrade(reqHeaders) {
+			var message = http.StatusText(int(code))
+
+			if desc, found := cfg.Codes.Find(code); found {
+				message = desc.Message
+			}
+
+			wsRejected.Inc(strconv.FormatUint(uint64(code), 10))
+
+			ctx.Response.Header.Set("X-Robots-Tag", "noindex")
+			ctx.Error(minimalContent(plainTextFormat, code, message), int(code))
+
+			return
+		}
+
+		var format preferredFormat
+
+		// the format of the profile and the one forced by the User-Agent rules win over the one requested by the client
+		if hasProfile && profile.Format != nil {
+			format = configuredFormat(*profile.Format)
+		} else if forced, ok := cfg.FormatRules.Match(string(reqHeaders.UserAgent())); ok {
+			format = configuredFormat(forced)
+		} else if format = detectPreferredFormatForClient(reqHeaders); format == unknownFormat {
+			format = configuredFormat(cfg.DefaultFormat) // the client does not specify a supported format
+		}
+
+		// the gRPC status is sent in the trailers, and the gRPC-Web clients expect the 200 OK
+		if format == grpcWebFormat {
+			httpCode = http.StatusOK
+		}
+
+		// the fragment is always HTML, and the ESI processors do not include the non-200 responses
+		if isFragment {
+			format, httpCode = htmlFormat, http.StatusOK
+		}
+
+		var showDetails = cfg.ShowDetails && (detailsAllowed == nil || detailsAllowed(ctx))
 
 		{ // deal with the headers
 			switch format {
@@ -79,112 +482,528 @@ func New(cfg *config.Config, log *logger.Logger) (_ fasthttp.RequestHandler, clo
 			case xmlFormat:
 				ctx.SetContentType("application/xml; charset=utf-8")
 			case htmlFormat:
-				ctx.SetContentType("text/html; charset=utf-8")
+				ctx.SetContentType("text/html; charset=" + respCharset.String())
+			case grpcWebFormat:
+				ctx.SetContentType(grpcWebResponseContentType(reqHeaders))
 			default:
-				ctx.SetContentType("text/plain; charset=utf-8") // plainTextFormat as default
+				ctx.SetContentType("text/plain; charset=" + respCharset.String()) // plainTextFormat as default
 			}
 
 			// https://developers.google.com/search/docs/crawling-indexing/robots-meta-tag
 			// disallow indexing of the error pages
 			ctx.Response.Header.Set("X-Robots-Tag", "noindex")
 
-			switch code {
-			case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
-				http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable,
-				http.StatusGatewayTimeout:
-				// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Retry-After
-				// tell the client (search crawler) to retry the request after 120 seconds
-				ctx.Response.Header.Set("Retry-After", "120")
+			if isFragment {
+				setFragmentHeaders(&ctx.Response.Header, showDetails)
+			} else if showDetails && detailsAllowed != nil {
+				// the page with the details is for the granted client only (the shared caches must not reuse it)
+				ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "private, no-store")
+			}
+
+			// the ESI processors (like Akamai or Fastly, or Varnish with the matching VCL) parse the ESI tags only
+			// in the responses marked with the header
+			if cfg.ESI && format == htmlFormat {
+				var surrogate = `content="ESI/1.0"`
+
+				if current := ctx.Response.Header.Peek("Surrogate-Control"); len(current) > 0 {
+					surrogate = string(current) + ", " + surrogate
+				}
+
+				ctx.Response.Header.Set("Surrogate-Control", surrogate)
+			}
+
+			// the not ready service should be retried shortly; during the maintenance, the client should retry when
+			// the window ends; in the catch-all mode, the clients are never asked to retry - the missing path will
+			// not appear
+			if notReady {
+				ctx.Response.Header.Set("Retry-After", "5")
+			} else if inMaintenance {
+				var retryAfter = int(math.Ceil(maintenance.End.Sub(now).Seconds()))
+
+				ctx.Response.Header.Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			} else if !cfg.CatchAll.Enabled {
+				switch code {
+				case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+					http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable,
+					http.StatusGatewayTimeout:
+					// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Retry-After
+					// tell the client (search crawler) to retry the request after 120 seconds
+					ctx.Response.Header.Set("Retry-After", "120")
+				}
+			}
+
+			// ask the client to authenticate (the same way the auth gateway would do)
+			if code == http.StatusUnauthorized {
+				for _, challenge := range cfg.AuthChallenges {
+					ctx.Response.Header.Add("WWW-Authenticate", challenge)
+				}
+			}
+
+			// the 405 response must contain the list of the allowed methods (RFC 9110, section 15.5.6)
+			if code == http.StatusMethodNotAllowed {
+				if upstream := reqHeaders.Peek("Allow"); len(upstream) > 0 {
+					ctx.Response.Header.SetBytesV("Allow", upstream)
+				} else if allow, ok := cfg.AllowRules.Match(originalPath(ctx, cfg.Proxy.OriginalURIHeader())); ok {
+					ctx.Response.Header.Set("Allow", allow)
+				}
 			}
 
 			// proxy the headers from the incoming request to the error page response if they are defined in the config
 			for _, proxyHeader := range cfg.ProxyHeaders {
-				if value := reqHeaders.Peek(proxyHeader); len(value) > 0 {
+				var value = reqHeaders.Peek(proxyHeader)
+
+				if requestIDs.isUpstreamHeader(proxyHeader) { // the request ID is echoed as-is, so it must be safe
+					value, _ = sanitizeRequestID(value)
+				}
+
+				if len(value) > 0 {
 					ctx.Response.Header.SetBytesV(proxyHeader, value)
 				}
 			}
+
+			// the extra headers of the profile are set last, so they win over the others
+			if hasProfile {
+				for name, value := range profile.Headers {
+					ctx.Response.Header.Set(name, value)
+				}
+			}
 		}
 
 		ctx.SetStatusCode(httpCode)
 
 		// prepare the template properties for rendering
 		var tplProps = template.Props{
-			Code:               code,             // http status code
-			ShowRequestDetails: cfg.ShowDetails,  // status message
-			L10nDisabled:       cfg.L10n.Disable, // status description
+			Code:               code,               // http status code
+			ShowRequestDetails: showDetails,        // status message
+			L10nDisabled:       cfg.L10n.Disable,   // status description
+			TextDirection:      l10n.Direction(""), // the default text direction
+			LangCode:           l10n.DefaultLocale, // the language of the page
+			Charset:            "utf-8",            // the charset of the response
+			Timezone:           cfg.Timezone,
+			Datacenter:         dcCode,
+			OriginalStatus:     extractOriginalStatus(reqHeaders), // what the upstream actually returned
+			ShowOriginalStatus: cfg.ShowOriginalStatus,
+			PrintFriendly:      cfg.Accessibility.PrintFriendly,
+			ESI:                cfg.ESI,
+			LiveStatus:         liveStatus != "",
+			ErrorDetails:       extractErrorDetails(reqHeaders), // set by the upstream (e.g. validation errors)
+		}
+
+		if inMaintenance {
+			tplProps.MaintenanceStart = maintenance.Start.UTC().Format(time.RFC3339)
+			tplProps.MaintenanceEnd = maintenance.End.UTC().Format(time.RFC3339)
+		}
+
+		// the time the upstream took to fail (like the timeout) helps the first-line triage
+		if d := extractUpstreamDuration(reqHeaders); d > 0 {
+			tplProps.UpstreamDuration = strconv.FormatFloat(d.Seconds(), 'f', 1, 64)
+
+			upstreamDurations.Inc(strconv.FormatUint(uint64(code), 10), upstreamDurationBucket(d))
 		}
 
-		if cfg.ShowDetails {
+		if opt.probe != nil {
+			if status, checked := opt.probe.Status(); checked {
+				tplProps.UpstreamHealthy = status.Healthy
+				tplProps.UpstreamCheckedAt = status.CheckedAt.UTC().Format(time.RFC3339)
+			}
+		}
+
+		if b := banner.Load(); b.Message != "" {
+			tplProps.Banner, tplProps.BannerSeverity = b.Message, b.Severity
+		}
+
+		if cfg.OGImageBaseURL != "" { // served by the `/og/{code}.png` endpoint
+			tplProps.OGImage = cfg.OGImageBaseURL + "/og/" + strconv.FormatUint(uint64(code), 10) + ".png"
+		}
+
+		if cfg.BodyPreviewSize > 0 {
+			tplProps.BodyPreview = bodyPreview(ctx.PostBody(), cfg.BodyPreviewSize)
+		}
+
+		if code == http.StatusUnauthorized && len(cfg.AuthChallenges) > 0 {
+			tplProps.WWWAuthenticate = strings.Join(cfg.AuthChallenges, ", ")
+		}
+
+		if showDetails {
 			tplProps.Host = string(reqHeaders.Peek("Host")) // the value of the `Host` header
-			tplProps.RequestID = generateRequestID(reqHeaders)
+			tplProps.RequestID = requestIDs.generate(reqHeaders)
+			tplProps.ClientIP = clientIP.String(ctx)
+			tplProps.AcceptLanguage = string(reqHeaders.Peek(fasthttp.HeaderAcceptLanguage))
+			tplProps.SecCHUA = string(reqHeaders.Peek("Sec-CH-UA"))
+			tplProps.SecCHUAPlatform = string(reqHeaders.Peek("Sec-CH-UA-Platform"))
+			tplProps.OriginalURI = string(reqHeaders.Peek(cfg.Proxy.OriginalURIHeader()))
+			tplProps.ForwardedFor = string(reqHeaders.Peek(cfg.Proxy.ForwardedForHeader()))
 		}
 
 		// try to find the code message and description in the config and if not - use the standard status text or fallback
-		if desc, found := cfg.Codes.Find(code); found {
-			tplProps.Message = desc.Message
-			tplProps.Description = desc.Description
+		var codeDesc, codeFound = cfg.Codes.Find(code)
+
+		if codeFound {
+			tplProps.Message = codeDesc.Message
+			tplProps.Description = codeDesc.Description
 		} else if stdlibStatusText := http.StatusText(int(code)); stdlibStatusText != "" {
 			tplProps.Message = stdlibStatusText
 		} else {
 			tplProps.Message = "Unknown Status Code" // fallback
+
+			if !hasTLSErr || tlsErr.Message == "" { // the TLS errors use the non-standard codes (like 495) on purpose
+				unknown.report(ctx, codeSource, strconv.FormatUint(uint64(code), 10), clientIP.String(ctx))
+			}
+		}
+
+		// localize the message and description on the server side (if the client locale is known)
+		if !cfg.L10n.Disable {
+			// the page content depends on the client's preferred language, so the shared caches must keep the
+			// responses apart (the `lang` query parameter is a part of the URL anyway)
+			ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAcceptLanguage)
+
+			if tplProps.Locale = detectLocale(ctx); tplProps.Locale != "" {
+				tplProps.TextDirection = l10n.Direction(tplProps.Locale)
+				tplProps.LangCode = tplProps.Locale
+
+				ctx.Response.Header.Set(fasthttp.HeaderContentLanguage, tplProps.Locale)
+
+				// the localized overrides from the config take precedence over the built-in translations
+				var override, _ = codeDesc.Localize(tplProps.Locale)
+
+				if override.Message != "" {
+					tplProps.Message = override.Message
+				} else if translated, ok := l10n.Translate(tplProps.Locale, tplProps.Message); ok {
+					tplProps.Message = translated
+				} else if translated, ok = l10n.StatusText(tplProps.Locale, int(code)); ok && !codeFound {
+					tplProps.Message = translated // the standard status text of the code missing in the config
+				}
+
+				if override.Description != "" {
+					tplProps.Description = override.Description
+				} else if translated, ok := l10n.Translate(tplProps.Locale, tplProps.Description); ok {
+					tplProps.Description = translated
+				}
+			}
 		}
 
+		if transcoded(format) {
+			tplProps.Charset = respCharset.String()
+		}
+
+		if format == htmlFormat { // the animations are the concern of the HTML pages only
+			tplProps.ReducedMotion = reducedMotion(ctx, cfg.Accessibility.ReducedMotion)
+		}
+
+		// the allowlisted request headers are available to the template functions, so the page depends on them
+		if len(cfg.TemplateHeaders) > 0 {
+			tplProps.Request = template.NewRequest(cfg.TemplateHeaders, func(name string) string {
+				return string(reqHeaders.Peek(name))
+			})
+
+			for _, name := range cfg.TemplateHeaders {
+				ctx.Response.Header.Add(fasthttp.HeaderVary, name)
+			}
+		}
+
+		// the feature flags are resolved for the client (the gradual rollouts), so the page depends on them
+		if flags := opt.flags.Values(); len(flags) > 0 {
+			var (
+				subject  = clientIP.String(ctx)
+				resolved = make(map[string]string, len(flags))
+			)
+
+			for name, value := range flags {
+				resolved[name] = featureflag.Resolve(name, value, subject)
+			}
+
+			tplProps.Flags = template.NewFlags(resolved)
+		}
+
+		// the profile token overrides are not translated (as a freeform text)
+		if hasProfile {
+			tplProps.Profile = profileName
+
+			if err := tplProps.Override(profile.Tokens); err != nil { // never happens, the tokens are validated on start
+				log.Error("Profile tokens override failed", logger.String("profile", profileName), logger.Error(err))
+			}
+		}
+
+		// the error kind message and description are not translated (as a freeform text)
+		if hasKind {
+			tplProps.ErrorKind = kindName
+
+			if kind.Message != "" {
+				tplProps.Message = kind.Message
+			}
+
+			if kind.Description != "" {
+				tplProps.Description = kind.Description
+			}
+		}
+
+		// the TLS error message and description are not translated either
+		if hasTLSErr {
+			tplProps.TLSError = tlsErrName
+
+			if tlsErr.Message != "" {
+				tplProps.Message = tlsErr.Message
+			}
+
+			if tlsErr.Description != "" {
+				tplProps.Description = tlsErr.Description
+			}
+		}
+
+		// the maintenance message is not translated (as a freeform text)
+		if inMaintenance && maintenance.Message != "" {
+			tplProps.Description = maintenance.Message
+		}
+
+		// interpolate the placeholders (like `retry in {retry_after, plural, one {# second} other {# seconds}}`)
+		// in the (translated) message and description
+		if strings.ContainsRune(tplProps.Message+tplProps.Description, '{') {
+			var (
+				locale = tplProps.Locale
+				args   = map[string]any{"code": code}
+			)
+
+			if locale == "" {
+				locale = l10n.DefaultLocale
+			}
+
+			if retryAfter := ctx.Response.Header.Peek("Retry-After"); len(retryAfter) > 0 {
+				args["retry_after"] = string(retryAfter)
+			}
+
+			tplProps.Message = l10n.Format(locale, tplProps.Message, args)
+			tplProps.Description = l10n.Format(locale, tplProps.Description, args)
+		}
+
+		// the props are responded instead of the page (for debugging, using the management API)
+		if inspectingProps(ctx) {
+			writeProps(ctx, tplProps)
+
+			return
+		}
+
+		// the provided template source is rendered instead of the configured one (for the theme authors)
+		if source, ok := dryRunSource(ctx); ok {
+			var escaping = template.EscapeNone
+
+			switch format { //nolint:exhaustive // the other formats are not escaped
+			case jsonFormat:
+				escaping = jsonEscaping
+			case xmlFormat:
+				escaping = xmlEscaping
+			case htmlFormat:
+				escaping = htmlEscaping
+			}
+
+			if previewAt {
+				writeDryRun(ctx, limiter.at(now), source, tplProps, escaping)
+			} else {
+				writeDryRun(ctx, limiter, source, tplProps, escaping)
+			}
+
+			if transcoded(format) && ctx.Response.StatusCode() == http.StatusOK { // the errors are sent in UTF-8
+				encodeBody(ctx, log, respCharset, format == htmlFormat)
+			}
+
+			return
+		}
+
+		var (
+			templateName string // the HTML template name
+			cacheHit     bool   // the content is taken from the cache
+			cachedBy     string // the cache key template of the response body (if it's the cached content as-is)
+			nonce        string // the per-response CSP nonce of the HTML page (if the policy uses it)
+			renderErr    error  // the template rendering error (if any)
+		)
+
 		switch {
+		case format == grpcWebFormat: // the clients can't parse anything but the gRPC-Web frames
+			write(ctx, log, grpcWebResponse(ctx, code, tplProps.Message))
+
 		case format == jsonFormat && cfg.Formats.JSON != "":
-			if cached, ok := cache.Get(cfg.Formats.JSON, tplProps); ok { // cache hit
+			if cached, ok := tenantCache.Get(cfg.Formats.JSON, tplProps); ok { // cache hit
+				cacheHit = true
+				cachedBy = cfg.Formats.JSON
+
 				write(ctx, log, cached)
 			} else { // cache miss
-				if content, err := template.Render(cfg.Formats.JSON, tplProps); err != nil {
+				if content, err := limiter.render(cfg.Formats.JSON, tplProps, jsonEscaping); errors.Is(err, errTooManyRenders) {
+					renderErr = err
+
+					write(ctx, log, minimalContent(format, code, tplProps.Message)) // too busy to render
+				} else if err != nil {
+					renderErr = err
+
 					errAsJson, _ := json.Marshal(fmt.Sprintf("Failed to render the JSON template: %s", err.Error()))
 					write(ctx, log, errAsJson) // error during rendering
 				} else {
-					cache.Put(cfg.Formats.JSON, tplProps, []byte(content))
+					tenantCache.Put(cfg.Formats.JSON, tplProps, []byte(content))
+					cachedBy = cfg.Formats.JSON
 
 					write(ctx, log, content) // rendered successfully
 				}
 			}
 
 		case format == xmlFormat && cfg.Formats.XML != "":
-			if cached, ok := cache.Get(cfg.Formats.XML, tplProps); ok { // cache hit
+			if cached, ok := tenantCache.Get(cfg.Formats.XML, tplProps); ok { // cache hit
+				cacheHit = true
+				cachedBy = cfg.Formats.XML
+
 				write(ctx, log, cached)
 			} else { // cache miss
-				if content, err := template.Render(cfg.Formats.XML, tplProps); err != nil {
+				if content, err := limiter.render(cfg.Formats.XML, tplProps, xmlEscaping); errors.Is(err, errTooManyRenders) {
+					renderErr = err
+
+					write(ctx, log, minimalContent(format, code, tplProps.Message)) // too busy to render
+				} else if err != nil {
+					renderErr = err
+
 					write(ctx, log, fmt.Sprintf(
 						"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error>Failed to render the XML template: %s</error>\n", err.Error(),
 					))
 				} else {
-					cache.Put(cfg.Formats.XML, tplProps, []byte(content))
+					tenantCache.Put(cfg.Formats.XML, tplProps, []byte(content))
+					cachedBy = cfg.Formats.XML
 
 					write(ctx, log, content)
 				}
 			}
 
+		case isFragment: // the error content without the page shell
+			if cfg.Formats.Fragment == "" {
+				write(ctx, log, minimalFragment(code, tplProps.Message))
+			} else if cached, ok := tenantCache.Get(cfg.Formats.Fragment, tplProps); ok { // cache hit
+				cacheHit = true
+				cachedBy = cfg.Formats.Fragment
+
+				write(ctx, log, cached)
+			} else if content, err := limiter.render(cfg.Formats.Fragment, tplProps, htmlEscaping); err != nil {
+				renderErr = err
+
+				write(ctx, log, minimalFragment(code, tplProps.Message)) // too busy or failed to render
+			} else {
+				tenantCache.Put(cfg.Formats.Fragment, tplProps, []byte(content))
+				cachedBy = cfg.Formats.Fragment
+
+				write(ctx, log, content)
+			}
+
 		case format == htmlFormat:
-			var templateName = templateToUse(cfg)
+			templateName = routeTplName
+
+			// the feature flag switches the template (the theme) without redeploying the configuration
+			if name, ok := tplProps.Flags.Get(featureflag.TemplateFlag); ok && templateName == "" && cfg.Templates.Has(name) {
+				templateName = name
+			}
+
+			if templateName == "" && exp != nil {
+				templateName = exp.pick(ctx)
+			} else if templateName == "" {
+				templateName = rot.pick()
+			}
+
+			if cfg.ContentSecurityPolicy != "" {
+				if cspNoncePlaceholder != nil {
+					nonce = newCSPNonce()
+
+					ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-store") // the nonce must not be reused
+				}
+
+				ctx.Response.Header.Set("Content-Security-Policy",
+					strings.ReplaceAll(cfg.ContentSecurityPolicy, config.CSPNoncePlaceholder, nonce),
+				)
+			}
 
 			if tpl, found := cfg.Templates.Get(templateName); found { //nolint:nestif
-				if cached, ok := cache.Get(tpl, tplProps); ok { // cache hit
-					write(ctx, log, cached)
+				var (
+					tplOpts     = cfg.TemplateOptions[templateName] // the per-template opt-outs
+					tplMinifier = minifier
+					cached      []byte
+					ok          bool
+				)
+
+				if tplOpts.DisableMinification {
+					tplMinifier = nil
+				}
+
+				if nonce != "" {
+					tplProps.CSPNonce = string(cspNoncePlaceholder) // replaced with the nonce on write
+				}
+
+				for _, link := range tplOpts.Preload { // the template assets served separately
+					ctx.Response.Header.Add(fasthttp.HeaderLink, link)
+				}
+
+				if !tplOpts.DisableCache {
+					cached, ok = tenantCache.Get(tpl, tplProps)
+				}
+
+				// the browser may start fetching the assets while the page is rendered (the cached page is sent
+				// right away, so there is nothing to wait for)
+				if !ok && cfg.EarlyHints && !cfg.Shadow && len(tplOpts.Preload) > 0 {
+					if err := ctx.EarlyHints(); err != nil {
+						log.Debug("Failed to send the early hints", logger.Error(err))
+					}
+				}
+
+				if ok { // cache hit
+					cacheHit = true
+
+					if nonce == "" { // the nonce is unique per response
+						cachedBy = tpl
+					}
+
+					write(ctx, log, withNonce(cached, cspNoncePlaceholder, nonce))
+				} else if streamed(tpl, code) { // cache miss, the large template is streamed (not minified and cached)
+					var props = tplProps
+
+					if nonce != "" {
+						props.CSPNonce = nonce // the streamed page is never cached
+					}
+
+					if err := limiter.stream(ctx, log, tpl, props, htmlEscaping); err != nil {
+						renderErr = err
+
+						write(ctx, log, minimalContent(format, code, tplProps.Message)) // too busy to render
+					}
 				} else { // cache miss
-					if content, err := template.Render(tpl, tplProps); err != nil {
-						// TODO: add GZIP compression for the HTML content support
+					var script string
+
+					if code == http.StatusServiceUnavailable {
+						script = recovery
+					}
+
+					script += liveStatus
+
+					if nonce != "" { // the scripts are allowed by the policy with the nonce only
+						script = strings.ReplaceAll(script, "<script>", `<script nonce="`+string(cspNoncePlaceholder)+`">`)
+					}
+
+					if buf, err := limiter.renderHTML(
+						log, tpl, tplProps, htmlEscaping, tplMinifier, script,
+					); errors.Is(err, errTooManyRenders) {
+						renderErr = err
+
+						write(ctx, log, minimalContent(format, code, tplProps.Message)) // too busy to render
+					} else if err != nil {
+						renderErr = err
+
 						write(ctx, log, fmt.Sprintf(
 							"<!DOCTYPE html>\n<html><body>Failed to render the HTML template %s: %s</body></html>\n",
 							templateName,
 							err.Error(),
 						))
 					} else {
-						if !cfg.DisableMinification {
-							if mini, minErr := template.MiniHTML(content); minErr != nil {
-								log.Warn("HTML minification failed", logger.Error(minErr))
-							} else {
-								content = mini
+						if !tplOpts.DisableCache {
+							tenantCache.Put(tpl, tplProps, bytes.Clone(buf.Bytes()))
+
+							if nonce == "" { // the nonce is unique per response
+								cachedBy = tpl
 							}
 						}
 
-						cache.Put(tpl, tplProps, []byte(content))
-
-						write(ctx, log, content)
+						write(ctx, log, withNonce(buf.Bytes(), cspNoncePlaceholder, nonce))
+						putBuffer(buf)
 					}
 				}
 			} else {
@@ -195,73 +1014,136 @@ func New(cfg *config.Config, log *logger.Logger) (_ fasthttp.RequestHandler, clo
 
 		default: // plainTextFormat as default
 			if cfg.Formats.PlainText != "" { //nolint:nestif
-				if cached, ok := cache.Get(cfg.Formats.PlainText, tplProps); ok { // cache hit
+				if cached, ok := tenantCache.Get(cfg.Formats.PlainText, tplProps); ok { // cache hit
+					cacheHit = true
+					cachedBy = cfg.Formats.PlainText
+
 					write(ctx, log, cached)
 				} else { // cache miss
-					if content, err := template.Render(cfg.Formats.PlainText, tplProps); err != nil {
+					if content, err := limiter.render(cfg.Formats.PlainText, tplProps, template.EscapeNone); errors.Is(err, errTooManyRenders) {
+						renderErr = err
+
+						write(ctx, log, minimalContent(format, code, tplProps.Message)) // too busy to render
+					} else if err != nil {
+						renderErr = err
+
 						write(ctx, log, fmt.Sprintf("Failed to render the PlainText template: %s", err.Error()))
 					} else {
-						cache.Put(cfg.Formats.PlainText, tplProps, []byte(content))
+						content = shaper.shape(content)
+
+						tenantCache.Put(cfg.Formats.PlainText, tplProps, []byte(content))
+						cachedBy = cfg.Formats.PlainText
 
 						write(ctx, log, content)
 					}
 				}
+			} else if cfg.Formats.Unsupported != "" { // the template of the requested format is not set
+				if cached, ok := tenantCache.Get(cfg.Formats.Unsupported, tplProps); ok { // cache hit
+					cacheHit = true
+					cachedBy = cfg.Formats.Unsupported
+
+					write(ctx, log, cached)
+				} else if content, err := limiter.render(cfg.Formats.Unsupported, tplProps, template.EscapeNone); err != nil {
+					renderErr = err
+
+					write(ctx, log, minimalContent(plainTextFormat, code, tplProps.Message))
+				} else {
+					content = shaper.shape(content)
+
+					tenantCache.Put(cfg.Formats.Unsupported, tplProps, []byte(content))
+					cachedBy = cfg.Formats.Unsupported
+
+					write(ctx, log, content)
+				}
 			} else {
-				write(ctx, log, `The requested content format is not supported.
-Please create an issue on the project's GitHub page to request support for this format.
+				write(ctx, log, shaper.shape(minimalContent(plainTextFormat, code, tplProps.Message)))
+			}
+		}
+
+		served.Inc(strconv.FormatUint(uint64(code), 10), formatName(format))
+
+		var bodyLimit uint // the response body size limit (zero means no limit)
+
+		switch format {
+		case jsonFormat:
+			bodyLimit = cfg.BodyLimits.JSON
+		case xmlFormat:
+			bodyLimit = cfg.BodyLimits.XML
+		case plainTextFormat:
+			bodyLimit = cfg.BodyLimits.PlainText
+		}
 
-Supported formats: JSON, XML, HTML, Plain Text
-`)
+		if bodyLimit > 0 && uint(len(ctx.Response.Body())) > bodyLimit && !ctx.Response.IsBodyStream() {
+			ctx.Response.SetBody(limitBody(ctx.Response.Body(), bodyLimit, format, minimalContent(format, code, "")))
+
+			cachedBy = "" // the body differs from the cached content now
+		}
+
+		if mark != nil && format == htmlFormat && !isFragment { // the watermark is unique per response
+			var requestID = tplProps.RequestID // set if the details are shown
+
+			if requestID == "" {
+				requestID = requestIDs.generate(reqHeaders)
 			}
+
+			mark.apply(ctx, opt.clock.Now(), requestID, nonce)
+
+			cachedBy = "" // the body differs from the cached content now
 		}
-	}, func() { stopOnce.Do(func() { close(stopCh) }) }
-}
 
-var (
-	templateChangedAt atomic.Pointer[time.Time] //nolint:gochecknoglobals // the time when the theme was changed last time
-	pickedTemplate    atomic.Pointer[string]    //nolint:gochecknoglobals // the name of the randomly picked template
-)
+		if transcoded(format) && !respCharset.IsUTF8() { // the content is rendered (and cached) in UTF-8
+			encodeBody(ctx, log, respCharset, format == htmlFormat)
 
-// templateToUse decides which template to use based on the rotation mode and the last time the template was changed.
-func templateToUse(cfg *config.Config) string {
-	switch rotationMode := cfg.RotationMode; rotationMode {
-	case config.RotationModeDisabled:
-		return cfg.TemplateName // not needed to do anything
-	case config.RotationModeRandomOnStartup:
-		return cfg.TemplateName // do nothing, the scope of this rotation mode is not here
-	case config.RotationModeRandomOnEachRequest:
-		return cfg.Templates.RandomName() // pick a random template on each request
-	case config.RotationModeRandomHourly, config.RotationModeRandomDaily:
-		var now, rndTemplate = time.Now(), cfg.Templates.RandomName()
-
-		if changedAt := templateChangedAt.Load(); changedAt == nil {
-			// the template was not changed yet (first request)
-			templateChangedAt.Store(&now)
-			pickedTemplate.Store(&rndTemplate)
-
-			return rndTemplate
-		} else {
-			// is it time to change the template?
-			if (rotationMode == config.RotationModeRandomHourly && changedAt.Hour() != now.Hour()) ||
-				(rotationMode == config.RotationModeRandomDaily && changedAt.Day() != now.Day()) {
-				templateChangedAt.Store(&now)
-				pickedTemplate.Store(&rndTemplate)
-
-				return rndTemplate
-			} else if lastUsed := pickedTemplate.Load(); lastUsed != nil {
-				// time to change the template has not come yet, so use the last picked template
-				return *lastUsed
-			} else {
-				// in case if the last picked template is not set, pick a random one and store it
-				templateChangedAt.Store(&now)
-				pickedTemplate.Store(&rndTemplate)
+			cachedBy = "" // the body differs from the cached content now
+		}
 
-				return rndTemplate
+		if sign != nil && !cfg.Shadow {
+			ctx.Response.Header.Set(SignatureHeader, sign.sign(ctx.Response.Body()))
+		}
+
+		// the signature covers the uncompressed body (the compression is the transfer concern)
+		if !cfg.DisableCompression && !cfg.Shadow && format != grpcWebFormat {
+			compressBody(ctx, tenantCache, cachedBy, tplProps)
+		}
+
+		// the cached content has the validators, so the repeated requests may be answered without the body
+		if cachedBy != "" && !cfg.Shadow {
+			conditional(ctx, tenantCache, cachedBy, tplProps)
+		}
+
+		// in the shadow mode, the decision is logged instead of serving the content (e.g. behind a traffic mirror)
+		if cfg.Shadow {
+			var attrs = []logger.Attr{
+				logger.String("path", string(ctx.Path())),
+				logger.Uint64("code", uint64(code)),
+				logger.String("format", formatName(format)),
+				logger.String("template", templateName),
+				logger.Bool("cache hit", cacheHit),
+				logger.Int("size", len(ctx.Response.Body())),
+			}
+
+			if renderErr != nil {
+				attrs = append(attrs, logger.Error(renderErr))
 			}
+
+			log.Info("Shadow render", attrs...)
+
+			ctx.Response.ResetBody()
+			ctx.SetStatusCode(http.StatusNoContent)
 		}
+	}, stop
+}
+
+// originalPath returns the path of the original request (from the proxy header, like `X-Original-URI` set by the
+// ingress-nginx) or the current request path if the header is missing.
+func originalPath(ctx *fasthttp.RequestCtx, header string) string {
+	if uri := ctx.Request.Header.Peek(header); len(uri) > 0 {
+		var path, _, _ = strings.Cut(string(uri), "?")
+
+		return path
 	}
 
-	return cfg.TemplateName // the fallback of the fallback :D
+	return string(ctx.Path())
 }
 
 // write the content to the response writer and log the error if any.
@@ -281,39 +1163,3 @@ func write[T string | []byte](ctx *fasthttp.RequestCtx, log *logger.Logger, cont
 		)
 	}
 }
-
-// generateRequestID generates a unique request ID.
-// If upstream has X-Request-Id or X-RequestID header, use {SERVER_ICAO}-{value}.
-// Otherwise generate {SERVER_ICAO}-{random 5 bytes hex}-{uuidv7 without dashes}.
-func generateRequestID(reqHeaders *fasthttp.RequestHeader) string {
-	serverICAO := os.Getenv("DATA_CENTRE_CODE")
-	if serverICAO == "" {
-		serverICAO = "CYK2"
-	}
-
-	// Check for upstream request ID headers
-	if upstreamID := reqHeaders.Peek("X-Request-Id"); len(upstreamID) > 0 {
-		return serverICAO + "-" + string(upstreamID)
-	}
-	if upstreamID := reqHeaders.Peek("X-RequestID"); len(upstreamID) > 0 {
-		return serverICAO + "-" + string(upstreamID)
-	}
-
-	// Generate new request ID: {SERVER_ICAO}-{random 5 bytes hex}-{uuidv7 without dashes}
-	randomBytes := make([]byte, 5)
-	if _, err := rand.Read(randomBytes); err != nil {
-		// fallback to a simple random string if crypto/rand fails
-		randomBytes = []byte{0x00, 0x00, 0x00, 0x00, 0x00}
-	}
-	randomHex := hex.EncodeToString(randomBytes)
-
-	// Generate UUID v7 and remove dashes
-	uuidV7, err := uuid.NewV7()
-	if err != nil {
-		// fallback to UUID v4 if v7 fails
-		uuidV7 = uuid.New()
-	}
-	uuidStr := strings.ReplaceAll(uuidV7.String(), "-", "")
-
-	return serverICAO + "-" + randomHex + "-" + uuidStr
-}
@@ -1,19 +1,45 @@
 package error_page_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
 	"net/http"
+	stdHttptest "net/http/httptest"
+	"net/http/httptrace"
+	"net/netip"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
 
+	"github.com/binaryYuki/error-pages/internal/clock"
 	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/featureflag"
 	"github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
 	"github.com/binaryYuki/error-pages/internal/http/httptest"
 	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+	"github.com/binaryYuki/error-pages/internal/upstream"
+	"github.com/binaryYuki/error-pages/internal/wasmhook"
 )
 
 func TestHandler(t *testing.T) {
+	if error_page.SlimBuild {
+		t.Skip("the slim build has neither the minifier nor the `connection` template")
+	}
+
 	t.Parallel()
 
 	for name, tt := range map[string]struct {
@@ -128,87 +154,2748 @@ func TestHandler(t *testing.T) {
 			wantHeaders:      map[string]string{"Content-Type": "application/json; charset=utf-8"},
 			wantBodyIncludes: []string{"100", "Continue"},
 		},
-		"unknown code": {
+		"allowed host": {
 			giveConfig: func() *config.Config {
 				cfg := config.New()
 
-				cfg.Codes = config.Codes{}
+				cfg.AllowedHosts = []string{"*.example.com"}
 
 				return &cfg
 			},
-			giveUrl:     "http://testing/1",
+			giveUrl:     "http://foo.example.com:8080/404",
 			giveHeaders: map[string]string{"Accept": "application/json"},
 
 			wantStatusCode:   http.StatusOK,
 			wantHeaders:      map[string]string{"Content-Type": "application/json; charset=utf-8"},
-			wantBodyIncludes: []string{"1", "Unknown Status Code"},
+			wantBodyIncludes: []string{"404", "Not Found"},
 		},
-	} {
-		t.Run(name, func(t *testing.T) {
-			t.Parallel()
+		"not allowed host": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
 
-			var handler, closeCache = error_page.New(tt.giveConfig(), logger.NewNop())
-			defer closeCache()
+				cfg.AllowedHosts = []string{"example.com"}
+				cfg.ShowDetails = true
 
-			req, reqErr := http.NewRequest(http.MethodGet, tt.giveUrl, http.NoBody)
-			require.NoError(t, reqErr)
+				return &cfg
+			},
+			giveUrl:     "http://evil.com/404",
+			giveHeaders: map[string]string{"Accept": "application/json", "X-Request-ID": "req-id-777"},
 
-			for k, v := range tt.giveHeaders {
-				req.Header.Set(k, v)
-			}
+			wantStatusCode:   http.StatusMisdirectedRequest,
+			wantHeaders:      map[string]string{"X-Request-Id": ""},
+			wantBodyIncludes: []string{"Misdirected Request"},
+		},
+		"routed code": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
 
-			httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
-				assert.Equal(t, tt.wantStatusCode, status)
+				cfg.Routes = config.Routes{
+					{Pattern: regexp.MustCompile(`^/old-api/`), Code: 410},
+					{Pattern: regexp.MustCompile(`^/internal/`), Code: 403},
+				}
 
-				for hName, hWant := range tt.wantHeaders {
-					for hGot := range headers {
-						if hGot == hName {
-							assert.Contains(t, hWant, headers.Get(hGot))
-						}
-					}
+				return &cfg
+			},
+			giveUrl:     "http://testing/old-api/404",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			wantBodyIncludes: []string{"410", "Gone"},
+		},
+		"routed template only": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				require.NoError(t, cfg.Templates.Add("routed", "routed {{ code }}"))
+
+				cfg.Routes = config.Routes{{Pattern: regexp.MustCompile(`^/docs/`), Template: "routed"}}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/docs/foo",
+			giveHeaders: map[string]string{"Accept": "text/html", "X-Code": "503"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Content-Type": "text/html; charset=utf-8"},
+			wantBodyIncludes: []string{"routed 503"},
+		},
+		"catch-all": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.DefaultCodeToRender = 503
+				cfg.CatchAll.Enabled = true
+				cfg.CatchAll.LogSampleRate = 1
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/foo/bar",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Retry-After": ""},
+			wantBodyIncludes: []string{"404", "Not Found"},
+		},
+		"catch-all without retry-after": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.CatchAll.Enabled = true
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/503",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Retry-After": ""},
+			wantBodyIncludes: []string{"503", "Service Unavailable"},
+		},
+		"auth challenges": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				require.NoError(t, cfg.Templates.Add("auth", "{{ code }}: {{ www_authenticate }}"))
+
+				cfg.TemplateName = "auth"
+				cfg.AuthChallenges = []string{`Basic realm="example"`, `Bearer`}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/401",
+			giveHeaders: map[string]string{"Accept": "text/html"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Www-Authenticate": `Basic realm="example"`},
+			wantBodyIncludes: []string{`401: Basic realm="example", Bearer`},
+		},
+		"auth challenges for non-401": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.AuthChallenges = []string{`Bearer`}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/403",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode: http.StatusOK,
+			wantHeaders:    map[string]string{"Www-Authenticate": ""},
+		},
+		"allow header from rules": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.AllowRules = config.AllowRules{
+					{Pattern: regexp.MustCompile(`^/api/`), Methods: []string{"GET", "POST"}},
+					{Pattern: regexp.MustCompile(`.*`), Methods: []string{"GET", "HEAD"}},
 				}
 
-				for _, wantBodyInclude := range tt.wantBodyIncludes {
-					assert.Contains(t, body, wantBodyInclude)
+				return &cfg
+			},
+			giveUrl:     "http://testing/405",
+			giveHeaders: map[string]string{"Accept": "application/json", "X-Original-URI": "/api/users?foo=bar"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Allow": "GET, POST"},
+			wantBodyIncludes: []string{"405"},
+		},
+		"allow header from rules (traefik)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Proxy.Mode = config.ProxyModeTraefik
+				cfg.AllowRules = config.AllowRules{
+					{Pattern: regexp.MustCompile(`^/api/`), Methods: []string{"GET", "POST"}},
+					{Pattern: regexp.MustCompile(`.*`), Methods: []string{"GET", "HEAD"}},
 				}
-			})
-		})
-	}
-}
 
-func TestRotationModeOnEachRequest(t *testing.T) {
-	t.Parallel()
+				return &cfg
+			},
+			giveUrl: "http://testing/405",
+			giveHeaders: map[string]string{
+				"Accept": "application/json", "X-Forwarded-Uri": "/api/users", "X-Original-URI": "/static/app.js",
+			},
 
-	var cfg = config.New()
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Allow": "GET, POST"},
+			wantBodyIncludes: []string{"405"},
+		},
+		"allow header from upstream": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
 
-	cfg.RotationMode = config.RotationModeRandomOnEachRequest
-	cfg.Templates = map[string]string{
-		"foo": "foo",
-		"bar": "bar",
-	}
+				cfg.AllowRules = config.AllowRules{{Pattern: regexp.MustCompile(`.*`), Methods: []string{"GET"}}}
 
-	var (
-		lastResponseBody string
-		changedTimes     int
+				return &cfg
+			},
+			giveUrl:     "http://testing/405",
+			giveHeaders: map[string]string{"Accept": "application/json", "Allow": "PUT"},
 
-		handler, closeCache = error_page.New(&cfg, logger.NewNop())
-	)
+			wantStatusCode: http.StatusOK,
+			wantHeaders:    map[string]string{"Allow": "PUT"},
+		},
+		"locale from the query": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
 
-	defer func() { closeCache(); closeCache(); closeCache() }() // multiple calls should not panic
+				return &cfg
+			},
+			giveUrl:     "http://testing/404?lang=de",
+			giveHeaders: map[string]string{"Accept": "application/json", "Accept-Language": "fr"},
 
-	for range 300 {
-		req, reqErr := http.NewRequest(http.MethodGet, "http://testing/", http.NoBody)
-		require.NoError(t, reqErr)
+			wantStatusCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Set-Cookie":       "lang=de; max-age=31536000; path=/; SameSite=Lax",
+				"Content-Language": "de",
+				"Vary":             "Accept-Language",
+			},
+			wantBodyIncludes: []string{"404", "Nicht gefunden"},
+		},
+		"locale from the cookie": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
 
-		req.Header.Set("Accept", "text/html")
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "application/json", "Accept-Language": "fr", "Cookie": "lang=de"},
 
-		httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
-			if lastResponseBody != body {
-				changedTimes++
-				lastResponseBody = body
-			}
-		})
-	}
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Set-Cookie": ""},
+			wantBodyIncludes: []string{"Nicht gefunden"},
+		},
+		"locale from the header": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
 
-	assert.True(t, changedTimes > 30, "the template should be changed at least 30 times")
+				return &cfg
+			},
+			giveUrl:     "http://testing/404?lang=xx",
+			giveHeaders: map[string]string{"Accept": "application/json", "Accept-Language": "fr-FR,fr;q=0.9"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"Introuvable"},
+		},
+		"localized standard status text": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/402?lang=de",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"402", "Zahlung erforderlich"},
+		},
+		"l10n disabled": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.L10n.Disable = true
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404?lang=de",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Set-Cookie": "", "Content-Language": "", "Vary": ""},
+			wantBodyIncludes: []string{"Not Found"},
+		},
+		"lang code": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Templates = map[string]string{"foo": `<html lang="{{ lang_code }}" dir="{{ text_direction }}">`}
+				cfg.TemplateName = "foo"
+				cfg.DisableMinification = true
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "text/html", "Accept-Language": "pt-BR,en;q=0.5"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Content-Language": "pt", "Vary": "Accept-Language"},
+			wantBodyIncludes: []string{`<html lang="pt" dir="ltr">`},
+		},
+		"reduced motion client hint": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Templates = map[string]string{"foo": `motion={{ reduced_motion }} print={{ print_friendly }}`}
+				cfg.TemplateName = "foo"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "text/html", "Sec-CH-Prefers-Reduced-Motion": "reduce"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Accept-CH": "Sec-CH-Prefers-Reduced-Motion"},
+			wantBodyIncludes: []string{"motion=true print=false"},
+		},
+		"no motion preference": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Templates = map[string]string{"foo": `motion={{ reduced_motion }}`}
+				cfg.TemplateName = "foo"
+				cfg.Accessibility.ReducedMotion = config.MotionPreferenceNoPreference
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "text/html", "Sec-CH-Prefers-Reduced-Motion": "reduce"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Accept-CH": ""},
+			wantBodyIncludes: []string{"motion=false"},
+		},
+		"reduced motion and print friendly": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Templates = map[string]string{"foo": `motion={{ reduced_motion }} print={{ print_friendly }}`}
+				cfg.TemplateName = "foo"
+				cfg.Accessibility.ReducedMotion = config.MotionPreferenceReduce
+				cfg.Accessibility.PrintFriendly = true
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "text/html"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Accept-CH": ""},
+			wantBodyIncludes: []string{"motion=true print=true"},
+		},
+		"default lang code": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Templates = map[string]string{"foo": `<html lang="{{ lang_code }}">`}
+				cfg.TemplateName = "foo"
+				cfg.DisableMinification = true
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "text/html", "Accept-Language": "xx"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Content-Language": ""}, // the locale is unknown
+			wantBodyIncludes: []string{`<html lang="en">`},
+		},
+		"localized code override": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Codes["404"] = config.CodeDescription{
+					Message:     "Not Found",
+					Description: "The server can not find the requested page",
+					Localized:   map[string]config.LocalizedDescription{"de": {Message: "Nicht hier"}},
+				}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404?lang=de",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode: http.StatusOK,
+			wantBodyIncludes: []string{
+				`"Nicht hier"`,
+				`"Der Server kann die angeforderte Seite nicht finden"`, // not overridden - the built-in translation
+			},
+		},
+		"html escaping": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.TemplateName = "ghost"
+				cfg.Codes["404"] = config.CodeDescription{Message: "x<script>alert(1)</script>"}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "text/html"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"x&lt;script"}, // the minifier keeps only the required escaping
+		},
+		"auto escaping disabled": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.DisableAutoEscape = true
+				cfg.Codes["404"] = config.CodeDescription{Message: "a&b"}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept": "application/xml"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"<message>a&b</message>"},
+		},
+		"client context tokens": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.ShowDetails = true
+				cfg.Formats.PlainText = "{{ accept_language }}|{{ sec_ch_ua }}|{{ sec_ch_ua_platform }}"
+
+				return &cfg
+			},
+			giveUrl: "http://testing/404",
+			giveHeaders: map[string]string{
+				"Accept-Language":    "de-DE,de;q=0.9",
+				"Sec-CH-UA":          `"Chromium";v="124"`,
+				"Sec-CH-UA-Platform": `"Linux"`,
+			},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{`de-DE,de;q=0.9|"Chromium";v="124"|"Linux"`},
+		},
+		"client context tokens (details hidden)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Formats.PlainText = "[{{ accept_language }}{{ sec_ch_ua }}{{ sec_ch_ua_platform }}]"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"Accept-Language": "de", "Sec-CH-UA-Platform": `"Linux"`},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"[]"},
+		},
+		"datacenter token and request ID": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.ShowDetails = true
+				cfg.Datacenter.Code = "FRA1"
+				cfg.Formats.PlainText = "{{ datacenter }} {{ request_id }}"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"X-Request-Id": "abc"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"FRA1 FRA1-abc"},
+		},
+		"datacenter token (default)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Formats.PlainText = "[{{ datacenter }}]"
+
+				return &cfg
+			},
+			giveUrl: "http://testing/404",
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"[CYK2]"},
+		},
+		"banner (ghost)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.TemplateName = "ghost"
+				cfg.Banner.Message = "We are investigating <elevated> error rates"
+				cfg.Banner.Severity = config.BannerSeverityCritical
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/503",
+			giveHeaders: map[string]string{"Accept": "text/html"},
+
+			wantStatusCode: http.StatusOK,
+			wantBodyIncludes: []string{
+				`class="banner banner-critical"`,
+				"We are investigating &lt;elevated> error rates", // the minifier does not escape ">"
+			},
+		},
+		"banner (connection)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.TemplateName = "connection"
+				cfg.Banner.Message = "Scheduled maintenance"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/503",
+			giveHeaders: map[string]string{"Accept": "text/html"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{`class="banner banner-info"`, "Scheduled maintenance"},
+		},
+		"banner tokens": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Banner.Message = "Degraded"
+				cfg.Banner.Severity = config.BannerSeverityWarning
+				cfg.Formats.PlainText = "[{{ banner_severity }}: {{ banner }}]"
+
+				return &cfg
+			},
+			giveUrl: "http://testing/503",
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"[warning: Degraded]"},
+		},
+		"error kind": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.RespondWithSameHTTPCode = true
+				cfg.ErrorKinds = config.ErrorKinds{
+					"quota_exceeded": {Code: 429, Message: "Quota Exceeded", Description: "Upgrade your plan"},
+				}
+				cfg.Formats.PlainText = "{{ code }} {{ error_kind }}: {{ message }} ({{ description }})"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/500",
+			giveHeaders: map[string]string{"X-Error-Kind": "Quota_Exceeded"},
+
+			wantStatusCode:   http.StatusTooManyRequests,
+			wantBodyIncludes: []string{"429 quota_exceeded: Quota Exceeded (Upgrade your plan)"},
+		},
+		"error kind (template)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.ErrorKinds = config.ErrorKinds{"region_blocked": {Code: 451, Template: "blocked"}}
+				_ = cfg.Templates.Add("blocked", "<p>blocked {{ code }}: {{ message }}</p>")
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/",
+			giveHeaders: map[string]string{"X-Error-Kind": "region_blocked", "Accept": "text/html"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"<p>blocked 451: Unavailable For Legal Reasons</p>"},
+		},
+		"error kind (unknown)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.ErrorKinds = config.ErrorKinds{"quota_exceeded": {Code: 429}}
+				cfg.Formats.PlainText = "{{ code }} [{{ error_kind }}]"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/502",
+			giveHeaders: map[string]string{"X-Error-Kind": "region_blocked"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"502 []"},
+		},
+		"profile (header)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.RespondWithSameHTTPCode = true
+				cfg.Profiles = config.Profiles{"blocked-by-waf": {
+					Code:    403,
+					Headers: map[string]string{"X-Blocked-By": "waf"},
+					Tokens:  map[string]string{"message": "Request Blocked"},
+				}}
+				cfg.Formats.PlainText = "{{ code }} {{ profile }}: {{ message }} ({{ description }})"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/500",
+			giveHeaders: map[string]string{"X-Error-Profile": "Blocked-By-WAF"},
+
+			wantStatusCode:   http.StatusForbidden,
+			wantHeaders:      map[string]string{"X-Blocked-By": "waf"},
+			wantBodyIncludes: []string{"403 blocked-by-waf: Request Blocked (Access is forbidden to the requested page)"},
+		},
+		"profile (path, template, and format)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				var html = config.FormatHTML
+
+				cfg.Profiles = config.Profiles{"billing-suspended": {Code: 402, Template: "billing", Format: &html}}
+				_ = cfg.Templates.Add("billing", "<p>billing {{ code }}: {{ message }}</p>")
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/profile/billing-suspended",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Content-Type": "text/html; charset=utf-8"},
+			wantBodyIncludes: []string{"<p>billing 402: Payment Required</p>"},
+		},
+		"profile (error kind wins)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Profiles = config.Profiles{"blocked-by-waf": {Code: 403}}
+				cfg.ErrorKinds = config.ErrorKinds{"quota_exceeded": {Code: 429}}
+				cfg.Formats.PlainText = "{{ code }} [{{ profile }}] [{{ error_kind }}]"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/",
+			giveHeaders: map[string]string{"X-Error-Profile": "blocked-by-waf", "X-Error-Kind": "quota_exceeded"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"429 [blocked-by-waf] [quota_exceeded]"},
+		},
+		"profile (unknown)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Profiles = config.Profiles{"blocked-by-waf": {Code: 403}}
+				cfg.Formats.PlainText = "{{ code }} [{{ profile }}]"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/502",
+			giveHeaders: map[string]string{"X-Error-Profile": "billing-suspended"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"502 []"},
+		},
+		"TLS error": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.RespondWithSameHTTPCode = true
+				cfg.Formats.PlainText = "{{ code }} {{ tls_error }}: {{ message }}"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/400",
+			giveHeaders: map[string]string{"X-SSL-Error": "FAILED:certificate has expired"},
+
+			wantStatusCode:   495,
+			wantBodyIncludes: []string{"495 certificate_expired: SSL Certificate Expired"},
+		},
+		"TLS error (custom header and template)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.TLSErrors.Header = "X-TLS-Error"
+				cfg.TLSErrors.Kinds[config.TLSErrorProtocolUnsupported] = config.ErrorKind{Code: 426, Template: "tls"}
+				_ = cfg.Templates.Add("tls", "<p>tls {{ code }}: {{ message }}</p>")
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/400",
+			giveHeaders: map[string]string{"X-TLS-Error": "unsupported protocol", "Accept": "text/html"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"<p>tls 426: Upgrade Required</p>"},
+		},
+		"TLS error (error kind wins)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.ErrorKinds = config.ErrorKinds{"quota_exceeded": {Code: 429}}
+				cfg.Formats.PlainText = "{{ code }} [{{ error_kind }}] [{{ tls_error }}]"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/400",
+			giveHeaders: map[string]string{"X-Error-Kind": "quota_exceeded", "X-SSL-Error": "NONE"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"429 [quota_exceeded] []"},
+		},
+		"TLS error (verification succeeded)": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Formats.PlainText = "{{ code }} [{{ tls_error }}]"
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/404",
+			giveHeaders: map[string]string{"X-SSL-Error": "SUCCESS"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{"404 []"},
+		},
+		"description interpolation": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Codes["503"] = config.CodeDescription{
+					Message:     "Error {code}",
+					Description: "Retry in {retry_after, plural, one {# second} other {# seconds}}",
+				}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/503",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantBodyIncludes: []string{`"Error 503"`, `"Retry in 120 seconds"`},
+		},
+		"unknown code": {
+			giveConfig: func() *config.Config {
+				cfg := config.New()
+
+				cfg.Codes = config.Codes{}
+
+				return &cfg
+			},
+			giveUrl:     "http://testing/1",
+			giveHeaders: map[string]string{"Accept": "application/json"},
+
+			wantStatusCode:   http.StatusOK,
+			wantHeaders:      map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			wantBodyIncludes: []string{"1", "Unknown Status Code"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var handler, closeCache = error_page.New(tt.giveConfig(), logger.NewNop())
+			defer closeCache()
+
+			req, reqErr := http.NewRequest(http.MethodGet, tt.giveUrl, http.NoBody)
+			require.NoError(t, reqErr)
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+				assert.Equal(t, tt.wantStatusCode, status)
+
+				for hName, hWant := range tt.wantHeaders {
+					for hGot := range headers {
+						if hGot == hName {
+							assert.Contains(t, hWant, headers.Get(hGot))
+						}
+					}
+				}
+
+				for _, wantBodyInclude := range tt.wantBodyIncludes {
+					assert.Contains(t, body, wantBodyInclude)
+				}
+			})
+		})
+	}
+}
+
+func TestHandler_BodyPreview(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveSize uint
+		want     string
+	}{
+		"disabled": {giveSize: 0, want: "[]"},
+		"enabled":  {giveSize: 9, want: `[{"event":…]`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			cfg.BodyPreviewSize = tt.giveSize
+			cfg.Formats.PlainText = "[{{ body_preview }}]"
+
+			var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+			defer closeCache()
+
+			req, reqErr := http.NewRequest(http.MethodPost, "http://testing/400", strings.NewReader(`{"event":"push"}`))
+			require.NoError(t, reqErr)
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) {
+				assert.Equal(t, tt.want, body)
+			})
+		})
+	}
+}
+
+func TestHandler_UpstreamRequestID(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveID     string
+		wantBody   string
+		wantHeader string
+	}{
+		"valid":     {giveID: "abc-123_x.y:z", wantBody: "[FRA1-abc-123_x.y:z]", wantHeader: "abc-123_x.y:z"},
+		"truncated": {giveID: strings.Repeat("a", 200), wantBody: "[FRA1-" + strings.Repeat("a", 128) + "]", wantHeader: strings.Repeat("a", 128)},
+		"invalid":   {giveID: `"><script>alert(1)</script>`, wantHeader: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			cfg.ShowDetails = true
+			cfg.Datacenter.Code = "FRA1"
+			cfg.Formats.PlainText = "[{{ request_id }}]"
+
+			var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+			defer closeCache()
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+			require.NoError(t, reqErr)
+
+			req.Header.Set("X-Request-Id", tt.giveID)
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+				assert.Equal(t, tt.wantHeader, headers.Get("X-Request-Id"))
+
+				if tt.wantBody != "" {
+					assert.Equal(t, tt.wantBody, body)
+				} else {
+					assert.NotContains(t, body, "script")
+					assert.Regexp(t, `^\[FRA1-[0-9a-f-]+\]$`, body) // a new one is generated
+				}
+			})
+		})
+	}
+}
+
+func TestHandler_CacheTenants(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.AllowedHosts = []string{"foo.com", "*.bar.com"}
+	cfg.Formats.PlainText = "{{ code }}"
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+	)
+
+	defer closeCache()
+
+	for _, url := range []string{
+		"http://foo.com/404", "http://foo.com/404", "http://a.bar.com/404", "http://b.bar.com/404",
+	} {
+		req, reqErr := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, reqErr)
+
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "404", body)
+		})
+	}
+
+	var requests = reg.Counter("error_pages_cache_requests_total", "", "tenant", "result")
+
+	assert.Equal(t, uint64(1), requests.Value("foo.com", "miss"))
+	assert.Equal(t, uint64(1), requests.Value("foo.com", "hit"))
+	assert.Equal(t, uint64(1), requests.Value("*.bar.com", "miss")) // the subdomains share the same tenant
+	assert.Equal(t, uint64(1), requests.Value("*.bar.com", "hit"))
+}
+
+func TestHandler_BannerControl(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cfg = config.New()
+		ctl error_page.BannerControl
+	)
+
+	cfg.Formats.PlainText = "[{{ banner_severity }}: {{ banner }}]"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithBannerControl(&ctl))
+	defer closeCache()
+
+	var render = func() (body string) {
+		req, reqErr := http.NewRequest(http.MethodGet, "http://testing/503", http.NoBody)
+		require.NoError(t, reqErr)
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+		return
+	}
+
+	assert.Equal(t, "[: ]", render())
+
+	b, err := ctl.Set(" Partial outage ", "CRITICAL")
+	require.NoError(t, err)
+	assert.Equal(t, error_page.Banner{Message: "Partial outage", Severity: "critical"}, b)
+	assert.Equal(t, b, ctl.Get())
+
+	assert.Equal(t, "[critical: Partial outage]", render())
+
+	_, err = ctl.Set("foo", "fatal")
+	require.ErrorContains(t, err, "unrecognized banner severity")
+
+	_, err = ctl.Set(strings.Repeat("x", 513), "")
+	require.ErrorIs(t, err, error_page.ErrBannerTooLong)
+
+	_, err = ctl.Set("", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "[: ]", render())
+}
+
+func TestHandler_Maintenance(t *testing.T) {
+	t.Parallel()
+
+	var now = time.Now().UTC().Truncate(time.Second)
+
+	var newConfig = func(start, end time.Time) *config.Config {
+		var cfg = config.New()
+
+		w, err := config.NewMaintenanceWindow(start.Format(time.RFC3339), end.Format(time.RFC3339), 0, []string{"^/api/"}, nil)
+		require.NoError(t, err)
+
+		w.Message = "Back in {retry_after, plural, one {# second} other {a while}}"
+		w.Template = "maint"
+
+		cfg.Maintenance = config.MaintenanceWindows{w}
+		cfg.RespondWithSameHTTPCode = true
+		require.NoError(t, cfg.Templates.Add("maint", "maint {{ code }} [{{ maintenance_start }} - {{ maintenance_end }}] {{ description }}"))
+
+		return &cfg
+	}
+
+	var do = func(t *testing.T, cfg *config.Config, url string) (status int, body string, headers http.Header) {
+		t.Helper()
+
+		var handler, closeCache = error_page.New(cfg, logger.NewNop())
+		defer closeCache()
+
+		req, reqErr := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, reqErr)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(s int, b string, h http.Header) { status, body, headers = s, b, h })
+
+		return
+	}
+
+	t.Run("active", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			start, end         = now.Add(-time.Hour), now.Add(time.Hour)
+			status, body, hdrs = do(t, newConfig(start, end), "http://testing/api/404")
+		)
+
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "maint 503 ["+start.Format(time.RFC3339)+" - "+end.Format(time.RFC3339)+"] Back in a while", body)
+
+		retryAfter, err := strconv.Atoi(hdrs.Get("Retry-After"))
+		require.NoError(t, err)
+		assert.InDelta(t, 3600, retryAfter, 5)
+	})
+
+	t.Run("not matched path", func(t *testing.T) {
+		t.Parallel()
+
+		var status, body, _ = do(t, newConfig(now.Add(-time.Hour), now.Add(time.Hour)), "http://testing/404")
+
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.NotContains(t, body, "maint")
+	})
+
+	t.Run("ended", func(t *testing.T) {
+		t.Parallel()
+
+		var status, body, _ = do(t, newConfig(now.Add(-2*time.Hour), now.Add(-time.Hour)), "http://testing/api/404")
+
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.NotContains(t, body, "maint")
+	})
+}
+
+func TestHandler_UpstreamHealth(t *testing.T) {
+	t.Parallel()
+
+	var origin = stdHttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable) // always down
+	}))
+
+	t.Cleanup(origin.Close)
+
+	var do = func(t *testing.T, cfg *config.Config, url, accept string, opts ...error_page.Option) (body string) {
+		t.Helper()
+
+		var handler, closeCache = error_page.New(cfg, logger.NewNop(), opts...)
+		defer closeCache()
+
+		req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", accept)
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+		return body
+	}
+
+	t.Run("tokens", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			cfg   = config.New()
+			probe = upstream.NewProber(origin.URL, time.Minute, time.Second)
+			opt   = error_page.WithUpstreamProbe(probe)
+		)
+
+		cfg.Formats.PlainText = "{{ upstream_healthy }} [{{ upstream_checked_at }}]"
+
+		assert.Equal(t, "false []", do(t, &cfg, "http://testing/502", "text/plain"))      // no probe
+		assert.Equal(t, "false []", do(t, &cfg, "http://testing/502", "text/plain", opt)) // not checked yet
+
+		probe.Check(context.Background())
+
+		assert.Regexp(t, `^false \[\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z]$`, do(t, &cfg, "http://testing/502", "text/plain", opt))
+	})
+
+	t.Run("recovery script", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			cfg   = config.New()
+			probe = upstream.NewProber(origin.URL, time.Minute, time.Second)
+			opt   = error_page.WithUpstreamProbe(probe)
+		)
+
+		cfg.UpstreamHealth.RecoveryURL = "/errors/check"
+		cfg.DisableMinification = true
+		require.NoError(t, cfg.Templates.Add("foo", "<html><body>{{ code }}</BODY></html>"))
+		cfg.TemplateName = "foo"
+
+		assert.Equal(t, "<html><body>503</BODY></html>", do(t, &cfg, "http://testing/503", "text/html")) // no probe
+		assert.Equal(t, "<html><body>404</BODY></html>", do(t, &cfg, "http://testing/404", "text/html", opt))
+		assert.Regexp(t,
+			`^<html><body>503<script>\(\(\)=>\{const u="/errors/check",i=10000;.+fetch\(u,.+</script></BODY></html>$`,
+			do(t, &cfg, "http://testing/503", "text/html", opt),
+		)
+	})
+}
+
+func TestHandler_Shadow(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf bytes.Buffer
+		cfg = config.New()
+	)
+
+	log, logErr := logger.New(logger.InfoLevel, logger.JSONFormat, &buf)
+	require.NoError(t, logErr)
+
+	cfg.Shadow = true
+	cfg.TemplateName = "foo"
+	cfg.DisableMinification = true
+	require.NoError(t, cfg.Templates.Add("foo", "<p>{{ code }}</p>"))
+
+	var handler, closeCache = error_page.New(&cfg, log)
+	defer closeCache()
+
+	req, err := http.NewRequest(http.MethodGet, "http://testing/503", http.NoBody)
+	require.NoError(t, err)
+
+	req.Header.Set("Accept", "text/html")
+
+	for range 2 {
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusNoContent, status)
+			assert.Empty(t, body)
+		})
+	}
+
+	var lines []string
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, `"msg":"Shadow render"`) {
+			lines = append(lines, line)
+		}
+	}
+
+	require.Len(t, lines, 2)
+
+	for i, hit := range []string{"false", "true"} {
+		assert.Contains(t, lines[i], `"path":"/503","code":503,"format":"html","template":"foo","cache hit":`+hit+`,"size":10`)
+	}
+}
+
+func TestHandler_Signing(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Signing.Algorithm = config.SigningHMACSHA256
+	cfg.Signing.Key = "0123456789abcdef"
+	cfg.Formats.PlainText = "{{ code }}"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	var mac = hmac.New(sha256.New, []byte(cfg.Signing.Key))
+
+	_, _ = mac.Write([]byte("503"))
+
+	httptest.HandleFast(t, handler, http.MethodGet, "http://testing/503", http.NoBody, func(_ int, body string, headers http.Header) {
+		assert.Equal(t, "503", body)
+		assert.Equal(t, "hmac-sha256="+base64.StdEncoding.EncodeToString(mac.Sum(nil)), headers.Get(error_page.SignatureHeader))
+	})
+}
+
+func TestHandler_UnknownCodes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		buf bytes.Buffer
+		cfg = config.New()
+		reg = metrics.NewRegistry()
+	)
+
+	log, logErr := logger.New(logger.InfoLevel, logger.JSONFormat, &buf)
+	require.NoError(t, logErr)
+
+	cfg.Formats.PlainText = "{{ code }}"
+	cfg.UnknownCodeLogInterval = time.Hour
+
+	var handler, closeCache = error_page.New(&cfg, log, error_page.WithMetrics(reg))
+	defer closeCache()
+
+	for _, tt := range []struct {
+		url, xCode, wantBody string
+	}{
+		{url: "http://testing/", xCode: "0", wantBody: "404"}, // the invalid header is ignored (the default code)
+		{url: "http://testing/499", wantBody: "499"},
+		{url: "http://testing/", xCode: "520", wantBody: "520"},
+		{url: "http://testing/404", wantBody: "404"}, // known code
+	} {
+		req, reqErr := http.NewRequest(http.MethodGet, tt.url, http.NoBody)
+		require.NoError(t, reqErr)
+
+		if tt.xCode != "" {
+			req.Header.Set("X-Code", tt.xCode)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) {
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+
+	var requests = reg.Counter("error_pages_unknown_codes_total", "", "source")
+
+	assert.Equal(t, uint64(1), requests.Value("invalid-header"))
+	assert.Equal(t, uint64(1), requests.Value("url"))
+	assert.Equal(t, uint64(1), requests.Value("header"))
+
+	var lines []string
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, `"level":"warn"`) {
+			lines = append(lines, line)
+		}
+	}
+
+	require.Len(t, lines, 1) // the rest is suppressed by the rate limit
+	assert.Contains(t, lines[0], `"msg":"Invalid code header","source":"invalid-header","value":"\"0\""`)
+	assert.Contains(t, lines[0], `"path":"/","suppressed":0`)
+}
+
+func TestHandler_RequestHeaders(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		givePrecedence  config.CodePrecedence
+		giveURL         string
+		giveHeaders     [][2]string
+		wantStatus      int
+		wantBody        string
+		wantRejectedFor string
+	}{
+		"url wins": {
+			giveURL:     "http://testing/404",
+			giveHeaders: [][2]string{{"X-Code", "503"}},
+			wantStatus:  http.StatusOK,
+			wantBody:    "404",
+		},
+		"header wins": {
+			givePrecedence: config.CodePrecedenceHeader,
+			giveURL:        "http://testing/404",
+			giveHeaders:    [][2]string{{"X-Code", "503"}},
+			wantStatus:     http.StatusOK,
+			wantBody:       "503",
+		},
+		"conflict rejected": {
+			givePrecedence:  config.CodePrecedenceReject,
+			giveURL:         "http://testing/404",
+			giveHeaders:     [][2]string{{"X-Code", "503"}},
+			wantStatus:      http.StatusBadRequest,
+			wantBody:        "Bad Request\n",
+			wantRejectedFor: "code-conflict",
+		},
+		"same codes": {
+			givePrecedence: config.CodePrecedenceReject,
+			giveURL:        "http://testing/404",
+			giveHeaders:    [][2]string{{"X-Code", "404"}},
+			wantStatus:     http.StatusOK,
+			wantBody:       "404",
+		},
+		"duplicate headers": {
+			giveURL:         "http://testing/",
+			giveHeaders:     [][2]string{{"X-Code", "404"}, {"X-Code", "503"}},
+			wantStatus:      http.StatusBadRequest,
+			wantBody:        "Bad Request\n",
+			wantRejectedFor: "duplicate-header",
+		},
+		"oversized header": {
+			giveURL:         "http://testing/404",
+			giveHeaders:     [][2]string{{"X-Format", strings.Repeat("a", 65)}},
+			wantStatus:      http.StatusRequestHeaderFieldsTooLarge,
+			wantBody:        "Request Header Fields Too Large\n",
+			wantRejectedFor: "oversized-header",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			cfg.Formats.PlainText = "{{ code }}"
+			cfg.RequestHeaders.CodePrecedence = tt.givePrecedence
+			cfg.RequestHeaders.RejectDuplicates = true
+			cfg.RequestHeaders.MaxValueSize = 64
+
+			var (
+				reg                 = metrics.NewRegistry()
+				handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+			)
+
+			defer closeCache()
+
+			req, err := http.NewRequest(http.MethodGet, tt.giveURL, http.NoBody)
+			require.NoError(t, err)
+
+			for _, h := range tt.giveHeaders {
+				req.Header.Add(h[0], h[1])
+			}
+
+			httptest.HandleFastRequest(t, handler, req, func(status int, body string, _ http.Header) {
+				assert.Equal(t, tt.wantStatus, status)
+				assert.Equal(t, tt.wantBody, body)
+			})
+
+			if tt.wantRejectedFor != "" {
+				assert.Equal(t, uint64(1), reg.Counter("error_pages_rejected_requests_total", "", "reason").
+					Value(tt.wantRejectedFor))
+			}
+		})
+	}
+}
+
+func TestHandler_Streaming(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.TemplateName = "foo"
+	cfg.StreamThreshold = 32
+	require.NoError(t, cfg.Templates.Add("foo", "<html>\n  <body>{{ code }}: {{ message }}</body>\n</html>"))
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+	)
+
+	defer closeCache()
+
+	req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+	require.NoError(t, err)
+
+	req.Header.Set("Accept", "text/html")
+
+	for range 2 {
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "<html>\n  <body>404: Not Found</body>\n</html>", body) // not minified
+			assert.Contains(t, headers.Get("Content-Type"), "text/html")
+		})
+	}
+
+	// the streamed pages are not cached
+	assert.Equal(t, uint64(2), reg.Counter("error_pages_cache_requests_total", "", "tenant", "result").Value("", "miss"))
+}
+
+func TestHandler_TemplateOptions(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.TemplateName = "foo"
+	cfg.TemplateOptions = map[string]config.TemplateOptions{"foo": {DisableMinification: true, DisableCache: true}}
+	require.NoError(t, cfg.Templates.Add("foo", "<p>\n  {{ code }}\n</p>"))
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+	)
+
+	defer closeCache()
+
+	req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+	require.NoError(t, err)
+
+	req.Header.Set("Accept", "text/html")
+
+	for range 2 {
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) {
+			assert.Equal(t, "<p>\n  404\n</p>", body) // not minified
+		})
+	}
+
+	var requests = reg.Counter("error_pages_cache_requests_total", "", "tenant", "result")
+
+	assert.Zero(t, requests.Value("", "miss")) // the cache is not used at all
+	assert.Zero(t, requests.Value("", "hit"))
+}
+
+func TestHandler_ContentSecurityPolicy(t *testing.T) {
+	if error_page.SlimBuild {
+		t.Skip("the slim build does not minify the HTML")
+	}
+
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.TemplateName = "foo"
+	cfg.ContentSecurityPolicy = "script-src 'nonce-{nonce}'"
+	require.NoError(t, cfg.Templates.Add("foo", `<script nonce="{{ csp_nonce }}">{{ code }}</script>`))
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+		nonces              = make(map[string]struct{})
+		nonceRe             = regexp.MustCompile(`^script-src 'nonce-([A-Za-z0-9+/]{22}==)'$`)
+	)
+
+	defer closeCache()
+
+	req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+	require.NoError(t, err)
+
+	req.Header.Set("Accept", "text/html")
+
+	for range 3 {
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+			var m = nonceRe.FindStringSubmatch(headers.Get("Content-Security-Policy"))
+			require.Len(t, m, 2)
+
+			assert.Equal(t, `<script nonce="`+m[1]+`">404</script>`, body)
+			assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+
+			nonces[m[1]] = struct{}{}
+		})
+	}
+
+	assert.Len(t, nonces, 3) // a fresh nonce for every response
+	assert.Equal(t, uint64(2), reg.Counter("error_pages_cache_requests_total", "", "tenant", "result").Value("", "hit"))
+}
+
+func TestHandler_EarlyHints(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.TemplateName = "foo"
+	cfg.EarlyHints = true
+	cfg.TemplateOptions = map[string]config.TemplateOptions{"foo": {Preload: []string{
+		"</assets/app.css>; rel=preload; as=style",
+		"</assets/app.js>; rel=preload; as=script",
+	}}}
+	require.NoError(t, cfg.Templates.Add("foo", "foo {{ code }}"))
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+
+	defer closeCache()
+
+	var request = func(t *testing.T, path string) (hints []http.Header, links []string) {
+		t.Helper()
+
+		var trace = &httptrace.ClientTrace{Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, http.Header(header))
+			}
+
+			return nil
+		}}
+
+		req, err := http.NewRequestWithContext(
+			httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, "http://testing"+path, http.NoBody,
+		)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, _ string, headers http.Header) {
+			links = headers.Values("Link")
+		})
+
+		return hints, links
+	}
+
+	var wantLinks = []string{"</assets/app.css>; rel=preload; as=style", "</assets/app.js>; rel=preload; as=script"}
+
+	t.Run("cache miss", func(t *testing.T) {
+		hints, links := request(t, "/404")
+
+		require.Len(t, hints, 1)
+		assert.Equal(t, wantLinks, hints[0].Values("Link"))
+		assert.Equal(t, wantLinks, links)
+	})
+
+	t.Run("cache hit", func(t *testing.T) {
+		hints, links := request(t, "/404")
+
+		assert.Empty(t, hints) // the cached page is sent right away
+		assert.Equal(t, wantLinks, links)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var req, err = http.NewRequest(http.MethodGet, "http://testing/500", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "application/json")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, _ string, headers http.Header) {
+			assert.Empty(t, headers.Values("Link")) // the links are for the HTML pages only
+		})
+	})
+}
+
+func TestHandler_UnavailableUntilReady(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cfg       = config.New()
+		readiness error_page.Readiness
+	)
+
+	cfg.Formats.PlainText = "{{ code }}"
+	cfg.UnavailableUntilReady = true
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithReadiness(&readiness))
+	defer closeCache()
+
+	var do = func() (status int, body string, headers http.Header) {
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		httptest.HandleFastRequest(t, handler, req, func(s int, b string, h http.Header) { status, body, headers = s, b, h })
+
+		return
+	}
+
+	var warmUp, reload = readiness.Begin(), readiness.Begin()
+
+	assert.False(t, readiness.Ready())
+
+	status, body, headers := do()
+	assert.Equal(t, http.StatusServiceUnavailable, status) // even if the same HTTP code is not responded
+	assert.Equal(t, "503", body)
+	assert.Equal(t, "5", headers.Get("Retry-After"))
+
+	warmUp()
+	warmUp() // only the first call counts
+
+	assert.False(t, readiness.Ready())
+
+	reload()
+
+	assert.True(t, readiness.Ready())
+
+	status, body, headers = do()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "404", body)
+	assert.Empty(t, headers.Get("Retry-After"))
+
+	cfg.UnavailableUntilReady = false // the readiness is ignored
+
+	defer readiness.Begin()()
+
+	status, body, _ = do()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "404", body)
+}
+
+func TestHandler_UnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Formats.JSON, cfg.Formats.PlainText = "", "" // the JSON is requested, but both templates are empty
+
+	var do = func() (body string) {
+		var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+		defer closeCache()
+
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "application/json")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+		return
+	}
+
+	var body = do()
+	assert.Contains(t, body, "Error 404: Not Found")
+	assert.Contains(t, body, "The requested content format is not supported")
+	assert.NotContains(t, body, "GitHub")
+
+	cfg.Formats.Unsupported = "{{ code }}: {{ message }} (not supported)"
+	assert.Equal(t, "404: Not Found (not supported)", do())
+
+	cfg.Formats.Unsupported = ""
+	assert.Equal(t, "404: Not Found\n", do()) // the minimal content
+}
+
+func TestHandler_DefaultFormat(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Formats.JSON = `{"code": {{ code }}}`
+	cfg.DefaultFormat = config.FormatJSON
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	for accept, want := range map[string]string{
+		"":                 `{"code": 404}`, // no header
+		"*/*":              `{"code": 404}`,
+		"image/webp":       `{"code": 404}`, // not supported
+		"text/plain":       "Error 404: Not Found",
+		"application/json": `{"code": 404}`,
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+			assert.Contains(t, body, want, accept)
+
+			if strings.HasPrefix(want, "{") {
+				assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"), accept)
+			}
+		})
+	}
+}
+
+func TestHandler_FormatRules(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Formats.JSON = `{"code": {{ code }}}`
+
+	for _, raw := range []string{`^kube-probe/=plaintext`, `Go-http-client=json`} {
+		rule, err := config.ParseFormatRule(raw)
+		require.NoError(t, err)
+
+		cfg.FormatRules = append(cfg.FormatRules, rule)
+	}
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	for name, tt := range map[string]struct {
+		giveUserAgent, giveAccept string
+		wantContentType           string
+	}{
+		"probe asks for html": {"kube-probe/1.29", "text/html", "text/plain; charset=utf-8"},
+		"sdk":                 {"Go-http-client/2.0", "", "application/json; charset=utf-8"},
+		"browser":             {"Mozilla/5.0", "text/html", "text/html; charset=utf-8"},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("User-Agent", tt.giveUserAgent)
+
+		if tt.giveAccept != "" {
+			req.Header.Set("Accept", tt.giveAccept)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, _ string, headers http.Header) {
+			assert.Equal(t, tt.wantContentType, headers.Get("Content-Type"), name)
+		})
+	}
+}
+
+func TestHandler_OriginalStatus(t *testing.T) {
+	t.Parallel()
+
+	var do = func(cfg *config.Config, accept, originalStatus string) (body string) {
+		var handler, closeCache = error_page.New(cfg, logger.NewNop())
+		defer closeCache()
+
+		req, err := http.NewRequest(http.MethodGet, "http://testing/503", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", accept)
+
+		if originalStatus != "" {
+			req.Header.Set("X-Original-Status", originalStatus)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+		return
+	}
+
+	var cfg = config.New()
+
+	assert.NotContains(t, do(&cfg, "application/json", "502"), "original_status") // disabled by default
+
+	cfg.ShowOriginalStatus = true
+
+	assert.Contains(t, do(&cfg, "application/json", "502"), `"original_status": 502`)
+	assert.Contains(t, do(&cfg, "application/xml", "502"), "<originalStatus>502</originalStatus>")
+	assert.NotContains(t, do(&cfg, "application/json", ""), "original_status")
+	assert.NotContains(t, do(&cfg, "application/json", "foo"), "original_status")
+
+	cfg.Formats.JSON, _ = config.JSONSchemaV2.Format()
+
+	assert.Contains(t, do(&cfg, "application/json", "502"), `"original_status": 502`)
+
+	cfg.Formats.PlainText = "{{ code }} ({{ original_status }})" // the token is available regardless of the option
+	cfg.ShowOriginalStatus = false
+
+	assert.Equal(t, "503 (504)", do(&cfg, "text/plain", "504"))
+	assert.Equal(t, "503 (0)", do(&cfg, "text/plain", "1234"))
+}
+
+func TestHandler_ErrorDetails(t *testing.T) {
+	t.Parallel()
+
+	var do = func(cfg *config.Config, accept string, details ...string) (body string) {
+		var handler, closeCache = error_page.New(cfg, logger.NewNop())
+		defer closeCache()
+
+		req, err := http.NewRequest(http.MethodGet, "http://testing/422", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", accept)
+
+		for _, d := range details {
+			req.Header.Add("X-Error-Detail", d)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+		return
+	}
+
+	var cfg = config.New()
+
+	var body = do(&cfg, "application/json", `{"field": "email", "message": "is <invalid>"}`, "quota exceeded")
+
+	var payload struct {
+		ErrorDetails []map[string]string `json:"error_details"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(body), &payload), body)
+	assert.Equal(t, []map[string]string{{"field": "email", "message": "is <invalid>"}, {"message": "quota exceeded"}},
+		payload.ErrorDetails)
+
+	assert.NotContains(t, do(&cfg, "application/json"), "error_details")
+
+	body = do(&cfg, "application/xml", `{"field": "e\"mail", "message": "is <invalid>"}`)
+	assert.Contains(t, body, `<detail field="e&#34;mail">is &lt;invalid&gt;</detail>`)
+	assert.NotContains(t, do(&cfg, "application/xml"), "errorDetails")
+
+	cfg.Formats.JSON, _ = config.JSONSchemaV2.Format()
+
+	var v2 struct {
+		Error struct {
+			Details []map[string]string `json:"details"`
+		} `json:"error"`
+	}
+
+	body = do(&cfg, "application/json", "quota exceeded")
+
+	require.NoError(t, json.Unmarshal([]byte(body), &v2), body)
+	assert.Equal(t, []map[string]string{{"message": "quota exceeded"}}, v2.Error.Details)
+}
+
+func TestHandler_GRPCWeb(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.RespondWithSameHTTPCode = true
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	for name, tt := range map[string]struct {
+		giveURL, giveContentType string
+		wantContentType          string
+		wantBody                 []byte
+	}{
+		"proto": {
+			giveURL:         "http://testing/503",
+			giveContentType: "application/grpc-web+proto",
+			wantContentType: "application/grpc-web+proto",
+			wantBody:        append([]byte{0x80, 0, 0, 0, 50}, "grpc-status:14\r\ngrpc-message:Service Unavailable\r\n"...),
+		},
+		"json": {
+			giveURL:         "http://testing/404",
+			giveContentType: "application/grpc-web+json",
+			wantContentType: "application/grpc-web+json",
+			wantBody:        append([]byte{0x80, 0, 0, 0, 40}, "grpc-status:12\r\ngrpc-message:Not Found\r\n"...),
+		},
+		"text": {
+			giveURL:         "http://testing/404",
+			giveContentType: "application/grpc-web-text",
+			wantContentType: "application/grpc-web-text",
+			wantBody: []byte(base64.StdEncoding.EncodeToString(
+				append([]byte{0x80, 0, 0, 0, 40}, "grpc-status:12\r\ngrpc-message:Not Found\r\n"...),
+			)),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodPost, tt.giveURL, http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tt.giveContentType)
+
+			httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+				assert.Equal(t, http.StatusOK, status) // the gRPC status is in the trailers
+				assert.Equal(t, tt.wantContentType, headers.Get("Content-Type"))
+				assert.Equal(t, string(tt.wantBody), body)
+				assert.NotEmpty(t, headers.Get("Grpc-Status"))
+				assert.NotEmpty(t, headers.Get("Grpc-Message"))
+			})
+		})
+	}
+}
+
+func TestHandler_WebSocketUpgrade(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New() // the same HTTP code is not responded by default
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+	)
+
+	defer closeCache()
+
+	req, err := http.NewRequest(http.MethodGet, "http://testing/503", http.NoBody)
+	require.NoError(t, err)
+
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+	httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "503: Service Unavailable\n", body)
+		assert.True(t, strings.HasPrefix(headers.Get("Content-Type"), "text/plain"))
+		assert.Empty(t, headers.Get("Sec-WebSocket-Accept"))
+	})
+
+	assert.Equal(t, uint64(1), reg.Counter("error_pages_websocket_rejected_total", "", "code").Value("503"))
+}
+
+func TestHandler_CacheShrink(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Memory.CacheShrinkAt = 1 // MiB, the heap of the tests is always larger
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+		shrinks             = reg.Counter("error_pages_cache_shrinks_total", "")
+	)
+
+	defer closeCache()
+
+	httptest.HandleFast(t, handler, http.MethodGet, "http://testing/404", http.NoBody,
+		func(status int, _ string, _ http.Header) { assert.Equal(t, http.StatusOK, status) },
+	)
+
+	assert.Eventually(t, func() bool { return shrinks.Value() > 0 }, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestHandler_PlainTextOutput(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Formats.PlainText = "{{ code }}: {{ message }}\n\x1b[31m{{ description }}"
+	cfg.Codes["404"] = config.CodeDescription{Message: "Страница не найдена", Description: "找不到页面\u200b"}
+	cfg.PlainTextOutput.MaxLineWidth = 12
+	cfg.PlainTextOutput.Normalization = config.TextNormalizationSafe
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+
+	defer closeCache()
+
+	for range 2 { // the second response is cached
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/404", http.NoBody,
+			func(status int, body string, _ http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Equal(t, "404: Страни…\n[31m找不到…", body) // the CJK characters take two columns
+			},
+		)
+	}
+}
+
+func TestHandler_Charset(t *testing.T) {
+	if error_page.SlimBuild {
+		t.Skip("the slim build does not support the multibyte charsets")
+	}
+
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Charset = "gbk"
+	cfg.Templates = map[string]string{"foo": `<meta charset="{{ charset }}"><p>{{ message }} 😀</p>`}
+	cfg.TemplateName = "foo"
+	cfg.DisableMinification = true
+	cfg.Formats.PlainText = "{{ charset }}: {{ message }} 😀"
+	cfg.Codes["404"] = config.CodeDescription{Message: "页面未找到"}
+	cfg.L10n.Disable = true
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+
+	defer closeCache()
+
+	for name, tc := range map[string]struct {
+		giveAccept      string
+		wantContentType string
+		wantBody        string
+	}{
+		"html": {
+			giveAccept:      "text/html",
+			wantContentType: "text/html; charset=GBK",
+			wantBody:        "<meta charset=\"GBK\"><p>\xd2\xb3\xc3\xe6\xce\xb4\xd5\xd2\xb5\xbd &#128512;</p>",
+		},
+		"plain text": {
+			giveAccept:      "text/plain",
+			wantContentType: "text/plain; charset=GBK",
+			wantBody:        "GBK: \xd2\xb3\xc3\xe6\xce\xb4\xd5\xd2\xb5\xbd ?",
+		},
+		"json": {
+			giveAccept:      "application/json",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        "页面未找到",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Accept", tc.giveAccept)
+
+			for range 2 { // the second response is cached (in UTF-8)
+				httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+					assert.Equal(t, http.StatusOK, status)
+					assert.Equal(t, tc.wantContentType, headers.Get("Content-Type"))
+					assert.Contains(t, body, tc.wantBody)
+				})
+			}
+		})
+	}
+}
+
+func TestHandler_TemplateHeaders(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.TemplateHeaders = []string{"X-Tenant"}
+	cfg.Formats.PlainText = `{{ code }} for [{{ header "X-Tenant" }}]`
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+
+	defer closeCache()
+
+	for _, tenant := range []string{"acme", "globex", "acme", ""} { // the pages are cached per the header value
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("X-Tenant", tenant)
+
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Contains(t, headers.Values("Vary"), "X-Tenant")
+			assert.Equal(t, "404 for ["+tenant+"]", body)
+		})
+	}
+}
+
+func TestHandler_UpstreamDuration(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Formats.PlainText = "{{ code }}{{ if upstream_duration }} after {{ upstream_duration }}s{{ end }}"
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+	)
+
+	defer closeCache()
+
+	for duration, want := range map[string]string{
+		"30.004":        "504 after 30.0s",
+		"0.002, 30.001": "504 after 30.0s",
+		"":              "504",
+		"foo":           "504",
+	} {
+		req, reqErr := http.NewRequest(http.MethodGet, "http://testing/504", http.NoBody)
+		require.NoError(t, reqErr)
+
+		if duration != "" {
+			req.Header.Set("X-Upstream-Duration", duration)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, want, body)
+		})
+	}
+
+	var durations = reg.Counter("error_pages_upstream_duration_total", "", "code", "duration")
+
+	assert.Equal(t, uint64(2), durations.Value("504", "30-60s"))
+	assert.Equal(t, uint64(0), durations.Value("504", "0-1s"))
+}
+
+func TestHandler_Fragment(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveConfig   func(*config.Config)
+		giveUrl      string
+		wantBody     string
+		wantHeaders  map[string]string
+		wantIncludes []string
+	}{
+		"default": {
+			giveUrl: "http://testing/fragment/404?lang=de",
+			wantHeaders: map[string]string{
+				"Content-Type":      "text/html; charset=utf-8",
+				"Cache-Control":     "public, max-age=60",
+				"Surrogate-Control": "max-age=60",
+			},
+			wantIncludes: []string{`<div class="error-page" data-code="404"`, `lang="de"`, "Nicht gefunden"},
+		},
+		"custom": {
+			giveConfig: func(cfg *config.Config) { cfg.Formats.Fragment = `<p>{{ code }} {{ message }}</p>` },
+			giveUrl:    "http://testing/fragment/503.html",
+			wantBody:   "<p>503 Service Unavailable</p>",
+		},
+		"empty": {
+			giveConfig: func(cfg *config.Config) { cfg.Formats.Fragment = "" },
+			giveUrl:    "http://testing/fragment/500",
+			wantBody:   "<div class=\"error-page\" data-code=\"500\"><h1>500: Internal Server Error</h1></div>\n",
+		},
+		"with details": {
+			giveConfig:   func(cfg *config.Config) { cfg.ShowDetails = true },
+			giveUrl:      "http://testing/fragment/404",
+			wantHeaders:  map[string]string{"Cache-Control": "private, no-store", "Surrogate-Control": "no-store"},
+			wantIncludes: []string{"Request ID"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			cfg.RespondWithSameHTTPCode = true
+
+			if tt.giveConfig != nil {
+				tt.giveConfig(&cfg)
+			}
+
+			var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+			defer closeCache()
+
+			req, err := http.NewRequest(http.MethodGet, tt.giveUrl, http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Accept", "application/json") // the fragment is always HTML
+
+			httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.NotContains(t, body, "<html")
+
+				if tt.wantBody != "" {
+					assert.Equal(t, tt.wantBody, body)
+				}
+
+				for _, want := range tt.wantIncludes {
+					assert.Contains(t, body, want)
+				}
+
+				for key, want := range tt.wantHeaders {
+					assert.Equal(t, want, headers.Get(key))
+				}
+			})
+		})
+	}
+}
+
+func TestHandler_ESI(t *testing.T) {
+	if error_page.SlimBuild {
+		t.Skip("the slim build does not minify the HTML")
+	}
+
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.ESI = true
+	cfg.Templates = map[string]string{"foo": `<html><body>  {{ esiInclude "/fragment/503" }}  </body></html>`}
+	cfg.TemplateName = "foo"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	for url, want := range map[string]string{
+		"http://testing/503":          `content="ESI/1.0"`,
+		"http://testing/fragment/503": `max-age=60, content="ESI/1.0"`,
+	} {
+		req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+			assert.Equal(t, want, headers.Get("Surrogate-Control"))
+
+			if url == "http://testing/503" { // the tag is kept self-closed by the minifier
+				assert.Equal(t, `<html><body><esi:include src="/fragment/503" onerror="continue"/></body></html>`, body)
+			}
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://testing/503", http.NoBody)
+	require.NoError(t, err)
+
+	req.Header.Set("Accept", "application/json")
+
+	httptest.HandleFastRequest(t, handler, req, func(_ int, _ string, headers http.Header) {
+		assert.Empty(t, headers.Get("Surrogate-Control")) // only the HTML responses are processed
+	})
+}
+
+func TestHandler_Compression(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveConfig         func(*config.Config)
+		giveAcceptEncoding string
+		giveAccept         string
+		wantEncoding       string
+		wantVary           bool
+	}{
+		"gzip": {
+			giveAcceptEncoding: "gzip, deflate",
+			wantEncoding:       "gzip",
+			wantVary:           true,
+		},
+		"brotli is preferred": {
+			giveAcceptEncoding: "gzip, deflate, br",
+			wantEncoding:       "br",
+			wantVary:           true,
+		},
+		"quality values": {
+			giveAcceptEncoding: "br;q=0.5, gzip;q=0.8",
+			wantEncoding:       "gzip",
+			wantVary:           true,
+		},
+		"not acceptable": {
+			giveAcceptEncoding: "br;q=0, gzip;q=0",
+			wantVary:           true,
+		},
+		"wildcard": {
+			giveAcceptEncoding: "gzip;q=0, *",
+			wantEncoding:       "br",
+			wantVary:           true,
+		},
+		"identity": {
+			giveAcceptEncoding: "identity",
+			wantVary:           true,
+		},
+		"json": {
+			giveConfig: func(cfg *config.Config) {
+				cfg.Formats.JSON = `{"code": {{ code }}, "padding": "` + strings.Repeat("-", 512) + `"}`
+			},
+			giveAcceptEncoding: "gzip",
+			giveAccept:         "application/json",
+			wantEncoding:       "gzip",
+			wantVary:           true,
+		},
+		"too small to compress": {
+			giveConfig:         func(cfg *config.Config) { cfg.Formats.PlainText = "{{ code }}" },
+			giveAcceptEncoding: "gzip",
+			giveAccept:         "text/plain",
+		},
+		"disabled": {
+			giveConfig:         func(cfg *config.Config) { cfg.DisableCompression = true },
+			giveAcceptEncoding: "gzip, br",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			if tt.giveConfig != nil {
+				tt.giveConfig(&cfg)
+			}
+
+			var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+			defer closeCache()
+
+			var plain string // the uncompressed body
+
+			for _, acceptEncoding := range []string{"", tt.giveAcceptEncoding, tt.giveAcceptEncoding} { // cached twice
+				req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+				require.NoError(t, err)
+
+				req.Header.Set("Accept", "text/html")
+				req.Header.Set("Accept-Encoding", acceptEncoding)
+
+				if tt.giveAccept != "" {
+					req.Header.Set("Accept", tt.giveAccept)
+				}
+
+				if acceptEncoding == "" {
+					req.Header.Set("Accept-Encoding", "identity") // otherwise, the client adds its own
+				}
+
+				httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+					assert.Equal(t, http.StatusOK, status)
+
+					if acceptEncoding == "" {
+						plain = body
+
+						return
+					}
+
+					assert.Equal(t, tt.wantEncoding, headers.Get("Content-Encoding"))
+					assert.Equal(t, tt.wantVary, slices.Contains(headers.Values("Vary"), "Accept-Encoding"))
+
+					var decoded []byte
+
+					switch tt.wantEncoding {
+					case "gzip":
+						decoded, err = fasthttp.AppendGunzipBytes(nil, []byte(body))
+					case "br":
+						decoded, err = fasthttp.AppendUnbrotliBytes(nil, []byte(body))
+					default:
+						decoded = []byte(body)
+					}
+
+					require.NoError(t, err)
+					assert.Equal(t, plain, string(decoded))
+					assert.NotEmpty(t, plain)
+				})
+			}
+		})
+	}
+}
+
+func TestHandler_DetailsAccess(t *testing.T) {
+	t.Parallel()
+
+	var (
+		office   = []netip.Prefix{netip.MustParsePrefix("10.8.0.0/16")}
+		internal = net.ParseIP("10.8.1.2")
+		external = net.ParseIP("203.0.113.1")
+	)
+
+	// the WASM hook allowing the inputs starting with "10." (the client IP is on the first line), the module is:
+	//
+	//	(func (export "alloc") (param i32) (result i32) (i32.const 1024))
+	//	(func (export "allow") (param $ptr i32) (param $len i32) (result i32)
+	//	  (i32.and (i32.ge_u (local.get $len) (i32.const 3))
+	//	    (i32.eq (i32.and (i32.load (local.get $ptr)) (i32.const 0xffffff)) (i32.const 0x2e3031))))
+	tenNet, hookErr := wasmhook.Compile([]byte("\x00asm\x01\x00\x00\x00\x01\x0c\x02\x60\x01\x7f\x01\x7f\x60\x02\x7f\x7f" +
+		"\x01\x7f\x03\x03\x02\x00\x01\x05\x03\x01\x00\x01\x07\x1a\x03\x06memory\x02\x00\x05alloc\x00\x00\x05allow" +
+		"\x00\x01\x0a\x21\x02\x05\x00\x41\x80\x08\x0b\x19\x00\x20\x01\x41\x03\x4f\x20\x00\x28\x02\x00\x41\xff\xff" +
+		"\xff\x07\x71\x41\xb1\xe0\xb8\x01\x46\x71\x0b"))
+	require.NoError(t, hookErr)
+
+	for name, tt := range map[string]struct {
+		giveAccess  config.DetailsAccess
+		giveOptions []error_page.Option
+		giveIP      net.IP
+		giveHeaders map[string]string
+		wantDetails bool
+	}{
+		"not restricted": {
+			giveIP:      external,
+			wantDetails: true,
+		},
+		"network granted": {
+			giveAccess:  config.DetailsAccess{Networks: office},
+			giveIP:      internal,
+			wantDetails: true,
+		},
+		"network denied": {
+			giveAccess: config.DetailsAccess{Networks: office},
+			giveIP:     external,
+		},
+		"token granted": {
+			giveAccess:  config.DetailsAccess{Networks: office, Header: "X-Token", Token: "s3cr3t"},
+			giveIP:      external,
+			giveHeaders: map[string]string{"X-Token": "s3cr3t"},
+			wantDetails: true,
+		},
+		"wrong token": {
+			giveAccess:  config.DetailsAccess{Header: "X-Token", Token: "s3cr3t"},
+			giveIP:      internal,
+			giveHeaders: map[string]string{"X-Token": "s3cr3"},
+		},
+		"wasm hook granted": {
+			giveAccess:  config.DetailsAccess{Hook: tenNet},
+			giveIP:      internal,
+			wantDetails: true,
+		},
+		"wasm hook denied": {
+			giveAccess: config.DetailsAccess{Hook: tenNet},
+			giveIP:     external,
+		},
+		"token granted before wasm hook": {
+			giveAccess:  config.DetailsAccess{Header: "X-Token", Token: "s3cr3t", Hook: tenNet},
+			giveIP:      external,
+			giveHeaders: map[string]string{"X-Token": "s3cr3t"},
+			wantDetails: true,
+		},
+		"custom predicate": {
+			giveAccess: config.DetailsAccess{Networks: office},
+			giveOptions: []error_page.Option{error_page.WithDetailsPredicate(func(ctx *fasthttp.RequestCtx) bool {
+				return string(ctx.Request.Header.Peek("X-Employee")) == "yes"
+			})},
+			giveIP:      external,
+			giveHeaders: map[string]string{"X-Employee": "yes"},
+			wantDetails: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			cfg.ShowDetails, cfg.DetailsAccess = true, tt.giveAccess
+
+			var handler, closeCache = error_page.New(&cfg, logger.NewNop(), tt.giveOptions...)
+			defer closeCache()
+
+			var (
+				req fasthttp.Request
+				ctx fasthttp.RequestCtx
+			)
+
+			req.SetRequestURI("http://testing/503")
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("X-Request-ID", "secret-request-id")
+
+			for key, value := range tt.giveHeaders {
+				req.Header.Set(key, value)
+			}
+
+			ctx.Init(&req, &net.TCPAddr{IP: tt.giveIP, Port: 12345}, nil)
+
+			handler(&ctx)
+
+			if tt.wantDetails {
+				assert.Contains(t, string(ctx.Response.Body()), "secret-request-id")
+			} else {
+				assert.NotContains(t, string(ctx.Response.Body()), "secret-request-id")
+			}
+
+			// the pages with the restricted details must not be reused by the shared caches
+			if tt.wantDetails && (tt.giveAccess.Restricted() || tt.giveOptions != nil) {
+				assert.Equal(t, "private, no-store", string(ctx.Response.Header.Peek("Cache-Control")))
+			} else {
+				assert.Empty(t, ctx.Response.Header.Peek("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestHandler_Watermark(t *testing.T) {
+	t.Parallel()
+
+	var (
+		fake = clock.NewFake(time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC))
+		cfg  = config.New()
+	)
+
+	cfg.Templates = map[string]string{"foo": `<html><body><h1>{{ code }}</h1></body></html>`}
+	cfg.TemplateName = "foo"
+	cfg.ContentSecurityPolicy = "style-src 'nonce-{nonce}'"
+	cfg.Watermark.Mode, cfg.Watermark.InstanceID = config.WatermarkModeFooter, "eu-<1>"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithClock(fake))
+	defer closeCache()
+
+	var nonceRe = regexp.MustCompile(`'nonce-([^']+)'`)
+
+	for _, requestID := range []string{"first", "second"} { // the second page is taken from the cache
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+		req.Header.Set("X-Request-Id", requestID)
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+			var nonce = nonceRe.FindStringSubmatch(headers.Get("Content-Security-Policy"))
+			require.Len(t, nonce, 2)
+
+			assert.Regexp(t, `^<html><body><h1>404</h1><style nonce="`+regexp.QuoteMeta(nonce[1])+`">`+
+				`\.ep-watermark\{[^}]+\}</style><div class="ep-watermark" aria-hidden="true">eu-&lt;1&gt; &middot; `+
+				fake.Now().Format(time.RFC3339)+` &middot; [^<]+-`+requestID+`</div></body></html>$`, body)
+		})
+
+		fake.Advance(time.Second)
+	}
+
+	t.Run("not html", func(t *testing.T) {
+		for _, url := range []string{"http://testing/404", "http://testing/fragment/404"} {
+			req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+			require.NoError(t, err)
+
+			if !strings.Contains(url, "fragment") {
+				req.Header.Set("Accept", "application/json")
+			}
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) {
+				assert.NotContains(t, body, "ep-watermark")
+			})
+		}
+	})
+}
+
+func TestHandler_LiveStatus(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Templates = map[string]string{"foo": `<html><body>{{ if live_status }}live{{ end }}</body></html>`}
+	cfg.TemplateName = "foo"
+	cfg.ContentSecurityPolicy = "script-src 'nonce-{nonce}'"
+	cfg.LiveStatus.URL = "/errors/status.json"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	for range 2 { // the second page is taken from the cache
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+			var nonce = regexp.MustCompile(`'nonce-([^']+)'`).FindStringSubmatch(headers.Get("Content-Security-Policy"))
+			require.Len(t, nonce, 2)
+
+			assert.True(t, strings.HasPrefix(body, `<html><body>live<script nonce="`+nonce[1]+`">`), body)
+			assert.Contains(t, body, `"/errors/status.json"`)
+			assert.Contains(t, body, `"`+error_page.LiveStatusEvent+`"`)
+			assert.True(t, strings.HasSuffix(body, "</script></body></html>"), body)
+		})
+	}
+}
+
+func TestHandler_OGImage(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Templates = map[string]string{"foo": `image: [{{ og_image }}]`}
+	cfg.TemplateName = "foo"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	var render = func(t *testing.T, url string) (body string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+		return body
+	}
+
+	assert.Equal(t, `image: []`, render(t, "http://testing/404"))
+
+	cfg.OGImageBaseURL = "https://errors.example.com/pages"
+
+	handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	assert.Equal(t, `image: [https://errors.example.com/pages/og/503.png]`, render(t, "http://testing/503"))
+}
+
+func TestHandler_ProxyMode(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveProxy config.Proxy
+		wantBody  string
+	}{
+		"generic": {wantBody: "[/generic?a=b] [203.0.113.7, 10.0.0.1]"},
+		"traefik": {giveProxy: config.Proxy{Mode: config.ProxyModeTraefik}, wantBody: "[/traefik] [203.0.113.7, 10.0.0.1]"},
+		"override": {
+			giveProxy: config.Proxy{OriginalURI: "X-Request-Uri", ForwardedFor: "X-Chain"},
+			wantBody:  "[/own] [a, b]",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg = config.New()
+
+			cfg.Templates = map[string]string{"foo": `[{{ original_uri }}] [{{ forwarded_for }}]`}
+			cfg.TemplateName = "foo"
+			cfg.ShowDetails = true
+			cfg.Proxy = tt.giveProxy
+
+			var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+			defer closeCache()
+
+			req, err := http.NewRequest(http.MethodGet, "http://testing/404.html", http.NoBody)
+			require.NoError(t, err)
+
+			for header, value := range map[string]string{
+				"Accept":          "text/html",
+				"X-Code":          "404",
+				"X-Original-URI":  "/generic?a=b",
+				"X-Forwarded-Uri": "/traefik",
+				"X-Forwarded-For": "203.0.113.7, 10.0.0.1",
+				"X-Request-Uri":   "/own",
+				"X-Chain":         "a, b",
+			} {
+				req.Header.Set(header, value)
+			}
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) {
+				assert.Equal(t, tt.wantBody, body)
+			})
+		})
+	}
+}
+
+func TestHandler_FeatureFlags(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Templates = map[string]string{
+		"foo": `foo: {{ if flagOn "new_copy" }}new{{ else }}old{{ end }} [{{ flag "everyone" }}] [{{ flag "nobody" }}]`,
+		"bar": `bar: {{ flag "theme" }}`,
+	}
+	cfg.TemplateName = "foo"
+
+	var (
+		store  = featureflag.New(map[string]string{"new_copy": "on", "everyone": "100%", "nobody": "0%"}, time.Minute)
+		render = func(t *testing.T, handler fasthttp.RequestHandler) (body string) {
+			t.Helper()
+
+			req, err := http.NewRequest(http.MethodGet, "http://testing/404.html", http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Accept", "text/html")
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, b string, _ http.Header) { body = b })
+
+			return body
+		}
+	)
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithFeatureFlags(store))
+	defer closeCache()
+
+	assert.Equal(t, "foo: new [true] [false]", render(t, handler))
+
+	// the template is switched by the flag (the unknown templates are ignored)
+	var switched = featureflag.New(map[string]string{"template": "bar", "theme": "dark"}, time.Minute)
+
+	handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithFeatureFlags(switched))
+	defer closeCache()
+
+	assert.Equal(t, "bar: dark", render(t, handler))
+
+	handler, closeCache = error_page.New(&cfg, logger.NewNop(),
+		error_page.WithFeatureFlags(featureflag.New(map[string]string{"template": "baz"}, time.Minute)),
+	)
+	defer closeCache()
+
+	assert.Equal(t, "foo: old [] []", render(t, handler))
+}
+
+func TestHandler_ConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Templates = map[string]string{"foo": `{{ code }}: {{ message }} ` + strings.Repeat("lorem ipsum ", 50)}
+	cfg.TemplateName = "foo"
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	var do = func(t *testing.T, h fasthttp.RequestHandler, headers map[string]string) (int, string, http.Header) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, "http://testing/404.html", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+		req.Header.Set("Accept-Encoding", "identity") // the client adds gzip otherwise
+
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		var (
+			status int
+			body   string
+			hdrs   http.Header
+		)
+
+		httptest.HandleFastRequest(t, h, req, func(c int, b string, h http.Header) { status, body, hdrs = c, b, h })
+
+		return status, body, hdrs
+	}
+
+	status, body, headers := do(t, handler, nil) // rendered and cached
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, body)
+
+	var etag, lastModified = headers.Get("ETag"), headers.Get("Last-Modified")
+
+	assert.Regexp(t, `^"[0-9a-f]{16}"$`, etag)
+	assert.NotEmpty(t, lastModified)
+
+	status, body, headers = do(t, handler, nil) // from the cache, the same validators
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, body)
+	assert.Equal(t, etag, headers.Get("ETag"))
+
+	for name, tt := range map[string]struct {
+		giveHeaders map[string]string
+		wantStatus  int
+	}{
+		"matching tag": {giveHeaders: map[string]string{"If-None-Match": etag}, wantStatus: http.StatusNotModified},
+		"weak tag in a list": {
+			giveHeaders: map[string]string{"If-None-Match": `"foo", W/` + etag},
+			wantStatus:  http.StatusNotModified,
+		},
+		"any tag":   {giveHeaders: map[string]string{"If-None-Match": "*"}, wantStatus: http.StatusNotModified},
+		"other tag": {giveHeaders: map[string]string{"If-None-Match": `"foo"`}, wantStatus: http.StatusOK},
+		"not modified since": {
+			giveHeaders: map[string]string{"If-Modified-Since": lastModified},
+			wantStatus:  http.StatusNotModified,
+		},
+		"modified since": {
+			giveHeaders: map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"},
+			wantStatus:  http.StatusOK,
+		},
+		"tag takes precedence": {
+			giveHeaders: map[string]string{"If-None-Match": `"foo"`, "If-Modified-Since": lastModified},
+			wantStatus:  http.StatusOK,
+		},
+		"compressed": { // another encoding means another tag
+			giveHeaders: map[string]string{"If-None-Match": etag, "Accept-Encoding": "gzip"},
+			wantStatus:  http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, body, headers := do(t, handler, tt.giveHeaders)
+
+			assert.Equal(t, tt.wantStatus, status)
+			assert.NotEmpty(t, headers.Get("ETag"))
+
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Empty(t, body)
+			} else {
+				assert.NotEmpty(t, body)
+			}
+		})
+	}
+
+	_, _, headers = do(t, handler, map[string]string{"Accept-Encoding": "gzip"})
+
+	var gzipped = headers.Get("ETag")
+
+	assert.Equal(t, strings.TrimSuffix(etag, `"`)+`-gzip"`, gzipped)
+
+	status, _, _ = do(t, handler, map[string]string{"If-None-Match": gzipped, "Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusNotModified, status)
+
+	// the error status codes are always sent with the page
+	cfg.RespondWithSameHTTPCode = true
+
+	handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	_, _, headers = do(t, handler, nil)
+
+	status, body, _ = do(t, handler, map[string]string{"If-None-Match": headers.Get("ETag")})
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.NotEmpty(t, body)
+}
+
+func TestHandler_BodyLimits(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Formats.JSON = `{"code": {{ code }}, "description": {{ description | json }}}`
+	cfg.Formats.PlainText = `{{ code }}: {{ description }}`
+	cfg.Codes["404"] = config.CodeDescription{Message: "Not Found", Description: strings.Repeat("reflected ", 100)}
+	cfg.BodyLimits = config.BodyLimits{JSON: 256, PlainText: 128}
+
+	var handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	defer closeCache()
+
+	for range 2 { // the second body is taken from the cache
+		for accept, check := range map[string]func(t *testing.T, body string){
+			"application/json": func(t *testing.T, body string) {
+				var decoded struct{ Description string }
+
+				require.NoError(t, json.Unmarshal([]byte(body), &decoded), body)
+				assert.True(t, strings.HasSuffix(decoded.Description, "…"))
+				assert.LessOrEqual(t, len(body), 256)
+			},
+			"text/plain": func(t *testing.T, body string) {
+				assert.True(t, strings.HasSuffix(body, "\n[truncated]\n"))
+				assert.LessOrEqual(t, len(body), 128)
+			},
+		} {
+			req, err := http.NewRequest(http.MethodGet, "http://testing/404", http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Accept", accept)
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) { check(t, body) })
+		}
+	}
+}
+
+func TestRotationModeOnEachRequest(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.RotationMode = config.RotationModeRandomOnEachRequest
+	cfg.Templates = map[string]string{
+		"foo": "foo",
+		"bar": "bar",
+	}
+
+	var (
+		lastResponseBody string
+		changedTimes     int
+
+		handler, closeCache = error_page.New(&cfg, logger.NewNop())
+	)
+
+	defer func() { closeCache(); closeCache(); closeCache() }() // multiple calls should not panic
+
+	for range 300 {
+		req, reqErr := http.NewRequest(http.MethodGet, "http://testing/", http.NoBody)
+		require.NoError(t, reqErr)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+			if lastResponseBody != body {
+				changedTimes++
+				lastResponseBody = body
+			}
+		})
+	}
+
+	assert.True(t, changedTimes > 30, "the template should be changed at least 30 times")
+}
+
+func TestRotationModeOnStartup(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.RotationMode = config.RotationModeRandomOnStartup
+	cfg.TemplateName = "unknown" // ignored in this mode
+	cfg.Templates = map[string]string{
+		"foo": "foo",
+		"bar": "bar",
+	}
+
+	var (
+		reg                 = metrics.NewRegistry()
+		handler, closeCache = error_page.New(&cfg, logger.NewNop(), error_page.WithMetrics(reg))
+		picked              string
+	)
+
+	defer closeCache()
+
+	for range 50 {
+		req, reqErr := http.NewRequest(http.MethodGet, "http://testing/", http.NoBody)
+		require.NoError(t, reqErr)
+
+		req.Header.Set("Accept", "text/html")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, body string, _ http.Header) {
+			if picked == "" {
+				picked = body
+			}
+
+			require.Equal(t, picked, body, "the template must not change")
+		})
+	}
+
+	var gauge = reg.Gauge("error_pages_active_template", "", "template")
+
+	assert.InDelta(t, 1, gauge.Value(picked), 0)
+	assert.Equal(t, "unknown", cfg.TemplateName) // the config is not modified
 }
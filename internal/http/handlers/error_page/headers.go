@@ -0,0 +1,54 @@
+package error_page
+
+import (
+	"bytes"
+
+	"github.com/valyala/fasthttp"
+)
+
+// The reasons of the request rejection (reported in the logs and metrics).
+const (
+	rejectOversizedHeader = "oversized-header"
+	rejectDuplicateHeader = "duplicate-header"
+	rejectCodeConflict    = "code-conflict"
+)
+
+// selectorHeaders are the request headers used to select the code, format, and kind of the error page. The Accept
+// header is a list, so its repeated values are legitimate (unlike the others).
+var selectorHeaders = [...]struct { //nolint:gochecknoglobals
+	name string
+	list bool
+}{
+	{name: "X-Code"},
+	{name: "X-Format"},
+	{name: errorKindHeader},
+	{name: profileHeader},
+	{name: fasthttp.HeaderContentType},
+	{name: fasthttp.HeaderAccept, list: true},
+}
+
+// checkSelectorHeaders checks the headers used to select the error page. It returns the reason and the HTTP status
+// code to reject the request with, if any of the header values is longer than the max size (zero means no limit),
+// or if the header is repeated with different values (when the duplicates are rejected; otherwise, the first
+// value is used).
+func checkSelectorHeaders(headers *fasthttp.RequestHeader, maxSize uint, rejectDuplicates bool) (string, int) {
+	for _, h := range selectorHeaders {
+		var values = headers.PeekAll(h.name)
+
+		for _, value := range values {
+			if maxSize > 0 && uint(len(value)) > maxSize {
+				return rejectOversizedHeader, fasthttp.StatusRequestHeaderFieldsTooLarge
+			}
+		}
+
+		if rejectDuplicates && !h.list && len(values) > 1 {
+			for _, value := range values[1:] {
+				if !bytes.Equal(value, values[0]) {
+					return rejectDuplicateHeader, fasthttp.StatusBadRequest
+				}
+			}
+		}
+	}
+
+	return "", 0
+}
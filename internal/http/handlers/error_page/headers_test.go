@@ -0,0 +1,74 @@
+package error_page
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestCheckSelectorHeaders(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveHeaders     [][2]string
+		giveMaxSize     uint
+		giveRejectDupes bool
+		wantReason      string
+		wantStatus      int
+	}{
+		"no headers": {},
+		"regular": {
+			giveHeaders: [][2]string{{"X-Code", "404"}, {"X-Format", "text/html"}, {"Accept", "*/*"}},
+			giveMaxSize: 16,
+		},
+		"oversized": {
+			giveHeaders: [][2]string{{"X-Format", strings.Repeat("a", 17)}},
+			giveMaxSize: 16,
+			wantReason:  rejectOversizedHeader,
+			wantStatus:  fasthttp.StatusRequestHeaderFieldsTooLarge,
+		},
+		"oversized repeated": {
+			giveHeaders: [][2]string{{"Accept", "*/*"}, {"Accept", strings.Repeat("a", 17)}},
+			giveMaxSize: 16,
+			wantReason:  rejectOversizedHeader,
+			wantStatus:  fasthttp.StatusRequestHeaderFieldsTooLarge,
+		},
+		"no size limit": {
+			giveHeaders: [][2]string{{"X-Format", strings.Repeat("a", 4096)}},
+		},
+		"duplicates allowed": {
+			giveHeaders: [][2]string{{"X-Code", "404"}, {"X-Code", "503"}},
+		},
+		"duplicates rejected": {
+			giveHeaders:     [][2]string{{"X-Code", "404"}, {"X-Code", "503"}},
+			giveRejectDupes: true,
+			wantReason:      rejectDuplicateHeader,
+			wantStatus:      fasthttp.StatusBadRequest,
+		},
+		"same duplicates": {
+			giveHeaders:     [][2]string{{"X-Error-Kind", "quota"}, {"X-Error-Kind", "quota"}},
+			giveRejectDupes: true,
+		},
+		"list header": {
+			giveHeaders:     [][2]string{{"Accept", "text/html"}, {"Accept", "application/json"}},
+			giveRejectDupes: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var headers fasthttp.RequestHeader
+
+			for _, h := range tt.giveHeaders {
+				headers.Add(h[0], h[1])
+			}
+
+			reason, status := checkSelectorHeaders(&headers, tt.giveMaxSize, tt.giveRejectDupes)
+
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package error_page
+
+import (
+	"net"
+	"strings"
+)
+
+// matchHost returns the allowed hosts list entry (as-is) matching the given `Host` header value. The port (if any)
+// is ignored, and the comparison is case-insensitive. Allowed hosts may start with a wildcard (`*.example.com`) to
+// match any subdomain (but not the domain itself). The entry is used as the tenant name, so the number of tenants is
+// bounded by the configuration.
+func matchHost(host string, allowed []string) (string, bool) {
+	host = strings.ToLower(strings.TrimSpace(host))
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") { // ipv6 without port
+		host = host[1 : len(host)-1]
+	}
+
+	host = strings.TrimSuffix(host, ".") // fully qualified domain name
+
+	if host == "" {
+		return "", false
+	}
+
+	for _, entry := range allowed {
+		if a := strings.ToLower(entry); a == host {
+			return entry, true
+		} else if suffix, ok := strings.CutPrefix(a, "*"); ok && strings.HasPrefix(suffix, ".") &&
+			strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+			return entry, true
+		}
+	}
+
+	return "", false
+}
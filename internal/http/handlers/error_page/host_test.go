@@ -0,0 +1,67 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_matchHost(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveHost    string
+		giveAllowed []string
+		want        bool
+	}{
+		"empty list":                {giveHost: "example.com", want: false}, // the caller skips the check
+		"exact match":               {giveHost: "example.com", giveAllowed: []string{"foo.com", "example.com"}, want: true},
+		"case insensitive":          {giveHost: "ExAmPle.COM", giveAllowed: []string{"example.com"}, want: true},
+		"with port":                 {giveHost: "example.com:8080", giveAllowed: []string{"example.com"}, want: true},
+		"fqdn":                      {giveHost: "example.com.", giveAllowed: []string{"example.com"}, want: true},
+		"ipv4":                      {giveHost: "127.0.0.1:80", giveAllowed: []string{"127.0.0.1"}, want: true},
+		"ipv6 with port":            {giveHost: "[::1]:8080", giveAllowed: []string{"::1"}, want: true},
+		"ipv6 without port":         {giveHost: "[::1]", giveAllowed: []string{"::1"}, want: true},
+		"wildcard":                  {giveHost: "foo.example.com", giveAllowed: []string{"*.example.com"}, want: true},
+		"wildcard, deep":            {giveHost: "a.b.example.com", giveAllowed: []string{"*.example.com"}, want: true},
+		"wildcard, domain itself":   {giveHost: "example.com", giveAllowed: []string{"*.example.com"}, want: false},
+		"wildcard, similar":         {giveHost: "fooexample.com", giveAllowed: []string{"*.example.com"}, want: false},
+		"not in the list":           {giveHost: "evil.com", giveAllowed: []string{"example.com"}, want: false},
+		"suffix is not enough":      {giveHost: "notexample.com", giveAllowed: []string{"example.com"}, want: false},
+		"empty host":                {giveHost: "", giveAllowed: []string{"example.com"}, want: false},
+		"only port":                 {giveHost: ":8080", giveAllowed: []string{"example.com"}, want: false},
+		"broken wildcard is strict": {giveHost: "foo.example.com", giveAllowed: []string{"*example.com"}, want: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, ok := matchHost(tt.giveHost, tt.giveAllowed)
+
+			assert.Equal(t, tt.want, ok)
+
+			if tt.want {
+				assert.Contains(t, tt.giveAllowed, entry) // as-is
+			} else {
+				assert.Empty(t, entry)
+			}
+		})
+	}
+}
+
+func Test_matchHost_Entry(t *testing.T) {
+	t.Parallel()
+
+	var allowed = []string{"Example.com", "*.example.org"}
+
+	for give, want := range map[string]string{
+		"example.com:80":  "Example.com",
+		"foo.example.org": "*.example.org",
+		"bar.example.org": "*.example.org",
+		"evil.com":        "",
+	} {
+		got, ok := matchHost(give, allowed)
+
+		assert.Equal(t, want, got, give)
+		assert.Equal(t, want != "", ok, give)
+	}
+}
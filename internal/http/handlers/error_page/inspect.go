@@ -0,0 +1,107 @@
+package error_page
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+type (
+	// inspectPropsKey is the request user value key, marking the requests for the template props inspection.
+	inspectPropsKey struct{}
+
+	// dryRunKey is the request user value key with the template source of the dry-run render.
+	dryRunKey struct{}
+
+	// renderAtKey is the request user value key with the time the page is previewed at.
+	renderAtKey struct{}
+)
+
+// InspectProps marks the request, so the handler responds with the template props (the [template.Props.Values]
+// map in JSON format) instead of the rendered page. The props are prepared exactly the same way as for rendering,
+// so it helps to debug why a token is empty. The rejected requests (e.g. with an unexpected host) are answered as
+// usual.
+func InspectProps(ctx *fasthttp.RequestCtx) { ctx.SetUserValue(inspectPropsKey{}, true) }
+
+// inspectingProps reports whether the request is marked using the [InspectProps].
+func inspectingProps(ctx *fasthttp.RequestCtx) bool {
+	var inspect, _ = ctx.UserValue(inspectPropsKey{}).(bool)
+
+	return inspect
+}
+
+// writeProps responds with the template props in JSON format (the headers of the error page are dropped).
+func writeProps(ctx *fasthttp.RequestCtx, props template.Props) {
+	var body, err = json.Marshal(props.Values())
+	if err != nil {
+		ctx.Error(err.Error()+"\n", http.StatusInternalServerError)
+
+		return
+	}
+
+	ctx.Response.Reset()
+	ctx.SetContentType("application/json; charset=utf-8")
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-store")
+	ctx.SetStatusCode(http.StatusOK)
+	_, _ = ctx.Write(body)
+}
+
+// DryRun marks the request, so the handler renders the given template source (instead of the configured template
+// of the detected format) with the same props as the page would be rendered with. The output is neither minified
+// nor cached, and the template is never added to the configured ones. The render errors are responded with the
+// `422 Unprocessable Entity`.
+func DryRun(ctx *fasthttp.RequestCtx, source string) { ctx.SetUserValue(dryRunKey{}, source) }
+
+// dryRunSource returns the template source of the request marked using the [DryRun].
+func dryRunSource(ctx *fasthttp.RequestCtx) (string, bool) {
+	var source, ok = ctx.UserValue(dryRunKey{}).(string)
+
+	return source, ok
+}
+
+// writeDryRun renders the template source and responds with the output (the headers of the error page, except the
+// content type, are dropped).
+func writeDryRun(
+	ctx *fasthttp.RequestCtx,
+	limiter renderLimiter,
+	source string,
+	props template.Props,
+	escaping template.Escaping,
+) {
+	var contentType = string(ctx.Response.Header.ContentType())
+
+	content, err := limiter.render(source, props, escaping)
+
+	ctx.Response.Reset()
+
+	switch {
+	case errors.Is(err, errTooManyRenders):
+		ctx.Error(err.Error()+"\n", http.StatusServiceUnavailable)
+	case err != nil:
+		ctx.Error(err.Error()+"\n", http.StatusUnprocessableEntity)
+	default:
+		ctx.SetContentType(contentType)
+		ctx.SetStatusCode(http.StatusOK)
+		_, _ = ctx.WriteString(content)
+	}
+
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-store")
+}
+
+// RenderAt marks the request, so the handler prepares the page as if it was requested at the given time: the
+// maintenance windows are matched against this time, and the dry-run renders (see [DryRun]) use it for the
+// timestamp tokens (like `now`). The template rotation and the rendered pages cache are not affected, so it's
+// intended for the previews (the props inspection and dry-run renders) only.
+func RenderAt(ctx *fasthttp.RequestCtx, t time.Time) { ctx.SetUserValue(renderAtKey{}, t) }
+
+// renderTime returns the time of the request marked using the [RenderAt].
+func renderTime(ctx *fasthttp.RequestCtx) (time.Time, bool) {
+	var t, ok = ctx.UserValue(renderAtKey{}).(time.Time)
+
+	return t, ok
+}
@@ -0,0 +1,213 @@
+package error_page
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/clock"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+// errTooManyRenders is returned when the maximum number of concurrent renders is reached.
+var errTooManyRenders = errors.New("too many concurrent renders")
+
+// renderLimiter is a semaphore that limits the number of concurrent template renders (the nil slots mean no
+// limit). It also applies the resource limits to each render.
+type renderLimiter struct {
+	slots  chan struct{}
+	limits template.Limits
+	now    func() time.Time // the clock of the timestamp tokens (the current time is used if nil)
+
+	latency *metrics.Histogram // the render durations (optional)
+}
+
+// newRenderLimiter creates a new limiter with the given capacity (zero means no limit), the per-render limits, and
+// the optional clock of the timestamp tokens.
+func newRenderLimiter(limit uint, limits template.Limits, clk ...clock.Clock) renderLimiter {
+	var l = renderLimiter{limits: limits}
+
+	if len(clk) > 0 && clk[0] != nil {
+		l.now = clk[0].Now
+	}
+
+	if limit > 0 {
+		l.slots = make(chan struct{}, limit)
+	}
+
+	return l
+}
+
+// renderLatencyBuckets are the upper bounds (in seconds) of the render duration histogram buckets (the renders
+// usually take less than a millisecond).
+var renderLatencyBuckets = []float64{ //nolint:gochecknoglobals
+	.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1,
+}
+
+// withLatency returns a copy of the limiter that reports the render durations to the registry.
+func (l renderLimiter) withLatency(reg *metrics.Registry) renderLimiter {
+	l.latency = reg.Histogram(
+		"error_pages_render_duration_seconds", "Template render duration (the cache hits are not rendered)",
+		renderLatencyBuckets,
+	)
+
+	return l
+}
+
+// observe reports the duration of the render started at the given time.
+func (l renderLimiter) observe(start time.Time) { l.latency.Observe(time.Since(start).Seconds()) }
+
+// at returns a copy of the limiter (sharing the slots) that renders the templates at the given time.
+func (l renderLimiter) at(t time.Time) renderLimiter {
+	l.now = func() time.Time { return t }
+
+	return l
+}
+
+// options returns the render options.
+func (l renderLimiter) options(escaping template.Escaping) template.Options {
+	return template.Options{Limits: l.limits, Escaping: escaping, Now: l.now}
+}
+
+// tryAcquire tries to acquire a slot without blocking. The slot must be released using the release method.
+func (l renderLimiter) tryAcquire() bool {
+	if l.slots == nil {
+		return true
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release releases the previously acquired slot.
+func (l renderLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// render renders the template if there is a free slot, otherwise [errTooManyRenders] is returned.
+func (l renderLimiter) render(content string, props template.Props, escaping template.Escaping) (string, error) {
+	if !l.tryAcquire() {
+		return "", errTooManyRenders
+	}
+
+	defer l.release()
+	defer l.observe(time.Now())
+
+	return template.RenderWith(content, props, l.options(escaping))
+}
+
+// renderHTML renders the HTML template into the pooled buffer if there is a free slot (otherwise [errTooManyRenders]
+// is returned), minifies it (if the minifier is not nil), and injects the script (if any). So a render doesn't allocate the full
+// copies of the page on every step. The buffer must be returned using the [putBuffer].
+func (l renderLimiter) renderHTML(
+	log *logger.Logger,
+	content string,
+	props template.Props,
+	escaping template.Escaping,
+	minifier *template.Minifier,
+	script string,
+) (*bytes.Buffer, error) {
+	if !l.tryAcquire() {
+		return nil, errTooManyRenders
+	}
+
+	defer l.release()
+	defer l.observe(time.Now())
+
+	var buf = getBuffer()
+
+	if err := template.RenderTo(buf, content, props, l.options(escaping)); err != nil {
+		putBuffer(buf)
+
+		return nil, err
+	}
+
+	if minifier != nil {
+		var mini = getBuffer()
+
+		if err := minifier.To(mini, buf); err != nil {
+			putBuffer(mini)
+
+			log.Warn("HTML minification failed", logger.Error(err))
+		} else {
+			putBuffer(buf)
+
+			buf = mini
+		}
+	}
+
+	if script != "" {
+		injectScript(buf, script)
+	}
+
+	return buf, nil
+}
+
+// stream renders the template directly into the response body stream if there is a free slot (the slot is held
+// until the stream is written), otherwise [errTooManyRenders] is returned. The status and headers are sent before
+// the render, so the render errors are only logged.
+func (l renderLimiter) stream(
+	ctx *fasthttp.RequestCtx,
+	log *logger.Logger,
+	content string,
+	props template.Props,
+	escaping template.Escaping,
+) error {
+	if !l.tryAcquire() {
+		return errTooManyRenders
+	}
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer l.release()
+		defer l.observe(time.Now())
+
+		if err := template.RenderTo(w, content, props, l.options(escaping)); err != nil {
+			log.Error("Template streaming failed", logger.Error(err))
+		}
+	})
+
+	return nil
+}
+
+// minimalContent returns a minimal (without templating) response body in the given format. It is used when the
+// template cannot be rendered right now.
+func minimalContent(f preferredFormat, code uint16, message string) string {
+	switch f {
+	case jsonFormat:
+		msg, _ := json.Marshal(message)
+
+		return fmt.Sprintf("{\"error\": true, \"code\": %d, \"message\": %s}\n", code, msg)
+	case xmlFormat:
+		var msg strings.Builder
+
+		_ = xml.EscapeText(&msg, []byte(message))
+
+		return fmt.Sprintf(
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<error><code>%d</code><message>%s</message></error>\n",
+			code, msg.String(),
+		)
+	case htmlFormat:
+		return fmt.Sprintf(
+			"<!DOCTYPE html>\n<html><head><title>%[1]d</title></head><body><h1>%[1]d: %[2]s</h1></body></html>\n",
+			code, html.EscapeString(message),
+		)
+	default:
+		return fmt.Sprintf("%d: %s\n", code, message)
+	}
+}
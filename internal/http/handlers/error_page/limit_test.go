@@ -0,0 +1,173 @@
+package error_page
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestRenderLimiter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unlimited", func(t *testing.T) {
+		t.Parallel()
+
+		var l = newRenderLimiter(0, template.Limits{})
+
+		for range 10 {
+			assert.True(t, l.tryAcquire())
+		}
+
+		l.release() // noop
+
+		content, err := l.render("{{ code }}", template.Props{Code: 404}, template.EscapeNone)
+		require.NoError(t, err)
+		assert.Equal(t, "404", content)
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		t.Parallel()
+
+		var l = newRenderLimiter(2, template.Limits{})
+
+		assert.True(t, l.tryAcquire())
+		assert.True(t, l.tryAcquire())
+		assert.False(t, l.tryAcquire())
+
+		_, err := l.render("{{ code }}", template.Props{Code: 404}, template.EscapeNone)
+		assert.ErrorIs(t, err, errTooManyRenders)
+
+		l.release()
+
+		content, err := l.render("{{ code }}", template.Props{Code: 404}, template.EscapeNone)
+		require.NoError(t, err)
+		assert.Equal(t, "404", content)
+
+		assert.True(t, l.tryAcquire()) // the slot was released after the render
+	})
+}
+
+func TestRenderLimiter_Limits(t *testing.T) {
+	t.Parallel()
+
+	var l = newRenderLimiter(0, template.Limits{MaxIncludes: 1})
+
+	_, err := l.render(`{{ define "a" }}a{{ end }}{{ template "a" }}{{ template "a" }}`, template.Props{}, template.EscapeNone)
+	assert.ErrorIs(t, err, template.ErrMaxIncludesExceeded)
+}
+
+func TestMinimalContent(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveFormat preferredFormat
+		want       string
+	}{
+		"json":  {giveFormat: jsonFormat, want: `{"error": true, "code": 404, "message": "Not \u003cFound\u003e"}`},
+		"xml":   {giveFormat: xmlFormat, want: `<code>404</code><message>Not &lt;Found&gt;</message>`},
+		"html":  {giveFormat: htmlFormat, want: `<h1>404: Not &lt;Found&gt;</h1>`},
+		"plain": {giveFormat: plainTextFormat, want: "404: Not <Found>\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Contains(t, minimalContent(tt.giveFormat, 404, "Not <Found>"), tt.want)
+		})
+	}
+}
+
+func TestRenderLimiter_RenderHTML(t *testing.T) {
+	if SlimBuild {
+		t.Skip("the slim build does not minify the HTML")
+	}
+
+	t.Parallel()
+
+	var l = newRenderLimiter(1, template.Limits{})
+
+	buf, err := l.renderHTML(logger.NewNop(), "<html>\n <body>\n  {{ code }}\n </body>\n</html>", template.Props{Code: 503},
+		template.EscapeHTML, template.NewMinifier(template.MinifyOptions{}), "<s>")
+	require.NoError(t, err)
+
+	assert.Equal(t, "<html><body>503<s></body></html>", buf.String())
+	putBuffer(buf)
+
+	buf, err = l.renderHTML(logger.NewNop(), "<p>{{ code }}</p>", template.Props{Code: 404}, template.EscapeHTML, nil, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "<p>404</p>", buf.String())
+
+	require.True(t, l.tryAcquire()) // the slot is released after the render
+	l.release()
+	putBuffer(buf)
+
+	_, err = l.renderHTML(logger.NewNop(), "{{ foo", template.Props{}, template.EscapeHTML, nil, "")
+	assert.Error(t, err)
+}
+
+// BenchmarkRenderHTML compares the HTML cache miss path of the handler before the pooled buffers were introduced
+// (render into a string, minify into another string, copy it into the cache and write it into the response) with
+// the current one (render and minify into the pooled buffers, copy into the cache and write into the response).
+//
+// The number of allocations is almost the same, since it's dominated by the template parsing (the template is
+// parsed on every render, with the functions bound to the properties). The pooled path saves the intermediate
+// full-size copies of the page only (about a third of the allocated bytes for the built-in template).
+func BenchmarkRenderHTML(b *testing.B) {
+	var (
+		cfg      = config.New()
+		tpl, _   = cfg.Templates.Get(cfg.TemplateName)
+		props    = template.Props{Code: 503, Message: "Service Unavailable", Description: "The server is down"}
+		l        = newRenderLimiter(0, template.Limits{})
+		minifier = template.NewMinifier(template.MinifyOptions{})
+		log      = logger.NewNop()
+		ctx      fasthttp.RequestCtx // the response body buffer is reused, like in the server
+	)
+
+	b.Run("strings", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			content, err := l.render(tpl, props, template.EscapeHTML)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if content, err = minifier.String(content); err != nil {
+				b.Fatal(err)
+			}
+
+			var cached = []byte(content)
+
+			ctx.Response.ResetBody()
+			write(&ctx, log, content)
+
+			_ = cached
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			buf, err := l.renderHTML(log, tpl, props, template.EscapeHTML, minifier, "")
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			var cached = bytes.Clone(buf.Bytes())
+
+			ctx.Response.ResetBody()
+			write(&ctx, log, buf.Bytes())
+			putBuffer(buf)
+
+			_ = cached
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package error_page
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// LiveStatusEvent is the name of the DOM event dispatched on the document with the fresh status (the
+// `/status.json` response) as the event detail, so the custom templates can update their content too.
+const LiveStatusEvent = "error-pages:status"
+
+// liveStatusScript returns the script that polls the status URL (with a jitter, and backing off exponentially up
+// to 16 intervals on failures) and updates the outage banner element (marked with the `data-live-banner`
+// attribute) of the page. The last seen status version is sent with every poll, so the endpoint responds with 204
+// (and without the body) until the status changes.
+func liveStatusScript(statusURL string, interval time.Duration) string {
+	var (
+		u, _ = json.Marshal(statusURL) // the <, >, and & are escaped, so it's safe inside the script element
+		e, _ = json.Marshal(LiveStatusEvent)
+	)
+
+	return fmt.Sprintf(`<script>(()=>{const u=%s,i=%d,m=i*16;let v="",d=i;const a=(s)=>{document.dispatchEvent(`+
+		`new CustomEvent(%s,{detail:s}));const e=document.querySelector("[data-live-banner]"),b=s.banner||{};`+
+		`if(e){e.textContent=b.message||"";e.hidden=!b.message;e.className="banner banner-"+(b.severity||"info")}};`+
+		`const p=()=>setTimeout(()=>fetch(u+(u.includes("?")?"&":"?")+"v="+encodeURIComponent(v),{cache:"no-store",`+
+		`credentials:"omit"}).then((r)=>{if(r.status===200){return r.json().then((s)=>{v=s.version;a(s);d=i})}`+
+		`if(r.status!==204){throw r}d=i}).catch(()=>{d=Math.min(d*2,m)}).finally(p),d*(.8+Math.random()*.4));`+
+		`p()})()</script>`, u, max(interval, time.Second).Milliseconds(), e)
+}
@@ -0,0 +1,47 @@
+package error_page
+
+import (
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+const (
+	localeParam     = "lang" // the query parameter and the cookie name to override the locale
+	localeCookieTTL = 365 * 24 * time.Hour
+)
+
+// detectLocale detects the client locale. The `lang` query parameter takes precedence over the `lang` cookie,
+// which takes precedence over the `Accept-Language` header. Only the supported locales are taken into account. An
+// empty string is returned if the locale cannot be detected.
+//
+// When the locale is overridden using the query parameter, the cookie is set, so the client keeps the chosen
+// locale across the error pages.
+func detectLocale(ctx *fasthttp.RequestCtx) string {
+	if fromQuery := l10n.NormalizeLocale(string(ctx.QueryArgs().Peek(localeParam))); l10n.HasLocale(fromQuery) {
+		var cookie = fasthttp.AcquireCookie()
+		defer fasthttp.ReleaseCookie(cookie)
+
+		cookie.SetKey(localeParam)
+		cookie.SetValue(fromQuery)
+		cookie.SetPath("/")
+		cookie.SetMaxAge(int(localeCookieTTL.Seconds()))
+		cookie.SetSameSite(fasthttp.CookieSameSiteLaxMode) // not HTTP-only, because the l10n script reads it
+
+		ctx.Response.Header.SetCookie(cookie)
+
+		return fromQuery
+	}
+
+	if fromCookie := l10n.NormalizeLocale(string(ctx.Request.Header.Cookie(localeParam))); l10n.HasLocale(fromCookie) {
+		return fromCookie
+	}
+
+	if fromHeader, ok := l10n.MatchAcceptLanguage(string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptLanguage))); ok {
+		return fromHeader
+	}
+
+	return ""
+}
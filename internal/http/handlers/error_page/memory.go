@@ -0,0 +1,22 @@
+package error_page
+
+import (
+	runtimeMetrics "runtime/metrics"
+)
+
+// heapObjectsMetric is the runtime metric of the memory occupied by the heap objects (the live ones and the dead
+// ones not swept yet). Unlike the [runtime.ReadMemStats], it's read without stopping the world.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// heapSize returns the current size of the heap objects in bytes (zero if the metric is not supported).
+func heapSize() uint64 {
+	var sample = [1]runtimeMetrics.Sample{{Name: heapObjectsMetric}}
+
+	runtimeMetrics.Read(sample[:])
+
+	if sample[0].Value.Kind() != runtimeMetrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
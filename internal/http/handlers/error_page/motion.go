@@ -0,0 +1,30 @@
+package error_page
+
+import (
+	"bytes"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// reducedMotionHint is the user preference client hint, mirroring the `prefers-reduced-motion` CSS media feature
+// (`reduce` or `no-preference`, https://wicg.github.io/user-preference-media-features-headers/).
+const reducedMotionHint = "Sec-CH-Prefers-Reduced-Motion"
+
+// reducedMotion reports whether the animations should be reduced for the request. With the
+// [config.MotionPreferenceAuto], the client hint is requested from the browser (it is sent with the subsequent
+// requests only), and the response is marked as varying by it.
+func reducedMotion(ctx *fasthttp.RequestCtx, preference config.MotionPreference) bool {
+	switch preference { //nolint:exhaustive // the client hint is ignored otherwise
+	case config.MotionPreferenceReduce:
+		return true
+	case config.MotionPreferenceAuto:
+		ctx.Response.Header.Add("Accept-CH", reducedMotionHint)
+		ctx.Response.Header.Add(fasthttp.HeaderVary, reducedMotionHint)
+
+		return string(bytes.TrimSpace(ctx.Request.Header.Peek(reducedMotionHint))) == "reduce"
+	}
+
+	return false
+}
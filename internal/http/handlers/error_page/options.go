@@ -0,0 +1,61 @@
+package error_page
+
+import (
+	"github.com/binaryYuki/error-pages/internal/clock"
+	"github.com/binaryYuki/error-pages/internal/featureflag"
+	"github.com/binaryYuki/error-pages/internal/lifecycle"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+	"github.com/binaryYuki/error-pages/internal/upstream"
+)
+
+type (
+	// Option allows to customize the handler.
+	Option func(*options)
+
+	options struct {
+		metrics   *metrics.Registry
+		rotation  *RotationControl
+		banner    *BannerControl
+		probe     *upstream.Prober
+		ready     *Readiness
+		clock     clock.Clock
+		lifecycle *lifecycle.Manager
+		details   DetailsPredicate
+		flags     *featureflag.Store
+	}
+)
+
+// WithMetrics sets the registry to report the handler metrics to.
+func WithMetrics(reg *metrics.Registry) Option { return func(o *options) { o.metrics = reg } }
+
+// WithRotationControl binds the rotation control to the handler, so the rotation state can be read and the
+// template switch can be forced (e.g. by the management API).
+func WithRotationControl(ctl *RotationControl) Option { return func(o *options) { o.rotation = ctl } }
+
+// WithBannerControl binds the banner control to the handler, so the outage banner can be changed at runtime (e.g.
+// by the management API).
+func WithBannerControl(ctl *BannerControl) Option { return func(o *options) { o.banner = ctl } }
+
+// WithUpstreamProbe sets the upstream health prober, whose last result is exposed as the `upstream_healthy` and
+// `upstream_checked_at` tokens (the prober should be run by the caller).
+func WithUpstreamProbe(p *upstream.Prober) Option { return func(o *options) { o.probe = p } }
+
+// WithReadiness sets the readiness of the service; while it is not ready (and the `UnavailableUntilReady` option
+// is enabled), every request is answered with the 503 error page.
+func WithReadiness(r *Readiness) Option { return func(o *options) { o.ready = r } }
+
+// WithClock sets the time source of the template rotation, the rendered pages expiry, the maintenance windows, and
+// the timestamp tokens (the system clock by default).
+func WithClock(c clock.Clock) Option { return func(o *options) { o.clock = c } }
+
+// WithLifecycle sets the manager to run the background services (like the rendered pages cache janitor) with, so
+// they are stopped along with the other services on shutdown (the handler's own manager is used by default).
+func WithLifecycle(m *lifecycle.Manager) Option { return func(o *options) { o.lifecycle = m } }
+
+// WithDetailsPredicate sets the predicate deciding per request whether the request details are shown (when the
+// details are enabled). It replaces the configured details access rules (e.g. to consult an external service).
+func WithDetailsPredicate(p DetailsPredicate) Option { return func(o *options) { o.details = p } }
+
+// WithFeatureFlags sets the feature flags store, whose values (resolved for the client) are available to the
+// templates using the `flag` and `flagOn` functions (the store should be refreshed by the caller).
+func WithFeatureFlags(s *featureflag.Store) Option { return func(o *options) { o.flags = s } }
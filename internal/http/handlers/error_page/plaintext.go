@@ -0,0 +1,256 @@
+package error_page
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// textShaper normalizes the plain text output and limits its line width, so the terminal clients and SMS gateways
+// don't get the mangled multibyte sequences. The zero value changes nothing.
+type textShaper struct {
+	maxWidth      uint // in the terminal columns, zero means no limit
+	normalization config.TextNormalization
+}
+
+// enabled reports whether the shaper changes anything.
+func (s textShaper) enabled() bool {
+	return s.maxWidth > 0 || s.normalization != config.TextNormalizationNone
+}
+
+// shape returns the normalized text with the lines truncated to the max width (the grapheme clusters, like the
+// emoji sequences or letters with the combining marks, are never split, and the ellipsis is appended to the
+// truncated lines).
+func (s textShaper) shape(text string) string {
+	if !s.enabled() {
+		return text
+	}
+
+	if s.normalization != config.TextNormalizationNone {
+		text = normalizeText(text, s.normalization == config.TextNormalizationASCII)
+	}
+
+	if s.maxWidth == 0 {
+		return text
+	}
+
+	var ellipsis = "…"
+
+	if s.normalization == config.TextNormalizationASCII {
+		ellipsis = "..."
+	}
+
+	var lines = strings.Split(text, "\n")
+
+	for i, line := range lines {
+		var body, cr = strings.CutSuffix(line, "\r") // keep the CRLF line endings
+
+		if body = truncateWidth(body, s.maxWidth, ellipsis); cr {
+			body += "\r"
+		}
+
+		lines[i] = body
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// asciiReplacements are the ASCII replacements of the common typographic characters (the other non-ASCII
+// characters are replaced with the `?`).
+var asciiReplacements = map[rune]string{ //nolint:gochecknoglobals
+	'\u00a0': " ", '\u2002': " ", '\u2003': " ", '\u2009': " ", // spaces
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '−': "-", // dashes
+	'‘': "'", '’': "'", '‚': "'", '′': "'", // single quotes
+	'“': `"`, '”': `"`, '„': `"`, '«': `"`, '»': `"`, '″': `"`, // double quotes
+	'…': "...", '•': "*", '·': "*", '™': "(TM)", '©': "(C)", '®': "(R)",
+}
+
+// normalizeText removes the invalid UTF-8 sequences, control characters (except the line breaks and tabs), and
+// invisible formatting characters (like the bidirectional overrides; the zero width joiner of the emoji sequences
+// is kept). In the ASCII mode, every non-ASCII grapheme cluster is replaced with its ASCII replacement or `?`.
+func normalizeText(text string, ascii bool) string {
+	var b strings.Builder
+
+	b.Grow(len(text))
+
+	for len(text) > 0 {
+		var r, size = utf8.DecodeRuneInString(text)
+
+		switch {
+		case r == utf8.RuneError && size <= 1: // invalid UTF-8
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case r == '\r' && strings.HasPrefix(text[size:], "\n"): // CRLF
+			b.WriteRune(r)
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r) && r != zeroWidthJoiner:
+		case ascii && r >= utf8.RuneSelf:
+			var cluster, clusterSize = nextCluster(text)
+
+			if replacement, ok := asciiReplacements[r]; ok && cluster == string(r) {
+				b.WriteString(replacement)
+			} else if r := []rune(cluster)[0]; !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Me, r) {
+				b.WriteByte('?') // the stray combining marks are dropped
+			}
+
+			size = clusterSize
+		default:
+			b.WriteRune(r)
+		}
+
+		text = text[size:]
+	}
+
+	return b.String()
+}
+
+// truncateWidth truncates the line to the max width (in the terminal columns), appending the ellipsis. The grapheme
+// clusters are never split.
+func truncateWidth(line string, maxWidth uint, ellipsis string) string {
+	if stringWidth(line) <= maxWidth {
+		return line
+	}
+
+	var limit = maxWidth
+
+	if ellipsisWidth := stringWidth(ellipsis); ellipsisWidth < maxWidth {
+		limit -= ellipsisWidth
+	} else {
+		ellipsis = "" // no room for the ellipsis
+	}
+
+	var (
+		width uint
+		end   int
+	)
+
+	for end < len(line) {
+		var cluster, size = nextCluster(line[end:])
+
+		if width += clusterWidth(cluster); width > limit {
+			break
+		}
+
+		end += size
+	}
+
+	return line[:end] + ellipsis
+}
+
+// stringWidth returns the width of the string in the terminal columns.
+func stringWidth(s string) (width uint) {
+	for len(s) > 0 {
+		var cluster, size = nextCluster(s)
+
+		width += clusterWidth(cluster)
+		s = s[size:]
+	}
+
+	return width
+}
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	emojiPresentation = '\ufe0f' // the variation selector 16
+)
+
+// nextCluster returns the (approximate) grapheme cluster at the beginning of the string and its size in bytes: the
+// base rune with the following combining marks, variation selectors, emoji modifiers, the runes joined using the
+// zero width joiner, or the pair of the regional indicators (a flag).
+func nextCluster(s string) (string, int) {
+	var base, size = utf8.DecodeRuneInString(s)
+
+	if base == utf8.RuneError && size <= 1 {
+		return s[:size], size
+	}
+
+	for joined := false; size < len(s); {
+		var r, n = utf8.DecodeRuneInString(s[size:])
+
+		switch {
+		case joined, isZeroWidth(r) || isEmojiModifier(r),
+			isRegionalIndicator(base) && isRegionalIndicator(r) && size == utf8.RuneLen(base):
+			joined = r == zeroWidthJoiner
+			size += n
+
+			continue
+		}
+
+		break
+	}
+
+	return s[:size], size
+}
+
+// clusterWidth returns the width of the grapheme cluster in the terminal columns.
+func clusterWidth(cluster string) uint {
+	var base, _ = utf8.DecodeRuneInString(cluster)
+
+	switch {
+	case isZeroWidth(base) || unicode.IsControl(base):
+		return 0
+	case isWide(base) || isRegionalIndicator(base):
+		return 2 //nolint:mnd
+	case strings.ContainsRune(cluster, emojiPresentation): // like the `❤️` (the text symbol as an emoji)
+		return 2 //nolint:mnd
+	}
+
+	return 1
+}
+
+// isZeroWidth reports whether the rune takes no space (the combining marks, variation selectors, and invisible
+// formatting characters).
+func isZeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+// isEmojiModifier reports whether the rune is the emoji skin tone modifier.
+func isEmojiModifier(r rune) bool { return r >= 0x1f3fb && r <= 0x1f3ff }
+
+// isRegionalIndicator reports whether the rune is the regional indicator symbol (a pair of them is a flag).
+func isRegionalIndicator(r rune) bool { return r >= 0x1f1e6 && r <= 0x1f1ff }
+
+// wideRanges are the East Asian wide and fullwidth characters and the emoji, which take two terminal columns.
+var wideRanges = [...][2]rune{ //nolint:gochecknoglobals
+	{0x1100, 0x115f},   // Hangul Jamo
+	{0x231a, 0x231b},   // watch, hourglass
+	{0x23e9, 0x23ec},   // media controls
+	{0x25fd, 0x25fe},   // small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x26a1, 0x26a1},   // high voltage
+	{0x26d4, 0x26d4},   // no entry
+	{0x2705, 0x2705},   // check mark
+	{0x274c, 0x274c},   // cross mark
+	{0x2753, 0x2755},   // question and exclamation marks
+	{0x2e80, 0x303e},   // CJK radicals, symbols, and punctuation
+	{0x3041, 0x33ff},   // Hiragana, Katakana, CJK compatibility
+	{0x3400, 0x4dbf},   // CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe30, 0xfe4f},   // CJK compatibility forms
+	{0xff00, 0xff60},   // fullwidth forms
+	{0xffe0, 0xffe6},   // fullwidth signs
+	{0x1f300, 0x1f64f}, // miscellaneous symbols and pictographs, emoticons
+	{0x1f680, 0x1f6ff}, // transport and map symbols
+	{0x1f900, 0x1f9ff}, // supplemental symbols and pictographs
+	{0x1fa70, 0x1faff}, // symbols and pictographs extended-A
+	{0x20000, 0x3fffd}, // CJK extensions
+}
+
+// isWide reports whether the rune takes two terminal columns.
+func isWide(r rune) bool {
+	if r < wideRanges[0][0] {
+		return false
+	}
+
+	for _, rng := range wideRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+
+	return false
+}
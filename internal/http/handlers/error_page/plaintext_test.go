@@ -0,0 +1,108 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestStringWidth(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]uint{
+		"":                     0,
+		"Not Found":            9,
+		"Ошибка":               6,
+		"找不到页面":                10,
+		"페이지":                  6,
+		"ｅｒｒｏｒ":                10,
+		"e\u0301":              1, // e with the combining acute accent
+		"\U0001F525":           2, // fire
+		"\U0001F44D\U0001F3FD": 2, // thumbs up with the skin tone
+		"\U0001F468\u200d\U0001F469\u200d\U0001F467": 2, // family (ZWJ sequence)
+		"\U0001F1FA\U0001F1E6":                       2, // flag
+		"❤\ufe0f":                                    2, // red heart (emoji presentation)
+		"❤":                                          1, // heavy black heart (text presentation)
+		"a\u200bb":                                   2, // zero width space
+	} {
+		assert.Equal(t, want, stringWidth(give), give)
+	}
+}
+
+func TestTextShaper_Shape(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		give   string
+		shaper textShaper
+		want   string
+	}{
+		"zero value": {
+			give: "Not Found\x1b[31m\n",
+			want: "Not Found\x1b[31m\n",
+		},
+		"short lines": {
+			give:   "404\nNot Found\n",
+			shaper: textShaper{maxWidth: 9},
+			want:   "404\nNot Found\n",
+		},
+		"truncated": {
+			give:   "404: Not Found\nThe page you are looking for does not exist\n",
+			shaper: textShaper{maxWidth: 10},
+			want:   "404: Not …\nThe page …\n",
+		},
+		"crlf": {
+			give:   "Service Unavailable\r\nRetry later\r\n",
+			shaper: textShaper{maxWidth: 8},
+			want:   "Service…\r\nRetry l…\r\n",
+		},
+		"wide characters": {
+			give:   "找不到页面",
+			shaper: textShaper{maxWidth: 6},
+			want:   "找不…", // the third character does not fit with the ellipsis
+		},
+		"combining marks": {
+			give:   "cafe\u0301 cafe\u0301",
+			shaper: textShaper{maxWidth: 5},
+			want:   "cafe\u0301…",
+		},
+		"emoji sequences": {
+			give:   "\U0001F468\u200d\U0001F469\u200d\U0001F467\U0001F1FA\U0001F1E6\U0001F44D\U0001F3FD",
+			shaper: textShaper{maxWidth: 5},
+			want:   "\U0001F468\u200d\U0001F469\u200d\U0001F467\U0001F1FA\U0001F1E6…",
+		},
+		"no room for the ellipsis": {
+			give:   "Not Found",
+			shaper: textShaper{maxWidth: 1},
+			want:   "N",
+		},
+		"safe": {
+			give:   "Not\x00 \x1b[31mFound\u202e\u200b\xff\t\U0001F468\u200d\U0001F469\r\n",
+			shaper: textShaper{normalization: config.TextNormalizationSafe},
+			want:   "Not [31mFound\t\U0001F468\u200d\U0001F469\r\n",
+		},
+		"safe keeps the unicode": {
+			give:   "Страница не найдена — 找不到页面 \U0001F525\n",
+			shaper: textShaper{normalization: config.TextNormalizationSafe},
+			want:   "Страница не найдена — 找不到页面 \U0001F525\n",
+		},
+		"ascii": {
+			give:   "“Not Found” — cafe\u0301 caf\u00e9\u00a0Ошибка \U0001F44D\U0001F3FD…\x07\n",
+			shaper: textShaper{normalization: config.TextNormalizationASCII},
+			want:   "\"Not Found\" - cafe caf? ?????? ?...\n",
+		},
+		"ascii truncated": {
+			give:   "Ошибка: страница не найдена",
+			shaper: textShaper{maxWidth: 10, normalization: config.TextNormalizationASCII},
+			want:   "??????:...",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.shaper.shape(tt.give))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package error_page
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize limits the capacity of the buffers returned to the pool, so a single huge page doesn't keep
+// the memory forever.
+const maxPooledBufferSize = 1 << 20 // 1 MiB
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }} //nolint:gochecknoglobals
+
+// getBuffer returns an empty buffer from the pool. It should be returned using the [putBuffer].
+func getBuffer() *bytes.Buffer { return bufferPool.Get().(*bytes.Buffer) } //nolint:forcetypeassert
+
+// putBuffer returns the buffer to the pool (the buffer must not be used after that).
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,51 @@
+package error_page
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// profileHeader is the request header with the name of the response profile (a canned response, like
+// `blocked-by-waf`).
+const profileHeader = "X-Error-Profile"
+
+// ProfilePathPrefix is the path prefix the response profile can be selected with (`/profile/{name}`).
+const ProfilePathPrefix = "/profile/"
+
+// extractProfile returns the response profile (and its normalized name) selected by the request path or (if the
+// path does not select any) the request header.
+func extractProfile(
+	path string, headers *fasthttp.RequestHeader, profiles config.Profiles,
+) (config.Profile, string, bool) {
+	if len(profiles) == 0 {
+		return config.Profile{}, "", false
+	}
+
+	if name, ok := strings.CutPrefix(path, ProfilePathPrefix); ok {
+		name = strings.ToLower(strings.TrimSuffix(name, "/"))
+
+		if profile, found := profiles.Find(name); found {
+			return profile, name, true
+		}
+	}
+
+	if headers != nil {
+		var name = strings.ToLower(strings.TrimSpace(string(headers.Peek(profileHeader))))
+
+		if profile, found := profiles.Find(name); found {
+			return profile, name, true
+		}
+	}
+
+	return config.Profile{}, "", false
+}
+
+// RequestContainsProfile checks if the given request path or headers select a known response profile.
+func RequestContainsProfile(path string, headers *fasthttp.RequestHeader, profiles config.Profiles) (ok bool) {
+	_, _, ok = extractProfile(path, headers, profiles)
+
+	return
+}
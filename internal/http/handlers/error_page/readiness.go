@@ -0,0 +1,23 @@
+package error_page
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Readiness tracks the pending tasks (e.g. warming up or reloading), during which the service is not ready to
+// serve the configured error pages. The zero value is ready. It is safe for concurrent use.
+type Readiness struct{ pending atomic.Int32 }
+
+// Begin marks a task as started; the service is not ready until the returned function is called (it may be called
+// more than once, only the first call counts).
+func (r *Readiness) Begin() (done func()) {
+	r.pending.Add(1)
+
+	var once sync.Once
+
+	return func() { once.Do(func() { r.pending.Add(-1) }) }
+}
+
+// Ready reports whether there are no pending tasks. A nil readiness is always ready.
+func (r *Readiness) Ready() bool { return r == nil || r.pending.Load() <= 0 }
@@ -0,0 +1,39 @@
+package error_page
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// recoveryScript returns the script that polls the check URL (with a jitter, to spread the requests of many open
+// pages) and reloads the page once the upstream is healthy again (the check endpoint responds with 204).
+func recoveryScript(checkURL string, interval time.Duration) string {
+	var u, _ = json.Marshal(checkURL) // the <, >, and & are escaped, so it's safe inside the script element
+
+	return fmt.Sprintf(`<script>(()=>{const u=%s,i=%d;const p=()=>setTimeout(()=>fetch(u,{cache:"no-store",`+
+		`credentials:"omit"}).then((r)=>{r.status===204?location.reload():p()}).catch(p),i*(.8+Math.random()*.4));`+
+		`p()})()</script>`, u, max(interval, time.Second).Milliseconds())
+}
+
+// injectScript inserts the script before the closing body tag (or appends it, if the tag is missing).
+func injectScript(buf *bytes.Buffer, script string) {
+	const tag = "</body>"
+
+	var html = buf.Bytes()
+
+	for i := len(html) - len(tag); i >= 0; i-- { // the tag case is ignored (the byte offsets are kept)
+		if html[i] == '<' && bytes.EqualFold(html[i:i+len(tag)], []byte(tag)) {
+			var tail = bytes.Clone(html[i:]) // the closing tags only
+
+			buf.Truncate(i)
+			buf.WriteString(script)
+			buf.Write(tail)
+
+			return
+		}
+	}
+
+	buf.WriteString(script)
+}
@@ -0,0 +1,43 @@
+package error_page
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryScript(t *testing.T) {
+	t.Parallel()
+
+	var script = recoveryScript("/check?a=1&b=</script>", 100*time.Millisecond)
+
+	assert.Contains(t, script, `const u="/check?a=1\u0026b=\u003c/script\u003e",i=1000;`) // escaped, 1s at least
+	assert.Regexp(t, `^<script>[^<]+</script>$`, script)
+}
+
+func TestInjectScript(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		give, want string
+	}{
+		"body":       {give: "<html><body>foo</body></html>", want: "<html><body>foo<s></body></html>"},
+		"upper case": {give: "<BODY>foo</BODY>", want: "<BODY>foo<s></BODY>"},
+		"last one":   {give: "<body>'</body>'</body>", want: "<body>'</body>'<s></body>"},
+		"unicode":    {give: "<body>İİ</body>", want: "<body>İİ<s></body>"},
+		"no body":    {give: "foo", want: "foo<s>"},
+		"empty":      {give: "", want: "<s>"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf = bytes.NewBufferString(tt.give)
+
+			injectScript(buf, "<s>")
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
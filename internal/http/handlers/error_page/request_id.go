@@ -0,0 +1,242 @@
+package error_page
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// requestIDGenerator generates the request IDs using the configured layout (the upstream request IDs are reused).
+type requestIDGenerator struct {
+	headers []string
+	prefix  string
+	cfRay   bool
+	layout  []config.RequestIDPart
+	flake   *sonyflake // nil if not used by the layout
+}
+
+// cfRayHeader is the Cloudflare request ID header (https://developers.cloudflare.com/fundamentals/reference/cf-ray/).
+const cfRayHeader = "CF-Ray"
+
+// newRequestIDGenerator creates a new request ID generator. The prefix defaults to the datacenter code, and the
+// layout defaults to the one of the format.
+func newRequestIDGenerator(
+	format config.RequestIDFormat,
+	settings config.RequestID,
+	dcCode string,
+) *requestIDGenerator {
+	var g = requestIDGenerator{headers: settings.Headers, prefix: settings.Prefix, cfRay: settings.CFRay}
+
+	if g.prefix == "" {
+		g.prefix = dcCode
+	}
+
+	var err error
+
+	if g.layout, err = config.ParseRequestIDLayout(settings.Layout); err != nil { // empty, or not validated
+		g.layout, _ = config.ParseRequestIDLayout(format.Layout())
+	}
+
+	for _, part := range g.layout {
+		if part.Placeholder == "sonyflake" {
+			g.flake = newSonyflake(machineID(dcCode))
+		}
+	}
+
+	return &g
+}
+
+// generate returns the request ID. If the Cloudflare `CF-Ray` passthrough is enabled and the header is valid, its
+// value is returned as-is. If upstream has a valid request ID header, {prefix}-{value} is returned (see
+// sanitizeRequestID). Otherwise, a new ID is generated using the layout, like (by the format):
+//
+//   - default: {prefix}-{rand:5}-{uuidv7}
+//   - ulid: {prefix}-{ulid}
+//   - sonyflake: {prefix}-{sonyflake}
+func (g *requestIDGenerator) generate(reqHeaders *fasthttp.RequestHeader) string {
+	if g.cfRay {
+		if ray, ok := sanitizeRequestID(reqHeaders.Peek(cfRayHeader)); ok {
+			return string(ray)
+		}
+	}
+
+	// Check for upstream request ID headers
+	for _, name := range g.headers {
+		if upstreamID, ok := sanitizeRequestID(reqHeaders.Peek(name)); ok {
+			return g.prefix + "-" + string(upstreamID)
+		}
+	}
+
+	var b strings.Builder
+
+	for _, part := range g.layout {
+		switch part.Placeholder {
+		case "":
+			b.WriteString(part.Literal)
+		case "prefix":
+			b.WriteString(g.prefix)
+		case "rand":
+			randomBytes := make([]byte, part.Size)
+			if _, err := rand.Read(randomBytes); err != nil {
+				// fallback to zeros if crypto/rand fails (the rest of the ID is still unique)
+				clear(randomBytes)
+			}
+
+			b.WriteString(hex.EncodeToString(randomBytes))
+		case "uuidv7":
+			id, err := uuid.NewV7()
+			if err != nil {
+				// fallback to UUID v4 if v7 fails
+				id = uuid.New()
+			}
+
+			b.WriteString(strings.ReplaceAll(id.String(), "-", ""))
+		case "uuidv4":
+			b.WriteString(strings.ReplaceAll(uuid.New().String(), "-", ""))
+		case "ulid":
+			b.WriteString(newULID(time.Now()))
+		case "sonyflake":
+			fmt.Fprintf(&b, "%016x", g.flake.next()) // fixed width keeps the lexicographic order
+		case "unixms":
+			b.WriteString(strconv.FormatInt(time.Now().UnixMilli(), 10))
+		}
+	}
+
+	return b.String()
+}
+
+// isUpstreamHeader reports whether the header (case-insensitive) carries the upstream request ID.
+func (g *requestIDGenerator) isUpstreamHeader(name string) bool {
+	if g.cfRay && strings.EqualFold(name, cfRayHeader) {
+		return true
+	}
+
+	for _, h := range g.headers {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxRequestIDLength limits the length of the upstream request ID echoed in the page and the response headers.
+const maxRequestIDLength = 128
+
+// sanitizeRequestID validates the upstream request ID and truncates it to the maxRequestIDLength. Only letters,
+// digits and "-_.:+=/@" are allowed - otherwise (or if the value is empty), false is returned.
+func sanitizeRequestID(v []byte) ([]byte, bool) {
+	if len(v) == 0 {
+		return nil, false
+	}
+
+	if len(v) > maxRequestIDLength {
+		v = v[:maxRequestIDLength]
+	}
+
+	for _, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("-_.:+=/@", c) >= 0:
+		default:
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+// crockford is the Crockford's base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID generates a new ULID (https://github.com/ulid/spec): 48 bits of the Unix time in milliseconds followed
+// by 80 random bits, encoded as 26 characters of the Crockford's base32.
+func newULID(now time.Time) string {
+	var (
+		hi = uint64(now.UnixMilli()) << 16 //nolint:gosec,mnd // the top 48 bits are the timestamp
+		lo uint64
+		rb [10]byte
+	)
+
+	_, _ = rand.Read(rb[:]) // never returns an error
+
+	hi |= uint64(binary.BigEndian.Uint16(rb[:2]))
+	lo = binary.BigEndian.Uint64(rb[2:])
+
+	var out [26]byte
+
+	for i := len(out) - 1; i >= 0; i-- { // 128 bits are encoded from the least significant 5 bits
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(out[:])
+}
+
+// Sonyflake ID layout (https://github.com/sony/sonyflake): 39 bits of the time in 10 ms units since the epoch,
+// 8 bits of the sequence number, and 16 bits of the machine ID.
+const (
+	sonyflakeTimeUnit    = 10 * time.Millisecond
+	sonyflakeBitsSeq     = 8
+	sonyflakeBitsMachine = 16
+	sonyflakeMaskSeq     = 1<<sonyflakeBitsSeq - 1
+)
+
+var sonyflakeEpoch = time.Date(2014, time.September, 1, 0, 0, 0, 0, time.UTC) //nolint:gochecknoglobals
+
+// sonyflake is a Sonyflake ID generator, safe for concurrent use.
+type sonyflake struct {
+	mu        sync.Mutex
+	machineID uint16
+	elapsed   int64 // in the time units since the epoch
+	sequence  uint16
+}
+
+func newSonyflake(machineID uint16) *sonyflake {
+	return &sonyflake{machineID: machineID}
+}
+
+// next returns the next unique ID. If the sequence overflows, it waits for the next time unit.
+func (s *sonyflake) next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current = int64(time.Since(sonyflakeEpoch) / sonyflakeTimeUnit)
+
+	if s.elapsed < current {
+		s.elapsed, s.sequence = current, 0
+	} else { // the same time unit (or the clock moved backwards)
+		if s.sequence = (s.sequence + 1) & sonyflakeMaskSeq; s.sequence == 0 {
+			s.elapsed++
+
+			time.Sleep(time.Until(sonyflakeEpoch.Add(time.Duration(s.elapsed) * sonyflakeTimeUnit)))
+		}
+	}
+
+	return uint64(s.elapsed)<<(sonyflakeBitsSeq+sonyflakeBitsMachine) | //nolint:gosec
+		uint64(s.sequence)<<sonyflakeBitsMachine |
+		uint64(s.machineID)
+}
+
+// machineID derives the 16-bit Sonyflake machine ID from the datacenter code.
+func machineID(code string) uint16 {
+	var h = fnv.New32a()
+
+	_, _ = h.Write([]byte(code))
+
+	var sum = h.Sum32()
+
+	return uint16(sum>>16 ^ sum&0xffff) //nolint:gosec,mnd
+}
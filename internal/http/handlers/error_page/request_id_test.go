@@ -0,0 +1,199 @@
+package error_page
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestRequestIDGenerator_Generate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		empty    fasthttp.RequestHeader
+		defaults = config.New().RequestID
+	)
+
+	t.Run("upstream", func(t *testing.T) {
+		t.Parallel()
+
+		var headers fasthttp.RequestHeader
+
+		headers.Set("X-Request-Id", "foo")
+
+		for _, format := range config.RequestIDFormats() {
+			assert.Equal(t, "ABCD-foo", newRequestIDGenerator(format, defaults, "ABCD").generate(&headers))
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		var id = newRequestIDGenerator(config.RequestIDFormatDefault, defaults, "ABCD").generate(&empty)
+
+		assert.Regexp(t, `^ABCD-[0-9a-f]{10}-[0-9a-f]{32}$`, id)
+	})
+
+	t.Run("ulid", func(t *testing.T) {
+		t.Parallel()
+
+		var id = newRequestIDGenerator(config.RequestIDFormatULID, defaults, "ABCD").generate(&empty)
+
+		assert.Regexp(t, `^ABCD-[0-9A-HJKMNP-TV-Z]{26}$`, id)
+	})
+
+	t.Run("sonyflake", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			gen  = newRequestIDGenerator(config.RequestIDFormatSonyflake, defaults, "ABCD")
+			prev string
+		)
+
+		for range 1000 {
+			var id = gen.generate(&empty)
+
+			require.Regexp(t, `^ABCD-[0-9a-f]{16}$`, id)
+			require.Greater(t, id, prev)
+
+			prev = id
+		}
+	})
+
+	t.Run("custom layout and prefix", func(t *testing.T) {
+		t.Parallel()
+
+		var settings = config.RequestID{Prefix: "edge", Layout: "{prefix}.{unixms}.{rand:2}.{sonyflake}"}
+
+		var id = newRequestIDGenerator(config.RequestIDFormatDefault, settings, "ABCD").generate(&empty)
+
+		assert.Regexp(t, `^edge\.[0-9]{13}\.[0-9a-f]{4}\.[0-9a-f]{16}$`, id)
+	})
+
+	t.Run("invalid layout falls back to the format", func(t *testing.T) {
+		t.Parallel()
+
+		var id = newRequestIDGenerator(config.RequestIDFormatULID, config.RequestID{Layout: "{nope}"}, "ABCD").
+			generate(&empty)
+
+		assert.Regexp(t, `^ABCD-[0-9A-HJKMNP-TV-Z]{26}$`, id)
+	})
+
+	t.Run("custom headers", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			settings = config.RequestID{Headers: []string{"X-Correlation-Id"}}
+			gen      = newRequestIDGenerator(config.RequestIDFormatDefault, settings, "ABCD")
+			headers  fasthttp.RequestHeader
+		)
+
+		headers.Set("X-Request-Id", "foo")
+		assert.NotEqual(t, "ABCD-foo", gen.generate(&headers)) // not in the list anymore
+
+		headers.Set("X-Correlation-Id", "bar")
+		assert.Equal(t, "ABCD-bar", gen.generate(&headers))
+
+		assert.True(t, gen.isUpstreamHeader("x-correlation-id"))
+		assert.False(t, gen.isUpstreamHeader("X-Request-Id"))
+		assert.False(t, gen.isUpstreamHeader("CF-Ray"))
+	})
+
+	t.Run("cf-ray", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			settings = config.RequestID{Headers: defaults.Headers, CFRay: true}
+			gen      = newRequestIDGenerator(config.RequestIDFormatDefault, settings, "ABCD")
+			headers  fasthttp.RequestHeader
+		)
+
+		headers.Set("X-Request-Id", "foo")
+		assert.Equal(t, "ABCD-foo", gen.generate(&headers))
+
+		headers.Set("Cf-Ray", "8f1d2c3b4a5e6f70-AMS")
+		assert.Equal(t, "8f1d2c3b4a5e6f70-AMS", gen.generate(&headers)) // as-is, and takes precedence
+
+		headers.Set("Cf-Ray", "<b>")
+		assert.Equal(t, "ABCD-foo", gen.generate(&headers)) // invalid values are ignored
+
+		assert.True(t, gen.isUpstreamHeader("cf-ray"))
+	})
+}
+
+func TestSanitizeRequestID(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		give   string
+		want   string
+		wantOk bool
+	}{
+		"empty":          {give: "", wantOk: false},
+		"uuid":           {give: "0d1e9a4c-5f5b-4c8a-9b43-5e7c2f3a1b6d", want: "0d1e9a4c-5f5b-4c8a-9b43-5e7c2f3a1b6d", wantOk: true},
+		"trace":          {give: "Root=1-67a2b3c4-abc;Parent=def", wantOk: false},
+		"punctuation":    {give: "a_b.c:d+e=f/g@h", want: "a_b.c:d+e=f/g@h", wantOk: true},
+		"html":           {give: "<b>", wantOk: false},
+		"space":          {give: "foo bar", wantOk: false},
+		"control":        {give: "foo\x00bar", wantOk: false},
+		"non-ascii":      {give: "фуу", wantOk: false},
+		"length cap":     {give: strings.Repeat("a", maxRequestIDLength+1), want: strings.Repeat("a", maxRequestIDLength), wantOk: true},
+		"tail truncated": {give: strings.Repeat("a", maxRequestIDLength) + "<", want: strings.Repeat("a", maxRequestIDLength), wantOk: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := sanitizeRequestID([]byte(tt.give))
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+
+	var gen = newRequestIDGenerator(config.RequestIDFormatDefault, config.New().RequestID, "ABCD")
+
+	assert.True(t, gen.isUpstreamHeader("x-request-id"))
+	assert.True(t, gen.isUpstreamHeader("X-RequestID"))
+	assert.False(t, gen.isUpstreamHeader("X-Trace-Id"))
+}
+
+func TestNewULID(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now   = time.Now()
+		early = newULID(now)
+		late  = newULID(now.Add(time.Millisecond))
+	)
+
+	assert.Len(t, early, 26)
+	assert.Less(t, early, late)
+	assert.Equal(t, newULID(now)[:10], early[:10]) // the same timestamp part
+	assert.Empty(t, strings.Trim(early, crockford))
+}
+
+func TestSonyflake_Next(t *testing.T) {
+	t.Parallel()
+
+	var (
+		flake = newSonyflake(machineID("ABCD"))
+		prev  uint64
+	)
+
+	for range 1000 {
+		var id = flake.next()
+
+		require.Greater(t, id, prev)
+		require.Equal(t, uint64(machineID("ABCD")), id&0xffff)
+
+		prev = id
+	}
+
+	assert.NotEqual(t, machineID("ABCD"), machineID("EFGH"))
+}
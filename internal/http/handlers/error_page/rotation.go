@@ -0,0 +1,266 @@
+package error_page
+
+import (
+	"errors"
+	"fmt"
+	mathRand "math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/binaryYuki/error-pages/internal/clock"
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+// ErrNotSwitchable is returned when the template switch is forced in the rotation mode that does not have a single
+// active template (disabled, per-request, or experiment).
+var ErrNotSwitchable = errors.New("the template can't be switched in this rotation mode")
+
+type (
+	// RotationControl exposes the template rotation state of the handler and allows to force the template switch.
+	// It becomes usable after the handler is created with the [WithRotationControl] option. The same control may be
+	// bound to the handlers of the reloaded configurations - the active template is kept then (see [rotator.inherit]).
+	RotationControl struct{ r atomic.Pointer[rotator] }
+
+	// RotationState describes the template rotation state.
+	RotationState struct {
+		Mode           string     `json:"mode"`
+		ActiveTemplate string     `json:"active_template,omitempty"` // empty until the first request (hourly/daily)
+		ChangedAt      *time.Time `json:"changed_at,omitempty"`      // when the active template was changed
+		NextSwitchAt   *time.Time `json:"next_switch_at,omitempty"`  // the earliest time of the scheduled switch
+		Templates      []string   `json:"templates"`                 // all the available templates
+	}
+)
+
+// State returns the current rotation state.
+func (c *RotationControl) State() RotationState {
+	if c == nil || c.r.Load() == nil {
+		return RotationState{}
+	}
+
+	return c.r.Load().state()
+}
+
+// Switch forces the switch to another (randomly picked) template and returns the new state. [ErrNotSwitchable] is
+// returned if the rotation mode has no single active template.
+func (c *RotationControl) Switch() (RotationState, error) {
+	if c == nil || c.r.Load() == nil {
+		return RotationState{}, ErrNotSwitchable
+	}
+
+	return c.r.Load().force()
+}
+
+// rotator decides which template to use based on the rotation mode. Every handler has its own rotator, so the
+// handlers created with different configs do not share the rotation state.
+type rotator struct {
+	cfg   *config.Config
+	log   *logger.Logger // optional
+	gauge *metrics.Gauge // reports the active template (1) and the previous ones (0)
+	clock clock.Clock    // the time source of the scheduled (hourly and daily) switches
+
+	onSwitch func(prev string) // called when the active template is replaced (optional)
+
+	mu        sync.Mutex                // serializes the template switches
+	active    atomic.Pointer[string]    // the active template name (not used with the per-request rotation)
+	changedAt atomic.Pointer[time.Time] // the time when the active template was changed last time
+}
+
+// newRotator creates a new rotator. With the random-on-startup mode, the template is picked here (the
+// user-provided template name is ignored).
+func newRotator(cfg *config.Config, log *logger.Logger, reg *metrics.Registry, clk clock.Clock) *rotator {
+	var r = rotator{
+		cfg:   cfg,
+		log:   log,
+		clock: clk,
+		gauge: reg.Gauge(
+			"error_pages_active_template", "The template used to render the HTML error pages (1 = active)", "template",
+		),
+	}
+
+	switch cfg.RotationMode { //nolint:exhaustive // the rest of the modes pick the template on the request
+	case config.RotationModeRandomOnStartup:
+		r.activate(cfg.Templates.RandomName(), clk.Now())
+	case config.RotationModeDisabled:
+		r.activate(cfg.TemplateName, clk.Now())
+	}
+
+	return &r
+}
+
+// inherit takes over the active template of the previous rotator (of the configuration before the reload), so the
+// picked (or forced) template is not changed by the reload. Nothing is inherited if the rotation mode is changed, or
+// the template is not available anymore.
+func (r *rotator) inherit(prev *rotator) {
+	if prev == nil || prev.cfg.RotationMode != r.cfg.RotationMode {
+		return
+	}
+
+	switch r.cfg.RotationMode { //nolint:exhaustive // no single active template (or it's configured) in the others
+	case config.RotationModeRandomOnStartup, config.RotationModeRandomHourly, config.RotationModeRandomDaily:
+	default:
+		return
+	}
+
+	name, changedAt := prev.active.Load(), prev.changedAt.Load()
+	if name == nil || changedAt == nil {
+		return
+	}
+
+	if _, ok := r.cfg.Templates.Get(*name); ok {
+		r.activate(*name, *changedAt)
+	}
+}
+
+// pick returns the template name to use for the current request (the experiment variants are picked by the
+// experiment itself, so the configured template name is returned in this mode).
+func (r *rotator) pick() string {
+	switch rotationMode := r.cfg.RotationMode; rotationMode {
+	case config.RotationModeDisabled, config.RotationModeExperiment:
+		return r.cfg.TemplateName
+	case config.RotationModeRandomOnStartup:
+		return *r.active.Load() // picked once, when the rotator was created
+	case config.RotationModeRandomOnEachRequest:
+		return r.cfg.Templates.RandomName() // pick a random template on each request
+	case config.RotationModeRandomHourly, config.RotationModeRandomDaily:
+		var now = r.clock.Now()
+
+		if name, changedAt := r.active.Load(), r.changedAt.Load(); name != nil && changedAt != nil &&
+			!r.due(*changedAt, now) {
+			return *name // time to change the template has not come yet, so use the last picked template
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		// check again, the template may have been switched by the concurrent request
+		if name, changedAt := r.active.Load(), r.changedAt.Load(); name != nil && changedAt != nil &&
+			!r.due(*changedAt, now) {
+			return *name
+		}
+
+		var name = r.cfg.Templates.RandomName()
+
+		r.activate(name, now)
+
+		return name
+	}
+
+	return r.cfg.TemplateName // the fallback of the fallback :D
+}
+
+// due reports whether it's time to change the template (for the hourly and daily rotation).
+func (r *rotator) due(changedAt, now time.Time) bool {
+	switch r.cfg.RotationMode { //nolint:exhaustive // only the scheduled modes
+	case config.RotationModeRandomHourly:
+		return changedAt.Hour() != now.Hour() || now.Sub(changedAt) >= time.Hour
+	case config.RotationModeRandomDaily:
+		return changedAt.Day() != now.Day() || now.Sub(changedAt) >= 24*time.Hour
+	}
+
+	return false
+}
+
+// activate remembers the active template name and reports it when it was changed.
+func (r *rotator) activate(name string, now time.Time) {
+	var prev = r.active.Swap(&name)
+
+	r.changedAt.Store(&now)
+
+	if prev != nil && *prev == name {
+		return // the same template was picked
+	}
+
+	if prev != nil {
+		r.gauge.Set(0, *prev)
+
+		if r.onSwitch != nil {
+			r.onSwitch(*prev)
+		}
+	}
+
+	r.gauge.Set(1, name)
+
+	if r.log != nil {
+		r.log.Info("Active template changed",
+			logger.String("name", name),
+			logger.String("rotation mode", r.cfg.RotationMode.String()),
+		)
+	}
+}
+
+// state returns the current rotation state.
+func (r *rotator) state() RotationState {
+	var state = RotationState{Mode: r.cfg.RotationMode.String(), Templates: r.cfg.Templates.Names()}
+
+	switch r.cfg.RotationMode { //nolint:exhaustive // no single active template in the other modes
+	case config.RotationModeDisabled, config.RotationModeRandomOnStartup,
+		config.RotationModeRandomHourly, config.RotationModeRandomDaily:
+		if name, changedAt := r.active.Load(), r.changedAt.Load(); name != nil && changedAt != nil {
+			state.ActiveTemplate, state.ChangedAt = *name, changedAt
+			state.NextSwitchAt = nextSwitchAt(r.cfg.RotationMode, *changedAt)
+		}
+	}
+
+	return state
+}
+
+// force switches to another (randomly picked) template.
+func (r *rotator) force() (RotationState, error) {
+	switch r.cfg.RotationMode { //nolint:exhaustive // the other modes can't be switched
+	case config.RotationModeRandomOnStartup, config.RotationModeRandomHourly, config.RotationModeRandomDaily:
+		r.mu.Lock()
+
+		var current string
+
+		if name := r.active.Load(); name != nil {
+			current = *name
+		}
+
+		r.activate(randomTemplateExcept(r.cfg.Templates.Names(), current), r.clock.Now())
+		r.mu.Unlock()
+
+		return r.state(), nil
+	}
+
+	return RotationState{}, fmt.Errorf("%w: %s", ErrNotSwitchable, r.cfg.RotationMode)
+}
+
+// nextSwitchAt returns the time when the hourly or daily rotation switches the template changed at the given time
+// (the switch happens on the first request after this time). Nil is returned for the other modes.
+func nextSwitchAt(mode config.RotationMode, changedAt time.Time) *time.Time {
+	var (
+		y, m, d = changedAt.Date()
+		next    time.Time
+	)
+
+	switch mode { //nolint:exhaustive // the other modes are not scheduled
+	case config.RotationModeRandomHourly:
+		next = time.Date(y, m, d, changedAt.Hour()+1, 0, 0, 0, changedAt.Location())
+	case config.RotationModeRandomDaily:
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, changedAt.Location())
+	default:
+		return nil
+	}
+
+	return &next
+}
+
+// randomTemplateExcept returns a random template name other than the given one (if possible).
+func randomTemplateExcept(names []string, except string) string {
+	var candidates = make([]string, 0, len(names))
+
+	for _, name := range names {
+		if name != except {
+			candidates = append(candidates, name)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return except
+	}
+
+	return candidates[mathRand.IntN(len(candidates))] //nolint:gosec
+}
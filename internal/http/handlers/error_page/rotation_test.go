@@ -0,0 +1,193 @@
+package error_page
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/clock"
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestRotator_Isolation(t *testing.T) {
+	t.Parallel()
+
+	var newConfig = func(templates ...string) *config.Config {
+		var cfg = config.New()
+
+		cfg.RotationMode = config.RotationModeRandomHourly
+		cfg.Templates = make(map[string]string, len(templates))
+
+		for _, name := range templates {
+			cfg.Templates[name] = name
+		}
+
+		return &cfg
+	}
+
+	var (
+		first  = newRotator(newConfig("a1", "a2"), nil, nil, clock.System)
+		second = newRotator(newConfig("b1", "b2"), nil, nil, clock.System)
+	)
+
+	var firstPicked, secondPicked = first.pick(), second.pick()
+
+	assert.Contains(t, []string{"a1", "a2"}, firstPicked)
+	assert.Contains(t, []string{"b1", "b2"}, secondPicked)
+
+	for range 100 { // the picked templates are kept until the next hour
+		assert.Equal(t, firstPicked, first.pick())
+		assert.Equal(t, secondPicked, second.pick())
+	}
+
+	var switchedFrom []string
+
+	second.onSwitch = func(prev string) { switchedFrom = append(switchedFrom, prev) }
+
+	_, err := second.force()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{secondPicked}, switchedFrom)
+
+	assert.Equal(t, firstPicked, first.pick()) // not affected by the other rotator
+	assert.NotEqual(t, secondPicked, second.pick())
+}
+
+func TestRotator_Scheduled(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.RotationMode = config.RotationModeRandomHourly
+	cfg.Templates = map[string]string{"a": "a", "b": "b", "c": "c"}
+
+	var (
+		start = time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC)
+		fake  = clock.NewFake(start)
+		r     = newRotator(&cfg, nil, nil, fake)
+		picks int
+	)
+
+	r.onSwitch = func(string) { picks++ }
+
+	var picked = r.pick()
+
+	assert.Equal(t, start, *r.state().ChangedAt)
+	assert.Equal(t, time.Date(2024, time.June, 1, 11, 0, 0, 0, time.UTC), *r.state().NextSwitchAt)
+
+	fake.Advance(44 * time.Minute) // 10:59
+
+	assert.Equal(t, picked, r.pick()) // the same hour
+	assert.Equal(t, start, *r.state().ChangedAt)
+
+	fake.Advance(time.Minute) // 11:00
+
+	r.pick() // the template is picked again (randomly, so it may be the same one)
+
+	assert.Equal(t, start.Add(45*time.Minute), *r.state().ChangedAt)
+	assert.Equal(t, time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC), *r.state().NextSwitchAt)
+	assert.LessOrEqual(t, picks, 1)
+}
+
+func TestRotator_Inherit(t *testing.T) {
+	t.Parallel()
+
+	var newConfig = func(mode config.RotationMode, templates ...string) *config.Config {
+		var cfg = config.New()
+
+		cfg.RotationMode = mode
+		cfg.Templates = make(map[string]string, len(templates))
+
+		for _, name := range templates {
+			cfg.Templates[name] = name
+		}
+
+		return &cfg
+	}
+
+	var (
+		fake = clock.NewFake(time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC))
+		prev = newRotator(newConfig(config.RotationModeRandomHourly, "a", "b", "c"), nil, nil, fake)
+	)
+
+	var picked = prev.pick()
+
+	fake.Advance(10 * time.Minute)
+
+	var kept = newRotator(newConfig(config.RotationModeRandomHourly, "a", "b", "c", "d"), nil, nil, fake)
+
+	kept.inherit(prev)
+
+	assert.Equal(t, picked, kept.pick())
+	assert.Equal(t, *prev.state().ChangedAt, *kept.state().ChangedAt) // the schedule is kept too
+
+	var otherMode = newRotator(newConfig(config.RotationModeRandomDaily, "a", "b", "c"), nil, nil, fake)
+
+	otherMode.inherit(prev)
+
+	assert.Nil(t, otherMode.active.Load()) // picked on the first request
+
+	var removed = newRotator(newConfig(config.RotationModeRandomHourly, "x"), nil, nil, fake)
+
+	removed.inherit(prev)
+
+	assert.Equal(t, "x", removed.pick())
+}
+
+func TestRotator_Due(t *testing.T) {
+	t.Parallel()
+
+	var (
+		hourly = rotator{cfg: &config.Config{RotationMode: config.RotationModeRandomHourly}}
+		daily  = rotator{cfg: &config.Config{RotationMode: config.RotationModeRandomDaily}}
+		at     = time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC)
+	)
+
+	assert.False(t, hourly.due(at, at.Add(30*time.Minute)))
+	assert.True(t, hourly.due(at, at.Add(45*time.Minute)))
+	assert.True(t, hourly.due(at, at.Add(24*time.Hour))) // the same hour of the next day
+
+	assert.False(t, daily.due(at, at.Add(12*time.Hour)))
+	assert.True(t, daily.due(at, at.Add(14*time.Hour)))
+	assert.True(t, daily.due(at, at.AddDate(0, 1, 0))) // the same day of the next month
+}
+
+func TestNextSwitchAt(t *testing.T) {
+	t.Parallel()
+
+	var changedAt = time.Date(2024, time.December, 31, 23, 15, 0, 0, time.UTC)
+
+	for name, tt := range map[string]struct {
+		giveMode config.RotationMode
+		want     *time.Time
+	}{
+		"hourly":   {giveMode: config.RotationModeRandomHourly, want: ptr(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))},
+		"daily":    {giveMode: config.RotationModeRandomDaily, want: ptr(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))},
+		"startup":  {giveMode: config.RotationModeRandomOnStartup},
+		"disabled": {giveMode: config.RotationModeDisabled},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, nextSwitchAt(tt.giveMode, changedAt))
+		})
+	}
+
+	assert.Equal(t, // not the end of the day
+		time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC),
+		*nextSwitchAt(config.RotationModeRandomDaily, time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC)),
+	)
+}
+
+func TestRandomTemplateExcept(t *testing.T) {
+	t.Parallel()
+
+	for range 100 {
+		assert.NotEqual(t, "foo", randomTemplateExcept([]string{"foo", "bar", "baz"}, "foo"))
+	}
+
+	assert.Equal(t, "foo", randomTemplateExcept([]string{"foo"}, "foo")) // the only one
+	assert.Equal(t, "foo", randomTemplateExcept(nil, "foo"))
+}
+
+func ptr[T any](v T) *T { return &v }
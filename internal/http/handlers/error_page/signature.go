@@ -0,0 +1,93 @@
+package error_page
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// SignatureHeader is the response header with the signature of the rendered body, in the `{algorithm}={base64}`
+// format (e.g. `ed25519=...`).
+const SignatureHeader = "X-Error-Page-Signature"
+
+const minHMACKeyLength = 16
+
+// signer signs the rendered response bodies, so the downstream proxies or clients can verify the body was produced
+// by the error pages and not tampered with in transit.
+type signer struct {
+	alg     config.SigningAlgorithm
+	hmacKey []byte
+	private ed25519.PrivateKey
+}
+
+// newSigner creates a new signer. The key is the shared secret for HMAC-SHA256, or the base64-encoded Ed25519
+// seed (32 bytes) or private key (64 bytes). Nil is returned for the [config.SigningNone] algorithm.
+func newSigner(alg config.SigningAlgorithm, key string) (*signer, error) {
+	switch alg {
+	case config.SigningNone:
+		return nil, nil //nolint:nilnil
+
+	case config.SigningHMACSHA256:
+		if len(key) < minHMACKeyLength {
+			return nil, fmt.Errorf("the HMAC signing key is too short (at least %d bytes expected)", minHMACKeyLength)
+		}
+
+		return &signer{alg: alg, hmacKey: []byte(key)}, nil
+
+	case config.SigningEd25519:
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("wrong Ed25519 signing key (base64 expected): %w", err)
+		}
+
+		switch len(raw) {
+		case ed25519.SeedSize:
+			return &signer{alg: alg, private: ed25519.NewKeyFromSeed(raw)}, nil
+		case ed25519.PrivateKeySize:
+			return &signer{alg: alg, private: ed25519.PrivateKey(raw)}, nil
+		}
+
+		return nil, fmt.Errorf(
+			"wrong Ed25519 signing key size (%d or %d bytes expected)", ed25519.SeedSize, ed25519.PrivateKeySize,
+		)
+	}
+
+	return nil, errors.New("unsupported signing algorithm: " + alg.String())
+}
+
+// CheckSigningKey validates the signing key for the algorithm.
+func CheckSigningKey(alg config.SigningAlgorithm, key string) error {
+	_, err := newSigner(alg, key)
+
+	return err
+}
+
+// sign returns the signature header value for the body.
+func (s *signer) sign(body []byte) string {
+	var sig []byte
+
+	if s.alg == config.SigningEd25519 {
+		sig = ed25519.Sign(s.private, body)
+	} else {
+		var mac = hmac.New(sha256.New, s.hmacKey)
+
+		_, _ = mac.Write(body)
+		sig = mac.Sum(nil)
+	}
+
+	return s.alg.String() + "=" + base64.StdEncoding.EncodeToString(sig)
+}
+
+// publicKey returns the base64-encoded Ed25519 public key (empty for HMAC).
+func (s *signer) publicKey() string {
+	if s.alg != config.SigningEd25519 {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(s.private.Public().(ed25519.PublicKey)) //nolint:forcetypeassert
+}
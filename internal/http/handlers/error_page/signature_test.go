@@ -0,0 +1,81 @@
+package error_page
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+func TestSigner(t *testing.T) {
+	t.Parallel()
+
+	var body = []byte("<p>503</p>")
+
+	t.Run("none", func(t *testing.T) {
+		t.Parallel()
+
+		s, err := newSigner(config.SigningNone, "")
+
+		require.NoError(t, err)
+		assert.Nil(t, s)
+	})
+
+	t.Run("hmac", func(t *testing.T) {
+		t.Parallel()
+
+		const key = "0123456789abcdef"
+
+		s, err := newSigner(config.SigningHMACSHA256, key)
+		require.NoError(t, err)
+
+		var mac = hmac.New(sha256.New, []byte(key))
+
+		_, _ = mac.Write(body)
+
+		assert.Equal(t, "hmac-sha256="+base64.StdEncoding.EncodeToString(mac.Sum(nil)), s.sign(body))
+		assert.Empty(t, s.publicKey())
+
+		_, err = newSigner(config.SigningHMACSHA256, "short")
+		assert.ErrorContains(t, err, "too short")
+	})
+
+	t.Run("ed25519", func(t *testing.T) {
+		t.Parallel()
+
+		var seed = make([]byte, ed25519.SeedSize)
+
+		for i := range seed {
+			seed[i] = byte(i)
+		}
+
+		var private = ed25519.NewKeyFromSeed(seed)
+
+		for _, key := range [][]byte{seed, private} {
+			s, err := newSigner(config.SigningEd25519, base64.StdEncoding.EncodeToString(key))
+			require.NoError(t, err)
+
+			sig, ok := strings.CutPrefix(s.sign(body), "ed25519=")
+			require.True(t, ok)
+
+			rawSig, err := base64.StdEncoding.DecodeString(sig)
+			require.NoError(t, err)
+
+			pub, err := base64.StdEncoding.DecodeString(s.publicKey())
+			require.NoError(t, err)
+
+			assert.True(t, ed25519.Verify(pub, body, rawSig))
+			assert.False(t, ed25519.Verify(pub, []byte("tampered"), rawSig))
+		}
+
+		assert.ErrorContains(t, CheckSigningKey(config.SigningEd25519, "not base64!"), "base64 expected")
+		assert.ErrorContains(t, CheckSigningKey(config.SigningEd25519, "Zm9v"), "wrong Ed25519 signing key size")
+	})
+}
@@ -0,0 +1,121 @@
+package error_page
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// maxTLSErrorLength limits the length of the TLS error header value (the OpenSSL reasons are short).
+const maxTLSErrorLength = 128
+
+// extractTLSError returns the TLS error kind (and its name) reported by the terminating proxy in the request header.
+func extractTLSError(
+	headers *fasthttp.RequestHeader, header string, kinds config.ErrorKinds,
+) (config.ErrorKind, string, bool) {
+	if headers == nil || header == "" || len(kinds) == 0 {
+		return config.ErrorKind{}, "", false
+	}
+
+	var value = headers.Peek(header)
+	if len(value) == 0 || len(value) > maxTLSErrorLength {
+		return config.ErrorKind{}, "", false
+	}
+
+	// the custom kinds are matched by name first, so any value can be mapped using the config
+	if kind, ok := kinds.Find(string(value)); ok {
+		return kind, strings.ToLower(strings.TrimSpace(string(value))), true
+	}
+
+	if name := normalizeTLSError(string(value)); name != "" {
+		if kind, ok := kinds.Find(name); ok {
+			return kind, name, true
+		}
+	}
+
+	return config.ErrorKind{}, "", false
+}
+
+// HeadersContainTLSError checks if the given headers contain a known TLS error.
+func HeadersContainTLSError(headers *fasthttp.RequestHeader, header string, kinds config.ErrorKinds) (ok bool) {
+	_, _, ok = extractTLSError(headers, header, kinds)
+
+	return
+}
+
+// normalizeTLSError converts the TLS error, reported by the proxy, into the built-in TLS error kind name. The
+// following values are supported (empty string is returned for the successful verification or unknown values):
+//
+//   - the kind names, in any case and with spaces or dashes (like `Certificate-Expired`)
+//   - the nginx `$ssl_client_verify` values (`NONE`, or `FAILED:<reason>`)
+//   - the OpenSSL verification result codes (like `10` for the expired certificate)
+//   - the OpenSSL error reasons (like `certificate has expired` or `unsupported protocol`)
+func normalizeTLSError(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if reason, failed := strings.CutPrefix(value, "failed:"); failed {
+		if name := normalizeTLSError(reason); name != "" {
+			return name
+		}
+
+		return config.TLSErrorCertificateInvalid // the verification failed for an unknown reason
+	}
+
+	switch value {
+	case "", "success", "ok":
+		return ""
+	case "none": // the client certificate was not presented
+		return config.TLSErrorCertificateRequired
+	}
+
+	if code, err := strconv.ParseUint(value, 10, 16); err == nil {
+		return openSSLVerifyError(code)
+	}
+
+	var name = strings.NewReplacer(" ", "_", "-", "_").Replace(value)
+
+	switch name {
+	case config.TLSErrorCertificateExpired, config.TLSErrorCertificateInvalid, config.TLSErrorCertificateRevoked,
+		config.TLSErrorCertificateUntrusted, config.TLSErrorCertificateRequired, config.TLSErrorHTTPToHTTPS,
+		config.TLSErrorProtocolUnsupported, config.TLSErrorHandshakeFailed, config.TLSErrorUpstreamCertificateInvalid:
+		return name
+	}
+
+	switch {
+	case strings.Contains(value, "expired"):
+		return config.TLSErrorCertificateExpired
+	case strings.Contains(value, "revoked"):
+		return config.TLSErrorCertificateRevoked
+	case strings.Contains(value, "self signed"), strings.Contains(value, "self-signed"),
+		strings.Contains(value, "unable to get local issuer"), strings.Contains(value, "untrusted"):
+		return config.TLSErrorCertificateUntrusted
+	case strings.Contains(value, "protocol"), strings.Contains(value, "wrong version number"),
+		strings.Contains(value, "no shared cipher"), strings.Contains(value, "version too low"):
+		return config.TLSErrorProtocolUnsupported
+	case strings.Contains(value, "http request") && strings.Contains(value, "https"):
+		return config.TLSErrorHTTPToHTTPS
+	case strings.Contains(value, "handshake"):
+		return config.TLSErrorHandshakeFailed
+	}
+
+	return ""
+}
+
+// openSSLVerifyError converts the OpenSSL verification result code (`X509_V_ERR_*`) into the TLS error kind name.
+func openSSLVerifyError(code uint64) string {
+	switch code {
+	case 0: // X509_V_OK
+		return ""
+	case 10: //nolint:mnd // X509_V_ERR_CERT_HAS_EXPIRED
+		return config.TLSErrorCertificateExpired
+	case 23: //nolint:mnd // X509_V_ERR_CERT_REVOKED
+		return config.TLSErrorCertificateRevoked
+	case 2, 18, 19, 20, 21: //nolint:mnd // unable to get the issuer, self-signed, or unable to verify the leaf
+		return config.TLSErrorCertificateUntrusted
+	}
+
+	return config.TLSErrorCertificateInvalid
+}
@@ -0,0 +1,41 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTLSError(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"":                               "",
+		"SUCCESS":                        "",
+		"0":                              "",
+		"certificate_expired":            "certificate_expired",
+		" Certificate-Expired ":          "certificate_expired",
+		"protocol unsupported":           "protocol_unsupported",
+		"NONE":                           "certificate_required",
+		"FAILED:certificate has expired": "certificate_expired",
+		"FAILED:certificate revoked":     "certificate_revoked",
+		"FAILED:self signed certificate": "certificate_untrusted",
+		"FAILED:something else":          "certificate_invalid",
+		"FAILED:":                        "certificate_invalid",
+		"10":                             "certificate_expired",
+		"23":                             "certificate_revoked",
+		"20":                             "certificate_untrusted",
+		"9":                              "certificate_invalid",
+		"unsupported protocol":           "protocol_unsupported",
+		"wrong version number":           "protocol_unsupported",
+		"The plain HTTP request was sent to HTTPS port": "http_to_https",
+		"sslv3 alert handshake failure":                 "handshake_failed",
+		"foo":                                           "",
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, want, normalizeTLSError(give))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package error_page
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+// Sources of the rendered code (reported for the unknown codes).
+const (
+	codeSourceRoute         = "route"
+	codeSourceURL           = "url"
+	codeSourceHeader        = "header"
+	codeSourceCatchAll      = "catch-all"
+	codeSourceDefault       = "default"
+	codeSourceProfile       = "profile"
+	codeSourceErrorKind     = "error-kind"
+	codeSourceTLSError      = "tls-error"
+	codeSourceMaintenance   = "maintenance"
+	codeSourceNotReady      = "not-ready"
+	codeSourceInvalidHeader = "invalid-header" // the code header is present, but its value is not a valid code
+)
+
+// maxReportedValueLength limits the length of the reported (logged) header value.
+const maxReportedValueLength = 32
+
+// logLimiter allows at most one log entry per interval and counts the suppressed ones. The zero interval disables
+// the logging. It's safe for concurrent use.
+type logLimiter struct {
+	interval   int64 // in nanoseconds
+	last       atomic.Int64
+	suppressed atomic.Uint64
+}
+
+func newLogLimiter(interval time.Duration) *logLimiter {
+	return &logLimiter{interval: interval.Nanoseconds()}
+}
+
+// allow reports whether the log entry may be written now, and returns the number of the entries suppressed since
+// the last allowed one.
+func (l *logLimiter) allow(now time.Time) (_ bool, suppressed uint64) {
+	if l.interval <= 0 {
+		return false, 0
+	}
+
+	var last, nano = l.last.Load(), now.UnixNano()
+
+	if (last != 0 && nano-last < l.interval) || !l.last.CompareAndSwap(last, nano) {
+		l.suppressed.Add(1)
+
+		return false, 0
+	}
+
+	return true, l.suppressed.Swap(0)
+}
+
+// invalidCodeHeader returns the (quoted and truncated) value of the code header if it's present, but is not a
+// valid code (like `X-Code: 0`).
+func invalidCodeHeader(headers *fasthttp.RequestHeader, codes statuscode.Parser) (string, bool) {
+	var value = headers.Peek(statuscode.Header)
+
+	if len(value) == 0 {
+		return "", false
+	}
+
+	if _, ok := codes.FromHeader(value); ok {
+		return "", false
+	}
+
+	if len(value) > maxReportedValueLength {
+		value = value[:maxReportedValueLength]
+	}
+
+	return strconv.Quote(string(value)), true
+}
+
+// unknownCodes reports the requests with the unknown codes (not defined in the config and the standard library) or
+// the invalid code header, so a misconfigured proxy can be found without the packet capture.
+type unknownCodes struct {
+	log      *logger.Logger
+	limiter  *logLimiter
+	requests *metrics.Counter // by the code source (optional)
+}
+
+func newUnknownCodes(log *logger.Logger, interval time.Duration, reg *metrics.Registry) *unknownCodes {
+	return &unknownCodes{
+		log:     log,
+		limiter: newLogLimiter(interval),
+		requests: reg.Counter(
+			"error_pages_unknown_codes_total", "Requests with the unknown codes or invalid code header by the code source",
+			"source",
+		),
+	}
+}
+
+// report counts the request and logs it (the logging is rate-limited). The value is the code (or the quoted
+// header value).
+func (u *unknownCodes) report(ctx *fasthttp.RequestCtx, source, value, clientIP string) {
+	u.requests.Inc(source)
+
+	var ok, suppressed = u.limiter.allow(time.Now())
+	if !ok {
+		return
+	}
+
+	var msg = "Unknown status code"
+
+	if source == codeSourceInvalidHeader {
+		msg = "Invalid code header"
+	}
+
+	u.log.Warn(msg,
+		logger.String("source", source),
+		logger.String("value", value),
+		logger.String("client ip", clientIP),
+		logger.String("remote addr", ctx.RemoteAddr().String()), // the proxy, the request came from
+		logger.String("host", string(ctx.Request.Header.Host())),
+		logger.String("path", string(ctx.Path())),
+		logger.Uint64("suppressed", suppressed),
+	)
+}
@@ -0,0 +1,61 @@
+package error_page
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+func TestLogLimiter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		limiter = newLogLimiter(time.Second)
+		now     = time.Now()
+	)
+
+	ok, suppressed := limiter.allow(now)
+	assert.True(t, ok)
+	assert.Zero(t, suppressed)
+
+	for range 3 {
+		ok, _ = limiter.allow(now.Add(500 * time.Millisecond))
+		assert.False(t, ok)
+	}
+
+	ok, suppressed = limiter.allow(now.Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), suppressed)
+
+	ok, _ = newLogLimiter(0).allow(now)
+	assert.False(t, ok) // disabled
+}
+
+func TestInvalidCodeHeader(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"0":                                    `"0"`,
+		"abc":                                  `"abc"`,
+		"1000":                                 `"1000"`,
+		"\x00<>":                               `"\x00<>"`,
+		"404":                                  "",
+		"":                                     "",
+		"123456789012345678901234567890123456": `"12345678901234567890123456789012"`,
+	} {
+		var headers fasthttp.RequestHeader
+
+		if give != "" {
+			headers.Set("X-Code", give)
+		}
+
+		got, ok := invalidCodeHeader(&headers, statuscode.Parser{})
+
+		assert.Equal(t, want != "", ok, give)
+		assert.Equal(t, want, got, give)
+	}
+}
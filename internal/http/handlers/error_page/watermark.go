@@ -0,0 +1,81 @@
+package error_page
+
+import (
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+)
+
+// watermark marks the HTML pages with the instance ID, the time, and the request ID, so the screenshots of the
+// pages (e.g. attached to the support tickets) can be traced to the exact serving instance and time.
+type watermark struct {
+	style    string // the watermark element style
+	instance string // the HTML-escaped instance ID
+}
+
+// newWatermark creates a new watermark. It returns nil if the watermarking is disabled. The host name is used as
+// the instance ID if it's not set.
+func newWatermark(mode config.WatermarkMode, instanceID string) *watermark {
+	var style string
+
+	switch mode {
+	case config.WatermarkModeFooter:
+		style = "position:fixed;right:.5em;bottom:.25em;z-index:2147483647;font:10px/1.4 monospace;color:#888"
+	case config.WatermarkModeInvisible: // readable on the screenshots after the contrast adjustment
+		style = "position:fixed;right:.5em;bottom:.25em;z-index:2147483647;font:10px/1.4 monospace;color:#888;" +
+			"opacity:.04;pointer-events:none;user-select:none"
+	default:
+		return nil
+	}
+
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+
+	return &watermark{style: style, instance: html.EscapeString(instanceID)}
+}
+
+// markup returns the watermark element. The style element carries the CSP nonce (if any), so the watermark is
+// styled under the policy with the nonce too.
+func (w *watermark) markup(at time.Time, requestID, nonce string) string {
+	var b strings.Builder
+
+	b.WriteString("<style")
+
+	if nonce != "" {
+		b.WriteString(` nonce="` + nonce + `"`)
+	}
+
+	b.WriteString(">.ep-watermark{" + w.style + "}</style>")
+	b.WriteString(`<div class="ep-watermark" aria-hidden="true">`)
+	b.WriteString(w.instance + " &middot; " + at.UTC().Format(time.RFC3339))
+
+	if requestID != "" {
+		b.WriteString(" &middot; " + html.EscapeString(requestID))
+	}
+
+	b.WriteString("</div>")
+
+	return b.String()
+}
+
+// apply inserts the watermark into the HTML response body before the closing body tag. The streamed bodies are
+// not watermarked.
+func (w *watermark) apply(ctx *fasthttp.RequestCtx, at time.Time, requestID, nonce string) {
+	if ctx.Response.IsBodyStream() {
+		return
+	}
+
+	var buf = getBuffer()
+	defer putBuffer(buf)
+
+	buf.Write(ctx.Response.Body())
+	injectScript(buf, w.markup(at, requestID, nonce))
+
+	ctx.Response.SetBody(buf.Bytes()) // the body is copied
+}
@@ -0,0 +1,22 @@
+package error_page
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// isWebSocketUpgrade reports whether the request is the WebSocket opening handshake (the `Upgrade` header includes
+// the `websocket` protocol, case is ignored).
+func isWebSocketUpgrade(headers *fasthttp.RequestHeader) bool {
+	for _, value := range headers.PeekAll(fasthttp.HeaderUpgrade) {
+		for protocol := range strings.SplitSeq(string(value), ",") {
+			// the protocol may have a version, like `websocket/13`
+			if name, _, _ := strings.Cut(strings.TrimSpace(protocol), "/"); strings.EqualFold(name, "websocket") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
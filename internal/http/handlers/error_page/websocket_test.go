@@ -0,0 +1,37 @@
+package error_page
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func Test_isWebSocketUpgrade(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveUpgrade []string
+		want        bool
+	}{
+		"none":       {},
+		"websocket":  {giveUpgrade: []string{"websocket"}, want: true},
+		"case":       {giveUpgrade: []string{"WebSocket"}, want: true},
+		"list":       {giveUpgrade: []string{"h2c, websocket/13"}, want: true},
+		"repeated":   {giveUpgrade: []string{"h2c", "websocket"}, want: true},
+		"other":      {giveUpgrade: []string{"h2c"}},
+		"not a name": {giveUpgrade: []string{"websockets"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var headers fasthttp.RequestHeader
+
+			for _, v := range tt.giveUpgrade {
+				headers.Add(fasthttp.HeaderUpgrade, v)
+			}
+
+			assert.Equal(t, tt.want, isWebSocketUpgrade(&headers))
+		})
+	}
+}
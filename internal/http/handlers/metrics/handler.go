@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+// Path is the path of the Prometheus metrics endpoint.
+const Path = "/metrics"
+
+// New creates a handler that returns the registered metrics in the Prometheus text exposition format.
+func New(reg *metrics.Registry) fasthttp.RequestHandler {
+	var notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+
+	return func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet:
+			ctx.SetContentType("text/plain; version=0.0.4; charset=utf-8")
+			ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-store")
+			ctx.SetStatusCode(http.StatusOK)
+			_, _ = reg.WriteTo(ctx)
+
+		case fasthttp.MethodHead:
+			ctx.SetStatusCode(http.StatusOK)
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package metrics_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/http/handlers/metrics"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	registry "github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		reg     = registry.NewRegistry()
+		handler = metrics.New(reg)
+		url     = "http://testing/metrics"
+		body    = http.NoBody
+	)
+
+	reg.Counter("requests_total", "Total requests", "code").Inc("404")
+
+	t.Run("get", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodGet, url, body, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", headers.Get("Content-Type"))
+			assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+			assert.Equal(t, "# HELP requests_total Total requests\n# TYPE requests_total counter\n"+
+				"requests_total{code=\"404\"} 1\n", body)
+		})
+	})
+
+	t.Run("head", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodHead, url, body, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Empty(t, body)
+		})
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodPost, url, body, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+			assert.Equal(t, "Method Not Allowed\n", body)
+		})
+	})
+}
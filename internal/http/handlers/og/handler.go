@@ -0,0 +1,102 @@
+package og
+
+import (
+	"bytes"
+	"image/color"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/a11y"
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/ogimage"
+)
+
+// PathPrefix is the path prefix of the social preview image endpoints (`/og/{code}.png`).
+const PathPrefix = "/og/"
+
+// the colors of the templates without the recognizable palette
+var ( //nolint:gochecknoglobals
+	defaultText       = color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+	defaultBackground = color.RGBA{R: 0x1a, G: 0x1a, B: 0x1a, A: 0xff}
+)
+
+// New creates a handler that returns the social preview image (`/og/{code}.png`, 1200x630 PNG) with the code and
+// its message, drawn in the text and background colors of the active template (the template name is returned by
+// the provided function). The images are rendered once per template and code, and cached in memory.
+func New(cfg *config.Config, codes statuscode.Parser, activeTemplate func() string) fasthttp.RequestHandler {
+	type key struct {
+		template string
+		code     uint16
+	}
+
+	var (
+		mu    sync.Mutex
+		cache = make(map[key][]byte)
+
+		notFound   = http.StatusText(http.StatusNotFound) + "\n"
+		notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+	)
+
+	var render = func(k key) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if img, ok := cache[k]; ok {
+			return img, nil
+		}
+
+		var text, background, themed = a11y.Palette([]byte(cfg.Templates[k.template]))
+		if !themed {
+			text, background = defaultText, defaultBackground
+		}
+
+		var message = http.StatusText(int(k.code))
+
+		if desc, found := cfg.Codes.Find(k.code); found {
+			message = desc.Message
+		}
+
+		var buf bytes.Buffer
+
+		if err := ogimage.Render(&buf, k.code, message, text, background); err != nil {
+			return nil, err
+		}
+
+		cache[k] = buf.Bytes()
+
+		return cache[k], nil
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		var name, isPNG = strings.CutSuffix(strings.TrimPrefix(string(ctx.Path()), PathPrefix), ".png")
+
+		code, ok := codes.FromHeader([]byte(name))
+		if !isPNG || !ok {
+			ctx.Error(notFound, http.StatusNotFound)
+
+			return
+		}
+
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet, fasthttp.MethodHead:
+			img, err := render(key{template: activeTemplate(), code: code})
+			if err != nil {
+				ctx.Error(err.Error()+"\n", http.StatusInternalServerError)
+
+				return
+			}
+
+			ctx.SetContentType("image/png")
+			ctx.Response.Header.Set("Cache-Control", "public, max-age=86400")
+			ctx.SetStatusCode(http.StatusOK)
+			_, _ = ctx.Write(img) // the body of the HEAD response is omitted by the server
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+		}
+	}
+}
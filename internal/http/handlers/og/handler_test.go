@@ -0,0 +1,92 @@
+package og_test
+
+import (
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/og"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/ogimage"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Templates = map[string]string{
+		"light": `<style>body { color: #202020; background-color: #fff }</style>`,
+		"plain": `<p>no styles</p>`,
+	}
+
+	var (
+		active  = "light"
+		handler = og.New(&cfg, statuscode.Parser{Strict: true}, func() string { return active })
+		body    = http.NoBody
+	)
+
+	var background = func(t *testing.T, image string) color.RGBA {
+		t.Helper()
+
+		img, err := png.Decode(strings.NewReader(image))
+		require.NoError(t, err)
+
+		assert.Equal(t, ogimage.Width, img.Bounds().Dx())
+		assert.Equal(t, ogimage.Height, img.Bounds().Dy())
+
+		return color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA) //nolint:forcetypeassert
+	}
+
+	t.Run("get", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/og/404.png", body, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "image/png", headers.Get("Content-Type"))
+			assert.Equal(t, "public, max-age=86400", headers.Get("Cache-Control"))
+			assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, background(t, body))
+		})
+	})
+
+	t.Run("head", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodHead, "http://testing/og/503.png", body, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Empty(t, body)
+		})
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, url := range []string{
+			"http://testing/og/404",
+			"http://testing/og/404.jpg",
+			"http://testing/og/999.png",
+			"http://testing/og/foo.png",
+			"http://testing/og/",
+		} {
+			httptest.HandleFast(t, handler, http.MethodGet, url, body, func(status int, _ string, _ http.Header) {
+				assert.Equal(t, http.StatusNotFound, status, url)
+			})
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodPost, "http://testing/og/404.png", body, func(status int, _ string, _ http.Header) {
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+		})
+	})
+
+	t.Run("template without the palette", func(t *testing.T) {
+		active = "plain"
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/og/404.png", body, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, color.RGBA{R: 0x1a, G: 0x1a, B: 0x1a, A: 0xff}, background(t, body))
+		})
+	})
+}
@@ -0,0 +1,258 @@
+// Package prebuilt provides a handler that serves pre-built (using the `build` command) error pages from the
+// directory, without any templating at runtime.
+package prebuilt
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/contentcoding"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+// contentTypes maps the supported file extensions to the content types. Only files with these extensions are
+// loaded, and the content negotiation is limited to them.
+var contentTypes = map[string]string{ //nolint:gochecknoglobals
+	".html": "text/html; charset=utf-8",
+	".htm":  "text/html; charset=utf-8",
+	".json": "application/json; charset=utf-8",
+	".xml":  "application/xml; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+}
+
+// maxFileSize limits the size of a single page to avoid loading something unexpected into memory.
+const maxFileSize = 4 << 20 // 4 MiB
+
+// encodings maps the pre-compressed variant file name suffixes (like `404.html.br`, see the `build` command
+// `--precompress` flag) to the content encodings, ordered by preference.
+var encodings = [...]struct{ suffix, name string }{{".br", "br"}, {".gz", "gzip"}} //nolint:gochecknoglobals
+
+type page struct {
+	contentType string
+	content     []byte
+	encoded     map[string][]byte // the pre-compressed variants by the content encoding name
+	encodings   []string          // the names of the pre-compressed variants, ordered by preference
+}
+
+// Options contains the handler options.
+type Options struct {
+	// DefaultCode is the code of the page to serve when the code is not specified in the request (or the page for
+	// the requested code does not exist).
+	DefaultCode uint16
+
+	// RespondWithSameHTTPCode determines whether the response should have the same HTTP status code as the served
+	// page.
+	RespondWithSameHTTPCode bool
+
+	// Template is the name of the template to serve the pages of. The `build` command writes the pages of every
+	// template into its own subdirectory (`{dir}/{template}/404.html`), so when the subdirectory exists, the pages
+	// are loaded from it. Otherwise (or if empty), the pages are loaded from the directory itself (the flat layout).
+	Template string
+
+	// Codes parses the status codes of the file names, request paths, and the `X-Code` header, the same way the
+	// templated pages handler does it (including the strict mode).
+	Codes statuscode.Parser
+}
+
+// New loads all the pages from the directory (files named like `{code}.{ext}`, for example `404.html`, see
+// [Options.Template] for the layout of the `build` command output) into memory and creates a handler that serves
+// them. The directory is read only once, so the handler never touches the file system at runtime.
+//
+// The pre-compressed variants of the pages (like `404.html.br` and `404.html.gz`) are loaded too, and sent as-is
+// (with the `Content-Encoding` response header) when the client accepts them (the `Accept-Encoding` request header).
+func New(dir string, opt Options) (fasthttp.RequestHandler, error) { //nolint:funlen
+	if opt.Template != "" {
+		if stat, sErr := os.Stat(filepath.Join(dir, opt.Template)); sErr == nil && stat.IsDir() {
+			dir = filepath.Join(dir, opt.Template)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the static directory: %w", err)
+	}
+
+	var (
+		pages    = make(map[uint16]map[string]page) // map[code]map[ext]page
+		variants = make(map[string]string)          // map[file name]content encoding (the pre-compressed pages)
+	)
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue // skip directories, symlinks, etc.
+		}
+
+		var code, ext, ok = parseName(opt.Codes, entry.Name())
+		if !ok {
+			for _, enc := range encodings {
+				if strings.HasSuffix(strings.ToLower(entry.Name()), enc.suffix) {
+					variants[entry.Name()] = enc.name // attached to the pages below
+				}
+			}
+
+			continue
+		}
+
+		if info, iErr := entry.Info(); iErr != nil || info.Size() > maxFileSize {
+			continue
+		}
+
+		content, rErr := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if rErr != nil {
+			return nil, fmt.Errorf("cannot read the static page %s: %w", entry.Name(), rErr)
+		}
+
+		if _, exists := pages[code]; !exists {
+			pages[code] = make(map[string]page)
+		}
+
+		pages[code][ext] = page{contentType: contentTypes[ext], content: content}
+	}
+
+	for _, enc := range encodings { // in the order of preference
+		for name, encoding := range variants {
+			if encoding != enc.name {
+				continue
+			}
+
+			var code, ext, ok = parseName(opt.Codes, name[:len(name)-len(enc.suffix)])
+			if !ok {
+				continue
+			}
+
+			p, exists := pages[code][ext]
+			if !exists {
+				continue // the variant without the original page
+			}
+
+			if info, iErr := os.Stat(filepath.Join(dir, name)); iErr != nil || info.Size() > maxFileSize {
+				continue
+			}
+
+			content, rErr := os.ReadFile(filepath.Join(dir, name))
+			if rErr != nil {
+				return nil, fmt.Errorf("cannot read the static page %s: %w", name, rErr)
+			}
+
+			if p.encoded == nil {
+				p.encoded = make(map[string][]byte, len(encodings))
+			}
+
+			p.encoded[encoding], p.encodings = content, append(p.encodings, encoding)
+			pages[code][ext] = p
+		}
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no pages found in the static directory %s (the files like '404.html' are expected)", dir)
+	}
+
+	var notFound = http.StatusText(http.StatusNotFound) + "\n"
+
+	return func(ctx *fasthttp.RequestCtx) {
+		var code, ext, ok = parseName(opt.Codes, string(ctx.Path()))
+		if !ok { // the code is not in the URL (or the URL is the index page)
+			code, ext = opt.DefaultCode, ".html"
+
+			if c, fromHeader := opt.Codes.FromHeader(ctx.Request.Header.Peek(statuscode.Header)); fromHeader {
+				code = c
+			}
+		}
+
+		var p, found = lookup(pages, code, ext)
+		if !found {
+			if p, found = lookup(pages, opt.DefaultCode, ext); !found {
+				ctx.Error(notFound, http.StatusNotFound)
+
+				return
+			}
+
+			code = opt.DefaultCode
+		}
+
+		ctx.SetContentType(p.contentType)
+		ctx.Response.Header.Set("X-Robots-Tag", "noindex")
+
+		var content = p.content
+
+		if len(p.encodings) > 0 {
+			ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAcceptEncoding)
+
+			var accept = string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptEncoding))
+
+			if encoding := contentcoding.Negotiate(accept, p.encodings...); encoding != "" {
+				ctx.Response.Header.SetContentEncoding(encoding)
+				content = p.encoded[encoding]
+			}
+		}
+
+		if opt.RespondWithSameHTTPCode {
+			ctx.SetStatusCode(int(code))
+		} else {
+			ctx.SetStatusCode(http.StatusOK)
+		}
+
+		_, _ = ctx.Write(content)
+	}, nil
+}
+
+// lookup searches for the page with the specified code and extension (the `.htm` and `.html` are interchangeable).
+func lookup(pages map[uint16]map[string]page, code uint16, ext string) (page, bool) {
+	if byExt, ok := pages[code]; ok {
+		if p, found := byExt[ext]; found {
+			return p, true
+		}
+
+		switch ext {
+		case ".html":
+			p, found := byExt[".htm"]
+
+			return p, found
+		case ".htm":
+			p, found := byExt[".html"]
+
+			return p, found
+		}
+	}
+
+	return page{}, false
+}
+
+// parseName extracts the code and the extension from the file name or the request path (like `404.html` or
+// `/404.json`). The name without the extension is treated as HTML. The code is parsed by the status code parser,
+// which accepts the HTML extensions only, so the other supported extensions are cut off before parsing.
+func parseName(codes statuscode.Parser, name string) (code uint16, ext string, _ bool) {
+	name = "/" + strings.TrimPrefix(name, "/")
+
+	var base = name
+
+	if !codes.Strict {
+		base, _, _ = strings.Cut(name, ";") // the path parameters, like `;jsessionid=...`
+	}
+
+	switch ext = strings.ToLower(path.Ext(base)); {
+	case ext == "":
+		ext = ".html"
+	case contentTypes[ext] == "" || (codes.Strict && path.Ext(base) != ext):
+		return 0, "", false
+	case ext != ".html" && ext != ".htm":
+		name = strings.TrimSuffix(base, path.Ext(base))
+	}
+
+	code, ok := codes.FromPath(name)
+
+	return code, ext, ok
+}
+
+// URLContainsCode checks if the given URL path looks like a page that this handler can serve.
+func URLContainsCode(url string, codes statuscode.Parser) (ok bool) {
+	_, _, ok = parseName(codes, url)
+
+	return
+}
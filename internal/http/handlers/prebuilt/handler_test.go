@@ -0,0 +1,235 @@
+package prebuilt_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/handlers/prebuilt"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var dir = t.TempDir()
+
+	for name, content := range map[string]string{
+		"404.html":  "<html>404</html>",
+		"404.json":  `{"code": 404}`,
+		"503.htm":   "<html>503</html>",
+		"foo.html":  "foo",
+		"500.php":   "<?php echo 500;",
+		"index.htm": "index",
+		"99.html":   "<html>99</html>",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "400.html"), 0o700))
+
+	var handler, err = prebuilt.New(dir, prebuilt.Options{DefaultCode: 404, RespondWithSameHTTPCode: true})
+
+	require.NoError(t, err)
+
+	for name, tt := range map[string]struct {
+		giveUrl     string
+		giveHeaders map[string]string
+
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		"index":                  {"/", nil, http.StatusNotFound, "text/html", "<html>404</html>"},
+		"html":                   {"/404.html", nil, http.StatusNotFound, "text/html", "<html>404</html>"},
+		"without extension":      {"/404", nil, http.StatusNotFound, "text/html", "<html>404</html>"},
+		"json":                   {"/404.json", nil, http.StatusNotFound, "application/json", `{"code": 404}`},
+		"htm instead of html":    {"/503.html", nil, http.StatusServiceUnavailable, "text/html", "<html>503</html>"},
+		"code in the header":     {"/", map[string]string{"X-Code": "503"}, http.StatusServiceUnavailable, "text/html", "<html>503</html>"},
+		"path parameters":        {"/503.htm;jsessionid=1", nil, http.StatusServiceUnavailable, "text/html", "<html>503</html>"},
+		"accept is ignored":      {"/404", map[string]string{"Accept": "application/json"}, http.StatusNotFound, "text/html", "<html>404</html>"},
+		"fallback to default":    {"/500.html", nil, http.StatusNotFound, "text/html", "<html>404</html>"},
+		"fallback, same format":  {"/503.json", nil, http.StatusNotFound, "application/json", `{"code": 404}`},
+		"directory is not used":  {"/400.html", nil, http.StatusNotFound, "text/html", "<html>404</html>"},
+		"no page for the format": {"/503.xml", nil, http.StatusNotFound, "text/plain", "Not Found"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://testing"+tt.giveUrl, http.NoBody)
+			require.NoError(t, reqErr)
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+				assert.Equal(t, tt.wantStatus, status)
+				assert.Contains(t, headers.Get("Content-Type"), tt.wantContentType)
+				assert.Contains(t, body, tt.wantBody)
+			})
+		})
+	}
+
+	t.Run("strict codes", func(t *testing.T) {
+		t.Parallel()
+
+		for giveStrict, wantBody := range map[bool]string{
+			false: "<html>99</html>",
+			true:  "<html>404</html>", // the code is not in the 100..599 range, so the page is not even loaded
+		} {
+			h, hErr := prebuilt.New(dir, prebuilt.Options{DefaultCode: 404, Codes: statuscode.Parser{Strict: giveStrict}})
+			require.NoError(t, hErr)
+
+			for _, url := range []string{"/", "/99.html"} {
+				req, reqErr := http.NewRequest(http.MethodGet, "http://testing"+url, http.NoBody)
+				require.NoError(t, reqErr)
+
+				req.Header.Set("X-Code", "99")
+
+				httptest.HandleFastRequest(t, h, req, func(_ int, body string, _ http.Header) {
+					assert.Equal(t, wantBody, body, url)
+				})
+			}
+		}
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		t.Parallel()
+
+		var _, err = prebuilt.New(t.TempDir(), prebuilt.Options{})
+
+		assert.ErrorContains(t, err, "no pages found")
+	})
+
+	t.Run("template subdirectory", func(t *testing.T) {
+		t.Parallel()
+
+		var root = t.TempDir() // the `build` command output layout
+
+		for _, name := range []string{"ghost", "l7"} {
+			require.NoError(t, os.Mkdir(filepath.Join(root, name), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(root, name, "404.html"), []byte(name), 0o600))
+		}
+
+		for template, want := range map[string]string{"ghost": "ghost", "l7": "l7"} {
+			handler, hErr := prebuilt.New(root, prebuilt.Options{DefaultCode: 404, Template: template})
+			require.NoError(t, hErr)
+
+			httptest.HandleFast(t, handler, http.MethodGet, "http://testing/404.html", http.NoBody,
+				func(_ int, body string, _ http.Header) { assert.Equal(t, want, body) },
+			)
+		}
+
+		_, mErr := prebuilt.New(root, prebuilt.Options{Template: "missing"}) // the flat layout is expected
+		assert.ErrorContains(t, mErr, "no pages found")
+	})
+
+	t.Run("flat layout with the template", func(t *testing.T) {
+		t.Parallel()
+
+		handler, hErr := prebuilt.New(dir, prebuilt.Options{DefaultCode: 404, Template: "ghost"})
+		require.NoError(t, hErr)
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/404.json", http.NoBody,
+			func(_ int, body string, _ http.Header) { assert.JSONEq(t, `{"code": 404}`, body) },
+		)
+	})
+
+	t.Run("pre-compressed variants", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			root    = t.TempDir()
+			content = []byte(strings.Repeat("<p>404</p>", 100))
+		)
+
+		for name, data := range map[string][]byte{
+			"404.html":    content,
+			"404.html.br": fasthttp.AppendBrotliBytes(nil, content),
+			"404.html.gz": fasthttp.AppendGzipBytes(nil, content),
+			"503.json.gz": fasthttp.AppendGzipBytes(nil, []byte(`{}`)), // without the original page
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(root, name), data, 0o600))
+		}
+
+		handler, hErr := prebuilt.New(root, prebuilt.Options{DefaultCode: 404})
+		require.NoError(t, hErr)
+
+		for giveAcceptEncoding, wantEncoding := range map[string]string{
+			"":                  "",
+			"identity":          "",
+			"gzip, deflate, br": "br",
+			"gzip":              "gzip",
+			"*, br;q=0":         "gzip",
+			"*;q=0":             "",
+		} {
+			req, reqErr := http.NewRequest(http.MethodGet, "http://testing/404.html", http.NoBody)
+			require.NoError(t, reqErr)
+
+			req.Header.Set("Accept-Encoding", giveAcceptEncoding)
+
+			httptest.HandleFastRequest(t, handler, req, func(_ int, body string, headers http.Header) {
+				assert.Equal(t, wantEncoding, headers.Get("Content-Encoding"), giveAcceptEncoding)
+				assert.Equal(t, "Accept-Encoding", headers.Get("Vary"))
+				assert.Contains(t, headers.Get("Content-Type"), "text/html")
+
+				var decoded = []byte(body)
+
+				switch wantEncoding {
+				case "br":
+					decoded, _ = fasthttp.AppendUnbrotliBytes(nil, decoded)
+				case "gzip":
+					decoded, _ = fasthttp.AppendGunzipBytes(nil, decoded)
+				}
+
+				assert.Equal(t, content, decoded, giveAcceptEncoding)
+			})
+		}
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/503.json", http.NoBody,
+			func(_ int, body string, headers http.Header) {
+				assert.Equal(t, "Not Found\n", body) // the orphan variant is not loaded
+				assert.Empty(t, headers.Get("Content-Encoding"))
+			},
+		)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		t.Parallel()
+
+		var _, err = prebuilt.New(filepath.Join(t.TempDir(), "missing"), prebuilt.Options{})
+
+		assert.ErrorContains(t, err, "cannot read the static directory")
+	})
+}
+
+func TestURLContainsCode(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string][2]bool{ // lenient, strict
+		"/404.html":        {true, true},
+		"/404.JSON":        {true, false},
+		"/404.txt":         {true, true},
+		"/404":             {true, true},
+		"/404.php":         {false, false},
+		"/foo.html":        {false, false},
+		"/":                {false, false},
+		"/0.html":          {false, false},
+		"/099.html":        {true, false},
+		"/998":             {true, false},
+		"/1000":            {false, false},
+		"/404;jsessionid=": {true, false},
+		"/404.json;v=1":    {true, false},
+	} {
+		assert.Equal(t, want[0], prebuilt.URLContainsCode(give, statuscode.Parser{}), give)
+		assert.Equal(t, want[1], prebuilt.URLContainsCode(give, statuscode.Parser{Strict: true}), give)
+	}
+}
@@ -0,0 +1,175 @@
+package renderprops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+// maxBatchRenders limits the number of the variants rendered by a single batch request.
+const maxBatchRenders = 256
+
+type (
+	// batchRequest is the batch render request. Every combination of the codes, formats, and locales is rendered.
+	batchRequest struct {
+		Codes    []uint16 `json:"codes"`
+		Formats  []string `json:"formats"`  // `html` by default
+		Locales  []string `json:"locales"`  // the locale detected for the request by default
+		Template string   `json:"template"` // optional template source (the configured template is used if empty)
+	}
+
+	// batchResponse is the batch render response.
+	batchResponse struct {
+		Renders []batchRender `json:"renders"`
+	}
+
+	// batchRender is a single rendered variant.
+	batchRender struct {
+		Code        uint16 `json:"code"`
+		Format      string `json:"format"`
+		Locale      string `json:"locale,omitempty"`
+		Status      int    `json:"status"`
+		ContentType string `json:"content_type"`
+		Body        string `json:"body"`
+	}
+)
+
+// isBatch reports whether the request is the batch render request (a JSON body).
+func isBatch(ctx *fasthttp.RequestCtx) bool {
+	mediaType, _, _ := mime.ParseMediaType(string(ctx.Request.Header.ContentType()))
+
+	return mediaType == "application/json"
+}
+
+// batch renders every combination of the codes, formats, and locales from the JSON request body the same way the
+// dry-run does (using the template source from the request, or the configured template), and responds with all the
+// rendered bodies at once, so the CI pipelines may snapshot many variants with a single request.
+func batch(ctx *fasthttp.RequestCtx, errorPages fasthttp.RequestHandler, codes statuscode.Parser) {
+	var (
+		req batchRequest
+		dec = json.NewDecoder(bytes.NewReader(ctx.PostBody()))
+	)
+
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&req); err != nil {
+		ctx.Error("wrong batch request: "+err.Error()+"\n", http.StatusBadRequest)
+
+		return
+	}
+
+	if len(req.Codes) == 0 {
+		ctx.Error("wrong batch request: at least one code is required\n", http.StatusBadRequest)
+
+		return
+	}
+
+	for _, code := range req.Codes {
+		if _, ok := codes.FromHeader([]byte(strconv.FormatUint(uint64(code), 10))); !ok {
+			ctx.Error(fmt.Sprintf("wrong code: %d\n", code), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	var formats = make([]config.Format, 0, max(len(req.Formats), 1))
+
+	for _, value := range req.Formats {
+		f, err := config.ParseFormat(value)
+		if err != nil {
+			ctx.Error(err.Error()+"\n", http.StatusBadRequest)
+
+			return
+		}
+
+		formats = append(formats, f)
+	}
+
+	if len(formats) == 0 {
+		formats = append(formats, config.FormatHTML)
+	}
+
+	var locales = make([]string, 0, max(len(req.Locales), 1))
+
+	for _, value := range req.Locales {
+		var locale = l10n.NormalizeLocale(value)
+
+		if !l10n.HasLocale(locale) {
+			ctx.Error("unsupported locale: "+value+"\n", http.StatusBadRequest)
+
+			return
+		}
+
+		locales = append(locales, locale)
+	}
+
+	if len(locales) == 0 {
+		locales = append(locales, "") // detected for the request
+	}
+
+	var total = len(req.Codes) * len(formats) * len(locales)
+
+	if total > maxBatchRenders {
+		ctx.Error(fmt.Sprintf("too many renders (%d), the maximum is %d\n", total, maxBatchRenders), http.StatusBadRequest)
+
+		return
+	}
+
+	at, ok := queryTime(ctx)
+	if !ok {
+		return
+	}
+
+	var resp = batchResponse{Renders: make([]batchRender, 0, total)}
+
+	for _, code := range req.Codes {
+		for _, format := range formats {
+			for _, locale := range locales {
+				var uri = "/" + strconv.FormatUint(uint64(code), 10)
+
+				if locale != "" {
+					uri += "?lang=" + locale // takes precedence over the cookie and Accept-Language header
+				}
+
+				var synthetic = synthesize(ctx, uri, at)
+
+				synthetic.Request.ResetBody()
+				synthetic.Request.Header.Del(fasthttp.HeaderContentType)
+				synthetic.Request.Header.Del(fasthttp.HeaderAcceptEncoding) // the bodies are embedded as-is
+				synthetic.Request.Header.Set("X-Format", formatMimeTypes[format])
+
+				if req.Template != "" {
+					ep.DryRun(synthetic, req.Template)
+				}
+
+				errorPages(synthetic)
+
+				resp.Renders = append(resp.Renders, batchRender{
+					Code:        code,
+					Format:      format.String(),
+					Locale:      locale,
+					Status:      synthetic.Response.StatusCode(),
+					ContentType: string(synthetic.Response.Header.ContentType()),
+					Body:        string(synthetic.Response.Body()),
+				})
+			}
+		}
+	}
+
+	body, _ := json.Marshal(resp) //nolint:errchkjson
+
+	ctx.SetContentType("application/json; charset=utf-8")
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-store")
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.SetBody(body)
+}
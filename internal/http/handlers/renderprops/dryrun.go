@@ -0,0 +1,93 @@
+package renderprops
+
+import (
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+// DryRunPath is the path of the dry-run render API endpoint.
+const DryRunPath = "/api/render"
+
+// maxSourceSize limits the template source size.
+const maxSourceSize = 1 << 20 // 1 MiB
+
+// formatMimeTypes are the MIME types of the formats, requested by the synthetic request (in the `X-Format` header).
+var formatMimeTypes = map[config.Format]string{ //nolint:gochecknoglobals
+	config.FormatPlainText: "text/plain",
+	config.FormatJSON:      "application/json",
+	config.FormatXML:       "application/xml",
+	config.FormatHTML:      "text/html",
+}
+
+// NewDryRun creates a handler that renders the template source from the request body (POST) with the props the
+// error pages handler would use for the synthetic request (see [New]), and responds with the output. The format
+// (and so the values escaping) is taken from the `format` query parameter (`html` by default). The rendered output
+// is never cached, and the template is never added to the configured ones, so the theme authors may iterate
+// against the live instance's configuration and tokens.
+//
+// The JSON request body (the `application/json` content type) switches the handler to the batch mode, rendering
+// many codes, formats, and locales at once (see [batch]).
+func NewDryRun(errorPages fasthttp.RequestHandler, codes statuscode.Parser) fasthttp.RequestHandler {
+	var (
+		notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+		tooLarge   = http.StatusText(http.StatusRequestEntityTooLarge) + "\n"
+	)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Method()) != fasthttp.MethodPost {
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "POST")
+
+			return
+		}
+
+		var source = ctx.PostBody()
+
+		if len(source) > maxSourceSize {
+			ctx.Error(tooLarge, http.StatusRequestEntityTooLarge)
+
+			return
+		} else if isBatch(ctx) {
+			batch(ctx, errorPages, codes)
+
+			return
+		} else if len(source) == 0 {
+			ctx.Error("empty template source\n", http.StatusBadRequest)
+
+			return
+		}
+
+		var format = config.FormatHTML
+
+		if value := ctx.QueryArgs().Peek("format"); len(value) > 0 {
+			f, err := config.ParseFormat(string(value))
+			if err != nil {
+				ctx.Error(err.Error()+"\n", http.StatusBadRequest)
+
+				return
+			}
+
+			format = f
+		}
+
+		synthetic, ok := newSynthetic(ctx, codes)
+		if !ok {
+			return
+		}
+
+		// the body is the template source, not the client's one
+		synthetic.Request.ResetBody()
+		synthetic.Request.Header.Del(fasthttp.HeaderContentType)
+		synthetic.Request.Header.Set("X-Format", formatMimeTypes[format])
+
+		ep.DryRun(synthetic, string(source))
+		errorPages(synthetic)
+
+		synthetic.Response.CopyTo(&ctx.Response)
+	}
+}
@@ -0,0 +1,240 @@
+package renderprops_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/renderprops"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Codes["503"] = config.CodeDescription{Message: "Down <for> maintenance"}
+	cfg.Maintenance = config.MaintenanceWindows{{
+		Start:   time.Date(2030, time.January, 1, 2, 0, 0, 0, time.UTC),
+		End:     time.Date(2030, time.January, 1, 4, 0, 0, 0, time.UTC),
+		Message: "Upgrading",
+		Code:    http.StatusServiceUnavailable,
+		Paths:   []*regexp.Regexp{regexp.MustCompile(`^/preview`)}, // never matches the synthetic requests
+		Codes:   []string{"418"},
+	}}
+
+	var errorPages, closeCache = ep.New(&cfg, logger.NewNop())
+
+	t.Cleanup(closeCache)
+
+	var (
+		handler = renderprops.NewDryRun(errorPages, statuscode.Parser{})
+		do      = func(t *testing.T, method, url, source string, fn func(int, string, http.Header)) {
+			t.Helper()
+
+			req, err := http.NewRequest(method, url, strings.NewReader(source))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded") // like curl -d does
+
+			httptest.HandleFastRequest(t, handler, req, fn)
+		}
+	)
+
+	t.Run("html", func(t *testing.T) {
+		t.Parallel()
+
+		const source = "<p>{{ code }}: {{ message }}</p>{{ if body_preview }}!{{ end }}"
+
+		for range 2 { // never cached, so the second render is the same
+			do(t, http.MethodPost, "http://testing/api/render?code=503", source, func(status int, body string, h http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Equal(t, "text/html; charset=utf-8", h.Get("Content-Type"))
+				assert.Equal(t, "no-store", h.Get("Cache-Control"))
+				assert.Equal(t, "<p>503: Down &lt;for&gt; maintenance</p>", body)
+			})
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		do(t, http.MethodPost, "http://testing/api/render?code=503&format=json", `{"message": "{{ message }}"}`,
+			func(status int, body string, h http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Equal(t, "application/json; charset=utf-8", h.Get("Content-Type"))
+				assert.Equal(t, `{"message": "Down \u003cfor\u003e maintenance"}`, body)
+			},
+		)
+	})
+
+	t.Run("at", func(t *testing.T) {
+		t.Parallel()
+
+		const source = `{{ now.Year }} {{ code }} {{ description }}`
+
+		do(t, http.MethodPost, "http://testing/api/render?code=418&format=plaintext&at=2030-01-01T03:00:00Z", source,
+			func(status int, body string, _ http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Equal(t, "2030 503 Upgrading", body) // in the maintenance window
+			},
+		)
+
+		do(t, http.MethodPost, "http://testing/api/render?code=418&format=plaintext&at=8760h", `{{ now.Year }}`,
+			func(status int, body string, _ http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Equal(t, strconv.Itoa(time.Now().Add(8760*time.Hour).Year()), body) // a year from now
+			},
+		)
+	})
+
+	t.Run("render error", func(t *testing.T) {
+		t.Parallel()
+
+		do(t, http.MethodPost, "http://testing/api/render", "{{ foo }}", func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusUnprocessableEntity, status)
+			assert.Contains(t, body, `function "foo" not defined`)
+		})
+	})
+
+	for name, tt := range map[string]struct {
+		giveMethod, giveURL, giveSource string
+		wantStatus                      int
+	}{
+		"empty source":   {http.MethodPost, "http://testing/api/render", "", http.StatusBadRequest},
+		"wrong format":   {http.MethodPost, "http://testing/api/render?format=yaml", "foo", http.StatusBadRequest},
+		"wrong code":     {http.MethodPost, "http://testing/api/render?code=foo", "foo", http.StatusBadRequest},
+		"wrong time":     {http.MethodPost, "http://testing/api/render?at=tomorrow", "foo", http.StatusBadRequest},
+		"wrong method":   {http.MethodGet, "http://testing/api/render", "", http.StatusMethodNotAllowed},
+		"too large body": {http.MethodPost, "http://testing/api/render", strings.Repeat("x", 1<<20+1), http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			do(t, tt.giveMethod, tt.giveURL, tt.giveSource, func(status int, _ string, _ http.Header) {
+				assert.Equal(t, tt.wantStatus, status)
+			})
+		})
+	}
+}
+
+func TestDryRun_Batch(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Codes["503"] = config.CodeDescription{Message: "Down <for> maintenance"}
+
+	var errorPages, closeCache = ep.New(&cfg, logger.NewNop())
+
+	t.Cleanup(closeCache)
+
+	var (
+		handler = renderprops.NewDryRun(errorPages, statuscode.Parser{})
+		do      = func(t *testing.T, url, body string, fn func(int, string, http.Header)) {
+			t.Helper()
+
+			req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", "de")
+
+			httptest.HandleFastRequest(t, handler, req, fn)
+		}
+	)
+
+	t.Run("renders", func(t *testing.T) {
+		t.Parallel()
+
+		const give = `{
+			"codes": [404, 503],
+			"formats": ["html", "json"],
+			"locales": ["fr", "EN"],
+			"template": "{{ code }} {{ locale }} {{ message }}"
+		}`
+
+		do(t, "http://testing/api/render", give, func(status int, body string, h http.Header) {
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "application/json; charset=utf-8", h.Get("Content-Type"))
+			assert.Equal(t, "no-store", h.Get("Cache-Control"))
+
+			var got struct {
+				Renders []struct {
+					Code        int    `json:"code"`
+					Format      string `json:"format"`
+					Locale      string `json:"locale"`
+					Status      int    `json:"status"`
+					ContentType string `json:"content_type"`
+					Body        string `json:"body"`
+				} `json:"renders"`
+			}
+
+			require.NoError(t, json.Unmarshal([]byte(body), &got))
+			require.Len(t, got.Renders, 8)
+
+			assert.Equal(t, 404, got.Renders[0].Code)
+			assert.Equal(t, "html", got.Renders[0].Format)
+			assert.Equal(t, "fr", got.Renders[0].Locale)
+			assert.Equal(t, http.StatusOK, got.Renders[0].Status)
+			assert.Equal(t, "text/html; charset=utf-8", got.Renders[0].ContentType)
+			assert.Equal(t, "404 fr Introuvable", got.Renders[0].Body)
+
+			assert.Equal(t, "en", got.Renders[1].Locale)
+			assert.Equal(t, "json", got.Renders[2].Format)
+			assert.Equal(t, "application/json; charset=utf-8", got.Renders[2].ContentType)
+
+			assert.Equal(t, 503, got.Renders[7].Code)
+			assert.Equal(t, "503 en Down \\u003cfor\\u003e maintenance", got.Renders[7].Body)
+		})
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		do(t, "http://testing/api/render", `{"codes": [503], "template": "{{ locale }}"}`,
+			func(status int, body string, _ http.Header) {
+				require.Equal(t, http.StatusOK, status)
+				assert.JSONEq(t, `{"renders": [{
+					"code": 503, "format": "html", "status": 200, "content_type": "text/html; charset=utf-8", "body": "de"
+				}]}`, body) // the locale is detected for the request
+			},
+		)
+	})
+
+	var tooMany = `{"codes": [` + strings.Repeat("503,", 256) + `503]}` // 257 renders
+
+	for name, tt := range map[string]struct {
+		giveURL, giveBody string
+		wantStatus        int
+	}{
+		"wrong json":    {"http://testing/api/render", `{"codes": `, http.StatusBadRequest},
+		"unknown field": {"http://testing/api/render", `{"codes": [503], "foo": 1}`, http.StatusBadRequest},
+		"no codes":      {"http://testing/api/render", `{"formats": ["html"]}`, http.StatusBadRequest},
+		"wrong code":    {"http://testing/api/render", `{"codes": [1000]}`, http.StatusBadRequest},
+		"wrong format":  {"http://testing/api/render", `{"codes": [503], "formats": ["yaml"]}`, http.StatusBadRequest},
+		"wrong locale":  {"http://testing/api/render", `{"codes": [503], "locales": ["xx"]}`, http.StatusBadRequest},
+		"wrong time":    {"http://testing/api/render?at=tomorrow", `{"codes": [503]}`, http.StatusBadRequest},
+		"too many":      {"http://testing/api/render", tooMany, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			do(t, tt.giveURL, tt.giveBody, func(status int, _ string, _ http.Header) {
+				assert.Equal(t, tt.wantStatus, status)
+			})
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package renderprops
+
+import (
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+// Path is the path of the render props API endpoint.
+const Path = "/api/render-props"
+
+// New creates a handler that returns the template props (tokens) the error pages handler would use to render the
+// page for the synthetic request (GET), in JSON format. The synthetic request is a copy of the incoming one (so the
+// headers like `Accept-Language` or `X-Forwarded-For` may be passed) with the `/{code}` path, where the code is
+// taken from the `code` query parameter (the path is `/` without it, so the default code is used). The code is
+// validated using the same parser as the error pages handler uses.
+func New(errorPages fasthttp.RequestHandler, codes statuscode.Parser) fasthttp.RequestHandler {
+	var notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if method := string(ctx.Method()); method != fasthttp.MethodGet && method != fasthttp.MethodHead {
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "GET, HEAD")
+
+			return
+		}
+
+		synthetic, ok := newSynthetic(ctx, codes)
+		if !ok {
+			return
+		}
+
+		ep.InspectProps(synthetic)
+		errorPages(synthetic)
+
+		synthetic.Response.CopyTo(&ctx.Response)
+
+		if string(ctx.Method()) == fasthttp.MethodHead {
+			ctx.Response.SkipBody = true
+		}
+	}
+}
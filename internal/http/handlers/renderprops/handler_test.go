@@ -0,0 +1,98 @@
+package renderprops_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/renderprops"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.ShowDetails = true
+	cfg.Datacenter.Code = "ams"
+	cfg.Codes["503"] = config.CodeDescription{Message: "Down for maintenance", Description: "Back soon"}
+
+	var errorPages, closeCache = ep.New(&cfg, logger.NewNop())
+
+	t.Cleanup(closeCache)
+
+	var handler = renderprops.New(errorPages, statuscode.Parser{})
+
+	t.Run("props", func(t *testing.T) {
+		t.Parallel()
+
+		req, err := http.NewRequest(http.MethodGet, "http://testing/api/render-props?code=503", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept", "text/html")
+		req.Header.Set("Accept-Language", "fr-CA")
+		req.Header.Set("Authorization", "Bearer s3cr3t")
+
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
+			assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+			assert.Empty(t, headers.Get("Retry-After")) // the error page headers are dropped
+
+			var props map[string]any
+
+			require.NoError(t, json.Unmarshal([]byte(body), &props))
+
+			assert.InDelta(t, 503, props["code"], 0)
+			assert.Equal(t, "Down for maintenance", props["message"])
+			assert.Equal(t, "Back soon", props["description"])
+			assert.Equal(t, "testing", props["host"])
+			assert.Equal(t, "fr-CA", props["accept_language"])
+			assert.Equal(t, "ams", props["datacenter"])
+			assert.Equal(t, true, props["show_details"])
+			assert.Contains(t, props, "csp_nonce") // the empty tokens are included too
+			assert.Empty(t, props["banner"])
+		})
+	})
+
+	t.Run("default code", func(t *testing.T) {
+		t.Parallel()
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/api/render-props", http.NoBody,
+			func(status int, body string, _ http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Contains(t, body, `"code":404`)
+			},
+		)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		t.Parallel()
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/api/render-props?code=foo", http.NoBody,
+			func(status int, body string, _ http.Header) {
+				assert.Equal(t, http.StatusBadRequest, status)
+				assert.Equal(t, "wrong code: foo\n", body)
+			},
+		)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		t.Parallel()
+
+		httptest.HandleFast(t, handler, http.MethodPost, "http://testing/api/render-props", http.NoBody,
+			func(status int, _ string, headers http.Header) {
+				assert.Equal(t, http.StatusMethodNotAllowed, status)
+				assert.Equal(t, "GET, HEAD", headers.Get("Allow"))
+			},
+		)
+	})
+}
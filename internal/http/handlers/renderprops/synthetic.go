@@ -0,0 +1,94 @@
+package renderprops
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+// newSynthetic prepares the synthetic request for the error pages handler - a copy of the incoming one (without the
+// API credentials) with the `/{code}` path, where the code is taken from the `code` query parameter (the path is `/`
+// without it). The optional `at` query parameter previews the page at another time (see [queryTime]). If the code
+// or time is wrong, the `400 Bad Request` is responded and false is returned.
+func newSynthetic(ctx *fasthttp.RequestCtx, codes statuscode.Parser) (*fasthttp.RequestCtx, bool) {
+	at, ok := queryTime(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	var path = "/"
+
+	if value := ctx.QueryArgs().Peek("code"); len(value) > 0 {
+		code, valid := codes.FromHeader(value)
+		if !valid {
+			ctx.Error("wrong code: "+string(value)+"\n", http.StatusBadRequest)
+
+			return nil, false
+		}
+
+		path += strconv.FormatUint(uint64(code), 10)
+	}
+
+	return synthesize(ctx, path, at), true
+}
+
+// queryTime returns the time from the optional `at` query parameter - an RFC 3339 time (like `2025-01-01T02:00:00Z`)
+// or an offset from now (like `24h` for tomorrow, or `-30m`). The zero time is returned without the parameter. If
+// the time is wrong, the `400 Bad Request` is responded and false is returned.
+func queryTime(ctx *fasthttp.RequestCtx) (time.Time, bool) {
+	var value = ctx.QueryArgs().Peek("at")
+
+	if len(value) == 0 {
+		return time.Time{}, true
+	}
+
+	at, err := parseTime(string(value), time.Now())
+	if err != nil {
+		ctx.Error(err.Error()+"\n", http.StatusBadRequest)
+
+		return time.Time{}, false
+	}
+
+	return at, true
+}
+
+// synthesize creates the synthetic GET request with the URI (the path and query) as a copy of the incoming one
+// (without the API credentials). The page is rendered at the given time, unless it's zero.
+func synthesize(ctx *fasthttp.RequestCtx, uri string, at time.Time) *fasthttp.RequestCtx {
+	var (
+		synthetic fasthttp.RequestCtx
+		req       fasthttp.Request
+	)
+
+	ctx.Request.CopyTo(&req)
+	req.SetRequestURI(uri)
+	req.Header.Del(fasthttp.HeaderAuthorization) // the API credentials are not the client's ones
+	req.Header.SetMethod(fasthttp.MethodGet)
+
+	synthetic.Init(&req, ctx.RemoteAddr(), nil)
+
+	if !at.IsZero() {
+		ep.RenderAt(&synthetic, at)
+	}
+
+	return &synthetic
+}
+
+// parseTime parses the RFC 3339 time or the offset (duration) from now.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("wrong time: %s (expected RFC 3339 time or duration, like 24h)", value)
+}
@@ -0,0 +1,57 @@
+package rotation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+)
+
+// Path is the path of the rotation API endpoint.
+const Path = "/api/rotation"
+
+// New creates a handler that returns the template rotation state (GET) or forces the switch to another template
+// (POST) in JSON format.
+func New(ctl *ep.RotationControl) fasthttp.RequestHandler {
+	var notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+
+	var respond = func(ctx *fasthttp.RequestCtx, state ep.RotationState) {
+		var body, _ = json.Marshal(state) //nolint:errchkjson
+
+		ctx.SetContentType("application/json; charset=utf-8")
+		ctx.Response.Header.Set("Cache-Control", "no-store")
+		ctx.SetStatusCode(http.StatusOK)
+		_, _ = ctx.Write(body)
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet:
+			respond(ctx, ctl.State())
+
+		case fasthttp.MethodHead:
+			ctx.SetStatusCode(http.StatusOK)
+
+		case fasthttp.MethodPost:
+			state, err := ctl.Switch()
+			if errors.Is(err, ep.ErrNotSwitchable) {
+				ctx.Error(err.Error()+"\n", http.StatusConflict)
+
+				return
+			} else if err != nil {
+				ctx.Error(err.Error()+"\n", http.StatusInternalServerError)
+
+				return
+			}
+
+			respond(ctx, state)
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "GET, HEAD, POST")
+		}
+	}
+}
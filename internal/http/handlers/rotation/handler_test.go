@@ -0,0 +1,118 @@
+package rotation_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/rotation"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var newControl = func(t *testing.T, mode config.RotationMode) *ep.RotationControl {
+		t.Helper()
+
+		var (
+			cfg = config.New()
+			ctl ep.RotationControl
+		)
+
+		cfg.RotationMode = mode
+		cfg.Templates = map[string]string{"foo": "foo", "bar": "bar", "baz": "baz"}
+		cfg.TemplateName = "foo"
+
+		var _, closeCache = ep.New(&cfg, logger.NewNop(), ep.WithRotationControl(&ctl))
+
+		t.Cleanup(closeCache)
+
+		return &ctl
+	}
+
+	var decode = func(t *testing.T, body string) (state ep.RotationState) {
+		t.Helper()
+
+		require.NoError(t, json.Unmarshal([]byte(body), &state))
+
+		return
+	}
+
+	t.Run("get", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = rotation.New(newControl(t, config.RotationModeDisabled))
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/api/rotation", http.NoBody, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
+			assert.Equal(t, "no-store", headers.Get("Cache-Control"))
+
+			var state = decode(t, body)
+
+			assert.Equal(t, "disabled", state.Mode)
+			assert.Equal(t, "foo", state.ActiveTemplate)
+			assert.NotNil(t, state.ChangedAt)
+			assert.Nil(t, state.NextSwitchAt)
+			assert.ElementsMatch(t, []string{"foo", "bar", "baz"}, state.Templates)
+		})
+	})
+
+	t.Run("force switch", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			handler = rotation.New(newControl(t, config.RotationModeRandomOnStartup))
+			before  string
+		)
+
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/api/rotation", http.NoBody, func(_ int, body string, _ http.Header) {
+			before = decode(t, body).ActiveTemplate
+		})
+
+		require.NotEmpty(t, before)
+
+		httptest.HandleFast(t, handler, http.MethodPost, "http://testing/api/rotation", http.NoBody, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.NotEqual(t, before, decode(t, body).ActiveTemplate)
+		})
+	})
+
+	t.Run("not switchable", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = rotation.New(newControl(t, config.RotationModeRandomOnEachRequest))
+
+		httptest.HandleFast(t, handler, http.MethodPost, "http://testing/api/rotation", http.NoBody, func(status int, _ string, _ http.Header) {
+			assert.Equal(t, http.StatusConflict, status)
+		})
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = rotation.New(newControl(t, config.RotationModeDisabled))
+
+		httptest.HandleFast(t, handler, http.MethodDelete, "http://testing/api/rotation", http.NoBody, func(status int, _ string, headers http.Header) {
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+			assert.Equal(t, "GET, HEAD, POST", headers.Get("Allow"))
+		})
+	})
+
+	t.Run("unbound control", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = rotation.New(&ep.RotationControl{})
+
+		httptest.HandleFast(t, handler, http.MethodPost, "http://testing/api/rotation", http.NoBody, func(status int, _ string, _ http.Header) {
+			assert.Equal(t, http.StatusConflict, status)
+		})
+	})
+}
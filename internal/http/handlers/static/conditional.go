@@ -0,0 +1,30 @@
+package static
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// isSingleByteRange reports whether the `Range` header value is the single byte range (like `bytes=0-99`). The
+// other units and the multiple ranges are not supported, so the header is ignored and the whole content is sent.
+func isSingleByteRange(value []byte) bool {
+	return bytes.HasPrefix(value, []byte("bytes=")) && !bytes.ContainsRune(value, ',')
+}
+
+// ifRange reports whether the range may be sent according to the `If-Range` header value - it must be the same
+// (strong) entity tag or the exact last modification date. The missing header allows the range.
+func ifRange(value []byte, etag string, lastModified time.Time) bool {
+	if len(value) == 0 {
+		return true
+	}
+
+	if value[0] == '"' || bytes.HasPrefix(value, []byte("W/")) { // the weak tags never match (RFC 9110, section 13.1.5)
+		return string(value) == etag
+	}
+
+	date, err := fasthttp.ParseHTTPDate(value)
+
+	return err == nil && date.Equal(lastModified)
+}
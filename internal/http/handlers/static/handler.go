@@ -1,31 +1,137 @@
 package static
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/contentcoding"
+	"github.com/binaryYuki/error-pages/internal/http/precondition"
 )
 
-//go:embed favicon.ico
-var Favicon []byte
+//go:generate go run precompress.go favicon.ico
+
+var (
+	//go:embed favicon.ico
+	favicon []byte
+	//go:embed favicon.ico.br
+	faviconBrotli []byte
+	//go:embed favicon.ico.gz
+	faviconGzip []byte
+)
 
-// New creates a new handler that returns the provided content for GET and HEAD requests.
-func New(content []byte) fasthttp.RequestHandler {
-	var notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+// Favicon is the embedded favicon with its pre-compressed variants.
+var Favicon = Asset{Content: favicon, Brotli: faviconBrotli, Gzip: faviconGzip} //nolint:gochecknoglobals
 
-	return func(ctx *fasthttp.RequestCtx) {
-		switch string(ctx.Method()) {
-		case fasthttp.MethodGet:
-			ctx.SetContentType(http.DetectContentType(content))
-			ctx.SetStatusCode(http.StatusOK)
-			_, _ = ctx.Write(content)
+// Asset is the content with its pre-compressed variants. The variants are written at build time by the
+// `go generate` (see the precompress.go), so nothing is compressed on the application start.
+type Asset struct {
+	Content []byte
+	Brotli  []byte // the Brotli compressed content (optional)
+	Gzip    []byte // the GZIP compressed content (optional)
+}
+
+// encoded is a variant of the content (the original or pre-compressed one).
+type encoded struct {
+	name    string // the content encoding name (e.g. `br` or `gzip`), empty for the original content
+	content []byte
+	etag    string // the strong entity tag of the variant
+}
 
-		case fasthttp.MethodHead:
-			ctx.SetStatusCode(http.StatusOK)
+// New creates a new handler that returns the provided asset for GET and HEAD requests (the HEAD responses have the
+// same headers as the GET ones, without the body).
+//
+// The pre-compressed variant is served when the client supports it (based on the `Accept-Encoding` request header).
+// Variants that are not smaller than the original content are not served.
+//
+// Every variant has its own `ETag`, and the `Last-Modified` is the handler creation time (the content is not changed
+// while running), so the caches may revalidate the content using the `If-None-Match` and `If-Modified-Since`
+// request headers (the `304 Not Modified` is responded). The single byte range requests (the `Range` header,
+// optionally with the `If-Range`) are supported too.
+func New(asset Asset) fasthttp.RequestHandler {
+	var (
+		content      = asset.Content
+		notAllowed   = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+		notSatisfied = http.StatusText(http.StatusRequestedRangeNotSatisfiable) + "\n"
+		contentType  = http.DetectContentType(content)
+		lastModified = time.Now().UTC().Truncate(time.Second) // the HTTP dates have a second precision
+		hash         = sha256.Sum256(content)
+		tag          = hex.EncodeToString(hash[:8])
+		original     = encoded{content: content, etag: `"` + tag + `"`}
+		variants     = make([]encoded, 0, 2) //nolint:mnd // ordered by preference
+		names        = make([]string, 0, 2)  //nolint:mnd // the variant names for the negotiation
+	)
 
-		default:
+	for _, v := range []encoded{{name: "br", content: asset.Brotli}, {name: "gzip", content: asset.Gzip}} {
+		if len(v.content) > 0 && len(v.content) < len(content) {
+			v.etag = `"` + tag + "-" + v.name + `"`
+			variants = append(variants, v)
+			names = append(names, v.name)
+		}
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		var method = string(ctx.Method())
+
+		if method != fasthttp.MethodGet && method != fasthttp.MethodHead {
 			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+
+			return
 		}
+
+		var (
+			reqHeaders = &ctx.Request.Header
+			variant    = original
+		)
+
+		if len(variants) > 0 {
+			ctx.Response.Header.Set(fasthttp.HeaderVary, fasthttp.HeaderAcceptEncoding)
+
+			var accepted = contentcoding.Negotiate(string(reqHeaders.Peek(fasthttp.HeaderAcceptEncoding)), names...)
+
+			for _, v := range variants {
+				if v.name == accepted {
+					ctx.Response.Header.Set(fasthttp.HeaderContentEncoding, v.name)
+					variant = v
+
+					break
+				}
+			}
+		}
+
+		ctx.Response.Header.Set(fasthttp.HeaderETag, variant.etag)
+		ctx.Response.Header.SetLastModified(lastModified)
+		ctx.Response.Header.Set(fasthttp.HeaderAcceptRanges, "bytes")
+
+		if precondition.NotModified(reqHeaders, variant.etag, lastModified) {
+			ctx.SetStatusCode(http.StatusNotModified) // the validators are kept (RFC 9110, section 15.4.5)
+
+			return
+		}
+
+		var body, status = variant.content, http.StatusOK
+
+		if byteRange := reqHeaders.Peek(fasthttp.HeaderRange); isSingleByteRange(byteRange) &&
+			ifRange(reqHeaders.Peek(fasthttp.HeaderIfRange), variant.etag, lastModified) {
+			start, end, err := fasthttp.ParseByteRange(byteRange, len(body))
+			if err != nil {
+				ctx.Error(notSatisfied, http.StatusRequestedRangeNotSatisfiable)
+				ctx.Response.Header.Set(fasthttp.HeaderContentRange, "bytes */"+strconv.Itoa(len(body)))
+
+				return
+			}
+
+			ctx.Response.Header.SetContentRange(start, end, len(body))
+			body, status = body[start:end+1], http.StatusPartialContent
+		}
+
+		ctx.SetContentType(contentType)
+		ctx.SetStatusCode(status)
+		_, _ = ctx.Write(body) // the server skips the body of the HEAD response, but keeps its length
 	}
 }
@@ -2,9 +2,14 @@ package static_test
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
 
 	"gh.tarampamp.am/error-pages/internal/http/handlers/static"
 	"gh.tarampamp.am/error-pages/internal/http/httptest"
@@ -14,7 +19,7 @@ func TestServeHTTP(t *testing.T) {
 	t.Parallel()
 
 	var (
-		handler = static.New([]byte{1, 2, 3})
+		handler = static.New(static.Asset{Content: []byte{1, 2, 3}})
 		url     = "http://testing"
 		body    = http.NoBody
 	)
@@ -30,7 +35,8 @@ func TestServeHTTP(t *testing.T) {
 	t.Run("head", func(t *testing.T) {
 		httptest.HandleFast(t, handler, http.MethodHead, url, body, func(status int, body string, headers http.Header) {
 			assert.Equal(t, http.StatusOK, status)
-			assert.Empty(t, headers.Get("Content-Type"))
+			assert.Equal(t, "application/octet-stream", headers.Get("Content-Type"))
+			assert.Equal(t, "3", headers.Get("Content-Length"))
 			assert.Empty(t, body)
 		})
 	})
@@ -62,7 +68,271 @@ func TestServeHTTP_Favicon(t *testing.T) {
 		func(status int, body string, headers http.Header) {
 			assert.Equal(t, http.StatusOK, status)
 			assert.Equal(t, "image/x-icon", headers.Get("Content-Type"))
-			assert.Equal(t, static.Favicon, []byte(body))
+			assert.Equal(t, static.Favicon.Content, []byte(body))
 		},
 	)
+
+	t.Run("pre-compressed variants are up to date", func(t *testing.T) {
+		t.Parallel()
+
+		brotli, err := fasthttp.AppendUnbrotliBytes(nil, static.Favicon.Brotli)
+		require.NoError(t, err)
+		assert.Equal(t, static.Favicon.Content, brotli, "run `go generate` to update the favicon.ico.br")
+
+		gzip, err := fasthttp.AppendGunzipBytes(nil, static.Favicon.Gzip)
+		require.NoError(t, err)
+		assert.Equal(t, static.Favicon.Content, gzip, "run `go generate` to update the favicon.ico.gz")
+	})
+}
+
+// compressed returns the asset with the pre-compressed variants of the content.
+func compressed(content []byte) static.Asset {
+	return static.Asset{
+		Content: content,
+		Brotli:  fasthttp.AppendBrotliBytes(nil, content),
+		Gzip:    fasthttp.AppendGzipBytes(nil, content),
+	}
+}
+
+func TestServeHTTP_Compressed(t *testing.T) {
+	t.Parallel()
+
+	var (
+		content = []byte(strings.Repeat("compressible content ", 100))
+		handler = static.New(compressed(content))
+	)
+
+	for name, tt := range map[string]struct {
+		giveAcceptEncoding string
+		wantEncoding       string
+		wantDecode         func([]byte) ([]byte, error)
+	}{
+		"brotli": {
+			giveAcceptEncoding: "gzip, deflate, br",
+			wantEncoding:       "br",
+			wantDecode:         func(b []byte) ([]byte, error) { return fasthttp.AppendUnbrotliBytes(nil, b) },
+		},
+		"gzip": {
+			giveAcceptEncoding: "gzip",
+			wantEncoding:       "gzip",
+			wantDecode:         func(b []byte) ([]byte, error) { return fasthttp.AppendGunzipBytes(nil, b) },
+		},
+		"brotli is not acceptable": {
+			giveAcceptEncoding: "br;q=0, gzip;q=0.5",
+			wantEncoding:       "gzip",
+			wantDecode:         func(b []byte) ([]byte, error) { return fasthttp.AppendGunzipBytes(nil, b) },
+		},
+		"wildcard without brotli": {
+			giveAcceptEncoding: "*, br;q=0",
+			wantEncoding:       "gzip",
+			wantDecode:         func(b []byte) ([]byte, error) { return fasthttp.AppendGunzipBytes(nil, b) },
+		},
+		"brotli only": {
+			giveAcceptEncoding: "*;q=0, br",
+			wantEncoding:       "br",
+			wantDecode:         func(b []byte) ([]byte, error) { return fasthttp.AppendUnbrotliBytes(nil, b) },
+		},
+		"gzip is preferred": {
+			giveAcceptEncoding: "br;q=0.5, gzip",
+			wantEncoding:       "gzip",
+			wantDecode:         func(b []byte) ([]byte, error) { return fasthttp.AppendGunzipBytes(nil, b) },
+		},
+		"nothing is acceptable": {
+			giveAcceptEncoding: "*;q=0",
+			wantDecode:         func(b []byte) ([]byte, error) { return b, nil },
+		},
+		"identity": {
+			giveAcceptEncoding: "identity",
+			wantDecode:         func(b []byte) ([]byte, error) { return b, nil },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodGet, "http://testing", http.NoBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Accept-Encoding", tt.giveAcceptEncoding)
+
+			httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Equal(t, tt.wantEncoding, headers.Get("Content-Encoding"))
+				assert.Equal(t, "Accept-Encoding", headers.Get("Vary"))
+				assert.Equal(t, "text/plain; charset=utf-8", headers.Get("Content-Type"))
+
+				decoded, decErr := tt.wantDecode([]byte(body))
+				require.NoError(t, decErr)
+				assert.Equal(t, content, decoded)
+			})
+		})
+	}
+
+	t.Run("head", func(t *testing.T) {
+		t.Parallel()
+
+		req, err := http.NewRequest(http.MethodHead, "http://testing", http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("Accept-Encoding", "br")
+
+		httptest.HandleFastRequest(t, handler, req, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "br", headers.Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", headers.Get("Vary"))
+			assert.Equal(t, "text/plain; charset=utf-8", headers.Get("Content-Type"))
+			assert.Equal(t, strconv.Itoa(len(compressed(content).Brotli)), headers.Get("Content-Length"))
+			assert.Empty(t, body)
+		})
+	})
+}
+
+func TestServeHTTP_Conditional(t *testing.T) {
+	t.Parallel()
+
+	var handler = static.New(static.Asset{Content: []byte("0123456789")})
+
+	var do = func(t *testing.T, method string, headers map[string]string, fn func(int, string, http.Header)) {
+		t.Helper()
+
+		req, err := http.NewRequest(method, "http://testing", http.NoBody)
+		require.NoError(t, err)
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, fn)
+	}
+
+	var etag, lastModified string
+
+	do(t, http.MethodGet, nil, func(status int, body string, headers http.Header) {
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "0123456789", body)
+		assert.Equal(t, "bytes", headers.Get("Accept-Ranges"))
+
+		etag, lastModified = headers.Get("ETag"), headers.Get("Last-Modified")
+	})
+
+	require.Regexp(t, `^"[0-9a-f]{16}"$`, etag)
+	require.NotEmpty(t, lastModified)
+
+	var since, err = http.ParseTime(lastModified)
+	require.NoError(t, err)
+
+	for name, tt := range map[string]struct {
+		giveMethod  string
+		giveHeaders map[string]string
+		wantStatus  int
+		wantBody    string
+		wantRange   string
+	}{
+		"if-none-match": {
+			giveHeaders: map[string]string{"If-None-Match": `"foo", W/` + etag},
+			wantStatus:  http.StatusNotModified,
+		},
+		"if-none-match any": {
+			giveHeaders: map[string]string{"If-None-Match": "*"},
+			wantStatus:  http.StatusNotModified,
+		},
+		"if-none-match head": {
+			giveMethod:  http.MethodHead,
+			giveHeaders: map[string]string{"If-None-Match": etag},
+			wantStatus:  http.StatusNotModified,
+		},
+		"if-none-match changed": {
+			giveHeaders: map[string]string{"If-None-Match": `"foo"`},
+			wantStatus:  http.StatusOK,
+			wantBody:    "0123456789",
+		},
+		"if-none-match wins": {
+			giveHeaders: map[string]string{"If-None-Match": `"foo"`, "If-Modified-Since": lastModified},
+			wantStatus:  http.StatusOK,
+			wantBody:    "0123456789",
+		},
+		"if-modified-since": {
+			giveHeaders: map[string]string{"If-Modified-Since": lastModified},
+			wantStatus:  http.StatusNotModified,
+		},
+		"if-modified-since older": {
+			giveHeaders: map[string]string{"If-Modified-Since": since.Add(-time.Second).Format(http.TimeFormat)},
+			wantStatus:  http.StatusOK,
+			wantBody:    "0123456789",
+		},
+		"range": {
+			giveHeaders: map[string]string{"Range": "bytes=2-4"},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "234",
+			wantRange:   "bytes 2-4/10",
+		},
+		"range suffix": {
+			giveHeaders: map[string]string{"Range": "bytes=-3"},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "789",
+			wantRange:   "bytes 7-9/10",
+		},
+		"range open": {
+			giveHeaders: map[string]string{"Range": "bytes=8-", "If-Range": etag},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "89",
+			wantRange:   "bytes 8-9/10",
+		},
+		"range if-range date": {
+			giveHeaders: map[string]string{"Range": "bytes=0-0", "If-Range": lastModified},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "0",
+			wantRange:   "bytes 0-0/10",
+		},
+		"range if-range changed": {
+			giveHeaders: map[string]string{"Range": "bytes=0-0", "If-Range": `"foo"`},
+			wantStatus:  http.StatusOK,
+			wantBody:    "0123456789",
+		},
+		"range multiple": {
+			giveHeaders: map[string]string{"Range": "bytes=0-1,5-6"},
+			wantStatus:  http.StatusOK,
+			wantBody:    "0123456789",
+		},
+		"range unsatisfiable": {
+			giveHeaders: map[string]string{"Range": "bytes=10-20"},
+			wantStatus:  http.StatusRequestedRangeNotSatisfiable,
+			wantBody:    "Requested Range Not Satisfiable\n",
+			wantRange:   "bytes */10",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var method = tt.giveMethod
+
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			do(t, method, tt.giveHeaders, func(status int, body string, headers http.Header) {
+				assert.Equal(t, tt.wantStatus, status)
+				assert.Equal(t, tt.wantBody, body)
+				assert.Equal(t, tt.wantRange, headers.Get("Content-Range"))
+
+				if status != http.StatusRequestedRangeNotSatisfiable {
+					assert.Equal(t, etag, headers.Get("ETag"))
+				}
+			})
+		})
+	}
+
+	t.Run("compressed variant", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = static.New(compressed([]byte(strings.Repeat("compressible content ", 100))))
+
+		req, reqErr := http.NewRequest(http.MethodGet, "http://testing", http.NoBody)
+		require.NoError(t, reqErr)
+
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		httptest.HandleFastRequest(t, handler, req, func(_ int, _ string, headers http.Header) {
+			assert.True(t, strings.HasSuffix(headers.Get("ETag"), `-gzip"`))
+		})
+	})
 }
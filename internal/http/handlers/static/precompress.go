@@ -0,0 +1,31 @@
+//go:build ignore
+
+package main
+
+import (
+	"os"
+
+	"github.com/valyala/fasthttp"
+)
+
+// main writes the Brotli (`.br`) and GZIP (`.gz`) compressed variants next to every file passed in the arguments,
+// so the embedded assets are compressed once at build time (not on every application start).
+func main() {
+	for _, name := range os.Args[1:] {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+
+		for ext, compressed := range map[string][]byte{
+			".br": fasthttp.AppendBrotliBytesLevel(nil, content, fasthttp.CompressBrotliBestCompression),
+			".gz": fasthttp.AppendGzipBytesLevel(nil, content, fasthttp.CompressBestCompression),
+		} {
+			if err = os.WriteFile(name+ext, compressed, 0o644); err != nil { //nolint:gosec,mnd
+				panic(err)
+			}
+		}
+
+		println("✔ " + name + " pre-compressed")
+	}
+}
@@ -0,0 +1,118 @@
+package status
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/upstream"
+)
+
+// Path is the path of the status endpoint.
+const Path = "/status.json"
+
+type (
+	// Status is the current state shown on the error pages.
+	Status struct {
+		Version     string        `json:"version"` // changes whenever the rest of the status changes
+		Banner      ep.Banner     `json:"banner"`
+		Maintenance []Maintenance `json:"maintenance"`        // the active and upcoming windows
+		Upstream    *Upstream     `json:"upstream,omitempty"` // nil if the upstream health is unknown
+	}
+
+	// Maintenance is the scheduled maintenance window.
+	Maintenance struct {
+		Start   string `json:"start"` // RFC 3339, UTC
+		End     string `json:"end"`   // RFC 3339, UTC
+		Message string `json:"message,omitempty"`
+		Active  bool   `json:"active"`
+	}
+
+	// Upstream is the result of the last upstream health check.
+	Upstream struct {
+		Healthy bool `json:"healthy"`
+	}
+)
+
+// New creates a handler that returns the current outage banner, maintenance windows, and upstream health state,
+// polled by the already open error pages. The `v` query parameter (or the `If-None-Match` header) with the last
+// seen version makes the handler respond with 204 (304 for the header) until the status changes, so the polls are
+// almost free while nothing happens. CORS is allowed, since the pages may be served from another origin.
+func New(banner *ep.BannerControl, windows config.MaintenanceWindows, probe *upstream.Prober) fasthttp.RequestHandler {
+	var notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+
+	var current = func(now time.Time) Status {
+		var s = Status{Banner: banner.Get(), Maintenance: make([]Maintenance, 0, len(windows))}
+
+		for _, w := range windows {
+			if !now.Before(w.End) { // already finished
+				continue
+			}
+
+			s.Maintenance = append(s.Maintenance, Maintenance{
+				Start:   w.Start.UTC().Format(time.RFC3339),
+				End:     w.End.UTC().Format(time.RFC3339),
+				Message: w.Message,
+				Active:  !now.Before(w.Start),
+			})
+		}
+
+		if probe != nil {
+			if last, checked := probe.Status(); checked { // the check time is omitted, so the version is stable
+				s.Upstream = &Upstream{Healthy: last.Healthy}
+			}
+		}
+
+		var h = fnv.New64a()
+
+		_ = json.NewEncoder(h).Encode(s) //nolint:errchkjson // the version is empty while hashing
+
+		s.Version = strconv.FormatUint(h.Sum64(), 36)
+
+		return s
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		switch method := string(ctx.Method()); method {
+		case fasthttp.MethodGet, fasthttp.MethodHead:
+			var (
+				s    = current(time.Now())
+				etag = strconv.Quote(s.Version)
+			)
+
+			ctx.Response.Header.Set("Cache-Control", "no-cache")
+			ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+			ctx.Response.Header.Set("ETag", etag)
+
+			switch {
+			case string(ctx.QueryArgs().Peek("v")) == s.Version:
+				ctx.SetStatusCode(http.StatusNoContent)
+
+				return
+			case string(ctx.Request.Header.Peek("If-None-Match")) == etag:
+				ctx.SetStatusCode(http.StatusNotModified)
+
+				return
+			}
+
+			ctx.SetContentType("application/json; charset=utf-8")
+			ctx.SetStatusCode(http.StatusOK)
+
+			if method == fasthttp.MethodGet {
+				var body, _ = json.Marshal(s) //nolint:errchkjson
+
+				_, _ = ctx.Write(body)
+			}
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "GET, HEAD") // set after the error, since it resets the response
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package status_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/status"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cfg = config.New()
+		ctl ep.BannerControl
+		now = time.Now().UTC()
+		url = "http://testing" + status.Path
+	)
+
+	cfg.Banner.Message = "Degraded performance"
+	cfg.Maintenance = config.MaintenanceWindows{
+		{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}, // finished
+		{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Message: "Database upgrade"},
+		{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+	}
+
+	var _, closeCache = ep.New(&cfg, logger.NewNop(), ep.WithBannerControl(&ctl))
+	defer closeCache()
+
+	var (
+		handler = status.New(&ctl, cfg.Maintenance, nil)
+		get     = func(t *testing.T, url string) (s status.Status, etag string) {
+			t.Helper()
+
+			httptest.HandleFast(t, handler, http.MethodGet, url, http.NoBody, func(code int, body string, headers http.Header) {
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
+				assert.Equal(t, "no-cache", headers.Get("Cache-Control"))
+				assert.Equal(t, "*", headers.Get("Access-Control-Allow-Origin"))
+				require.NoError(t, json.Unmarshal([]byte(body), &s))
+
+				etag = headers.Get("ETag")
+			})
+
+			return
+		}
+	)
+
+	var s, etag = get(t, url)
+
+	assert.NotEmpty(t, s.Version)
+	assert.Equal(t, `"`+s.Version+`"`, etag)
+	assert.Equal(t, ep.Banner{Message: "Degraded performance", Severity: "info"}, s.Banner)
+	assert.Nil(t, s.Upstream) // the upstream health probe is not configured
+
+	require.Len(t, s.Maintenance, 2)
+	assert.Equal(t, status.Maintenance{
+		Start:   now.Add(-time.Hour).Format(time.RFC3339),
+		End:     now.Add(time.Hour).Format(time.RFC3339),
+		Message: "Database upgrade",
+		Active:  true,
+	}, s.Maintenance[0])
+	assert.False(t, s.Maintenance[1].Active)
+
+	t.Run("not changed", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodGet, url+"?v="+s.Version, http.NoBody,
+			func(code int, body string, _ http.Header) {
+				assert.Equal(t, http.StatusNoContent, code)
+				assert.Empty(t, body)
+			},
+		)
+
+		req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+		require.NoError(t, err)
+
+		req.Header.Set("If-None-Match", etag)
+
+		httptest.HandleFastRequest(t, handler, req, func(code int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusNotModified, code)
+			assert.Empty(t, body)
+		})
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		_, err := ctl.Set("All good", "")
+		require.NoError(t, err)
+
+		var changed, _ = get(t, url+"?v="+s.Version)
+
+		assert.NotEqual(t, s.Version, changed.Version)
+		assert.Equal(t, "All good", changed.Banner.Message)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodPost, url, http.NoBody, func(code int, _ string, headers http.Header) {
+			assert.Equal(t, http.StatusMethodNotAllowed, code)
+			assert.Equal(t, "GET, HEAD", headers.Get("Allow"))
+		})
+	})
+}
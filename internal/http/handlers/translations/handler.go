@@ -0,0 +1,65 @@
+package translations
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+// PathPrefix is the path prefix of the translation catalog endpoints (`/l10n/{locale}.json`).
+const PathPrefix = "/l10n/"
+
+// New creates a handler that returns the translation catalog for the locale from the request path
+// (`/l10n/{locale}.json`) in JSON format. The translations are keyed by the phrase tokens, the same way the
+// client-side localization script does.
+func New() fasthttp.RequestHandler {
+	var bodies = make(map[string][]byte) // map[locale]json
+
+	for _, locale := range l10n.Locales() {
+		var translations, _ = l10n.Export(locale)
+
+		bodies[locale], _ = json.Marshal(struct { //nolint:errchkjson
+			Locale       string            `json:"locale"`
+			Direction    string            `json:"direction"`
+			Translations map[string]string `json:"translations"`
+		}{
+			Locale:       locale,
+			Direction:    l10n.Direction(locale),
+			Translations: translations,
+		})
+	}
+
+	var (
+		notFound   = http.StatusText(http.StatusNotFound) + "\n"
+		notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
+	)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		var locale, isJSON = strings.CutSuffix(strings.TrimPrefix(string(ctx.Path()), PathPrefix), ".json")
+
+		body, found := bodies[strings.ToLower(locale)]
+		if !isJSON || !found {
+			ctx.Error(notFound, http.StatusNotFound)
+
+			return
+		}
+
+		switch string(ctx.Method()) {
+		case fasthttp.MethodGet:
+			ctx.SetContentType("application/json; charset=utf-8")
+			ctx.Response.Header.Set("Cache-Control", "public, max-age=86400")
+			ctx.SetStatusCode(http.StatusOK)
+			_, _ = ctx.Write(body)
+
+		case fasthttp.MethodHead:
+			ctx.SetStatusCode(http.StatusOK)
+
+		default:
+			ctx.Error(notAllowed, http.StatusMethodNotAllowed)
+		}
+	}
+}
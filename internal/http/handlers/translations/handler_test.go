@@ -0,0 +1,63 @@
+package translations_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/http/handlers/translations"
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		handler = translations.New()
+		body    = http.NoBody
+	)
+
+	t.Run("get", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/l10n/de.json", body, func(status int, body string, headers http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
+			assert.NotEmpty(t, headers.Get("Cache-Control"))
+			assert.Contains(t, body, `"locale":"de"`)
+			assert.Contains(t, body, `"direction":"ltr"`)
+			assert.Contains(t, body, `"error":"Fehler"`)
+		})
+	})
+
+	t.Run("default locale", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodGet, "http://testing/l10n/en.json", body, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, `{"locale":"en","direction":"ltr","translations":{}}`, body)
+		})
+	})
+
+	t.Run("head", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodHead, "http://testing/l10n/fr.json", body, func(status int, body string, _ http.Header) {
+			assert.Equal(t, http.StatusOK, status)
+			assert.Empty(t, body)
+		})
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		for _, url := range []string{
+			"http://testing/l10n/xx.json",
+			"http://testing/l10n/de",
+			"http://testing/l10n/",
+		} {
+			httptest.HandleFast(t, handler, http.MethodGet, url, body, func(status int, _ string, _ http.Header) {
+				assert.Equal(t, http.StatusNotFound, status, url)
+			})
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		httptest.HandleFast(t, handler, http.MethodPost, "http://testing/l10n/de.json", body, func(status int, _ string, _ http.Header) {
+			assert.Equal(t, http.StatusMethodNotAllowed, status)
+		})
+	})
+}
@@ -0,0 +1,58 @@
+// Package apiauth protects the management API endpoints with the bearer token.
+package apiauth
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Challenge is the `WWW-Authenticate` header value of the rejected requests.
+const Challenge = `Bearer realm="error-pages"`
+
+// New creates a middleware that rejects the requests without the `Authorization: Bearer <token>` header with the
+// `401 Unauthorized` (the token is compared in constant time). Without the token, the read-only requests (GET, HEAD,
+// and OPTIONS) are allowed, and the mutating ones are allowed from the loopback addresses only (the rest are
+// rejected with the `403 Forbidden`), so the API can't be changed by any client reaching the server.
+func New(token string) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
+	var (
+		unauthorized = http.StatusText(http.StatusUnauthorized) + "\n"
+		forbidden    = "the API token is required to make changes from the non-loopback addresses\n"
+	)
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		if token == "" {
+			return func(ctx *fasthttp.RequestCtx) {
+				switch string(ctx.Method()) {
+				case fasthttp.MethodGet, fasthttp.MethodHead, fasthttp.MethodOptions:
+				default:
+					if !ctx.RemoteIP().IsLoopback() {
+						ctx.Error(forbidden, http.StatusForbidden)
+
+						return
+					}
+				}
+
+				next(ctx)
+			}
+		}
+
+		return func(ctx *fasthttp.RequestCtx) {
+			var scheme, credentials, _ = bytes.Cut(
+				bytes.TrimSpace(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)), []byte(" "),
+			)
+
+			if !bytes.EqualFold(scheme, []byte("Bearer")) ||
+				subtle.ConstantTimeCompare(bytes.TrimSpace(credentials), []byte(token)) != 1 {
+				ctx.Error(unauthorized, http.StatusUnauthorized)
+				ctx.Response.Header.Set(fasthttp.HeaderWWWAuthenticate, Challenge)
+
+				return
+			}
+
+			next(ctx)
+		}
+	}
+}
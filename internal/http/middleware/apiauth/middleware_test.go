@@ -0,0 +1,96 @@
+package apiauth_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/http/middleware/apiauth"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var next = func(ctx *fasthttp.RequestCtx) { _, _ = ctx.WriteString("ok") }
+
+	var do = func(t *testing.T, handler fasthttp.RequestHandler, authorization string) (status int, body string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, "http://testing/api/rotation", http.NoBody)
+		require.NoError(t, err)
+
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(s int, b string, h http.Header) {
+			status, body = s, b
+
+			if s == http.StatusUnauthorized {
+				assert.Equal(t, apiauth.Challenge, h.Get("WWW-Authenticate"))
+			}
+		})
+
+		return
+	}
+
+	t.Run("no token", func(t *testing.T) {
+		t.Parallel()
+
+		status, body := do(t, apiauth.New("")(next), "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("no token, changes", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = apiauth.New("")(next)
+
+		for remoteIP, want := range map[string]int{
+			"127.0.0.1":   http.StatusOK,
+			"::1":         http.StatusOK,
+			"10.0.0.1":    http.StatusForbidden,
+			"203.0.113.9": http.StatusForbidden,
+		} {
+			var (
+				ctx fasthttp.RequestCtx
+				req fasthttp.Request
+			)
+
+			req.Header.SetMethod(http.MethodPost)
+			req.SetRequestURI("http://testing/api/banner")
+
+			ctx.Init(&req, &net.TCPAddr{IP: net.ParseIP(remoteIP), Port: 1234}, nil)
+
+			handler(&ctx)
+
+			assert.Equal(t, want, ctx.Response.StatusCode(), remoteIP)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		t.Parallel()
+
+		var handler = apiauth.New("s3cr3t")(next)
+
+		for authorization, want := range map[string]int{
+			"":                 http.StatusUnauthorized,
+			"s3cr3t":           http.StatusUnauthorized,
+			"Basic s3cr3t":     http.StatusUnauthorized,
+			"Bearer s3cr3":     http.StatusUnauthorized,
+			"Bearer s3cr3t!":   http.StatusUnauthorized,
+			"Bearer s3cr3t":    http.StatusOK,
+			"bearer  s3cr3t ":  http.StatusOK,
+			" Bearer s3cr3t  ": http.StatusOK,
+		} {
+			status, _ := do(t, handler, authorization)
+			assert.Equal(t, want, status, authorization)
+		}
+	})
+}
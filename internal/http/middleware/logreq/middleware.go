@@ -5,14 +5,17 @@ import (
 
 	"github.com/valyala/fasthttp"
 
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
 	"github.com/binaryYuki/error-pages/internal/logger"
 )
 
 // New creates a middleware that logs every incoming request.
 //
-// The skipper function should return true if the request should be skipped. It's ok to pass nil.
+// The client IP resolver is used to log the real client IP address (behind the trusted proxies), and the skipper
+// function should return true if the request should be skipped. It's ok to pass nil for both.
 func New(
 	log *logger.Logger,
+	clientIP *clientip.Resolver,
 	skipper func(*fasthttp.RequestCtx) bool,
 ) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
@@ -34,6 +37,7 @@ func New(
 					logger.String("referer", string(ctx.Referer())),
 					logger.String("content type", string(ctx.Response.Header.ContentType())),
 					logger.String("remote addr", ctx.RemoteAddr().String()),
+					logger.String("client ip", clientIP.String(ctx)),
 					logger.Duration("duration", time.Since(now).Round(time.Microsecond)),
 				}
 
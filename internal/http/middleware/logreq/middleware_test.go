@@ -20,7 +20,7 @@ func TestNew(t *testing.T) {
 		buf    bytes.Buffer
 		log, _ = logger.New(logger.DebugLevel, logger.JSONFormat, &buf)
 
-		mw     = logreq.New(log, nil)
+		mw     = logreq.New(log, nil, nil)
 		req, _ = http.NewRequest(http.MethodPut, "http://testing/foo/bar", http.NoBody)
 	)
 
@@ -0,0 +1,147 @@
+// Package loopguard protects the error pages from the proxy loops (error backend → proxy → error backend), so the
+// misconfiguration fails fast instead of rendering the pages recursively.
+package loopguard
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+// Header marks the error pages responses. The request carrying it has already been processed by the error pages
+// (the proxy passed the error response back), so it's rejected instead of being rendered again.
+const Header = "X-Error-Pages"
+
+// The reasons of the loop suspicion (reported in the metrics).
+const (
+	ReasonMarker = "marker" // the request carries the [Header]
+	ReasonVia    = "via"    // the `Via` header chain includes this service
+	ReasonRate   = "rate"   // the same client requests the same code too often
+)
+
+// Options contains the middleware options.
+type Options struct {
+	// Pseudonym is the name of this service in the `Via` header chains (empty disables the check).
+	Pseudonym string
+
+	// MaxRate is the maximum number of requests per second from the same client for the same code (the same path
+	// and the `X-Code` header), zero means no limit.
+	MaxRate uint
+
+	// ClientIP resolves the client IP address for the rate limiting (behind the trusted proxies; optional).
+	ClientIP *clientip.Resolver
+
+	// Metrics is the registry to report the suspected loops to (optional).
+	Metrics *metrics.Registry
+
+	// Now returns the current time (optional, defaults to [time.Now]).
+	Now func() time.Time
+
+	// OnLoop is called for the rejected requests (optional). With the rate limit, it's called only once per
+	// client, code, and second, so the logs are not flooded.
+	OnLoop func(ctx *fasthttp.RequestCtx, reason string)
+}
+
+// New creates a middleware that marks the responses with the [Header] and rejects the suspected loops (the marked
+// requests, the `Via` chains including this service, and the repeated requests from the same client) with the
+// `508 Loop Detected` and a minimal body.
+func New(opt Options) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
+	var (
+		loopDetected = http.StatusText(http.StatusLoopDetected) + "\n"
+		suspected    = opt.Metrics.Counter(
+			"error_pages_loop_suspected_total", "Requests rejected as the suspected proxy loops by reason", "reason",
+		)
+		rates = rateCounter{counts: make(map[string]uint)}
+	)
+
+	if opt.Now == nil {
+		opt.Now = time.Now
+	}
+
+	var reject = func(ctx *fasthttp.RequestCtx, reason string, notify bool) {
+		suspected.Inc(reason)
+
+		if notify && opt.OnLoop != nil {
+			opt.OnLoop(ctx, reason)
+		}
+
+		ctx.Error(loopDetected, http.StatusLoopDetected)
+		ctx.Response.Header.Set(Header, "1") // the rejection is marked too, so the loop is broken anyway
+	}
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			var headers = &ctx.Request.Header
+
+			if len(headers.Peek(Header)) > 0 {
+				reject(ctx, ReasonMarker, true)
+
+				return
+			}
+
+			if opt.Pseudonym != "" && viaIncludes(headers.PeekAll(fasthttp.HeaderVia), opt.Pseudonym) {
+				reject(ctx, ReasonVia, true)
+
+				return
+			}
+
+			if opt.MaxRate > 0 {
+				var key = opt.ClientIP.String(ctx) + "\x00" + string(ctx.Path()) + "\x00" + string(headers.Peek("X-Code"))
+
+				if n := rates.hit(key, opt.Now()); n > opt.MaxRate {
+					reject(ctx, ReasonRate, n == opt.MaxRate+1) // notify once per second
+
+					return
+				}
+			}
+
+			next(ctx)
+
+			ctx.Response.Header.Set(Header, "1")
+		}
+	}
+}
+
+// viaIncludes reports whether any of the `Via` header entries (like `1.1 proxy, 1.0 error-pages (comment)`) is
+// received by the pseudonym (case is ignored).
+func viaIncludes(values [][]byte, pseudonym string) bool {
+	for _, value := range values {
+		for entry := range strings.SplitSeq(string(value), ",") {
+			// the entry is `[protocol-name "/"] protocol-version received-by [comment]`
+			if fields := strings.Fields(entry); len(fields) >= 2 && strings.EqualFold(fields[1], pseudonym) { //nolint:mnd
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// rateCounter counts the requests by key within the current second (a fixed window, so the memory is bounded by
+// the number of distinct keys per second). It's safe for concurrent use.
+type rateCounter struct {
+	mu     sync.Mutex
+	second int64
+	counts map[string]uint
+}
+
+// hit counts the request and returns the number of requests with the same key within the current second.
+func (r *rateCounter) hit(key string, now time.Time) uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s := now.Unix(); s != r.second {
+		r.second = s
+		clear(r.counts)
+	}
+
+	r.counts[key]++
+
+	return r.counts[key]
+}
@@ -0,0 +1,97 @@
+package loopguard_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/httptest"
+	"github.com/binaryYuki/error-pages/internal/http/middleware/loopguard"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var (
+		reg     = metrics.NewRegistry()
+		reasons []string
+		calls   int
+	)
+
+	var handler = loopguard.New(loopguard.Options{
+		Pseudonym: "error-pages",
+		MaxRate:   3,
+		Metrics:   reg,
+		Now:       func() time.Time { return time.Unix(1700000000, 0) }, // the same second
+		OnLoop:    func(_ *fasthttp.RequestCtx, reason string) { reasons = append(reasons, reason) },
+	})(func(ctx *fasthttp.RequestCtx) {
+		calls++
+
+		ctx.SetStatusCode(http.StatusNotFound)
+		_, _ = ctx.WriteString("page")
+	})
+
+	var do = func(path string, headers map[string]string) (status int, body string) {
+		req, err := http.NewRequest(http.MethodGet, "http://testing"+path, http.NoBody)
+		require.NoError(t, err)
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		httptest.HandleFastRequest(t, handler, req, func(s int, b string, h http.Header) {
+			status, body = s, b
+
+			assert.Equal(t, "1", h.Get(loopguard.Header)) // every response is marked
+		})
+
+		return
+	}
+
+	status, body := do("/404", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "page", body)
+
+	t.Run("marker", func(t *testing.T) {
+		status, body := do("/500", map[string]string{loopguard.Header: "1"}) // the response is passed back
+		assert.Equal(t, http.StatusLoopDetected, status)
+		assert.Equal(t, "Loop Detected\n", body)
+	})
+
+	t.Run("via", func(t *testing.T) {
+		status, _ := do("/501", map[string]string{"Via": "1.1 varnish, 1.1 Error-Pages (fasthttp)"})
+		assert.Equal(t, http.StatusLoopDetected, status)
+
+		status, _ = do("/501", map[string]string{"Via": "1.1 varnish, HTTP/1.0 error-pages-proxy"})
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		for range 2 { // the first request for the code is made above
+			status, _ := do("/404", nil)
+			assert.Equal(t, http.StatusNotFound, status)
+		}
+
+		for range 3 {
+			status, _ := do("/404", nil)
+			assert.Equal(t, http.StatusLoopDetected, status)
+		}
+
+		status, _ := do("/404", map[string]string{"X-Code": "410"}) // another code
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	assert.Equal(t, 5, calls)
+	assert.Equal(t, []string{loopguard.ReasonMarker, loopguard.ReasonVia, loopguard.ReasonRate}, reasons) // notified once
+
+	var counter = reg.Counter("error_pages_loop_suspected_total", "")
+
+	assert.Equal(t, uint64(1), counter.Value(loopguard.ReasonMarker))
+	assert.Equal(t, uint64(1), counter.Value(loopguard.ReasonVia))
+	assert.Equal(t, uint64(3), counter.Value(loopguard.ReasonRate))
+}
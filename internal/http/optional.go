@@ -0,0 +1,67 @@
+//go:build !slim
+
+package http
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	metricsHandler "github.com/binaryYuki/error-pages/internal/http/handlers/metrics"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/og"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/lifecycle"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+	"github.com/binaryYuki/error-pages/internal/publish"
+	"github.com/binaryYuki/error-pages/internal/s3"
+)
+
+// the optional subsystems (excluded from the slim build, see the optional_slim.go)
+
+// newRegistry creates the metrics registry.
+func newRegistry() *metrics.Registry { return metrics.NewRegistry() }
+
+// newMetricsEndpoint creates the Prometheus metrics endpoint handler (served on the [metricsHandler.Path]).
+func newMetricsEndpoint(reg *metrics.Registry) (string, fasthttp.RequestHandler, error) {
+	return metricsHandler.Path, metricsHandler.New(reg), nil
+}
+
+// newOGHandler creates the social preview images handler (served on the paths with the returned prefix, nil if the
+// images are not available).
+func newOGHandler(
+	cfg *config.Config,
+	codes statuscode.Parser,
+	activeTemplate func() string,
+) (string, fasthttp.RequestHandler, error) {
+	return og.PathPrefix, og.New(cfg, codes, activeTemplate), nil
+}
+
+// startPublisher starts publishing the rendered pages of the active template to the S3 bucket.
+func startPublisher(
+	cfg *config.Config,
+	log *logger.Logger,
+	services *lifecycle.Manager,
+	activeTemplate func() string,
+) error {
+	creds, err := s3.CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	store, err := s3.NewClient(cfg.Publish.Bucket, cfg.Publish.Region, cfg.Publish.Endpoint, creds)
+	if err != nil {
+		return err
+	}
+
+	var publisher = publish.New(cfg, log, store, activeTemplate)
+
+	services.Go("pages publisher", func(ctx context.Context) error {
+		publisher.Run(ctx)
+
+		return nil
+	})
+
+	return nil
+}
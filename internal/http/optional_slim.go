@@ -0,0 +1,44 @@
+//go:build slim
+
+package http
+
+import (
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/lifecycle"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+// the optional subsystems are excluded from the slim build to reduce the binary size, so enabling them is an error
+
+// errSlim returns the error about the feature not available in the slim build.
+func errSlim(feature string) error {
+	return fmt.Errorf("%s is not available in the slim build", feature)
+}
+
+// newRegistry returns nil - the metrics are not collected.
+func newRegistry() *metrics.Registry { return nil }
+
+// newMetricsEndpoint returns the error - there is no metrics endpoint.
+func newMetricsEndpoint(*metrics.Registry) (string, fasthttp.RequestHandler, error) {
+	return "", nil, errSlim("the metrics endpoint")
+}
+
+// newOGHandler returns no handler - the social preview images are not served (so the pages can't refer to them).
+func newOGHandler(cfg *config.Config, _ statuscode.Parser, _ func() string) (string, fasthttp.RequestHandler, error) {
+	if cfg.OGImageBaseURL != "" {
+		return "", nil, errSlim("the social preview images endpoint")
+	}
+
+	return "", nil, nil
+}
+
+// startPublisher returns the error - the pages are not published.
+func startPublisher(*config.Config, *logger.Logger, *lifecycle.Manager, func() string) error {
+	return errSlim("the pages publisher")
+}
@@ -0,0 +1,38 @@
+// Package precondition provides the evaluation of the conditional request headers (RFC 9110, section 13).
+package precondition
+
+import (
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// NotModified reports whether the client content (per the `If-None-Match` or `If-Modified-Since` request headers)
+// is still fresh, so the `304 Not Modified` may be responded. The `If-None-Match` takes precedence, so the
+// `If-Modified-Since` is ignored when both are set (RFC 9110, section 13.2.2).
+func NotModified(headers *fasthttp.RequestHeader, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := headers.Peek(fasthttp.HeaderIfNoneMatch); len(ifNoneMatch) > 0 {
+		return etagListMatches(string(ifNoneMatch), etag)
+	}
+
+	if ifModifiedSince := headers.Peek(fasthttp.HeaderIfModifiedSince); len(ifModifiedSince) > 0 {
+		if since, err := fasthttp.ParseHTTPDate(ifModifiedSince); err == nil {
+			return !lastModified.After(since)
+		}
+	}
+
+	return false
+}
+
+// etagListMatches reports whether the `If-None-Match` header value (like `"foo", W/"bar"` or `*`) includes the
+// entity tag, using the weak comparison.
+func etagListMatches(list, etag string) bool {
+	for item := range strings.SplitSeq(list, ",") {
+		if item = strings.TrimSpace(item); item == "*" || strings.TrimPrefix(item, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package precondition_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	"github.com/binaryYuki/error-pages/internal/http/precondition"
+)
+
+func TestNotModified(t *testing.T) {
+	t.Parallel()
+
+	var (
+		etag     = `"abc"`
+		modified = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		before   = string(fasthttp.AppendHTTPDate(nil, modified.Add(-time.Second)))
+		same     = string(fasthttp.AppendHTTPDate(nil, modified))
+		after    = string(fasthttp.AppendHTTPDate(nil, modified.Add(time.Hour)))
+	)
+
+	for name, tt := range map[string]struct {
+		giveHeaders map[string]string
+		want        bool
+	}{
+		"no headers":                 {},
+		"same etag":                  {giveHeaders: map[string]string{"If-None-Match": `"abc"`}, want: true},
+		"weak etag":                  {giveHeaders: map[string]string{"If-None-Match": `W/"abc"`}, want: true},
+		"etag in the list":           {giveHeaders: map[string]string{"If-None-Match": `"foo", "abc"`}, want: true},
+		"wildcard":                   {giveHeaders: map[string]string{"If-None-Match": `*`}, want: true},
+		"other etag":                 {giveHeaders: map[string]string{"If-None-Match": `"foo", W/"bar"`}},
+		"unquoted etag":              {giveHeaders: map[string]string{"If-None-Match": `abc`}},
+		"modified since":             {giveHeaders: map[string]string{"If-Modified-Since": before}},
+		"not modified since (same)":  {giveHeaders: map[string]string{"If-Modified-Since": same}, want: true},
+		"not modified since (after)": {giveHeaders: map[string]string{"If-Modified-Since": after}, want: true},
+		"wrong date":                 {giveHeaders: map[string]string{"If-Modified-Since": "yesterday"}},
+		"etag takes precedence (hit)": {
+			giveHeaders: map[string]string{"If-None-Match": `"abc"`, "If-Modified-Since": before},
+			want:        true,
+		},
+		"etag takes precedence (miss)": {
+			giveHeaders: map[string]string{"If-None-Match": `"foo"`, "If-Modified-Since": after},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var headers fasthttp.RequestHeader
+
+			for key, value := range tt.giveHeaders {
+				headers.Set(key, value)
+			}
+
+			assert.Equal(t, tt.want, precondition.NotModified(&headers, etag, modified))
+		})
+	}
+}
@@ -7,25 +7,48 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/valyala/fasthttp"
 
 	"github.com/binaryYuki/error-pages/internal/appmeta"
 	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/featureflag"
+	"github.com/binaryYuki/error-pages/internal/http/clientip"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/banner"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/check"
 	ep "github.com/binaryYuki/error-pages/internal/http/handlers/error_page"
 	"github.com/binaryYuki/error-pages/internal/http/handlers/live"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/prebuilt"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/renderprops"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/rotation"
 	"github.com/binaryYuki/error-pages/internal/http/handlers/static"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/status"
+	"github.com/binaryYuki/error-pages/internal/http/handlers/translations"
 	"github.com/binaryYuki/error-pages/internal/http/handlers/version"
+	"github.com/binaryYuki/error-pages/internal/http/middleware/apiauth"
 	"github.com/binaryYuki/error-pages/internal/http/middleware/logreq"
+	"github.com/binaryYuki/error-pages/internal/http/middleware/loopguard"
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+	"github.com/binaryYuki/error-pages/internal/lifecycle"
 	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/metrics"
+	"github.com/binaryYuki/error-pages/internal/upstream"
 )
 
 // Server is an HTTP server for serving error pages.
 type Server struct {
-	log        *logger.Logger
-	server     *fasthttp.Server
-	beforeStop func()
+	log       *logger.Logger
+	server    *fasthttp.Server
+	handler   *atomic.Pointer[fasthttp.RequestHandler] // the handler of the current configuration
+	lifecycle *lifecycle.Manager                       // the background services, stopped before the server
+
+	// the runtime state, shared by the handlers of all the configurations (so it survives the reloads)
+	rotation  *ep.RotationControl
+	banner    *ep.BannerControl
+	readiness *ep.Readiness
+	registry  *metrics.Registry
 }
 
 // NewServer creates a new HTTP server.
@@ -35,9 +58,16 @@ func NewServer(log *logger.Logger, readBufferSize uint) Server {
 		writeTimeout = readTimeout + 10*time.Second // should be bigger than the read timeout
 	)
 
+	var handler = new(atomic.Pointer[fasthttp.RequestHandler])
+
 	return Server{
 		log: log,
 		server: &fasthttp.Server{
+			Handler: func(ctx *fasthttp.RequestCtx) {
+				if h := handler.Load(); h != nil {
+					(*h)(ctx)
+				}
+			},
 			ReadTimeout:                  readTimeout,
 			WriteTimeout:                 writeTimeout,
 			ReadBufferSize:               int(readBufferSize), //nolint:gosec
@@ -46,29 +76,262 @@ func NewServer(log *logger.Logger, readBufferSize uint) Server {
 			CloseOnShutdown:              true,
 			Logger:                       logger.NewStdLog(log),
 		},
-		beforeStop: func() {}, // noop
+		handler:   handler,
+		lifecycle: lifecycle.New(log),
+		rotation:  new(ep.RotationControl),
+		banner:    new(ep.BannerControl),
+		readiness: new(ep.Readiness),
+		registry:  newRegistry(),
 	}
 }
 
 // Register server handlers, middlewares, etc.
 func (s *Server) Register(cfg *config.Config) error {
+	handler, err := s.newHandler(cfg, s.lifecycle)
+	if err != nil {
+		return err
+	}
+
+	s.handler.Store(&handler)
+
+	return nil
+}
+
+// Reload applies the new configuration to the running server. The handlers are created for the new configuration
+// and replace the current ones atomically (the requests in flight are completed by the previous handlers), and then
+// the background services of the previous configuration are stopped. If the handlers cannot be created, the
+// previous configuration is kept. It must not be called concurrently with [Server.Stop].
+func (s *Server) Reload(ctx context.Context, cfg *config.Config) error {
+	var services = lifecycle.New(s.log)
+
+	handler, err := s.newHandler(cfg, services)
+	if err != nil {
+		_ = services.Stop(ctx)
+
+		return err
+	}
+
+	var previous = s.lifecycle
+
+	s.handler.Store(&handler)
+	s.lifecycle = services
+
+	return previous.Stop(ctx)
+}
+
+// newHandler creates the request handler (with all the routes and middlewares) for the configuration. The
+// background services are started using the provided lifecycle manager. The runtime state (the rotation and banner
+// controls, the readiness, and the metrics) is shared with the handlers of the previous configurations.
+func (s *Server) newHandler(cfg *config.Config, services *lifecycle.Manager) (fasthttp.RequestHandler, error) {
 	var (
 		liveHandler    = live.New()
 		versionHandler = version.New(appmeta.Version())
 		faviconHandler = static.New(static.Favicon)
+		l10nHandler    = translations.New()
+
+		rotationCtl, bannerCtl = s.rotation, s.banner
+		readiness              = s.readiness    // not ready while warming up
+		probe                  *upstream.Prober // nil if the upstream health URL is not configured
+		registry               = s.registry     // collected even if the endpoint is disabled (may be enabled on reload)
+	)
+
+	if cfg.UpstreamHealth.URL != "" {
+		probe = upstream.NewProber(cfg.UpstreamHealth.URL, cfg.UpstreamHealth.Interval, cfg.UpstreamHealth.Timeout)
 
-		errorPagesHandler, closeCache = ep.New(cfg, s.log)
+		var probed = readiness.Begin() // the pages are not ready until the upstream status is known
+
+		services.Go("upstream health prober", func(ctx context.Context) error {
+			probe.Run(ctx)
+
+			return nil
+		})
+		services.Go("upstream readiness waiter", func(ctx context.Context) error {
+			defer probed()
+
+			select {
+			case <-probe.Checked():
+			case <-ctx.Done():
+			}
+
+			return nil
+		})
+	}
+
+	var flags *featureflag.Store // nil if the feature flags are not configured (or not needed in the static mode)
+
+	if ff := cfg.FeatureFlags; (len(ff.Values) > 0 || ff.File != "" || ff.RedisURL != "") && cfg.StaticDir == "" {
+		var backends []featureflag.Backend
+
+		if ff.File != "" {
+			backends = append(backends, featureflag.File(ff.File))
+		}
+
+		if ff.RedisURL != "" {
+			redis, err := featureflag.NewRedis(ff.RedisURL, ff.RedisKey)
+			if err != nil {
+				return nil, err
+			}
+
+			backends = append(backends, redis)
+		}
+
+		flags = featureflag.New(ff.Values, ff.Interval, backends...)
+
+		if len(backends) > 0 {
+			var loaded = readiness.Begin() // the pages are not ready until the flags are loaded
+
+			services.Go("feature flags loader", func(ctx context.Context) error {
+				flags.Run(ctx, func(err error) { s.log.Warn("Feature flags reload failed", logger.Error(err)) })
+
+				return nil
+			})
+			services.Go("feature flags readiness waiter", func(ctx context.Context) error {
+				defer loaded()
+
+				select {
+				case <-flags.Loaded():
+				case <-ctx.Done():
+				}
+
+				return nil
+			})
+		}
+	}
+
+	var (
+		errorPagesHandler, closeCache = ep.New(cfg, s.log,
+			ep.WithRotationControl(rotationCtl),
+			ep.WithBannerControl(bannerCtl),
+			ep.WithUpstreamProbe(probe),
+			ep.WithReadiness(readiness),
+			ep.WithLifecycle(services),
+			ep.WithMetrics(registry),
+			ep.WithFeatureFlags(flags),
+		)
+
+		apiAuth         = apiauth.New(cfg.APIToken)
+		rotationHandler = apiAuth(rotation.New(rotationCtl))
+		bannerHandler   = apiAuth(banner.New(bannerCtl))
+		checkHandler    = check.New(probe, cfg.UpstreamHealth.Interval)
+		statusHandler   = status.New(bannerCtl, cfg.Maintenance, probe)
 
 		notFound   = http.StatusText(http.StatusNotFound) + "\n"
 		notAllowed = http.StatusText(http.StatusMethodNotAllowed) + "\n"
 	)
 
-	// wrap the before shutdown function to close the cache
-	s.beforeStop = closeCache
+	var activeTemplate = func() string {
+		if name := rotationCtl.State().ActiveTemplate; name != "" {
+			return name
+		}
 
-	s.server.Handler = func(ctx *fasthttp.RequestCtx) {
+		return cfg.TemplateName // the per-request rotation has no single active template
+	}
+
+	// the rendered pages of the active template are published to the bucket (not needed in the static mode)
+	if cfg.Publish.Bucket != "" && cfg.StaticDir == "" {
+		if err := startPublisher(cfg, s.log, services, activeTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		metricsPath     string
+		metricsEndpoint fasthttp.RequestHandler // nil if the metrics endpoint is disabled
+	)
+
+	if cfg.EnableMetrics {
+		var err error
+
+		if metricsPath, metricsEndpoint, err = newMetricsEndpoint(registry); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		clientIP        = clientip.New(cfg.ClientIP.TrustedProxies, cfg.ClientIP.MaxHops)
+		codes           = statuscode.Parser{Strict: cfg.RequestHeaders.StrictCodes}
+		urlContainsCode = func(url string) (ok bool) { _, ok = codes.FromPath(url); return } //nolint:nlreturn
+		routes          = cfg.Routes
+	)
+
+	// the props are inspected (and the dry-run renders are done) using the rendering handler itself, so the
+	// synthetic requests are handled exactly the same way as the real ones
+	var (
+		renderPropsHandler = apiAuth(renderprops.New(errorPagesHandler, codes))
+		dryRunHandler      = apiAuth(renderprops.NewDryRun(errorPagesHandler, codes))
+	)
+
+	var (
+		ogPrefix  string
+		ogHandler fasthttp.RequestHandler // nil if the images are not available (or not needed in the static mode)
+	)
+
+	if cfg.StaticDir == "" {
+		var err error
+
+		if ogPrefix, ogHandler, err = newOGHandler(cfg, codes, activeTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	// in the static mode, the pre-built pages are served instead of rendering them at runtime
+	if cfg.StaticDir != "" {
+		closeCache() // not needed in this mode
+
+		var defaultCode = cfg.DefaultCodeToRender
+
+		if cfg.CatchAll.Enabled {
+			defaultCode = http.StatusNotFound
+		}
+
+		handler, err := prebuilt.New(cfg.StaticDir, prebuilt.Options{
+			DefaultCode:             defaultCode,
+			RespondWithSameHTTPCode: cfg.RespondWithSameHTTPCode,
+			Template:                cfg.TemplateName,
+			Codes:                   codes,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		errorPagesHandler, routes = handler, nil // no routing table
+		urlContainsCode = func(url string) bool { return prebuilt.URLContainsCode(url, codes) }
+	}
+
+	// the error pages responses are marked, so the requests looping back from a misconfigured proxy fail fast
+	errorPagesHandler = loopguard.New(loopguard.Options{
+		Pseudonym: cfg.LoopGuard.Pseudonym,
+		MaxRate:   cfg.LoopGuard.MaxRate,
+		ClientIP:  clientIP,
+		Metrics:   registry,
+		OnLoop: func(ctx *fasthttp.RequestCtx, reason string) {
+			s.log.Warn("Proxy loop suspected",
+				logger.String("reason", reason),
+				logger.String("path", string(ctx.Path())),
+				logger.String("remote addr", ctx.RemoteAddr().String()),
+				logger.String("client ip", clientIP.String(ctx)),
+			)
+		},
+	})(errorPagesHandler)
+
+	var handler fasthttp.RequestHandler = func(ctx *fasthttp.RequestCtx) {
 		var url, method = string(ctx.Path()), string(ctx.Method())
 
+		// strip the path prefix (if configured) before the routing, so the handlers see the path without it
+		if prefix := cfg.PathPrefix; prefix != "" {
+			if url != prefix && !strings.HasPrefix(url, prefix+"/") {
+				ctx.Error(notFound, fasthttp.StatusNotFound)
+
+				return
+			}
+
+			if url = strings.TrimPrefix(url, prefix); url == "" {
+				url = "/"
+			}
+
+			ctx.URI().SetPath(url)
+		}
+
 		switch {
 		// live endpoints
 		case url == "/healthz" || url == "/health/live" || url == "/health" || url == "/live":
@@ -78,10 +341,46 @@ func (s *Server) Register(cfg *config.Config) error {
 		case url == "/version":
 			versionHandler(ctx)
 
+		// Prometheus metrics endpoint (if enabled)
+		case metricsEndpoint != nil && url == metricsPath:
+			metricsEndpoint(ctx)
+
 		// favicon.ico endpoint
 		case url == "/favicon.ico":
 			faviconHandler(ctx)
 
+		// translation catalogs (for the client-side localization), unless the localization is disabled:
+		//	- /l10n/{locale}.json
+		case strings.HasPrefix(url, translations.PathPrefix) && !cfg.L10n.Disable:
+			l10nHandler(ctx)
+
+		// management API (if enabled; the templates are not rendered in the static mode)
+		case url == rotation.Path && cfg.EnableAPI && cfg.StaticDir == "":
+			rotationHandler(ctx)
+
+		case url == banner.Path && cfg.EnableAPI && cfg.StaticDir == "":
+			bannerHandler(ctx)
+
+		// debugging API (exposes the request details, so the authentication is required)
+		case url == renderprops.Path && cfg.EnableAPI && cfg.APIToken != "" && cfg.StaticDir == "":
+			renderPropsHandler(ctx)
+
+		case url == renderprops.DryRunPath && cfg.EnableAPI && cfg.APIToken != "" && cfg.StaticDir == "":
+			dryRunHandler(ctx)
+
+		// the upstream health check, polled by the error pages (if the upstream health probe is configured)
+		case url == check.Path && probe != nil:
+			checkHandler(ctx)
+
+		// the live status, polled by the already open error pages (the banner is not set in the static mode)
+		case url == status.Path && cfg.StaticDir == "":
+			statusHandler(ctx)
+
+		// the social preview images, referenced by the pages meta tags (the templates are not used in the static mode):
+		//	- /og/{code}.png
+		case ogHandler != nil && strings.HasPrefix(url, ogPrefix):
+			ogHandler(ctx)
+
 		// error pages endpoints:
 		//	- /
 		//	-	/{code}.html
@@ -89,7 +388,36 @@ func (s *Server) Register(cfg *config.Config) error {
 		//	- /{code}
 		//
 		// the HTTP method is not limited to GET and HEAD - it can be any
-		case url == "/" || ep.URLContainsCode(url) || ep.HeadersContainCode(&ctx.Request.Header):
+		case url == "/" || urlContainsCode(url) || headerContainsCode(codes, &ctx.Request.Header):
+			errorPagesHandler(ctx)
+
+		// the HTML fragments for the SSI and ESI includes (not supported in the static mode):
+		//	- /fragment/{code}
+		case strings.HasPrefix(url, ep.FragmentPathPrefix) && cfg.StaticDir == "" && ep.URLContainsFragment(url, codes):
+			errorPagesHandler(ctx)
+
+		// requests with a known error kind (the `X-Error-Kind` header; not supported in the static mode)
+		case len(cfg.ErrorKinds) > 0 && cfg.StaticDir == "" &&
+			ep.HeadersContainErrorKind(&ctx.Request.Header, cfg.ErrorKinds):
+			errorPagesHandler(ctx)
+
+		// requests with a known response profile (the `X-Error-Profile` header or the `/profile/{name}` path; not
+		// supported in the static mode)
+		case len(cfg.Profiles) > 0 && cfg.StaticDir == "" &&
+			ep.RequestContainsProfile(url, &ctx.Request.Header, cfg.Profiles):
+			errorPagesHandler(ctx)
+
+		// requests with a known TLS error, reported by the terminating proxy (not supported in the static mode)
+		case cfg.TLSErrors.Header != "" && cfg.StaticDir == "" &&
+			ep.HeadersContainTLSError(&ctx.Request.Header, cfg.TLSErrors.Header, cfg.TLSErrors.Kinds):
+			errorPagesHandler(ctx)
+
+		// paths matched by the routing table
+		case len(routes) > 0 && routesMatch(routes, url):
+			errorPagesHandler(ctx)
+
+		// in the catch-all mode, any other request renders the "not found" error page
+		case cfg.CatchAll.Enabled:
 			errorPagesHandler(ctx)
 
 		// wrong requests handling
@@ -106,13 +434,11 @@ func (s *Server) Register(cfg *config.Config) error {
 	}
 
 	// apply middleware
-	s.server.Handler = logreq.New(s.log, func(ctx *fasthttp.RequestCtx) bool {
+	return logreq.New(s.log, clientIP, func(ctx *fasthttp.RequestCtx) bool {
 		// skip logging healthcheck and .ico (favicon) requests
 		return strings.Contains(strings.ToLower(string(ctx.UserAgent())), "healthcheck") ||
 			strings.HasSuffix(string(ctx.Path()), ".ico")
-	})(s.server.Handler)
-
-	return nil
+	})(handler), nil
 }
 
 // Start server.
@@ -141,7 +467,23 @@ func (s *Server) Stop(timeout time.Duration) error {
 	var ctx, cancel = context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	s.beforeStop()
+	// the background services (like the cache janitor or the upstream health prober) are stopped first, in the
+	// reverse order of their start
+	var servicesErr = s.lifecycle.Stop(ctx)
+
+	return errors.Join(servicesErr, s.server.ShutdownWithContext(ctx))
+}
+
+// headerContainsCode reports whether the request headers contain a valid error code.
+func headerContainsCode(codes statuscode.Parser, headers *fasthttp.RequestHeader) bool {
+	_, ok := codes.FromHeader(headers.Peek(statuscode.Header))
+
+	return ok
+}
+
+// routesMatch reports whether the path matches any route in the routing table.
+func routesMatch(routes config.Routes, path string) bool {
+	_, ok := routes.Match(path)
 
-	return s.server.ShutdownWithContext(ctx)
+	return ok
 }
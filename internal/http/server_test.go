@@ -1,11 +1,18 @@
 package http_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	stdHttptest "net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,6 +22,7 @@ import (
 	"github.com/binaryYuki/error-pages/internal/config"
 	appHttp "github.com/binaryYuki/error-pages/internal/http"
 	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/proxycheck"
 )
 
 // TestRouting in fact is a test for the whole server, because it tests all the routes and their handlers.
@@ -308,6 +316,460 @@ func TestRouting(t *testing.T) {
 	})
 }
 
+func TestRouting_PathPrefix(t *testing.T) {
+	var (
+		srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+		cfg = config.New()
+	)
+
+	cfg.PathPrefix = "/errors"
+	cfg.Routes = config.Routes{{Pattern: regexp.MustCompile(`^/old-api/`), Code: 410}}
+
+	require.NoError(t, srv.Register(&cfg))
+
+	var baseUrl, stopServer = startServer(t, &srv)
+
+	defer stopServer()
+
+	for path, wantCode := range map[string]int{
+		"/errors/healthz":      http.StatusOK,
+		"/errors/version":      http.StatusOK,
+		"/errors/l10n/de.json": http.StatusOK,
+		"/errors":              http.StatusOK,
+		"/errors/":             http.StatusOK,
+		"/errors/404.html":     http.StatusOK,
+		"/healthz":             http.StatusNotFound,
+		"/404.html":            http.StatusNotFound,
+		"/errorsfoo":           http.StatusNotFound,
+		"/errors/foo":          http.StatusNotFound,
+		"/errors/old-api/":     http.StatusOK,
+		"/old-api/":            http.StatusNotFound,
+	} {
+		status, _, _ := sendRequest(t, http.MethodGet, baseUrl+path)
+
+		assert.Equal(t, wantCode, status, path)
+	}
+}
+
+func TestRouting_CatchAll(t *testing.T) {
+	var (
+		srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+		cfg = config.New()
+	)
+
+	cfg.CatchAll.Enabled = true
+	cfg.RespondWithSameHTTPCode = true
+
+	require.NoError(t, srv.Register(&cfg))
+
+	var baseUrl, stopServer = startServer(t, &srv)
+
+	defer stopServer()
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		status, body, headers := sendRequest(t, method, baseUrl+"/foo/bar", map[string]string{"Accept": "text/plain"})
+
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Contains(t, string(body), "Not Found")
+		assert.Empty(t, headers.Get("Retry-After"))
+	}
+
+	status, _, _ := sendRequest(t, http.MethodGet, baseUrl+"/healthz")
+
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestRouting_API(t *testing.T) {
+	for name, enabled := range map[string]bool{"enabled": true, "disabled": false} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+				cfg = config.New()
+			)
+
+			cfg.EnableAPI = enabled
+
+			require.NoError(t, srv.Register(&cfg))
+
+			var baseUrl, stopServer = startServer(t, &srv)
+
+			defer stopServer()
+
+			status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/rotation")
+
+			if enabled {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Contains(t, string(body), `"mode":"disabled"`)
+			} else {
+				assert.Equal(t, http.StatusNotFound, status)
+			}
+		})
+	}
+}
+
+func TestRouting_APIToken(t *testing.T) {
+	for name, token := range map[string]string{"with token": "s3cr3t", "without token": ""} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+				cfg = config.New()
+			)
+
+			cfg.EnableAPI, cfg.APIToken = true, token
+
+			require.NoError(t, srv.Register(&cfg))
+
+			var baseUrl, stopServer = startServer(t, &srv)
+
+			defer stopServer()
+
+			var auth = map[string]string{"Authorization": "Bearer s3cr3t"}
+
+			status, _, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/rotation", auth)
+			assert.Equal(t, http.StatusOK, status)
+
+			status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/render-props?code=503", auth)
+
+			if token != "" {
+				assert.Equal(t, http.StatusOK, status)
+				assert.Contains(t, string(body), `"code":503`)
+
+				status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/rotation") // no credentials
+				assert.Equal(t, http.StatusUnauthorized, status)
+
+				status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/render-props")
+				assert.Equal(t, http.StatusUnauthorized, status)
+
+				status, _, _ = sendRequest(t, http.MethodPost, baseUrl+"/api/render")
+				assert.Equal(t, http.StatusUnauthorized, status)
+			} else {
+				assert.Equal(t, http.StatusNotFound, status) // not available without the token
+			}
+		})
+	}
+}
+
+func TestRouting_ErrorKind(t *testing.T) {
+	var (
+		srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+		cfg = config.New()
+	)
+
+	cfg.ErrorKinds = config.ErrorKinds{"quota_exceeded": {Code: 429}}
+	cfg.RespondWithSameHTTPCode = true
+
+	require.NoError(t, srv.Register(&cfg))
+
+	var baseUrl, stopServer = startServer(t, &srv)
+
+	defer stopServer()
+
+	status, _, _ := sendRequest(t, http.MethodGet, baseUrl+"/foo", map[string]string{"X-Error-Kind": "quota_exceeded"})
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/foo", map[string]string{"X-Error-Kind": "region_blocked"})
+	assert.Equal(t, http.StatusNotFound, status) // unknown kind, not routed
+}
+
+func TestRouting_Fragment(t *testing.T) {
+	var (
+		srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+		cfg = config.New()
+	)
+
+	cfg.RespondWithSameHTTPCode = true
+
+	require.NoError(t, srv.Register(&cfg))
+
+	var baseUrl, stopServer = startServer(t, &srv)
+
+	defer stopServer()
+
+	status, body, headers := sendRequest(t, http.MethodGet, baseUrl+"/fragment/404")
+	assert.Equal(t, http.StatusOK, status) // the ESI processors do not include the non-200 responses
+	assert.Contains(t, string(body), `data-code="404"`)
+	assert.NotContains(t, string(body), "<html")
+	assert.Equal(t, "max-age=60", headers.Get("Surrogate-Control"))
+
+	status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/fragment/foo")
+	assert.Equal(t, http.StatusNotFound, status) // not routed
+}
+
+func TestRouting_Metrics(t *testing.T) {
+	for name, enabled := range map[string]bool{"enabled": true, "disabled": false} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+				cfg = config.New()
+			)
+
+			cfg.EnableMetrics = enabled
+
+			if enabled && slimBuild {
+				require.Error(t, srv.Register(&cfg)) // not available in the slim build
+
+				return
+			}
+
+			require.NoError(t, srv.Register(&cfg))
+
+			var baseUrl, stopServer = startServer(t, &srv)
+
+			defer stopServer()
+
+			for range 2 {
+				status, _, _ := sendRequest(t, http.MethodGet, baseUrl+"/404")
+				require.Equal(t, http.StatusOK, status)
+			}
+
+			status, body, headers := sendRequest(t, http.MethodGet, baseUrl+"/metrics")
+
+			if !enabled {
+				assert.Equal(t, http.StatusNotFound, status)
+
+				return
+			}
+
+			assert.Equal(t, http.StatusOK, status)
+			assert.Contains(t, headers.Get("Content-Type"), "text/plain")
+			assert.Contains(t, string(body), `error_pages_responses_total{code="404",format="text"} 2`)
+			assert.Contains(t, string(body), `error_pages_cache_requests_total{tenant="",result="hit"} 1`)
+			assert.Contains(t, string(body), `error_pages_cache_requests_total{tenant="",result="miss"} 1`)
+			assert.Contains(t, string(body), "error_pages_render_duration_seconds_count 1\n")
+		})
+	}
+}
+
+// TestProxyContracts verifies the reverse proxy integrations conventions (the same checks the `verify-proxy` command
+// runs) against the server with different configurations.
+func TestProxyContracts(t *testing.T) {
+	for name, tt := range map[string]struct {
+		giveConfig   func(*config.Config)
+		wantFailures []string // "integration: check"
+	}{
+		"default": {
+			giveConfig: func(*config.Config) {},
+			wantFailures: []string{
+				"ingress-nginx: same HTTP status code",
+				"ingress-nginx: request ID from the X-Request-ID header",
+			},
+		},
+		"ingress-nginx ready": {
+			giveConfig: func(cfg *config.Config) {
+				cfg.RespondWithSameHTTPCode = true
+				cfg.ShowDetails = true
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+				cfg = config.New()
+			)
+
+			tt.giveConfig(&cfg)
+
+			require.NoError(t, srv.Register(&cfg))
+
+			var baseUrl, stopServer = startServer(t, &srv)
+
+			defer stopServer()
+
+			results, err := proxycheck.Verify(context.Background(), http.DefaultClient, baseUrl, proxycheck.Integrations())
+			require.NoError(t, err)
+
+			var failures []string
+
+			for _, result := range results {
+				if !result.Passed() {
+					failures = append(failures, result.Integration+": "+result.Check)
+				}
+			}
+
+			assert.Equal(t, tt.wantFailures, failures)
+		})
+	}
+}
+
+func TestRouting_Check(t *testing.T) {
+	var origin = stdHttptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	defer origin.Close()
+
+	for name, probe := range map[string]bool{"enabled": true, "disabled": false} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+				cfg = config.New()
+			)
+
+			if probe {
+				cfg.UpstreamHealth.URL = origin.URL
+			}
+
+			require.NoError(t, srv.Register(&cfg))
+
+			var baseUrl, stopServer = startServer(t, &srv)
+
+			defer stopServer()
+
+			if probe {
+				require.Eventually(t, func() bool {
+					status, _, _ := sendRequest(t, http.MethodGet, baseUrl+"/check")
+
+					return status == http.StatusNoContent
+				}, time.Second, 10*time.Millisecond)
+			} else {
+				status, _, _ := sendRequest(t, http.MethodGet, baseUrl+"/check")
+
+				assert.Equal(t, http.StatusNotFound, status)
+			}
+		})
+	}
+}
+
+func TestRouting_Publish(t *testing.T) {
+	if slimBuild {
+		t.Skip("the publisher is not available in the slim build")
+	}
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+
+	var bucket = stdHttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			mu.Lock()
+			keys = append(keys, r.URL.Path)
+			mu.Unlock()
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound) // nothing is published yet
+	}))
+
+	defer bucket.Close()
+
+	var cfg = config.New()
+
+	cfg.Publish.Bucket, cfg.Publish.Endpoint, cfg.Publish.Prefix = "pages", bucket.URL, "errors"
+	cfg.Codes = config.Codes{"404": cfg.Codes["404"], "503": cfg.Codes["503"]} // fewer pages to render
+
+	t.Run("missing credentials", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+
+		var srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+
+		assert.ErrorContains(t, srv.Register(&cfg), "missing AWS credentials")
+	})
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	var srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+
+	require.NoError(t, srv.Register(&cfg))
+
+	var _, stopServer = startServer(t, &srv)
+
+	defer stopServer()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return slices.Contains(keys, "/pages/errors/SHA256SUMS")
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Contains(t, keys, "/pages/errors/404.html")
+	assert.Contains(t, keys, "/pages/errors/error.html")
+}
+
+func TestServer_Reload(t *testing.T) {
+	var (
+		srv = appHttp.NewServer(logger.NewNop(), 1025*5)
+		cfg = config.New()
+	)
+
+	cfg.Formats.PlainText = "before: {{ code }}"
+
+	require.NoError(t, srv.Register(&cfg))
+
+	var baseUrl, stopServer = startServer(t, &srv)
+
+	defer stopServer()
+
+	var render = func() string {
+		status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/404")
+		require.Equal(t, http.StatusOK, status)
+
+		return string(body)
+	}
+
+	assert.Equal(t, "before: 404", render())
+
+	t.Run("applied", func(t *testing.T) {
+		var fresh = config.New()
+
+		fresh.Formats.PlainText = "after: {{ code }}"
+
+		require.NoError(t, srv.Reload(context.Background(), &fresh))
+
+		assert.Equal(t, "after: 404", render()) // the cache of the previous configuration is not used
+	})
+
+	t.Run("wrong configuration is not applied", func(t *testing.T) {
+		var fresh = config.New()
+
+		fresh.Formats.PlainText = "wrong: {{ code }}"
+		fresh.StaticDir = filepath.Join(t.TempDir(), "missing")
+
+		require.Error(t, srv.Reload(context.Background(), &fresh))
+
+		assert.Equal(t, "after: 404", render())
+	})
+
+	t.Run("runtime state is kept", func(t *testing.T) {
+		var fresh = config.New()
+
+		fresh.EnableAPI, fresh.EnableMetrics = true, !slimBuild
+
+		require.NoError(t, srv.Reload(context.Background(), &fresh))
+
+		req, err := http.NewRequest(http.MethodPut, baseUrl+"/api/banner", strings.NewReader(`{"message": "Outage"}`))
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		render()
+
+		fresh = config.New()
+		fresh.EnableAPI, fresh.EnableMetrics = true, !slimBuild
+
+		require.NoError(t, srv.Reload(context.Background(), &fresh))
+
+		render()
+
+		_, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/banner")
+		assert.Contains(t, string(body), `"message":"Outage"`)
+
+		if slimBuild {
+			return // no metrics in the slim build
+		}
+
+		_, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/metrics")
+		assert.Contains(t, string(body), `error_pages_responses_total{code="404",format="text"} 5`) // all the renders
+	})
+}
+
 // sendRequest is a helper function to send an HTTP request and return its status code, body, and headers.
 func sendRequest(t *testing.T, method, url string, headers ...map[string]string) (
 	status int,
@@ -362,7 +824,13 @@ func startServer(t *testing.T, srv *appHttp.Server) (_ string, stop func()) {
 		<-time.After(5 * time.Millisecond)
 	}
 
-	return fmt.Sprintf("http://%s", hostPort), func() { assert.NoError(t, srv.Stop(350*time.Millisecond)) }
+	return fmt.Sprintf("http://%s", hostPort), func() {
+		// the kept-alive client connections that have not sent a request yet are never considered idle by the server,
+		// so they are closed first to not wait for them until the timeout
+		http.DefaultClient.CloseIdleConnections()
+
+		assert.NoError(t, srv.Stop(350*time.Millisecond))
+	}
 }
 
 // getFreeTcpPort is a helper function to get a free TCP port number.
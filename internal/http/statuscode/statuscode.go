@@ -0,0 +1,141 @@
+// Package statuscode parses the HTTP status codes of the error pages from the request paths (like `/404.html`) and
+// the `X-Code` header values, so the same request is always resolved to the same code.
+//
+// The proxies are not consistent in what they pass to the error pages: the path may be percent-encoded (like
+// `/%34%30%34`) or carry the path parameters (like `/404;jsessionid=...`), and the header value may be padded with
+// the whitespace. By default, these variations are normalized; in the strict mode, only the canonical forms are
+// accepted.
+package statuscode
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Header is the request header with the status code, set by the ingress-nginx
+// (https://kubernetes.github.io/ingress-nginx/user-guide/custom-errors/).
+const Header = "X-Code"
+
+// Parser parses the status codes. The zero value is the lenient parser.
+type Parser struct {
+	// Strict accepts only the canonical forms: the path must be `/NNN`, `/NNN.html`, or `/NNN.htm`, and the header
+	// value must be `NNN`, where `NNN` is the status code in the 100..599 range (RFC 9110, section 15).
+	//
+	// Otherwise, the path is percent-decoded, its query and parameters (after the `;`) are ignored, the extension
+	// case is ignored, the header value is trimmed, and any code in the 1..998 range is accepted.
+	Strict bool
+}
+
+// FromPath returns the status code from the request path (the path must consist of the code file name only).
+func (p Parser) FromPath(path string) (uint16, bool) {
+	if p.Strict {
+		return strictFromPath(path)
+	}
+
+	return lenientFromPath(path)
+}
+
+// FromHeader returns the status code from the header value.
+func (p Parser) FromHeader(value []byte) (uint16, bool) {
+	if p.Strict {
+		return strictCode(string(value))
+	}
+
+	if value = trimOWS(value); len(value) == 0 || len(value) > 3 { //nolint:mnd
+		return 0, false
+	}
+
+	return lenientCode(string(value))
+}
+
+// lenientFromPath extracts the code from the path, normalizing the variations of the proxies.
+func lenientFromPath(path string) (uint16, bool) {
+	path, _, _ = strings.Cut(path, "?") // the query is not a part of the path
+
+	if strings.ContainsRune(path, '%') {
+		var decoded, err = url.PathUnescape(path)
+		if err != nil {
+			return 0, false
+		}
+
+		path = decoded
+	}
+
+	var name = strings.ToLower(strings.TrimLeft(path, "/"))
+
+	name, _, _ = strings.Cut(name, ";") // the path parameters, like `;jsessionid=...`
+
+	if strings.ContainsRune(name, '/') {
+		return 0, false // the code must be the only path segment
+	}
+
+	if base, ext, found := strings.Cut(name, "."); found {
+		if ext != "html" && ext != "htm" {
+			return 0, false
+		}
+
+		name = base
+	}
+
+	return lenientCode(name)
+}
+
+// strictFromPath extracts the code from the canonical path only.
+func strictFromPath(path string) (uint16, bool) {
+	var name, ok = strings.CutPrefix(path, "/")
+	if !ok {
+		return 0, false
+	}
+
+	if base, ext, found := strings.Cut(name, "."); found {
+		if ext != "html" && ext != "htm" {
+			return 0, false
+		}
+
+		name = base
+	}
+
+	return strictCode(name)
+}
+
+// lenientCode parses the decimal code in the 1..998 range.
+func lenientCode(s string) (uint16, bool) {
+	if code, err := strconv.ParseUint(s, 10, 16); err == nil && code > 0 && code < 999 {
+		return uint16(code), true
+	}
+
+	return 0, false
+}
+
+// strictCode parses the three-digit code in the 100..599 range (no signs, spaces, or leading zeros).
+func strictCode(s string) (uint16, bool) {
+	if len(s) != 3 || s[0] < '1' || s[0] > '5' { //nolint:mnd
+		return 0, false
+	}
+
+	var code uint16
+
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+
+		code = code*10 + uint16(s[i]-'0') //nolint:mnd
+	}
+
+	return code, true
+}
+
+// trimOWS trims the optional whitespace (spaces and tabs) around the header value.
+func trimOWS(b []byte) []byte {
+	for len(b) > 0 && (b[0] == ' ' || b[0] == '\t') {
+		b = b[1:]
+	}
+
+	for len(b) > 0 && (b[len(b)-1] == ' ' || b[len(b)-1] == '\t') {
+		b = b[:len(b)-1]
+	}
+
+	return b
+}
@@ -0,0 +1,145 @@
+package statuscode_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/http/statuscode"
+)
+
+func TestParser_FromPath(t *testing.T) {
+	t.Parallel()
+
+	for givePath, tt := range map[string]struct {
+		wantLenient, wantStrict uint16
+	}{
+		"/404":                   {wantLenient: 404, wantStrict: 404},
+		"/404.htm":               {wantLenient: 404, wantStrict: 404},
+		"/404.html":              {wantLenient: 404, wantStrict: 404},
+		"/404.HTM":               {wantLenient: 404},
+		"/404.HtmL":              {wantLenient: 404},
+		"/404;jsessionid=abc":    {wantLenient: 404},
+		"/404.html;jsessionid=a": {wantLenient: 404},
+		"/%34%30%34":             {wantLenient: 404},
+		"/%34%30%34.html":        {wantLenient: 404},
+		"/404?foo=bar":           {wantLenient: 404},
+		"//404":                  {wantLenient: 404},
+		"404":                    {wantLenient: 404},
+		"/0404":                  {wantLenient: 404},
+		"/42":                    {wantLenient: 42},
+		"/600":                   {wantLenient: 600},
+		"/998":                   {wantLenient: 998},
+		"/999":                   {},
+		"/0":                     {},
+		"/+404":                  {},
+		"/-404":                  {},
+		"/404.css":               {},
+		"/404.html.css":          {},
+		"/404.":                  {},
+		"/foo/404":               {},
+		"/foo/404.html":          {},
+		"/404/":                  {},
+		"/%34%30%34%2Ffoo":       {},
+		"/%zz":                   {},
+		"/error":                 {},
+		"/":                      {},
+		"/////":                  {},
+		"///404//":               {},
+		"":                       {},
+	} {
+		t.Run(givePath, func(t *testing.T) {
+			t.Parallel()
+
+			var code, ok = statuscode.Parser{}.FromPath(givePath)
+
+			assert.Equal(t, tt.wantLenient, code)
+			assert.Equal(t, tt.wantLenient != 0, ok)
+
+			code, ok = statuscode.Parser{Strict: true}.FromPath(givePath)
+
+			assert.Equal(t, tt.wantStrict, code)
+			assert.Equal(t, tt.wantStrict != 0, ok)
+		})
+	}
+}
+
+func TestParser_FromHeader(t *testing.T) {
+	t.Parallel()
+
+	for giveValue, tt := range map[string]struct {
+		wantLenient, wantStrict uint16
+	}{
+		"404":   {wantLenient: 404, wantStrict: 404},
+		"100":   {wantLenient: 100, wantStrict: 100},
+		"599":   {wantLenient: 599, wantStrict: 599},
+		" 404 ": {wantLenient: 404},
+		"\t404": {wantLenient: 404},
+		"42":    {wantLenient: 42},
+		"042":   {wantLenient: 42},
+		"600":   {wantLenient: 600},
+		"999":   {},
+		"1000":  {},
+		"0":     {},
+		"-1":    {},
+		"+40":   {},
+		"foo":   {},
+		"4 04":  {},
+		"":      {},
+	} {
+		t.Run(giveValue, func(t *testing.T) {
+			t.Parallel()
+
+			var code, ok = statuscode.Parser{}.FromHeader([]byte(giveValue))
+
+			assert.Equal(t, tt.wantLenient, code)
+			assert.Equal(t, tt.wantLenient != 0, ok)
+
+			code, ok = statuscode.Parser{Strict: true}.FromHeader([]byte(giveValue))
+
+			assert.Equal(t, tt.wantStrict, code)
+			assert.Equal(t, tt.wantStrict != 0, ok)
+		})
+	}
+}
+
+// the strict mode accepts a subset of the lenient one (with the same codes), and the codes are always in range
+func FuzzParser_FromPath(f *testing.F) {
+	for _, seed := range []string{
+		"/404", "/404.html", "/404;jsessionid=abc", "/%34%30%34", "///404//", "/foo/404", "/%zz", "",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		lenient, okLenient := statuscode.Parser{}.FromPath(path)
+		strict, okStrict := statuscode.Parser{Strict: true}.FromPath(path)
+
+		if okLenient && (lenient < 1 || lenient > 998) {
+			t.Fatalf("lenient code %d out of range for %q", lenient, path)
+		}
+
+		if okStrict && (strict < 100 || strict > 599 || !okLenient || strict != lenient) {
+			t.Fatalf("strict code %d (lenient %d, %t) mismatch for %q", strict, lenient, okLenient, path)
+		}
+	})
+}
+
+func FuzzParser_FromHeader(f *testing.F) {
+	for _, seed := range []string{"404", " 404 ", "042", "1000", "-1", "foo", ""} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, value []byte) {
+		lenient, okLenient := statuscode.Parser{}.FromHeader(value)
+		strict, okStrict := statuscode.Parser{Strict: true}.FromHeader(value)
+
+		if okLenient && (lenient < 1 || lenient > 998) {
+			t.Fatalf("lenient code %d out of range for %q", lenient, value)
+		}
+
+		if okStrict && (strict < 100 || strict > 599 || !okLenient || strict != lenient) {
+			t.Fatalf("strict code %d (lenient %d, %t) mismatch for %q", strict, lenient, okLenient, value)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+// Package lifecycle manages the background goroutines (like the rendered pages cache janitor, the upstream health
+// prober, or the pages publisher), so they are started in order, stopped in the reverse order on shutdown, and their
+// failures (errors and panics) are reported to the logger instead of being lost.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+// Manager runs the named services in the background goroutines. It's safe for concurrent use.
+type Manager struct {
+	log *logger.Logger
+
+	mu       sync.Mutex
+	services []*service
+	stopped  bool
+}
+
+type service struct {
+	name    string
+	cancel  context.CancelFunc
+	done    chan struct{}
+	started time.Time
+}
+
+// New creates a new lifecycle manager. The logger may be nil.
+func New(log *logger.Logger) *Manager { return &Manager{log: log} }
+
+// Go starts the named service in a new goroutine. The service must return once the context is canceled; the
+// returned error (except the context cancellation) and panics are logged. The returned function stops the service
+// and waits until it returns (it's safe to call it multiple times, or after the manager is stopped).
+//
+// The services started after the manager is stopped are not run at all.
+func (m *Manager) Go(name string, run func(ctx context.Context) error) (stop func()) {
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	var s = &service{name: name, cancel: cancel, done: make(chan struct{}), started: time.Now()}
+
+	stop = func() { s.cancel(); <-s.done }
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopped {
+		cancel()
+		close(s.done)
+
+		return stop
+	}
+
+	m.services = append(m.services, s)
+
+	go m.run(ctx, s, run)
+
+	return stop
+}
+
+// run runs the service, reporting its failure.
+func (m *Manager) run(ctx context.Context, s *service, run func(context.Context) error) {
+	defer close(s.done)
+
+	defer func() {
+		if r := recover(); r != nil {
+			m.failed(s, fmt.Errorf("panic: %v", r), logger.String("stack", string(debug.Stack())))
+		}
+	}()
+
+	if err := run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		m.failed(s, err)
+	}
+}
+
+// failed logs the service failure.
+func (m *Manager) failed(s *service, err error, attrs ...logger.Attr) {
+	if m.log == nil {
+		return
+	}
+
+	m.log.Error("Background service failed", append([]logger.Attr{
+		logger.String("service", s.name),
+		logger.Duration("uptime", time.Since(s.started).Round(time.Millisecond)),
+		logger.Error(err),
+	}, attrs...)...)
+}
+
+// Stop stops all the services in the reverse order (the last started is stopped first), waiting for each one to
+// return. An error is returned if some services are not stopped until the context is done.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	var services = slices.Clone(m.services)
+	m.stopped, m.services = true, nil
+	m.mu.Unlock()
+
+	var pending []string
+
+	for _, s := range slices.Backward(services) {
+		s.cancel()
+
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			pending = append(pending, s.name)
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("background services are not stopped in time: %v", pending)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package lifecycle_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/lifecycle"
+	"github.com/binaryYuki/error-pages/internal/logger"
+)
+
+func TestManager_StopOrder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m       = lifecycle.New(nil)
+		mu      sync.Mutex
+		stopped []string
+	)
+
+	for _, name := range []string{"first", "second", "third"} {
+		m.Go(name, func(ctx context.Context) error {
+			<-ctx.Done()
+
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+
+			return ctx.Err()
+		})
+	}
+
+	require.NoError(t, m.Stop(context.Background()))
+	assert.Equal(t, []string{"third", "second", "first"}, stopped)
+
+	// the services started after the manager is stopped are not run
+	var ran bool
+
+	m.Go("late", func(context.Context) error { ran = true; return nil }) //nolint:nlreturn
+	assert.False(t, ran)
+}
+
+func TestManager_StopOne(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m    = lifecycle.New(nil)
+		done = make(chan struct{})
+	)
+
+	var stop = m.Go("service", func(ctx context.Context) error {
+		defer close(done)
+
+		<-ctx.Done()
+
+		return nil
+	})
+
+	stop()
+
+	select {
+	case <-done:
+	default:
+		t.Fatal("the service is not stopped")
+	}
+
+	stop() // safe to call twice
+
+	require.NoError(t, m.Stop(context.Background()))
+}
+
+func TestManager_Failures(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	log, logErr := logger.New(logger.InfoLevel, logger.JSONFormat, &buf)
+	require.NoError(t, logErr)
+
+	var m = lifecycle.New(log)
+
+	m.Go("failing", func(context.Context) error { return errors.New("boom") })()
+	m.Go("panicking", func(context.Context) error { panic("oops") })()
+	m.Go("canceled", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() })() //nolint:nlreturn
+
+	require.NoError(t, m.Stop(context.Background()))
+
+	var out = buf.String()
+
+	assert.Contains(t, out, `"service":"failing"`)
+	assert.Contains(t, out, `"error":"boom"`)
+	assert.Contains(t, out, `"service":"panicking"`)
+	assert.Contains(t, out, `"error":"panic: oops"`)
+	assert.NotContains(t, out, `"service":"canceled"`)
+}
+
+func TestManager_StopTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m       = lifecycle.New(nil)
+		release = make(chan struct{})
+	)
+
+	t.Cleanup(func() { close(release) })
+
+	m.Go("stuck", func(context.Context) error { <-release; return nil }) //nolint:nlreturn
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var err = m.Stop(ctx)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stuck")
+}
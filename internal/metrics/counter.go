@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing counter with optional labels. It's safe for concurrent use. The nil
+// counter is a no-op.
+type Counter struct {
+	name, help string
+	labels     []string
+
+	mu     sync.RWMutex
+	values map[string]*counterValue // map[joined_label_values]value
+}
+
+type counterValue struct {
+	labels []string
+	n      atomic.Uint64
+}
+
+func newCounter(name, help string, labels []string) *Counter {
+	return &Counter{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+}
+
+// Inc increments the counter for the given label values by 1.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add increments the counter for the given label values by delta. The number of label values must match the
+// number of the counter labels (missing values are treated as empty strings, extra values are ignored).
+func (c *Counter) Add(delta uint64, labelValues ...string) {
+	if c == nil {
+		return
+	}
+
+	c.value(labelValues).n.Add(delta)
+}
+
+// Value returns the current counter value for the given label values.
+func (c *Counter) Value(labelValues ...string) uint64 {
+	if c == nil {
+		return 0
+	}
+
+	var key = labelsKey(c.labels, labelValues)
+
+	c.mu.RLock()
+	v, ok := c.values[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return 0
+	}
+
+	return v.n.Load()
+}
+
+func (c *Counter) value(labelValues []string) *counterValue {
+	var key = labelsKey(c.labels, labelValues)
+
+	c.mu.RLock()
+	v, ok := c.values[key]
+	c.mu.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok = c.values[key]; !ok {
+		v = &counterValue{labels: strings.Split(key, keySeparator)}
+		c.values[key] = v
+	}
+
+	return v
+}
+
+func (c *Counter) describe() (string, string, string) { return c.name, c.help, "counter" }
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.RLock()
+	var keys = make([]string, 0, len(c.values))
+
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		var v = c.values[key]
+
+		_, _ = fmt.Fprintf(w, "%s%s %d\n", c.name, labelsString(c.labels, v.labels), v.n.Load())
+	}
+	c.mu.RUnlock()
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Gauge is a metric that can go up and down, with optional labels. It's safe for concurrent use. The nil gauge is
+// a no-op.
+type Gauge struct {
+	name, help string
+	labels     []string
+
+	mu     sync.RWMutex
+	values map[string]*gaugeValue // map[joined_label_values]value
+}
+
+type gaugeValue struct {
+	labels []string
+	bits   atomic.Uint64 // math.Float64bits of the value
+}
+
+func newGauge(name, help string, labels []string) *Gauge {
+	return &Gauge{name: name, help: help, labels: labels, values: make(map[string]*gaugeValue)}
+}
+
+// Set sets the gauge value for the given label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	if g == nil {
+		return
+	}
+
+	g.value(labelValues).bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current gauge value for the given label values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	if g == nil {
+		return 0
+	}
+
+	var key = labelsKey(g.labels, labelValues)
+
+	g.mu.RLock()
+	v, ok := g.values[key]
+	g.mu.RUnlock()
+
+	if !ok {
+		return 0
+	}
+
+	return math.Float64frombits(v.bits.Load())
+}
+
+func (g *Gauge) value(labelValues []string) *gaugeValue {
+	var key = labelsKey(g.labels, labelValues)
+
+	g.mu.RLock()
+	v, ok := g.values[key]
+	g.mu.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if v, ok = g.values[key]; !ok {
+		v = &gaugeValue{labels: strings.Split(key, keySeparator)}
+		g.values[key] = v
+	}
+
+	return v
+}
+
+func (g *Gauge) describe() (string, string, string) { return g.name, g.help, "gauge" }
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.RLock()
+	var keys = make([]string, 0, len(g.values))
+
+	for key := range g.values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		var v = g.values[key]
+
+		_, _ = fmt.Fprintf(w, "%s%s %s\n",
+			g.name, labelsString(g.labels, v.labels), strconv.FormatFloat(math.Float64frombits(v.bits.Load()), 'g', -1, 64),
+		)
+	}
+	g.mu.RUnlock()
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Histogram counts the observed values in the configurable buckets (and tracks their sum and count), with
+// optional labels. It's safe for concurrent use. The nil histogram is a no-op.
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // the sorted upper bounds (the +Inf bucket is implicit)
+
+	mu     sync.RWMutex
+	values map[string]*histogramValue // map[joined_label_values]value
+}
+
+type histogramValue struct {
+	labels  []string
+	buckets []atomic.Uint64 // the non-cumulative counts (the cumulative ones are calculated on write)
+	count   atomic.Uint64
+	sumBits atomic.Uint64 // math.Float64bits of the sum
+}
+
+func newHistogram(name, help string, buckets []float64, labels []string) *Histogram {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: slices.Compact(buckets),
+		values:  make(map[string]*histogramValue),
+	}
+}
+
+// Observe adds the value to the histogram for the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+
+	var hv = h.value(labelValues)
+
+	hv.count.Add(1) // before the bucket, so the written count is never less than the cumulative bucket counts
+
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) { // the values above the last bound are +Inf
+		hv.buckets[i].Add(1)
+	}
+
+	for {
+		var old = hv.sumBits.Load()
+
+		if hv.sumBits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Count returns the number of the observed values for the given label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	if h == nil {
+		return 0
+	}
+
+	var key = labelsKey(h.labels, labelValues)
+
+	h.mu.RLock()
+	v, ok := h.values[key]
+	h.mu.RUnlock()
+
+	if !ok {
+		return 0
+	}
+
+	return v.count.Load()
+}
+
+func (h *Histogram) value(labelValues []string) *histogramValue {
+	var key = labelsKey(h.labels, labelValues)
+
+	h.mu.RLock()
+	v, ok := h.values[key]
+	h.mu.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if v, ok = h.values[key]; !ok {
+		v = &histogramValue{labels: strings.Split(key, keySeparator), buckets: make([]atomic.Uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+
+	return v
+}
+
+func (h *Histogram) describe() (string, string, string) { return h.name, h.help, "histogram" }
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.RLock()
+	var keys = make([]string, 0, len(h.values))
+
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var (
+		names  = append(slices.Clone(h.labels), "le")
+		values = make([]string, len(names))
+	)
+
+	for _, key := range keys {
+		var (
+			v          = h.values[key]
+			cumulative uint64
+		)
+
+		copy(values, v.labels)
+
+		for i, bound := range h.buckets {
+			cumulative += v.buckets[i].Load()
+			values[len(values)-1] = strconv.FormatFloat(bound, 'g', -1, 64)
+
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelsString(names, values), cumulative)
+		}
+
+		var count = v.count.Load()
+
+		values[len(values)-1] = "+Inf"
+
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelsString(names, values), count)
+
+		var (
+			labels = labelsString(h.labels, v.labels)
+			sum    = strconv.FormatFloat(math.Float64frombits(v.sumBits.Load()), 'g', -1, 64)
+		)
+
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", h.name, labels, sum, h.name, labels, count)
+	}
+	h.mu.RUnlock()
+}
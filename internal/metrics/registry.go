@@ -0,0 +1,192 @@
+// Package metrics provides a tiny, dependency-free metrics registry with the Prometheus text exposition format
+// support.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// collector is a metric that can be written in the Prometheus text exposition format.
+type collector interface {
+	describe() (name, help, kind string)
+	write(w *bufio.Writer)
+}
+
+// Registry holds the registered metrics. It's safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]collector // map[name]metric
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry { return &Registry{metrics: make(map[string]collector)} }
+
+// Counter returns the counter with the given name, creating it if needed. The same counter is returned for the
+// same name, so it's safe to call this method multiple times. The nil registry returns an unregistered counter.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	if r == nil {
+		return newCounter(name, help, labels)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name].(*Counter); ok {
+		return existing
+	}
+
+	var c = newCounter(name, help, labels)
+
+	r.metrics[name] = c
+
+	return c
+}
+
+// Gauge returns the gauge with the given name, creating it if needed. The same gauge is returned for the same
+// name. The nil registry returns an unregistered gauge.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	if r == nil {
+		return newGauge(name, help, labels)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name].(*Gauge); ok {
+		return existing
+	}
+
+	var g = newGauge(name, help, labels)
+
+	r.metrics[name] = g
+
+	return g
+}
+
+// Histogram returns the histogram with the given name and buckets (the upper bounds, the +Inf bucket is added
+// automatically), creating it if needed. The same histogram is returned for the same name (the buckets are ignored
+// then). The nil registry returns an unregistered histogram.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if r == nil {
+		return newHistogram(name, help, buckets, labels)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name].(*Histogram); ok {
+		return existing
+	}
+
+	var h = newHistogram(name, help, buckets, labels)
+
+	r.metrics[name] = h
+
+	return h
+}
+
+// WriteTo writes all the registered metrics in the Prometheus text exposition format (sorted by name).
+func (r *Registry) WriteTo(out io.Writer) (int64, error) {
+	if r == nil {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	var names = make([]string, 0, len(r.metrics))
+
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+
+	var list = make([]collector, 0, len(names))
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		list = append(list, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	var (
+		cw = &countingWriter{w: out}
+		w  = bufio.NewWriter(cw)
+	)
+
+	for _, m := range list {
+		name, help, kind := m.describe()
+
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+
+		m.write(w)
+	}
+
+	err := w.Flush()
+
+	return cw.n, err
+}
+
+// keySeparator joins the label values into the map key (the byte is invalid in UTF-8, so it never appears in
+// the label values).
+const keySeparator = "\xff"
+
+// labelsKey normalizes the label values (to the number of the label names) and joins them into the map key.
+func labelsKey(names, values []string) string {
+	var normalized = make([]string, len(names))
+
+	copy(normalized, values)
+
+	return strings.Join(normalized, keySeparator)
+}
+
+// labelsString formats the label pairs (like `{code="404",format="json"}`). An empty string is returned for no
+// labels.
+func labelsString(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	b.WriteByte('{')
+
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`) //nolint:gochecknoglobals
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)            //nolint:gochecknoglobals
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// countingWriter counts the number of written bytes.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,170 @@
+package metrics_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/metrics"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	t.Parallel()
+
+	var reg = metrics.NewRegistry()
+
+	var requests = reg.Counter("requests_total", "Total requests\nserved", "code", "format")
+
+	requests.Inc("404", "json")
+	requests.Add(2, "404", "json")
+	requests.Inc("500", `x"ml\`)
+
+	reg.Counter("a_total", "Without labels").Inc()
+
+	assert.Same(t, requests, reg.Counter("requests_total", "ignored"))
+
+	var buf strings.Builder
+
+	n, err := reg.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	assert.Equal(t, `# HELP a_total Without labels
+# TYPE a_total counter
+a_total 1
+# HELP requests_total Total requests\nserved
+# TYPE requests_total counter
+requests_total{code="404",format="json"} 3
+requests_total{code="500",format="x\"ml\\"} 1
+`, buf.String())
+}
+
+func TestCounter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			c  = metrics.NewRegistry().Counter("foo", "", "variant")
+			wg sync.WaitGroup
+		)
+
+		for range 10 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for range 100 {
+					c.Inc("a")
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		assert.Equal(t, uint64(1000), c.Value("a"))
+		assert.Equal(t, uint64(0), c.Value("b"))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		var c *metrics.Counter
+
+		c.Inc("a")
+		assert.Equal(t, uint64(0), c.Value("a"))
+
+		var reg *metrics.Registry
+
+		reg.Counter("foo", "").Inc() // unregistered counter
+
+		n, err := reg.WriteTo(&strings.Builder{})
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestGauge(t *testing.T) {
+	t.Parallel()
+
+	var (
+		reg   = metrics.NewRegistry()
+		gauge = reg.Gauge("active", "Active template", "template")
+	)
+
+	gauge.Set(1, "foo")
+	gauge.Set(1, "bar")
+	gauge.Set(0, "foo")
+	gauge.Set(0.5) // missing label value
+
+	assert.Same(t, gauge, reg.Gauge("active", "ignored"))
+	assert.InDelta(t, 1, gauge.Value("bar"), 0)
+	assert.InDelta(t, 0, gauge.Value("baz"), 0)
+
+	var buf strings.Builder
+
+	_, err := reg.WriteTo(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# HELP active Active template
+# TYPE active gauge
+active{template=""} 0.5
+active{template="bar"} 1
+active{template="foo"} 0
+`, buf.String())
+
+	var nilGauge *metrics.Gauge
+
+	nilGauge.Set(1)
+	assert.InDelta(t, 0, nilGauge.Value(), 0)
+}
+
+func TestHistogram(t *testing.T) {
+	t.Parallel()
+
+	var (
+		reg       = metrics.NewRegistry()
+		histogram = reg.Histogram("render_seconds", "Render latency", []float64{1, 0.1, 0.5}, "format")
+	)
+
+	histogram.Observe(0.05, "html")
+	histogram.Observe(0.1, "html") // the bound is inclusive
+	histogram.Observe(0.7, "html")
+	histogram.Observe(3, "html")
+	histogram.Observe(0.2, "json")
+
+	assert.Same(t, histogram, reg.Histogram("render_seconds", "ignored", nil))
+	assert.Equal(t, uint64(4), histogram.Count("html"))
+	assert.Equal(t, uint64(0), histogram.Count("xml"))
+
+	var buf strings.Builder
+
+	_, err := reg.WriteTo(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# HELP render_seconds Render latency
+# TYPE render_seconds histogram
+render_seconds_bucket{format="html",le="0.1"} 2
+render_seconds_bucket{format="html",le="0.5"} 2
+render_seconds_bucket{format="html",le="1"} 3
+render_seconds_bucket{format="html",le="+Inf"} 4
+render_seconds_sum{format="html"} 3.85
+render_seconds_count{format="html"} 4
+render_seconds_bucket{format="json",le="0.1"} 0
+render_seconds_bucket{format="json",le="0.5"} 1
+render_seconds_bucket{format="json",le="1"} 1
+render_seconds_bucket{format="json",le="+Inf"} 1
+render_seconds_sum{format="json"} 0.2
+render_seconds_count{format="json"} 1
+`, buf.String())
+
+	var nilHistogram *metrics.Histogram
+
+	nilHistogram.Observe(1)
+	assert.Zero(t, nilHistogram.Count())
+}
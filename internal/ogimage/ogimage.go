@@ -0,0 +1,185 @@
+// Package ogimage draws the social preview images of the error pages (referenced by the Open Graph `og:image` meta
+// tags) - the status code and message on the themed background, using the built-in bitmap font.
+package ogimage
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Width and Height are the image dimensions (the size recommended for the Open Graph images).
+const Width, Height = 1200, 630
+
+const (
+	codeScale, messageScale = 24, 7 // the size of the font pixel (in the image pixels)
+	margin                  = 100   // the minimal horizontal margin of the message
+	gap                     = 72    // the space between the code and the message
+	maxMessageLines         = 2
+)
+
+// Render draws the image with the HTTP code and the status message in the given colors and writes it in PNG format.
+// The message is uppercased, and it's omitted if it has the characters the font can't draw (like the CJK ones).
+func Render(w io.Writer, code uint16, message string, text, background color.RGBA) error {
+	var (
+		accent = blend(text, background, 0.35) //nolint:mnd
+		img    = image.NewPaletted(image.Rect(0, 0, Width, Height), color.Palette{background, text, accent})
+		lines  []string
+	)
+
+	if m, ok := printable(message); ok {
+		lines = wrap(m, (Width-2*margin+messageScale)/(messageScale*(glyphWidth+1)), maxMessageLines)
+	}
+
+	var (
+		lineHeight = messageScale * (glyphHeight + 3) // with the line spacing
+		height     = codeScale * glyphHeight
+	)
+
+	if len(lines) > 0 {
+		height += gap + len(lines)*lineHeight - 3*messageScale
+	}
+
+	var top = (Height - height) / 2
+
+	drawText(img, strconv.FormatUint(uint64(code), 10), codeScale, top, 1)
+
+	if top += codeScale * glyphHeight; len(lines) > 0 {
+		fill(img, image.Rect(Width/2-60, top+gap/2-4, Width/2+60, top+gap/2+4), 2) //nolint:mnd // the divider
+
+		top += gap
+
+		for _, line := range lines {
+			drawText(img, line, messageScale, top, 1)
+
+			top += lineHeight
+		}
+	}
+
+	fill(img, image.Rect(0, Height-12, Width, Height), 2) //nolint:mnd // the accent stripe at the bottom
+
+	return (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(w, img)
+}
+
+// printable converts the text to the form the font can draw (uppercased, without the diacritics, and with the
+// collapsed spaces). False is returned if some characters are still not supported.
+func printable(s string) (string, bool) {
+	var b strings.Builder
+
+	for _, r := range norm.NFD.String(strings.ReplaceAll(s, "…", "...")) {
+		if unicode.Is(unicode.Mn, r) { // the combining marks (diacritics)
+			continue
+		}
+
+		if r = unicode.ToUpper(r); unicode.IsSpace(r) {
+			r = ' '
+		}
+
+		if _, ok := glyphs[r]; !ok {
+			return "", false
+		}
+
+		b.WriteRune(r)
+	}
+
+	return strings.Join(strings.Fields(b.String()), " "), true
+}
+
+// wrap splits the text into the lines of up to width characters (the words are broken only if they don't fit into
+// a single line), and truncates it with an ellipsis to maxLines lines.
+func wrap(s string, width, maxLines int) []string {
+	var (
+		lines []string
+		line  []rune
+	)
+
+	for _, word := range strings.Fields(s) {
+		for w := []rune(word); len(w) > 0; {
+			if len(line) > 0 && len(line)+1+len(w) > width {
+				lines, line = append(lines, string(line)), nil
+			}
+
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+
+			var n = min(len(w), width-len(line))
+
+			line, w = append(line, w[:n]...), w[n:]
+		}
+	}
+
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+
+	if len(lines) > maxLines {
+		var last = []rune(lines[maxLines-1])
+
+		if len(last) > width-3 {
+			var cut = width - 3
+
+			for i := cut; i > 0; i-- { // don't leave a part of the word
+				if last[i] == ' ' {
+					cut = i
+
+					break
+				}
+			}
+
+			last = last[:cut]
+		}
+
+		lines = append(lines[:maxLines-1], strings.TrimRight(string(last), " ")+"...")
+	}
+
+	return lines
+}
+
+// drawText draws the single line of text, centered horizontally, using the palette color index.
+func drawText(img *image.Paletted, s string, scale, top int, colorIndex uint8) {
+	var (
+		runes = []rune(s)
+		left  = (img.Rect.Dx() - (len(runes)*(glyphWidth+1)-1)*scale) / 2
+	)
+
+	for i, r := range runes {
+		var x = left + i*(glyphWidth+1)*scale
+
+		for row, bits := range glyphs[r] {
+			for col := range glyphWidth {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+
+				var at = image.Pt(x+col*scale, top+row*scale)
+
+				fill(img, image.Rectangle{Min: at, Max: at.Add(image.Pt(scale, scale))}, colorIndex)
+			}
+		}
+	}
+}
+
+// fill fills the rectangle using the palette color index.
+func fill(img *image.Paletted, r image.Rectangle, colorIndex uint8) {
+	r = r.Intersect(img.Rect)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Pix[img.PixOffset(x, y)] = colorIndex
+		}
+	}
+}
+
+// blend mixes two colors (the weight is the share of the first one).
+func blend(a, b color.RGBA, weight float64) color.RGBA {
+	var mix = func(x, y uint8) uint8 { return uint8(float64(x)*weight + float64(y)*(1-weight) + 0.5) } //nolint:mnd
+
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 0xff}
+}
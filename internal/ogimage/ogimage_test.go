@@ -0,0 +1,78 @@
+package ogimage
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	var (
+		text = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+		back = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	)
+
+	for _, message := range []string{"Not Found", "", "見つかりません", strings.Repeat("A very long message ", 20)} {
+		var buf bytes.Buffer
+
+		require.NoError(t, Render(&buf, 404, message, text, back))
+
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+
+		assert.Equal(t, Width, img.Bounds().Dx())
+		assert.Equal(t, Height, img.Bounds().Dy())
+		assert.Equal(t, back, color.RGBAModel.Convert(img.At(0, 0)))
+
+		var hasText bool
+
+		for y := 0; y < Height && !hasText; y++ {
+			for x := 0; x < Width && !hasText; x++ {
+				hasText = color.RGBAModel.Convert(img.At(x, y)) == text
+			}
+		}
+
+		assert.True(t, hasText, "the code is not drawn")
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"Not Found":               "NOT FOUND",
+		"Service  Unavailable\n":  "SERVICE UNAVAILABLE",
+		"Nicht gefunden (Fehler)": "NICHT GEFUNDEN (FEHLER)",
+		"Requête invalide…":       "REQUETE INVALIDE...",
+		"":                        "",
+	} {
+		got, ok := printable(give)
+
+		assert.True(t, ok, give)
+		assert.Equal(t, want, got)
+	}
+
+	for _, give := range []string{"見つかりません", "Ошибка", "100 €"} {
+		_, ok := printable(give)
+
+		assert.False(t, ok, give)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"NOT FOUND"}, wrap("NOT FOUND", 10, 2))
+	assert.Equal(t, []string{"REQUEST", "TIMEOUT"}, wrap("REQUEST TIMEOUT", 10, 2))
+	assert.Equal(t, []string{"ABCDEFGHIJ", "KL"}, wrap("ABCDEFGHIJKL", 10, 2))
+	assert.Equal(t, []string{"ONE TWO", "THREE..."}, wrap("ONE TWO THREE FOUR FIVE", 10, 2))
+	assert.Equal(t, []string{"ONE TWO", "ABCDEFG..."}, wrap("ONE TWO ABCDEFGHIJKLMNOP", 10, 2))
+	assert.Empty(t, wrap("", 10, 2))
+}
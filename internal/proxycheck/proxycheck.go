@@ -0,0 +1,228 @@
+// Package proxycheck verifies that a running error pages instance honors the header and path conventions of the
+// reverse proxies it's integrated with (like ingress-nginx or Traefik), by sending the requests the proxies send.
+package proxycheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Integration is a reverse proxy integration mode with the checks of its conventions.
+type Integration struct {
+	Name   string
+	Checks []Check
+}
+
+// Check is a single convention of the integration: the request the proxy sends, and the expectation about the
+// response.
+type Check struct {
+	Name    string
+	Hint    string // how to fix the configuration if the check fails (optional)
+	Path    string // the request path (relative to the base URL)
+	Headers map[string]string
+
+	// Expect returns an error if the response does not meet the expectation.
+	Expect func(status int, headers http.Header, body string) error
+}
+
+// Result is the result of a single check.
+type Result struct {
+	Integration, Check string
+	Err                error // nil if the check passed
+	Hint               string
+}
+
+// Passed reports whether the check passed.
+func (r Result) Passed() bool { return r.Err == nil }
+
+// requestID is the request ID the proxies pass to the error pages (it must appear in the request details).
+const requestID = "proxycheck-0123456789"
+
+// Integrations returns the supported integration modes.
+func Integrations() []Integration { //nolint:funlen
+	return []Integration{
+		{
+			// the custom errors of ingress-nginx: the request to the default backend has the original status in the
+			// `X-Code` header, the format in the `X-Format` header, and the request ID in the `X-Request-ID` header
+			Name: "ingress-nginx",
+			Checks: []Check{
+				{
+					Name:    "code from the X-Code header",
+					Path:    "/",
+					Headers: map[string]string{"X-Code": "503", "X-Format": "text/html", "X-Original-URI": "/app"},
+					Expect:  bodyContains("503"),
+				},
+				{
+					Name:    "format from the X-Format header",
+					Path:    "/",
+					Headers: map[string]string{"X-Code": "404", "X-Format": "application/json"},
+					Expect:  contentType("application/json"),
+				},
+				{
+					Name: "same HTTP status code",
+					Hint: "the status is responded to the client as-is, enable --send-same-http-code",
+					Path: "/",
+					Headers: map[string]string{
+						"X-Code": "503", "X-Format": "text/html", "X-Namespace": "default", "X-Service-Name": "app",
+					},
+					Expect: status(http.StatusServiceUnavailable),
+				},
+				{
+					Name:    "request ID from the X-Request-ID header",
+					Hint:    "the request details are not shown, enable --show-details",
+					Path:    "/",
+					Headers: map[string]string{"X-Code": "502", "X-Format": "application/json", "X-Request-ID": requestID},
+					Expect:  bodyContains(requestID),
+				},
+			},
+		},
+		{
+			// the errors middleware of Traefik: the page is requested using the configured query (like
+			// `/{status}.html`) with the original request headers, and the original status is responded by Traefik
+			Name: "traefik",
+			Checks: []Check{
+				{
+					Name:    "code from the query path",
+					Path:    "/502.html",
+					Headers: map[string]string{"Accept": "text/html,application/xhtml+xml,*/*;q=0.8"},
+					Expect:  all(bodyContains("502"), contentType("text/html")),
+				},
+				{
+					Name:    "format from the original Accept header",
+					Path:    "/502.html",
+					Headers: map[string]string{"Accept": "application/json"},
+					Expect:  contentType("application/json"),
+				},
+			},
+		},
+		{
+			// the generic reverse proxies (like nginx with the `error_page` directive, HAProxy, or Caddy): the page
+			// is requested using the `/{code}` path with the original request headers
+			Name: "generic",
+			Checks: []Check{
+				{
+					Name:    "code from the path",
+					Path:    "/404",
+					Headers: map[string]string{"Accept": "text/html"},
+					Expect:  all(bodyContains("404"), contentType("text/html")),
+				},
+				{
+					Name:    "XML format from the Accept header",
+					Path:    "/500",
+					Headers: map[string]string{"Accept": "application/xml"},
+					Expect:  all(bodyContains("500"), contentType("xml")),
+				},
+				{
+					Name:    "code from the X-Code header",
+					Path:    "/",
+					Headers: map[string]string{"X-Code": "429", "Accept": "application/json"},
+					Expect:  bodyContains("429"),
+				},
+			},
+		},
+	}
+}
+
+// Verify runs the checks of the integrations against the instance at the base URL (like `http://127.0.0.1:8080`,
+// with the path prefix if any). An error is returned only if the instance can't be requested.
+func Verify(ctx context.Context, client *http.Client, baseURL string, integrations []Integration) ([]Result, error) {
+	var results = make([]Result, 0, len(integrations)*4) //nolint:mnd
+
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	for _, integration := range integrations {
+		for _, check := range integration.Checks {
+			httpStatus, headers, body, err := send(ctx, client, baseURL+check.Path, check.Headers)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", integration.Name, check.Name, err)
+			}
+
+			var result = Result{Integration: integration.Name, Check: check.Name}
+
+			if result.Err = check.Expect(httpStatus, headers, body); result.Err != nil {
+				result.Hint = check.Hint
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// send sends the GET request and returns the response status, headers, and body.
+func send(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	headers map[string]string,
+) (int, http.Header, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return 0, nil, "", err
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, "", err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) //nolint:mnd // 1 MiB is enough for any page
+	if err != nil {
+		return 0, nil, "", err
+	}
+
+	return resp.StatusCode, resp.Header, string(body), nil
+}
+
+func status(want int) func(int, http.Header, string) error {
+	return func(got int, _ http.Header, _ string) error {
+		if got != want {
+			return fmt.Errorf("the status code is %d, expected %d", got, want)
+		}
+
+		return nil
+	}
+}
+
+func contentType(want string) func(int, http.Header, string) error {
+	return func(_ int, headers http.Header, _ string) error {
+		if got := headers.Get("Content-Type"); !strings.Contains(got, want) {
+			return fmt.Errorf("the content type is %s, expected %s", strconv.Quote(got), want)
+		}
+
+		return nil
+	}
+}
+
+func bodyContains(want string) func(int, http.Header, string) error {
+	return func(_ int, _ http.Header, body string) error {
+		if !strings.Contains(body, want) {
+			return fmt.Errorf("the response body does not contain %s", strconv.Quote(want))
+		}
+
+		return nil
+	}
+}
+
+func all(expectations ...func(int, http.Header, string) error) func(int, http.Header, string) error {
+	return func(status int, headers http.Header, body string) error {
+		for _, expect := range expectations {
+			if err := expect(status, headers, body); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
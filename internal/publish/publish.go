@@ -0,0 +1,263 @@
+// Package publish uploads the rendered HTML error pages to the object storage bucket, so a static fallback (like
+// the S3 website or CDN error pages) stays in sync with the live service.
+package publish
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/s3"
+	"github.com/binaryYuki/error-pages/internal/template"
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+const (
+	// ManifestName is the name of the manifest with the SHA-256 checksums of the published pages (in the
+	// `sha256sum` format). It's uploaded after the pages, and used to skip the unchanged pages after a restart.
+	ManifestName = "SHA256SUMS"
+
+	// ErrorDocumentName is the name of the default code page copy (to be used as the S3 website error document).
+	ErrorDocumentName = "error.html"
+)
+
+// Store is the object storage the pages are published to (the [s3.Client] implements it).
+type Store interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error) // [s3.ErrNotFound] if the object does not exist
+}
+
+// Publisher renders the pages of the active template and uploads the changed ones. It is safe for concurrent use.
+type Publisher struct {
+	cfg      *config.Config
+	log      *logger.Logger
+	store    Store
+	template func() string // returns the active template name
+
+	mu        sync.Mutex        // serializes the publishing
+	published map[string]string // map[page_name]sha256 of the published pages, nil until the manifest is loaded
+	current   string            // the template name the published pages are rendered with (empty until published)
+}
+
+// New creates a new publisher. The activeTemplate function returns the name of the template the pages are rendered
+// with (it may change over time, e.g. with the template rotation).
+func New(cfg *config.Config, log *logger.Logger, store Store, activeTemplate func() string) *Publisher {
+	return &Publisher{cfg: cfg, log: log, store: store, template: activeTemplate}
+}
+
+// Publish renders the pages and uploads the changed ones, followed by the manifest (if anything has changed). It
+// returns the number of the uploaded pages.
+func (p *Publisher) Publish(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var templateName = p.template()
+
+	pages, err := p.render(ctx, templateName)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.published == nil {
+		p.published = p.load(ctx)
+	}
+
+	var (
+		sums     = make(map[string]string, len(pages))
+		uploaded int
+	)
+
+	for _, name := range slices.Sorted(maps.Keys(pages)) {
+		var sum = sha256.Sum256(pages[name])
+
+		if sums[name] = hex.EncodeToString(sum[:]); p.published[name] == sums[name] {
+			continue // not changed
+		}
+
+		if err = p.store.Put(ctx, p.key(name), pages[name], "text/html; charset=utf-8"); err != nil {
+			return uploaded, err // the uploaded pages are compared again on the next attempt
+		}
+
+		uploaded++
+	}
+
+	if uploaded == 0 && maps.Equal(sums, p.published) {
+		p.current = templateName
+
+		return 0, nil // everything is up to date
+	}
+
+	if err = p.store.Put(ctx, p.key(ManifestName), manifest(sums), "text/plain; charset=utf-8"); err != nil {
+		return uploaded, err
+	}
+
+	p.published, p.current = sums, templateName
+
+	p.log.Info("Error pages published",
+		logger.String("template", templateName),
+		logger.String("bucket", p.cfg.Publish.Bucket),
+		logger.String("prefix", p.cfg.Publish.Prefix),
+		logger.Int("uploaded", uploaded),
+	)
+
+	return uploaded, nil
+}
+
+// Run publishes the pages immediately (the publisher is created for every configuration, so the pages are
+// published on startup and after every reload), and then whenever the active template changes (e.g. with the
+// template rotation) - it's checked on every interval, and the pages are not rendered again until it changes (or
+// the previous attempt failed). It returns when the context is canceled.
+func (p *Publisher) Run(ctx context.Context) {
+	var ticker = time.NewTicker(p.cfg.Publish.Interval)
+	defer ticker.Stop()
+
+	for {
+		if p.outdated() {
+			if _, err := p.Publish(ctx); err != nil && ctx.Err() == nil {
+				p.log.Warn("Cannot publish the error pages", logger.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// outdated reports whether the published pages are rendered with a template other than the active one (or not
+// published yet).
+func (p *Publisher) outdated() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.current == "" || p.current != p.template()
+}
+
+// key returns the bucket key of the page.
+func (p *Publisher) key(name string) string { return path.Join(p.cfg.Publish.Prefix, name) }
+
+// load returns the checksums of the published pages from the manifest in the bucket (an empty map if it's missing,
+// so all the pages are uploaded).
+func (p *Publisher) load(ctx context.Context) map[string]string {
+	var sums = make(map[string]string)
+
+	data, err := p.store.Get(ctx, p.key(ManifestName))
+	if err != nil {
+		if !errors.Is(err, s3.ErrNotFound) {
+			p.log.Warn("Cannot load the published pages manifest", logger.Error(err))
+		}
+
+		return sums
+	}
+
+	for scanner := bufio.NewScanner(strings.NewReader(string(data))); scanner.Scan(); {
+		if sum, name, ok := strings.Cut(scanner.Text(), "  "); ok {
+			sums[name] = sum
+		}
+	}
+
+	return sums
+}
+
+// render returns the HTML pages of the template (by the page name, like `404.html`). It stops early when the context
+// is canceled (e.g. on reload or shutdown), so the whole batch is not waited for.
+func (p *Publisher) render(ctx context.Context, templateName string) (map[string][]byte, error) {
+	var content, found = p.cfg.Templates.Get(templateName)
+	if !found {
+		return nil, fmt.Errorf("template '%s' not found", templateName)
+	}
+
+	var (
+		pages = make(map[string][]byte, len(p.cfg.Codes)+1)
+		opts  = template.Options{
+			Limits: template.Limits{
+				Timeout:       p.cfg.TemplateLimits.RenderTimeout,
+				MaxDepth:      p.cfg.TemplateLimits.MaxDepth,
+				MaxIncludes:   p.cfg.TemplateLimits.MaxIncludes,
+				MaxIterations: p.cfg.TemplateLimits.MaxIterations,
+			},
+			Escaping: template.EscapeHTML,
+		}
+		minifier *template.Minifier // nil if the minification is disabled
+	)
+
+	if p.cfg.DisableAutoEscape {
+		opts.Escaping = template.EscapeNone
+	}
+
+	if !p.cfg.DisableMinification && !p.cfg.TemplateOptions[templateName].DisableMinification {
+		minifier = template.NewMinifier(template.MinifyOptions{
+			KeepComments:            p.cfg.Minification.KeepComments,
+			KeepConditionalComments: p.cfg.Minification.KeepConditionalComments,
+			KeepInlineCSS:           p.cfg.Minification.KeepInlineCSS,
+			KeepInlineJS:            p.cfg.Minification.KeepInlineJS,
+			KeepESI:                 p.cfg.ESI,
+		})
+	}
+
+	for code, desc := range p.cfg.Codes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		c, err := strconv.ParseUint(code, 10, 16)
+		if err != nil {
+			continue // the wildcard codes (like `4**`) have no pages
+		}
+
+		html, err := template.RenderWith(content, template.Props{
+			Code:          uint16(c), //nolint:gosec // parsed as a 16-bit number
+			Message:       desc.Message,
+			Description:   desc.Description,
+			L10nDisabled:  p.cfg.L10n.Disable,
+			TextDirection: l10n.Direction(""),
+			LangCode:      l10n.DefaultLocale,
+			Charset:       "utf-8",
+			ReducedMotion: p.cfg.Accessibility.ReducedMotion == config.MotionPreferenceReduce, // no client hints
+			PrintFriendly: p.cfg.Accessibility.PrintFriendly,
+			ESI:           p.cfg.ESI,
+		}, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot render template '%s': %w", templateName, err)
+		}
+
+		if minifier != nil {
+			if mini, minErr := minifier.String(html); minErr == nil {
+				html = mini
+			}
+		}
+
+		pages[code+".html"] = []byte(html)
+
+		if uint16(c) == p.cfg.DefaultCodeToRender { //nolint:gosec
+			pages[ErrorDocumentName] = []byte(html)
+		}
+	}
+
+	return pages, nil
+}
+
+// manifest returns the manifest of the pages (sorted by the name).
+func manifest(sums map[string]string) []byte {
+	var b strings.Builder
+
+	for _, name := range slices.Sorted(maps.Keys(sums)) {
+		b.WriteString(sums[name] + "  " + name + "\n")
+	}
+
+	return []byte(b.String())
+}
@@ -0,0 +1,194 @@
+package publish_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/config"
+	"github.com/binaryYuki/error-pages/internal/logger"
+	"github.com/binaryYuki/error-pages/internal/publish"
+	"github.com/binaryYuki/error-pages/internal/s3"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+	puts    []string
+	failPut error
+}
+
+func (s *fakeStore) Put(_ context.Context, key string, body []byte, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failPut != nil {
+		return s.failPut
+	}
+
+	s.objects[key] = string(body)
+	s.puts = append(s.puts, key)
+
+	return nil
+}
+
+func (s *fakeStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if body, ok := s.objects[key]; ok {
+		return []byte(body), nil
+	}
+
+	return nil, s3.ErrNotFound
+}
+
+func (s *fakeStore) reset() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var puts = s.puts
+
+	s.puts = nil
+
+	return puts
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Publish.Bucket, cfg.Publish.Prefix = "bucket", "errors"
+	cfg.Codes = config.Codes{"404": {Message: "Not Found"}, "503": {Message: "Service Unavailable"}, "5**": {}}
+	cfg.Templates = map[string]string{"foo": "<p>foo {{ code }}</p>", "bar": "<p>bar {{ code }}: {{ message }}</p>"}
+
+	var (
+		store  = &fakeStore{objects: make(map[string]string)}
+		active = "foo"
+		pub    = publish.New(&cfg, logger.NewNop(), store, func() string { return active })
+		ctx    = context.Background()
+	)
+
+	uploaded, err := pub.Publish(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, uploaded)
+	assert.Equal(t, []string{
+		"errors/404.html", "errors/503.html", "errors/error.html", "errors/" + publish.ManifestName,
+	}, store.reset())
+	assert.Equal(t, "<p>foo 404</p>", store.objects["errors/404.html"])
+	assert.Equal(t, "<p>foo 404</p>", store.objects["errors/error.html"])
+	assert.Len(t, strings.Split(strings.TrimSpace(store.objects["errors/"+publish.ManifestName]), "\n"), 3)
+
+	t.Run("not changed", func(t *testing.T) {
+		uploaded, err = pub.Publish(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, uploaded)
+		assert.Empty(t, store.reset())
+	})
+
+	t.Run("restarted", func(t *testing.T) { // the published pages are loaded from the manifest
+		uploaded, err = publish.New(&cfg, logger.NewNop(), store, func() string { return active }).Publish(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, uploaded)
+		assert.Empty(t, store.reset())
+	})
+
+	t.Run("template changed", func(t *testing.T) {
+		active = "bar"
+
+		uploaded, err = pub.Publish(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, uploaded)
+		assert.Len(t, store.reset(), 4)
+		assert.Equal(t, "<p>bar 503: Service Unavailable</p>", store.objects["errors/503.html"])
+	})
+
+	t.Run("upload error", func(t *testing.T) {
+		active, store.failPut = "foo", errors.New("access denied")
+
+		_, err = pub.Publish(ctx)
+		require.ErrorContains(t, err, "access denied")
+
+		store.failPut = nil
+
+		uploaded, err = pub.Publish(ctx) // retried
+		require.NoError(t, err)
+		assert.Equal(t, 3, uploaded)
+	})
+
+	t.Run("missing template", func(t *testing.T) {
+		active = "baz"
+
+		_, err = pub.Publish(ctx)
+		require.ErrorContains(t, err, "template 'baz' not found")
+	})
+}
+
+func TestPublisher_Publish_Canceled(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cfg         = config.New()
+		store       = &fakeStore{objects: make(map[string]string)}
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	cancel() // e.g. the reload or shutdown
+
+	_, err := publish.New(&cfg, logger.NewNop(), store, func() string { return cfg.TemplateName }).Publish(ctx)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, store.reset()) // nothing is rendered and uploaded
+}
+
+func TestPublisher_Run(t *testing.T) {
+	t.Parallel()
+
+	var cfg = config.New()
+
+	cfg.Publish.Interval = 5 * time.Millisecond
+	cfg.Codes = config.Codes{"404": {Message: "Not Found"}}
+	cfg.Templates = map[string]string{"foo": "<p>foo {{ nowUnix }}</p>", "bar": "<p>bar</p>"}
+
+	var (
+		store       = &fakeStore{objects: make(map[string]string)}
+		active      atomic.Pointer[string]
+		foo, bar    = "foo", "bar"
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	active.Store(&foo)
+
+	go func() {
+		defer close(done)
+
+		publish.New(&cfg, logger.NewNop(), store, func() string { return *active.Load() }).Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return len(store.reset()) > 0 }, time.Second, time.Millisecond) // on startup
+
+	<-time.After(50 * time.Millisecond) // many intervals
+
+	assert.Empty(t, store.reset()) // the pages are not rendered again until the template changes
+
+	active.Store(&bar) // e.g. the rotation
+
+	require.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		return store.objects["404.html"] == "<p>bar</p>"
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,276 @@
+// Package s3 uploads objects to the Amazon S3 (or an S3-compatible) bucket, signing the requests using the AWS
+// Signature Version 4. Only the single-part uploads are supported, which is enough for the error pages.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultRegion is used when the region is not specified.
+const DefaultRegion = "us-east-1"
+
+// Credentials are the AWS access keys.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // optional, for the temporary credentials
+}
+
+// CredentialsFromEnv reads the credentials from the standard AWS environment variables (`AWS_ACCESS_KEY_ID`,
+// `AWS_SECRET_ACCESS_KEY`, and the optional `AWS_SESSION_TOKEN`).
+func CredentialsFromEnv() (Credentials, error) {
+	var creds = Credentials{
+		AccessKeyID:     strings.TrimSpace(os.Getenv("AWS_ACCESS_KEY_ID")),
+		SecretAccessKey: strings.TrimSpace(os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		SessionToken:    strings.TrimSpace(os.Getenv("AWS_SESSION_TOKEN")),
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, errors.New("missing AWS credentials (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+
+	return creds, nil
+}
+
+// Client uploads the objects to the bucket. It is safe for concurrent use.
+type Client struct {
+	bucket   string
+	region   string
+	endpoint *url.URL // nil means the AWS endpoint (with the virtual-hosted-style URLs)
+	creds    Credentials
+	http     interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+	now func() time.Time
+}
+
+// NewClient creates a new client for the bucket. An empty region means [DefaultRegion], and an empty endpoint
+// means the AWS one. With a custom endpoint (like `http://127.0.0.1:9000` for MinIO), the path-style URLs are used.
+func NewClient(bucket, region, endpoint string, creds Credentials) (*Client, error) {
+	if bucket = strings.TrimSpace(bucket); bucket == "" {
+		return nil, errors.New("missing S3 bucket name")
+	}
+
+	var c = Client{
+		bucket: bucket,
+		region: strings.TrimSpace(region),
+		creds:  creds,
+		http:   http.DefaultClient,
+		now:    time.Now,
+	}
+
+	if c.region == "" {
+		c.region = DefaultRegion
+	}
+
+	if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+		if err := ValidateEndpoint(endpoint); err != nil {
+			return nil, err
+		}
+
+		c.endpoint, _ = url.Parse(endpoint) // already validated
+	}
+
+	return &c, nil
+}
+
+// ValidateEndpoint checks that the custom S3 endpoint is an absolute HTTP(S) URL.
+func ValidateEndpoint(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("wrong S3 endpoint [%s]: %w", s, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("wrong S3 endpoint [%s]: an absolute http(s) URL is expected", s)
+	}
+
+	return nil
+}
+
+// objectURL returns the URL of the object with the key.
+func (c *Client) objectURL(key string) *url.URL {
+	if c.endpoint == nil {
+		return &url.URL{
+			Scheme: "https",
+			Host:   c.bucket + ".s3." + c.region + ".amazonaws.com",
+			Path:   "/" + key,
+		}
+	}
+
+	var u = *c.endpoint
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.bucket + "/" + key
+
+	return &u
+}
+
+// Put uploads the object with the key (like `errors/404.html`) and content type.
+func (c *Client) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	var u = c.objectURL(strings.TrimLeft(key, "/"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	var sum = sha256.Sum256(body)
+
+	sign(req, hex.EncodeToString(sum[:]), c.creds, c.region, c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot upload %s: %w", key, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var msg, _ = io.ReadAll(io.LimitReader(resp.Body, 1<<10)) //nolint:mnd // the error message is short
+
+		return fmt.Errorf("cannot upload %s: unexpected status code %d: %s", key, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10)) //nolint:mnd // allow the connection reuse
+
+	return nil
+}
+
+// ErrNotFound is returned by [Client.Get] when the object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Get downloads the object with the key (up to 16 MiB). [ErrNotFound] is returned if the object does not exist.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	var u = c.objectURL(strings.TrimLeft(key, "/"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	sign(req, emptyPayloadHash, c.creds, c.region, c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot download %s: %w", key, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	var body, rErr = io.ReadAll(io.LimitReader(resp.Body, 16<<20)) //nolint:mnd
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("cannot download %s: unexpected status code %d: %s", key, resp.StatusCode,
+			bytes.TrimSpace(body[:min(len(body), 1<<10)]), //nolint:mnd // the error message is short
+		)
+	case rErr != nil:
+		return nil, fmt.Errorf("cannot download %s: %w", key, rErr)
+	}
+
+	return body, nil
+}
+
+// emptyPayloadHash is the SHA-256 hash of the empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign signs the request using the AWS Signature Version 4 (the `Host` and all the request headers are signed).
+func sign(req *http.Request, payloadHash string, creds Credentials, region string, now time.Time) {
+	const (
+		algorithm = "AWS4-HMAC-SHA256"
+		service   = "s3"
+	)
+
+	var (
+		amzDate = now.UTC().Format("20060102T150405Z")
+		date    = amzDate[:8]
+		scope   = date + "/" + region + "/" + service + "/aws4_request"
+	)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	var headers = map[string]string{"host": req.URL.Host}
+
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	var (
+		names     = slices.Sorted(maps.Keys(headers))
+		canonical strings.Builder
+	)
+
+	canonical.WriteString(req.Method + "\n" + escapePath(req.URL.Path) + "\n" + req.URL.Query().Encode() + "\n")
+
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.Join(strings.Fields(headers[name]), " ") + "\n")
+	}
+
+	var signedHeaders = strings.Join(names, ";")
+
+	canonical.WriteString("\n" + signedHeaders + "\n" + payloadHash)
+
+	var (
+		requestHash  = sha256.Sum256([]byte(canonical.String()))
+		stringToSign = algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+		key          = []byte("AWS4" + creds.SecretAccessKey)
+	)
+
+	for _, part := range []string{date, region, service, "aws4_request", stringToSign} {
+		key = hmacSHA256(key, part)
+	}
+
+	req.Header.Set("Authorization", algorithm+" Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+hex.EncodeToString(key),
+	)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var h = hmac.New(sha256.New, key)
+
+	_, _ = h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+// escapePath URI-encodes every byte of the path, except the unreserved characters and slashes.
+func escapePath(p string) string {
+	var b strings.Builder
+
+	for i := range len(p) {
+		switch c := p[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
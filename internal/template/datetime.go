@@ -0,0 +1,58 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var locations sync.Map //nolint:gochecknoglobals // map[name]*time.Location
+
+// loadLocation returns the time zone location by the IANA name (like `Europe/Berlin`), caching the loaded ones.
+// An empty name means UTC.
+func loadLocation(name string) (*time.Location, error) {
+	if name = strings.TrimSpace(name); name == "" {
+		return time.UTC, nil
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil //nolint:forcetypeassert
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locations.Store(name, loc)
+
+	return loc, nil
+}
+
+// toTime converts the value (time, unix timestamp in seconds, or RFC 3339 string) into the time. The timestamps
+// and strings are converted into the given location, while the time values are kept as-is.
+func toTime(v any, loc *time.Location) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case int:
+		return time.Unix(int64(t), 0).In(loc), nil
+	case int64:
+		return time.Unix(t, 0).In(loc), nil
+	case string:
+		if unix, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64); err == nil {
+			return time.Unix(unix, 0).In(loc), nil
+		}
+
+		parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(t))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("wrong time value [%s]: %w", t, err)
+		}
+
+		return parsed.In(loc), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time value type %T", v)
+}
@@ -0,0 +1,123 @@
+package template
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	htmlTemplate "html/template"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+// Escaping is the mode of the automatic escaping of the values written by the template actions.
+type Escaping byte
+
+const (
+	// EscapeNone means the values are written as-is (the plain text or the legacy behavior).
+	EscapeNone Escaping = iota
+
+	// EscapeHTML means the context-aware HTML escaping (using the `html/template` package): the values are escaped
+	// depending on where they are written - the HTML text, attribute value, JS string, CSS, or URL.
+	EscapeHTML
+
+	// EscapeJSON means the values are escaped as the JSON string content (without the quotes), unless the action
+	// already ends with the `json` function.
+	EscapeJSON
+
+	// EscapeXML means the values are escaped as the XML text, unless the action already ends with the `escape`
+	// function.
+	EscapeXML
+)
+
+// names of the escaping functions appended to the template actions (not intended to be used in the templates)
+const (
+	jsonEscaperName = "_escape_json"
+	xmlEscaperName  = "_escape_xml"
+)
+
+// escapers are the functions appended to the template actions for the text-based escaping modes.
+var escapers = template.FuncMap{ //nolint:gochecknoglobals
+	jsonEscaperName: func(v any) string {
+		var b, _ = json.Marshal(fmt.Sprint(v)) //nolint:errchkjson // the string is always serializable
+
+		return string(b[1 : len(b)-1]) // without the quotes
+	},
+	xmlEscaperName: func(v any) string {
+		var b strings.Builder
+
+		_ = xml.EscapeText(&b, []byte(fmt.Sprint(v)))
+
+		return b.String()
+	},
+}
+
+// htmlOverrides are the functions that behave differently in the [EscapeHTML] mode: the trusted content is marked
+// as safe, and the manual escaping is disabled (the values are escaped automatically, so the double escaping is
+// avoided).
+var htmlOverrides = template.FuncMap{ //nolint:gochecknoglobals
+	"escape": func(s string) string { return s },
+	"json": func(v any) htmlTemplate.JS {
+		b, _ := json.Marshal(v) //nolint:errchkjson
+
+		return htmlTemplate.JS(b) //nolint:gosec // the JSON is a safe JS expression
+	},
+	"l10nScript": func() htmlTemplate.JS { return htmlTemplate.JS(l10n.L10n()) }, //nolint:gosec // the embedded script
+}
+
+// escapeActions appends the escaping function call to each action (`{{ ... }}`) of the templates that writes
+// the value, unless the action already ends with one of the skip functions. The same approach is used by the
+// `html/template` package.
+func escapeActions(tmpl *template.Template, escaper string, skip ...string) {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Root != nil {
+			walkActions(t.Root, func(n *parse.ActionNode) {
+				if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) == 0 { // variable declarations write nothing
+					return
+				}
+
+				var last = n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+
+				if len(last.Args) > 0 {
+					if ident, ok := last.Args[0].(*parse.IdentifierNode); ok {
+						if ident.Ident == escaper || slices.Contains(skip, ident.Ident) {
+							return
+						}
+					}
+				}
+
+				n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+					NodeType: parse.NodeCommand,
+					Pos:      n.Pos,
+					Args:     []parse.Node{parse.NewIdentifier(escaper).SetPos(n.Pos)},
+				})
+			})
+		}
+	}
+}
+
+// walkActions calls the fn for each action node in the tree.
+func walkActions(node parse.Node, fn func(*parse.ActionNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, child := range n.Nodes {
+				walkActions(child, fn)
+			}
+		}
+	case *parse.ActionNode:
+		fn(n)
+	case *parse.IfNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	case *parse.RangeNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	case *parse.WithNode:
+		walkActions(n.List, fn)
+		walkActions(n.ElseList, fn)
+	}
+}
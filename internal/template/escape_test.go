@@ -0,0 +1,78 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestRenderWith_Escaping(t *testing.T) {
+	t.Parallel()
+
+	var props = template.Props{Code: 404, Message: `<b>"Not" & 'found'</b>`, Host: "evil.com\"><script>"}
+
+	for name, tt := range map[string]struct {
+		giveTemplate string
+		giveEscaping template.Escaping
+		want         string
+	}{
+		"none": {
+			giveTemplate: `{{ message }}`,
+			giveEscaping: template.EscapeNone,
+			want:         `<b>"Not" & 'found'</b>`,
+		},
+		"html text": {
+			giveTemplate: `<p>{{ message }}</p>`,
+			giveEscaping: template.EscapeHTML,
+			want:         `<p>&lt;b&gt;&#34;Not&#34; &amp; &#39;found&#39;&lt;/b&gt;</p>`,
+		},
+		"html attribute (no double escaping)": {
+			giveTemplate: `<a title="{{ host | escape }}">`,
+			giveEscaping: template.EscapeHTML,
+			want:         `<a title="evil.com&#34;&gt;&lt;script&gt;">`,
+		},
+		"html js string": {
+			giveTemplate: `<script>var h = '{{ host }}';</script>`,
+			giveEscaping: template.EscapeHTML,
+			want:         `<script>var h = 'evil.com\u0022\u003e\u003cscript\u003e';</script>`,
+		},
+		"html js value": {
+			giveTemplate: `<script>var c = {{ code | json }};</script>`,
+			giveEscaping: template.EscapeHTML,
+			want:         `<script>var c = 404;</script>`,
+		},
+		"json": {
+			giveTemplate: `{"code": {{ code }}, "message": "{{ message }}", "host": {{ host | json }}}`,
+			giveEscaping: template.EscapeJSON,
+			want: `{"code": 404, "message": "\u003cb\u003e\"Not\" \u0026 'found'\u003c/b\u003e", ` +
+				`"host": "evil.com\"\u003e\u003cscript\u003e"}`,
+		},
+		"json (control flow)": {
+			giveTemplate: `{{ if code }}{{ $m := message }}"{{ $m }}"{{ end }}`,
+			giveEscaping: template.EscapeJSON,
+			want:         `"\u003cb\u003e\"Not\" \u0026 'found'\u003c/b\u003e"`,
+		},
+		"xml": {
+			giveTemplate: `<code>{{ code }}</code><message>{{ message }}</message>`,
+			giveEscaping: template.EscapeXML,
+			want:         `<code>404</code><message>&lt;b&gt;&#34;Not&#34; &amp; &#39;found&#39;&lt;/b&gt;</message>`,
+		},
+		"xml (already escaped)": {
+			giveTemplate: `<host>{{ host | escape }}</host>`,
+			giveEscaping: template.EscapeXML,
+			want:         `<host>evil.com&#34;&gt;&lt;script&gt;</host>`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := template.RenderWith(tt.giveTemplate, props, template.Options{Escaping: tt.giveEscaping})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, content)
+		})
+	}
+}
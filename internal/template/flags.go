@@ -0,0 +1,57 @@
+package template
+
+import (
+	"slices"
+	"strings"
+)
+
+type (
+	// Flags are the feature flags of the request (already resolved for the client), sorted by name. They're a part
+	// of the [Props] (so the pages are cached separately for each distinct set of values), but aren't exposed as a
+	// token - use the `flag` and `flagOn` template functions instead.
+	Flags []Flag
+
+	// Flag is a single feature flag.
+	Flag struct{ Name, Value string }
+)
+
+// NewFlags creates the flags from the map[name]value.
+func NewFlags(values map[string]string) Flags {
+	if len(values) == 0 {
+		return nil
+	}
+
+	var flags = make(Flags, 0, len(values))
+
+	for name, value := range values {
+		flags = append(flags, Flag{Name: name, Value: value})
+	}
+
+	// the stable order makes the cache key independent of the map order
+	slices.SortFunc(flags, func(a, b Flag) int { return strings.Compare(a.Name, b.Name) })
+
+	return flags
+}
+
+// Get returns the flag value. False is returned if the flag is not set.
+func (f Flags) Get(name string) (string, bool) {
+	if i, found := slices.BinarySearchFunc(f, name, func(flag Flag, name string) int {
+		return strings.Compare(flag.Name, name)
+	}); found {
+		return f[i].Value, true
+	}
+
+	return "", false
+}
+
+// On reports whether the flag is enabled (the value is `true`, `on`, `yes`, `enabled`, or `1`, the case is ignored).
+func (f Flags) On(name string) bool {
+	value, _ := f.Get(name)
+
+	switch strings.ToLower(value) {
+	case "true", "on", "yes", "enabled", "1":
+		return true
+	}
+
+	return false
+}
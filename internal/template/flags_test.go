@@ -0,0 +1,35 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestNewFlags(t *testing.T) {
+	t.Parallel()
+
+	var flags = template.NewFlags(map[string]string{"theme": "dark", "new_503_copy": "ON", "beta": "false"})
+
+	assert.Equal(t, template.Flags{
+		{Name: "beta", Value: "false"},
+		{Name: "new_503_copy", Value: "ON"},
+		{Name: "theme", Value: "dark"},
+	}, flags)
+
+	value, ok := flags.Get("theme")
+	assert.True(t, ok)
+	assert.Equal(t, "dark", value)
+
+	_, ok = flags.Get("missing")
+	assert.False(t, ok)
+
+	assert.True(t, flags.On("new_503_copy"))
+	assert.False(t, flags.On("beta"))
+	assert.False(t, flags.On("theme"))
+	assert.False(t, flags.On("missing"))
+
+	assert.Empty(t, template.NewFlags(nil))
+}
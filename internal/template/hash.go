@@ -0,0 +1,50 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileHashLength is the length of the file content hash (in hex characters) returned by the [fileHash].
+const fileHashLength = 8
+
+type fileHashEntry struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+var fileHashes sync.Map //nolint:gochecknoglobals // map[path]fileHashEntry
+
+// fileHash returns a short hash of the file content (the first 8 hex characters of the SHA-256), suitable for
+// cache busting. The hash is cached until the file modification time or size changes. An empty string is returned
+// if the file cannot be read.
+func fileHash(path string) string {
+	stat, err := os.Stat(path)
+	if err != nil || stat.IsDir() {
+		return ""
+	}
+
+	if cached, ok := fileHashes.Load(path); ok {
+		if e := cached.(fileHashEntry); e.size == stat.Size() && e.modTime.Equal(stat.ModTime()) { //nolint:forcetypeassert
+			return e.hash
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var (
+		sum  = sha256.Sum256(content)
+		hash = hex.EncodeToString(sum[:])[:fileHashLength]
+	)
+
+	fileHashes.Store(path, fileHashEntry{modTime: stat.ModTime(), size: stat.Size(), hash: hash})
+
+	return hash
+}
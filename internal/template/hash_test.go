@@ -0,0 +1,45 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestRender_Hash(t *testing.T) {
+	t.Parallel()
+
+	var (
+		path = filepath.Join(t.TempDir(), "app.css")
+		tpl  = `{{ hash "` + filepath.ToSlash(path) + `" }}`
+	)
+
+	require.NoError(t, os.WriteFile(path, []byte("body{}"), 0o600))
+
+	first, err := template.Render(tpl, template.Props{})
+	require.NoError(t, err)
+	assert.Equal(t, "7c98040a", first) // sha256("body{}")[:8]
+
+	again, err := template.Render(tpl, template.Props{})
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	// the content (and modification time) is changed - the hash must be recalculated
+	require.NoError(t, os.WriteFile(path, []byte("body{color:red}"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Hour)))
+
+	changed, err := template.Render(tpl, template.Props{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+	assert.Len(t, changed, 8)
+
+	missing, err := template.Render(`{{ hash "./not-exists.css" }}`, template.Props{})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
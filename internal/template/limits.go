@@ -0,0 +1,284 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"text/template/parse"
+	"time"
+)
+
+// Limits restricts the resources a single template render may consume, so a malicious (or just broken) template
+// cannot block the renderer. The zero value of each field means no limit.
+type Limits struct {
+	// Timeout is the maximum render duration. The render is aborted on the next output write (or the `{{ range }}`
+	// loop start) after the deadline, and the error is returned immediately (without waiting for the render to
+	// finish).
+	Timeout time.Duration
+
+	// MaxDepth is the maximum nesting of the `{{ template "name" }}` calls. Recursive templates are always
+	// rejected when this limit is set, since their depth depends on the data.
+	MaxDepth uint
+
+	// MaxIncludes is the maximum number of the `{{ template "name" }}` actions in the template (including the
+	// actions inside the defined templates).
+	MaxIncludes uint
+
+	// MaxIterations is the maximum total number of the `{{ range }}` iterations in a single render (including the
+	// nested loops). The loops that write nothing are never stopped by the [Limits.Timeout], so the size of every
+	// loop is checked before it starts, and the loops started after the timeout are refused too.
+	MaxIterations uint
+}
+
+var (
+	// ErrRenderTimeout is returned when the render takes longer than allowed.
+	ErrRenderTimeout = errors.New("template render timeout exceeded")
+
+	// ErrMaxDepthExceeded is returned when the template calls are nested too deeply (or recursively).
+	ErrMaxDepthExceeded = errors.New("template nesting depth exceeded")
+
+	// ErrMaxIncludesExceeded is returned when the template contains too many template calls.
+	ErrMaxIncludesExceeded = errors.New("too many template includes")
+
+	// ErrMaxIterationsExceeded is returned when the template loops iterate too many times.
+	ErrMaxIterationsExceeded = errors.New("too many template loop iterations")
+)
+
+// rangeGuardName is the name of the function every `{{ range }}` pipeline is passed through (see the
+// [Limits.guardRanges]). It can't clash with the template functions, since they never start with an underscore.
+const rangeGuardName = "_rangeGuard"
+
+// guarded reports whether the loops must be guarded (see the [Limits.guardRanges]).
+func (l Limits) guarded() bool { return l.Timeout > 0 || l.MaxIterations > 0 }
+
+// rangeGuard returns the function checking the loop (the value to range over, returned as-is) before it starts: the
+// total number of the iterations of the render must not exceed the limit, and the render must not time out. The
+// function is bound to a single render (started now).
+func (l Limits) rangeGuard() func(v any) (any, error) {
+	var (
+		total    uint64 // the render is executed by a single goroutine
+		deadline time.Time
+	)
+
+	if l.Timeout > 0 {
+		deadline = time.Now().Add(l.Timeout)
+	}
+
+	return func(v any) (any, error) {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, ErrRenderTimeout
+		}
+
+		if l.MaxIterations == 0 {
+			return v, nil
+		}
+
+		var n uint64
+
+		switch rv := reflect.ValueOf(v); rv.Kind() { //nolint:exhaustive // the other values can't be ranged over
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = uint64(max(rv.Int(), 0))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n = rv.Uint()
+		case reflect.Array, reflect.Slice, reflect.Map, reflect.Chan:
+			n = uint64(rv.Len()) //nolint:gosec // never negative
+		}
+
+		if total += n; total > uint64(l.MaxIterations) || total < n /* overflow */ {
+			return nil, fmt.Errorf("%w: more than %d", ErrMaxIterationsExceeded, l.MaxIterations)
+		}
+
+		return v, nil
+	}
+}
+
+// guardRanges passes the pipeline of every `{{ range }}` action in the templates through the [Limits.rangeGuard]
+// function (registered as the [rangeGuardName]), like `{{ range $i := .Items | _rangeGuard }}`.
+func (l Limits) guardRanges(trees []*parse.Tree) {
+	for _, t := range trees {
+		if t != nil && t.Root != nil {
+			walkRanges(t.Root, func(n *parse.RangeNode) {
+				n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+					NodeType: parse.NodeCommand,
+					Pos:      n.Pos,
+					Args:     []parse.Node{parse.NewIdentifier(rangeGuardName).SetPos(n.Pos)},
+				})
+			})
+		}
+	}
+}
+
+// walkRanges calls the fn for each range node in the tree (including the nested ones).
+func walkRanges(node parse.Node, fn func(*parse.RangeNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, child := range n.Nodes {
+				walkRanges(child, fn)
+			}
+		}
+	case *parse.RangeNode:
+		fn(n)
+		walkRanges(n.List, fn)
+		walkRanges(n.ElseList, fn)
+	case *parse.IfNode:
+		walkRanges(n.List, fn)
+		walkRanges(n.ElseList, fn)
+	case *parse.WithNode:
+		walkRanges(n.List, fn)
+		walkRanges(n.ElseList, fn)
+	}
+}
+
+// check statically validates the parsed templates (the root one and the defined ones) against the depth and
+// includes limits.
+func (l Limits) check(root string, trees []*parse.Tree) error {
+	if l.MaxDepth == 0 && l.MaxIncludes == 0 {
+		return nil
+	}
+
+	var (
+		calls    = make(map[string][]string) // map[template_name]called_template_names
+		includes uint
+	)
+
+	for _, t := range trees {
+		if t == nil || t.Root == nil {
+			continue
+		}
+
+		walkTemplateCalls(t.Root, func(n *parse.TemplateNode) {
+			calls[t.Name] = append(calls[t.Name], n.Name)
+			includes++
+		})
+	}
+
+	if l.MaxIncludes > 0 && includes > l.MaxIncludes {
+		return fmt.Errorf("%w: %d (the limit is %d)", ErrMaxIncludesExceeded, includes, l.MaxIncludes)
+	}
+
+	if l.MaxDepth > 0 {
+		if depth, ok := callDepth(root, calls, map[string]bool{}); !ok {
+			return fmt.Errorf("%w: recursive template calls are not allowed", ErrMaxDepthExceeded)
+		} else if depth > l.MaxDepth {
+			return fmt.Errorf("%w: %d (the limit is %d)", ErrMaxDepthExceeded, depth, l.MaxDepth)
+		}
+	}
+
+	return nil
+}
+
+// walkTemplateCalls calls the fn for each template call node in the tree.
+func walkTemplateCalls(node parse.Node, fn func(*parse.TemplateNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, child := range n.Nodes {
+				walkTemplateCalls(child, fn)
+			}
+		}
+	case *parse.TemplateNode:
+		fn(n)
+	case *parse.IfNode:
+		walkTemplateCalls(n.List, fn)
+		walkTemplateCalls(n.ElseList, fn)
+	case *parse.RangeNode:
+		walkTemplateCalls(n.List, fn)
+		walkTemplateCalls(n.ElseList, fn)
+	case *parse.WithNode:
+		walkTemplateCalls(n.List, fn)
+		walkTemplateCalls(n.ElseList, fn)
+	}
+}
+
+// callDepth returns the maximal nesting of the template calls starting from the named template. If the calls are
+// recursive, false is returned.
+func callDepth(name string, calls map[string][]string, visiting map[string]bool) (uint, bool) {
+	if visiting[name] {
+		return 0, false
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	var maxDepth uint
+
+	for _, called := range calls[name] {
+		depth, ok := callDepth(called, calls, visiting)
+		if !ok {
+			return 0, false
+		}
+
+		maxDepth = max(maxDepth, depth+1)
+	}
+
+	return maxDepth, true
+}
+
+// executor is the parsed template (from the `text/template` or `html/template` package).
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// execute executes the template with the timeout (if set), writing the output into the writer. On the timeout,
+// the error is returned immediately (without waiting for the render to finish), and the writer is never written
+// to after that, so it may be reused.
+func (l Limits) execute(w io.Writer, tmpl executor, props Props) error {
+	if l.Timeout <= 0 {
+		return tmpl.Execute(w, props)
+	}
+
+	var (
+		done = make(chan error, 1)
+		dw   = &deadlineWriter{w: w, deadline: time.Now().Add(l.Timeout)}
+		t    = time.NewTimer(l.Timeout)
+	)
+
+	defer t.Stop()
+
+	go func() { done <- tmpl.Execute(dw, props) }()
+
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrRenderTimeout) {
+			return ErrRenderTimeout
+		}
+
+		return err
+	case <-t.C:
+		dw.close() // waits for the in-flight write (if any)
+
+		return ErrRenderTimeout
+	}
+}
+
+// deadlineWriter is a writer that refuses to write after the deadline (or after closing), so the template
+// execution stops as soon as it produces any output after the timeout.
+type deadlineWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	deadline time.Time
+	closed   bool
+}
+
+var _ io.Writer = (*deadlineWriter)(nil) // ensure the interface is implemented
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed || time.Now().After(w.deadline) {
+		return 0, ErrRenderTimeout
+	}
+
+	return w.w.Write(p)
+}
+
+// close prevents any further writes to the underlying writer.
+func (w *deadlineWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+}
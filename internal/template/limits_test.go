@@ -0,0 +1,85 @@
+package template_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestRenderWith_Limits(t *testing.T) {
+	t.Parallel()
+
+	const (
+		nested    = `{{ define "a" }}A{{ template "b" }}{{ end }}{{ define "b" }}B{{ end }}{{ template "a" }}`
+		recursive = `{{ define "a" }}{{ if . }}{{ template "a" }}{{ end }}{{ end }}{{ template "a" }}`
+		includes  = `{{ define "a" }}a{{ end }}{{ range 3 }}{{ template "a" }}{{ end }}{{ template "a" }}`
+		slow      = `{{ range 100000000 }}x{{ end }}`
+		silent    = `{{ range 1000000000 }}{{ end }}`                // writes nothing, so never stopped by the writer
+		loops     = `{{ range 10 }}{{ range 10 }}{{ end }}{{ end }}` // 10 + 10 * 10 iterations
+	)
+
+	for name, tt := range map[string]struct {
+		giveContent string
+		giveLimits  template.Limits
+		want        string
+		wantErr     error
+	}{
+		"no limits":           {giveContent: nested, want: "AB"},
+		"depth ok":            {giveContent: nested, giveLimits: template.Limits{MaxDepth: 2}, want: "AB"},
+		"depth exceeded":      {giveContent: nested, giveLimits: template.Limits{MaxDepth: 1}, wantErr: template.ErrMaxDepthExceeded},
+		"recursion":           {giveContent: recursive, giveLimits: template.Limits{MaxDepth: 100}, wantErr: template.ErrMaxDepthExceeded},
+		"includes ok":         {giveContent: includes, giveLimits: template.Limits{MaxIncludes: 2}, want: "aaaa"},
+		"includes exceeded":   {giveContent: includes, giveLimits: template.Limits{MaxIncludes: 1}, wantErr: template.ErrMaxIncludesExceeded},
+		"timeout not reached": {giveContent: nested, giveLimits: template.Limits{Timeout: time.Second}, want: "AB"},
+		"timeout":             {giveContent: slow, giveLimits: template.Limits{Timeout: 10 * time.Millisecond}, wantErr: template.ErrRenderTimeout},
+		"iterations ok":       {giveContent: `{{ range 3 }}a{{ end }}`, giveLimits: template.Limits{MaxIterations: 3}, want: "aaa"},
+		"iterations exceeded": {giveContent: `{{ range 4 }}a{{ end }}`, giveLimits: template.Limits{MaxIterations: 3}, wantErr: template.ErrMaxIterationsExceeded},
+		"silent loop":         {giveContent: silent, giveLimits: template.Limits{Timeout: time.Minute, MaxIterations: 1000}, wantErr: template.ErrMaxIterationsExceeded},
+		"nested loops ok":     {giveContent: loops, giveLimits: template.Limits{MaxIterations: 110}, want: ""},
+		"nested loops":        {giveContent: loops, giveLimits: template.Limits{MaxIterations: 109}, wantErr: template.ErrMaxIterationsExceeded},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			content, err := template.RenderWith(tt.giveContent, template.Props{}, template.Options{Limits: tt.giveLimits})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, content)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, content)
+		})
+	}
+}
+
+// TestRenderWith_Limits_RunawayLoop checks that the render goroutine of the loop that writes nothing does not keep
+// running after the timeout (not parallel, so the goroutines of the other tests are not counted).
+func TestRenderWith_Limits_RunawayLoop(t *testing.T) {
+	var before = runtime.NumGoroutine()
+
+	for range 10 {
+		_, err := template.RenderWith(
+			`{{ range 1000000000 }}{{ range 0 }}{{ end }}{{ end }}`, // the inner loop is started on every iteration
+			template.Props{},
+			template.Options{Limits: template.Limits{Timeout: 10 * time.Millisecond}},
+		)
+
+		require.ErrorIs(t, err, template.ErrRenderTimeout)
+	}
+
+	// polled here, since the [assert.Eventually] runs the condition in its own goroutine
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before) // the render goroutines are stopped
+}
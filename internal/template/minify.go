@@ -1,6 +1,12 @@
+//go:build !slim
+
 package template
 
 import (
+	"bytes"
+	"io"
+	"regexp"
+
 	"github.com/tdewolff/minify/v2"
 	"github.com/tdewolff/minify/v2/css"
 	"github.com/tdewolff/minify/v2/html"
@@ -8,16 +14,69 @@ import (
 	"github.com/tdewolff/minify/v2/svg"
 )
 
-var htmlMinify = func() *minify.M { //nolint:gochecknoglobals
+// Minifier minifies HTML data, including inline CSS, SVG and JS (depending on the options). It is safe for
+// concurrent use.
+type Minifier struct {
+	m       *minify.M
+	keepESI bool
+}
+
+// esiEmptyElementRe matches the ESI empty elements, which the HTML minifier does not keep self-closed (and the ESI
+// processors require them to be).
+var esiEmptyElementRe = regexp.MustCompile(`<(esi:(?:include|comment))(\s[^>]*?)?\s*/?>`) //nolint:gochecknoglobals
+
+// NewMinifier creates a new HTML minifier with the given options.
+func NewMinifier(opts MinifyOptions) *Minifier {
 	var m = minify.New()
 
-	m.AddFunc("text/css", css.Minify)
-	m.Add("text/html", &html.Minifier{KeepDocumentTags: true, KeepEndTags: true, KeepQuotes: true})
+	if !opts.KeepInlineCSS {
+		m.AddFunc("text/css", css.Minify)
+	}
+
+	m.Add("text/html", &html.Minifier{
+		KeepComments:        opts.KeepComments,
+		KeepSpecialComments: opts.KeepConditionalComments,
+		KeepDocumentTags:    true,
+		KeepEndTags:         true,
+		KeepQuotes:          true,
+	})
 	m.AddFunc("image/svg+xml", svg.Minify)
-	m.AddFunc("application/javascript", js.Minify)
 
-	return m
-}()
+	if !opts.KeepInlineJS {
+		m.AddFunc("application/javascript", js.Minify)
+	}
+
+	return &Minifier{m: m, keepESI: opts.KeepESI}
+}
+
+// String minifies HTML data.
+func (m *Minifier) String(data string) (string, error) {
+	out, err := m.m.String("text/html", data)
+	if err != nil || !m.keepESI {
+		return out, err
+	}
+
+	return esiEmptyElementRe.ReplaceAllString(out, "<$1$2/>"), nil
+}
+
+// To minifies HTML data from the buffer into the writer. The buffer content is read in place (without copying).
+func (m *Minifier) To(w io.Writer, data *bytes.Buffer) error {
+	if !m.keepESI {
+		return m.m.Minify("text/html", w, data)
+	}
+
+	var out bytes.Buffer // the ESI elements are fixed in the whole output
+
+	if err := m.m.Minify("text/html", &out, data); err != nil {
+		return err
+	}
+
+	_, err := w.Write(esiEmptyElementRe.ReplaceAll(out.Bytes(), []byte("<$1$2/>")))
+
+	return err
+}
+
+var htmlMinify = NewMinifier(MinifyOptions{}) //nolint:gochecknoglobals
 
 // MiniHTML minifies HTML data, including inline CSS, SVG and JS.
-func MiniHTML(data string) (string, error) { return htmlMinify.String("text/html", data) }
+func MiniHTML(data string) (string, error) { return htmlMinify.String(data) }
@@ -0,0 +1,10 @@
+package template
+
+// MinifyOptions are the HTML minifier options. The zero value means the most aggressive minification.
+type MinifyOptions struct {
+	KeepComments            bool // keep all the HTML comments
+	KeepConditionalComments bool // keep the IE conditional comments (like `<!--[if IE]>...<![endif]-->`)
+	KeepInlineCSS           bool // do not minify the inline CSS (the style elements and attributes)
+	KeepInlineJS            bool // do not minify the inline JS
+	KeepESI                 bool // keep the ESI empty elements (like `<esi:include src="..."/>`) self-closed
+}
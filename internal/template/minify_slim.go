@@ -0,0 +1,28 @@
+//go:build slim
+
+package template
+
+import (
+	"bytes"
+	"io"
+)
+
+// Minifier is a no-op in the slim build (the minifier dependencies are excluded to reduce the binary size), so the
+// HTML data is returned as is. It is safe for concurrent use.
+type Minifier struct{}
+
+// NewMinifier creates a new (no-op) HTML minifier, the options are ignored.
+func NewMinifier(MinifyOptions) *Minifier { return &Minifier{} }
+
+// String returns HTML data as is.
+func (*Minifier) String(data string) (string, error) { return data, nil }
+
+// To writes HTML data from the buffer into the writer as is.
+func (*Minifier) To(w io.Writer, data *bytes.Buffer) error {
+	_, err := data.WriteTo(w)
+
+	return err
+}
+
+// MiniHTML returns HTML data as is.
+func MiniHTML(data string) (string, error) { return data, nil }
@@ -1,10 +1,14 @@
+//go:build !slim
+
 package template_test
 
 import (
+	"bytes"
 	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"gh.tarampamp.am/error-pages/internal/template"
 )
@@ -92,3 +96,75 @@ func TestMiniHTML(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestNewMinifier(t *testing.T) {
+	t.Parallel()
+
+	const give = `<html>
+<head>
+	<!--[if IE]><p>IE only</p><![endif]-->
+	<!-- comment -->
+	<style> .foo { color: #ff0000; } </style>
+	<script> let foo = 1; </script>
+</head>
+</html>`
+
+	for name, tt := range map[string]struct {
+		giveOpts template.MinifyOptions
+		want     string
+	}{
+		"default": {
+			want: `<html><head><style>.foo{color:red}</style><script>let foo=1</script></head></html>`,
+		},
+		"keep comments": {
+			giveOpts: template.MinifyOptions{KeepComments: true},
+			want: `<html><head><!--[if IE]><p>IE only</p><![endif]--><!-- comment --><style>.foo{color:red}</style>` +
+				`<script>let foo=1</script></head></html>`,
+		},
+		"keep conditional comments": {
+			giveOpts: template.MinifyOptions{KeepConditionalComments: true},
+			want: `<html><head><!--[if IE]><p>IE only</p><![endif]--><style>.foo{color:red}</style>` +
+				`<script>let foo=1</script></head></html>`,
+		},
+		"keep css and js": {
+			giveOpts: template.MinifyOptions{KeepInlineCSS: true, KeepInlineJS: true},
+			want:     `<html><head><style> .foo { color: #ff0000; } </style><script> let foo = 1; </script></head></html>`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got, err = template.NewMinifier(tt.giveOpts).String(give)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMinifier_KeepESI(t *testing.T) {
+	t.Parallel()
+
+	const give = `<div>
+	<esi:include src="/fragment/503" onerror="continue" />
+	<esi:remove> <p>Fallback</p> </esi:remove>
+	<esi:comment text="the banner"/>
+</div>`
+
+	var minifier = template.NewMinifier(template.MinifyOptions{KeepESI: true})
+
+	got, err := minifier.String(give)
+	require.NoError(t, err)
+	assert.Equal(t, `<div><esi:include src="/fragment/503" onerror="continue"/><esi:remove><p>Fallback</p></esi:remove>`+
+		`<esi:comment text="the banner"/></div>`, got)
+
+	var out bytes.Buffer
+
+	require.NoError(t, minifier.To(&out, bytes.NewBufferString(give)))
+	assert.Equal(t, got, out.String())
+
+	// without the option, the empty elements are not self-closed
+	got, err = template.NewMinifier(template.MinifyOptions{}).String(give)
+	require.NoError(t, err)
+	assert.NotContains(t, got, "/>")
+}
@@ -0,0 +1,20 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// toNumber converts the value (any integer, the float without the fractional part, or the numeric string) into
+// the integer number.
+func toNumber(v any) (int64, error) {
+	var s = strings.TrimSpace(fmt.Sprint(v)) // `503` for the int, uint16, float64 (503.0), or string values
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("wrong number value [%v]: %w", v, err)
+	}
+
+	return n, nil
+}
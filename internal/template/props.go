@@ -1,15 +1,62 @@
 package template
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
 
 type Props struct {
-	Code               uint16 `token:"code"`          // http status code
-	Message            string `token:"message"`       // status message
-	Description        string `token:"description"`   // status description
-	RequestID          string `token:"request_id"`    // unique request ID: {SERVER_ICAO}-{upstream_id} or {SERVER_ICAO}-{random}-{uuidv7}
-	Host               string `token:"host"`          // the value of the `Host` header
-	ShowRequestDetails bool   `token:"show_details"`  // (config) show request details?
-	L10nDisabled       bool   `token:"l10n_disabled"` // (config) disable localization feature?
+	Code               uint16 `token:"code"`                // http status code
+	Message            string `token:"message"`             // status message
+	Description        string `token:"description"`         // status description
+	RequestID          string `token:"request_id"`          // unique request ID: {prefix}-{upstream_id}, CF-Ray, or generated by the layout
+	Host               string `token:"host"`                // the value of the `Host` header
+	ClientIP           string `token:"client_ip"`           // the client IP address (respecting the trusted proxies)
+	AcceptLanguage     string `token:"accept_language"`     // the value of the `Accept-Language` header
+	SecCHUA            string `token:"sec_ch_ua"`           // the value of the `Sec-CH-UA` header (client hints)
+	SecCHUAPlatform    string `token:"sec_ch_ua_platform"`  // the value of the `Sec-CH-UA-Platform` header (client hints)
+	OriginalURI        string `token:"original_uri"`        // the original request URI (e.g. `X-Forwarded-Uri`)
+	ForwardedFor       string `token:"forwarded_for"`       // the client addresses chain (the `X-Forwarded-For` header)
+	WWWAuthenticate    string `token:"www_authenticate"`    // the `WWW-Authenticate` challenges (for 401 responses only)
+	Locale             string `token:"locale"`              // the detected client locale (empty if unknown)
+	TextDirection      string `token:"text_direction"`      // the text direction for the locale (`ltr` or `rtl`)
+	LangCode           string `token:"lang_code"`           // the language of the page (the locale, or `en` by default)
+	Charset            string `token:"charset"`             // the charset of the response (`utf-8` by default)
+	Datacenter         string `token:"datacenter"`          // (config) the datacenter code (also used in the request IDs)
+	Timezone           string `token:"timezone"`            // (config) the timezone for the date and time (empty for UTC)
+	BodyPreview        string `token:"body_preview"`        // the sanitized and truncated request body (if enabled)
+	ErrorKind          string `token:"error_kind"`          // the error kind name from the `X-Error-Kind` header (if known)
+	TLSError           string `token:"tls_error"`           // the TLS error name reported by the proxy (if known)
+	Profile            string `token:"profile"`             // the selected response profile name (if any)
+	Banner             string `token:"banner"`              // the outage banner message (empty if not set)
+	BannerSeverity     string `token:"banner_severity"`     // the outage banner severity (`info`, `warning`, or `critical`)
+	MaintenanceStart   string `token:"maintenance_start"`   // the start of the active maintenance window (RFC 3339, UTC)
+	MaintenanceEnd     string `token:"maintenance_end"`     // the end of the active maintenance window (RFC 3339, UTC)
+	UpstreamCheckedAt  string `token:"upstream_checked_at"` // the time of the last upstream health check (RFC 3339, UTC)
+	UpstreamDuration   string `token:"upstream_duration"`   // the `X-Upstream-Duration` header value (seconds, like `30.0`)
+	CSPNonce           string `token:"csp_nonce"`           // the per-response CSP nonce (if the policy uses it)
+	OGImage            string `token:"og_image"`            // (config) the social preview image URL (if enabled)
+	OriginalStatus     uint16 `token:"original_status"`     // the code from the `X-Original-Status` header (0 if missing)
+	UpstreamHealthy    bool   `token:"upstream_healthy"`    // the last upstream health check succeeded?
+	ShowRequestDetails bool   `token:"show_details"`        // (config) show request details?
+	ShowOriginalStatus bool   `token:"show_original"`       // (config) include the original status into the payloads?
+	L10nDisabled       bool   `token:"l10n_disabled"`       // (config) disable localization feature?
+	ReducedMotion      bool   `token:"reduced_motion"`      // disable the animations (config, or the client hint)?
+	PrintFriendly      bool   `token:"print_friendly"`      // (config) include the print-friendly styles?
+	ESI                bool   `token:"esi"`                 // (config) the Edge Side Includes are enabled?
+	LiveStatus         bool   `token:"live_status"`         // (config) the live status updates are enabled?
+
+	ErrorDetails []ErrorDetail `token:"error_details"` // the details from the `X-Error-Detail` headers (if any)
+
+	Request Request // the per-request context of the template functions (not a token)
+	Flags   Flags   // the feature flags of the request (not a token)
+}
+
+// ErrorDetail is a single error detail passed by the upstream (like a validation error of the request field).
+type ErrorDetail struct {
+	Field   string `json:"field,omitempty"` // the related field (optional)
+	Message string `json:"message"`
 }
 
 // Values convert the Props struct into a map where each key is a token associated with its corresponding value.
@@ -24,3 +71,40 @@ func (p Props) Values() map[string]any {
 
 	return result
 }
+
+// Override sets the string tokens (like `message` or `description`) to the given values by the token names. If any
+// of the tokens is unknown or not a string, an error is returned and the props are not changed.
+func (p *Props) Override(tokens map[string]string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var (
+		v      = reflect.ValueOf(p).Elem()
+		fields = make(map[string]int, v.NumField())
+	)
+
+	for i := range v.NumField() {
+		if token, tagExists := v.Type().Field(i).Tag.Lookup("token"); tagExists && v.Field(i).Kind() == reflect.String {
+			fields[token] = i
+		}
+	}
+
+	var names = make([]string, 0, len(tokens))
+
+	for name := range tokens {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("token %q is unknown or can't be overridden", name)
+		}
+
+		names = append(names, name)
+	}
+
+	slices.Sort(names) // for the stable errors
+
+	for _, name := range names {
+		v.Field(fields[name]).SetString(tokens[name])
+	}
+
+	return nil
+}
@@ -17,15 +17,98 @@ func TestProps_Values(t *testing.T) {
 		Description:        "c",
 		RequestID:          "d",
 		Host:               "e",
+		ClientIP:           "f",
+		AcceptLanguage:     "l",
+		SecCHUA:            "m",
+		SecCHUAPlatform:    "n",
+		OriginalURI:        "ad",
+		ForwardedFor:       "ae",
+		WWWAuthenticate:    "g",
+		Locale:             "h",
+		TextDirection:      "i",
+		LangCode:           "y",
+		Charset:            "z",
+		Datacenter:         "o",
+		Timezone:           "j",
+		BodyPreview:        "k",
+		ErrorKind:          "u",
+		TLSError:           "aa",
+		Profile:            "ab",
+		Banner:             "p",
+		BannerSeverity:     "q",
+		MaintenanceStart:   "r",
+		MaintenanceEnd:     "s",
+		UpstreamCheckedAt:  "t",
+		UpstreamDuration:   "d",
+		CSPNonce:           "v",
+		OGImage:            "ac",
+		OriginalStatus:     2,
+		UpstreamHealthy:    true,
 		ShowRequestDetails: false,
+		ShowOriginalStatus: true,
 		L10nDisabled:       true,
+		ReducedMotion:      true,
+		PrintFriendly:      false,
+		ESI:                true,
+		LiveStatus:         true,
+
+		ErrorDetails: []template.ErrorDetail{{Field: "w", Message: "x"}},
 	}.Values(), map[string]any{
-		"code":          uint16(1),
-		"message":       "b",
-		"description":   "c",
-		"request_id":    "d",
-		"host":          "e",
-		"show_details":  false,
-		"l10n_disabled": true,
+		"code":                uint16(1),
+		"message":             "b",
+		"description":         "c",
+		"request_id":          "d",
+		"host":                "e",
+		"client_ip":           "f",
+		"accept_language":     "l",
+		"sec_ch_ua":           "m",
+		"sec_ch_ua_platform":  "n",
+		"original_uri":        "ad",
+		"forwarded_for":       "ae",
+		"www_authenticate":    "g",
+		"locale":              "h",
+		"text_direction":      "i",
+		"lang_code":           "y",
+		"charset":             "z",
+		"datacenter":          "o",
+		"timezone":            "j",
+		"body_preview":        "k",
+		"error_kind":          "u",
+		"tls_error":           "aa",
+		"profile":             "ab",
+		"banner":              "p",
+		"banner_severity":     "q",
+		"maintenance_start":   "r",
+		"maintenance_end":     "s",
+		"upstream_checked_at": "t",
+		"upstream_duration":   "d",
+		"csp_nonce":           "v",
+		"og_image":            "ac",
+		"original_status":     uint16(2),
+		"upstream_healthy":    true,
+		"show_details":        false,
+		"show_original":       true,
+		"l10n_disabled":       true,
+		"reduced_motion":      true,
+		"print_friendly":      false,
+		"esi":                 true,
+		"live_status":         true,
+		"error_details":       []template.ErrorDetail{{Field: "w", Message: "x"}},
 	})
 }
+
+func TestProps_Override(t *testing.T) {
+	t.Parallel()
+
+	var props = template.Props{Code: 403, Message: "Forbidden", Description: "Access is forbidden"}
+
+	assert.NoError(t, props.Override(nil))
+	assert.NoError(t, props.Override(map[string]string{"message": "Request Blocked", "banner": "WAF"}))
+	assert.Equal(t, template.Props{
+		Code: 403, Message: "Request Blocked", Description: "Access is forbidden", Banner: "WAF",
+	}, props)
+
+	assert.ErrorContains(t, props.Override(map[string]string{"description": "foo", "code": "404"}), `"code"`)
+	assert.ErrorContains(t, props.Override(map[string]string{"unknown": "foo"}), `"unknown"`)
+	assert.Equal(t, "Access is forbidden", props.Description) // not changed
+}
@@ -0,0 +1,65 @@
+package template
+
+import (
+	"net/textproto"
+	"slices"
+	"strings"
+)
+
+// maxRequestHeaderLength limits the length of the request header value available to the template functions.
+const maxRequestHeaderLength = 256
+
+type (
+	// Request is the per-request context of the template functions (like `header "X-Tenant"`). It's a part of the
+	// [Props] (so the pages are cached separately for each distinct context), but isn't exposed as a token.
+	Request struct {
+		// Headers are the allowlisted request headers, sorted by name (the missing ones have empty values).
+		Headers []RequestHeader
+	}
+
+	// RequestHeader is a single request header.
+	RequestHeader struct{ Name, Value string }
+)
+
+// NewRequest creates the request context with the allowlisted headers, using the getter to read their values. The
+// header names are canonicalized, and the values are trimmed and truncated.
+func NewRequest(allowlist []string, get func(name string) string) Request {
+	if len(allowlist) == 0 {
+		return Request{}
+	}
+
+	var headers = make([]RequestHeader, 0, len(allowlist))
+
+	for _, name := range allowlist {
+		if name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name)); name == "" {
+			continue
+		}
+
+		var value = strings.TrimSpace(get(name))
+
+		if len(value) > maxRequestHeaderLength {
+			value = strings.ToValidUTF8(value[:maxRequestHeaderLength], "")
+		}
+
+		headers = append(headers, RequestHeader{Name: name, Value: value})
+	}
+
+	// the stable order makes the cache key independent of the allowlist order
+	slices.SortFunc(headers, func(a, b RequestHeader) int { return strings.Compare(a.Name, b.Name) })
+
+	return Request{Headers: slices.CompactFunc(headers, func(a, b RequestHeader) bool { return a.Name == b.Name })}
+}
+
+// Header returns the value of the allowlisted request header (the name case is ignored). False is returned if the
+// header is not allowlisted.
+func (r Request) Header(name string) (string, bool) {
+	name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+
+	if i, found := slices.BinarySearchFunc(r.Headers, name, func(h RequestHeader, name string) int {
+		return strings.Compare(h.Name, name)
+	}); found {
+		return r.Headers[i].Value, true
+	}
+
+	return "", false
+}
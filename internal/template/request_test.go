@@ -0,0 +1,39 @@
+package template_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/template"
+)
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+
+	var headers = map[string]string{"X-Tenant": " acme ", "X-Long": strings.Repeat("a", 300)}
+
+	var request = template.NewRequest([]string{"x-tenant", "X-Plan", " X-TENANT", "", "x-long"}, func(name string) string {
+		return headers[name]
+	})
+
+	assert.Equal(t, []template.RequestHeader{
+		{Name: "X-Long", Value: strings.Repeat("a", 256)},
+		{Name: "X-Plan"},
+		{Name: "X-Tenant", Value: "acme"},
+	}, request.Headers)
+
+	value, ok := request.Header("x-tenant")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", value)
+
+	value, ok = request.Header("X-Plan")
+	assert.True(t, ok)
+	assert.Empty(t, value)
+
+	_, ok = request.Header("Authorization")
+	assert.False(t, ok)
+
+	assert.Empty(t, template.NewRequest(nil, nil))
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	htmlTemplate "html/template"
+	"io"
 	"maps"
 	"os"
 	"strconv"
 	"strings"
 	"text/template"
+	"text/template/parse"
 	"time"
 
 	"github.com/binaryYuki/error-pages/internal/appmeta"
@@ -105,35 +108,295 @@ var builtInFunctions = template.FuncMap{ //nolint:gochecknoglobals
 	//	`{{ escape "<test>" }}`	// `&lt;test&gt;`
 	"escape": html.EscapeString,
 
+	// returns a short hash of the file content (for cache busting; empty if the file cannot be read), the path is
+	// relative to the working directory:
+	//	`{{ hash "static/app.css" }}`	// `5d41402a`
+	"hash": fileHash,
+
 	// returns the content of the JS file with a script for automatic error page localization:
 	//	`{{ l10nScript }}`	// `Object.defineProperty(window, ...`
 	"l10nScript": l10n.L10n,
 }
 
+// Options are the rendering options.
+type Options struct {
+	// Limits restricts the resources the render may consume.
+	Limits Limits
+
+	// Escaping is the mode of the automatic escaping of the values written by the template actions.
+	Escaping Escaping
+
+	// Now is the clock used by the `now` and `nowUnix` functions (the current time is used if nil). A fixed clock
+	// makes the render deterministic (e.g. for the reproducible builds).
+	Now func() time.Time
+}
+
+// Render renders the template content using the given properties, without any resource limits and escaping.
 func Render(content string, props Props) (string, error) {
+	return RenderWith(content, props, Options{})
+}
+
+// RenderWith renders the template content using the given properties and options. The template is rejected (or the
+// render is aborted) when it exceeds the limits.
+func RenderWith(content string, props Props, opts Options) (string, error) {
+	tmpl, err := prepare(content, props, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+
+	if err = opts.Limits.execute(&buf, tmpl, props); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// RenderTo renders the template content directly into the writer (without buffering the whole output), so the
+// large pages can be streamed or rendered into the reused buffers. On the render error, the output written so far
+// is not reverted, but the writer is never written to after the function returns.
+func RenderTo(w io.Writer, content string, props Props, opts Options) error {
+	tmpl, err := prepare(content, props, opts)
+	if err != nil {
+		return err
+	}
+
+	return opts.Limits.execute(w, tmpl, props)
+}
+
+// prepare parses the template content (with the functions bound to the properties) and checks it against the
+// limits.
+func prepare(content string, props Props, opts Options) (executor, error) { //nolint:funlen
 	var fns = maps.Clone(builtInFunctions)
 
+	var locale = l10n.DefaultLocale // the locale for the date and time formatting
+
+	if !props.L10nDisabled && props.Locale != "" {
+		locale = props.Locale
+	}
+
+	tz, tzErr := loadLocation(props.Timezone)
+	if tzErr != nil {
+		return nil, fmt.Errorf("wrong timezone: %w", tzErr)
+	}
+
+	var now = time.Now
+
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	var deadline time.Time // the zero time means no deadline
+
+	if opts.Limits.Timeout > 0 {
+		deadline = now().Add(opts.Limits.Timeout)
+	}
+
 	maps.Copy(fns, template.FuncMap{ // add custom functions
 		"hide_details": func() bool { return !props.ShowRequestDetails }, // inverted logic
 		"l10n_enabled": func() bool { return !props.L10nDisabled },       // inverted logic
+
+		// the current time in the configured timezone:
+		//	`{{ now }}`	// `2024-03-05 14:03:00 +0100 CET`
+		"now": func() time.Time { return now().In(tz) },
+
+		// the current time in unix format (overrides the built-in function to respect the clock option)
+		"nowUnix": func() int64 { return now().Unix() },
+
+		// the time the render must complete by, in the configured timezone (the zero time without the timeout):
+		//	`{{ if not deadline.IsZero }}{{ deadline | formatTime }}{{ end }}`	// `14:03 CET`
+		"deadline": func() time.Time { return deadline.In(tz) },
+
+		// returns the value of the allowlisted request header (empty if missing), the pages are cached per the
+		// allowlisted headers values; using the header that is not allowlisted is an error:
+		//	`{{ header "X-Tenant" }}`	// `acme`
+		"header": func(name string) (string, error) {
+			if value, ok := props.Request.Header(name); ok {
+				return value, nil
+			}
+
+			return "", fmt.Errorf("request header %q is not allowlisted", name)
+		},
+
+		// returns the value of the feature flag (empty if not set); the flags rolled out to the share of the clients
+		// (like `25%`) are `true` or `false`:
+		//	`{{ flag "theme" }}`	// `dark`
+		"flag": func(name string) string { v, _ := props.Flags.Get(name); return v }, //nolint:nlreturn
+
+		// reports whether the feature flag is enabled (`true`, `on`, `yes`, `enabled`, or `1`):
+		//	`{{ if flagOn "new_503_copy" }}...{{ end }}`
+		"flagOn": props.Flags.On,
+
+		// emits the ESI include tag (nothing if the Edge Side Includes are disabled), processed by the ESI proxy; the
+		// optional second argument is the alternative source:
+		//	`{{ esiInclude "/fragment/503" }}`	// `<esi:include src="/fragment/503" onerror="continue"/>`
+		"esiInclude": func(src string, alt ...string) htmlTemplate.HTML {
+			if !props.ESI {
+				return ""
+			}
+
+			var tag = `<esi:include src="` + html.EscapeString(src) + `"`
+
+			if len(alt) > 0 && alt[0] != "" {
+				tag += ` alt="` + html.EscapeString(alt[0]) + `"`
+			}
+
+			return htmlTemplate.HTML(tag + ` onerror="continue"/>`) //nolint:gosec // the attributes are escaped
+		},
+
+		// formats the date (time, unix timestamp, or RFC 3339 string) using the client locale layout:
+		//	`{{ formatDate now }}`	// `05.03.2024` (for the `de` locale)
+		"formatDate": func(v any) (string, error) {
+			t, err := toTime(v, tz)
+
+			return t.Format(l10n.DateLayout(locale)), err
+		},
+
+		// formats the time using the client locale layout (with the timezone abbreviation):
+		//	`{{ formatTime now }}`	// `14:03 CET`
+		"formatTime": func(v any) (string, error) {
+			t, err := toTime(v, tz)
+
+			return t.Format(l10n.TimeLayout(locale) + " MST"), err
+		},
+
+		// converts the time into the given timezone (IANA name):
+		//	`{{ now | inTimezone "Asia/Tokyo" | formatTime }}`	// `22:03 JST`
+		"inTimezone": func(name string, v any) (time.Time, error) {
+			loc, err := loadLocation(name)
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			t, err := toTime(v, loc)
+
+			return t.In(loc), err
+		},
+
+		// translates the phrase into the client locale (the phrase is used as-is if the translation is missing) and
+		// interpolates the optional key-value arguments (the ICU-style plural forms are supported):
+		//	`{{ translate "Error" }}`	// `Fehler` (for the `de` locale)
+		//	`{{ translate "Retry in {n, plural, one {# second} other {# seconds}}" "n" 30 }}`	// `Retry in 30 seconds`
+		"translate": func(phrase string, args ...any) string {
+			var locale = l10n.DefaultLocale
+
+			if !props.L10nDisabled && props.Locale != "" {
+				locale = props.Locale
+
+				if translated, ok := l10n.Translate(props.Locale, phrase); ok {
+					phrase = translated
+				}
+			}
+
+			if len(args) == 0 {
+				return phrase
+			}
+
+			var values = make(map[string]any, len(args)/2) //nolint:mnd
+
+			for i := 0; i+1 < len(args); i += 2 {
+				values[fmt.Sprint(args[i])] = args[i+1]
+			}
+
+			return l10n.Format(locale, phrase, values)
+		},
+
+		// formats the integer number using the client locale digit grouping:
+		//	`{{ formatNumber 1234567 }}`	// `1.234.567` (for the `de` locale)
+		"formatNumber": func(v any) (string, error) {
+			n, err := toNumber(v)
+
+			return l10n.FormatNumber(locale, n), err
+		},
+
+		// spells out the number (0..999) in words of the client locale, the digits are used for the numbers out of
+		// the range:
+		//	`{{ code | spellNumber }}`	// `five hundred three`
+		"spellNumber": func(v any) (string, error) {
+			n, err := toNumber(v)
+			if err != nil {
+				return "", err
+			}
+
+			if spelled, ok := l10n.SpellNumber(locale, n); ok {
+				return spelled, nil
+			}
+
+			return l10n.FormatNumber(locale, n), nil
+		},
+
+		// formats the integer number as the ordinal one using the client locale conventions:
+		//	`{{ ordinal 3 }}`	// `3rd` (`3.` for the `de` locale)
+		"ordinal": func(v any) (string, error) {
+			n, err := toNumber(v)
+
+			return l10n.Ordinal(locale, n), err
+		},
 	})
 
+	if opts.Limits.guarded() {
+		fns[rangeGuardName] = opts.Limits.rangeGuard()
+	}
+
 	// allow the direct access to the properties tokens, e.g. `{{ service_port | json }}`
 	// instead of `{{ .service_port | json }}`
 	for k, v := range props.Values() {
 		fns[k] = func() any { return v }
 	}
 
+	if opts.Escaping == EscapeHTML {
+		maps.Copy(fns, htmlOverrides)
+
+		tmpl, tErr := htmlTemplate.New("template").Funcs(htmlTemplate.FuncMap(fns)).Parse(content)
+		if tErr != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", tErr)
+		}
+
+		var trees = make([]*parse.Tree, 0, len(tmpl.Templates()))
+
+		for _, t := range tmpl.Templates() {
+			trees = append(trees, t.Tree)
+		}
+
+		if err := opts.Limits.check(tmpl.Name(), trees); err != nil {
+			return nil, err
+		}
+
+		if opts.Limits.guarded() {
+			opts.Limits.guardRanges(trees)
+		}
+
+		return tmpl, nil
+	}
+
+	maps.Copy(fns, escapers)
+
 	tmpl, tErr := template.New("template").Funcs(fns).Parse(content)
 	if tErr != nil {
-		return "", fmt.Errorf("failed to parse template: %w", tErr)
+		return nil, fmt.Errorf("failed to parse template: %w", tErr)
 	}
 
-	var buf strings.Builder
+	switch opts.Escaping {
+	case EscapeJSON:
+		escapeActions(tmpl, jsonEscaperName, "json")
+	case EscapeXML:
+		escapeActions(tmpl, xmlEscaperName, "escape")
+	}
 
-	if err := tmpl.Execute(&buf, props); err != nil {
-		return "", err
+	var trees = make([]*parse.Tree, 0, len(tmpl.Templates()))
+
+	for _, t := range tmpl.Templates() {
+		trees = append(trees, t.Tree)
 	}
 
-	return buf.String(), nil
+	if err := opts.Limits.check(tmpl.Name(), trees); err != nil {
+		return nil, err
+	}
+
+	if opts.Limits.guarded() {
+		opts.Limits.guardRanges(trees)
+	}
+
+	return tmpl, nil
 }
@@ -1,6 +1,7 @@
 package template_test
 
 import (
+	"bytes"
 	"os"
 	"strconv"
 	"testing"
@@ -116,6 +117,64 @@ func TestRender(t *testing.T) {
 			wantResult:   `{"code": "201", "message": {"here":[ " Yeah " ]}}`,
 		},
 
+		"fn translate": {
+			giveTemplate: `{{ translate "Error" }} {{ translate "Unknown phrase" }}`,
+			giveProps:    template.Props{Locale: "de"},
+			wantResult:   "Fehler Unknown phrase",
+		},
+		"fn translate (l10n disabled)": {
+			giveTemplate: `{{ translate "Error" }}`,
+			giveProps:    template.Props{Locale: "de", L10nDisabled: true},
+			wantResult:   "Error",
+		},
+		"fn translate (with arguments)": {
+			giveTemplate: `{{ translate "Retry in {n, plural, one {# second} other {# seconds}}" "n" 1 }}`,
+			giveProps:    template.Props{Locale: "de"},
+			wantResult:   "Retry in 1 second",
+		},
+		"fn formatDate": {
+			giveTemplate: `{{ formatDate 1709647380 }}`,
+			giveProps:    template.Props{Locale: "de", Timezone: "Europe/Berlin"},
+			wantResult:   "05.03.2024",
+		},
+		"fn formatTime": {
+			giveTemplate: `{{ formatTime "2024-03-05T14:03:00Z" }}`,
+			giveProps:    template.Props{Timezone: "Europe/Berlin"},
+			wantResult:   "3:03 PM CET",
+		},
+		"fn inTimezone": {
+			giveTemplate: `{{ 1709647380 | inTimezone "Asia/Tokyo" | formatTime }}`,
+			giveProps:    template.Props{Locale: "fr"},
+			wantResult:   "23:03 JST",
+		},
+		"fn formatDate (wrong value)": {
+			giveTemplate: `{{ formatDate "foo" }}`,
+			wantErrMsg:   "wrong time value",
+		},
+		"fn spellNumber": {
+			giveTemplate: `{{ code | spellNumber }} / {{ spellNumber 1234 }}`,
+			giveProps:    template.Props{Code: 503},
+			wantResult:   "five hundred three / 1,234",
+		},
+		"fn spellNumber (de)": {
+			giveTemplate: `{{ code | spellNumber }}`,
+			giveProps:    template.Props{Code: 503, Locale: "de"},
+			wantResult:   "fünfhundertdrei",
+		},
+		"fn formatNumber and ordinal": {
+			giveTemplate: `{{ formatNumber 1234567 }} {{ ordinal "3" }}`,
+			giveProps:    template.Props{Locale: "de"},
+			wantResult:   "1.234.567 3.",
+		},
+		"fn ordinal (wrong value)": {
+			giveTemplate: `{{ ordinal "foo" }}`,
+			wantErrMsg:   "wrong number value",
+		},
+		"wrong timezone": {
+			giveTemplate: `{{ now }}`,
+			giveProps:    template.Props{Timezone: "Foo/Bar"},
+			wantErrMsg:   "wrong timezone",
+		},
 		"fn l10n_enabled": {
 			giveTemplate: "{{ if l10n_enabled }}Y{{ else }}N{{ end }}",
 			giveProps:    template.Props{L10nDisabled: true},
@@ -201,3 +260,103 @@ func TestRender(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderWith_Now(t *testing.T) {
+	t.Parallel()
+
+	var fixed = time.Date(2024, 3, 5, 14, 3, 0, 0, time.UTC)
+
+	content, err := template.RenderWith(
+		`{{ nowUnix }} {{ now.Hour }}`,
+		template.Props{Timezone: "Europe/Berlin"},
+		template.Options{Now: func() time.Time { return fixed }},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, strconv.FormatInt(fixed.Unix(), 10)+" 15", content) // in the Berlin timezone
+}
+
+func TestRenderWith_RequestContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		fixed = time.Date(2024, 3, 5, 14, 3, 0, 0, time.UTC)
+		props = template.Props{Request: template.NewRequest([]string{"x-tenant", "X-Plan"}, func(name string) string {
+			return map[string]string{"X-Tenant": "acme"}[name]
+		})}
+	)
+
+	content, err := template.RenderWith(
+		`{{ header "x-tenant" }} [{{ header "X-Plan" }}] {{ deadline.Sub now }}`,
+		props,
+		template.Options{Now: func() time.Time { return fixed }, Limits: template.Limits{Timeout: 2 * time.Second}},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "acme [] 2s", content)
+
+	content, err = template.Render(`{{ deadline.IsZero }}`, props)
+
+	require.NoError(t, err)
+	assert.Equal(t, "true", content)
+
+	_, err = template.Render(`{{ header "Authorization" }}`, props)
+
+	assert.ErrorContains(t, err, `request header "Authorization" is not allowlisted`)
+}
+
+func TestRenderWith_FeatureFlags(t *testing.T) {
+	t.Parallel()
+
+	var props = template.Props{Flags: template.NewFlags(map[string]string{"theme": "dark", "new_503_copy": "true"})}
+
+	content, err := template.Render(
+		`{{ flag "theme" }} [{{ flag "missing" }}] {{ if flagOn "new_503_copy" }}new{{ else }}old{{ end }}`, props,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "dark [] new", content)
+
+	content, err = template.Render(`{{ if flagOn "new_503_copy" }}new{{ else }}old{{ end }}`, template.Props{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "old", content)
+}
+
+func TestRenderWith_ESIInclude(t *testing.T) {
+	t.Parallel()
+
+	const source = `{{ esiInclude "/fragment/503?a=1&b=2" }}{{ esiInclude "/banner" "/banner-fallback" }}`
+
+	content, err := template.RenderWith(source, template.Props{ESI: true}, template.Options{Escaping: template.EscapeHTML})
+
+	require.NoError(t, err)
+	assert.Equal(t, `<esi:include src="/fragment/503?a=1&amp;b=2" onerror="continue"/>`+
+		`<esi:include src="/banner" alt="/banner-fallback" onerror="continue"/>`, content)
+
+	content, err = template.RenderWith(source, template.Props{}, template.Options{Escaping: template.EscapeHTML})
+
+	require.NoError(t, err)
+	assert.Empty(t, content) // disabled
+}
+
+func TestRenderTo(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, template.RenderTo(&buf, `<p>{{ code }}: {{ message }}</p>`, template.Props{
+		Code:    503,
+		Message: "<Service Unavailable>",
+	}, template.Options{Escaping: template.EscapeHTML}))
+
+	assert.Equal(t, "<p>503: &lt;Service Unavailable&gt;</p>", buf.String())
+
+	buf.Reset()
+
+	assert.ErrorIs(t, template.RenderTo(&buf, `{{ range 100000000 }}x{{ end }}`, template.Props{}, template.Options{
+		Limits: template.Limits{Timeout: 10 * time.Millisecond},
+	}), template.ErrRenderTimeout)
+
+	assert.Error(t, template.RenderTo(&buf, `{{ foo`, template.Props{}, template.Options{}))
+}
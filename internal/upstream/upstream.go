@@ -0,0 +1,143 @@
+// Package upstream polls the health endpoint of the upstream service, so the error pages can tell whether the
+// service is still down or appears to be recovering.
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the result of the last health check.
+type Status struct {
+	Healthy   bool      // the upstream responded with a 2xx or 3xx status code
+	CheckedAt time.Time // when the check was completed
+}
+
+// Prober periodically checks the upstream health URL. It is safe for concurrent use.
+type Prober struct {
+	url      string
+	interval time.Duration
+	timeout  time.Duration
+	client   interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+
+	last        atomic.Pointer[Status]
+	checked     chan struct{} // closed once the first check is completed
+	checkedOnce sync.Once
+}
+
+// ValidateURL checks that the health URL is an absolute HTTP(S) URL.
+func ValidateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("wrong upstream health URL [%s]: %w", s, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("wrong upstream health URL [%s]: an absolute http(s) URL is expected", s)
+	}
+
+	return nil
+}
+
+// ValidateRecoveryURL checks that the check endpoint URL (as seen by the browsers) is an absolute path or an
+// absolute HTTP(S) URL.
+func ValidateRecoveryURL(s string) error {
+	if strings.HasPrefix(s, "/") && !strings.HasPrefix(s, "//") {
+		if _, err := url.Parse(s); err != nil {
+			return fmt.Errorf("wrong upstream recovery URL [%s]: %w", s, err)
+		}
+
+		return nil
+	}
+
+	if err := ValidateURL(s); err != nil {
+		return fmt.Errorf("wrong upstream recovery URL [%s]: an absolute path or http(s) URL is expected", s)
+	}
+
+	return nil
+}
+
+// NewProber creates a new prober for the health URL. The timeout limits a single check (it is capped by the
+// interval).
+func NewProber(healthURL string, interval, timeout time.Duration) *Prober {
+	if timeout <= 0 || timeout > interval {
+		timeout = interval
+	}
+
+	return &Prober{
+		url:      healthURL,
+		interval: interval,
+		timeout:  timeout,
+		checked:  make(chan struct{}),
+		client:   &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }},
+	}
+}
+
+// Status returns the result of the last check. The second value is false if nothing has been checked yet.
+func (p *Prober) Status() (Status, bool) {
+	if s := p.last.Load(); s != nil {
+		return *s, true
+	}
+
+	return Status{}, false
+}
+
+// Check performs a single health check and stores its result.
+func (p *Prober) Check(ctx context.Context) Status {
+	var status = Status{Healthy: p.healthy(ctx)}
+
+	status.CheckedAt = time.Now()
+	p.last.Store(&status)
+	p.checkedOnce.Do(func() { close(p.checked) })
+
+	return status
+}
+
+// Checked returns a channel that is closed once the first check is completed.
+func (p *Prober) Checked() <-chan struct{} { return p.checked }
+
+func (p *Prober) healthy(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, http.NoBody)
+	if err != nil {
+		return false
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10)) //nolint:mnd // allow the connection reuse
+
+	return resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest
+}
+
+// Run checks the upstream immediately and then on every interval, until the context is canceled.
+func (p *Prober) Run(ctx context.Context) {
+	var ticker = time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package upstream_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/upstream"
+)
+
+func TestValidateURL(t *testing.T) {
+	t.Parallel()
+
+	for give, wantErr := range map[string]bool{
+		"http://127.0.0.1:8080/healthz": false,
+		"https://example.com/health":    false,
+		"example.com/health":            true,
+		"ftp://example.com/health":      true,
+		"http:///health":                true,
+		"":                              true,
+		"http://[::1":                   true,
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			if err := upstream.ValidateURL(give); wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateRecoveryURL(t *testing.T) {
+	t.Parallel()
+
+	for give, wantErr := range map[string]bool{
+		"/check":                           false,
+		"/errors/check?from=page":          false,
+		"https://errors.example.com/check": false,
+		"check":                            true,
+		"//example.com/check":              true,
+		"javascript:alert(1)":              true,
+		"":                                 true,
+	} {
+		t.Run(give, func(t *testing.T) {
+			t.Parallel()
+
+			if err := upstream.ValidateRecoveryURL(give); wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProber_Check(t *testing.T) {
+	t.Parallel()
+
+	var code atomic.Int32
+
+	code.Store(http.StatusServiceUnavailable)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(int(code.Load()))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var prober = upstream.NewProber(srv.URL, time.Minute, time.Second)
+
+	_, checked := prober.Status()
+	assert.False(t, checked)
+
+	select {
+	case <-prober.Checked():
+		t.Fatal("the first check is not completed yet")
+	default:
+	}
+
+	var status = prober.Check(context.Background())
+
+	select {
+	case <-prober.Checked():
+	default:
+		t.Fatal("the first check is completed")
+	}
+
+	assert.False(t, status.Healthy)
+	assert.WithinDuration(t, time.Now(), status.CheckedAt, time.Second)
+
+	got, checked := prober.Status()
+	assert.True(t, checked)
+	assert.Equal(t, status, got)
+
+	for _, c := range []int{http.StatusOK, http.StatusNoContent, http.StatusFound} {
+		code.Store(int32(c)) //nolint:gosec
+
+		assert.True(t, prober.Check(context.Background()).Healthy, c)
+	}
+
+	code.Store(http.StatusNotFound)
+
+	assert.False(t, prober.Check(context.Background()).Healthy)
+
+	srv.Close() // connection refused
+
+	assert.False(t, prober.Check(context.Background()).Healthy)
+}
+
+func TestProber_Timeout(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var start = time.Now()
+
+	assert.False(t, upstream.NewProber(srv.URL, time.Minute, 20*time.Millisecond).Check(context.Background()).Healthy)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestProber_Run(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits.Add(1) }))
+
+	t.Cleanup(srv.Close)
+
+	var (
+		prober      = upstream.NewProber(srv.URL, 10*time.Millisecond, 0)
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	go func() { prober.Run(ctx); close(done) }()
+
+	require.Eventually(t, func() bool { return hits.Load() >= 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	status, checked := prober.Status()
+	assert.True(t, checked)
+	assert.True(t, status.Healthy)
+}
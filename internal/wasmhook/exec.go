@@ -0,0 +1,617 @@
+package wasmhook
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	maxPages     = 16        // the memory limit (1 MiB)
+	maxCallDepth = 512       // the nested calls limit
+	maxStack     = 1 << 16   // the operand stack values limit
+	maxFuel      = 1_000_000 // the executed instructions limit per call
+)
+
+// ErrOutOfFuel is returned when the hook executes too many instructions (e.g. loops forever).
+var ErrOutOfFuel = errors.New("out of fuel")
+
+// trap is the runtime error that aborts the execution (recovered by the caller).
+type trap struct{ err error }
+
+func trapf(format string, args ...any) trap { return trap{fmt.Errorf(format, args...)} }
+
+type (
+	// instance is the module instance with its own memory and globals (not safe for the concurrent use).
+	instance struct {
+		m       *module
+		mem     []byte
+		globals []uint64
+		stack   []uint64
+		fuel    int
+		depth   int
+	}
+
+	// label is the branch target of the structured instruction.
+	label struct {
+		arity  int // the number of values carried by the branch
+		height int // the operand stack height at the block start
+		target int // the position to continue from after the branch
+		end    int // the position of the `end` instruction
+		loop   bool
+	}
+)
+
+// invoke calls the function with the arguments and returns its results. Any trap is returned as an error.
+func (in *instance) invoke(idx uint32, args ...uint64) (results []uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var t trap
+
+			switch v := r.(type) {
+			case trap:
+				t = v
+			case error: // e.g. the stack index out of range on the malformed code
+				t = trap{v}
+			default:
+				t = trapf("%v", v)
+			}
+
+			err = fmt.Errorf("wasm trap: %w", t.err)
+		}
+	}()
+
+	in.fuel, in.depth, in.stack = maxFuel, 0, append(in.stack[:0], args...)
+
+	var f = &in.m.functions[idx]
+
+	in.call(idx)
+
+	return in.stack[len(in.stack)-len(f.typ.results):], nil
+}
+
+func (in *instance) push(v uint64) {
+	if len(in.stack) >= maxStack {
+		panic(trapf("stack overflow"))
+	}
+
+	in.stack = append(in.stack, v)
+}
+
+func (in *instance) pop() uint64 {
+	var v = in.stack[len(in.stack)-1]
+
+	in.stack = in.stack[:len(in.stack)-1]
+
+	return v
+}
+
+func (in *instance) pop32() uint32 { return uint32(in.pop()) } //nolint:gosec // the i32 values
+
+func (in *instance) push32(v uint32) { in.push(uint64(v)) }
+
+func (in *instance) pushBool(v bool) {
+	if v {
+		in.push(1)
+	} else {
+		in.push(0)
+	}
+}
+
+// addr returns the effective memory address of the access with the given size, or traps when out of bounds.
+func (in *instance) addr(offset uint32, size int) int {
+	var ea = uint64(in.pop32()) + uint64(offset)
+
+	if ea+uint64(size) > uint64(len(in.mem)) { //nolint:gosec // 1..8
+		panic(trapf("out of bounds memory access"))
+	}
+
+	return int(ea) //nolint:gosec // checked above
+}
+
+// call executes the function, taking the arguments from the stack and leaving the results on it.
+func (in *instance) call(idx uint32) { //nolint:funlen,gocognit,gocyclo
+	if int(idx) >= len(in.m.functions) {
+		panic(trapf("wrong function index %d", idx))
+	}
+
+	if in.depth++; in.depth > maxCallDepth {
+		panic(trapf("call stack exhausted"))
+	}
+
+	var (
+		f      = &in.m.functions[idx]
+		np     = len(f.typ.params)
+		locals = make([]uint64, np+len(f.locals))
+	)
+
+	copy(locals, in.stack[len(in.stack)-np:])
+	in.stack = in.stack[:len(in.stack)-np]
+
+	var (
+		r      = reader{b: f.code}
+		base   = len(in.stack)
+		nRes   = len(f.typ.results)
+		labels = []label{{arity: nRes, height: base, target: len(f.code)}}
+	)
+
+	// branch jumps to the n-th enclosing label (0 is the innermost one)
+	var branch = func(n uint32) {
+		if int(n) >= len(labels) {
+			panic(trapf("wrong branch depth %d", n))
+		}
+
+		var l = labels[len(labels)-1-int(n)]
+
+		copy(in.stack[l.height:], in.stack[len(in.stack)-l.arity:])
+		in.stack = in.stack[:l.height+l.arity]
+
+		if l.loop { // the loop keeps its label
+			labels = labels[:len(labels)-int(n)]
+		} else {
+			labels = labels[:len(labels)-1-int(n)]
+		}
+
+		r.pos = l.target
+	}
+
+	for len(labels) > 0 && !r.eof() {
+		if in.fuel--; in.fuel < 0 {
+			panic(trap{ErrOutOfFuel})
+		}
+
+		var pos = r.pos
+
+		op, _ := r.byte() // the body is checked by the scan, so the errors are not possible here
+
+		switch {
+		case op == opUnreachable:
+			panic(trapf("unreachable"))
+		case op == opNop:
+		case op == opBlock, op == opLoop, op == opIf:
+			var (
+				t, _     = r.byte()
+				arity, _ = blockArity(t)
+				b        = f.blocks[pos]
+				l        = label{arity: arity, target: b.endPos + 1, end: b.endPos}
+			)
+
+			if op == opLoop {
+				l.arity, l.target, l.loop = 0, r.pos, true // the branch to the loop starts the next iteration
+			}
+
+			if op == opIf && in.pop32() == 0 {
+				if b.elsePos == 0 {
+					r.pos = b.endPos + 1
+
+					break
+				}
+
+				r.pos = b.elsePos + 1
+			}
+
+			l.height = len(in.stack)
+			labels = append(labels, l)
+		case op == opElse: // the end of the `then` branch
+			r.pos = labels[len(labels)-1].end
+		case op == opEnd:
+			labels = labels[:len(labels)-1]
+		case op == opBr:
+			n, _ := r.u32()
+			branch(n)
+		case op == opBrIf:
+			n, _ := r.u32()
+
+			if in.pop32() != 0 {
+				branch(n)
+			}
+		case op == opBrTable:
+			var (
+				targets = f.brs[pos]
+				i       = in.pop32()
+			)
+
+			if int64(i) >= int64(len(targets)-1) {
+				i = uint32(len(targets) - 1) //nolint:gosec // the default one
+			}
+
+			branch(targets[i])
+		case op == opReturn:
+			branch(uint32(len(labels) - 1)) //nolint:gosec // limited by the code size
+		case op == opCall:
+			n, _ := r.u32()
+			in.call(n)
+		case op == opDrop:
+			in.pop()
+		case op == opSelect:
+			var c, b, a = in.pop32(), in.pop(), in.pop()
+
+			if c != 0 {
+				in.push(a)
+			} else {
+				in.push(b)
+			}
+		case op == opLocalGet:
+			n, _ := r.u32()
+			in.push(locals[n])
+		case op == opLocalSet:
+			n, _ := r.u32()
+			locals[n] = in.pop()
+		case op == opLocalTee:
+			n, _ := r.u32()
+			locals[n] = in.stack[len(in.stack)-1]
+		case op == opGlobalGet:
+			n, _ := r.u32()
+			in.push(in.globals[n])
+		case op == opGlobalSet:
+			n, _ := r.u32()
+
+			if !in.m.globals[n].mutable {
+				panic(trapf("global %d is immutable", n))
+			}
+
+			in.globals[n] = in.pop()
+		case isLoad(op):
+			_, _ = r.u32()
+			offset, _ := r.u32()
+			in.load(op, offset)
+		case isStore(op):
+			_, _ = r.u32()
+			offset, _ := r.u32()
+			in.store(op, offset)
+		case op == opMemorySize:
+			_, _ = r.byte()
+			in.push32(uint32(len(in.mem) / pageSize)) //nolint:gosec // limited by maxPages
+		case op == opMemoryGrow:
+			_, _ = r.byte()
+			in.push32(in.grow(in.pop32()))
+		case op == opI32Const:
+			v, _ := r.s32()
+			in.push32(uint32(v)) //nolint:gosec // the two's complement
+		case op == opI64Const:
+			v, _ := r.s64()
+			in.push(uint64(v)) //nolint:gosec // the two's complement
+		case op == opPrefixFC:
+			sub, _ := r.u32()
+			in.bulk(sub)
+
+			if r.pos++; sub == opMemoryCopy { // skip the memory indexes
+				r.pos++
+			}
+		case op >= 0x45 && op <= 0x4f:
+			in.compare32(op)
+		case op >= 0x50 && op <= 0x5a:
+			in.compare64(op)
+		case op >= 0x67 && op <= 0x78:
+			in.arith32(op)
+		case op >= 0x79 && op <= 0x8a:
+			in.arith64(op)
+		default:
+			in.convert(op)
+		}
+	}
+
+	// keep only the results (the stack of the well-formed code has nothing else at this point)
+	copy(in.stack[base:], in.stack[len(in.stack)-nRes:])
+	in.stack = in.stack[:base+nRes]
+
+	in.depth--
+}
+
+func (in *instance) load(op byte, offset uint32) { //nolint:gocyclo
+	var m = in.mem
+
+	switch op {
+	case 0x28: // i32.load
+		in.push32(binary.LittleEndian.Uint32(m[in.addr(offset, 4):]))
+	case 0x29: // i64.load
+		in.push(binary.LittleEndian.Uint64(m[in.addr(offset, 8):]))
+	case 0x2c: // i32.load8_s
+		in.push32(uint32(int32(int8(m[in.addr(offset, 1)])))) //nolint:gosec // the sign extension
+	case 0x2d: // i32.load8_u
+		in.push32(uint32(m[in.addr(offset, 1)]))
+	case 0x2e: // i32.load16_s
+		in.push32(uint32(int32(int16(binary.LittleEndian.Uint16(m[in.addr(offset, 2):]))))) //nolint:gosec
+	case 0x2f: // i32.load16_u
+		in.push32(uint32(binary.LittleEndian.Uint16(m[in.addr(offset, 2):])))
+	case 0x30: // i64.load8_s
+		in.push(uint64(int64(int8(m[in.addr(offset, 1)])))) //nolint:gosec
+	case 0x31: // i64.load8_u
+		in.push(uint64(m[in.addr(offset, 1)]))
+	case 0x32: // i64.load16_s
+		in.push(uint64(int64(int16(binary.LittleEndian.Uint16(m[in.addr(offset, 2):]))))) //nolint:gosec
+	case 0x33: // i64.load16_u
+		in.push(uint64(binary.LittleEndian.Uint16(m[in.addr(offset, 2):])))
+	case 0x34: // i64.load32_s
+		in.push(uint64(int64(int32(binary.LittleEndian.Uint32(m[in.addr(offset, 4):]))))) //nolint:gosec
+	case 0x35: // i64.load32_u
+		in.push(uint64(binary.LittleEndian.Uint32(m[in.addr(offset, 4):])))
+	}
+}
+
+func (in *instance) store(op byte, offset uint32) {
+	var (
+		v = in.pop()
+		m = in.mem
+	)
+
+	switch op {
+	case 0x36, 0x3e: // i32.store, i64.store32
+		binary.LittleEndian.PutUint32(m[in.addr(offset, 4):], uint32(v)) //nolint:gosec // truncated by design
+	case 0x37: // i64.store
+		binary.LittleEndian.PutUint64(m[in.addr(offset, 8):], v)
+	case 0x3a, 0x3c: // i32.store8, i64.store8
+		m[in.addr(offset, 1)] = byte(v)
+	case 0x3b, 0x3d: // i32.store16, i64.store16
+		binary.LittleEndian.PutUint16(m[in.addr(offset, 2):], uint16(v)) //nolint:gosec // truncated by design
+	}
+}
+
+// grow grows the memory by the given number of pages and returns the previous size (or -1 on failure).
+func (in *instance) grow(pages uint32) uint32 {
+	var (
+		current = uint32(len(in.mem) / pageSize) //nolint:gosec // limited by maxPages
+		limit   = uint64(maxPages)
+	)
+
+	if in.m.memory != nil {
+		limit = min(limit, uint64(in.m.memory.max))
+	}
+
+	if in.m.memory == nil || uint64(current)+uint64(pages) > limit {
+		return math.MaxUint32 // -1
+	}
+
+	in.mem = append(in.mem, make([]byte, int(pages)*pageSize)...)
+
+	return current
+}
+
+// bulk executes the bulk memory instruction.
+func (in *instance) bulk(sub uint32) {
+	var (
+		n   = uint64(in.pop32())
+		src = uint64(in.pop32()) // the value for the `memory.fill`
+		dst = uint64(in.pop32())
+	)
+
+	if in.fuel -= int(n / 64); in.fuel < 0 { //nolint:mnd // roughly the cost of the equivalent loop
+		panic(trap{ErrOutOfFuel})
+	}
+
+	switch sub {
+	case opMemoryCopy:
+		if src+n > uint64(len(in.mem)) || dst+n > uint64(len(in.mem)) {
+			panic(trapf("out of bounds memory access"))
+		}
+
+		copy(in.mem[dst:dst+n], in.mem[src:src+n])
+	case opMemoryFill:
+		if dst+n > uint64(len(in.mem)) {
+			panic(trapf("out of bounds memory access"))
+		}
+
+		for i := dst; i < dst+n; i++ {
+			in.mem[i] = byte(src)
+		}
+	}
+}
+
+func (in *instance) compare32(op byte) {
+	if op == 0x45 { // i32.eqz
+		in.pushBool(in.pop32() == 0)
+
+		return
+	}
+
+	var b, a = in.pop32(), in.pop32()
+
+	switch op {
+	case 0x46:
+		in.pushBool(a == b)
+	case 0x47:
+		in.pushBool(a != b)
+	case 0x48:
+		in.pushBool(int32(a) < int32(b)) //nolint:gosec // signed
+	case 0x49:
+		in.pushBool(a < b)
+	case 0x4a:
+		in.pushBool(int32(a) > int32(b)) //nolint:gosec // signed
+	case 0x4b:
+		in.pushBool(a > b)
+	case 0x4c:
+		in.pushBool(int32(a) <= int32(b)) //nolint:gosec // signed
+	case 0x4d:
+		in.pushBool(a <= b)
+	case 0x4e:
+		in.pushBool(int32(a) >= int32(b)) //nolint:gosec // signed
+	case 0x4f:
+		in.pushBool(a >= b)
+	}
+}
+
+func (in *instance) compare64(op byte) {
+	if op == 0x50 { // i64.eqz
+		in.pushBool(in.pop() == 0)
+
+		return
+	}
+
+	var b, a = in.pop(), in.pop()
+
+	switch op {
+	case 0x51:
+		in.pushBool(a == b)
+	case 0x52:
+		in.pushBool(a != b)
+	case 0x53:
+		in.pushBool(int64(a) < int64(b)) //nolint:gosec // signed
+	case 0x54:
+		in.pushBool(a < b)
+	case 0x55:
+		in.pushBool(int64(a) > int64(b)) //nolint:gosec // signed
+	case 0x56:
+		in.pushBool(a > b)
+	case 0x57:
+		in.pushBool(int64(a) <= int64(b)) //nolint:gosec // signed
+	case 0x58:
+		in.pushBool(a <= b)
+	case 0x59:
+		in.pushBool(int64(a) >= int64(b)) //nolint:gosec // signed
+	case 0x5a:
+		in.pushBool(a >= b)
+	}
+}
+
+func (in *instance) arith32(op byte) { //nolint:gocyclo
+	switch op {
+	case 0x67: // i32.clz
+		in.push32(uint32(bits.LeadingZeros32(in.pop32()))) //nolint:gosec // 0..32
+
+		return
+	case 0x68: // i32.ctz
+		in.push32(uint32(bits.TrailingZeros32(in.pop32()))) //nolint:gosec // 0..32
+
+		return
+	case 0x69: // i32.popcnt
+		in.push32(uint32(bits.OnesCount32(in.pop32()))) //nolint:gosec // 0..32
+
+		return
+	}
+
+	var b, a = in.pop32(), in.pop32()
+
+	if (op >= 0x6d && op <= 0x70) && b == 0 {
+		panic(trapf("integer divide by zero"))
+	}
+
+	switch op {
+	case 0x6a:
+		in.push32(a + b)
+	case 0x6b:
+		in.push32(a - b)
+	case 0x6c:
+		in.push32(a * b)
+	case 0x6d: // i32.div_s
+		if int32(a) == math.MinInt32 && int32(b) == -1 { //nolint:gosec // signed
+			panic(trapf("integer overflow"))
+		}
+
+		in.push32(uint32(int32(a) / int32(b))) //nolint:gosec // signed
+	case 0x6e:
+		in.push32(a / b)
+	case 0x6f: // i32.rem_s
+		if int32(b) == -1 { //nolint:gosec // signed (the remainder is zero, and the MinInt32 % -1 panics in Go)
+			in.push32(0)
+		} else {
+			in.push32(uint32(int32(a) % int32(b))) //nolint:gosec // signed
+		}
+	case 0x70:
+		in.push32(a % b)
+	case 0x71:
+		in.push32(a & b)
+	case 0x72:
+		in.push32(a | b)
+	case 0x73:
+		in.push32(a ^ b)
+	case 0x74:
+		in.push32(a << (b & 31))
+	case 0x75:
+		in.push32(uint32(int32(a) >> (b & 31))) //nolint:gosec // arithmetic shift
+	case 0x76:
+		in.push32(a >> (b & 31))
+	case 0x77:
+		in.push32(bits.RotateLeft32(a, int(b&31)))
+	case 0x78:
+		in.push32(bits.RotateLeft32(a, -int(b&31)))
+	}
+}
+
+func (in *instance) arith64(op byte) { //nolint:gocyclo
+	switch op {
+	case 0x79: // i64.clz
+		in.push(uint64(bits.LeadingZeros64(in.pop()))) //nolint:gosec // 0..64
+
+		return
+	case 0x7a: // i64.ctz
+		in.push(uint64(bits.TrailingZeros64(in.pop()))) //nolint:gosec // 0..64
+
+		return
+	case 0x7b: // i64.popcnt
+		in.push(uint64(bits.OnesCount64(in.pop()))) //nolint:gosec // 0..64
+
+		return
+	}
+
+	var b, a = in.pop(), in.pop()
+
+	if (op >= 0x7f && op <= 0x82) && b == 0 {
+		panic(trapf("integer divide by zero"))
+	}
+
+	switch op {
+	case 0x7c:
+		in.push(a + b)
+	case 0x7d:
+		in.push(a - b)
+	case 0x7e:
+		in.push(a * b)
+	case 0x7f: // i64.div_s
+		if int64(a) == math.MinInt64 && int64(b) == -1 { //nolint:gosec // signed
+			panic(trapf("integer overflow"))
+		}
+
+		in.push(uint64(int64(a) / int64(b))) //nolint:gosec // signed
+	case 0x80:
+		in.push(a / b)
+	case 0x81: // i64.rem_s
+		if int64(b) == -1 { //nolint:gosec // signed (see the i32.rem_s)
+			in.push(0)
+		} else {
+			in.push(uint64(int64(a) % int64(b))) //nolint:gosec // signed
+		}
+	case 0x82:
+		in.push(a % b)
+	case 0x83:
+		in.push(a & b)
+	case 0x84:
+		in.push(a | b)
+	case 0x85:
+		in.push(a ^ b)
+	case 0x86:
+		in.push(a << (b & 63))
+	case 0x87:
+		in.push(uint64(int64(a) >> (b & 63))) //nolint:gosec // arithmetic shift
+	case 0x88:
+		in.push(a >> (b & 63))
+	case 0x89:
+		in.push(bits.RotateLeft64(a, int(b&63))) //nolint:gosec // 0..63
+	case 0x8a:
+		in.push(bits.RotateLeft64(a, -int(b&63))) //nolint:gosec // 0..63
+	}
+}
+
+func (in *instance) convert(op byte) {
+	var v = in.pop()
+
+	switch op {
+	case 0xa7: // i32.wrap_i64
+		in.push32(uint32(v)) //nolint:gosec // truncated by design
+	case 0xac: // i64.extend_i32_s
+		in.push(uint64(int64(int32(v)))) //nolint:gosec // the sign extension
+	case 0xad: // i64.extend_i32_u
+		in.push(uint64(uint32(v))) //nolint:gosec // the zero extension
+	case 0xc0: // i32.extend8_s
+		in.push32(uint32(int32(int8(v)))) //nolint:gosec
+	case 0xc1: // i32.extend16_s
+		in.push32(uint32(int32(int16(v)))) //nolint:gosec
+	case 0xc2: // i64.extend8_s
+		in.push(uint64(int64(int8(v)))) //nolint:gosec
+	case 0xc3: // i64.extend16_s
+		in.push(uint64(int64(int16(v)))) //nolint:gosec
+	case 0xc4: // i64.extend32_s
+		in.push(uint64(int64(int32(v)))) //nolint:gosec
+	}
+}
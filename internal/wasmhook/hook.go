@@ -0,0 +1,144 @@
+// Package wasmhook runs the user-provided WebAssembly modules that decide whether the request details may be shown
+// on the error page (see the `details_access` configuration).
+//
+// The modules are executed by a small sandboxed interpreter: they can't import anything (so there is no access to
+// the file system, network, clock, etc.), only the integer instructions are supported, the memory is limited to
+// 1 MiB, and every call is limited by the number of executed instructions (the "fuel"), so a hook that loops
+// forever is stopped and treated as a denial.
+//
+// The module must export:
+//
+//   - the linear memory (defined in the module, since the imports are not allowed)
+//   - `alloc(len: i32) -> i32` - returns the pointer to the buffer of the given length for the input
+//   - `allow(ptr: i32, len: i32) -> i32` - returns non-zero when the details may be shown
+//
+// The input is the client IP address on the first line, followed by the request headers, one `Name: value` per
+// line. Every call gets a fresh module instance (the state of the previous calls is never visible).
+package wasmhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Hook is the compiled WebAssembly hook. It's safe for concurrent use.
+type Hook struct {
+	m            *module
+	mem          []byte   // the memory after the instantiation (copied for every call)
+	globals      []uint64 // the globals after the instantiation (copied for every call)
+	alloc, allow uint32   // the exported functions indexes
+}
+
+// Load reads and compiles the hook from the file.
+func Load(path string) (*Hook, error) {
+	bin, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compile(bin)
+}
+
+// Compile decodes and checks the module, and runs its start function (if any).
+func Compile(bin []byte) (*Hook, error) {
+	m, err := decode(bin)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.memory == nil {
+		return nil, errors.New("the module has no memory")
+	}
+
+	if m.memory.min > maxPages {
+		return nil, fmt.Errorf("the module requires too much memory (%d pages, the limit is %d)", m.memory.min, maxPages)
+	}
+
+	var h = Hook{m: m}
+
+	for _, fn := range []struct {
+		name    string
+		idx     *uint32
+		params  int
+		results int
+	}{
+		{"alloc", &h.alloc, 1, 1},
+		{"allow", &h.allow, 2, 1}, //nolint:mnd
+	} {
+		idx, ok := m.exports[fn.name]
+		if !ok {
+			return nil, fmt.Errorf("the module does not export the '%s' function", fn.name)
+		}
+
+		if t := m.functions[idx].typ; !isI32(t.params, fn.params) || !isI32(t.results, fn.results) {
+			return nil, fmt.Errorf("the '%s' function has a wrong signature", fn.name)
+		}
+
+		*fn.idx = idx
+	}
+
+	var in = instance{m: m, mem: make([]byte, int(m.memory.min)*pageSize), globals: make([]uint64, len(m.globals))}
+
+	for i, g := range m.globals {
+		in.globals[i] = g.init
+	}
+
+	for _, seg := range m.data {
+		copy(in.mem[seg.offset:], seg.data)
+	}
+
+	if m.start != nil {
+		if _, err = in.invoke(*m.start); err != nil {
+			return nil, fmt.Errorf("start function: %w", err)
+		}
+	}
+
+	h.mem, h.globals = in.mem, in.globals
+
+	return &h, nil
+}
+
+// isI32 reports whether the types are exactly n i32 values.
+func isI32(types []byte, n int) bool {
+	if len(types) != n {
+		return false
+	}
+
+	for _, t := range types {
+		if t != typeI32 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Allow calls the hook with the input and reports whether the details may be shown. The error is returned when
+// the hook traps (e.g. runs out of fuel or accesses the memory out of bounds).
+func (h *Hook) Allow(input []byte) (bool, error) {
+	var in = instance{
+		m:       h.m,
+		mem:     append(make([]byte, 0, len(h.mem)), h.mem...),
+		globals: append([]uint64(nil), h.globals...),
+	}
+
+	res, err := in.invoke(h.alloc, uint64(len(input)))
+	if err != nil {
+		return false, fmt.Errorf("alloc: %w", err)
+	}
+
+	var ptr = uint64(uint32(res[0])) //nolint:gosec // i32
+
+	if ptr+uint64(len(input)) > uint64(len(in.mem)) {
+		return false, fmt.Errorf("alloc: the returned pointer %d is out of the memory bounds", ptr)
+	}
+
+	copy(in.mem[ptr:], input)
+
+	if res, err = in.invoke(h.allow, ptr, uint64(len(input))); err != nil {
+		return false, fmt.Errorf("allow: %w", err)
+	}
+
+	return uint32(res[0]) != 0, nil //nolint:gosec // i32
+}
@@ -0,0 +1,302 @@
+package wasmhook_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/binaryYuki/error-pages/internal/wasmhook"
+)
+
+// the helpers below assemble the modules in the binary format, so the tests don't need the WebAssembly toolchain
+
+func uleb(v uint32) []byte {
+	var b []byte
+
+	for {
+		c := byte(v & 0x7f)
+
+		if v >>= 7; v != 0 {
+			b = append(b, c|0x80)
+		} else {
+			return append(b, c)
+		}
+	}
+}
+
+func sleb(v int32) []byte {
+	var b []byte
+
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+
+		b = append(b, c|0x80)
+	}
+}
+
+func concat(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+func vec(items ...[]byte) []byte { return concat(uleb(uint32(len(items))), concat(items...)) } //nolint:gosec
+
+func section(id byte, items ...[]byte) []byte {
+	var content = vec(items...)
+
+	return concat([]byte{id}, uleb(uint32(len(content))), content) //nolint:gosec
+}
+
+func name(s string) []byte { return concat(uleb(uint32(len(s))), []byte(s)) } //nolint:gosec
+
+// body encodes the function body with the i32 locals.
+func body(i32Locals uint32, code ...[]byte) []byte {
+	var locals = vec()
+
+	if i32Locals > 0 {
+		locals = vec(concat(uleb(i32Locals), []byte{0x7f}))
+	}
+
+	var content = concat(locals, concat(code...), []byte{0x0b})
+
+	return concat(uleb(uint32(len(content))), content) //nolint:gosec
+}
+
+func i32(v int32) []byte { return concat([]byte{0x41}, sleb(v)) }
+
+func op(b ...byte) []byte { return b }
+
+// hookModule assembles the hook module: one memory page, the `alloc` function returning the fixed pointer 1024,
+// and the `allow(ptr, len)` function with the given body (the locals after the params are i32).
+func hookModule(allowLocals uint32, allow ...[]byte) []byte {
+	return concat(
+		[]byte("\x00asm\x01\x00\x00\x00"),
+		section(1, // types
+			[]byte{0x60, 1, 0x7f, 1, 0x7f},       // (i32) -> i32
+			[]byte{0x60, 2, 0x7f, 0x7f, 1, 0x7f}, // (i32, i32) -> i32
+		),
+		section(3, []byte{0}, []byte{1}), // functions
+		section(5, []byte{0, 1}),         // memory (min 1 page)
+		section(7, // exports
+			concat(name("memory"), []byte{2, 0}),
+			concat(name("alloc"), []byte{0, 0}),
+			concat(name("allow"), []byte{0, 1}),
+		),
+		section(10, // code
+			body(0, i32(1024)),
+			body(allowLocals, allow...),
+		),
+	)
+}
+
+// countLines counts the '\n' bytes of the input and allows when there are exactly `want` of them.
+func countLines(want int32) []byte {
+	// locals: 0 - ptr, 1 - len, 2 - i, 3 - count
+	return hookModule(2, //nolint:mnd
+		// block, loop
+		op(0x02, 0x40, 0x03, 0x40),
+		// br_if 1 (exit) when i >= len
+		op(0x20, 2, 0x20, 1, 0x4f, 0x0d, 1),
+		// count += mem[ptr+i] == '\n'
+		op(0x20, 0, 0x20, 2, 0x6a, 0x2d, 0, 0), i32('\n'), op(0x46, 0x20, 3, 0x6a, 0x21, 3),
+		// i++
+		op(0x20, 2), i32(1), op(0x6a, 0x21, 2),
+		// br 0 (next iteration), end, end
+		op(0x0c, 0, 0x0b, 0x0b),
+		// count == want
+		op(0x20, 3), i32(want), op(0x46),
+	)
+}
+
+func TestHook_Allow(t *testing.T) {
+	t.Parallel()
+
+	hook, err := wasmhook.Compile(countLines(2))
+	require.NoError(t, err)
+
+	for give, want := range map[string]bool{
+		"10.0.0.1\nX-Foo: bar\n":             true,
+		"10.0.0.1\n":                         false,
+		"10.0.0.1\nX-Foo: bar\nX-Baz: qux\n": false,
+		"":                                   false,
+	} {
+		allowed, allowErr := hook.Allow([]byte(give))
+		require.NoError(t, allowErr)
+		assert.Equal(t, want, allowed, give)
+	}
+}
+
+func TestHook_Allow_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	// stores the input length into the global, and returns its previous value: every call gets a fresh instance,
+	// so the previous value is always the initial one
+	var bin = concat(
+		[]byte("\x00asm\x01\x00\x00\x00"),
+		section(1, []byte{0x60, 1, 0x7f, 1, 0x7f}, []byte{0x60, 2, 0x7f, 0x7f, 1, 0x7f}),
+		section(3, []byte{0}, []byte{1}),
+		section(5, []byte{0, 1}),
+		section(6, concat([]byte{0x7f, 1}, i32(7), op(0x0b))), // mutable i32 global = 7
+		section(7, concat(name("alloc"), []byte{0, 0}), concat(name("allow"), []byte{0, 1})),
+		section(10,
+			body(0, i32(0)),
+			body(0, op(0x23, 0), i32(7), op(0x46), op(0x20, 1, 0x24, 0)), // global == 7; global = len
+		),
+	)
+
+	hook, err := wasmhook.Compile(bin)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				allowed, allowErr := hook.Allow([]byte("foo"))
+				assert.NoError(t, allowErr)
+				assert.True(t, allowed)
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestHook_Allow_Traps(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveModule []byte
+		wantErr    string
+	}{
+		"infinite loop": {
+			giveModule: hookModule(0, op(0x03, 0x40, 0x0c, 0, 0x0b), i32(1)), // loop br 0 end
+			wantErr:    "out of fuel",
+		},
+		"out of bounds": {
+			giveModule: hookModule(0, i32(1<<16-2), op(0x28, 2, 0)), // i32.load at the end of the page
+			wantErr:    "out of bounds memory access",
+		},
+		"divide by zero": {
+			giveModule: hookModule(0, i32(1), i32(0), op(0x6d)),
+			wantErr:    "integer divide by zero",
+		},
+		"unreachable": {
+			giveModule: hookModule(0, op(0x00)),
+			wantErr:    "unreachable",
+		},
+		"infinite recursion": {
+			giveModule: hookModule(0, op(0x20, 0, 0x20, 1, 0x10, 1)), // allow(ptr, len)
+			wantErr:    "call stack exhausted",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hook, err := wasmhook.Compile(tt.giveModule)
+			require.NoError(t, err)
+
+			allowed, err := hook.Allow([]byte("10.0.0.1\n"))
+			require.ErrorContains(t, err, tt.wantErr)
+			assert.False(t, allowed)
+		})
+	}
+
+	t.Run("out of fuel is detectable", func(t *testing.T) {
+		t.Parallel()
+
+		hook, err := wasmhook.Compile(hookModule(0, op(0x03, 0x40, 0x0c, 0, 0x0b), i32(1)))
+		require.NoError(t, err)
+
+		_, err = hook.Allow(nil)
+		require.ErrorIs(t, err, wasmhook.ErrOutOfFuel)
+	})
+}
+
+func TestCompile_Errors(t *testing.T) {
+	t.Parallel()
+
+	var header = []byte("\x00asm\x01\x00\x00\x00")
+
+	for name, tt := range map[string]struct {
+		giveModule []byte
+		wantErr    string
+	}{
+		"not a module": {
+			giveModule: []byte("foo bar baz"),
+			wantErr:    "not a WebAssembly module",
+		},
+		"version": {
+			giveModule: []byte("\x00asm\x02\x00\x00\x00"),
+			wantErr:    "unsupported WebAssembly version 2",
+		},
+		"truncated": {
+			giveModule: hookModule(0, i32(1))[:40],
+			wantErr:    "unexpected end",
+		},
+		"imports": {
+			giveModule: concat(header, section(2, concat(name("env"), name("now"), []byte{0, 0}))),
+			wantErr:    "imports are not allowed",
+		},
+		"floats": {
+			giveModule: concat(header, section(1, []byte{0x60, 1, 0x7d, 0})),
+			wantErr:    "unsupported value type 0x7d",
+		},
+		"float instruction": {
+			giveModule: hookModule(0, op(0x43, 0, 0, 0, 0, 0xa8)), // f32.const 0; i32.trunc_f32_s
+			wantErr:    "unsupported instruction 0x43",
+		},
+		"no memory": {
+			giveModule: concat(header),
+			wantErr:    "the module has no memory",
+		},
+		"too much memory": {
+			giveModule: concat(header, section(5, []byte{0, 17})),
+			wantErr:    "requires too much memory",
+		},
+		"no exports": {
+			giveModule: concat(header, section(5, []byte{0, 1})),
+			wantErr:    "does not export the 'alloc' function",
+		},
+		"wrong local": {
+			giveModule: hookModule(0, op(0x20, 5)),
+			wantErr:    "wrong local index 5",
+		},
+		"unterminated block": {
+			giveModule: hookModule(0, op(0x02, 0x40), i32(1)),
+			wantErr:    "function body is not terminated",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := wasmhook.Compile(tt.giveModule)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	var path = filepath.Join(t.TempDir(), "hook.wasm")
+
+	require.NoError(t, os.WriteFile(path, countLines(0), 0o600))
+
+	hook, err := wasmhook.Load(path)
+	require.NoError(t, err)
+
+	allowed, err := hook.Allow([]byte("no new lines"))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, err = wasmhook.Load(filepath.Join(t.TempDir(), "not-exists.wasm"))
+	require.Error(t, err)
+}
@@ -0,0 +1,575 @@
+package wasmhook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// the value types (only the integer ones are supported)
+const (
+	typeI32 byte = 0x7f
+	typeI64 byte = 0x7e
+)
+
+const (
+	pageSize = 64 << 10 // the WebAssembly memory page size
+
+	maxLocals    = 1024 // per function
+	maxFunctions = 10_000
+)
+
+type (
+	// funcType is the function signature.
+	funcType struct{ params, results []byte }
+
+	// function is the function defined in the module.
+	function struct {
+		typ    funcType
+		locals []byte           // the declared locals (without the params)
+		code   []byte           // the body expression (ends with the `end` instruction)
+		blocks map[int]block    // the structured instructions by their opcode position
+		brs    map[int][]uint32 // the `br_table` labels by the instruction position
+	}
+
+	// block is the position of the `else` (if any) and `end` instructions of the structured instruction.
+	block struct{ elsePos, endPos int }
+
+	// global is the module global variable.
+	global struct {
+		typ     byte
+		mutable bool
+		init    uint64
+	}
+
+	// dataSegment is the active data segment (copied into the memory on the instantiation).
+	dataSegment struct {
+		offset uint32
+		data   []byte
+	}
+
+	// module is the decoded (and checked) module.
+	module struct {
+		types     []funcType
+		functions []function
+		globals   []global
+		exports   map[string]uint32 // the exported functions indexes by the name
+		memory    *struct{ min, max uint32 }
+		data      []dataSegment
+		start     *uint32
+	}
+)
+
+var errUnexpectedEnd = errors.New("unexpected end of the module")
+
+// reader reads the WebAssembly binary format values.
+type reader struct {
+	b   []byte
+	pos int
+}
+
+func (r *reader) eof() bool { return r.pos >= len(r.b) }
+
+func (r *reader) byte() (byte, error) {
+	if r.eof() {
+		return 0, errUnexpectedEnd
+	}
+
+	r.pos++
+
+	return r.b[r.pos-1], nil
+}
+
+func (r *reader) bytes(n uint32) ([]byte, error) {
+	if uint64(r.pos)+uint64(n) > uint64(len(r.b)) {
+		return nil, errUnexpectedEnd
+	}
+
+	r.pos += int(n)
+
+	return r.b[r.pos-int(n) : r.pos], nil
+}
+
+// leb reads the LEB128 encoded integer of the given size (in bits).
+func (r *reader) leb(bits uint, signed bool) (uint64, error) {
+	var (
+		result uint64
+		shift  uint
+	)
+
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+
+		result |= uint64(b&0x7f) << shift
+		shift += 7
+
+		if b&0x80 == 0 {
+			if signed && shift < 64 && b&0x40 != 0 {
+				result |= ^uint64(0) << shift // the sign extension
+			}
+
+			return result, nil
+		}
+
+		if shift >= bits+7 {
+			return 0, errors.New("integer representation too long")
+		}
+	}
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, err := r.leb(32, false) //nolint:mnd
+
+	if v > math.MaxUint32 {
+		return 0, errors.New("integer too large")
+	}
+
+	return uint32(v), err
+}
+
+func (r *reader) s32() (int32, error) {
+	v, err := r.leb(32, true) //nolint:mnd
+
+	return int32(v), err //nolint:gosec // truncated by design
+}
+
+func (r *reader) s64() (int64, error) {
+	v, err := r.leb(64, true) //nolint:mnd
+
+	return int64(v), err //nolint:gosec // the two's complement
+}
+
+// valueTypes reads the vector of the value types.
+func (r *reader) valueTypes() ([]byte, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := r.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range types {
+		if err = checkValueType(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return types, nil
+}
+
+// checkValueType rejects the value types other than the integer ones.
+func checkValueType(t byte) error {
+	if t != typeI32 && t != typeI64 {
+		return fmt.Errorf("unsupported value type 0x%02x (only i32 and i64 are supported)", t)
+	}
+
+	return nil
+}
+
+// decode decodes the binary module.
+func decode(bin []byte) (*module, error) { //nolint:funlen,gocognit,gocyclo
+	if len(bin) < 8 || !bytes.Equal(bin[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a WebAssembly module")
+	}
+
+	if v := binary.LittleEndian.Uint32(bin[4:8]); v != 1 {
+		return nil, fmt.Errorf("unsupported WebAssembly version %d", v)
+	}
+
+	var (
+		m        = module{exports: make(map[string]uint32)}
+		r        = reader{b: bin, pos: 8}
+		funcs    []uint32 // the type indexes of the functions
+		lastID   byte
+		haveCode bool
+	)
+
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := r.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+
+		if id != 0 { // the custom sections may be anywhere
+			if id <= lastID && id != 12 { //nolint:mnd // the data count section goes before the code one
+				return nil, fmt.Errorf("section %d is out of order", id)
+			}
+
+			lastID = max(lastID, id)
+		}
+
+		var s = reader{b: content}
+
+		switch id {
+		case 0: // custom
+			continue
+		case 1: // type
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			for range n {
+				if form, err := s.byte(); err != nil {
+					return nil, err
+				} else if form != 0x60 { //nolint:mnd
+					return nil, fmt.Errorf("wrong function type form 0x%02x", form)
+				}
+
+				var t funcType
+
+				if t.params, err = s.valueTypes(); err != nil {
+					return nil, err
+				}
+
+				if t.results, err = s.valueTypes(); err != nil {
+					return nil, err
+				}
+
+				m.types = append(m.types, t)
+			}
+		case 2: // import
+			if n, err := s.u32(); err != nil {
+				return nil, err
+			} else if n > 0 {
+				return nil, errors.New("imports are not allowed (the hook must be self-contained)")
+			}
+		case 3: // function
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			if n > maxFunctions {
+				return nil, fmt.Errorf("too many functions (%d)", n)
+			}
+
+			for range n {
+				idx, err := s.u32()
+				if err != nil {
+					return nil, err
+				}
+
+				if int(idx) >= len(m.types) {
+					return nil, fmt.Errorf("wrong function type index %d", idx)
+				}
+
+				funcs = append(funcs, idx)
+			}
+		case 4: // table
+			continue // the indirect calls are not supported, so the tables are never used
+		case 5: // memory
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			if n > 1 {
+				return nil, errors.New("multiple memories are not supported")
+			}
+
+			if n == 1 {
+				flags, err := s.byte()
+				if err != nil {
+					return nil, err
+				}
+
+				var mem struct{ min, max uint32 }
+
+				if mem.min, err = s.u32(); err != nil {
+					return nil, err
+				}
+
+				mem.max = math.MaxUint32
+
+				switch flags {
+				case 0:
+				case 1:
+					if mem.max, err = s.u32(); err != nil {
+						return nil, err
+					}
+				default:
+					return nil, fmt.Errorf("unsupported memory limits flags 0x%02x", flags)
+				}
+
+				m.memory = &mem
+			}
+		case 6: // global
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			for range n {
+				var g global
+
+				if g.typ, err = s.byte(); err != nil {
+					return nil, err
+				}
+
+				if err = checkValueType(g.typ); err != nil {
+					return nil, err
+				}
+
+				mut, err := s.byte()
+				if err != nil {
+					return nil, err
+				}
+
+				g.mutable = mut == 1
+
+				if g.init, err = s.constExpr(g.typ); err != nil {
+					return nil, err
+				}
+
+				m.globals = append(m.globals, g)
+			}
+		case 7: // export
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			for range n {
+				nameLen, err := s.u32()
+				if err != nil {
+					return nil, err
+				}
+
+				name, err := s.bytes(nameLen)
+				if err != nil {
+					return nil, err
+				}
+
+				kind, err := s.byte()
+				if err != nil {
+					return nil, err
+				}
+
+				idx, err := s.u32()
+				if err != nil {
+					return nil, err
+				}
+
+				if kind == 0 { // only the functions are looked up
+					m.exports[string(name)] = idx
+				}
+			}
+		case 8: // start
+			idx, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			m.start = &idx
+		case 9: // element
+			continue // see the table section
+		case 10: // code
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			if int(n) != len(funcs) {
+				return nil, errors.New("the function and code sections sizes mismatch")
+			}
+
+			for i := range n {
+				f, err := s.function(m.types[funcs[i]])
+				if err != nil {
+					return nil, fmt.Errorf("function %d: %w", i, err)
+				}
+
+				m.functions = append(m.functions, f)
+			}
+
+			haveCode = true
+		case 11: // data
+			n, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+
+			for range n {
+				flags, err := s.u32()
+				if err != nil {
+					return nil, err
+				}
+
+				var seg dataSegment
+
+				switch flags {
+				case 0, 2: //nolint:mnd // active (with the explicit memory index)
+					if flags == 2 { //nolint:mnd
+						if idx, err := s.u32(); err != nil {
+							return nil, err
+						} else if idx != 0 {
+							return nil, errors.New("multiple memories are not supported")
+						}
+					}
+
+					offset, err := s.constExpr(typeI32)
+					if err != nil {
+						return nil, err
+					}
+
+					seg.offset = uint32(offset) //nolint:gosec // i32
+				case 1: // passive (the `memory.init` is not supported, so never used)
+				default:
+					return nil, fmt.Errorf("wrong data segment flags %d", flags)
+				}
+
+				size, err := s.u32()
+				if err != nil {
+					return nil, err
+				}
+
+				if seg.data, err = s.bytes(size); err != nil {
+					return nil, err
+				}
+
+				if flags != 1 {
+					m.data = append(m.data, seg)
+				}
+			}
+		case 12: // data count
+			continue
+		default:
+			return nil, fmt.Errorf("unknown section %d", id)
+		}
+
+		if !s.eof() {
+			return nil, fmt.Errorf("section %d size mismatch", id)
+		}
+	}
+
+	if len(funcs) > 0 && !haveCode {
+		return nil, errors.New("missing code section")
+	}
+
+	for name, idx := range m.exports {
+		if int(idx) >= len(m.functions) {
+			return nil, fmt.Errorf("export '%s': wrong function index %d", name, idx)
+		}
+	}
+
+	if m.start != nil && int(*m.start) >= len(m.functions) {
+		return nil, fmt.Errorf("wrong start function index %d", *m.start)
+	}
+
+	for _, seg := range m.data {
+		if m.memory == nil || uint64(seg.offset)+uint64(len(seg.data)) > uint64(m.memory.min)*pageSize {
+			return nil, errors.New("data segment does not fit into the memory")
+		}
+	}
+
+	return &m, nil
+}
+
+// constExpr reads the constant expression (like `i32.const 42 end`) of the given type.
+func (r *reader) constExpr(typ byte) (uint64, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+
+	var v uint64
+
+	switch {
+	case op == opI32Const && typ == typeI32:
+		n, err := r.s32()
+		if err != nil {
+			return 0, err
+		}
+
+		v = uint64(uint32(n)) //nolint:gosec // the two's complement
+	case op == opI64Const && typ == typeI64:
+		n, err := r.s64()
+		if err != nil {
+			return 0, err
+		}
+
+		v = uint64(n) //nolint:gosec // the two's complement
+	default:
+		return 0, fmt.Errorf("unsupported constant expression opcode 0x%02x", op)
+	}
+
+	if end, err := r.byte(); err != nil {
+		return 0, err
+	} else if end != opEnd {
+		return 0, errors.New("constant expression is not terminated")
+	}
+
+	return v, nil
+}
+
+// function reads the function body and finds the structured instructions boundaries.
+func (r *reader) function(typ funcType) (function, error) {
+	size, err := r.u32()
+	if err != nil {
+		return function{}, err
+	}
+
+	body, err := r.bytes(size)
+	if err != nil {
+		return function{}, err
+	}
+
+	var (
+		b = reader{b: body}
+		f = function{typ: typ, blocks: make(map[int]block), brs: make(map[int][]uint32)}
+	)
+
+	groups, err := b.u32()
+	if err != nil {
+		return f, err
+	}
+
+	for range groups {
+		n, err := b.u32()
+		if err != nil {
+			return f, err
+		}
+
+		t, err := b.byte()
+		if err != nil {
+			return f, err
+		}
+
+		if err = checkValueType(t); err != nil {
+			return f, err
+		}
+
+		if len(f.locals)+len(typ.params)+int(n) > maxLocals {
+			return f, errors.New("too many locals")
+		}
+
+		f.locals = append(f.locals, bytes.Repeat([]byte{t}, int(n))...)
+	}
+
+	f.code = body[b.pos:]
+
+	if err = scan(&f); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
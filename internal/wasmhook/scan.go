@@ -0,0 +1,208 @@
+package wasmhook
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the opcodes referenced by name (the numeric ones are handled by the ranges in the interpreter)
+const (
+	opUnreachable byte = 0x00
+	opNop         byte = 0x01
+	opBlock       byte = 0x02
+	opLoop        byte = 0x03
+	opIf          byte = 0x04
+	opElse        byte = 0x05
+	opEnd         byte = 0x0b
+	opBr          byte = 0x0c
+	opBrIf        byte = 0x0d
+	opBrTable     byte = 0x0e
+	opReturn      byte = 0x0f
+	opCall        byte = 0x10
+	opDrop        byte = 0x1a
+	opSelect      byte = 0x1b
+	opLocalGet    byte = 0x20
+	opLocalSet    byte = 0x21
+	opLocalTee    byte = 0x22
+	opGlobalGet   byte = 0x23
+	opGlobalSet   byte = 0x24
+	opMemorySize  byte = 0x3f
+	opMemoryGrow  byte = 0x40
+	opI32Const    byte = 0x41
+	opI64Const    byte = 0x42
+	opPrefixFC    byte = 0xfc
+
+	opMemoryCopy uint32 = 10 // with the 0xfc prefix
+	opMemoryFill uint32 = 11 // with the 0xfc prefix
+
+	blockEmpty byte = 0x40 // the block type without the result
+)
+
+// isLoad reports whether the opcode is the supported (integer) memory load.
+func isLoad(op byte) bool { return op == 0x28 || op == 0x29 || (op >= 0x2c && op <= 0x35) }
+
+// isStore reports whether the opcode is the supported (integer) memory store.
+func isStore(op byte) bool { return op == 0x36 || op == 0x37 || (op >= 0x3a && op <= 0x3e) }
+
+// isNumeric reports whether the opcode is the supported integer instruction without immediates.
+func isNumeric(op byte) bool {
+	return (op >= 0x45 && op <= 0x5a) || // the comparisons
+		(op >= 0x67 && op <= 0x8a) || // the arithmetic
+		op == 0xa7 || op == 0xac || op == 0xad || // the conversions
+		(op >= 0xc0 && op <= 0xc4) // the sign extensions
+}
+
+// blockArity returns the number of results of the block type (only the empty and single value types are supported).
+func blockArity(t byte) (int, error) {
+	switch t {
+	case blockEmpty:
+		return 0, nil
+	case typeI32, typeI64:
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("unsupported block type 0x%02x", t)
+}
+
+// scan walks the function body once, rejects the unsupported instructions and records the positions of the
+// `else` and `end` instructions for every structured one, so the interpreter can jump without searching.
+func scan(f *function) error { //nolint:funlen,gocognit,gocyclo
+	var (
+		r       = reader{b: f.code}
+		nLocals = uint32(len(f.typ.params) + len(f.locals)) //nolint:gosec // limited by maxLocals
+
+		open []int // the positions of the open structured instructions
+	)
+
+	for !r.eof() {
+		var pos = r.pos
+
+		op, err := r.byte()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case op == opUnreachable, op == opNop, op == opReturn, op == opDrop, op == opSelect, isNumeric(op):
+		case op == opBlock, op == opLoop, op == opIf:
+			t, err := r.byte()
+			if err != nil {
+				return err
+			}
+
+			if _, err = blockArity(t); err != nil {
+				return err
+			}
+
+			open = append(open, pos)
+			f.blocks[pos] = block{}
+		case op == opElse:
+			if len(open) == 0 || f.code[open[len(open)-1]] != opIf {
+				return errors.New("else without if")
+			}
+
+			var b = f.blocks[open[len(open)-1]]
+
+			if b.elsePos != 0 {
+				return errors.New("duplicate else")
+			}
+
+			b.elsePos = pos
+			f.blocks[open[len(open)-1]] = b
+		case op == opEnd:
+			if len(open) == 0 { // the end of the function body
+				if !r.eof() {
+					return errors.New("instructions after the function end")
+				}
+
+				return nil
+			}
+
+			var b = f.blocks[open[len(open)-1]]
+
+			b.endPos = pos
+			f.blocks[open[len(open)-1]] = b
+			open = open[:len(open)-1]
+		case op == opBr, op == opBrIf:
+			if _, err = r.u32(); err != nil {
+				return err
+			}
+		case op == opBrTable:
+			n, err := r.u32()
+			if err != nil {
+				return err
+			}
+
+			if n > uint32(len(f.code)) { // every label takes at least one byte
+				return errUnexpectedEnd
+			}
+
+			var labels = make([]uint32, n+1) // the last one is the default
+
+			for i := range labels {
+				if labels[i], err = r.u32(); err != nil {
+					return err
+				}
+			}
+
+			f.brs[pos] = labels
+		case op == opCall, op == opGlobalGet, op == opGlobalSet:
+			if _, err = r.u32(); err != nil {
+				return err
+			}
+		case op == opLocalGet, op == opLocalSet, op == opLocalTee:
+			idx, err := r.u32()
+			if err != nil {
+				return err
+			}
+
+			if idx >= nLocals {
+				return fmt.Errorf("wrong local index %d", idx)
+			}
+		case isLoad(op), isStore(op):
+			if _, err = r.u32(); err != nil { // align
+				return err
+			}
+
+			if _, err = r.u32(); err != nil { // offset
+				return err
+			}
+		case op == opMemorySize, op == opMemoryGrow:
+			if _, err = r.byte(); err != nil { // the memory index
+				return err
+			}
+		case op == opI32Const:
+			if _, err = r.s32(); err != nil {
+				return err
+			}
+		case op == opI64Const:
+			if _, err = r.s64(); err != nil {
+				return err
+			}
+		case op == opPrefixFC:
+			sub, err := r.u32()
+			if err != nil {
+				return err
+			}
+
+			var indexes int
+
+			switch sub {
+			case opMemoryCopy:
+				indexes = 2
+			case opMemoryFill:
+				indexes = 1
+			default:
+				return fmt.Errorf("unsupported instruction 0xfc %d", sub)
+			}
+
+			if _, err = r.bytes(uint32(indexes)); err != nil { //nolint:gosec // 1 or 2
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported instruction 0x%02x at %d", op, pos)
+		}
+	}
+
+	return errors.New("function body is not terminated")
+}
@@ -0,0 +1,7 @@
+// Package winsvc provides the Windows service lifecycle handling (running the application as a service, its
+// installation and removal). On other platforms, the application never runs as a service, and the installation
+// is not supported.
+package winsvc
+
+// DefaultName is the default name of the Windows service.
+const DefaultName = "error-pages"
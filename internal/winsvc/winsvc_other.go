@@ -0,0 +1,22 @@
+//go:build !windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+)
+
+var errNotSupported = errors.New("windows services are supported only on Windows")
+
+// IsService reports whether the process is running as a Windows service (always false on this platform).
+func IsService() bool { return false }
+
+// Run is not supported on this platform.
+func Run(string, func(context.Context) error) error { return errNotSupported }
+
+// Install is not supported on this platform.
+func Install(string, ...string) error { return errNotSupported }
+
+// Uninstall is not supported on this platform.
+func Uninstall(string) error { return errNotSupported }
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package winsvc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/internal/winsvc"
+)
+
+func TestNotSupported(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, winsvc.IsService())
+	assert.Error(t, winsvc.Run(winsvc.DefaultName, func(context.Context) error { return nil }))
+	assert.Error(t, winsvc.Install(winsvc.DefaultName, "serve"))
+	assert.Error(t, winsvc.Uninstall(winsvc.DefaultName))
+}
@@ -0,0 +1,164 @@
+//go:build windows
+
+package winsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// IsService reports whether the process is running as a Windows service.
+func IsService() bool {
+	ok, err := svc.IsWindowsService()
+
+	return err == nil && ok
+}
+
+type handler struct {
+	run    func(context.Context) error
+	runErr error
+}
+
+// Execute implements the [svc.Handler] interface. The application is started in a separate goroutine with a
+// context that is canceled on the stop (or shutdown) request.
+func (h *handler) Execute(_ []string, req <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+
+	status <- svc.Status{State: svc.StartPending}
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan error, 1)
+	)
+
+	defer cancel()
+
+	go func() { done <- h.run(ctx) }()
+
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+
+	for {
+		select {
+		case err := <-done: // the application stopped by itself
+			h.runErr = err
+
+			if err != nil {
+				return true, 1 // service-specific exit code
+			}
+
+			return false, 0
+
+		case c := <-req:
+			switch c.Cmd { //nolint:exhaustive
+			case svc.Interrogate:
+				status <- c.CurrentStatus
+
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+
+				cancel()
+
+				h.runErr = <-done
+
+				return false, 0
+			}
+		}
+	}
+}
+
+// Run runs the function as a Windows service with the given name. The context passed to the function is canceled
+// when the service is requested to stop.
+func Run(name string, run func(context.Context) error) error {
+	var h = handler{run: run}
+
+	if err := svc.Run(name, &h); err != nil {
+		return err
+	}
+
+	return h.runErr
+}
+
+// Install registers the current executable as a Windows service with the given name. The args are passed to the
+// executable on the service start (e.g. `serve --port 8080`).
+func Install(name string, args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot detect the executable path: %w", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the service manager: %w", err)
+	}
+
+	defer func() { _ = m.Disconnect() }()
+
+	if s, openErr := m.OpenService(name); openErr == nil {
+		_ = s.Close()
+
+		return fmt.Errorf("service %s already exists", name)
+	}
+
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: "Error Pages",
+		Description: "HTTP server serving the error pages",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("cannot create the service: %w", err)
+	}
+
+	return s.Close()
+}
+
+// Uninstall stops (if running) and removes the Windows service with the given name.
+func Uninstall(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the service manager: %w", err)
+	}
+
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+
+	defer func() { _ = s.Close() }()
+
+	if st, qErr := s.Query(); qErr == nil && st.State != svc.Stopped {
+		if _, err = s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("cannot stop the service: %w", err)
+		}
+
+		const (
+			timeout  = 10 * time.Second
+			interval = 300 * time.Millisecond
+		)
+
+		for deadline := time.Now().Add(timeout); ; {
+			if st, qErr = s.Query(); qErr != nil || st.State == svc.Stopped {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				return errors.New("timeout waiting for the service to stop")
+			}
+
+			time.Sleep(interval)
+		}
+	}
+
+	if err = s.Delete(); err != nil {
+		return fmt.Errorf("cannot remove the service: %w", err)
+	}
+
+	return nil
+}
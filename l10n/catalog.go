@@ -0,0 +1,149 @@
+package l10n
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale of the original (untranslated) phrases.
+const DefaultLocale = "en"
+
+// catalog is a map of the phrase tokens to the translations (map[token]map[locale]translation).
+type catalog map[string]map[string]string
+
+var ( //nolint:gochecknoglobals
+	phraseRe      = regexp.MustCompile(`\[tkn\('((?:[^'\\]|\\.)*)'\),\s*new Map\(\[`)
+	translationRe = regexp.MustCompile(`\['([a-zA-Z-]+)',\s*'((?:[^'\\]|\\.)*)'\]`)
+	jsUnescaper   = strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\"`, `"`)
+
+	// the translations are parsed from the JS file (the single source of truth) on the first use
+	loadCatalog = sync.OnceValue(func() catalog { return parseCatalog(content) })
+)
+
+// parseCatalog extracts the translations from the `data` map of the JS file.
+func parseCatalog(js string) catalog {
+	var (
+		result = make(catalog)
+		phrase map[string]string
+	)
+
+	for _, line := range strings.Split(js, "\n") {
+		if m := phraseRe.FindStringSubmatch(line); m != nil {
+			phrase = make(map[string]string)
+			result[Token(unescape(m[1]))] = phrase
+
+			continue
+		}
+
+		if m := translationRe.FindStringSubmatch(line); m != nil && phrase != nil {
+			phrase[strings.ToLower(m[1])] = unescape(m[2])
+		}
+	}
+
+	return result
+}
+
+// unescape removes the JS string escaping (the single-quoted string content is expected).
+func unescape(s string) string { return jsUnescaper.Replace(s) }
+
+// Token converts the phrase into the token (lower case, only letters and digits), the same way the JS script does.
+func Token(phrase string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		} else if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return -1
+	}, phrase)
+}
+
+// Translate returns the phrase translated into the given locale. The phrase is returned as-is for the default
+// locale. If the translation is not found, false is returned.
+func Translate(locale, phrase string) (string, bool) {
+	if locale = NormalizeLocale(locale); locale == DefaultLocale {
+		return phrase, true
+	}
+
+	if translations, ok := loadCatalog()[Token(phrase)]; ok {
+		if translated, found := translations[locale]; found {
+			return translated, true
+		}
+	}
+
+	return "", false
+}
+
+// Export returns all the translations into the given locale (map[token]translation, the tokens are the same as
+// the JS script uses). For the default locale, an empty map is returned. If the locale is not supported, false is
+// returned.
+func Export(locale string) (map[string]string, bool) {
+	if locale = NormalizeLocale(locale); locale == DefaultLocale {
+		return map[string]string{}, true
+	} else if !HasLocale(locale) {
+		return nil, false
+	}
+
+	var result = make(map[string]string)
+
+	for token, translations := range loadCatalog() {
+		if translated, ok := translations[locale]; ok {
+			result[token] = translated
+		}
+	}
+
+	return result, true
+}
+
+// Locales returns a sorted list of all the supported locales (including the default one).
+func Locales() []string {
+	var set = map[string]struct{}{DefaultLocale: {}}
+
+	for _, translations := range loadCatalog() {
+		for locale := range translations {
+			set[locale] = struct{}{}
+		}
+	}
+
+	var list = make([]string, 0, len(set))
+
+	for locale := range set {
+		list = append(list, locale)
+	}
+
+	slices.Sort(list)
+
+	return list
+}
+
+// HasLocale checks whether the locale is supported.
+func HasLocale(locale string) bool {
+	if locale = NormalizeLocale(locale); locale == DefaultLocale {
+		return true
+	} else if locale == "" {
+		return false
+	}
+
+	for _, translations := range loadCatalog() {
+		if _, ok := translations[locale]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizeLocale converts the language tag (like `de-AT` or `PT_br`) into the locale name (two lower-case
+// letters, like `de`), the same way the JS script does.
+func NormalizeLocale(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if len(tag) > 2 { //nolint:mnd
+		tag = tag[:2]
+	}
+
+	return tag
+}
@@ -0,0 +1,86 @@
+package l10n_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveLocale, givePhrase string
+		want                   string
+		wantOk                 bool
+	}{
+		"de":               {giveLocale: "de", givePhrase: "Error", want: "Fehler", wantOk: true},
+		"de-AT":            {giveLocale: "de-AT", givePhrase: "Not Found", want: "Nicht gefunden", wantOk: true},
+		"token normalized": {giveLocale: "fr", givePhrase: "  eRRor!", want: "Erreur", wantOk: true},
+		"escaped quote":    {giveLocale: "it", givePhrase: "Double-check the URL", want: "Ricontrolla l'URL", wantOk: true},
+		"en":               {giveLocale: "en", givePhrase: "Whatever", want: "Whatever", wantOk: true},
+		"unknown phrase":   {giveLocale: "de", givePhrase: "Whatever"},
+		"unknown locale":   {giveLocale: "xx", givePhrase: "Error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := l10n.Translate(tt.giveLocale, tt.givePhrase)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLocales(t *testing.T) {
+	t.Parallel()
+
+	var locales = l10n.Locales()
+
+	for _, want := range []string{"de", "en", "fr", "it", "zh"} {
+		assert.Contains(t, locales, want)
+		assert.True(t, l10n.HasLocale(want))
+	}
+
+	assert.IsNonDecreasing(t, locales)
+	assert.False(t, l10n.HasLocale("xx"))
+	assert.False(t, l10n.HasLocale(""))
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	de, ok := l10n.Export("de-AT")
+	assert.True(t, ok)
+	assert.Equal(t, "Fehler", de[l10n.Token("Error")])
+
+	en, ok := l10n.Export("en")
+	assert.True(t, ok)
+	assert.Empty(t, en)
+
+	_, ok = l10n.Export("xx")
+	assert.False(t, ok)
+}
+
+func TestMatchAcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"de-DE,de;q=0.9,en;q=0.8": "de",
+		"xx, fr;q=0.5, en;q=0.7":  "en",
+		"fr;q=0.5, it":            "it",
+		"en-US":                   "en",
+		"xx, yy":                  "",
+		"de;q=0, fr;q=0.1":        "fr",
+		"":                        "",
+		"*":                       "",
+	} {
+		got, ok := l10n.MatchAcceptLanguage(give)
+
+		assert.Equal(t, want != "", ok, give)
+		assert.Equal(t, want, got, give)
+	}
+}
@@ -0,0 +1,42 @@
+package l10n
+
+// dateLayouts maps the locales to the date layouts (in the Go format) commonly used in these locales.
+var dateLayouts = map[string]string{ //nolint:gochecknoglobals
+	"en": "01/02/2006",
+	"de": "02.01.2006",
+	"ru": "02.01.2006",
+	"uk": "02.01.2006",
+	"pl": "02.01.2006",
+	"ro": "02.01.2006",
+	"no": "02.01.2006",
+	"fr": "02/01/2006",
+	"es": "02/01/2006",
+	"it": "02/01/2006",
+	"pt": "02/01/2006",
+	"id": "02/01/2006",
+	"nl": "02-01-2006",
+	"hu": "2006. 01. 02.",
+	"ko": "2006. 01. 02.",
+	"zh": "2006/01/02",
+	"ja": "2006/01/02",
+}
+
+// DateLayout returns the date layout (in the Go format, like `02.01.2006`) for the locale. The ISO 8601 layout
+// (`2006-01-02`) is returned for the unknown locales.
+func DateLayout(locale string) string {
+	if layout, ok := dateLayouts[NormalizeLocale(locale)]; ok {
+		return layout
+	}
+
+	return "2006-01-02"
+}
+
+// TimeLayout returns the time layout (in the Go format) for the locale: the 12-hour clock for the English locale,
+// and the 24-hour clock for the others.
+func TimeLayout(locale string) string {
+	if NormalizeLocale(locale) == DefaultLocale {
+		return "3:04 PM"
+	}
+
+	return "15:04"
+}
@@ -0,0 +1,27 @@
+package l10n_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+func TestDateTimeLayouts(t *testing.T) {
+	t.Parallel()
+
+	var moment = time.Date(2024, time.March, 5, 14, 3, 0, 0, time.UTC)
+
+	for locale, want := range map[string]string{
+		"en":    "03/05/2024 2:03 PM",
+		"de-AT": "05.03.2024 14:03",
+		"fr":    "05/03/2024 14:03",
+		"zh":    "2024/03/05 14:03",
+		"xx":    "2024-03-05 14:03",
+		"":      "2024-03-05 14:03",
+	} {
+		assert.Equal(t, want, moment.Format(l10n.DateLayout(locale)+" "+l10n.TimeLayout(locale)), locale)
+	}
+}
@@ -0,0 +1,18 @@
+package l10n
+
+import "slices"
+
+// rtlLocales is a list of the locales with the right-to-left writing direction.
+var rtlLocales = []string{"ar", "dv", "fa", "he", "ps", "ur", "yi"} //nolint:gochecknoglobals
+
+// IsRTL checks whether the locale uses the right-to-left writing direction.
+func IsRTL(locale string) bool { return slices.Contains(rtlLocales, NormalizeLocale(locale)) }
+
+// Direction returns the text direction for the locale (`rtl` or `ltr`), ready to use in the HTML `dir` attribute.
+func Direction(locale string) string {
+	if IsRTL(locale) {
+		return "rtl"
+	}
+
+	return "ltr"
+}
@@ -0,0 +1,25 @@
+package l10n_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+func TestDirection(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"ar":    "rtl",
+		"he-IL": "rtl",
+		"FA":    "rtl",
+		"en":    "ltr",
+		"de":    "ltr",
+		"":      "ltr",
+	} {
+		assert.Equal(t, want, l10n.Direction(give), give)
+		assert.Equal(t, want == "rtl", l10n.IsRTL(give), give)
+	}
+}
@@ -0,0 +1,234 @@
+package l10n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format formats the message using the subset of the ICU MessageFormat syntax:
+//
+//   - `{name}` - the argument value
+//   - `{name, plural, =0 {no items} one {# item} other {# items}}` - the plural form (the `#` is replaced with the
+//     number); the exact matches (`=N`) take precedence over the locale plural categories
+//   - `{name, select, foo {…} other {…}}` - the message selected by the argument value
+//
+// The placeholders with missing arguments and the malformed ones are kept as-is. The ICU quoting (apostrophes)
+// is not supported, so the apostrophes are always literal.
+func Format(locale, message string, args map[string]any) string {
+	if !strings.ContainsRune(message, '{') {
+		return message
+	}
+
+	var b strings.Builder
+
+	b.Grow(len(message))
+
+	for i := 0; i < len(message); {
+		if message[i] != '{' {
+			b.WriteByte(message[i])
+			i++
+
+			continue
+		}
+
+		var end = closingBrace(message, i)
+		if end < 0 { // unbalanced braces - write the rest as-is
+			b.WriteString(message[i:])
+
+			break
+		}
+
+		if formatted, ok := formatArg(locale, message[i+1:end], args); ok {
+			b.WriteString(formatted)
+		} else {
+			b.WriteString(message[i : end+1])
+		}
+
+		i = end + 1
+	}
+
+	return b.String()
+}
+
+// formatArg formats the placeholder content (without the outer braces).
+func formatArg(locale, arg string, args map[string]any) (string, bool) {
+	var parts = splitTopLevel(arg, 3) //nolint:mnd // name, type, style
+
+	var value, ok = args[strings.TrimSpace(parts[0])]
+	if !ok {
+		return "", false
+	}
+
+	if len(parts) == 1 {
+		return fmt.Sprint(value), true
+	}
+
+	if len(parts) != 3 { //nolint:mnd
+		return "", false
+	}
+
+	var options, optOk = parseOptions(parts[2])
+	if !optOk {
+		return "", false
+	}
+
+	switch strings.TrimSpace(parts[1]) {
+	case "plural":
+		n, isNum := toInt(value)
+		if !isNum {
+			return "", false
+		}
+
+		var msg, found = options["="+strconv.FormatInt(n, 10)]
+
+		if !found {
+			if msg, found = options[PluralCategory(locale, n)]; !found {
+				if msg, found = options[PluralOther]; !found {
+					return "", false
+				}
+			}
+		}
+
+		return Format(locale, replaceHash(msg, strconv.FormatInt(n, 10)), args), true
+
+	case "select":
+		var msg, found = options[fmt.Sprint(value)]
+
+		if !found {
+			if msg, found = options[PluralOther]; !found {
+				return "", false
+			}
+		}
+
+		return Format(locale, msg, args), true
+	}
+
+	return "", false
+}
+
+// parseOptions parses the plural/select options (`one {…} other {…}`) into the map[selector]message.
+func parseOptions(s string) (map[string]string, bool) {
+	var result = make(map[string]string)
+
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var open = strings.IndexByte(s, '{')
+		if open < 1 {
+			return nil, false
+		}
+
+		var end = closingBrace(s, open)
+		if end < 0 {
+			return nil, false
+		}
+
+		result[strings.TrimSpace(s[:open])] = s[open+1 : end]
+		s = s[end+1:]
+	}
+
+	return result, len(result) > 0
+}
+
+// closingBrace returns the index of the brace that closes the one at the given index, or -1.
+func closingBrace(s string, open int) int {
+	var depth = 0
+
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitTopLevel splits the string by commas that are not inside braces (up to n parts).
+func splitTopLevel(s string, n int) []string {
+	var (
+		parts = make([]string, 0, n)
+		depth = 0
+		start = 0
+	)
+
+	for i := 0; i < len(s) && len(parts) < n-1; i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// replaceHash replaces the `#` characters that are not inside the nested placeholders with the number.
+func replaceHash(s, number string) string {
+	var (
+		b     strings.Builder
+		depth = 0
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '#' && depth == 0:
+			b.WriteString(number)
+
+			continue
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+// toInt converts the value into the integer number (if possible).
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true //nolint:gosec
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true //nolint:gosec
+	case float32:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+
+		return i, err == nil
+	}
+
+	return 0, false
+}
@@ -0,0 +1,77 @@
+package l10n_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	const seconds = "Retry in {n, plural, =0 {a moment} one {# second} other {# seconds}}"
+
+	for name, tt := range map[string]struct {
+		giveLocale, giveMessage string
+		giveArgs                map[string]any
+		want                    string
+	}{
+		"no placeholders":  {giveMessage: "Hello, world", want: "Hello, world"},
+		"interpolation":    {giveMessage: "Error {code} on {host}", giveArgs: map[string]any{"code": 404, "host": "example.com"}, want: "Error 404 on example.com"},
+		"missing argument": {giveMessage: "Error {code}", want: "Error {code}"},
+		"unbalanced":       {giveMessage: "Error {code", giveArgs: map[string]any{"code": 1}, want: "Error {code"},
+		"apostrophe":       {giveMessage: "l'URL {x}", giveArgs: map[string]any{"x": "y"}, want: "l'URL y"},
+		"plural one":       {giveLocale: "en", giveMessage: seconds, giveArgs: map[string]any{"n": 1}, want: "Retry in 1 second"},
+		"plural other":     {giveLocale: "en", giveMessage: seconds, giveArgs: map[string]any{"n": 30}, want: "Retry in 30 seconds"},
+		"plural exact":     {giveLocale: "en", giveMessage: seconds, giveArgs: map[string]any{"n": "0"}, want: "Retry in a moment"},
+		"plural not a num": {giveLocale: "en", giveMessage: seconds, giveArgs: map[string]any{"n": "foo"}, want: seconds},
+		"plural ru few": {
+			giveLocale:  "ru",
+			giveMessage: "{n, plural, one {# секунда} few {# секунды} many {# секунд} other {# секунды}}",
+			giveArgs:    map[string]any{"n": uint16(22)},
+			want:        "22 секунды",
+		},
+		"plural nested": {
+			giveLocale:  "en",
+			giveMessage: "{n, plural, one {# item on {host}} other {# items on {host}}}",
+			giveArgs:    map[string]any{"n": 2, "host": "#example"},
+			want:        "2 items on #example",
+		},
+		"select": {
+			giveMessage: "{kind, select, client {Your fault} other {Our fault}}",
+			giveArgs:    map[string]any{"kind": "server"},
+			want:        "Our fault",
+		},
+		"malformed plural": {giveMessage: "{n, plural, one}", giveArgs: map[string]any{"n": 1}, want: "{n, plural, one}"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, l10n.Format(tt.giveLocale, tt.giveMessage, tt.giveArgs))
+		})
+	}
+}
+
+func TestPluralCategory(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		locale string
+		n      int64
+		want   string
+	}{
+		{"en", 1, l10n.PluralOne}, {"en", 0, l10n.PluralOther}, {"en", 2, l10n.PluralOther},
+		{"fr", 0, l10n.PluralOne}, {"fr", 2, l10n.PluralOther},
+		{"ru", 1, l10n.PluralOne}, {"ru", 11, l10n.PluralMany}, {"ru", 23, l10n.PluralFew}, {"ru", 25, l10n.PluralMany},
+		{"pl", 1, l10n.PluralOne}, {"pl", 21, l10n.PluralMany}, {"pl", 4, l10n.PluralFew},
+		{"ro", 0, l10n.PluralFew}, {"ro", 19, l10n.PluralFew}, {"ro", 20, l10n.PluralOther},
+		{"ar", 0, l10n.PluralZero}, {"ar", 2, l10n.PluralTwo}, {"ar", 105, l10n.PluralFew}, {"ar", 111, l10n.PluralMany},
+		{"he", 2, l10n.PluralTwo},
+		{"zh", 1, l10n.PluralOther},
+		{"en", -1, l10n.PluralOne},
+	} {
+		assert.Equal(t, tt.want, l10n.PluralCategory(tt.locale, tt.n), "%s %d", tt.locale, tt.n)
+	}
+}
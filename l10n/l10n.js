@@ -1296,8 +1296,20 @@ Object.defineProperty(window, 'l10n', {
       ])],
     ]));
 
-    // detect browser locale (take only 2 first symbols)
-    let activeLocale = navigator.language.substring(0, 2).toLowerCase();
+    /**
+     * Locales with the right-to-left writing direction.
+     *
+     * @type {string[]}
+     */
+    const rtlLocales = ['ar', 'dv', 'fa', 'he', 'ps', 'ur', 'yi'];
+
+    // detect the locale (take only 2 first symbols): the `lang` query parameter and cookie (set by the server when
+    // the locale is overridden) take precedence over the browser locale
+    let activeLocale = (
+      new URLSearchParams(window.location.search).get('lang')
+      || (document.cookie.match(/(?:^|;\s*)lang=([^;]+)/) || [])[1]
+      || navigator.language
+    ).substring(0, 2).toLowerCase();
 
     // noinspection JSUnusedGlobalSymbols
     /**
@@ -1340,6 +1352,7 @@ Object.defineProperty(window, 'l10n', {
 
       const l10nAttr = 'data-l10n'; // using this attribute we understand that this element should be localized
       const l10nOriginalTextAttr = 'data-l10n-original'; // to keep the original text
+      let localizedCount = 0;
 
       // loop through all elements with the `data-l10n` attribute
       Array.prototype.forEach.call(document.querySelectorAll('[' + l10nAttr + ']'), ($el) => {
@@ -1360,10 +1373,17 @@ Object.defineProperty(window, 'l10n', {
 
         if (localized) {
           $el.innerText = localized; // set the translated text
+          localizedCount++;
         } else {
           console.debug(`Unsupported l10n token detected: "${token}" (locale "${activeLocale}")`, $el);
         }
       });
+
+      // switch the text direction (and the page language) only when the page is (at least partially) translated
+      if (localizedCount > 0) {
+        document.documentElement.dir = rtlLocales.includes(activeLocale) ? 'rtl' : 'ltr';
+        document.documentElement.lang = activeLocale;
+      }
     };
   },
   writable: false,
@@ -0,0 +1,50 @@
+package l10n
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MatchAcceptLanguage returns the most preferred supported locale from the `Accept-Language` header value
+// (the weights are respected). If none of the languages is supported, false is returned.
+func MatchAcceptLanguage(header string) (string, bool) {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var list = make([]weighted, 0, strings.Count(header, ",")+1)
+
+	for _, item := range strings.Split(header, ",") {
+		var tag, params, _ = strings.Cut(strings.TrimSpace(item), ";")
+
+		if tag = strings.TrimSpace(tag); tag == "" || tag == "*" {
+			continue
+		}
+
+		var q = 1.0
+
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+
+		if q > 0 {
+			list = append(list, weighted{locale: NormalizeLocale(tag), q: q})
+		}
+	}
+
+	// the stable sort keeps the original order for the same weights
+	slices.SortStableFunc(list, func(a, b weighted) int { return cmp.Compare(b.q, a.q) })
+
+	for _, item := range list {
+		if HasLocale(item.locale) {
+			return item.locale, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,697 @@
+package l10n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// groupSeparators maps the locales to the digit group separators (CLDR), the comma is used for the others.
+var groupSeparators = map[string]string{ //nolint:gochecknoglobals
+	"de": ".", "es": ".", "id": ".", "it": ".", "nl": ".", "pt": ".", "ro": ".",
+	"fr": "\u202f", // the narrow no-break space
+	// the no-break space
+	"hu": "\u00a0", "no": "\u00a0", "pl": "\u00a0", "ru": "\u00a0", "uk": "\u00a0",
+}
+
+// FormatNumber formats the integer number using the digit grouping of the locale (like `1,234` for `en`, `1.234`
+// for `de`, or `1 234` for `ru`). The numbers with four digits are not grouped for the `es` and `pl` locales.
+func FormatNumber(locale string, n int64) string {
+	locale = NormalizeLocale(locale)
+
+	var digits, sign = strconv.FormatInt(n, 10), ""
+
+	if n < 0 {
+		digits, sign = digits[1:], "-"
+	}
+
+	if len(digits) <= 3 || (len(digits) == 4 && (locale == "es" || locale == "pl")) { //nolint:mnd
+		return sign + digits
+	}
+
+	var separator, ok = groupSeparators[locale]
+	if !ok {
+		separator = ","
+	}
+
+	var b strings.Builder
+
+	b.WriteString(sign)
+
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+
+		b.WriteRune(d)
+	}
+
+	return b.String()
+}
+
+// Ordinal formats the integer number as the ordinal one, using the locale conventions (like `503rd` for `en`,
+// `503.` for `de`, or `503e` for `fr`).
+func Ordinal(locale string, n int64) string { //nolint:cyclop
+	var s = strconv.FormatInt(n, 10)
+
+	switch NormalizeLocale(locale) {
+	case "de", "hu", "no", "pl":
+		return s + "."
+	case "fr":
+		if n == 1 {
+			return s + "er"
+		}
+
+		return s + "e"
+	case "nl":
+		return s + "e"
+	case "es":
+		return s + ".º"
+	case "it", "pt":
+		return s + "º"
+	case "ro":
+		if n == 1 {
+			return "primul"
+		}
+
+		return "al " + s + "-lea"
+	case "ru", "uk":
+		return s + "-й"
+	case "id":
+		return "ke-" + s
+	case "ko":
+		return s + "번째"
+	case "zh":
+		return "第" + s
+	}
+
+	if mod100 := n % 100; mod100 >= 11 && mod100 <= 13 { //nolint:mnd
+		return s + "th"
+	}
+
+	switch n % 10 { //nolint:mnd
+	case 1:
+		return s + "st"
+	case 2: //nolint:mnd
+		return s + "nd"
+	case 3: //nolint:mnd
+		return s + "rd"
+	}
+
+	return s + "th"
+}
+
+// maxSpelledNumber is the largest number that can be spelled out (the HTTP codes have three digits).
+const maxSpelledNumber = 999
+
+// SpellNumber spells out the integer number from 0 to 999 in words of the locale (like `five hundred three` for
+// `en`, or `fünfhundertdrei` for `de`), for the decorative typography. False is returned for the numbers out of
+// the range.
+func SpellNumber(locale string, n int64) (string, bool) {
+	if n < 0 || n > maxSpelledNumber {
+		return "", false
+	}
+
+	var spell, ok = spellers[NormalizeLocale(locale)]
+	if !ok {
+		spell = spellEnglish
+	}
+
+	return spell(int(n)), true
+}
+
+// spellers map the locales to the functions spelling out the numbers from 0 to 999.
+var spellers = map[string]func(n int) string{ //nolint:gochecknoglobals
+	"de": spellGerman,
+	"es": spellSpanish,
+	"fr": spellFrench,
+	"hu": spellHungarian,
+	"id": spellIndonesian,
+	"it": spellItalian,
+	"ko": spellKorean,
+	"nl": spellDutch,
+	"no": spellNorwegian,
+	"pl": spellSlavic(
+		"zero",
+		[...]string{"", "jeden", "dwa", "trzy", "cztery", "pięć", "sześć", "siedem", "osiem", "dziewięć", "dziesięć",
+			"jedenaście", "dwanaście", "trzynaście", "czternaście", "piętnaście", "szesnaście", "siedemnaście",
+			"osiemnaście", "dziewiętnaście"},
+		[...]string{"", "", "dwadzieścia", "trzydzieści", "czterdzieści", "pięćdziesiąt", "sześćdziesiąt",
+			"siedemdziesiąt", "osiemdziesiąt", "dziewięćdziesiąt"},
+		[...]string{"", "sto", "dwieście", "trzysta", "czterysta", "pięćset", "sześćset", "siedemset", "osiemset",
+			"dziewięćset"},
+	),
+	"pt": spellPortuguese,
+	"ro": spellRomanian,
+	"ru": spellSlavic(
+		"ноль",
+		[...]string{"", "один", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять", "десять",
+			"одиннадцать", "двенадцать", "тринадцать", "четырнадцать", "пятнадцать", "шестнадцать", "семнадцать",
+			"восемнадцать", "девятнадцать"},
+		[...]string{"", "", "двадцать", "тридцать", "сорок", "пятьдесят", "шестьдесят", "семьдесят", "восемьдесят",
+			"девяносто"},
+		[...]string{"", "сто", "двести", "триста", "четыреста", "пятьсот", "шестьсот", "семьсот", "восемьсот",
+			"девятьсот"},
+	),
+	"uk": spellSlavic(
+		"нуль",
+		[...]string{"", "один", "два", "три", "чотири", "п’ять", "шість", "сім", "вісім", "дев’ять", "десять",
+			"одинадцять", "дванадцять", "тринадцять", "чотирнадцять", "п’ятнадцять", "шістнадцять", "сімнадцять",
+			"вісімнадцять", "дев’ятнадцять"},
+		[...]string{"", "", "двадцять", "тридцять", "сорок", "п’ятдесят", "шістдесят", "сімдесят", "вісімдесят",
+			"дев’яносто"},
+		[...]string{"", "сто", "двісті", "триста", "чотириста", "п’ятсот", "шістсот", "сімсот", "вісімсот",
+			"дев’ятсот"},
+	),
+	"zh": spellChinese,
+}
+
+// join joins the non-empty words with the separator.
+func join(sep string, words ...string) string {
+	var parts = make([]string, 0, len(words))
+
+	for _, w := range words {
+		if w != "" {
+			parts = append(parts, w)
+		}
+	}
+
+	return strings.Join(parts, sep)
+}
+
+func spellEnglish(n int) string {
+	var (
+		ones = [...]string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+			"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+		tens = [...]string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		}
+
+		return tens[n/10] + "-" + ones[n%10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	return join(" ", ones[n/100]+" hundred", below100(n%100))
+}
+
+func spellGerman(n int) string {
+	var (
+		ones = [...]string{"null", "eins", "zwei", "drei", "vier", "fünf", "sechs", "sieben", "acht", "neun", "zehn",
+			"elf", "zwölf", "dreizehn", "vierzehn", "fünfzehn", "sechzehn", "siebzehn", "achtzehn", "neunzehn"}
+		tens = [...]string{"", "", "zwanzig", "dreißig", "vierzig", "fünfzig", "sechzig", "siebzig", "achtzig",
+			"neunzig"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		case n%10 == 1:
+			return "einund" + tens[n/10]
+		}
+
+		return ones[n%10] + "und" + tens[n/10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	var hundreds = ones[n/100]
+
+	if n/100 == 1 {
+		hundreds = "ein"
+	}
+
+	return hundreds + "hundert" + below100(n%100)
+}
+
+func spellFrench(n int) string {
+	var ones = [...]string{"zéro", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf", "dix",
+		"onze", "douze", "treize", "quatorze", "quinze", "seize", "dix-sept", "dix-huit", "dix-neuf"}
+
+	var tens = [...]string{"", "", "vingt", "trente", "quarante", "cinquante", "soixante"}
+
+	var below100 = func(n int, last bool) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n < 70: //nolint:mnd
+			switch n % 10 {
+			case 0:
+				return tens[n/10]
+			case 1:
+				return tens[n/10] + " et un"
+			}
+
+			return tens[n/10] + "-" + ones[n%10]
+		case n < 80: //nolint:mnd
+			if n == 71 { //nolint:mnd
+				return "soixante et onze"
+			}
+
+			return "soixante-" + ones[n-60]
+		case n == 80: //nolint:mnd
+			if last {
+				return "quatre-vingts"
+			}
+
+			return "quatre-vingt"
+		}
+
+		return "quatre-vingt-" + ones[n-80]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n, true)
+	}
+
+	var hundreds = "cent"
+
+	if n/100 > 1 {
+		hundreds = ones[n/100] + " cent"
+
+		if n%100 == 0 {
+			hundreds += "s"
+		}
+	}
+
+	return join(" ", hundreds, below100(n%100, true))
+}
+
+func spellSpanish(n int) string {
+	var (
+		ones = [...]string{"cero", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve", "diez",
+			"once", "doce", "trece", "catorce", "quince", "dieciséis", "diecisiete", "dieciocho", "diecinueve",
+			"veinte", "veintiuno", "veintidós", "veintitrés", "veinticuatro", "veinticinco", "veintiséis",
+			"veintisiete", "veintiocho", "veintinueve"}
+		tens = [...]string{"", "", "", "treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta",
+			"noventa"}
+		hundreds = [...]string{"", "ciento", "doscientos", "trescientos", "cuatrocientos", "quinientos",
+			"seiscientos", "setecientos", "ochocientos", "novecientos"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 30: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		}
+
+		return tens[n/10] + " y " + ones[n%10]
+	}
+
+	switch {
+	case n == 0:
+		return ones[0]
+	case n < 100: //nolint:mnd
+		return below100(n)
+	case n == 100: //nolint:mnd
+		return "cien"
+	}
+
+	return join(" ", hundreds[n/100], below100(n%100))
+}
+
+func spellItalian(n int) string {
+	var (
+		ones = [...]string{"zero", "uno", "due", "tre", "quattro", "cinque", "sei", "sette", "otto", "nove", "dieci",
+			"undici", "dodici", "tredici", "quattordici", "quindici", "sedici", "diciassette", "diciotto",
+			"diciannove"}
+		tens = [...]string{"", "", "venti", "trenta", "quaranta", "cinquanta", "sessanta", "settanta", "ottanta",
+			"novanta"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		case n%10 == 1 || n%10 == 8: // the final vowel is dropped before `uno` and `otto`
+			return tens[n/10][:len(tens[n/10])-1] + ones[n%10]
+		case n%10 == 3: //nolint:mnd
+			return tens[n/10] + "tré"
+		}
+
+		return tens[n/10] + ones[n%10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	var hundreds = "cento"
+
+	if n/100 > 1 {
+		hundreds = ones[n/100] + "cento"
+	}
+
+	var rest = below100(n % 100)
+
+	if strings.HasPrefix(rest, "ott") { // `centotto`, `centottanta`
+		hundreds = hundreds[:len(hundreds)-1]
+	} else if rest == "tre" {
+		rest = "tré"
+	}
+
+	return hundreds + rest
+}
+
+func spellPortuguese(n int) string {
+	var (
+		ones = [...]string{"zero", "um", "dois", "três", "quatro", "cinco", "seis", "sete", "oito", "nove", "dez",
+			"onze", "doze", "treze", "catorze", "quinze", "dezesseis", "dezessete", "dezoito", "dezenove"}
+		tens = [...]string{"", "", "vinte", "trinta", "quarenta", "cinquenta", "sessenta", "setenta", "oitenta",
+			"noventa"}
+		hundreds = [...]string{"", "cento", "duzentos", "trezentos", "quatrocentos", "quinhentos", "seiscentos",
+			"setecentos", "oitocentos", "novecentos"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		}
+
+		return tens[n/10] + " e " + ones[n%10]
+	}
+
+	switch {
+	case n == 0:
+		return ones[0]
+	case n < 100: //nolint:mnd
+		return below100(n)
+	case n == 100: //nolint:mnd
+		return "cem"
+	case n%100 == 0:
+		return hundreds[n/100]
+	}
+
+	return hundreds[n/100] + " e " + below100(n%100)
+}
+
+func spellDutch(n int) string {
+	var (
+		ones = [...]string{"nul", "een", "twee", "drie", "vier", "vijf", "zes", "zeven", "acht", "negen", "tien",
+			"elf", "twaalf", "dertien", "veertien", "vijftien", "zestien", "zeventien", "achttien", "negentien"}
+		tens = [...]string{"", "", "twintig", "dertig", "veertig", "vijftig", "zestig", "zeventig", "tachtig",
+			"negentig"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		case strings.HasSuffix(ones[n%10], "e"): // `tweeëntwintig`, `drieëndertig`
+			return ones[n%10] + "ën" + tens[n/10]
+		}
+
+		return ones[n%10] + "en" + tens[n/10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	var hundreds = "honderd"
+
+	if n/100 > 1 {
+		hundreds = ones[n/100] + "honderd"
+	}
+
+	return hundreds + below100(n%100)
+}
+
+func spellNorwegian(n int) string {
+	var (
+		ones = [...]string{"null", "en", "to", "tre", "fire", "fem", "seks", "sju", "åtte", "ni", "ti", "elleve",
+			"tolv", "tretten", "fjorten", "femten", "seksten", "sytten", "atten", "nitten"}
+		tens = [...]string{"", "", "tjue", "tretti", "førti", "femti", "seksti", "sytti", "åtti", "nitti"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		}
+
+		return tens[n/10] + ones[n%10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	var hundreds = "hundre"
+
+	if n/100 > 1 {
+		hundreds = ones[n/100] + " hundre"
+	}
+
+	if n%100 == 0 {
+		return hundreds
+	}
+
+	return hundreds + " og " + below100(n%100)
+}
+
+func spellRomanian(n int) string {
+	var (
+		ones = [...]string{"zero", "unu", "doi", "trei", "patru", "cinci", "șase", "șapte", "opt", "nouă", "zece",
+			"unsprezece", "doisprezece", "treisprezece", "paisprezece", "cincisprezece", "șaisprezece",
+			"șaptesprezece", "optsprezece", "nouăsprezece"}
+		tens = [...]string{"", "", "douăzeci", "treizeci", "patruzeci", "cincizeci", "șaizeci", "șaptezeci",
+			"optzeci", "nouăzeci"}
+		hundreds = [...]string{"", "o sută", "două sute", "trei sute", "patru sute", "cinci sute", "șase sute",
+			"șapte sute", "opt sute", "nouă sute"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 20: //nolint:mnd
+			return ones[n]
+		case n%10 == 0:
+			return tens[n/10]
+		}
+
+		return tens[n/10] + " și " + ones[n%10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	return join(" ", hundreds[n/100], below100(n%100))
+}
+
+func spellHungarian(n int) string {
+	var (
+		ones = [...]string{"nulla", "egy", "kettő", "három", "négy", "öt", "hat", "hét", "nyolc", "kilenc"}
+		tens = [...]string{"", "tizen", "huszon", "harminc", "negyven", "ötven", "hatvan", "hetven", "nyolcvan",
+			"kilencven"}
+	)
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 10: //nolint:mnd
+			return ones[n]
+		case n == 10: //nolint:mnd
+			return "tíz"
+		case n == 20: //nolint:mnd
+			return "húsz"
+		case n%10 == 0:
+			return tens[n/10]
+		}
+
+		return tens[n/10] + ones[n%10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	var hundreds = "száz"
+
+	switch n / 100 {
+	case 1:
+	case 2: //nolint:mnd
+		hundreds = "kétszáz"
+	default:
+		hundreds = ones[n/100] + "száz"
+	}
+
+	return hundreds + below100(n%100)
+}
+
+func spellIndonesian(n int) string {
+	var ones = [...]string{"nol", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan"}
+
+	var below100 = func(n int) string {
+		switch {
+		case n == 0:
+			return ""
+		case n < 10: //nolint:mnd
+			return ones[n]
+		case n == 10: //nolint:mnd
+			return "sepuluh"
+		case n == 11: //nolint:mnd
+			return "sebelas"
+		case n < 20: //nolint:mnd
+			return ones[n%10] + " belas"
+		case n%10 == 0:
+			return ones[n/10] + " puluh"
+		}
+
+		return ones[n/10] + " puluh " + ones[n%10]
+	}
+
+	if n == 0 {
+		return ones[0]
+	} else if n < 100 { //nolint:mnd
+		return below100(n)
+	}
+
+	var hundreds = "seratus"
+
+	if n/100 > 1 {
+		hundreds = ones[n/100] + " ratus"
+	}
+
+	return join(" ", hundreds, below100(n%100))
+}
+
+func spellKorean(n int) string {
+	var digits = [...]string{"영", "일", "이", "삼", "사", "오", "육", "칠", "팔", "구"}
+
+	if n == 0 {
+		return digits[0]
+	}
+
+	var b strings.Builder
+
+	for _, unit := range [...]struct {
+		value int
+		name  string
+	}{{100, "백"}, {10, "십"}, {1, ""}} {
+		var d = n / unit.value % 10 //nolint:mnd
+
+		if d == 0 {
+			continue
+		}
+
+		if d > 1 || unit.value == 1 { // `백` and `십` instead of `일백` and `일십`
+			b.WriteString(digits[d])
+		}
+
+		b.WriteString(unit.name)
+	}
+
+	return b.String()
+}
+
+func spellChinese(n int) string {
+	var digits = [...]string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+	if n == 0 {
+		return digits[0]
+	}
+
+	var (
+		h, t, u = n / 100, n / 10 % 10, n % 10 //nolint:mnd
+		b       strings.Builder
+	)
+
+	if h > 0 {
+		b.WriteString(digits[h] + "百")
+	}
+
+	switch {
+	case t > 0:
+		if h > 0 || t > 1 { // `十五`, but `一百一十五`
+			b.WriteString(digits[t])
+		}
+
+		b.WriteString("十")
+	case h > 0 && u > 0: // `五百零三`
+		b.WriteString(digits[0])
+	}
+
+	if u > 0 {
+		b.WriteString(digits[u])
+	}
+
+	return b.String()
+}
+
+// spellSlavic returns the function spelling out the numbers in the Slavic languages (the words are separated with
+// spaces, and the units below 20 and tens have their own words).
+func spellSlavic(zero string, ones [20]string, tens, hundreds [10]string) func(n int) string {
+	return func(n int) string {
+		if n == 0 {
+			return zero
+		}
+
+		var below100 = n % 100 //nolint:mnd
+
+		if below100 < 20 { //nolint:mnd
+			return join(" ", hundreds[n/100], ones[below100])
+		}
+
+		return join(" ", hundreds[n/100], tens[below100/10], ones[below100%10])
+	}
+}
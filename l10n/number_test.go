@@ -0,0 +1,140 @@
+package l10n_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+func TestFormatNumber(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveLocale string
+		giveNumber int64
+		want       string
+	}{
+		"en":              {giveLocale: "en", giveNumber: 1234567, want: "1,234,567"},
+		"en small":        {giveLocale: "en", giveNumber: 503, want: "503"},
+		"en negative":     {giveLocale: "en", giveNumber: -1234, want: "-1,234"},
+		"de":              {giveLocale: "de-AT", giveNumber: 1234567, want: "1.234.567"},
+		"fr":              {giveLocale: "fr", giveNumber: 12345, want: "12 345"},
+		"ru":              {giveLocale: "ru", giveNumber: 12345, want: "12 345"},
+		"es four digits":  {giveLocale: "es", giveNumber: 1234, want: "1234"},
+		"es five digits":  {giveLocale: "es", giveNumber: 12345, want: "12.345"},
+		"unknown locale":  {giveLocale: "xx", giveNumber: 1234, want: "1,234"},
+		"pl four digits":  {giveLocale: "pl", giveNumber: 1234, want: "1234"},
+		"zh grouped":      {giveLocale: "zh", giveNumber: 1234, want: "1,234"},
+		"id five digits":  {giveLocale: "id", giveNumber: 10000, want: "10.000"},
+		"no seven digits": {giveLocale: "no", giveNumber: 1000000, want: "1 000 000"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, l10n.FormatNumber(tt.giveLocale, tt.giveNumber))
+		})
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveLocale string
+		giveNumber int64
+		want       string
+	}{
+		"en 1":   {giveLocale: "en", giveNumber: 1, want: "1st"},
+		"en 2":   {giveLocale: "en", giveNumber: 502, want: "502nd"},
+		"en 3":   {giveLocale: "en", giveNumber: 503, want: "503rd"},
+		"en 11":  {giveLocale: "en", giveNumber: 411, want: "411th"},
+		"en 404": {giveLocale: "en", giveNumber: 404, want: "404th"},
+		"de":     {giveLocale: "de", giveNumber: 503, want: "503."},
+		"fr 1":   {giveLocale: "fr", giveNumber: 1, want: "1er"},
+		"fr":     {giveLocale: "fr", giveNumber: 503, want: "503e"},
+		"es":     {giveLocale: "es", giveNumber: 503, want: "503.º"},
+		"ro":     {giveLocale: "ro", giveNumber: 503, want: "al 503-lea"},
+		"ru":     {giveLocale: "ru", giveNumber: 503, want: "503-й"},
+		"zh":     {giveLocale: "zh", giveNumber: 503, want: "第503"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, l10n.Ordinal(tt.giveLocale, tt.giveNumber))
+		})
+	}
+}
+
+func TestSpellNumber(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveLocale string
+		giveNumber int64
+		want       string
+	}{
+		"en 0":   {giveLocale: "en", giveNumber: 0, want: "zero"},
+		"en 503": {giveLocale: "en", giveNumber: 503, want: "five hundred three"},
+		"en 421": {giveLocale: "en", giveNumber: 421, want: "four hundred twenty-one"},
+		"en 400": {giveLocale: "en", giveNumber: 400, want: "four hundred"},
+		"de 503": {giveLocale: "de", giveNumber: 503, want: "fünfhundertdrei"},
+		"de 421": {giveLocale: "de", giveNumber: 421, want: "vierhunderteinundzwanzig"},
+		"de 101": {giveLocale: "de", giveNumber: 101, want: "einhunderteins"},
+		"fr 503": {giveLocale: "fr", giveNumber: 503, want: "cinq cent trois"},
+		"fr 500": {giveLocale: "fr", giveNumber: 500, want: "cinq cents"},
+		"fr 71":  {giveLocale: "fr", giveNumber: 71, want: "soixante et onze"},
+		"fr 80":  {giveLocale: "fr", giveNumber: 80, want: "quatre-vingts"},
+		"fr 497": {giveLocale: "fr", giveNumber: 497, want: "quatre cent quatre-vingt-dix-sept"},
+		"es 100": {giveLocale: "es", giveNumber: 100, want: "cien"},
+		"es 503": {giveLocale: "es", giveNumber: 503, want: "quinientos tres"},
+		"es 421": {giveLocale: "es", giveNumber: 421, want: "cuatrocientos veintiuno"},
+		"es 451": {giveLocale: "es", giveNumber: 451, want: "cuatrocientos cincuenta y uno"},
+		"it 503": {giveLocale: "it", giveNumber: 503, want: "cinquecentotré"},
+		"it 421": {giveLocale: "it", giveNumber: 421, want: "quattrocentoventuno"},
+		"it 408": {giveLocale: "it", giveNumber: 408, want: "quattrocentotto"},
+		"pt 100": {giveLocale: "pt", giveNumber: 100, want: "cem"},
+		"pt 503": {giveLocale: "pt", giveNumber: 503, want: "quinhentos e três"},
+		"pt 421": {giveLocale: "pt", giveNumber: 421, want: "quatrocentos e vinte e um"},
+		"nl 503": {giveLocale: "nl", giveNumber: 503, want: "vijfhonderddrie"},
+		"nl 422": {giveLocale: "nl", giveNumber: 422, want: "vierhonderdtweeëntwintig"},
+		"no 503": {giveLocale: "no", giveNumber: 503, want: "fem hundre og tre"},
+		"pl 503": {giveLocale: "pl", giveNumber: 503, want: "pięćset trzy"},
+		"pl 429": {giveLocale: "pl", giveNumber: 429, want: "czterysta dwadzieścia dziewięć"},
+		"ru 503": {giveLocale: "ru", giveNumber: 503, want: "пятьсот три"},
+		"ru 415": {giveLocale: "ru", giveNumber: 415, want: "четыреста пятнадцать"},
+		"uk 503": {giveLocale: "uk", giveNumber: 503, want: "п’ятсот три"},
+		"ro 503": {giveLocale: "ro", giveNumber: 503, want: "cinci sute trei"},
+		"ro 121": {giveLocale: "ro", giveNumber: 121, want: "o sută douăzeci și unu"},
+		"hu 503": {giveLocale: "hu", giveNumber: 503, want: "ötszázhárom"},
+		"hu 221": {giveLocale: "hu", giveNumber: 221, want: "kétszázhuszonegy"},
+		"id 503": {giveLocale: "id", giveNumber: 503, want: "lima ratus tiga"},
+		"id 111": {giveLocale: "id", giveNumber: 111, want: "seratus sebelas"},
+		"id 429": {giveLocale: "id", giveNumber: 429, want: "empat ratus dua puluh sembilan"},
+		"ko 503": {giveLocale: "ko", giveNumber: 503, want: "오백삼"},
+		"ko 115": {giveLocale: "ko", giveNumber: 115, want: "백십오"},
+		"zh 503": {giveLocale: "zh", giveNumber: 503, want: "五百零三"},
+		"zh 115": {giveLocale: "zh", giveNumber: 115, want: "一百一十五"},
+		"zh 15":  {giveLocale: "zh", giveNumber: 15, want: "十五"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := l10n.SpellNumber(tt.giveLocale, tt.giveNumber)
+
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("out of range", func(t *testing.T) {
+		t.Parallel()
+
+		for _, n := range []int64{-1, 1000} {
+			_, ok := l10n.SpellNumber("en", n)
+
+			assert.False(t, ok)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package l10n
+
+// Plural categories (CLDR).
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// PluralCategory returns the CLDR plural category of the integer number for the locale (the simplified rules for
+// the integer numbers only). The [PluralOther] is returned for the unknown locales.
+func PluralCategory(locale string, n int64) string { //nolint:gocyclo,cyclop
+	if n < 0 {
+		n = -n
+	}
+
+	var mod10, mod100 = n % 10, n % 100 //nolint:mnd
+
+	switch NormalizeLocale(locale) {
+	case "zh", "id", "ko", "ja", "th", "vi":
+		return PluralOther
+
+	case "fr", "pt":
+		if n == 0 || n == 1 {
+			return PluralOne
+		}
+
+	case "ru", "uk":
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return PluralOne
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return PluralFew
+		default:
+			return PluralMany
+		}
+
+	case "pl":
+		switch {
+		case n == 1:
+			return PluralOne
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return PluralFew
+		default:
+			return PluralMany
+		}
+
+	case "ro":
+		switch {
+		case n == 1:
+			return PluralOne
+		case n == 0 || (mod100 >= 2 && mod100 <= 19):
+			return PluralFew
+		}
+
+	case "ar":
+		switch {
+		case n == 0:
+			return PluralZero
+		case n == 1:
+			return PluralOne
+		case n == 2: //nolint:mnd
+			return PluralTwo
+		case mod100 >= 3 && mod100 <= 10:
+			return PluralFew
+		case mod100 >= 11 && mod100 <= 99:
+			return PluralMany
+		}
+
+	case "he":
+		switch n {
+		case 1:
+			return PluralOne
+		case 2: //nolint:mnd
+			return PluralTwo
+		}
+
+	default: // en, de, nl, es, it, hu, no, and most of the others
+		if n == 1 {
+			return PluralOne
+		}
+	}
+
+	return PluralOther
+}
@@ -0,0 +1,403 @@
+package l10n
+
+import "net/http"
+
+// statusTexts are the translations of the standard HTTP status phrases (map[code]map[locale]phrase) missing in the
+// JS file, so the codes without the configured messages are not rendered in English only. The phrases follow the
+// RFC 9110 names (like `Content Too Large` for 413).
+var statusTexts = map[int]map[string]string{ //nolint:gochecknoglobals
+	402: { // Payment Required
+		"de": "Zahlung erforderlich",
+		"es": "Pago requerido",
+		"fr": "Paiement requis",
+		"hu": "Fizetés szükséges",
+		"id": "Pembayaran diperlukan",
+		"it": "Pagamento richiesto",
+		"ko": "결제 필요",
+		"nl": "Betaling vereist",
+		"no": "Betaling kreves",
+		"pl": "Wymagana płatność",
+		"pt": "Pagamento necessário",
+		"ro": "Plată necesară",
+		"ru": "Требуется оплата",
+		"uk": "Потрібна оплата",
+		"zh": "需要付款",
+	},
+	406: { // Not Acceptable
+		"de": "Nicht annehmbar",
+		"es": "No aceptable",
+		"fr": "Non acceptable",
+		"hu": "Nem elfogadható",
+		"id": "Tidak dapat diterima",
+		"it": "Non accettabile",
+		"ko": "허용되지 않음",
+		"nl": "Niet aanvaardbaar",
+		"no": "Ikke akseptabelt",
+		"pl": "Nieakceptowalne",
+		"pt": "Não aceitável",
+		"ro": "Inacceptabil",
+		"ru": "Неприемлемо",
+		"uk": "Неприйнятно",
+		"zh": "无法接受",
+	},
+	413: { // Content Too Large
+		"de": "Anfrage zu groß",
+		"es": "Contenido demasiado grande",
+		"fr": "Contenu trop volumineux",
+		"hu": "Túl nagy kérés",
+		"id": "Konten terlalu besar",
+		"it": "Contenuto troppo grande",
+		"ko": "요청 본문이 너무 큼",
+		"nl": "Inhoud te groot",
+		"no": "Innholdet er for stort",
+		"pl": "Zbyt duża zawartość",
+		"pt": "Conteúdo muito grande",
+		"ro": "Conținut prea mare",
+		"ru": "Слишком большой запрос",
+		"uk": "Завеликий запит",
+		"zh": "请求实体过大",
+	},
+	414: { // URI Too Long
+		"de": "URI zu lang",
+		"es": "URI demasiado largo",
+		"fr": "URI trop longue",
+		"hu": "Túl hosszú URI",
+		"id": "URI terlalu panjang",
+		"it": "URI troppo lungo",
+		"ko": "URI가 너무 김",
+		"nl": "URI te lang",
+		"no": "URI-en er for lang",
+		"pl": "Zbyt długi URI",
+		"pt": "URI muito longo",
+		"ro": "URI prea lung",
+		"ru": "Слишком длинный URI",
+		"uk": "Задовгий URI",
+		"zh": "请求 URI 过长",
+	},
+	415: { // Unsupported Media Type
+		"de": "Nicht unterstützter Medientyp",
+		"es": "Tipo de medio no soportado",
+		"fr": "Type de média non supporté",
+		"hu": "Nem támogatott médiatípus",
+		"id": "Tipe media tidak didukung",
+		"it": "Tipo di media non supportato",
+		"ko": "지원되지 않는 미디어 유형",
+		"nl": "Niet-ondersteund mediatype",
+		"no": "Mediatypen støttes ikke",
+		"pl": "Nieobsługiwany typ danych",
+		"pt": "Tipo de mídia não suportado",
+		"ro": "Tip media neacceptat",
+		"ru": "Неподдерживаемый тип данных",
+		"uk": "Непідтримуваний тип даних",
+		"zh": "不支持的媒体类型",
+	},
+	417: { // Expectation Failed
+		"de": "Erwartung fehlgeschlagen",
+		"es": "Expectativa fallida",
+		"fr": "Comportement attendu insatisfaisant",
+		"hu": "Az elvárás nem teljesíthető",
+		"id": "Ekspektasi gagal",
+		"it": "Aspettativa non soddisfatta",
+		"ko": "기대 실패",
+		"nl": "Verwachting mislukt",
+		"no": "Forventningen mislyktes",
+		"pl": "Oczekiwanie nieudane",
+		"pt": "Expectativa falhou",
+		"ro": "Așteptare eșuată",
+		"ru": "Ожидание не выполнено",
+		"uk": "Очікування не виконано",
+		"zh": "预期失败",
+	},
+	418: { // I'm a Teapot
+		"de": "Ich bin eine Teekanne",
+		"es": "Soy una tetera",
+		"fr": "Je suis une théière",
+		"hu": "Én egy teáskanna vagyok",
+		"id": "Saya adalah teko",
+		"it": "Sono una teiera",
+		"ko": "저는 찻주전자에요",
+		"nl": "Ik ben een theepot",
+		"no": "Jeg er en tekanne",
+		"pl": "Jestem czajniczkiem",
+		"pt": "Eu sou um bule",
+		"ro": "Sunt un ceainic",
+		"ru": "Я чайник",
+		"uk": "Я чайник",
+		"zh": "我是一只茶壶",
+	},
+	421: { // Misdirected Request
+		"de": "Fehlgeleitete Anfrage",
+		"es": "Solicitud mal dirigida",
+		"fr": "Requête mal dirigée",
+		"hu": "Félreirányított kérés",
+		"id": "Permintaan salah arah",
+		"it": "Richiesta mal indirizzata",
+		"ko": "잘못 전달된 요청",
+		"nl": "Verkeerd gerichte aanvraag",
+		"no": "Feildirigert forespørsel",
+		"pl": "Błędnie skierowane żądanie",
+		"pt": "Requisição mal direcionada",
+		"ro": "Cerere direcționată greșit",
+		"ru": "Неверно адресованный запрос",
+		"uk": "Неправильно адресований запит",
+		"zh": "请求被误导",
+	},
+	422: { // Unprocessable Content
+		"de": "Nicht verarbeitbarer Inhalt",
+		"es": "Contenido no procesable",
+		"fr": "Contenu non traitable",
+		"hu": "Feldolgozhatatlan tartalom",
+		"id": "Konten tidak dapat diproses",
+		"it": "Contenuto non elaborabile",
+		"ko": "처리할 수 없는 콘텐츠",
+		"nl": "Onverwerkbare inhoud",
+		"no": "Innholdet kan ikke behandles",
+		"pl": "Nieprzetwarzalna zawartość",
+		"pt": "Conteúdo não processável",
+		"ro": "Conținut neprocesabil",
+		"ru": "Необрабатываемое содержимое",
+		"uk": "Необроблюваний вміст",
+		"zh": "无法处理的内容",
+	},
+	423: { // Locked
+		"de": "Gesperrt",
+		"es": "Bloqueado",
+		"fr": "Verrouillé",
+		"hu": "Zárolva",
+		"id": "Terkunci",
+		"it": "Bloccato",
+		"ko": "잠김",
+		"nl": "Vergrendeld",
+		"no": "Låst",
+		"pl": "Zablokowane",
+		"pt": "Bloqueado",
+		"ro": "Blocat",
+		"ru": "Заблокировано",
+		"uk": "Заблоковано",
+		"zh": "已锁定",
+	},
+	424: { // Failed Dependency
+		"de": "Fehlgeschlagene Abhängigkeit",
+		"es": "Dependencia fallida",
+		"fr": "Dépendance échouée",
+		"hu": "Sikertelen függőség",
+		"id": "Dependensi gagal",
+		"it": "Dipendenza fallita",
+		"ko": "종속성 실패",
+		"nl": "Mislukte afhankelijkheid",
+		"no": "Mislykket avhengighet",
+		"pl": "Nieudana zależność",
+		"pt": "Dependência falhou",
+		"ro": "Dependență eșuată",
+		"ru": "Невыполненная зависимость",
+		"uk": "Невиконана залежність",
+		"zh": "依赖失败",
+	},
+	425: { // Too Early
+		"de": "Zu früh",
+		"es": "Demasiado pronto",
+		"fr": "Trop tôt",
+		"hu": "Túl korai",
+		"id": "Terlalu dini",
+		"it": "Troppo presto",
+		"ko": "너무 이름",
+		"nl": "Te vroeg",
+		"no": "For tidlig",
+		"pl": "Zbyt wcześnie",
+		"pt": "Muito cedo",
+		"ro": "Prea devreme",
+		"ru": "Слишком рано",
+		"uk": "Зарано",
+		"zh": "太早",
+	},
+	426: { // Upgrade Required
+		"de": "Upgrade erforderlich",
+		"es": "Se requiere actualización",
+		"fr": "Mise à niveau requise",
+		"hu": "Frissítés szükséges",
+		"id": "Peningkatan diperlukan",
+		"it": "Aggiornamento richiesto",
+		"ko": "업그레이드 필요",
+		"nl": "Upgrade vereist",
+		"no": "Oppgradering kreves",
+		"pl": "Wymagana aktualizacja",
+		"pt": "Atualização necessária",
+		"ro": "Actualizare necesară",
+		"ru": "Требуется обновление",
+		"uk": "Потрібне оновлення",
+		"zh": "需要升级",
+	},
+	428: { // Precondition Required
+		"de": "Vorbedingung erforderlich",
+		"es": "Se requiere precondición",
+		"fr": "Condition préalable requise",
+		"hu": "Előfeltétel szükséges",
+		"id": "Prasyarat diperlukan",
+		"it": "Precondizione richiesta",
+		"ko": "전제 조건 필요",
+		"nl": "Voorwaarde vereist",
+		"no": "Forutsetning kreves",
+		"pl": "Wymagany warunek wstępny",
+		"pt": "Pré-condição necessária",
+		"ro": "Precondiție necesară",
+		"ru": "Необходимо предусловие",
+		"uk": "Потрібна передумова",
+		"zh": "需要前提条件",
+	},
+	431: { // Request Header Fields Too Large
+		"de": "Header-Felder der Anfrage zu groß",
+		"es": "Campos de encabezado de la solicitud demasiado grandes",
+		"fr": "Champs d’en-tête de requête trop volumineux",
+		"hu": "Túl nagy kérésfejléc-mezők",
+		"id": "Bidang header permintaan terlalu besar",
+		"it": "Campi di intestazione della richiesta troppo grandi",
+		"ko": "요청 헤더 필드가 너무 큼",
+		"nl": "Aanvraagheadervelden te groot",
+		"no": "Forespørselens hodefelt er for store",
+		"pl": "Zbyt duże pola nagłówka żądania",
+		"pt": "Campos de cabeçalho da requisição muito grandes",
+		"ro": "Câmpurile antetului cererii sunt prea mari",
+		"ru": "Поля заголовка запроса слишком большие",
+		"uk": "Поля заголовка запиту завеликі",
+		"zh": "请求头字段太大",
+	},
+	451: { // Unavailable For Legal Reasons
+		"de": "Aus rechtlichen Gründen nicht verfügbar",
+		"es": "No disponible por razones legales",
+		"fr": "Indisponible pour raisons légales",
+		"hu": "Jogi okokból nem elérhető",
+		"id": "Tidak tersedia karena alasan hukum",
+		"it": "Non disponibile per motivi legali",
+		"ko": "법적 사유로 이용할 수 없음",
+		"nl": "Niet beschikbaar om juridische redenen",
+		"no": "Utilgjengelig av juridiske årsaker",
+		"pl": "Niedostępne z przyczyn prawnych",
+		"pt": "Indisponível por motivos legais",
+		"ro": "Indisponibil din motive legale",
+		"ru": "Недоступно по юридическим причинам",
+		"uk": "Недоступно з юридичних причин",
+		"zh": "因法律原因不可用",
+	},
+	501: { // Not Implemented
+		"de": "Nicht implementiert",
+		"es": "No implementado",
+		"fr": "Non implémenté",
+		"hu": "Nincs megvalósítva",
+		"id": "Tidak diimplementasikan",
+		"it": "Non implementato",
+		"ko": "구현되지 않음",
+		"nl": "Niet geïmplementeerd",
+		"no": "Ikke implementert",
+		"pl": "Nie zaimplementowano",
+		"pt": "Não implementado",
+		"ro": "Neimplementat",
+		"ru": "Не реализовано",
+		"uk": "Не реалізовано",
+		"zh": "未实现",
+	},
+	506: { // Variant Also Negotiates
+		"de": "Variante verhandelt ebenfalls",
+		"es": "La variante también negocia",
+		"fr": "La variante négocie aussi",
+		"hu": "A változat is egyeztet",
+		"id": "Varian juga bernegosiasi",
+		"it": "Anche la variante negozia",
+		"ko": "변형도 협상함",
+		"nl": "Variant onderhandelt ook",
+		"no": "Varianten forhandler også",
+		"pl": "Wariant również negocjuje",
+		"pt": "Variante também negocia",
+		"ro": "Varianta negociază de asemenea",
+		"ru": "Вариант тоже проводит согласование",
+		"uk": "Варіант теж узгоджується",
+		"zh": "变体也在协商",
+	},
+	507: { // Insufficient Storage
+		"de": "Speicher unzureichend",
+		"es": "Almacenamiento insuficiente",
+		"fr": "Espace insuffisant",
+		"hu": "Elégtelen tárhely",
+		"id": "Penyimpanan tidak mencukupi",
+		"it": "Spazio di archiviazione insufficiente",
+		"ko": "저장 공간 부족",
+		"nl": "Onvoldoende opslagruimte",
+		"no": "Utilstrekkelig lagringsplass",
+		"pl": "Niewystarczająca ilość miejsca",
+		"pt": "Armazenamento insuficiente",
+		"ro": "Spațiu de stocare insuficient",
+		"ru": "Переполнение хранилища",
+		"uk": "Недостатньо місця",
+		"zh": "存储空间不足",
+	},
+	508: { // Loop Detected
+		"de": "Endlosschleife erkannt",
+		"es": "Bucle detectado",
+		"fr": "Boucle détectée",
+		"hu": "Hurok észlelve",
+		"id": "Perulangan terdeteksi",
+		"it": "Rilevato un ciclo",
+		"ko": "루프 감지됨",
+		"nl": "Lus gedetecteerd",
+		"no": "Løkke oppdaget",
+		"pl": "Wykryto pętlę",
+		"pt": "Loop detectado",
+		"ro": "Buclă detectată",
+		"ru": "Обнаружен цикл",
+		"uk": "Виявлено цикл",
+		"zh": "检测到循环",
+	},
+	510: { // Not Extended
+		"de": "Nicht erweitert",
+		"es": "No extendido",
+		"fr": "Non étendu",
+		"hu": "Nincs kiterjesztve",
+		"id": "Tidak diperluas",
+		"it": "Non esteso",
+		"ko": "확장되지 않음",
+		"nl": "Niet uitgebreid",
+		"no": "Ikke utvidet",
+		"pl": "Brak rozszerzenia",
+		"pt": "Não estendido",
+		"ro": "Neextins",
+		"ru": "Не расширено",
+		"uk": "Не розширено",
+		"zh": "未扩展",
+	},
+	511: { // Network Authentication Required
+		"de": "Netzwerkauthentifizierung erforderlich",
+		"es": "Se requiere autenticación de red",
+		"fr": "Authentification réseau requise",
+		"hu": "Hálózati hitelesítés szükséges",
+		"id": "Autentikasi jaringan diperlukan",
+		"it": "Autenticazione di rete richiesta",
+		"ko": "네트워크 인증 필요",
+		"nl": "Netwerkauthenticatie vereist",
+		"no": "Nettverksautentisering kreves",
+		"pl": "Wymagane uwierzytelnienie w sieci",
+		"pt": "Autenticação de rede necessária",
+		"ro": "Autentificare de rețea necesară",
+		"ru": "Требуется сетевая аутентификация",
+		"uk": "Потрібна мережева автентифікація",
+		"zh": "需要网络认证",
+	},
+}
+
+// StatusText returns the standard HTTP status phrase of the code (like `Not Found`), translated into the given
+// locale. The bundled phrases are preferred, and the JS file translations are used for the rest. For the default
+// locale, the English phrase is returned. If the code is unknown, or the translation is not found, false is returned.
+func StatusText(locale string, code int) (string, bool) {
+	var phrase = http.StatusText(code)
+	if phrase == "" {
+		return "", false
+	}
+
+	if locale = NormalizeLocale(locale); locale == DefaultLocale {
+		return phrase, true
+	}
+
+	if translated, ok := statusTexts[code][locale]; ok {
+		return translated, true
+	}
+
+	return Translate(locale, phrase)
+}
@@ -0,0 +1,55 @@
+package l10n_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/binaryYuki/error-pages/l10n"
+)
+
+func TestStatusText(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		giveLocale string
+		giveCode   int
+		want       string
+		wantOk     bool
+	}{
+		"bundled":        {giveLocale: "de", giveCode: http.StatusPaymentRequired, want: "Zahlung erforderlich", wantOk: true},
+		"bundled de-AT":  {giveLocale: "de-AT", giveCode: http.StatusLocked, want: "Gesperrt", wantOk: true},
+		"from catalog":   {giveLocale: "fr", giveCode: http.StatusNotFound, want: "Introuvable", wantOk: true},
+		"en":             {giveLocale: "en", giveCode: http.StatusTooEarly, want: "Too Early", wantOk: true},
+		"unknown code":   {giveLocale: "de", giveCode: 599},
+		"unknown locale": {giveLocale: "xx", giveCode: http.StatusPaymentRequired},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := l10n.StatusText(tt.giveLocale, tt.giveCode)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStatusText_AllLocales(t *testing.T) {
+	t.Parallel()
+
+	// every client error and server error code is translated into every supported locale
+	for code := 400; code < 600; code++ {
+		if http.StatusText(code) == "" {
+			continue
+		}
+
+		for _, locale := range l10n.Locales() {
+			got, ok := l10n.StatusText(locale, code)
+
+			assert.Truef(t, ok, "code %d, locale %s", code, locale)
+			assert.NotEmptyf(t, got, "code %d, locale %s", code, locale)
+		}
+	}
+}
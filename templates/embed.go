@@ -1,15 +1,11 @@
 package templates
 
 import (
-	"embed"
 	"io/fs"
 	"path/filepath"
 	"strings"
 )
 
-//go:embed *.html
-var content embed.FS
-
 // BuiltIn returns a map of built-in templates. The key is the template name and the value is the template content.
 func BuiltIn() map[string]string {
 	var (
@@ -0,0 +1,8 @@
+//go:build !slim
+
+package templates
+
+import "embed"
+
+//go:embed *.html
+var content embed.FS
@@ -0,0 +1,10 @@
+//go:build slim
+
+package templates
+
+import "embed"
+
+// only the lightest template is built into the slim build, the others may be added using the `--add-template` flag
+//
+//go:embed ghost.html
+var content embed.FS